@@ -58,11 +58,26 @@ CREATE TABLE IF NOT EXISTS power_plants (
     current_output_mw FLOAT NOT NULL,
     efficiency FLOAT NOT NULL,
     location JSONB NOT NULL,
+    bus_id INT,
     is_operational BOOLEAN NOT NULL DEFAULT true,
     created_at TIMESTAMPTZ DEFAULT now(),
     updated_at TIMESTAMPTZ DEFAULT now()
 );
 
+-- Create buses/substations table
+CREATE TABLE IF NOT EXISTS buses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    simulation_id UUID REFERENCES simulations(id) ON DELETE CASCADE,
+    bus_id INT NOT NULL,
+    name STRING NOT NULL,
+    bus_type STRING NOT NULL DEFAULT 'bus',
+    voltage_kv FLOAT NOT NULL,
+    location JSONB,
+    connected_components JSONB,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
 -- Create transmission lines table
 CREATE TABLE IF NOT EXISTS transmission_lines (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -79,6 +94,24 @@ CREATE TABLE IF NOT EXISTS transmission_lines (
     updated_at TIMESTAMPTZ DEFAULT now()
 );
 
+-- Create storage units table
+CREATE TABLE IF NOT EXISTS storage_units (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    simulation_id UUID REFERENCES simulations(id) ON DELETE CASCADE,
+    unit_id INT NOT NULL,
+    name STRING NOT NULL,
+    capacity_mwh FLOAT NOT NULL,
+    max_charge_mw FLOAT NOT NULL,
+    max_discharge_mw FLOAT NOT NULL,
+    round_trip_efficiency FLOAT NOT NULL,
+    state_of_charge FLOAT NOT NULL,
+    location JSONB NOT NULL,
+    bus_id INT,
+    is_operational BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now()
+);
+
 -- Create simulation results table for time-series data
 CREATE TABLE IF NOT EXISTS simulation_results (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -92,10 +125,64 @@ CREATE TABLE IF NOT EXISTS simulation_results (
     efficiency_percentage FLOAT NOT NULL,
     fault_count INT NOT NULL DEFAULT 0,
     metadata JSONB,
+    UNIQUE INDEX idx_result_tick (simulation_id, tick_number),
     INDEX idx_simulation_timestamp (simulation_id, timestamp),
     INDEX idx_timestamp (timestamp)
 );
 
+-- Create running aggregates of each simulation's results and faults
+CREATE TABLE IF NOT EXISTS simulation_summaries (
+    simulation_id UUID PRIMARY KEY REFERENCES simulations(id) ON DELETE CASCADE,
+    result_count INT8 NOT NULL DEFAULT 0,
+    fault_count INT8 NOT NULL DEFAULT 0,
+    first_tick INT,
+    last_tick INT,
+    first_timestamp TIMESTAMPTZ,
+    last_timestamp TIMESTAMPTZ,
+    sum_generation_mw FLOAT NOT NULL DEFAULT 0,
+    min_generation_mw FLOAT,
+    max_generation_mw FLOAT,
+    last_generation_mw FLOAT,
+    sum_consumption_mw FLOAT NOT NULL DEFAULT 0,
+    min_consumption_mw FLOAT,
+    max_consumption_mw FLOAT,
+    last_consumption_mw FLOAT,
+    sum_frequency_hz FLOAT NOT NULL DEFAULT 0,
+    min_frequency_hz FLOAT,
+    max_frequency_hz FLOAT,
+    last_frequency_hz FLOAT,
+    sum_voltage_kv FLOAT NOT NULL DEFAULT 0,
+    min_voltage_kv FLOAT,
+    max_voltage_kv FLOAT,
+    last_voltage_kv FLOAT,
+    sum_efficiency_percentage FLOAT NOT NULL DEFAULT 0,
+    min_efficiency_percentage FLOAT,
+    max_efficiency_percentage FLOAT,
+    last_efficiency_percentage FLOAT,
+    stale BOOL NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create frequency stability scores computed after each run
+CREATE TABLE IF NOT EXISTS stability_scores (
+    simulation_id UUID PRIMARY KEY REFERENCES simulations(id) ON DELETE CASCADE,
+    nominal_frequency_hz FLOAT NOT NULL,
+    band_hz FLOAT NOT NULL,
+    samples INT8 NOT NULL DEFAULT 0,
+    duration_seconds FLOAT NOT NULL DEFAULT 0,
+    seconds_outside_band FLOAT NOT NULL DEFAULT 0,
+    percent_outside_band FLOAT NOT NULL DEFAULT 0,
+    nadir_hz FLOAT,
+    nadir_at TIMESTAMPTZ,
+    zenith_hz FLOAT,
+    max_deviation_hz FLOAT NOT NULL DEFAULT 0,
+    max_rocof_hz_per_s FLOAT NOT NULL DEFAULT 0,
+    mean_rocof_hz_per_s FLOAT NOT NULL DEFAULT 0,
+    score FLOAT NOT NULL DEFAULT 0,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    INDEX idx_stability_score (score DESC)
+);
+
 -- Create detailed metrics table for individual components
 CREATE TABLE IF NOT EXISTS component_metrics (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -148,6 +235,8 @@ CREATE INDEX IF NOT EXISTS idx_simulations_org ON simulations(organization_id);
 CREATE INDEX IF NOT EXISTS idx_simulations_status ON simulations(status);
 CREATE INDEX IF NOT EXISTS idx_power_plants_sim ON power_plants(simulation_id);
 CREATE INDEX IF NOT EXISTS idx_transmission_lines_sim ON transmission_lines(simulation_id);
+CREATE INDEX IF NOT EXISTS idx_storage_units_sim ON storage_units(simulation_id);
+CREATE INDEX IF NOT EXISTS idx_buses_sim ON buses(simulation_id);
 
 -- Create views for common queries
 CREATE VIEW IF NOT EXISTS simulation_summary AS
@@ -239,6 +328,9 @@ CREATE TRIGGER update_power_plants_updated_at BEFORE UPDATE ON power_plants
 CREATE TRIGGER update_transmission_lines_updated_at BEFORE UPDATE ON transmission_lines
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+CREATE TRIGGER update_storage_units_updated_at BEFORE UPDATE ON storage_units
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
 -- Grant permissions
 GRANT ALL ON DATABASE voltedge TO voltedge;
 GRANT ALL ON ALL TABLES IN SCHEMA public TO voltedge;
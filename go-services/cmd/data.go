@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"voltedge/go-services/internal/backup"
+	"voltedge/go-services/internal/config"
+	"voltedge/go-services/internal/database"
+)
+
+// dataSession is the database and storage configuration shared by the
+// export, backup and restore subcommands
+type dataSession struct {
+	conn    *database.Connection
+	store   *database.BackupService
+	storage config.ObjectStorageConfig
+}
+
+// openDataSession connects to the configured database, creating the schema
+// first when migrate is set
+func openDataSession(migrate bool) (*dataSession, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Only problems are worth logging in a one-shot command
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	conn, err := database.NewConnection(newDatabaseConfig(cfg.Database), logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if migrate {
+		if err := conn.Migrate(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to run database migrations: %w", err)
+		}
+	}
+
+	return &dataSession{
+		conn:    conn,
+		store:   database.NewBackupService(conn.DB, logger),
+		storage: cfg.ObjectStorage,
+	}, nil
+}
+
+func (s *dataSession) openStorage(target string) (backup.Storage, error) {
+	return backup.OpenStorage(target, newS3Options(s.storage))
+}
+
+func newS3Options(cfg config.ObjectStorageConfig) backup.S3Options {
+	return backup.S3Options{
+		Endpoint:        cfg.Endpoint,
+		Region:          cfg.Region,
+		AccessKeyID:     cfg.AccessKeyID,
+		SecretAccessKey: cfg.SecretAccessKey,
+		UseSSL:          cfg.UseSSL,
+	}
+}
+
+func (s *dataSession) Close() {
+	s.conn.Close()
+}
+
+// signalContext returns a context cancelled by SIGINT or SIGTERM
+func signalContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
+func newExportCmd() *cobra.Command {
+	var (
+		simulation string
+		format     string
+		out        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a simulation's results for offline analysis",
+		Long: `Write a simulation's definition and its results, component metrics, fault
+events and alerts to a directory or to s3://bucket/prefix. Tables are written
+as parquet (zstd compressed), csv or ndjson.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.Parse(simulation)
+			if err != nil {
+				return fmt.Errorf("invalid --simulation: %w", err)
+			}
+
+			session, err := openDataSession(false)
+			if err != nil {
+				return err
+			}
+			defer session.Close()
+
+			storage, err := session.openStorage(out)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signalContext(cmd)
+			defer cancel()
+
+			files, err := backup.Export(ctx, session.store, storage, id, format)
+			printExportedFiles(cmd.OutOrStdout(), storage, files)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&simulation, "simulation", "", "ID of the simulation to export (required)")
+	cmd.Flags().StringVar(&format, "format", backup.FormatParquet, "table format: parquet, csv or ndjson")
+	cmd.Flags().StringVar(&out, "out", "./dump", "output directory or s3://bucket/prefix")
+	cmd.MarkFlagRequired("simulation")
+
+	return cmd
+}
+
+func printExportedFiles(out io.Writer, storage backup.Storage, files []backup.ExportedFile) {
+	for _, file := range files {
+		fmt.Fprintf(out, "  %-28s %d rows\n", file.Name, file.Rows)
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(out, "Exported to %s\n", storage)
+	}
+}
+
+func newBackupCmd() *cobra.Command {
+	var (
+		simulations []string
+		out         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up simulations, their configuration and results",
+		Long: `Snapshot simulations with their topology, results, metrics, faults, alerts
+and emission summaries to a directory or to s3://bucket/prefix. Every
+simulation is included unless --simulation is given. Use the restore
+subcommand to load a backup.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]uuid.UUID, 0, len(simulations))
+			for _, simulation := range simulations {
+				id, err := uuid.Parse(simulation)
+				if err != nil {
+					return fmt.Errorf("invalid --simulation %q: %w", simulation, err)
+				}
+				ids = append(ids, id)
+			}
+
+			session, err := openDataSession(false)
+			if err != nil {
+				return err
+			}
+			defer session.Close()
+
+			storage, err := session.openStorage(out)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signalContext(cmd)
+			defer cancel()
+
+			manifest, err := backup.Backup(ctx, session.store, storage, database.BackupTables, ids)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			for _, table := range manifest.Tables {
+				fmt.Fprintf(w, "  %-28s %d rows\n", table.Name, table.Rows)
+			}
+			fmt.Fprintf(w, "Backed up %d simulations to %s\n", len(manifest.Simulations), storage)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&simulations, "simulation", nil, "simulation IDs to back up (default all)")
+	cmd.Flags().StringVar(&out, "out", "", "backup directory or s3://bucket/prefix (required)")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:     "restore",
+		Aliases: []string{"import"},
+		Short:   "Restore a backup into the database",
+		Long: `Load a backup written by the backup subcommand. Rows that already exist
+are skipped, so a partially restored backup can be restored again. The users
+owning the backed-up simulations must already exist.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session, err := openDataSession(true)
+			if err != nil {
+				return err
+			}
+			defer session.Close()
+
+			storage, err := session.openStorage(from)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signalContext(cmd)
+			defer cancel()
+
+			restored, err := backup.Restore(ctx, session.store, storage)
+
+			w := cmd.OutOrStdout()
+			for _, table := range restored {
+				fmt.Fprintf(w, "  %-28s %d rows, %d inserted\n", table.Name, table.Rows, table.Inserted)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Restored from %s\n", storage)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "backup directory or s3://bucket/prefix (required)")
+	cmd.MarkFlagRequired("from")
+
+	return cmd
+}
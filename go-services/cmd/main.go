@@ -2,20 +2,45 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"voltedge/go-services/internal/analytics"
 	"voltedge/go-services/internal/api"
+	"voltedge/go-services/internal/archive"
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/backup"
+	"voltedge/go-services/internal/chaos"
+	"voltedge/go-services/internal/cluster"
 	"voltedge/go-services/internal/config"
 	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/engine"
 	"voltedge/go-services/internal/grpc"
+	"voltedge/go-services/internal/lifecycle"
+	"voltedge/go-services/internal/lock"
+	"voltedge/go-services/internal/logging"
+	"voltedge/go-services/internal/notifications"
 	"voltedge/go-services/internal/observability"
 	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/internal/playback"
+	"voltedge/go-services/internal/prediction"
+	"voltedge/go-services/internal/realtime"
+	"voltedge/go-services/internal/reconcile"
+	"voltedge/go-services/internal/recording"
+	"voltedge/go-services/internal/remotewrite"
+	"voltedge/go-services/internal/report"
+	"voltedge/go-services/internal/selftest"
+	"voltedge/go-services/internal/snapshot"
+	"voltedge/go-services/internal/usage"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -44,6 +69,7 @@ observability, and orchestration for the VoltEdge energy grid simulator.`,
 	rootCmd.PersistentFlags().String("grpc-port", "8081", "gRPC server port")
 	rootCmd.PersistentFlags().String("metrics-port", "9090", "metrics server port")
 	rootCmd.PersistentFlags().String("zig-endpoint", "localhost:9091", "Zig simulation engine endpoint")
+	rootCmd.PersistentFlags().Bool("demo", false, "run simulations on the built-in demo engine instead of the Zig engine")
 
 	// Bind flags to viper
 	viper.BindPFlags(rootCmd.PersistentFlags())
@@ -52,8 +78,20 @@ observability, and orchestration for the VoltEdge energy grid simulator.`,
 	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(newHealthCmd())
 	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newServiceTokenCmd())
+	rootCmd.AddCommand(newRunCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newBackupCmd())
+	rootCmd.AddCommand(newRestoreCmd())
+	rootCmd.AddCommand(newReplayCmd())
+	rootCmd.AddCommand(newTopCmd())
 
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			fmt.Fprintln(os.Stderr, "Error:", exitErr.err)
+			os.Exit(exitErr.code)
+		}
 		logrus.Fatal(err)
 	}
 }
@@ -64,16 +102,17 @@ func runServer(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if viper.GetBool("demo") {
+		cfg.Orchestration.EngineBackend = "demo"
+	}
 
-	// Set log level
-	level, err := logrus.ParseLevel(cfg.Log.Level)
+	// Route logs to the configured output and format
+	logger := logrus.New()
+	logOutput, err := logging.Configure(cfg.Log, logrus.StandardLogger(), logger)
 	if err != nil {
-		return fmt.Errorf("invalid log level: %w", err)
+		return fmt.Errorf("failed to configure logging: %w", err)
 	}
-	logrus.SetLevel(level)
-	logrus.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: time.RFC3339,
-	})
+	defer logOutput.Close()
 
 	logrus.WithFields(logrus.Fields{
 		"version":    version,
@@ -85,30 +124,49 @@ func runServer(cmd *cobra.Command, args []string) error {
 	observability.Init(&cfg.Observability)
 
 	// Initialize database connection
-	dbConfig := database.Config{
-		Host:         cfg.Database.Host,
-		Port:         cfg.Database.Port,
-		User:         cfg.Database.Username,
-		Password:     cfg.Database.Password,
-		Database:     cfg.Database.Database,
-		SSLMode:      cfg.Database.SSLMode,
-		MaxOpenConns: cfg.Database.MaxConns,
-		MaxIdleConns: cfg.Database.MinConns,
-		MaxLifetime:  cfg.Database.MaxLifetime,
-		MaxIdleTime:  cfg.Database.MaxIdleTime,
-	}
-
-	logger := logrus.New()
-	logger.SetLevel(level)
-	logger.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: time.RFC3339,
-	})
+	dbConfig := newDatabaseConfig(cfg.Database)
 
 	dbConn, err := database.NewConnection(dbConfig, logger)
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to connect to database")
 	}
-	defer dbConn.Close()
+
+	// Components start in dependency order and stop in reverse, so nothing
+	// is closed while a component started after it still uses it
+	components := lifecycle.New()
+	components.Register(lifecycle.Component{
+		Name:   "observability",
+		OnStop: func(context.Context) error { observability.Shutdown(); return nil },
+	})
+	if sentry := cfg.Observability.Sentry; sentry.DSN != "" {
+		hostname, _ := os.Hostname()
+		reporter, err := observability.NewSentryReporter(observability.SentryOptions{
+			DSN:         sentry.DSN,
+			Environment: sentry.Environment,
+			Release:     version,
+			ServerName:  hostname,
+			SampleRate:  sentry.SampleRate,
+			QueueSize:   sentry.QueueSize,
+			Timeout:     sentry.Timeout,
+		})
+		if err != nil {
+			return err
+		}
+		observability.SetErrorReporter(reporter)
+		// Registered early so it stops late and still sends the errors of
+		// components stopping before it
+		components.Register(lifecycle.Component{
+			Name: "error-reporting",
+			OnStop: func(ctx context.Context) error {
+				observability.SetErrorReporter(nil)
+				return reporter.Close(ctx)
+			},
+		})
+	}
+	components.Register(lifecycle.Component{
+		Name:   "database",
+		OnStop: func(context.Context) error { return dbConn.Close() },
+	})
 
 	// Run database migrations
 	if err := dbConn.Migrate(); err != nil {
@@ -117,82 +175,941 @@ func runServer(cmd *cobra.Command, args []string) error {
 
 	// Initialize simulation service
 	simulationService := database.NewSimulationService(dbConn.DB, logger)
-	defer observability.Shutdown()
+	simulationService.SetBulkLoad(cfg.Database.BulkLoad)
+	webhookService := database.NewWebhookService(dbConn.DB, logger)
+	metadataService := database.NewMetadataService(dbConn.DB, logger)
+	userService := database.NewUserService(dbConn.DB, logger)
+	auditService := database.NewAuditService(dbConn.DB, logger)
+	tagService := database.NewTagService(dbConn.DB, logger)
+	dashboardService := database.NewDashboardService(dbConn.DB, logger)
+	predictionService := database.NewPredictionService(dbConn.DB, logger)
+	tokens := auth.NewTokenManager(cfg.Security.JWTSecret, cfg.Security.JWTExpiry, cfg.Security.ImpersonationTTL)
+	outboxService := database.NewOutboxService(dbConn.DB, logger)
+	locker := newLocker(cfg.Lock, dbConn, logger)
+	notifier := notifications.NewDispatcher(webhookService)
+	notifier.SetOutbox(outboxService, notifications.OutboxOptions{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+		RetryBackoff: cfg.Outbox.RetryBackoff,
+		MaxBackoff:   cfg.Outbox.MaxBackoff,
+		Retention:    cfg.Outbox.Retention,
+		Locker:       locker,
+	})
+	alertRouting := database.NewAlertRoutingService(dbConn.DB, logger)
+	alertRouter := notifications.NewAlertRouter(alertRouting, notifications.AlertRouterOptions{
+		PagerDutyURL: cfg.Alerting.PagerDutyURL,
+		SMTP: notifications.SMTPOptions{
+			Host:     cfg.Alerting.SMTP.Host,
+			Port:     cfg.Alerting.SMTP.Port,
+			Username: cfg.Alerting.SMTP.Username,
+			Password: cfg.Alerting.SMTP.Password,
+			From:     cfg.Alerting.SMTP.From,
+		},
+		SilenceRetention: cfg.Alerting.SilenceRetention,
+	})
+	notifier.SetAlertRouter(alertRouter)
+	preferenceService := database.NewNotificationPreferenceService(dbConn.DB, logger)
+	notifier.SetPreferences(preferenceService)
+	alertRouter.SetPreferences(preferenceService)
+	predictions := newPredictionService(cfg.Prediction, simulationService, predictionService)
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Historical results are read through the archiver when archival is on,
+	// so archived results are served transparently
+	var results playback.ResultSource = simulationService
+	var archiver *archive.Archiver
+	if cfg.Archive.Enabled {
+		storage, err := backup.OpenStorage(cfg.Archive.Target, newS3Options(cfg.ObjectStorage))
+		if err != nil {
+			return fmt.Errorf("failed to open archive storage: %w", err)
+		}
+		archiver = archive.New(database.NewArchiveService(dbConn.DB, logger), simulationService, storage, archive.Options{
+			Interval:  cfg.Archive.Interval,
+			MinAge:    cfg.Archive.MinAge,
+			BatchSize: cfg.Archive.BatchSize,
+			ReadMode:  cfg.Archive.ReadMode,
+			Locker:    locker,
+		})
+		results = archiver
+	}
+	reports := report.NewGenerator(simulationService, results)
+	notifier.SetReports(reports)
 
-	// Initialize orchestration service
-	orchestrator := orchestration.NewOrchestrator(&cfg.Orchestration)
-	if err := orchestrator.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start orchestrator: %w", err)
+	snapshotStorage, err := backup.OpenStorage(cfg.Snapshots.Target, newS3Options(cfg.ObjectStorage))
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot storage: %w", err)
 	}
-	defer orchestrator.Stop()
+	snapshots := snapshot.New(database.NewSnapshotService(dbConn.DB, logger), snapshotStorage)
+
+	playbacks := playback.NewManager(results, playback.Options{
+		IdleTimeout: cfg.Playback.IdleTimeout,
+		MaxFrames:   cfg.Playback.MaxFrames,
+		MaxSpeed:    cfg.Playback.MaxSpeed,
+		MaxFrameGap: cfg.Playback.MaxFrameGap,
+	})
 
 	// Initialize gRPC client for Zig communication
 	grpcClient, err := grpc.NewClient(cfg.Zig.Endpoint)
 	if err != nil {
 		return fmt.Errorf("failed to create gRPC client: %w", err)
 	}
-	defer grpcClient.Close()
+	components.Register(lifecycle.Component{
+		Name:   "grpc-client",
+		OnStop: func(context.Context) error { return grpcClient.Close() },
+	})
+	orchestratorDependencies := []string{"database", "grpc-client"}
 
-	// Initialize API server
-	apiServer := api.NewServer(&cfg.API, orchestrator, grpcClient, simulationService)
+	// Record API traffic and engine calls for replay testing
+	var recorder *recording.Recorder
+	if cfg.Recording.Enabled {
+		recorder, err = recording.New(recording.Options{
+			Path:         cfg.Recording.Path,
+			MaxBodyBytes: cfg.Recording.MaxBodyBytes,
+			RedactFields: cfg.Recording.RedactFields,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start recording: %w", err)
+		}
+		components.Register(lifecycle.Component{
+			Name:   "recorder",
+			OnStop: func(context.Context) error { return recorder.Close() },
+		})
+		orchestratorDependencies = append(orchestratorDependencies, "recorder")
+	}
 
-	// Start HTTP server
-	httpServer := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfg.API.Port),
-		Handler:      apiServer.Handler(),
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	// Register simulation engines
+	var simulationEngine engine.Engine
+	switch cfg.Orchestration.EngineBackend {
+	case "mock":
+		simulationEngine = engine.NewMockEngine(time.Second)
+	case "demo":
+		simulationEngine = engine.NewDemoEngine(engine.DemoOptions{TickInterval: time.Second})
+		logrus.Warn("Running in demo mode: simulations run on the built-in demo engine, not the Zig engine")
+	default:
+		simulationEngine = grpc.NewEngine(grpcClient, engine.ZigDescriptor(cfg.Zig.Endpoint, cfg.Orchestration.MaxConcurrentSimulations))
 	}
+	if recorder != nil {
+		simulationEngine = recording.WrapEngine(simulationEngine, recorder)
+	}
+	engines := engine.NewRegistry()
+	engines.RegisterEngine(simulationEngine)
 
-	// Start metrics server
-	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf(":%s", cfg.Observability.MetricsPort),
-		Handler: observability.MetricsHandler(),
+	// Initialize orchestration service
+	orchestrator := orchestration.NewOrchestrator(&cfg.Orchestration)
+	orchestrator.SetEngineRegistry(engines)
+	orchestrator.SetRepository(simulationRepository{
+		simulations: simulationService,
+		clustered:   cfg.Cluster.Enabled,
+		nodeID:      cfg.Cluster.NodeID,
+	})
+	orchestrator.SetMetricsSink(componentMetricsSink{simulationService})
+	orchestrator.SetLocker(locker)
+	orchestrator.SetMetadataLimits(cfg.API.Metadata)
+	orchestrator.OnTransition(simulationStatusStore{simulationService}.record)
+	orchestrator.OnTransition(recordTransitionMetrics)
+	orchestrator.OnTransition(stabilityScorer{simulationService}.record)
+	orchestrator.OnTransition(emissionsRecorder{simulationService}.record)
+	orchestrator.SetConcurrencyOverrides(organizationConcurrency{userService}.overrides)
+	orchestrator.SetActiveRuns(clusterRuns{simulationService}.active)
+	orchestrator.SetRunOutcomes(runOutcomes{simulationService}.outcome)
+	orchestrator.SetWorkerCrashHandler(func(crash orchestration.WorkerCrash) {
+		observability.RecordWorkerPanic(crash.Scope)
+		observability.ReportError(observability.ErrorEvent{
+			Err:    crash.Panic,
+			Level:  observability.ErrorLevelFatal,
+			Source: "worker",
+			Tags: map[string]string{
+				"scope":         crash.Scope,
+				"worker_id":     strconv.Itoa(crash.WorkerID),
+				"simulation_id": crash.SimulationID,
+			},
+			Stack: crash.Panic.Stack,
+		})
+	})
+
+	// Chaos experiments inject failures only while an admin runs one
+	chaosController := chaos.New(chaos.Options{
+		Enabled:         cfg.Chaos.Enabled,
+		DefaultDuration: cfg.Chaos.DefaultDuration,
+		MaxDuration:     cfg.Chaos.MaxDuration,
+	})
+	if cfg.Chaos.Enabled {
+		if err := chaosController.InstrumentDB(dbConn.DB); err != nil {
+			return fmt.Errorf("failed to instrument database for chaos experiments: %w", err)
+		}
+		grpcClient.SetFaultInjector(chaosController.GRPCFault)
+		orchestrator.SetWorkerFaultInjector(chaosController.WorkerFault)
+		logrus.Warn("Chaos experiments are enabled")
 	}
 
-	// Start servers in goroutines
-	go func() {
-		logrus.WithField("port", cfg.API.Port).Info("Starting HTTP server")
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logrus.WithError(err).Fatal("HTTP server failed")
+	// Push per-simulation telemetry to a TSDB
+	if rw := cfg.Observability.RemoteWrite; rw.Enabled {
+		exporter := remotewrite.New(remotewrite.Options{
+			URL:            rw.URL,
+			BearerToken:    rw.BearerToken,
+			Username:       rw.Username,
+			Password:       rw.Password,
+			ExternalLabels: rw.ExternalLabels,
+			BatchSize:      rw.BatchSize,
+			FlushInterval:  rw.FlushInterval,
+			QueueSize:      rw.QueueSize,
+			Timeout:        rw.Timeout,
+			MaxRetries:     rw.MaxRetries,
+			RetryBackoff:   rw.RetryBackoff,
+			MaxBackoff:     rw.MaxBackoff,
+		})
+		components.Register(lifecycle.Component{
+			Name:    "remote-write",
+			OnStart: func(ctx context.Context) error { exporter.Start(ctx); return nil },
+			OnStop:  func(context.Context) error { exporter.Stop(); return nil },
+		})
+		orchestratorDependencies = append(orchestratorDependencies, "remote-write")
+		orchestrator.SetMetricsExporter(exporter)
+	}
+	components.Register(lifecycle.Component{
+		Name:      "orchestrator",
+		DependsOn: orchestratorDependencies,
+		OnStart:   orchestrator.Start,
+		OnStop:    func(context.Context) error { orchestrator.Stop(); return nil },
+	})
+
+	// Deliver events stored in the outbox, including those left by a previous process
+	components.Register(lifecycle.Component{
+		Name:      "outbox",
+		DependsOn: []string{"database"},
+		Run:       lifecycle.Background(notifier.RunOutbox),
+	})
+	// Send notifications held back by users' quiet hours and digest preferences
+	components.Register(lifecycle.Component{
+		Name:      "digests",
+		DependsOn: []string{"database"},
+		Run:       lifecycle.Background(notifier.RunDigests),
+	})
+	// Email users' scheduled reports, which needs a mail server
+	if cfg.Alerting.SMTP.Host != "" {
+		scheduler := report.NewScheduler(reports, preferenceService, alertRouter, orchestrator)
+		components.Register(lifecycle.Component{
+			Name:      "report-scheduler",
+			DependsOn: []string{"database"},
+			Run: lifecycle.Background(func(ctx context.Context) {
+				scheduler.Run(ctx, cfg.Reports.ScheduleInterval)
+			}),
+		})
+	}
+
+	// Correct database rows left active by a previous process
+	reconciler := reconcile.New(simulationService, orchestrator, reconcile.Options{
+		Interval:    cfg.Orchestration.ReconcileInterval,
+		GracePeriod: cfg.Orchestration.ReconcileGracePeriod,
+		Locker:      locker,
+	})
+	components.Register(lifecycle.Component{
+		Name:      "reconciler",
+		DependsOn: []string{"database", "orchestrator"},
+		OnStart:   func(ctx context.Context) error { reconciler.Start(ctx); return nil },
+	})
+
+	if archiver != nil {
+		components.Register(lifecycle.Component{
+			Name:      "archiver",
+			DependsOn: []string{"database"},
+			OnStart:   func(ctx context.Context) error { archiver.Start(ctx); return nil },
+		})
+	}
+
+	// Publish connection pool stats and watch for saturation
+	poolMonitor := database.NewPoolMonitor(dbConn, database.PoolMonitorOptions{
+		Interval:          cfg.Database.StatsInterval,
+		WaitRateThreshold: cfg.Database.WaitRateThreshold,
+	})
+	components.Register(lifecycle.Component{
+		Name:      "pool-monitor",
+		DependsOn: []string{"database"},
+		OnStart:   func(ctx context.Context) error { poolMonitor.Start(ctx); return nil },
+	})
+
+	// Summarize simulations whose results predate summaries or went stale
+	components.Register(lifecycle.Component{
+		Name:      "summary-backfill",
+		DependsOn: []string{"database"},
+		Run: lifecycle.Background(func(ctx context.Context) {
+			simulationService.RunSummaryBackfill(ctx, cfg.Database.SummaryBackfillInterval)
+		}),
+	})
+
+	// Drop login attempts and sessions past the login history retention
+	sessionService := database.NewSessionService(dbConn.DB, logger)
+	components.Register(lifecycle.Component{
+		Name:      "login-history",
+		DependsOn: []string{"database"},
+		Run: lifecycle.Background(func(ctx context.Context) {
+			sessionService.RunLoginHistoryPruning(ctx, cfg.API.Login.HistoryRetention)
+		}),
+	})
+
+	// Encrypt sensitive fields stored before encryption was enabled or with
+	// a key since rotated out
+	if database.FieldEncryption() && cfg.Database.ReencryptOnStart {
+		encryption := database.NewEncryptionService(dbConn.DB, logger)
+		components.Register(lifecycle.Component{
+			Name:      "field-encryption",
+			DependsOn: []string{"database"},
+			Run: lifecycle.Background(func(ctx context.Context) {
+				encryption.ReencryptFields(ctx)
+			}),
+		})
+	}
+
+	// Join the cluster so simulations are owned by exactly one replica
+	var clusterManager *cluster.Manager
+	if cfg.Cluster.Enabled {
+		clusterManager = cluster.New(database.NewClusterService(dbConn.DB, logger), orchestrator, cluster.Options{
+			NodeID:            cfg.Cluster.NodeID,
+			Address:           cfg.Cluster.AdvertiseURL,
+			HeartbeatInterval: cfg.Cluster.HeartbeatInterval,
+			NodeTTL:           cfg.Cluster.NodeTTL,
+			InternalToken:     cfg.Cluster.InternalToken,
+			Locker:            locker,
+		})
+		components.Register(lifecycle.Component{
+			Name:      "cluster",
+			DependsOn: []string{"database", "orchestrator"},
+			OnStart:   func(ctx context.Context) error { clusterManager.Start(ctx); return nil },
+			OnStop:    func(context.Context) error { clusterManager.Stop(); return nil },
+		})
+	}
+
+	// Meter usage per organization for quotas and billing
+	var meter *usage.Meter
+	if cfg.Usage.Enabled {
+		meter = usage.New(database.NewUsageService(dbConn.DB, logger), userService, orchestrator, usage.Options{
+			FlushInterval: cfg.Usage.FlushInterval,
+			DefaultQuotas: usage.Quotas{
+				APICallsPerDay:          cfg.Usage.Quotas.APICallsPerDay,
+				SimulationHoursPerMonth: cfg.Usage.Quotas.SimulationHoursPerMonth,
+				ResultRows:              cfg.Usage.Quotas.ResultRows,
+			},
+		})
+		// Run rather than Start, so stopping waits for the final flush
+		components.Register(lifecycle.Component{
+			Name:      "usage",
+			DependsOn: []string{"database", "orchestrator"},
+			Run:       lifecycle.Background(meter.Run),
+		})
+	}
+
+	if cfg.Orchestration.EngineBackend == "zig" {
+		orchestrator.SetEngineController(grpcClient)
+	}
+
+	// The self-test streams through the hub the API publishes to
+	hub := realtime.NewHub()
+	var selfTest *selftest.SelfTest
+	if cfg.SelfTest.Enabled {
+		selfTest = selftest.New(simulationService, orchestrator, selftest.Options{
+			Timeout: cfg.SelfTest.Timeout,
+			Engines: engines,
+			Hub:     hub,
+		})
+	}
+
+	// Initialize API server
+	apiServer := api.NewServer(&cfg.API, api.Dependencies{
+		Orchestrator:      orchestrator,
+		GRPCClient:        grpcClient,
+		SimulationService: simulationService,
+		WebhookService:    webhookService,
+		MetadataService:   metadataService,
+		UserService:       userService,
+		AuditService:      auditService,
+		TagService:        tagService,
+		DashboardService:  dashboardService,
+		PreferenceService: preferenceService,
+		Tokens:            tokens,
+		Notifier:          notifier,
+		Engines:           engines,
+		Predictions:       predictions,
+		Playback:          playbacks,
+		Reconciler:        reconciler,
+		Cluster:           clusterManager,
+		Archiver:          archiver,
+		AlertRouting:      alertRouting,
+		AlertRouter:       alertRouter,
+		Snapshots:         snapshots,
+		Chaos:             chaosController,
+		Recorder:          recorder,
+		Usage:             meter,
+		DatabasePool:      poolMonitor,
+		Reports:           reports,
+		ShareLinks:        database.NewShareLinkService(dbConn.DB, logger),
+		Realtime:          hub,
+		SelfTest:          selfTest,
+		UserSessions:      sessionService,
+	})
+
+	// Metrics share the API port with the REST API when multiplexed
+	handler := apiServer.Handler()
+	if cfg.API.Multiplex.Metrics {
+		handler = &api.Multiplexer{
+			REST:        handler,
+			Metrics:     observability.MetricsHandler(),
+			MetricsPath: cfg.Observability.MetricsPath,
 		}
-	}()
+	}
 
-	go func() {
-		logrus.WithField("port", cfg.Observability.MetricsPort).Info("Starting metrics server")
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logrus.WithError(err).Error("Metrics server failed")
+	// Servers start last and stop first, so no request arrives once the
+	// components serving it are stopping
+	components.Register(serverComponent("http-server", &http.Server{
+		Addr:         fmt.Sprintf(":%s", cfg.API.Port),
+		Handler:      handler,
+		ReadTimeout:  cfg.API.ReadTimeout,
+		WriteTimeout: cfg.API.WriteTimeout,
+		IdleTimeout:  cfg.API.IdleTimeout,
+	}))
+
+	// The internal API listens apart from the public one when it has a port
+	if internalHandler := apiServer.InternalHandler(); internalHandler != nil {
+		tlsConfig, err := api.InternalTLSConfig(cfg.API.Internal)
+		if err != nil {
+			return err
 		}
-	}()
+		components.Register(serverComponent("internal-server", &http.Server{
+			Addr:         fmt.Sprintf(":%s", cfg.API.Internal.Port),
+			Handler:      internalHandler,
+			TLSConfig:    tlsConfig,
+			ReadTimeout:  cfg.API.ReadTimeout,
+			WriteTimeout: cfg.API.WriteTimeout,
+			IdleTimeout:  cfg.API.IdleTimeout,
+		}))
+	}
+
+	// Metrics have a server of their own unless served on the API port
+	if !cfg.API.Multiplex.Metrics {
+		components.Register(serverComponent("metrics-server", &http.Server{
+			Addr:    fmt.Sprintf(":%s", cfg.Observability.MetricsPort),
+			Handler: observability.MetricsHandler(),
+		}))
+	}
 
-	// Wait for interrupt signal
+	// Runs once the servers are up, so /readyz reports it as pending
+	if selfTest != nil {
+		components.Register(lifecycle.Component{
+			Name:      "self-test",
+			DependsOn: []string{"orchestrator", "http-server"},
+			Run: func(ctx context.Context) error {
+				report := selfTest.Run(ctx)
+				if cfg.SelfTest.Required {
+					return report.Err()
+				}
+				return nil
+			},
+		})
+	}
+
+	if err := components.Start(context.Background()); err != nil {
+		return err
+	}
+
+	// Wait for interrupt signal or a server failing
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	var failure error
+	select {
+	case <-sigChan:
+	case failure = <-components.Failed():
+		logrus.WithError(failure).Error("Component failed")
+	}
 
-	logrus.Info("Shutting down servers...")
+	logrus.Info("Shutting down...")
 
 	// Graceful shutdown with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 
-	// Shutdown HTTP server
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		logrus.WithError(err).Error("HTTP server shutdown failed")
+	if err := components.Stop(shutdownCtx); err != nil {
+		logrus.WithError(err).Error("Shutdown was not clean")
 	}
 
-	// Shutdown metrics server
-	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
-		logrus.WithError(err).Error("Metrics server shutdown failed")
+	logrus.Info("Servers stopped")
+	return failure
+}
+
+// serverComponent runs an HTTP server as a component, serving TLS when it
+// has a TLS configuration
+func serverComponent(name string, server *http.Server) lifecycle.Component {
+	return lifecycle.Component{
+		Name: name,
+		Run: func(context.Context) error {
+			logrus.WithFields(logrus.Fields{
+				"addr": server.Addr,
+				"tls":  server.TLSConfig != nil,
+			}).Infof("Starting %s", name)
+
+			var err error
+			if server.TLSConfig != nil {
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				err = server.ListenAndServe()
+			}
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		},
+		OnStop: server.Shutdown,
 	}
+}
 
-	logrus.Info("Servers stopped")
-	return nil
+// newDatabaseConfig maps the database settings onto a connection config
+func newDatabaseConfig(cfg config.DatabaseConfig) database.Config {
+	return database.Config{
+		Host:         cfg.Host,
+		Port:         cfg.Port,
+		User:         cfg.Username,
+		Password:     cfg.Password,
+		Database:     cfg.Database,
+		SSLMode:      cfg.SSLMode,
+		MaxOpenConns: cfg.MaxConns,
+		MaxIdleConns: cfg.MinConns,
+		MaxLifetime:  cfg.MaxLifetime,
+		MaxIdleTime:  cfg.MaxIdleTime,
+		QueryTimeout: cfg.QueryTimeout,
+		IDFormat:     cfg.IDFormat,
+
+		PreparedStmt:           cfg.PreparedStatements,
+		StatementCacheCapacity: cfg.StatementCacheCapacity,
+		SkipDefaultTransaction: cfg.SkipDefaultTransaction,
+		CreateBatchSize:        cfg.CreateBatchSize,
+
+		EncryptionKeys:     cfg.EncryptionKeys,
+		EncryptionKeysFile: cfg.EncryptionKeysFile,
+	}
+}
+
+// newPredictionService selects the configured forecasting backend
+func newPredictionService(cfg config.PredictionConfig, results prediction.ResultSource, snapshots prediction.SnapshotStore) *prediction.Service {
+	opts := prediction.Options{
+		Horizon:     cfg.Horizon,
+		HistorySize: cfg.HistorySize,
+	}
+
+	var backend prediction.Backend = prediction.NewStatistical()
+	if cfg.Backend == "http" {
+		backend = prediction.NewHTTP(cfg.Endpoint, cfg.Timeout)
+		if cfg.Fallback {
+			opts.Fallback = prediction.NewStatistical()
+		}
+	}
+
+	logrus.WithField("backend", backend.Name()).Info("Prediction backend configured")
+	return prediction.NewService(backend, results, snapshots, opts)
+}
+
+// newLocker selects the configured lock backend
+func newLocker(cfg config.LockConfig, dbConn *database.Connection, logger *logrus.Logger) lock.Locker {
+	logrus.WithField("backend", cfg.Backend).Info("Lock backend configured")
+	if cfg.Backend == "local" {
+		return lock.NewLocal()
+	}
+	return database.NewLeaseLocker(dbConn.DB, logger, cfg.TTL)
+}
+
+// componentMetricsSink persists orchestrator runtime metrics as component metric rows
+type componentMetricsSink struct {
+	simulations *database.SimulationService
+}
+
+// RecordSimulationMetrics stores a sample with one timestamp for all of its rows.
+// Only simulations with a database row can be persisted; legacy sim_* IDs and
+// simulations that were never stored are skipped.
+func (s componentMetricsSink) RecordSimulationMetrics(simulationID string, sample orchestration.MetricsSample) error {
+	id, err := uuid.Parse(simulationID)
+	if err != nil {
+		return nil
+	}
+	ctx := context.Background()
+	if exists, err := s.simulations.SimulationExists(ctx, id); err != nil || !exists {
+		return err
+	}
+
+	row := func(componentType string, componentID int, name string, value float64, unit string) database.ComponentMetric {
+		return database.ComponentMetric{
+			SimulationID:  id,
+			ComponentType: componentType,
+			ComponentID:   componentID,
+			Timestamp:     sample.Timestamp,
+			MetricName:    name,
+			MetricValue:   value,
+			Unit:          unit,
+		}
+	}
+
+	metrics := []database.ComponentMetric{
+		row(orchestration.ComponentTypeSimulation, 0, "events_processed", float64(sample.EventsProcessed), "count"),
+		row(orchestration.ComponentTypeSimulation, 0, "avg_tick_time", sample.TickTimeMS, "ms"),
+		row(orchestration.ComponentTypeSimulation, 0, "memory_usage", float64(sample.MemoryUsageMB), "MB"),
+	}
+	for _, component := range sample.Components {
+		metrics = append(metrics, row(component.Type, component.ID, component.Metric, component.Value, component.Unit))
+	}
+
+	return s.simulations.AddComponentMetrics(ctx, metrics)
+}
+
+// clusterRuns reads the runs active on any instance from the simulations
+// table, where each instance records the status of its runs
+type clusterRuns struct {
+	simulations *database.SimulationService
+}
+
+func (r clusterRuns) active(ctx context.Context) ([]orchestration.ActiveRun, error) {
+	rows, err := r.simulations.GetSimulationOwnersByStatus(ctx, reconcile.StatusRunning, reconcile.StatusPaused)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]orchestration.ActiveRun, len(rows))
+	for i, row := range rows {
+		runs[i] = orchestration.ActiveRun{
+			SimulationID:   row.ID.String(),
+			OrganizationID: row.OrganizationID,
+			OwnerID:        row.UserID,
+		}
+	}
+	return runs, nil
+}
+
+// organizationConcurrency reads organizations' concurrency limit overrides
+// from their settings
+type organizationConcurrency struct {
+	users *database.UserService
+}
+
+func (s organizationConcurrency) overrides(organizationID uuid.UUID) (orchestration.ConcurrencyOverrides, error) {
+	organization, err := s.users.GetOrganization(organizationID)
+	if err != nil || organization == nil {
+		return orchestration.ConcurrencyOverrides{}, err
+	}
+
+	perOrganization, perUser := organization.ConcurrencyOverrides()
+	return orchestration.ConcurrencyOverrides{PerOrganization: perOrganization, PerUser: perUser}, nil
+}
+
+// runOutcomes scores finished batch instances from their stored results
+type runOutcomes struct {
+	simulations *database.SimulationService
+}
+
+// outcome has no results for legacy sim_* IDs, which store none
+func (s runOutcomes) outcome(ctx context.Context, simulationID string, baseFrequencyHz, toleranceHz float64) (*orchestration.RunOutcome, error) {
+	id, err := uuid.Parse(simulationID)
+	if err != nil {
+		return nil, nil
+	}
+
+	adequacy, err := analytics.AssessAdequacy(ctx, s.simulations, id, baseFrequencyHz, toleranceHz)
+	if err != nil {
+		return nil, err
+	}
+	return &orchestration.RunOutcome{
+		Results:                 adequacy.Results,
+		PeakLoadMW:              adequacy.PeakLoadMW,
+		PeakGenerationMW:        adequacy.PeakGenerationMW,
+		LossOfLoadResults:       adequacy.LossOfLoadResults,
+		LossOfLoadHours:         adequacy.LossOfLoadHours,
+		UnservedEnergyMWh:       adequacy.UnservedEnergyMWh,
+		MaxFrequencyDeviationHz: adequacy.MaxFrequencyDeviationHz,
+		FrequencyExcursions:     adequacy.FrequencyExcursions,
+	}, nil
+}
+
+// simulationRepository stores orchestrator simulations as rows of the
+// simulations table, keyed by the simulation's own ID
+type simulationRepository struct {
+	simulations *database.SimulationService
+	// In a cluster only the waiting simulations of this node are restored.
+	// Without a configured node ID a node is new on every start and owns
+	// none; the leader reassigns them and they are adopted instead.
+	clustered bool
+	nodeID    string
+}
+
+func (r simulationRepository) CreateSimulation(ctx context.Context, spec orchestration.SimulationSpec) error {
+	row, err := simulationRow(spec)
+	if err != nil || row == nil {
+		return err
+	}
+	return r.simulations.CreateSimulation(ctx, row)
+}
+
+func (r simulationRepository) SaveSimulation(ctx context.Context, spec orchestration.SimulationSpec) error {
+	row, err := simulationRow(spec)
+	if err != nil || row == nil {
+		return err
+	}
+	return r.simulations.SaveSimulation(ctx, row)
+}
+
+func (r simulationRepository) SaveSimulationConfig(ctx context.Context, spec orchestration.SimulationSpec) error {
+	row, err := simulationRow(spec)
+	if err != nil || row == nil {
+		return err
+	}
+	return r.simulations.SaveSimulationConfig(ctx, row)
+}
+
+// simulationRow maps a simulation onto its row; legacy sim_* IDs have none
+func simulationRow(spec orchestration.SimulationSpec) (*database.Simulation, error) {
+	id, err := uuid.Parse(spec.ID)
+	if err != nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(spec.Config)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]any
+	if err := json.Unmarshal(encoded, &config); err != nil {
+		return nil, err
+	}
+
+	return &database.Simulation{
+		ID:             id,
+		Name:           spec.Name,
+		Description:    spec.Description,
+		UserID:         spec.OwnerID,
+		OrganizationID: spec.OrganizationID,
+		Engine:         spec.Engine,
+		Config:         config,
+		Tags:           spec.Tags,
+		Metadata:       spec.Metadata,
+		CreatedAt:      spec.CreatedAt,
+		Version:        spec.Version,
+		DependsOn:      dependencyRows(spec.DependsOn),
+	}, nil
+}
+
+func dependencyRows(deps []orchestration.Dependency) []database.SimulationDependency {
+	if len(deps) == 0 {
+		return nil
+	}
+	rows := make([]database.SimulationDependency, len(deps))
+	for i, dep := range deps {
+		rows[i] = database.SimulationDependency{SimulationID: dep.SimulationID, HandoffState: dep.HandoffState}
+	}
+	return rows
+}
+
+func (r simulationRepository) LoadSimulation(ctx context.Context, id string) (*orchestration.StoredSimulation, error) {
+	simulationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil
+	}
+	row, err := r.simulations.FindSimulation(ctx, simulationID)
+	if err != nil || row == nil {
+		return nil, err
+	}
+	return storedSimulation(row)
+}
+
+func (r simulationRepository) LoadWaitingSimulations(ctx context.Context) ([]orchestration.StoredSimulation, error) {
+	owner := ""
+	if r.clustered {
+		if r.nodeID == "" {
+			return nil, nil
+		}
+		owner = r.nodeID
+	}
+
+	rows, err := r.simulations.GetWaitingSimulations(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make([]orchestration.StoredSimulation, 0, len(rows))
+	for i := range rows {
+		simulation, err := storedSimulation(&rows[i])
+		if err != nil {
+			logrus.WithError(err).WithField("simulation_id", rows[i].ID).Warn("Skipping waiting simulation")
+			continue
+		}
+		stored = append(stored, *simulation)
+	}
+	return stored, nil
+}
+
+// storedSimulation maps a row back onto the simulation it stores
+func storedSimulation(row *database.Simulation) (*orchestration.StoredSimulation, error) {
+	var config orchestration.SimulationConfig
+	encoded, err := json.Marshal(row.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &config); err != nil {
+		return nil, fmt.Errorf("invalid stored config: %w", err)
+	}
+
+	// A run that was cut off by a restart cannot be picked up again
+	status := orchestration.StatusIdle
+	switch row.Status {
+	case reconcile.StatusCompleted, reconcile.StatusStopped:
+		status = orchestration.StatusCompleted
+	case reconcile.StatusFailed, reconcile.StatusInterrupted:
+		status = orchestration.StatusError
+	}
+
+	var deps []orchestration.Dependency
+	for _, dep := range row.DependsOn {
+		deps = append(deps, orchestration.Dependency{SimulationID: dep.SimulationID, HandoffState: dep.HandoffState})
+	}
+
+	return &orchestration.StoredSimulation{
+		Spec: orchestration.SimulationSpec{
+			ID:          row.ID.String(),
+			Name:        row.Name,
+			Description: row.Description,
+			Engine:      row.Engine,
+			Config:      config,
+			Tags:        row.Tags,
+			Metadata:    row.Metadata,
+			CreatedAt:   row.CreatedAt,
+			Version:     row.Version,
+
+			OrganizationID: row.OrganizationID,
+			OwnerID:        row.UserID,
+
+			DependsOn: deps,
+		},
+		Status: status,
+	}, nil
+}
+
+func (r simulationRepository) DeleteSimulation(ctx context.Context, id string) error {
+	simulationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return r.simulations.DeleteSimulation(ctx, simulationID)
+}
+
+// simulationStatusStore persists orchestrator status transitions to the
+// simulations table
+type simulationStatusStore struct {
+	simulations *database.SimulationService
+}
+
+// record stores the status a run moved to. Idle and waiting simulations have
+// not run, so their rows are left alone. Simulations share their ID with
+// their row; legacy sim_* IDs have none.
+func (s simulationStatusStore) record(transition orchestration.Transition) {
+	id, err := uuid.Parse(transition.SimulationID)
+	if err != nil {
+		return
+	}
+
+	var status string
+	switch transition.To {
+	case orchestration.StatusRunning:
+		status = reconcile.StatusRunning
+	case orchestration.StatusPaused:
+		status = reconcile.StatusPaused
+	case orchestration.StatusCompleted:
+		status = reconcile.StatusCompleted
+		if errors.Is(transition.Err, orchestration.ErrStopRequested) {
+			status = reconcile.StatusStopped
+		}
+	case orchestration.StatusError:
+		status = reconcile.StatusFailed
+	default:
+		return
+	}
+
+	if err := s.simulations.UpdateSimulationStatus(context.Background(), id, status); err != nil {
+		logrus.WithError(err).WithField("simulation_id", transition.SimulationID).Warn("Failed to persist simulation status")
+	}
+}
+
+// stabilityScorer computes the frequency stability of runs as they end, so
+// scenarios can be ranked without anyone asking for each score first
+type stabilityScorer struct {
+	simulations *database.SimulationService
+}
+
+// record scores a run that completed or was stopped. Scoring reads all of the
+// run's results, so it happens in the background.
+func (s stabilityScorer) record(transition orchestration.Transition) {
+	ran := transition.From == orchestration.StatusRunning || transition.From == orchestration.StatusPaused
+	if transition.To != orchestration.StatusCompleted || !ran {
+		return
+	}
+	id, err := uuid.Parse(transition.SimulationID)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		ctx := context.Background()
+		simulation, err := s.simulations.GetSimulation(ctx, id)
+		if err != nil || simulation == nil {
+			return
+		}
+		score, err := analytics.ScoreStability(ctx, s.simulations, id, analytics.NominalFrequency(simulation), analytics.DefaultStabilityBandHz)
+		if err != nil {
+			logrus.WithError(err).WithField("simulation_id", transition.SimulationID).Warn("Failed to score frequency stability")
+			return
+		}
+		logrus.WithFields(logrus.Fields{
+			"simulation_id": transition.SimulationID,
+			"score":         score.Score,
+		}).Debug("Frequency stability scored")
+	}()
+}
+
+// emissionsRecorder stores the emissions totals of runs as they end, so
+// reading a run's emissions does not have to write them
+type emissionsRecorder struct {
+	simulations *database.SimulationService
+}
+
+// record computes the emissions of a run that completed or was stopped, in
+// the background like stability scoring
+func (s emissionsRecorder) record(transition orchestration.Transition) {
+	ran := transition.From == orchestration.StatusRunning || transition.From == orchestration.StatusPaused
+	if transition.To != orchestration.StatusCompleted || !ran {
+		return
+	}
+	id, err := uuid.Parse(transition.SimulationID)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		report, err := analytics.RecordEmissions(context.Background(), s.simulations, id)
+		if err != nil {
+			if !errors.Is(err, analytics.ErrNoPlantOutputs) {
+				logrus.WithError(err).WithField("simulation_id", transition.SimulationID).Warn("Failed to record emissions")
+			}
+			return
+		}
+		logrus.WithFields(logrus.Fields{
+			"simulation_id": transition.SimulationID,
+			"emissions_kg":  report.TotalEmissionsKg,
+		}).Debug("Emissions recorded")
+	}()
+}
+
+// recordTransitionMetrics counts runs starting, ending and failing
+func recordTransitionMetrics(transition orchestration.Transition) {
+	ran := transition.From == orchestration.StatusRunning || transition.From == orchestration.StatusPaused
+	switch {
+	case transition.To == orchestration.StatusRunning && !ran:
+		observability.RecordSimulationStart(transition.SimulationID)
+	case transition.To == orchestration.StatusCompleted && ran:
+		observability.RecordSimulationStop(transition.SimulationID, transition.Duration)
+	case transition.To == orchestration.StatusError && ran:
+		observability.RecordSimulationError(transition.SimulationID)
+	}
 }
 
 func newVersionCmd() *cobra.Command {
@@ -220,6 +1137,36 @@ func newHealthCmd() *cobra.Command {
 	}
 }
 
+func newServiceTokenCmd() *cobra.Command {
+	var ttl time.Duration
+	cmd := &cobra.Command{
+		Use:   "service-token <service>",
+		Short: "Issue a token for a backend service to call the internal API with",
+		Long: `Sign a token naming a backend service, such as the engine sidecar, with
+api.internal.service_secret. The service sends it in the
+X-VoltEdge-Service-Token header of its calls to /internal/v1.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if cfg.API.Internal.ServiceSecret == "" {
+				return errors.New("api.internal.service_secret is not set")
+			}
+
+			token, err := auth.NewServiceTokens(cfg.API.Internal.ServiceSecret).Issue(args[0], ttl)
+			if err != nil {
+				return err
+			}
+			fmt.Println(token)
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&ttl, "ttl", 0, "token lifetime; zero issues a token that does not expire")
+	return cmd
+}
+
 func newConfigCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "config",
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"voltedge/go-services/internal/recording"
+)
+
+// replayOptions holds the flags of the replay subcommand
+type replayOptions struct {
+	file    string
+	server  string
+	token   string
+	pace    float64
+	timeout time.Duration
+	verbose bool
+}
+
+func newReplayCmd() *cobra.Command {
+	opts := replayOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Re-drive recorded API traffic against a gateway",
+		Long: `Send the API requests of a recording (see recording.enabled) to a gateway
+in their recorded order and compare the status codes it returns with the
+recorded ones. IDs the gateway assigns to created resources are substituted
+into later requests. Redacted values are sent as recorded, so requests that
+depended on them are expected to differ. Exits with:
+
+  0  every request matched
+  1  some requests returned a different status
+  2  the recording could not be read or the gateway reached`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := signalContext(cmd)
+			defer cancel()
+			return replayRecording(ctx, cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.file, "file", "", "recording to replay (JSON lines)")
+	cmd.Flags().StringVar(&opts.server, "server", envOr("VOLTEDGE_SERVER", "http://localhost:8080"), "gateway base URL")
+	cmd.Flags().StringVar(&opts.token, "token", os.Getenv("VOLTEDGE_TOKEN"), "bearer token sent with every request")
+	cmd.Flags().Float64Var(&opts.pace, "pace", 0, "replay the recorded gaps between requests at this speed-up (0 sends them back to back)")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of each request")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "print every request, not only mismatches")
+
+	return cmd
+}
+
+// replayRecording replays a recording and prints the mismatches and a summary
+func replayRecording(ctx context.Context, out io.Writer, opts replayOptions) error {
+	if opts.file == "" {
+		return &exitCodeError{exitError, errors.New("--file is required")}
+	}
+	if opts.pace < 0 {
+		return &exitCodeError{exitError, errors.New("--pace must not be negative")}
+	}
+
+	file, err := os.Open(opts.file)
+	if err != nil {
+		return &exitCodeError{exitError, err}
+	}
+	defer file.Close()
+
+	report, err := recording.Replay(ctx, recording.NewReader(file), recording.ReplayOptions{
+		Target: opts.server,
+		Token:  opts.token,
+		Pace:   opts.pace,
+		Client: &http.Client{Timeout: opts.timeout},
+	}, func(exchange *recording.HTTPExchange, status int, mismatch *recording.Mismatch) {
+		switch {
+		case mismatch != nil:
+			fmt.Fprintf(out, "MISMATCH #%d %s %s: recorded %d, got %d", mismatch.Seq, mismatch.Method, mismatch.Path, mismatch.RecordedStatus, mismatch.ReplayedStatus)
+			if mismatch.Message != "" {
+				fmt.Fprintf(out, " (%s)", mismatch.Message)
+			}
+			fmt.Fprintln(out)
+		case opts.verbose:
+			fmt.Fprintf(out, "ok %s %s: %d\n", exchange.Method, exchange.Path, status)
+		}
+	})
+	if report != nil {
+		fmt.Fprintf(out, "Replayed %d requests: %d matched, %d mismatched (%d engine calls in recording)\n",
+			report.Requests, report.Matched, len(report.Mismatches), report.EngineCalls)
+	}
+	if err != nil {
+		return &exitCodeError{exitError, err}
+	}
+	if len(report.Mismatches) > 0 {
+		return &exitCodeError{exitFailed, fmt.Errorf("%d of %d replayed requests returned a different status", len(report.Mismatches), report.Requests)}
+	}
+	return nil
+}
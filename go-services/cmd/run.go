@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"voltedge/go-services/internal/api"
+	"voltedge/go-services/internal/config"
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/pkg/client"
+)
+
+// Exit codes of the run subcommand
+const (
+	exitCompleted   = 0
+	exitFailed      = 1
+	exitError       = 2
+	exitTimedOut    = 3
+	exitInterrupted = 130
+)
+
+// exitCodeError carries the process exit code for a command's error
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// runOptions holds the flags of the run subcommand
+type runOptions struct {
+	specPath string
+	server   string
+	token    string
+	watch    bool
+	embedded bool
+	interval time.Duration
+	timeout  time.Duration
+}
+
+func newRunCmd() *cobra.Command {
+	opts := runOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run a simulation headlessly",
+		Long: `Submit a simulation to a running gateway, or to an embedded one with
+--embedded, and start it. With --watch, progress is streamed to the terminal
+and the command exits with the simulation's final status:
+
+  0    completed
+  1    failed
+  2    could not be submitted or watched
+  3    --timeout elapsed first
+  130  interrupted`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSimulation(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.specPath, "config", "", "simulation spec file (YAML or JSON)")
+	cmd.Flags().StringVar(&opts.server, "server", envOr("VOLTEDGE_SERVER", "http://localhost:8080"), "gateway base URL")
+	cmd.Flags().StringVar(&opts.token, "token", os.Getenv("VOLTEDGE_TOKEN"), "bearer token for the gateway")
+	cmd.Flags().BoolVar(&opts.watch, "watch", false, "stream progress and exit with the final status")
+	cmd.Flags().BoolVar(&opts.embedded, "embedded", false, "run against an in-process gateway instead of --server")
+	cmd.Flags().DurationVar(&opts.interval, "interval", time.Second, "progress polling interval")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "give up watching after this long (0 waits forever)")
+
+	return cmd
+}
+
+// runSimulation submits and starts the simulation, then watches it if asked
+func runSimulation(ctx context.Context, out io.Writer, opts runOptions) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if opts.specPath == "" {
+		return &exitCodeError{exitError, errors.New("--config is required")}
+	}
+
+	req, err := loadSimulationSpec(opts.specPath)
+	if err != nil {
+		return &exitCodeError{exitError, err}
+	}
+
+	server := opts.server
+	if opts.embedded {
+		address, stop, err := startEmbeddedGateway()
+		if err != nil {
+			return &exitCodeError{exitError, fmt.Errorf("failed to start embedded gateway: %w", err)}
+		}
+		defer stop()
+		server = address
+	}
+
+	gateway := client.New(server, client.WithToken(opts.token))
+
+	simulation, err := gateway.CreateSimulation(ctx, *req)
+	if err != nil {
+		return &exitCodeError{exitError, fmt.Errorf("failed to create simulation: %w", err)}
+	}
+	for _, warning := range simulation.Warnings {
+		fmt.Fprintf(out, "Warning: %s\n", warning.Message)
+	}
+	if err := gateway.StartSimulation(ctx, simulation.ID); err != nil {
+		return &exitCodeError{exitError, fmt.Errorf("failed to start simulation %s: %w", simulation.ID, err)}
+	}
+	fmt.Fprintf(out, "Started simulation %s (%s)\n", simulation.ID, simulation.Name)
+
+	if !opts.watch {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	final, err := watchSimulation(ctx, gateway, simulation.ID, opts.interval, newProgress(out))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return &exitCodeError{exitTimedOut, fmt.Errorf("simulation %s did not finish within %s", simulation.ID, opts.timeout)}
+	case errors.Is(err, context.Canceled):
+		// Do not leave the run going on the gateway
+		stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		gateway.StopSimulation(stopCtx, simulation.ID)
+		return &exitCodeError{exitInterrupted, fmt.Errorf("interrupted, stopped simulation %s", simulation.ID)}
+	case err != nil:
+		return &exitCodeError{exitError, err}
+	}
+
+	fmt.Fprintf(out, "Simulation %s finished: %s\n", final.ID, final.Status)
+	if final.Status != orchestration.StatusCompleted.String() {
+		message := final.Status
+		if final.Error != "" {
+			message = final.Error
+		}
+		return &exitCodeError{exitFailed, fmt.Errorf("simulation %s failed: %s", final.ID, message)}
+	}
+	return nil
+}
+
+// watchSimulation polls a simulation until it reaches a terminal status
+func watchSimulation(ctx context.Context, gateway *client.Client, id string, interval time.Duration, progress *progress) (*api.SimulationResponse, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		simulation, err := gateway.GetSimulation(ctx, id)
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("failed to get simulation %s: %w", id, err)
+		}
+		if err == nil {
+			terminal := isTerminalStatus(simulation.Status)
+			progress.update(simulation, terminal)
+			if terminal {
+				return simulation, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			progress.done()
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// isTerminalStatus reports whether a run has finished. A started simulation
+// only goes back to idle when it is canceled while queued.
+func isTerminalStatus(status string) bool {
+	switch status {
+	case orchestration.StatusCompleted.String(), orchestration.StatusError.String(), orchestration.StatusIdle.String():
+		return true
+	default:
+		return false
+	}
+}
+
+// loadSimulationSpec reads a create request from a YAML or JSON file
+func loadSimulationSpec(path string) (*api.CreateSimulationRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulation spec: %w", err)
+	}
+
+	// YAML is decoded generically and re-encoded so the API's JSON field names apply
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse simulation spec: %w", err)
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return nil, fmt.Errorf("failed to parse simulation spec: %w", err)
+		}
+	}
+
+	var req api.CreateSimulationRequest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return nil, fmt.Errorf("failed to parse simulation spec: %w", err)
+	}
+	if req.Name == "" {
+		return nil, fmt.Errorf("simulation spec must have a name")
+	}
+
+	return &req, nil
+}
+
+// startEmbeddedGateway serves the API with an in-process orchestrator on a
+// loopback port. Nothing is persisted.
+func startEmbeddedGateway() (string, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", nil, err
+	}
+	logrus.SetLevel(logrus.WarnLevel)
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	// Simulations run on the in-process mock engine, so no Zig binary is needed
+	engines := engine.NewRegistry()
+	engines.RegisterEngine(engine.NewMockEngine(time.Second))
+
+	orchestrator := orchestration.NewOrchestrator(&cfg.Orchestration)
+	orchestrator.SetEngineRegistry(engines)
+	orchestrator.SetMetadataLimits(cfg.API.Metadata)
+	if err := orchestrator.Start(context.Background()); err != nil {
+		return "", nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		orchestrator.Stop()
+		return "", nil, err
+	}
+
+	server := &http.Server{Handler: api.NewServer(&cfg.API, api.Dependencies{Orchestrator: orchestrator}).Handler()}
+	go server.Serve(listener)
+
+	stop := func() {
+		server.Close()
+		orchestrator.Stop()
+	}
+	return "http://" + listener.Addr().String(), stop, nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// progress renders a simulation's progress. On a terminal the line is redrawn
+// in place; otherwise a line is printed whenever the status changes.
+type progress struct {
+	out        io.Writer
+	tty        bool
+	started    time.Time
+	frame      int
+	lastStatus string
+}
+
+func newProgress(out io.Writer) *progress {
+	tty := false
+	if file, ok := out.(*os.File); ok {
+		if info, err := file.Stat(); err == nil {
+			tty = info.Mode()&os.ModeCharDevice != 0
+		}
+	}
+	return &progress{out: out, tty: tty, started: time.Now()}
+}
+
+// progressWidth is the width of the bar in characters
+const progressWidth = 24
+
+func (p *progress) update(simulation *api.SimulationResponse, terminal bool) {
+	line := fmt.Sprintf("%s %-9s %8s  events %d  tick %.2fms  mem %dMB",
+		p.bar(simulation.Progress, terminal),
+		simulation.Status,
+		time.Since(p.started).Truncate(time.Second),
+		simulation.EventsProcessed,
+		simulation.AvgTickTimeMS,
+		simulation.MemoryUsageMB,
+	)
+	if simulation.Progress != nil {
+		line += "  " + formatProgress(simulation.Progress)
+	}
+	if simulation.Queue != nil {
+		line += "  " + formatQueue(simulation.Queue)
+	}
+
+	if p.tty {
+		fmt.Fprintf(p.out, "\r\033[K%s", line)
+		if terminal {
+			fmt.Fprintln(p.out)
+		}
+		return
+	}
+
+	if simulation.Status != p.lastStatus || terminal {
+		fmt.Fprintln(p.out, line)
+	}
+	p.lastStatus = simulation.Status
+}
+
+// bar draws a full bar once the run finishes. Until then it fills with the
+// run's progress when its config declares a target, and otherwise a block
+// sweeps across it.
+func (p *progress) bar(runProgress *orchestration.Progress, terminal bool) string {
+	if terminal {
+		return "[" + strings.Repeat("=", progressWidth) + "]"
+	}
+	if runProgress != nil {
+		filled := int(runProgress.Percent / 100 * progressWidth)
+		return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled) + "]"
+	}
+
+	p.frame++
+	position := p.frame % (2 * (progressWidth - 3))
+	if position >= progressWidth-3 {
+		position = 2*(progressWidth-3) - position
+	}
+	return "[" + strings.Repeat(" ", position) + "===" + strings.Repeat(" ", progressWidth-3-position) + "]"
+}
+
+// formatProgress describes a run's progress as its percentage and, while it
+// runs, the estimated time left
+func formatProgress(runProgress *orchestration.Progress) string {
+	text := fmt.Sprintf("%.1f%%", runProgress.Percent)
+	if runProgress.ETASeconds != nil {
+		eta := time.Duration(*runProgress.ETASeconds * float64(time.Second))
+		text += fmt.Sprintf(" eta %s", eta.Round(time.Second))
+	}
+	return text
+}
+
+// formatQueue summarizes a queued run's place in line as "queued #3 starts in 40s"
+func formatQueue(queue *orchestration.QueueStatus) string {
+	text := fmt.Sprintf("queued #%d", queue.Position)
+	if queue.EstimatedStartAt != nil {
+		wait := max(time.Until(*queue.EstimatedStartAt), 0)
+		text += fmt.Sprintf(" starts in %s", wait.Round(time.Second))
+	}
+	return text
+}
+
+// done ends an in-place progress line
+func (p *progress) done() {
+	if p.tty {
+		fmt.Fprintln(p.out)
+	}
+}
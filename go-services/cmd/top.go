@@ -0,0 +1,527 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/spf13/cobra"
+
+	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/pkg/client"
+)
+
+// topOptions holds the flags of the top subcommand
+type topOptions struct {
+	server   string
+	token    string
+	interval time.Duration
+	history  int
+}
+
+func newTopCmd() *cobra.Command {
+	opts := topOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Watch and control a gateway's simulations in a terminal UI",
+		Long: `Show the simulations of a gateway with their live status. The selected
+simulation's results are streamed over the gateway's WebSocket and drawn as
+sparklines of total generation (the sum of the power plants' output_mw
+readings) and grid frequency (frequency_hz readings, when the engine reports
+them). Keys:
+
+  up/down  select a simulation
+  s        start the selected simulation
+  p        pause it, or resume it when paused
+  x        stop it
+  k        kill it, dropping the tick in progress
+  r        refresh now
+  q        quit`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := signalContext(cmd)
+			defer cancel()
+			return runTop(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.server, "server", envOr("VOLTEDGE_SERVER", "http://localhost:8080"), "gateway base URL")
+	cmd.Flags().StringVar(&opts.token, "token", os.Getenv("VOLTEDGE_TOKEN"), "bearer token for the gateway")
+	cmd.Flags().DurationVar(&opts.interval, "interval", 2*time.Second, "simulation list refresh interval")
+	cmd.Flags().IntVar(&opts.history, "history", 60, "results samples kept for the sparklines")
+
+	return cmd
+}
+
+// runTop runs the terminal UI until the user quits or ctx is canceled
+func runTop(ctx context.Context, opts topOptions) error {
+	if opts.interval <= 0 {
+		return &exitCodeError{exitError, errors.New("--interval must be positive")}
+	}
+	if opts.history < 2 {
+		return &exitCodeError{exitError, errors.New("--history must be at least 2")}
+	}
+
+	gateway := client.New(opts.server, client.WithToken(opts.token))
+	if _, err := gateway.ListSimulationsPage(ctx, client.SimulationListOptions{ListOptions: client.ListOptions{Limit: 1}}); err != nil {
+		return &exitCodeError{exitError, fmt.Errorf("failed to reach gateway %s: %w", opts.server, err)}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	view := newTopView(gateway, opts)
+	go func() {
+		<-ctx.Done()
+		view.app.Stop()
+	}()
+	go view.poll(ctx)
+
+	return view.app.Run()
+}
+
+// topSeries is the recent results of one simulation
+type topSeries struct {
+	generation []float64
+	frequency  []float64
+	// Progress carried by the latest results frame
+	progress *client.Progress
+}
+
+// topView is the state of the terminal UI. Its fields are only touched on
+// the UI goroutine; background work hands results over with QueueUpdateDraw.
+type topView struct {
+	gateway *client.Client
+	opts    topOptions
+
+	app     *tview.Application
+	table   *tview.Table
+	details *tview.TextView
+	footer  *tview.TextView
+
+	simulations []client.Simulation
+	selected    string
+	series      map[string]*topSeries
+	// Simulation whose results are being streamed, and how to stop it.
+	// Each stream is numbered so a replaced one cannot clear its successor.
+	streaming  string
+	stopStream context.CancelFunc
+	streamSeq  int
+	rebuilding bool
+	refresh    chan struct{}
+	message    string
+}
+
+func newTopView(gateway *client.Client, opts topOptions) *topView {
+	v := &topView{
+		gateway: gateway,
+		opts:    opts,
+		app:     tview.NewApplication(),
+		table:   tview.NewTable(),
+		details: tview.NewTextView(),
+		footer:  tview.NewTextView(),
+		series:  make(map[string]*topSeries),
+		refresh: make(chan struct{}, 1),
+	}
+
+	v.table.SetSelectable(true, false).SetFixed(1, 0)
+	v.table.SetBorder(true).SetTitle(" Simulations - " + gateway.BaseURL() + " ")
+	v.table.SetSelectionChangedFunc(func(row, _ int) {
+		if !v.rebuilding {
+			v.selectRow(row)
+		}
+	})
+	v.details.SetDynamicColors(true)
+	v.details.SetBorder(true).SetTitle(" Results ")
+	v.footer.SetDynamicColors(true)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.table, 0, 1, true).
+		AddItem(v.details, 6, 0, false).
+		AddItem(v.footer, 1, 0, false)
+	v.app.SetRoot(layout, true)
+	v.app.SetInputCapture(v.handleKey)
+
+	v.renderTable()
+	v.renderDetails()
+	v.renderFooter()
+	return v
+}
+
+// handleKey runs the keyboard commands; other keys reach the table
+func (v *topView) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Rune() {
+	case 'q':
+		v.app.Stop()
+	case 'r':
+		v.requestRefresh()
+	case 's':
+		v.control("start", v.gateway.StartSimulation)
+	case 'p':
+		if v.selectedStatus() == orchestration.StatusPaused.String() {
+			v.control("resume", v.gateway.ResumeSimulation)
+		} else {
+			v.control("pause", v.gateway.PauseSimulation)
+		}
+	case 'x':
+		v.control("stop", v.gateway.StopSimulation)
+	case 'k':
+		v.control("kill", v.gateway.ForceStopSimulation)
+	default:
+		return event
+	}
+	return nil
+}
+
+// selectedStatus returns the selected simulation's status as last listed
+func (v *topView) selectedStatus() string {
+	for _, simulation := range v.simulations {
+		if simulation.ID == v.selected {
+			return simulation.Status
+		}
+	}
+	return ""
+}
+
+// control applies an action to the selected simulation in the background
+func (v *topView) control(action string, apply func(context.Context, string) error) {
+	id := v.selected
+	if id == "" {
+		return
+	}
+	v.setMessage(fmt.Sprintf("%s %s...", action, id))
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := apply(ctx, id)
+		v.app.QueueUpdateDraw(func() {
+			if err != nil {
+				v.setMessage(fmt.Sprintf("[red]%s %s failed: %v", action, id, tview.Escape(err.Error())))
+				return
+			}
+			v.setMessage(fmt.Sprintf("%s %s: ok", action, id))
+		})
+		v.requestRefresh()
+	}()
+}
+
+// poll refreshes the simulation list every interval, or sooner when asked
+func (v *topView) poll(ctx context.Context) {
+	ticker := time.NewTicker(v.opts.interval)
+	defer ticker.Stop()
+
+	for {
+		simulations, err := v.fetch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		v.app.QueueUpdateDraw(func() {
+			if err != nil {
+				v.setMessage("[red]refresh failed: " + tview.Escape(err.Error()))
+				return
+			}
+			v.simulations = simulations
+			v.renderTable()
+			v.ensureStream()
+			v.renderDetails()
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-v.refresh:
+		}
+	}
+}
+
+// fetch lists every simulation of the gateway
+func (v *topView) fetch(ctx context.Context) ([]client.Simulation, error) {
+	var simulations []client.Simulation
+	for simulation, err := range v.gateway.ListSimulations(ctx, client.SimulationListOptions{}) {
+		if err != nil {
+			return nil, err
+		}
+		simulations = append(simulations, simulation)
+	}
+	return simulations, nil
+}
+
+func (v *topView) requestRefresh() {
+	select {
+	case v.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// selectRow makes the simulation in a table row the selected one
+func (v *topView) selectRow(row int) {
+	if row < 1 || row > len(v.simulations) {
+		return
+	}
+	v.selected = v.simulations[row-1].ID
+	v.ensureStream()
+	v.renderDetails()
+}
+
+// ensureStream streams the results of the selected simulation, replacing the
+// stream of the previously selected one
+func (v *topView) ensureStream() {
+	if v.selected == v.streaming {
+		return
+	}
+	if v.stopStream != nil {
+		v.stopStream()
+		v.stopStream = nil
+	}
+	v.streaming = v.selected
+	if v.selected == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	v.stopStream = cancel
+	v.streamSeq++
+	go v.stream(ctx, v.selected, v.streamSeq)
+}
+
+// stream feeds a simulation's results samples into its series until ctx is
+// canceled or the connection drops, after which the next refresh reconnects
+func (v *topView) stream(ctx context.Context, id string, seq int) {
+	frames, err := v.gateway.StreamResults(ctx, id)
+	if err != nil {
+		v.app.QueueUpdateDraw(func() {
+			if ctx.Err() == nil {
+				v.setMessage("[red]results stream failed: " + tview.Escape(err.Error()))
+			}
+			v.streamEnded(seq)
+		})
+		return
+	}
+
+	for frame := range frames {
+		v.app.QueueUpdateDraw(func() {
+			v.record(id, frame)
+			if id == v.selected {
+				v.renderDetails()
+			}
+		})
+	}
+	v.app.QueueUpdateDraw(func() { v.streamEnded(seq) })
+}
+
+// streamEnded forgets a stream that is no longer running
+func (v *topView) streamEnded(seq int) {
+	if v.streamSeq == seq {
+		v.streaming = ""
+		v.stopStream = nil
+	}
+}
+
+// record appends a results frame's sample to a simulation's series
+func (v *topView) record(id string, frame client.ResultsFrame) {
+	series, ok := v.series[id]
+	if !ok {
+		series = &topSeries{}
+		v.series[id] = series
+	}
+	series.progress = frame.Progress
+
+	var generation, frequency float64
+	var plants, readings int
+	for _, component := range frame.Components {
+		switch {
+		case component.Type == "power_plant" && component.Metric == "output_mw":
+			generation += component.Value
+			plants++
+		case component.Metric == "frequency_hz":
+			frequency += component.Value
+			readings++
+		}
+	}
+	if plants > 0 {
+		series.generation = appendBounded(series.generation, generation, v.opts.history)
+	}
+	if readings > 0 {
+		series.frequency = appendBounded(series.frequency, frequency/float64(readings), v.opts.history)
+	}
+}
+
+// appendBounded appends value, dropping the oldest values beyond limit
+func appendBounded(values []float64, value float64, limit int) []float64 {
+	values = append(values, value)
+	if len(values) > limit {
+		values = values[len(values)-limit:]
+	}
+	return values
+}
+
+// renderTable redraws the simulation list, keeping the selected simulation
+// selected
+func (v *topView) renderTable() {
+	v.rebuilding = true
+	defer func() { v.rebuilding = false }()
+
+	v.table.Clear()
+	for col, title := range []string{"ID", "NAME", "STATUS", "ENGINE", "SPEED", "EVENTS", "TICK MS", "MEM MB"} {
+		v.table.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false).
+			SetExpansion(1))
+	}
+
+	selectedRow := 0
+	for i, simulation := range v.simulations {
+		row := i + 1
+		if simulation.ID == v.selected {
+			selectedRow = row
+		}
+		speed := "max"
+		if simulation.Speed > 0 {
+			speed = fmt.Sprintf("%gx", simulation.Speed)
+		}
+		cells := []string{
+			simulation.ID,
+			simulation.Name,
+			simulation.Status,
+			simulation.Engine,
+			speed,
+			fmt.Sprint(simulation.EventsProcessed),
+			fmt.Sprintf("%.2f", simulation.AvgTickTimeMS),
+			fmt.Sprint(simulation.MemoryUsageMB),
+		}
+		for col, text := range cells {
+			cell := tview.NewTableCell(tview.Escape(text)).SetExpansion(1)
+			if col == 2 {
+				cell.SetTextColor(statusColor(simulation.Status))
+			}
+			v.table.SetCell(row, col, cell)
+		}
+	}
+
+	switch {
+	case len(v.simulations) == 0:
+		v.selected = ""
+	case selectedRow == 0:
+		// The selected simulation is gone; fall back to the first one
+		selectedRow = 1
+		v.selected = v.simulations[0].ID
+	}
+	if selectedRow > 0 {
+		v.table.Select(selectedRow, 0)
+	}
+}
+
+// renderDetails redraws the sparklines of the selected simulation
+func (v *topView) renderDetails() {
+	if v.selected == "" {
+		v.details.SetText("No simulations")
+		return
+	}
+
+	var simulation *client.Simulation
+	for i := range v.simulations {
+		if v.simulations[i].ID == v.selected {
+			simulation = &v.simulations[i]
+		}
+	}
+	series := v.series[v.selected]
+	if series == nil {
+		series = &topSeries{}
+	}
+
+	var text strings.Builder
+	if simulation != nil {
+		fmt.Fprintf(&text, "[::b]%s[::-] %s  [%s]%s[-]",
+			tview.Escape(simulation.ID), tview.Escape(simulation.Name), statusColor(simulation.Status).String(), simulation.Status)
+		// Frames arrive more often than the list is polled
+		runProgress := simulation.Progress
+		if series.progress != nil && simulation.Status == orchestration.StatusRunning.String() {
+			runProgress = series.progress
+		}
+		if runProgress != nil {
+			fmt.Fprintf(&text, "  %s", formatProgress(runProgress))
+		}
+		if simulation.Error != "" {
+			fmt.Fprintf(&text, "  [red]%s[-]", tview.Escape(simulation.Error))
+		}
+	}
+	text.WriteString("\n\n")
+	writeSeries(&text, "Generation", series.generation, "MW", "%.1f")
+	writeSeries(&text, "Frequency ", series.frequency, "Hz", "%.3f")
+	v.details.SetText(text.String())
+}
+
+// writeSeries writes one labelled sparkline with the latest value
+func writeSeries(text *strings.Builder, label string, values []float64, unit, format string) {
+	fmt.Fprintf(text, "%s  ", label)
+	if len(values) == 0 {
+		text.WriteString("[gray]no readings yet[-]\n")
+		return
+	}
+	fmt.Fprintf(text, "[green]%s[-]  "+format+" %s\n", sparkline(values), values[len(values)-1], unit)
+}
+
+// sparkBlocks are the bars of a sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as a line of bars scaled between their minimum and
+// maximum
+func sparkline(values []float64) string {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		low = math.Min(low, value)
+		high = math.Max(high, value)
+	}
+
+	bars := make([]rune, len(values))
+	for i, value := range values {
+		level := 0
+		if high > low {
+			level = int((value - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		bars[i] = sparkBlocks[level]
+	}
+	return string(bars)
+}
+
+// statusColor colors a simulation status in the list
+func statusColor(status string) tcell.Color {
+	switch status {
+	case orchestration.StatusRunning.String():
+		return tcell.ColorGreen
+	case orchestration.StatusPaused.String():
+		return tcell.ColorYellow
+	case orchestration.StatusQueued.String():
+		return tcell.ColorTeal
+	case orchestration.StatusError.String():
+		return tcell.ColorRed
+	case orchestration.StatusCompleted.String():
+		return tcell.ColorBlue
+	default:
+		return tcell.ColorWhite
+	}
+}
+
+func (v *topView) setMessage(message string) {
+	v.message = message
+	v.renderFooter()
+}
+
+// renderFooter redraws the key help and the last command's outcome
+func (v *topView) renderFooter() {
+	help := "[yellow]s[-] start  [yellow]p[-] pause/resume  [yellow]x[-] stop  [yellow]k[-] kill  [yellow]r[-] refresh  [yellow]q[-] quit"
+	if v.message != "" {
+		help += "   " + v.message
+	}
+	v.footer.SetText(help)
+}
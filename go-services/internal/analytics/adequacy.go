@@ -0,0 +1,88 @@
+package analytics
+
+import (
+	"context"
+	"math"
+
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+)
+
+// AdequacyOutcome is how well a run's generation covered its load, taken
+// from its stored results
+type AdequacyOutcome struct {
+	Results          int64   `json:"results"`
+	PeakLoadMW       float64 `json:"peak_load_mw"`
+	PeakGenerationMW float64 `json:"peak_generation_mw"`
+	// Results where consumption exceeded generation, and the time and
+	// energy they account for
+	LossOfLoadResults       int64   `json:"loss_of_load_results"`
+	LossOfLoadHours         float64 `json:"loss_of_load_hours"`
+	UnservedEnergyMWh       float64 `json:"unserved_energy_mwh"`
+	MaxFrequencyDeviationHz float64 `json:"max_frequency_deviation_hz"`
+	FrequencyExcursions     int64   `json:"frequency_excursions"`
+}
+
+// Adequacy accumulates an adequacy outcome over results added in timestamp
+// order
+type Adequacy struct {
+	outcome     AdequacyOutcome
+	nominalHz   float64
+	toleranceHz float64
+	previous    *database.SimulationResult
+}
+
+// NewAdequacy starts the outcome of a run; frequency deviations beyond the
+// tolerance from nominal count as excursions
+func NewAdequacy(nominalHz, toleranceHz float64) *Adequacy {
+	return &Adequacy{nominalHz: nominalHz, toleranceHz: toleranceHz}
+}
+
+// Add records the next result. A shortfall lasts for the interval since the
+// previous result, so the first result counts towards no energy or hours.
+func (a *Adequacy) Add(result *database.SimulationResult) {
+	o := &a.outcome
+	o.Results++
+	o.PeakLoadMW = math.Max(o.PeakLoadMW, result.TotalConsumptionMW)
+	o.PeakGenerationMW = math.Max(o.PeakGenerationMW, result.TotalGenerationMW)
+
+	deviation := math.Abs(result.GridFrequencyHz - a.nominalHz)
+	o.MaxFrequencyDeviationHz = math.Max(o.MaxFrequencyDeviationHz, deviation)
+	if deviation > a.toleranceHz {
+		o.FrequencyExcursions++
+	}
+
+	if shortfall := result.TotalConsumptionMW - result.TotalGenerationMW; shortfall > 0 {
+		o.LossOfLoadResults++
+		if a.previous != nil {
+			if hours := result.Timestamp.Sub(a.previous.Timestamp).Hours(); hours > 0 {
+				o.LossOfLoadHours += hours
+				o.UnservedEnergyMWh += shortfall * hours
+			}
+		}
+	}
+
+	previous := *result
+	a.previous = &previous
+}
+
+// Outcome returns the outcome so far
+func (a *Adequacy) Outcome() *AdequacyOutcome {
+	outcome := a.outcome
+	return &outcome
+}
+
+// AssessAdequacy computes a run's adequacy outcome from its stored results.
+// The outcome has no results when none were recorded.
+func AssessAdequacy(ctx context.Context, simulations *database.SimulationService, simulationID uuid.UUID, nominalHz, toleranceHz float64) (*AdequacyOutcome, error) {
+	adequacy := NewAdequacy(nominalHz, toleranceHz)
+	err := simulations.StreamResults(ctx, simulationID, database.ResultWindow{Ascending: true}, func(result *database.SimulationResult) error {
+		adequacy.Add(result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adequacy.Outcome(), nil
+}
@@ -0,0 +1,231 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"voltedge/go-services/internal/database"
+)
+
+// MarginalCost returns the variable cost of producing one MWh at a plant
+func MarginalCost(plant database.PowerPlant) float64 {
+	return plant.FuelCostPerMWh + plant.OMCostPerMWh
+}
+
+// CostTick is the system cost at a single recorded tick
+type CostTick struct {
+	Timestamp      time.Time `json:"timestamp"`
+	TickNumber     int       `json:"tick_number"`
+	GenerationMW   float64   `json:"generation_mw"`
+	CostPerHour    float64   `json:"cost_per_hour"`
+	CumulativeCost float64   `json:"cumulative_cost"`
+}
+
+// PlantCost holds costs attributed to a single plant
+type PlantCost struct {
+	PlantID            int     `json:"plant_id"`
+	Name               string  `json:"name"`
+	FuelType           string  `json:"fuel_type"`
+	MarginalCostPerMWh float64 `json:"marginal_cost_per_mwh"`
+	EnergyMWh          float64 `json:"energy_mwh"`
+	FuelCost           float64 `json:"fuel_cost"`
+	OMCost             float64 `json:"om_cost"`
+	StartupCost        float64 `json:"startup_cost"`
+	TotalCost          float64 `json:"total_cost"`
+}
+
+// CostReport summarizes the operating cost of a simulation
+type CostReport struct {
+	TotalCost             float64     `json:"total_cost"`
+	FuelCost              float64     `json:"fuel_cost"`
+	OMCost                float64     `json:"om_cost"`
+	StartupCost           float64     `json:"startup_cost"`
+	TotalEnergyMWh        float64     `json:"total_energy_mwh"`
+	AverageCostPerMWh     float64     `json:"average_cost_per_mwh"`
+	CurrentCostPerHour    float64     `json:"current_cost_per_hour"`
+	Ticks                 []CostTick  `json:"ticks"`
+	ByPlant               []PlantCost `json:"by_plant"`
+	Samples               int         `json:"samples"`
+	From                  time.Time   `json:"from"`
+	To                    time.Time   `json:"to"`
+	AllocationMethodology string      `json:"allocation_methodology"`
+}
+
+// ComputeCosts integrates each plant's recorded output over time into fuel
+// and O&M costs. Every operational plant is charged its startup cost once at
+// the first reading, and readings of plants no longer in the simulation are
+// left out. Readings must be ordered by timestamp ascending; without any,
+// ErrNoPlantOutputs is returned.
+func ComputeCosts(outputs []database.ComponentMetric, plants []database.PowerPlant) (*CostReport, error) {
+	samples := plantSamples(outputs)
+	if len(samples) == 0 {
+		return nil, ErrNoPlantOutputs
+	}
+
+	report := &CostReport{
+		Ticks:                 make([]CostTick, len(samples)),
+		Samples:               len(samples),
+		From:                  samples[0].Timestamp,
+		To:                    samples[len(samples)-1].Timestamp,
+		AllocationMethodology: "plant_output",
+	}
+
+	// Startup costs are incurred when the simulation brings plants online
+	var startup float64
+	byPlant := make([]PlantCost, 0, len(plants))
+	index := make(map[int]int, len(plants))
+	for i, plant := range plants {
+		byPlant = append(byPlant, PlantCost{
+			PlantID:            plant.PlantID,
+			Name:               plant.Name,
+			FuelType:           strings.ToLower(plant.PlantType),
+			MarginalCostPerMWh: MarginalCost(plant),
+		})
+		if plant.IsOperational {
+			byPlant[i].StartupCost = plant.StartupCost
+			startup += plant.StartupCost
+		}
+		index[plant.PlantID] = i
+	}
+
+	// Plants keep their latest output until they report again
+	latest := make(map[int]float64, len(plants))
+	cumulative := startup
+	for t, sample := range samples {
+		for plantID, energy := range sample.EnergyMWh {
+			i, ok := index[plantID]
+			if !ok {
+				continue
+			}
+			byPlant[i].EnergyMWh += energy
+			byPlant[i].FuelCost += energy * plants[i].FuelCostPerMWh
+			byPlant[i].OMCost += energy * plants[i].OMCostPerMWh
+			cumulative += energy * byPlant[i].MarginalCostPerMWh
+		}
+		for plantID, output := range sample.OutputMW {
+			if _, ok := index[plantID]; ok {
+				latest[plantID] = output
+			}
+		}
+
+		tick := CostTick{
+			Timestamp:      sample.Timestamp,
+			TickNumber:     sample.TickNumber,
+			CumulativeCost: cumulative,
+		}
+		for plantID, output := range latest {
+			tick.GenerationMW += output
+			tick.CostPerHour += output * byPlant[index[plantID]].MarginalCostPerMWh
+		}
+		report.Ticks[t] = tick
+	}
+
+	for i := range byPlant {
+		plant := &byPlant[i]
+		plant.TotalCost = plant.FuelCost + plant.OMCost + plant.StartupCost
+
+		report.FuelCost += plant.FuelCost
+		report.OMCost += plant.OMCost
+		report.StartupCost += plant.StartupCost
+		report.TotalEnergyMWh += plant.EnergyMWh
+	}
+
+	report.TotalCost = report.FuelCost + report.OMCost + report.StartupCost
+	report.CurrentCostPerHour = report.Ticks[len(report.Ticks)-1].CostPerHour
+	if report.TotalEnergyMWh > 0 {
+		report.AverageCostPerMWh = report.TotalCost / report.TotalEnergyMWh
+	}
+	report.ByPlant = byPlant
+
+	return report, nil
+}
+
+// DispatchAllocation is the output assigned to a plant by economic dispatch
+type DispatchAllocation struct {
+	PlantID            int     `json:"plant_id"`
+	Name               string  `json:"name"`
+	FuelType           string  `json:"fuel_type"`
+	MarginalCostPerMWh float64 `json:"marginal_cost_per_mwh"`
+	MaxCapacityMW      float64 `json:"max_capacity_mw"`
+	CurrentOutputMW    float64 `json:"current_output_mw"`
+	SuggestedOutputMW  float64 `json:"suggested_output_mw"`
+	CostPerHour        float64 `json:"cost_per_hour"`
+}
+
+// DispatchSuggestion is a cost-optimal dispatch for a given demand
+type DispatchSuggestion struct {
+	DemandMW               float64              `json:"demand_mw"`
+	DispatchedMW           float64              `json:"dispatched_mw"`
+	UnservedMW             float64              `json:"unserved_mw"`
+	SystemMarginalCost     float64              `json:"system_marginal_cost_per_mwh"`
+	CostPerHour            float64              `json:"cost_per_hour"`
+	CurrentCostPerHour     float64              `json:"current_cost_per_hour"`
+	SavingsPerHour         float64              `json:"savings_per_hour"`
+	Allocations            []DispatchAllocation `json:"allocations"`
+	DispatchMethodology    string               `json:"dispatch_methodology"`
+	CurrentCostMethodology string               `json:"current_cost_methodology"`
+}
+
+// SuggestDispatch fills demand from the cheapest operational plants first (merit
+// order) and compares the result with the current dispatch, taken from each
+// plant's latest recorded output by plant ID. Without any recorded output
+// ErrNoPlantOutputs is returned. Startup costs and ramp limits are not
+// considered.
+func SuggestDispatch(plants []database.PowerPlant, currentMW map[int]float64, demandMW float64) (*DispatchSuggestion, error) {
+	if len(currentMW) == 0 {
+		return nil, ErrNoPlantOutputs
+	}
+
+	suggestion := &DispatchSuggestion{
+		DemandMW:               demandMW,
+		Allocations:            []DispatchAllocation{},
+		DispatchMethodology:    "merit_order",
+		CurrentCostMethodology: "plant_output",
+	}
+
+	for _, plant := range plants {
+		suggestion.CurrentCostPerHour += currentMW[plant.PlantID] * MarginalCost(plant)
+		if !plant.IsOperational {
+			continue
+		}
+		suggestion.Allocations = append(suggestion.Allocations, DispatchAllocation{
+			PlantID:            plant.PlantID,
+			Name:               plant.Name,
+			FuelType:           strings.ToLower(plant.PlantType),
+			MarginalCostPerMWh: MarginalCost(plant),
+			MaxCapacityMW:      plant.MaxCapacityMW,
+			CurrentOutputMW:    currentMW[plant.PlantID],
+		})
+	}
+
+	sort.SliceStable(suggestion.Allocations, func(i, j int) bool {
+		return suggestion.Allocations[i].MarginalCostPerMWh < suggestion.Allocations[j].MarginalCostPerMWh
+	})
+
+	remaining := demandMW
+	for i := range suggestion.Allocations {
+		if remaining <= 0 {
+			break
+		}
+		allocation := &suggestion.Allocations[i]
+		output := allocation.MaxCapacityMW
+		if output > remaining {
+			output = remaining
+		}
+		allocation.SuggestedOutputMW = output
+		allocation.CostPerHour = output * allocation.MarginalCostPerMWh
+		remaining -= output
+
+		suggestion.DispatchedMW += output
+		suggestion.CostPerHour += allocation.CostPerHour
+		suggestion.SystemMarginalCost = allocation.MarginalCostPerMWh
+	}
+
+	if remaining > 0 {
+		suggestion.UnservedMW = remaining
+	}
+	suggestion.SavingsPerHour = suggestion.CurrentCostPerHour - suggestion.CostPerHour
+
+	return suggestion, nil
+}
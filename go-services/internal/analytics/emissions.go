@@ -0,0 +1,192 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+)
+
+// defaultEmissionFactors are lifecycle CO2 intensities in kg/MWh by fuel type
+var defaultEmissionFactors = map[string]float64{
+	"coal":            820,
+	"oil":             650,
+	"gas":             490,
+	"biomass":         230,
+	"solar":           45,
+	"geothermal":      38,
+	"hydro":           24,
+	"nuclear":         12,
+	"wind":            11,
+	"battery_storage": 0,
+}
+
+// DefaultEmissionFactor returns the default CO2 intensity for a fuel type in kg/MWh
+func DefaultEmissionFactor(fuelType string) float64 {
+	return defaultEmissionFactors[strings.ToLower(fuelType)]
+}
+
+// EmissionFactor returns the configured factor of a plant, falling back to the fuel default
+func EmissionFactor(plant database.PowerPlant) float64 {
+	if plant.EmissionFactorKgPerMWh > 0 {
+		return plant.EmissionFactorKgPerMWh
+	}
+	return DefaultEmissionFactor(plant.PlantType)
+}
+
+// PlantEmissions holds emissions attributed to a single plant
+type PlantEmissions struct {
+	PlantID          int     `json:"plant_id"`
+	Name             string  `json:"name"`
+	FuelType         string  `json:"fuel_type"`
+	FactorKgPerMWh   float64 `json:"factor_kg_per_mwh"`
+	EnergyMWh        float64 `json:"energy_mwh"`
+	EmissionsKg      float64 `json:"emissions_kg"`
+	CurrentKgPerHour float64 `json:"current_kg_per_hour"`
+}
+
+// FuelEmissions aggregates emissions by fuel type
+type FuelEmissions struct {
+	FuelType         string  `json:"fuel_type"`
+	EnergyMWh        float64 `json:"energy_mwh"`
+	EmissionsKg      float64 `json:"emissions_kg"`
+	IntensityKgMWh   float64 `json:"intensity_kg_per_mwh"`
+	CurrentKgPerHour float64 `json:"current_kg_per_hour"`
+}
+
+// EmissionsReport summarizes a simulation's carbon emissions
+type EmissionsReport struct {
+	TotalEnergyMWh        float64          `json:"total_energy_mwh"`
+	TotalEmissionsKg      float64          `json:"total_emissions_kg"`
+	IntensityKgPerMWh     float64          `json:"intensity_kg_per_mwh"`
+	CurrentKgPerHour      float64          `json:"current_kg_per_hour"`
+	ByFuel                []FuelEmissions  `json:"by_fuel"`
+	ByPlant               []PlantEmissions `json:"by_plant"`
+	Samples               int              `json:"samples"`
+	From                  time.Time        `json:"from"`
+	To                    time.Time        `json:"to"`
+	AllocationMethodology string           `json:"allocation_methodology"`
+}
+
+// ComputeEmissions integrates each plant's recorded output over time and
+// applies its emission factor. Readings of plants no longer in the
+// simulation are left out. Readings must be ordered by timestamp ascending;
+// without any, ErrNoPlantOutputs is returned.
+func ComputeEmissions(outputs []database.ComponentMetric, plants []database.PowerPlant) (*EmissionsReport, error) {
+	samples := plantSamples(outputs)
+	if len(samples) == 0 {
+		return nil, ErrNoPlantOutputs
+	}
+
+	report := &EmissionsReport{
+		ByFuel:                []FuelEmissions{},
+		Samples:               len(samples),
+		From:                  samples[0].Timestamp,
+		To:                    samples[len(samples)-1].Timestamp,
+		AllocationMethodology: "plant_output",
+	}
+
+	byPlant := make([]PlantEmissions, 0, len(plants))
+	index := make(map[int]int, len(plants))
+	for i, plant := range plants {
+		byPlant = append(byPlant, PlantEmissions{
+			PlantID:        plant.PlantID,
+			Name:           plant.Name,
+			FuelType:       strings.ToLower(plant.PlantType),
+			FactorKgPerMWh: EmissionFactor(plant),
+		})
+		index[plant.PlantID] = i
+	}
+
+	for _, sample := range samples {
+		for plantID, energy := range sample.EnergyMWh {
+			if i, ok := index[plantID]; ok {
+				byPlant[i].EnergyMWh += energy
+				byPlant[i].EmissionsKg += energy * byPlant[i].FactorKgPerMWh
+			}
+		}
+		// The current rate is from each plant's latest reading
+		for plantID, output := range sample.OutputMW {
+			if i, ok := index[plantID]; ok {
+				byPlant[i].CurrentKgPerHour = output * byPlant[i].FactorKgPerMWh
+			}
+		}
+	}
+
+	fuels := make(map[string]*FuelEmissions)
+	for i := range byPlant {
+		plant := &byPlant[i]
+
+		fuel, ok := fuels[plant.FuelType]
+		if !ok {
+			fuel = &FuelEmissions{FuelType: plant.FuelType}
+			fuels[plant.FuelType] = fuel
+		}
+		fuel.EnergyMWh += plant.EnergyMWh
+		fuel.EmissionsKg += plant.EmissionsKg
+		fuel.CurrentKgPerHour += plant.CurrentKgPerHour
+
+		report.TotalEnergyMWh += plant.EnergyMWh
+		report.TotalEmissionsKg += plant.EmissionsKg
+		report.CurrentKgPerHour += plant.CurrentKgPerHour
+	}
+
+	for _, fuel := range fuels {
+		if fuel.EnergyMWh > 0 {
+			fuel.IntensityKgMWh = fuel.EmissionsKg / fuel.EnergyMWh
+		}
+		report.ByFuel = append(report.ByFuel, *fuel)
+	}
+	sort.Slice(report.ByFuel, func(i, j int) bool {
+		return report.ByFuel[i].EmissionsKg > report.ByFuel[j].EmissionsKg
+	})
+
+	if report.TotalEnergyMWh > 0 {
+		report.IntensityKgPerMWh = report.TotalEmissionsKg / report.TotalEnergyMWh
+	}
+	report.ByPlant = byPlant
+
+	return report, nil
+}
+
+// RecordEmissions computes a run's emissions over all of its recorded plant
+// output and stores the per-fuel totals with the run
+func RecordEmissions(ctx context.Context, simulations *database.SimulationService, simulationID uuid.UUID) (*EmissionsReport, error) {
+	outputs, err := simulations.GetPlantOutputs(ctx, simulationID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	plants, err := simulations.GetPowerPlants(ctx, simulationID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := ComputeEmissions(outputs, plants)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]database.EmissionSummary, len(report.ByFuel))
+	now := time.Now().UTC()
+	for i, fuel := range report.ByFuel {
+		summaries[i] = database.EmissionSummary{
+			SimulationID:      simulationID,
+			FuelType:          fuel.FuelType,
+			EnergyMWh:         fuel.EnergyMWh,
+			EmissionsKg:       fuel.EmissionsKg,
+			IntensityKgPerMWh: fuel.IntensityKgMWh,
+			CurrentKgPerHour:  fuel.CurrentKgPerHour,
+			PeriodStart:       report.From,
+			PeriodEnd:         report.To,
+			ComputedAt:        now,
+		}
+	}
+	if err := simulations.SaveEmissionSummaries(ctx, simulationID, summaries); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
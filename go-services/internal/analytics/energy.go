@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"voltedge/go-services/internal/database"
+)
+
+// renewableFuels are the plant types counted towards the renewables share
+var renewableFuels = map[string]bool{
+	"solar":      true,
+	"wind":       true,
+	"hydro":      true,
+	"geothermal": true,
+	"biomass":    true,
+}
+
+// IsRenewable reports whether a plant type is a renewable source
+func IsRenewable(fuelType string) bool {
+	return renewableFuels[strings.ToLower(fuelType)]
+}
+
+// EnergyBalance is the energy generated and consumed over a period
+type EnergyBalance struct {
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	GeneratedMWh float64   `json:"generated_mwh"`
+	ConsumedMWh  float64   `json:"consumed_mwh"`
+	// Generation in excess of consumption, lost in transmission
+	LossesMWh     float64 `json:"losses_mwh"`
+	LossesPercent float64 `json:"losses_percent"`
+	// Consumption generation fell short of while a fault was active
+	UnservedDuringFaultsMWh float64 `json:"unserved_during_faults_mwh"`
+	RenewableMWh            float64 `json:"renewable_mwh"`
+	RenewableSharePercent   float64 `json:"renewable_share_percent"`
+}
+
+// EnergyReport summarizes a simulation's energy balance, in total and per
+// window when a window length is given
+type EnergyReport struct {
+	EnergyBalance
+	Samples               int             `json:"samples"`
+	WindowSeconds         float64         `json:"window_seconds,omitempty"`
+	Windows               []EnergyBalance `json:"windows"`
+	AllocationMethodology string          `json:"allocation_methodology"`
+}
+
+// ComputeEnergyBalance integrates generation and consumption over time. Losses
+// are the generation in excess of consumption, and unserved energy the
+// consumption in excess of generation during intervals in which any fault
+// was active. Generation is attributed to plants by capacity to derive the
+// renewables share. With a positive step the totals
+// are also broken down into consecutive windows of that length starting at
+// the first result. Results must be ordered by timestamp ascending.
+func ComputeEnergyBalance(results []database.SimulationResult, plants []database.PowerPlant, faults []database.FaultEvent, step time.Duration) *EnergyReport {
+	report := &EnergyReport{
+		Samples:               len(results),
+		Windows:               []EnergyBalance{},
+		AllocationMethodology: "capacity_weighted",
+	}
+	if step > 0 {
+		report.WindowSeconds = step.Seconds()
+	}
+	if len(results) == 0 {
+		return report
+	}
+
+	var renewableShare float64
+	shares := capacityShares(plants)
+	for i, plant := range plants {
+		if IsRenewable(plant.PlantType) {
+			renewableShare += shares[i]
+		}
+	}
+
+	start := results[0].Timestamp
+	report.From = start
+	report.To = results[len(results)-1].Timestamp
+
+	outages := make([]outage, 0, len(faults))
+	for _, fault := range faults {
+		end := report.To
+		if fault.ResolvedAt != nil {
+			end = *fault.ResolvedAt
+		}
+		outages = append(outages, outage{fault.Timestamp, end})
+	}
+	sort.Slice(outages, func(i, j int) bool {
+		return outages[i].start.Before(outages[j].start)
+	})
+
+	windows := make(map[int]*EnergyBalance)
+	for i := 1; i < len(results); i++ {
+		previous, current := results[i-1], results[i]
+		hours := current.Timestamp.Sub(previous.Timestamp).Hours()
+		if hours <= 0 {
+			continue
+		}
+
+		interval := EnergyBalance{
+			GeneratedMWh: (previous.TotalGenerationMW + current.TotalGenerationMW) / 2 * hours,
+			ConsumedMWh:  (previous.TotalConsumptionMW + current.TotalConsumptionMW) / 2 * hours,
+			LossesMWh:    (surplus(previous) + surplus(current)) / 2 * hours,
+		}
+		interval.RenewableMWh = interval.GeneratedMWh * renewableShare
+		if faultActive(outages, previous.Timestamp, current.Timestamp) {
+			interval.UnservedDuringFaultsMWh = (shortfall(previous) + shortfall(current)) / 2 * hours
+		}
+		report.add(interval)
+
+		if step > 0 {
+			index := int(previous.Timestamp.Sub(start) / step)
+			window, ok := windows[index]
+			if !ok {
+				window = &EnergyBalance{
+					From: start.Add(time.Duration(index) * step),
+					To:   start.Add(time.Duration(index+1) * step),
+				}
+				windows[index] = window
+			}
+			window.add(interval)
+		}
+	}
+
+	report.finish()
+	indexes := make([]int, 0, len(windows))
+	for index := range windows {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		window := windows[index]
+		window.finish()
+		report.Windows = append(report.Windows, *window)
+	}
+
+	return report
+}
+
+func (b *EnergyBalance) add(interval EnergyBalance) {
+	b.GeneratedMWh += interval.GeneratedMWh
+	b.ConsumedMWh += interval.ConsumedMWh
+	b.LossesMWh += interval.LossesMWh
+	b.UnservedDuringFaultsMWh += interval.UnservedDuringFaultsMWh
+	b.RenewableMWh += interval.RenewableMWh
+}
+
+// finish derives the percentages from the totals
+func (b *EnergyBalance) finish() {
+	if b.GeneratedMWh > 0 {
+		b.LossesPercent = b.LossesMWh / b.GeneratedMWh * 100
+		b.RenewableSharePercent = b.RenewableMWh / b.GeneratedMWh * 100
+	}
+}
+
+func surplus(result database.SimulationResult) float64 {
+	return math.Max(0, result.TotalGenerationMW-result.TotalConsumptionMW)
+}
+
+func shortfall(result database.SimulationResult) float64 {
+	return math.Max(0, result.TotalConsumptionMW-result.TotalGenerationMW)
+}
+
+// faultActive reports whether any outage overlaps from..to. Outages must be
+// ordered by start.
+func faultActive(outages []outage, from, to time.Time) bool {
+	for _, o := range outages {
+		if !o.start.Before(to) {
+			return false
+		}
+		if o.end.After(from) {
+			return true
+		}
+	}
+	return false
+}
+
+// capacityShares returns each plant's share of operational capacity. Results only
+// record total generation, so it is attributed to plants in these proportions.
+func capacityShares(plants []database.PowerPlant) []float64 {
+	var totalCapacity float64
+	for _, plant := range plants {
+		if plant.IsOperational {
+			totalCapacity += plant.MaxCapacityMW
+		}
+	}
+
+	shares := make([]float64, len(plants))
+	for i, plant := range plants {
+		if plant.IsOperational && totalCapacity > 0 {
+			shares[i] = plant.MaxCapacityMW / totalCapacity
+		}
+	}
+	return shares
+}
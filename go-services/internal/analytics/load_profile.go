@@ -0,0 +1,153 @@
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Resampling methods for recorded series
+const (
+	ResampleMean = "mean"
+	ResampleMax  = "max"
+	ResampleLast = "last"
+)
+
+// Bounds on building a recorded load: the results read for it and the
+// points of the series, which ends up in the new simulation's config
+const (
+	MaxRecordedLoadSamples = 200000
+	MaxRecordedLoadPoints  = 50000
+)
+
+// ErrRecordedLoadTooLarge is returned when a recorded load would exceed
+// MaxRecordedLoadSamples or MaxRecordedLoadPoints
+var ErrRecordedLoadTooLarge = errors.New("recorded load is too large")
+
+// ResampleOptions controls how a recorded series is bucketed
+type ResampleOptions struct {
+	Interval time.Duration
+	Method   string
+	Scale    float64
+}
+
+// RecordedLoad is a load series rebuilt from stored simulation results
+type RecordedLoad struct {
+	SeriesMW        []float64 `json:"series_mw"`
+	IntervalSeconds float64   `json:"interval_seconds"`
+	BaseLoadMW      float64   `json:"base_load_mw"`
+	PeakLoadMW      float64   `json:"peak_load_mw"`
+	PeakMultiplier  float64   `json:"peak_multiplier"`
+	SourceSamples   int       `json:"source_samples"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+}
+
+// BuildRecordedLoad resamples recorded consumption into fixed-width buckets.
+// Results must be ordered by timestamp ascending. It fails with
+// ErrRecordedLoadTooLarge past the size bounds.
+func BuildRecordedLoad(results []database.SimulationResult, opts ResampleOptions) (*RecordedLoad, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no recorded results to build a load profile from")
+	}
+	if len(results) > MaxRecordedLoadSamples {
+		return nil, fmt.Errorf("%w: %d results, at most %d can be resampled; narrow the window", ErrRecordedLoadTooLarge, len(results), MaxRecordedLoadSamples)
+	}
+
+	if opts.Method == "" {
+		opts.Method = ResampleMean
+	}
+	if opts.Method != ResampleMean && opts.Method != ResampleMax && opts.Method != ResampleLast {
+		return nil, fmt.Errorf("unsupported resample method: %s", opts.Method)
+	}
+	if opts.Scale == 0 {
+		opts.Scale = 1
+	}
+
+	from := results[0].Timestamp
+	to := results[len(results)-1].Timestamp
+
+	// Without an interval every recorded sample becomes a point
+	if opts.Interval <= 0 {
+		if len(results) > MaxRecordedLoadPoints {
+			return nil, fmt.Errorf("%w: %d points, at most %d are allowed; set a resample interval", ErrRecordedLoadTooLarge, len(results), MaxRecordedLoadPoints)
+		}
+		series := make([]float64, len(results))
+		for i, result := range results {
+			series[i] = result.TotalConsumptionMW * opts.Scale
+		}
+		interval := 0.0
+		if len(results) > 1 {
+			interval = to.Sub(from).Seconds() / float64(len(results)-1)
+		}
+		return summarize(series, interval, len(results), from, to), nil
+	}
+
+	span := to.Sub(from) / opts.Interval
+	if span >= MaxRecordedLoadPoints {
+		return nil, fmt.Errorf("%w: %d points, at most %d are allowed; use a longer resample interval", ErrRecordedLoadTooLarge, int64(span)+1, MaxRecordedLoadPoints)
+	}
+	buckets := int(span) + 1
+	sums := make([]float64, buckets)
+	counts := make([]int, buckets)
+	series := make([]float64, buckets)
+
+	for _, result := range results {
+		idx := int(result.Timestamp.Sub(from) / opts.Interval)
+		value := result.TotalConsumptionMW * opts.Scale
+
+		switch opts.Method {
+		case ResampleMean:
+			sums[idx] += value
+		case ResampleMax:
+			if counts[idx] == 0 || value > series[idx] {
+				series[idx] = value
+			}
+		case ResampleLast:
+			series[idx] = value
+		}
+		counts[idx]++
+	}
+
+	// Fill empty buckets with the previous value so the series has no gaps
+	for i := range series {
+		if opts.Method == ResampleMean && counts[i] > 0 {
+			series[i] = sums[i] / float64(counts[i])
+		}
+		if counts[i] == 0 && i > 0 {
+			series[i] = series[i-1]
+		}
+	}
+
+	return summarize(series, opts.Interval.Seconds(), len(results), from, to), nil
+}
+
+// summarize computes base and peak figures for a series
+func summarize(series []float64, interval float64, samples int, from, to time.Time) *RecordedLoad {
+	var sum, peak float64
+	for _, value := range series {
+		sum += value
+		peak = math.Max(peak, value)
+	}
+
+	load := &RecordedLoad{
+		SeriesMW:        series,
+		IntervalSeconds: interval,
+		PeakLoadMW:      peak,
+		SourceSamples:   samples,
+		From:            from,
+		To:              to,
+	}
+
+	if len(series) > 0 {
+		load.BaseLoadMW = sum / float64(len(series))
+	}
+	if load.BaseLoadMW > 0 {
+		load.PeakMultiplier = peak / load.BaseLoadMW
+	}
+
+	return load
+}
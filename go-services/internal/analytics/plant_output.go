@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"errors"
+	"time"
+
+	"voltedge/go-services/internal/database"
+)
+
+// ErrNoPlantOutputs is returned when a simulation has recorded no output for
+// any of its power plants, so nothing can be attributed to them
+var ErrNoPlantOutputs = errors.New("no power plant output has been recorded")
+
+// plantSample is the output of the plants reported at one timestamp
+type plantSample struct {
+	Timestamp  time.Time
+	TickNumber int
+	// Output by plant ID
+	OutputMW map[int]float64
+	// Energy each plant reported here produced since its previous reading
+	EnergyMWh map[int]float64
+}
+
+// plantSamples groups per-plant output readings into samples by timestamp
+// and integrates each plant's output trapezoidally between its consecutive
+// readings. Readings must be ordered by timestamp ascending.
+func plantSamples(readings []database.ComponentMetric) []plantSample {
+	var samples []plantSample
+	previous := make(map[int]database.ComponentMetric)
+
+	for _, reading := range readings {
+		if len(samples) == 0 || !samples[len(samples)-1].Timestamp.Equal(reading.Timestamp) {
+			samples = append(samples, plantSample{
+				Timestamp: reading.Timestamp,
+				OutputMW:  make(map[int]float64),
+				EnergyMWh: make(map[int]float64),
+			})
+		}
+		sample := &samples[len(samples)-1]
+		if reading.TickNumber != nil {
+			sample.TickNumber = *reading.TickNumber
+		}
+		sample.OutputMW[reading.ComponentID] = reading.MetricValue
+
+		if last, ok := previous[reading.ComponentID]; ok {
+			if hours := reading.Timestamp.Sub(last.Timestamp).Hours(); hours > 0 {
+				sample.EnergyMWh[reading.ComponentID] = (last.MetricValue + reading.MetricValue) / 2 * hours
+			}
+		}
+		previous[reading.ComponentID] = reading
+	}
+
+	return samples
+}
@@ -0,0 +1,178 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"voltedge/go-services/internal/database"
+)
+
+// ComponentReliability holds the failure and repair statistics of a single
+// component over a reporting period
+type ComponentReliability struct {
+	ComponentType string `json:"component_type"`
+	ComponentID   int    `json:"component_id"`
+	Failures      int    `json:"failures"`
+	// Failures still unresolved at the end of the period
+	Unresolved    int     `json:"unresolved"`
+	DowntimeHours float64 `json:"downtime_hours"`
+	UptimeHours   float64 `json:"uptime_hours"`
+	// Mean time between failures: uptime per failure, nil without failures
+	MTBFHours *float64 `json:"mtbf_hours"`
+	// Mean time to repair over resolved failures, nil if none were resolved
+	MTTRHours           *float64 `json:"mttr_hours"`
+	AvailabilityPercent float64  `json:"availability_percent"`
+}
+
+// ReliabilityReport summarizes the availability of a simulation's faulted
+// components. Components without faults in the period are fully available and
+// not listed.
+type ReliabilityReport struct {
+	From        time.Time              `json:"from"`
+	To          time.Time              `json:"to"`
+	PeriodHours float64                `json:"period_hours"`
+	Failures    int                    `json:"failures"`
+	Components  []ComponentReliability `json:"components"`
+	// Fleet figures over the listed components
+	MTBFHours                  *float64 `json:"mtbf_hours"`
+	MTTRHours                  *float64 `json:"mttr_hours"`
+	AverageAvailabilityPercent float64  `json:"average_availability_percent"`
+	Methodology                string   `json:"methodology"`
+}
+
+type componentKey struct {
+	componentType string
+	componentID   int
+}
+
+// outage is a span during which a component was down
+type outage struct {
+	start, end time.Time
+}
+
+// ComputeReliability derives per-component MTBF, MTTR and availability from
+// fault events over the period from..to. A fault takes its component down
+// from its timestamp until it is resolved, or until the end of the period if
+// it is not; overlapping faults on one component count as one outage for
+// downtime but as separate failures. Faults are clipped to the period.
+func ComputeReliability(faults []database.FaultEvent, from, to time.Time) *ReliabilityReport {
+	report := &ReliabilityReport{
+		From:        from,
+		To:          to,
+		Components:  []ComponentReliability{},
+		Methodology: "uptime_per_failure",
+	}
+	if !to.After(from) {
+		return report
+	}
+	report.PeriodHours = to.Sub(from).Hours()
+
+	outages := make(map[componentKey][]outage)
+	stats := make(map[componentKey]*ComponentReliability)
+	repairHours := make(map[componentKey][]float64)
+	for _, fault := range faults {
+		key := componentKey{fault.ComponentType, fault.ComponentID}
+		component, ok := stats[key]
+		if !ok {
+			component = &ComponentReliability{ComponentType: fault.ComponentType, ComponentID: fault.ComponentID}
+			stats[key] = component
+		}
+
+		end := to
+		if fault.ResolvedAt != nil && !fault.ResolvedAt.After(to) {
+			end = *fault.ResolvedAt
+			repairHours[key] = append(repairHours[key], fault.ResolvedAt.Sub(fault.Timestamp).Hours())
+		} else {
+			component.Unresolved++
+		}
+		// Failures raised before the period only contribute their downtime
+		if !fault.Timestamp.Before(from) {
+			component.Failures++
+		}
+
+		start := fault.Timestamp
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(start) {
+			outages[key] = append(outages[key], outage{start, end})
+		}
+	}
+
+	var totalUptime, totalRepair float64
+	var repairs int
+	for key, component := range stats {
+		component.DowntimeHours = mergedHours(outages[key])
+		component.UptimeHours = report.PeriodHours - component.DowntimeHours
+		component.AvailabilityPercent = component.UptimeHours / report.PeriodHours * 100
+		if component.Failures > 0 {
+			mtbf := component.UptimeHours / float64(component.Failures)
+			component.MTBFHours = &mtbf
+		}
+		if len(repairHours[key]) > 0 {
+			var sum float64
+			for _, hours := range repairHours[key] {
+				sum += hours
+			}
+			mttr := sum / float64(len(repairHours[key]))
+			component.MTTRHours = &mttr
+			totalRepair += sum
+			repairs += len(repairHours[key])
+		}
+
+		report.Failures += component.Failures
+		report.AverageAvailabilityPercent += component.AvailabilityPercent
+		totalUptime += component.UptimeHours
+		report.Components = append(report.Components, *component)
+	}
+
+	sort.Slice(report.Components, func(i, j int) bool {
+		a, b := report.Components[i], report.Components[j]
+		if a.AvailabilityPercent != b.AvailabilityPercent {
+			return a.AvailabilityPercent < b.AvailabilityPercent
+		}
+		if a.ComponentType != b.ComponentType {
+			return a.ComponentType < b.ComponentType
+		}
+		return a.ComponentID < b.ComponentID
+	})
+
+	if len(report.Components) > 0 {
+		report.AverageAvailabilityPercent /= float64(len(report.Components))
+	}
+	if report.Failures > 0 {
+		mtbf := totalUptime / float64(report.Failures)
+		report.MTBFHours = &mtbf
+	}
+	if repairs > 0 {
+		mttr := totalRepair / float64(repairs)
+		report.MTTRHours = &mttr
+	}
+
+	return report
+}
+
+// mergedHours returns the hours covered by outages, counting overlaps once
+func mergedHours(outages []outage) float64 {
+	if len(outages) == 0 {
+		return 0
+	}
+	sort.Slice(outages, func(i, j int) bool {
+		return outages[i].start.Before(outages[j].start)
+	})
+
+	var total time.Duration
+	current := outages[0]
+	for _, next := range outages[1:] {
+		if next.start.After(current.end) {
+			total += current.end.Sub(current.start)
+			current = next
+			continue
+		}
+		if next.end.After(current.end) {
+			current.end = next.end
+		}
+	}
+	total += current.end.Sub(current.start)
+	return total.Hours()
+}
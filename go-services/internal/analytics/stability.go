@@ -0,0 +1,148 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	// DefaultNominalFrequencyHz is used when a simulation's config does not
+	// set nominal_frequency_hz
+	DefaultNominalFrequencyHz = 50.0
+	// DefaultStabilityBandHz is the deviation from nominal counted as outside
+	// normal operation
+	DefaultStabilityBandHz = 0.2
+	// Deviation and RoCoF at which their parts of the score reach zero. A
+	// 1 Hz/s RoCoF is a common loss-of-mains protection setting.
+	scoreDeviationLimitHz = 1.0
+	scoreRoCoFLimitHzPerS = 1.0
+)
+
+// FrequencyStability accumulates frequency stability KPIs over results added
+// in timestamp order
+type FrequencyStability struct {
+	score     database.StabilityScore
+	previous  *database.SimulationResult
+	rocofSum  float64
+	intervals int
+}
+
+// NewFrequencyStability starts the KPIs of a run around a nominal frequency
+// and band
+func NewFrequencyStability(simulationID uuid.UUID, nominalHz, bandHz float64) *FrequencyStability {
+	return &FrequencyStability{
+		score: database.StabilityScore{
+			SimulationID:       simulationID,
+			NominalFrequencyHz: nominalHz,
+			BandHz:             bandHz,
+		},
+	}
+}
+
+// Add records the next result. The interval since the previous result counts
+// as outside the band for the share of it the frequency, interpolated
+// linearly, spent outside.
+func (f *FrequencyStability) Add(result *database.SimulationResult) {
+	s := &f.score
+	frequency := result.GridFrequencyHz
+	s.Samples++
+
+	if s.NadirHz == nil || frequency < *s.NadirHz {
+		nadir, at := frequency, result.Timestamp
+		s.NadirHz, s.NadirAt = &nadir, &at
+	}
+	if s.ZenithHz == nil || frequency > *s.ZenithHz {
+		zenith := frequency
+		s.ZenithHz = &zenith
+	}
+	s.MaxDeviationHz = math.Max(s.MaxDeviationHz, math.Abs(frequency-s.NominalFrequencyHz))
+
+	if f.previous != nil {
+		seconds := result.Timestamp.Sub(f.previous.Timestamp).Seconds()
+		if seconds > 0 {
+			s.DurationSeconds += seconds
+			s.SecondsOutsideBand += seconds * f.outsideShare(f.previous.GridFrequencyHz, frequency)
+
+			rocof := math.Abs(frequency-f.previous.GridFrequencyHz) / seconds
+			s.MaxRoCoFHzPerS = math.Max(s.MaxRoCoFHzPerS, rocof)
+			f.rocofSum += rocof
+			f.intervals++
+		}
+	}
+	previous := *result
+	f.previous = &previous
+}
+
+// outsideShare returns the share of a linear move from a to b spent outside
+// the band
+func (f *FrequencyStability) outsideShare(a, b float64) float64 {
+	low := f.score.NominalFrequencyHz - f.score.BandHz
+	high := f.score.NominalFrequencyHz + f.score.BandHz
+	if a == b {
+		if a < low || a > high {
+			return 1
+		}
+		return 0
+	}
+
+	// Share of [a, b] inside [low, high]
+	lo, hi := math.Min(a, b), math.Max(a, b)
+	inside := math.Max(0, math.Min(hi, high)-math.Max(lo, low))
+	return 1 - inside/(hi-lo)
+}
+
+// Score returns the KPIs so far. The score averages three parts, each from 1
+// down to 0: the share of time inside the band, the largest deviation up to
+// 1 Hz and the largest RoCoF up to 1 Hz/s.
+func (f *FrequencyStability) Score() *database.StabilityScore {
+	s := f.score
+	s.ComputedAt = time.Now().UTC()
+	if f.intervals > 0 {
+		s.MeanRoCoFHzPerS = f.rocofSum / float64(f.intervals)
+	}
+	if s.DurationSeconds > 0 {
+		s.PercentOutsideBand = s.SecondsOutsideBand / s.DurationSeconds * 100
+	}
+	if s.Samples == 0 {
+		return &s
+	}
+
+	inBand := 1 - s.PercentOutsideBand/100
+	deviation := math.Max(0, 1-s.MaxDeviationHz/scoreDeviationLimitHz)
+	rocof := math.Max(0, 1-s.MaxRoCoFHzPerS/scoreRoCoFLimitHzPerS)
+	s.Score = (inBand + deviation + rocof) / 3 * 100
+	return &s
+}
+
+// NominalFrequency returns a simulation's nominal_frequency_hz config
+// setting, or DefaultNominalFrequencyHz
+func NominalFrequency(simulation *database.Simulation) float64 {
+	if value, ok := simulation.Config["nominal_frequency_hz"].(float64); ok && value > 0 {
+		return value
+	}
+	return DefaultNominalFrequencyHz
+}
+
+// ScoreStability computes a run's frequency stability from its stored
+// results and saves it with the run
+func ScoreStability(ctx context.Context, simulations *database.SimulationService, simulationID uuid.UUID, nominalHz, bandHz float64) (*database.StabilityScore, error) {
+	kpis := NewFrequencyStability(simulationID, nominalHz, bandHz)
+	err := simulations.StreamResults(ctx, simulationID, database.ResultWindow{Ascending: true}, func(result *database.SimulationResult) error {
+		kpis.Add(result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	score := kpis.Score()
+	if err := simulations.SaveStabilityScore(ctx, score); err != nil {
+		return nil, err
+	}
+	return score, nil
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/reconcile"
+)
+
+// MetadataMigrationRequest represents a request to move oversized metadata into artifacts
+type MetadataMigrationRequest struct {
+	Tables   []string `json:"tables"`
+	MaxBytes int      `json:"max_bytes"`
+	DryRun   bool     `json:"dry_run"`
+}
+
+// ReconciliationResponse reports the last reconciliation pass and recent corrections
+type ReconciliationResponse struct {
+	LastRun *reconcile.Report  `json:"last_run"`
+	Actions []reconcile.Action `json:"actions"`
+}
+
+// getLargestMetadata reports the largest metadata payloads across tables
+func (s *Server) getLargestMetadata(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 500 {
+		limit = 20
+	}
+
+	tables := database.MetadataTables()
+	if table := c.Query("table"); table != "" {
+		tables = []string{table}
+	}
+
+	largest := []database.MetadataSize{}
+	for _, table := range tables {
+		sizes, err := s.metadataService.LargestMetadata(table, limit)
+		if err != nil {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+		largest = append(largest, sizes...)
+	}
+
+	sort.Slice(largest, func(i, j int) bool {
+		return largest[i].SizeBytes > largest[j].SizeBytes
+	})
+	if len(largest) > limit {
+		largest = largest[:limit]
+	}
+
+	s.handleSuccess(c, gin.H{
+		"limits":   s.config.Metadata,
+		"payloads": largest,
+	}, "Largest metadata payloads retrieved successfully")
+}
+
+// migrateOversizedMetadata moves metadata above the size limit into artifacts
+func (s *Server) migrateOversizedMetadata(c *gin.Context) {
+	var req MetadataMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.MaxBytes == 0 {
+		req.MaxBytes = s.config.Metadata.MaxBytes
+	}
+	if req.MaxBytes <= 0 {
+		s.handleError(c, errors.New("max_bytes is required when no metadata size limit is configured"), http.StatusBadRequest)
+		return
+	}
+	if len(req.Tables) == 0 {
+		req.Tables = database.MetadataTables()
+	}
+
+	migrations := make([]*database.MetadataMigration, 0, len(req.Tables))
+	for _, table := range req.Tables {
+		migration, err := s.metadataService.MigrateOversizedMetadata(table, req.MaxBytes, req.DryRun)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+		migrations = append(migrations, migration)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"tables":    req.Tables,
+		"max_bytes": req.MaxBytes,
+		"dry_run":   req.DryRun,
+	}).Info("Oversized metadata migration requested")
+
+	s.handleSuccess(c, migrations, "Metadata migration completed")
+}
+
+// getArtifact returns the stored content of an artifact
+func (s *Server) getArtifact(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid artifact id"), http.StatusBadRequest)
+		return
+	}
+
+	artifact, err := s.metadataService.GetArtifact(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if artifact == nil {
+		s.handleError(c, errors.New("artifact not found"), http.StatusNotFound)
+		return
+	}
+
+	c.Header("X-VoltEdge-Artifact-Owner", artifact.OwnerTable+"/"+artifact.OwnerID.String())
+	c.Data(http.StatusOK, artifact.ContentType, artifact.Data)
+}
+
+// getDatabaseStats returns the latest sample of the database connection pool
+func (s *Server) getDatabaseStats(c *gin.Context) {
+	if s.databasePool == nil {
+		s.handleError(c, errors.New("database pool monitoring is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	s.handleSuccess(c, s.databasePool.Stats(), "Database stats retrieved successfully")
+}
+
+// getReconciliation returns the last reconciliation pass and recent corrections
+func (s *Server) getReconciliation(c *gin.Context) {
+	if s.reconciler == nil {
+		s.handleError(c, errors.New("reconciliation is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	response := ReconciliationResponse{
+		LastRun: s.reconciler.LastReport(),
+		Actions: s.reconciler.Actions(),
+	}
+
+	s.handleSuccess(c, response, "Reconciliation status retrieved successfully")
+}
+
+// runReconciliation runs a reconciliation pass immediately
+func (s *Server) runReconciliation(c *gin.Context) {
+	if s.reconciler == nil {
+		s.handleError(c, errors.New("reconciliation is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	report := s.reconciler.Run(c.Request.Context())
+
+	logrus.WithFields(logrus.Fields{
+		"checked": report.Checked,
+		"actions": len(report.Actions),
+	}).Info("Ran reconciliation on request")
+
+	s.handleSuccess(c, report, "Reconciliation completed")
+}
@@ -0,0 +1,713 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/notifications"
+)
+
+// ChannelRequest represents a request to create or update a notification channel.
+// Omitted secrets keep their current value on update.
+type ChannelRequest struct {
+	Name           string    `json:"name" binding:"required"`
+	Type           string    `json:"type" binding:"required,oneof=slack pagerduty email"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	WebhookURL     string    `json:"webhook_url"`
+	RoutingKey     string    `json:"routing_key"`
+	Recipients     []string  `json:"recipients"`
+	IsActive       *bool     `json:"is_active"`
+}
+
+// AlertRouteRequest represents a request to create or update an alert route
+type AlertRouteRequest struct {
+	Name                  string     `json:"name" binding:"required"`
+	ChannelID             uuid.UUID  `json:"channel_id" binding:"required"`
+	OrganizationID        uuid.UUID  `json:"organization_id"`
+	AlertTypes            []string   `json:"alert_types"`
+	MinSeverity           string     `json:"min_severity"`
+	SimulationID          *uuid.UUID `json:"simulation_id"`
+	DedupeWindowSeconds   int        `json:"dedupe_window_seconds" binding:"gte=0"`
+	ThrottleLimit         int        `json:"throttle_limit" binding:"gte=0"`
+	ThrottleWindowSeconds int        `json:"throttle_window_seconds" binding:"gte=0"`
+	IsActive              *bool      `json:"is_active"`
+}
+
+// SilenceRequest represents a request to create or update an alert silence.
+// Silences start immediately when starts_at is omitted.
+type SilenceRequest struct {
+	Comment        string     `json:"comment" binding:"required"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         time.Time  `json:"ends_at" binding:"required"`
+	SimulationID   *uuid.UUID `json:"simulation_id"`
+	ComponentType  string     `json:"component_type"`
+	ComponentID    *int       `json:"component_id"`
+	Severities     []string   `json:"severities"`
+	AlertTypes     []string   `json:"alert_types"`
+}
+
+// SilenceResponse represents a silence and its current state
+type SilenceResponse struct {
+	database.AlertSilence
+	State string `json:"state"`
+}
+
+// TestNotificationRequest customises the alert sent by a channel test
+type TestNotificationRequest struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// apply copies request fields onto a channel
+func (r *ChannelRequest) apply(channel *database.NotificationChannel) {
+	channel.Name = r.Name
+	channel.Type = r.Type
+	channel.OrganizationID = r.OrganizationID
+	channel.Recipients = r.Recipients
+	if r.WebhookURL != "" {
+		channel.WebhookURL = r.WebhookURL
+	}
+	if r.RoutingKey != "" {
+		channel.RoutingKey = r.RoutingKey
+	}
+	if r.IsActive != nil {
+		channel.IsActive = *r.IsActive
+	}
+}
+
+// validateChannel checks a channel has what its type needs to deliver, and
+// that a Slack webhook URL resolves to public addresses only
+func validateChannel(ctx context.Context, channel *database.NotificationChannel) error {
+	switch channel.Type {
+	case database.ChannelSlack:
+		parsed, err := url.Parse(channel.WebhookURL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return errors.New("slack channels need an https webhook_url")
+		}
+		if err := notifications.CheckDestination(ctx, channel.WebhookURL); err != nil {
+			return err
+		}
+	case database.ChannelPagerDuty:
+		if channel.RoutingKey == "" {
+			return errors.New("pagerduty channels need a routing_key")
+		}
+	case database.ChannelEmail:
+		if len(channel.Recipients) == 0 {
+			return errors.New("email channels need at least one recipient")
+		}
+		for _, recipient := range channel.Recipients {
+			if address, err := mail.ParseAddress(recipient); err != nil || address.Name != "" {
+				return errors.New("invalid recipient: " + recipient)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the route's severity and throttle settings
+func (r *AlertRouteRequest) validate() error {
+	if r.MinSeverity != "" && !notifications.ValidSeverity(r.MinSeverity) {
+		return errors.New("min_severity must be info, warning, error or critical")
+	}
+	if (r.ThrottleLimit > 0) != (r.ThrottleWindowSeconds > 0) {
+		return errors.New("throttle_limit and throttle_window_seconds must be set together")
+	}
+	return nil
+}
+
+// apply copies request fields onto a route
+func (r *AlertRouteRequest) apply(route *database.AlertRoute) {
+	route.Name = r.Name
+	route.ChannelID = r.ChannelID
+	route.OrganizationID = r.OrganizationID
+	route.AlertTypes = r.AlertTypes
+	route.MinSeverity = r.MinSeverity
+	route.SimulationID = r.SimulationID
+	route.DedupeWindowSeconds = r.DedupeWindowSeconds
+	route.ThrottleLimit = r.ThrottleLimit
+	route.ThrottleWindowSeconds = r.ThrottleWindowSeconds
+	if r.IsActive != nil {
+		route.IsActive = *r.IsActive
+	}
+}
+
+// validate checks the silence's time range and severities
+func (r *SilenceRequest) validate() error {
+	startsAt := time.Now()
+	if r.StartsAt != nil {
+		startsAt = *r.StartsAt
+	}
+	if !r.EndsAt.After(startsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	for _, severity := range r.Severities {
+		if !notifications.ValidSeverity(severity) {
+			return errors.New("severities must be info, warning, error or critical")
+		}
+	}
+	return nil
+}
+
+// apply copies request fields onto a silence
+func (r *SilenceRequest) apply(silence *database.AlertSilence) {
+	silence.Comment = r.Comment
+	silence.OrganizationID = r.OrganizationID
+	if r.StartsAt != nil {
+		silence.StartsAt = *r.StartsAt
+	} else if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	silence.EndsAt = r.EndsAt
+	silence.SimulationID = r.SimulationID
+	silence.ComponentType = r.ComponentType
+	silence.ComponentID = r.ComponentID
+	silence.Severities = r.Severities
+	silence.AlertTypes = r.AlertTypes
+}
+
+func newSilenceResponse(silence *database.AlertSilence) SilenceResponse {
+	return SilenceResponse{AlertSilence: *silence, State: silence.State(time.Now())}
+}
+
+// createChannel handles notification channel creation requests
+func (s *Server) createChannel(c *gin.Context) {
+	var req ChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	channel := &database.NotificationChannel{IsActive: true, OwnerID: actorID(c)}
+	req.apply(channel)
+
+	if err := validateChannel(c.Request.Context(), channel); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.alertRouting.CreateChannel(channel); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, channel.Version)
+	s.handleSuccess(c, channel, "Notification channel created successfully")
+}
+
+// listChannels handles notification channel listing requests
+func (s *Server) listChannels(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+
+	channels, err := s.alertRouting.ListChannels(limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, channels, "Notification channels retrieved successfully")
+}
+
+// getChannel handles single notification channel retrieval requests
+func (s *Server) getChannel(c *gin.Context) {
+	channel, ok := s.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	setETag(c, channel.Version)
+	s.handleSuccess(c, channel, "Notification channel retrieved successfully")
+}
+
+// updateChannel handles notification channel update requests. The If-Match
+// header must carry the version being updated.
+func (s *Server) updateChannel(c *gin.Context) {
+	expectedVersion, ok := s.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	channel, ok := s.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	if channel.Version != expectedVersion {
+		s.handleVersionConflict(c, database.ErrVersionConflict, channel.Version)
+		return
+	}
+
+	var req ChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	req.apply(channel)
+
+	if err := validateChannel(c.Request.Context(), channel); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.alertRouting.UpdateChannel(channel, expectedVersion); err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			current, getErr := s.alertRouting.GetChannel(channel.ID)
+			if getErr != nil || current == nil {
+				s.handleError(c, err, http.StatusConflict)
+				return
+			}
+			s.handleVersionConflict(c, err, current.Version)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, channel.Version)
+	s.handleSuccess(c, channel, "Notification channel updated successfully")
+}
+
+// deleteChannel handles notification channel deletion requests. Routes using
+// the channel are deleted with it.
+func (s *Server) deleteChannel(c *gin.Context) {
+	channel, ok := s.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	if err := s.alertRouting.DeleteChannel(channel.ID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Notification channel deleted successfully")
+}
+
+// testChannel sends a test alert through a channel, bypassing routes and throttling
+func (s *Server) testChannel(c *gin.Context) {
+	channel, ok := s.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	var req TestNotificationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	event := notifications.SampleEvent(notifications.EventAlertTriggered)
+	event.Message = "Test notification from VoltEdge"
+	event.Data = map[string]interface{}{"alert_type": "test"}
+	if req.Severity != "" {
+		event.Severity = req.Severity
+	}
+	if req.Message != "" {
+		event.Message = req.Message
+	}
+
+	if err := s.alertRouter.Send(c.Request.Context(), channel, event); err != nil {
+		s.handleError(c, err, http.StatusBadGateway)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Test notification sent successfully")
+}
+
+// createAlertRoute handles alert route creation requests
+func (s *Server) createAlertRoute(c *gin.Context) {
+	var req AlertRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if !s.validateAlertRoute(c, &req) {
+		return
+	}
+
+	route := &database.AlertRoute{IsActive: true}
+	req.apply(route)
+
+	if err := s.alertRouting.CreateRoute(route); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, route.Version)
+	s.handleSuccess(c, route, "Alert route created successfully")
+}
+
+// listAlertRoutes handles alert route listing requests
+func (s *Server) listAlertRoutes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+
+	routes, err := s.alertRouting.ListRoutes(limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, routes, "Alert routes retrieved successfully")
+}
+
+// getAlertRoute handles single alert route retrieval requests
+func (s *Server) getAlertRoute(c *gin.Context) {
+	route, ok := s.loadAlertRoute(c)
+	if !ok {
+		return
+	}
+
+	setETag(c, route.Version)
+	s.handleSuccess(c, route, "Alert route retrieved successfully")
+}
+
+// updateAlertRoute handles alert route update requests. The If-Match header
+// must carry the version being updated.
+func (s *Server) updateAlertRoute(c *gin.Context) {
+	expectedVersion, ok := s.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	route, ok := s.loadAlertRoute(c)
+	if !ok {
+		return
+	}
+
+	if route.Version != expectedVersion {
+		s.handleVersionConflict(c, database.ErrVersionConflict, route.Version)
+		return
+	}
+
+	var req AlertRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if !s.validateAlertRoute(c, &req) {
+		return
+	}
+
+	req.apply(route)
+
+	if err := s.alertRouting.UpdateRoute(route, expectedVersion); err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			current, getErr := s.alertRouting.GetRoute(route.ID)
+			if getErr != nil || current == nil {
+				s.handleError(c, err, http.StatusConflict)
+				return
+			}
+			s.handleVersionConflict(c, err, current.Version)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, route.Version)
+	s.handleSuccess(c, route, "Alert route updated successfully")
+}
+
+// deleteAlertRoute handles alert route deletion requests
+func (s *Server) deleteAlertRoute(c *gin.Context) {
+	route, ok := s.loadAlertRoute(c)
+	if !ok {
+		return
+	}
+
+	if err := s.alertRouting.DeleteRoute(route.ID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Alert route deleted successfully")
+}
+
+// listAlertNotifications returns recent routing outcomes, optionally for one route
+func (s *Server) listAlertNotifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+
+	var routeID *uuid.UUID
+	if value := c.Query("route_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			s.handleError(c, errors.New("invalid route_id"), http.StatusBadRequest)
+			return
+		}
+		routeID = &id
+	}
+
+	notifications, err := s.alertRouting.ListNotifications(routeID, limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, notifications, "Alert notifications retrieved successfully")
+}
+
+// createSilence handles alert silence creation requests
+func (s *Server) createSilence(c *gin.Context) {
+	var req SilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	silence := &database.AlertSilence{}
+	req.apply(silence)
+	if claims := currentClaims(c); claims != nil {
+		silence.CreatedBy = &claims.UserID
+	}
+
+	if err := s.alertRouting.CreateSilence(silence); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, silence.Version)
+	s.handleSuccess(c, newSilenceResponse(silence), "Alert silence created successfully")
+}
+
+// listSilences handles alert silence listing requests, optionally filtered by state
+func (s *Server) listSilences(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+
+	silences, err := s.alertRouting.ListSilences(c.Query("state"), limit, (page-1)*limit)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidSilenceState) {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]SilenceResponse, len(silences))
+	for i := range silences {
+		response[i] = newSilenceResponse(&silences[i])
+	}
+
+	s.handleSuccess(c, response, "Alert silences retrieved successfully")
+}
+
+// getSilence handles single alert silence retrieval requests
+func (s *Server) getSilence(c *gin.Context) {
+	silence, ok := s.loadSilence(c)
+	if !ok {
+		return
+	}
+
+	setETag(c, silence.Version)
+	s.handleSuccess(c, newSilenceResponse(silence), "Alert silence retrieved successfully")
+}
+
+// updateSilence handles alert silence update requests. The If-Match header
+// must carry the version being updated.
+func (s *Server) updateSilence(c *gin.Context) {
+	expectedVersion, ok := s.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	silence, ok := s.loadSilence(c)
+	if !ok {
+		return
+	}
+
+	if silence.Version != expectedVersion {
+		s.handleVersionConflict(c, database.ErrVersionConflict, silence.Version)
+		return
+	}
+
+	var req SilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.StartsAt == nil {
+		req.StartsAt = &silence.StartsAt
+	}
+	if err := req.validate(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	req.apply(silence)
+	s.saveSilence(c, silence, expectedVersion, "Alert silence updated successfully")
+}
+
+// expireSilence ends an alert silence now
+func (s *Server) expireSilence(c *gin.Context) {
+	silence, ok := s.loadSilence(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	if silence.State(now) == database.SilenceExpired {
+		s.handleError(c, errors.New("alert silence has already expired"), http.StatusConflict)
+		return
+	}
+
+	silence.EndsAt = now
+	if silence.StartsAt.After(now) {
+		silence.StartsAt = now
+	}
+	s.saveSilence(c, silence, silence.Version, "Alert silence expired successfully")
+}
+
+// saveSilence saves an updated silence and writes the response
+func (s *Server) saveSilence(c *gin.Context, silence *database.AlertSilence, expectedVersion int64, message string) {
+	if err := s.alertRouting.UpdateSilence(silence, expectedVersion); err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			current, getErr := s.alertRouting.GetSilence(silence.ID)
+			if getErr != nil || current == nil {
+				s.handleError(c, err, http.StatusConflict)
+				return
+			}
+			s.handleVersionConflict(c, err, current.Version)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, silence.Version)
+	s.handleSuccess(c, newSilenceResponse(silence), message)
+}
+
+// deleteSilence handles alert silence deletion requests
+func (s *Server) deleteSilence(c *gin.Context) {
+	silence, ok := s.loadSilence(c)
+	if !ok {
+		return
+	}
+
+	if err := s.alertRouting.DeleteSilence(silence.ID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Alert silence deleted successfully")
+}
+
+// loadSilence resolves the :id parameter to a silence, writing an error response on failure
+func (s *Server) loadSilence(c *gin.Context) (*database.AlertSilence, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid silence id"), http.StatusBadRequest)
+		return nil, false
+	}
+
+	silence, err := s.alertRouting.GetSilence(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	if silence == nil {
+		s.handleError(c, errors.New("alert silence not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return silence, true
+}
+
+// validateAlertRoute checks a route request and that its channel exists,
+// writing an error response on failure
+func (s *Server) validateAlertRoute(c *gin.Context, req *AlertRouteRequest) bool {
+	if err := req.validate(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return false
+	}
+
+	channel, err := s.alertRouting.GetChannel(req.ChannelID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return false
+	}
+	if channel == nil {
+		s.handleError(c, errors.New("notification channel not found"), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// loadChannel resolves the :id parameter to a channel, writing an error response on failure
+func (s *Server) loadChannel(c *gin.Context) (*database.NotificationChannel, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid channel id"), http.StatusBadRequest)
+		return nil, false
+	}
+
+	channel, err := s.alertRouting.GetChannel(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	if channel == nil {
+		s.handleError(c, errors.New("notification channel not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return channel, true
+}
+
+// loadAlertRoute resolves the :id parameter to a route, writing an error response on failure
+func (s *Server) loadAlertRoute(c *gin.Context) (*database.AlertRoute, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid route id"), http.StatusBadRequest)
+		return nil, false
+	}
+
+	route, err := s.alertRouting.GetRoute(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	if route == nil {
+		s.handleError(c, errors.New("alert route not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return route, true
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/realtime"
+)
+
+// BulkAcknowledgeRequest represents a request to acknowledge up to 1000 alerts
+type BulkAcknowledgeRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1,max=1000"`
+}
+
+// BulkAcknowledgeResponse lists the alerts a bulk acknowledgement changed.
+// Skipped alerts were missing, already acknowledged or resolved.
+type BulkAcknowledgeResponse struct {
+	Acknowledged []database.Alert `json:"acknowledged"`
+	Skipped      []uuid.UUID      `json:"skipped"`
+}
+
+// acknowledgeAlert acknowledges a single active alert
+func (s *Server) acknowledgeAlert(c *gin.Context) {
+	alert, ok := s.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	if alert.ResolvedAt != nil {
+		s.handleError(c, database.ErrAlertResolved, http.StatusConflict)
+		return
+	}
+	if alert.AcknowledgedAt != nil {
+		s.handleError(c, errors.New("alert is already acknowledged"), http.StatusConflict)
+		return
+	}
+
+	acknowledged, err := s.simulationService.AcknowledgeAlerts(c.Request.Context(), []uuid.UUID{alert.ID}, actorID(c))
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if len(acknowledged) == 0 {
+		// Acknowledged or resolved by someone else in the meantime
+		s.handleError(c, errors.New("alert is no longer active"), http.StatusConflict)
+		return
+	}
+
+	s.publishAlertUpdate(realtime.MessageAlertAcknowledged, &acknowledged[0])
+	s.handleSuccess(c, acknowledged[0], "Alert acknowledged successfully")
+}
+
+// acknowledgeAlerts acknowledges every listed alert that is still active
+func (s *Server) acknowledgeAlerts(c *gin.Context) {
+	var req BulkAcknowledgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	acknowledged, err := s.simulationService.AcknowledgeAlerts(c.Request.Context(), req.IDs, actorID(c))
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	changed := make(map[uuid.UUID]bool, len(acknowledged))
+	for i := range acknowledged {
+		changed[acknowledged[i].ID] = true
+		s.publishAlertUpdate(realtime.MessageAlertAcknowledged, &acknowledged[i])
+	}
+
+	response := BulkAcknowledgeResponse{
+		Acknowledged: acknowledged,
+		Skipped:      []uuid.UUID{},
+	}
+	for _, id := range req.IDs {
+		if !changed[id] {
+			response.Skipped = append(response.Skipped, id)
+			changed[id] = true // list repeated IDs once
+		}
+	}
+	if response.Acknowledged == nil {
+		response.Acknowledged = []database.Alert{}
+	}
+
+	s.handleSuccess(c, response, "Alerts acknowledged successfully")
+}
+
+// resolveAlert resolves an alert whether or not it was acknowledged
+func (s *Server) resolveAlert(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid alert id"), http.StatusBadRequest)
+		return
+	}
+
+	alert, err := s.simulationService.ResolveAlert(c.Request.Context(), id, actorID(c))
+	if err != nil {
+		if errors.Is(err, database.ErrAlertResolved) {
+			s.handleError(c, err, http.StatusConflict)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if alert == nil {
+		s.handleError(c, errors.New("alert not found"), http.StatusNotFound)
+		return
+	}
+
+	s.publishAlertUpdate(realtime.MessageAlertResolved, alert)
+	s.handleSuccess(c, alert, "Alert resolved successfully")
+}
+
+// publishAlertUpdate tells connected clients about an alert's new state
+func (s *Server) publishAlertUpdate(messageType string, alert *database.Alert) {
+	s.hub.Publish(realtime.Message{
+		Type:  messageType,
+		Topic: realtime.AlertsTopic(alert.SimulationID.String()),
+		Data:  alert,
+	})
+}
+
+// loadAlert resolves the :id parameter to an alert, writing an error response on failure
+func (s *Server) loadAlert(c *gin.Context) (*database.Alert, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid alert id"), http.StatusBadRequest)
+		return nil, false
+	}
+
+	alert, err := s.simulationService.GetAlert(c.Request.Context(), id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	if alert == nil {
+		s.handleError(c, errors.New("alert not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return alert, true
+}
+
+// actorID returns the authenticated user making the request, or nil when anonymous
+func actorID(c *gin.Context) *uuid.UUID {
+	claims := currentClaims(c)
+	if claims == nil {
+		return nil
+	}
+	id := claims.UserID
+	return &id
+}
@@ -0,0 +1,605 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/analytics"
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/observability"
+	"voltedge/go-services/internal/prediction"
+)
+
+// getEmissions computes carbon emissions for a simulation from its plants'
+// recorded output. Whole-run totals are stored when a run ends.
+func (s *Server) getEmissions(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithField("simulation_id", simulationID).Debug("Computing emissions")
+
+	outputs, err := s.simulationService.GetPlantOutputs(c.Request.Context(), simulationID, from, to)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	plants, err := s.simulationService.GetPowerPlants(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	report, err := analytics.ComputeEmissions(outputs, plants)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	for _, plant := range report.ByPlant {
+		observability.RecordPowerPlantEmissions(simulationID.String(), strconv.Itoa(plant.PlantID), plant.FuelType, plant.CurrentKgPerHour)
+	}
+
+	s.handleSuccess(c, report, "Emissions retrieved successfully")
+}
+
+// getCosts computes per-tick and cumulative operating cost for a simulation
+// from its plants' recorded output
+func (s *Server) getCosts(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	outputs, err := s.simulationService.GetPlantOutputs(c.Request.Context(), simulationID, from, to)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	plants, err := s.simulationService.GetPowerPlants(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	report, err := analytics.ComputeCosts(outputs, plants)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, report, "Costs retrieved successfully")
+}
+
+// getDispatchSuggestion returns a cost-optimal dispatch for the simulation's plants.
+// Demand defaults to the most recently recorded consumption.
+func (s *Server) getDispatchSuggestion(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	plants, err := s.simulationService.GetPowerPlants(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if len(plants) == 0 {
+		s.handleError(c, errors.New("simulation has no power plants"), http.StatusNotFound)
+		return
+	}
+
+	var demand float64
+	if raw := c.Query("demand_mw"); raw != "" {
+		demand, err = strconv.ParseFloat(raw, 64)
+		if err != nil || demand < 0 {
+			s.handleError(c, errors.New("demand_mw must be a non-negative number"), http.StatusBadRequest)
+			return
+		}
+	} else {
+		results, err := s.readCaches.latestResults.Get(c.Request.Context(), latestResultsKey(simulationID, 1), func(ctx context.Context) ([]database.SimulationResult, error) {
+			return s.simulationService.GetLatestSimulationResults(ctx, simulationID, 1)
+		})
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+		if len(results) == 0 {
+			s.handleError(c, errors.New("no recorded results; demand_mw is required"), http.StatusBadRequest)
+			return
+		}
+		demand = results[0].TotalConsumptionMW
+	}
+
+	current, err := s.simulationService.GetLatestPlantOutputs(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	suggestion, err := analytics.SuggestDispatch(plants, current, demand)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, suggestion, "Dispatch suggestion computed successfully")
+}
+
+// getEnergyBalance reports generated and consumed energy, losses, unserved
+// energy during faults and the renewables share of a simulation. ?step breaks
+// the totals down into windows of that length.
+func (s *Server) getEnergyBalance(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	var step time.Duration
+	if raw := c.Query("step"); raw != "" {
+		step, err = time.ParseDuration(raw)
+		if err != nil || step < time.Second {
+			s.handleError(c, errors.New("step must be a duration of at least 1s"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := s.resultsInRange(c.Request.Context(), simulationID, from, to)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if step > 0 && len(results) > 1 {
+		span := results[len(results)-1].Timestamp.Sub(results[0].Timestamp)
+		if span/step >= maxEnergyWindows {
+			s.handleError(c, fmt.Errorf("step is too short: at most %d windows are reported", maxEnergyWindows), http.StatusBadRequest)
+			return
+		}
+	}
+
+	plants, err := s.simulationService.GetPowerPlants(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	var faults []database.FaultEvent
+	if len(results) > 0 {
+		faults, err = s.simulationService.FaultEventsOverlapping(c.Request.Context(), simulationID, &results[0].Timestamp, &results[len(results)-1].Timestamp)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.handleSuccess(c, analytics.ComputeEnergyBalance(results, plants, faults, step), "Energy balance retrieved successfully")
+}
+
+// getReliability reports per-component MTBF, MTTR and availability from a
+// simulation's fault events as JSON, or as CSV with ?format=csv. The period
+// defaults to the simulation's recorded results.
+func (s *Server) getReliability(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		s.handleError(c, errors.New("format must be json or csv"), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	if from == nil || to == nil {
+		stats, err := s.simulationService.GetSimulationStatistics(c.Request.Context(), simulationID)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+		if from == nil {
+			from = stats.From
+		}
+		if to == nil {
+			to = stats.To
+		}
+	}
+	if from == nil || to == nil {
+		s.handleError(c, errors.New("no recorded results; from and to are required"), http.StatusBadRequest)
+		return
+	}
+
+	faults, err := s.simulationService.FaultEventsOverlapping(c.Request.Context(), simulationID, from, to)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	report := analytics.ComputeReliability(faults, *from, *to)
+
+	if format == "json" {
+		s.handleSuccess(c, report, "Reliability retrieved successfully")
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "reliability-"+simulationID.String()+".csv"))
+	c.Status(http.StatusOK)
+
+	csvWriter := csv.NewWriter(c.Writer)
+	csvWriter.Write([]string{
+		"component_type", "component_id", "failures", "unresolved", "downtime_hours",
+		"uptime_hours", "mtbf_hours", "mttr_hours", "availability_percent",
+	})
+	for _, component := range report.Components {
+		csvWriter.Write([]string{
+			component.ComponentType,
+			strconv.Itoa(component.ComponentID),
+			strconv.Itoa(component.Failures),
+			strconv.Itoa(component.Unresolved),
+			strconv.FormatFloat(component.DowntimeHours, 'f', -1, 64),
+			strconv.FormatFloat(component.UptimeHours, 'f', -1, 64),
+			formatOptionalFloat(component.MTBFHours),
+			formatOptionalFloat(component.MTTRHours),
+			strconv.FormatFloat(component.AvailabilityPercent, 'f', -1, 64),
+		})
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		logrus.WithError(err).WithField("path", requestPath(c)).Warn("Reliability export aborted")
+	}
+}
+
+// formatOptionalFloat formats a CSV cell, leaving it empty for nil
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// getStabilityScore returns a run's frequency stability KPIs, computing and
+// storing them if the run has none yet. ?recompute=true, ?nominal_hz or
+// ?band_hz compute them again.
+func (s *Server) getStabilityScore(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	recompute := c.Query("recompute") == "true" || c.Query("nominal_hz") != "" || c.Query("band_hz") != ""
+	if !recompute {
+		score, err := s.simulationService.GetStabilityScore(c.Request.Context(), simulationID)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+		if score != nil {
+			s.handleSuccess(c, score, "Stability score retrieved successfully")
+			return
+		}
+	}
+
+	simulation, err := s.simulationService.GetSimulation(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if simulation == nil {
+		s.handleError(c, errors.New("simulation not found"), http.StatusNotFound)
+		return
+	}
+
+	nominal := analytics.NominalFrequency(simulation)
+	if raw := c.Query("nominal_hz"); raw != "" {
+		nominal, err = strconv.ParseFloat(raw, 64)
+		if err != nil || nominal <= 0 {
+			s.handleError(c, errors.New("nominal_hz must be a positive number"), http.StatusBadRequest)
+			return
+		}
+	}
+	band := analytics.DefaultStabilityBandHz
+	if raw := c.Query("band_hz"); raw != "" {
+		band, err = strconv.ParseFloat(raw, 64)
+		if err != nil || band <= 0 {
+			s.handleError(c, errors.New("band_hz must be a positive number"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	score, err := analytics.ScoreStability(c.Request.Context(), s.simulationService, simulationID, nominal, band)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, score, "Stability score computed successfully")
+}
+
+// rankStability lists runs by stability score, most stable first. ?simulation_id
+// takes a comma-separated list to rank only those runs.
+func (s *Server) rankStability(c *gin.Context) {
+	var simulationIDs []uuid.UUID
+	for _, raw := range splitQueryList(c.Query("simulation_id")) {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			s.handleError(c, fmt.Errorf("invalid simulation id %q", raw), http.StatusBadRequest)
+			return
+		}
+		simulationIDs = append(simulationIDs, id)
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	ranks, err := s.simulationService.RankStability(c.Request.Context(), simulationIDs, limit, offset)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, ranks, "Stability ranking retrieved successfully")
+}
+
+// getPredictions forecasts load, fault likelihood and required generation for a simulation
+func (s *Server) getPredictions(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithField("simulation_id", simulationID).Debug("Getting predictions")
+
+	predicted, err := s.predictions.Predict(c.Request.Context(), simulationID)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, prediction.ErrNoHistory) {
+			status = http.StatusNotFound
+		}
+		s.handleError(c, err, status)
+		return
+	}
+
+	s.handleSuccess(c, predicted, "Predictions retrieved successfully")
+}
+
+// getPredictionAccuracy compares stored forecasts with the load later observed
+func (s *Server) getPredictionAccuracy(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	accuracy, err := s.predictions.Accuracy(c.Request.Context(), simulationID, limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, accuracy, "Prediction accuracy retrieved successfully")
+}
+
+const (
+	defaultTopComponents = 10
+	maxTopComponents     = 1000
+	// Windows an energy balance is broken down into at most
+	maxEnergyWindows = 10000
+	// Grid frequency deviations are measured from this unless ?nominal_hz is given
+	defaultNominalFrequencyHz = 50.0
+)
+
+// defaultPercentiles are reported when ?p is not given
+var defaultPercentiles = []float64{50, 95, 99}
+
+// getTopComponents ranks a simulation's components by an aggregate of one
+// metric, e.g. ?metric=line_utilization&n=10 for the ten most loaded lines.
+// ?agg picks the aggregate (default max) and ?order=asc ranks lowest first.
+func (s *Server) getTopComponents(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	query := database.TopComponentsQuery{
+		Metric:        c.Query("metric"),
+		ComponentType: c.Query("component_type"),
+		Aggregation:   c.Query("agg"),
+	}
+	if query.Metric == "" {
+		s.handleError(c, errors.New("metric is required"), http.StatusBadRequest)
+		return
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		s.handleError(c, errors.New("order must be asc or desc"), http.StatusBadRequest)
+		return
+	}
+
+	query.N, _ = strconv.Atoi(c.DefaultQuery("n", strconv.Itoa(defaultTopComponents)))
+	if query.N < 1 || query.N > maxTopComponents {
+		query.N = defaultTopComponents
+	}
+
+	query.From, query.To, err = parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	ranks, err := s.simulationService.TopComponents(c.Request.Context(), simulationID, query)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidAggregation) {
+			s.handleError(c, err, http.StatusBadRequest)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, ranks, "Top components retrieved successfully")
+}
+
+// getPercentiles summarizes the distribution of result fields (?field, e.g.
+// frequency_deviation_hz) and component metrics (?metric, e.g. line_loading,
+// optionally narrowed by ?component_type). Both accept comma-separated lists;
+// ?p sets the percentiles (default 50,95,99).
+func (s *Server) getPercentiles(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	fields := splitQueryList(c.Query("field"))
+	metrics := splitQueryList(c.Query("metric"))
+	if len(fields) == 0 && len(metrics) == 0 {
+		s.handleError(c, fmt.Errorf("field or metric is required; fields are %s", strings.Join(database.ResultPercentileFields, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	percentiles := defaultPercentiles
+	if raw := c.Query("p"); raw != "" {
+		percentiles = nil
+		for _, value := range splitQueryList(raw) {
+			p, err := strconv.ParseFloat(value, 64)
+			if err != nil || p < 0 || p > 100 {
+				s.handleError(c, fmt.Errorf("invalid percentile %q, expected 0-100", value), http.StatusBadRequest)
+				return
+			}
+			percentiles = append(percentiles, p)
+		}
+	}
+	fractions := make([]float64, len(percentiles))
+	for i, p := range percentiles {
+		fractions[i] = p / 100
+	}
+
+	nominal := defaultNominalFrequencyHz
+	if raw := c.Query("nominal_hz"); raw != "" {
+		nominal, err = strconv.ParseFloat(raw, 64)
+		if err != nil || nominal <= 0 {
+			s.handleError(c, errors.New("nominal_hz must be a positive number"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	summaries := make([]*database.PercentileSummary, 0, len(fields)+len(metrics))
+	for _, field := range fields {
+		summary, err := s.simulationService.ResultPercentiles(c.Request.Context(), simulationID, field, fractions, nominal, from, to)
+		if err != nil {
+			if errors.Is(err, database.ErrInvalidPercentileField) {
+				s.handleError(c, fmt.Errorf("%w; fields are %s", err, strings.Join(database.ResultPercentileFields, ", ")), http.StatusBadRequest)
+			} else {
+				s.handleError(c, err, http.StatusInternalServerError)
+			}
+			return
+		}
+		summaries = append(summaries, summary)
+	}
+	for _, metric := range metrics {
+		summary, err := s.simulationService.MetricPercentiles(c.Request.Context(), simulationID, metric, c.Query("component_type"), fractions, from, to)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+		summaries = append(summaries, summary)
+	}
+
+	s.handleSuccess(c, summaries, "Percentiles retrieved successfully")
+}
+
+// parseTimeWindow reads optional RFC3339 from/to query parameters
+func parseTimeWindow(c *gin.Context) (*time.Time, *time.Time, error) {
+	return parseTimeRange(c, "from", "to")
+}
+
+// parseTimeRange reads an optional RFC3339 range from two query parameters
+func parseTimeRange(c *gin.Context, fromParam, toParam string) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+
+	if raw := c.Query(fromParam); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s timestamp: %w", fromParam, err)
+		}
+		from = &t
+	}
+
+	if raw := c.Query(toParam); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s timestamp: %w", toParam, err)
+		}
+		to = &t
+	}
+
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, nil, fmt.Errorf("%s must not be before %s", toParam, fromParam)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/archive"
+	"voltedge/go-services/internal/database"
+)
+
+// ArchiveResponse reports the last archival pass and recent archives
+type ArchiveResponse struct {
+	LastRun  *archive.Report          `json:"last_run"`
+	Archives []database.ResultArchive `json:"archives"`
+}
+
+// resultsInRange reads a simulation's results, including archived ones
+func (s *Server) resultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]database.SimulationResult, error) {
+	if s.archiver != nil {
+		return s.archiver.GetSimulationResultsInRange(ctx, simulationID, from, to)
+	}
+	return s.simulationService.GetSimulationResultsInRange(ctx, simulationID, from, to)
+}
+
+// countResultsInRange counts a simulation's results, including archived
+// ones, without reading them
+func (s *Server) countResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) (int64, error) {
+	if s.archiver != nil {
+		return s.archiver.CountSimulationResultsInRange(ctx, simulationID, from, to)
+	}
+	return s.simulationService.CountSimulationResultsInRange(ctx, simulationID, from, to)
+}
+
+// getArchive returns the last archival pass and recent archives
+func (s *Server) getArchive(c *gin.Context) {
+	if s.archiver == nil {
+		s.handleError(c, errors.New("result archival is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	archives, err := s.archiver.Archives(limit, offset)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, ArchiveResponse{
+		LastRun:  s.archiver.LastReport(),
+		Archives: archives,
+	}, "Archive status retrieved successfully")
+}
+
+// runArchive runs an archival pass immediately
+func (s *Server) runArchive(c *gin.Context) {
+	if s.archiver == nil {
+		s.handleError(c, errors.New("result archival is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	report := s.archiver.Run(c.Request.Context())
+
+	logrus.WithFields(logrus.Fields{
+		"archived": report.Archived,
+		"rows":     report.Rows,
+	}).Info("Ran result archival on request")
+
+	s.handleSuccess(c, report, "Archival completed")
+}
+
+// archiveSimulation archives a simulation's results now, regardless of its age
+func (s *Server) archiveSimulation(c *gin.Context) {
+	if s.archiver == nil {
+		s.handleError(c, errors.New("result archival is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.archiver.Archive(c.Request.Context(), simulationID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrArchiveChanged) {
+			status = http.StatusConflict
+		}
+		s.handleError(c, err, status)
+		return
+	}
+
+	s.handleSuccess(c, result, "Simulation results archived successfully")
+}
+
+// rehydrateSimulation copies a simulation's archived results back into the database
+func (s *Server) rehydrateSimulation(c *gin.Context) {
+	if s.archiver == nil {
+		s.handleError(c, errors.New("result archival is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.archiver.Rehydrate(c.Request.Context(), simulationID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, archive.ErrNotArchived) {
+			status = http.StatusNotFound
+		}
+		s.handleError(c, err, status)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{"simulation_id": simulationID}, "Simulation results rehydrated successfully")
+}
@@ -0,0 +1,440 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/database"
+)
+
+// claimsKey is the gin context key holding verified token claims
+const claimsKey = "auth_claims"
+
+// LoginRequest represents a login request
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// TokenResponse represents an issued token
+type TokenResponse struct {
+	Token         string              `json:"token"`
+	ExpiresAt     time.Time           `json:"expires_at"`
+	UserID        uuid.UUID           `json:"user_id"`
+	Role          string              `json:"role"`
+	Impersonation *auth.Impersonation `json:"impersonation,omitempty"`
+	// Set when login started a cookie session; sent back in the
+	// X-CSRF-Token header of state-changing requests
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
+
+// ImpersonationRequest represents a request to act as another user
+type ImpersonationRequest struct {
+	UserID          uuid.UUID `json:"user_id" binding:"required"`
+	Reason          string    `json:"reason" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes"`
+}
+
+// ImpersonationPolicyRequest sets whether an organization allows impersonation
+type ImpersonationPolicyRequest struct {
+	AllowImpersonation bool `json:"allow_impersonation"`
+}
+
+// authMiddleware verifies bearer tokens when present. Requests without a token
+// continue anonymously; routes that need an identity use requireRole.
+func (s *Server) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" || s.tokens == nil {
+			s.sessionAuth(c)
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			s.handleError(c, errors.New("authorization header must use the Bearer scheme"), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		claims, err := s.tokens.Parse(token)
+		if err != nil {
+			s.handleError(c, err, http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+		revoked, err := s.sessionRevoked(c.Request.Context(), claims)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+		if revoked {
+			s.handleError(c, errors.New("session was revoked"), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		if claims.Impersonated() {
+			c.Header("X-VoltEdge-Impersonated-By", claims.Impersonation.AdminID.String())
+		}
+
+		c.Next()
+	}
+}
+
+// auditMiddleware records mutating requests by authenticated users and every
+// request made with an impersonation token
+func (s *Server) auditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		// The forwarding replica already audited the request
+		if c.GetBool(internalRequestKey) {
+			return
+		}
+
+		claims := currentClaims(c)
+		if claims == nil || s.auditService == nil {
+			return
+		}
+		if c.Request.Method == http.MethodGet && !claims.Impersonated() {
+			return
+		}
+
+		entry := &database.AuditLog{
+			ActorID:        &claims.UserID,
+			ActorEmail:     claims.Email,
+			OrganizationID: claims.OrganizationID,
+			Action:         c.Request.Method + " " + c.FullPath(),
+			Method:         c.Request.Method,
+			Path:           requestPath(c),
+			StatusCode:     c.Writer.Status(),
+			ClientIP:       c.ClientIP(),
+		}
+
+		if claims.Impersonated() {
+			entry.Impersonated = true
+			entry.ImpersonatorID = &claims.Impersonation.AdminID
+			entry.Details = map[string]any{
+				"impersonator_email": claims.Impersonation.AdminEmail,
+				"reason":             claims.Impersonation.Reason,
+				"token_id":           claims.ID,
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"impersonator_id": claims.Impersonation.AdminID,
+				"acting_as":       claims.UserID,
+				"method":          c.Request.Method,
+				"path":            requestPath(c),
+				"status":          c.Writer.Status(),
+			}).Warn("IMPERSONATED ACTION")
+		}
+
+		s.auditService.Record(entry)
+	}
+}
+
+// requireRole rejects requests whose token does not carry the role
+func (s *Server) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := currentClaims(c)
+		if claims == nil {
+			s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+		if claims.Role != role || claims.Impersonated() {
+			s.handleError(c, errors.New("insufficient privileges"), http.StatusForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// currentClaims returns the verified claims of the request, if any
+func currentClaims(c *gin.Context) *auth.Claims {
+	value, ok := c.Get(claimsKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := value.(*auth.Claims)
+	return claims
+}
+
+// errInvalidCredentials is the answer to every refused login, whatever the
+// reason, so responses do not reveal which emails have accounts
+var errInvalidCredentials = errors.New("invalid email or password")
+
+// dummyPasswordHash is compared against when a login has no usable password
+// hash, so unknown and inactive accounts take as long to refuse as a wrong
+// password. It has the default cost like stored hashes.
+var dummyPasswordHash = []byte("$2a$10$o/.oWz3IKduLmQqpTLvVteGqy5CqXAPoMAHvciuhMeR9/NQvPO3wO")
+
+// login exchanges credentials for a token
+func (s *Server) login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.userService.GetUserByEmail(req.Email)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Every login pays for one comparison before anything can refuse it,
+	// locked accounts included. A match against the dummy hash never counts.
+	hash, stored := dummyPasswordHash, false
+	if user != nil && user.IsActive {
+		if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err == nil {
+			hash, stored = []byte(user.PasswordHash), true
+		}
+	}
+	passwordMatches := bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) == nil && stored
+
+	if user != nil && s.loginLocked(c, req.Email, user) {
+		return
+	}
+	if user == nil || !user.IsActive || !passwordMatches {
+		s.recordLogin(c, req.Email, user, database.LoginFailureInvalidCredentials)
+		s.handleError(c, errInvalidCredentials, http.StatusUnauthorized)
+		return
+	}
+
+	token, claims, err := s.tokens.Issue(identityOf(user))
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if err := s.startUserSession(c, claims); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	s.recordLogin(c, req.Email, user, "")
+
+	response := newTokenResponse(token, claims)
+	if s.config.Sessions.Enabled {
+		response.CSRFToken = s.setSessionCookies(c, token, claims)
+	}
+
+	s.handleSuccess(c, response, "Login successful")
+}
+
+// impersonateUser issues a time-boxed token acting as another user
+func (s *Server) impersonateUser(c *gin.Context) {
+	admin := currentClaims(c)
+
+	var req ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	target, err := s.userService.GetUser(req.UserID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if target == nil || !target.IsActive {
+		s.handleError(c, errors.New("user not found"), http.StatusNotFound)
+		return
+	}
+
+	if target.OrganizationID != nil {
+		organization, err := s.userService.GetOrganization(*target.OrganizationID)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+		if organization != nil && !organization.AllowsImpersonation() {
+			s.recordImpersonation(c, admin, target, req.Reason, "impersonation.denied", nil)
+			s.handleError(c, errors.New("the user's organization does not allow impersonation"), http.StatusForbidden)
+			return
+		}
+	}
+
+	ttl := time.Duration(req.DurationMinutes) * time.Minute
+	token, claims, err := s.tokens.IssueImpersonation(admin, identityOf(target), ttl, req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrImpersonateAdmin) || errors.Is(err, auth.ErrNotAdmin) {
+			status = http.StatusForbidden
+		}
+		s.handleError(c, err, status)
+		return
+	}
+
+	s.recordImpersonation(c, admin, target, req.Reason, "impersonation.started", claims)
+	s.handleSuccess(c, newTokenResponse(token, claims), "Impersonation token issued")
+}
+
+// recordImpersonation writes the audit entry for an impersonation attempt
+func (s *Server) recordImpersonation(c *gin.Context, admin *auth.Claims, target *database.User, reason, action string, claims *auth.Claims) {
+	details := map[string]any{
+		"target_user_id":    target.ID,
+		"target_user_email": target.Email,
+		"reason":            reason,
+	}
+	if claims != nil {
+		details["token_id"] = claims.ID
+		details["expires_at"] = claims.ExpiresAt.Time
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"admin_id":       admin.UserID,
+		"target_user_id": target.ID,
+		"reason":         reason,
+		"action":         action,
+	}).Warn("Impersonation requested")
+
+	if s.auditService == nil {
+		return
+	}
+	s.auditService.Record(&database.AuditLog{
+		ActorID:        &admin.UserID,
+		ActorEmail:     admin.Email,
+		OrganizationID: target.OrganizationID,
+		Action:         action,
+		Method:         c.Request.Method,
+		Path:           requestPath(c),
+		ClientIP:       c.ClientIP(),
+		Details:        details,
+	})
+}
+
+// listAuditLogs returns audit log entries, optionally only impersonated ones
+func (s *Server) listAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	var filter database.AuditFilter
+	if raw := c.Query("actor_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			s.handleError(c, errors.New("invalid actor_id"), http.StatusBadRequest)
+			return
+		}
+		filter.ActorID = &id
+	}
+	if raw := c.Query("impersonator_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			s.handleError(c, errors.New("invalid impersonator_id"), http.StatusBadRequest)
+			return
+		}
+		filter.ImpersonatorID = &id
+	}
+	if raw := c.Query("impersonated"); raw != "" {
+		impersonated, err := strconv.ParseBool(raw)
+		if err != nil {
+			s.handleError(c, errors.New("invalid impersonated flag"), http.StatusBadRequest)
+			return
+		}
+		filter.Impersonated = &impersonated
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	filter.From, filter.To = from, to
+
+	entries, total, err := s.auditService.ListAuditLogs(filter, limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	}, "Audit log retrieved successfully")
+}
+
+// updateImpersonationPolicy lets an organization opt out of support impersonation
+func (s *Server) updateImpersonationPolicy(c *gin.Context) {
+	organizationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid organization id"), http.StatusBadRequest)
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	organization, err := s.userService.GetOrganization(organizationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if organization == nil {
+		s.handleError(c, errors.New("organization not found"), http.StatusNotFound)
+		return
+	}
+	// Only the organization owner may change its policy, never through impersonation
+	if organization.OwnerID != claims.UserID || claims.Impersonated() {
+		s.handleError(c, errors.New("only the organization owner can change the impersonation policy"), http.StatusForbidden)
+		return
+	}
+
+	var req ImpersonationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.userService.UpdateOrganizationSetting(organizationID, database.ImpersonationSetting, req.AllowImpersonation); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, req, "Impersonation policy updated")
+}
+
+// identityOf converts a user into a token identity
+func identityOf(user *database.User) auth.Identity {
+	return auth.Identity{
+		UserID:         user.ID,
+		Email:          user.Email,
+		Role:           user.Role,
+		OrganizationID: user.OrganizationID,
+	}
+}
+
+func newTokenResponse(token string, claims *auth.Claims) TokenResponse {
+	return TokenResponse{
+		Token:         token,
+		ExpiresAt:     claims.ExpiresAt.Time,
+		UserID:        claims.UserID,
+		Role:          claims.Role,
+		Impersonation: claims.Impersonation,
+	}
+}
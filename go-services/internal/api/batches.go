@@ -0,0 +1,130 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// maxBatchInstances bounds the size of a single Monte Carlo batch
+const maxBatchInstances = 10000
+
+// CreateBatchRequest represents a request to run a Monte Carlo batch
+type CreateBatchRequest struct {
+	Name          string                 `json:"name" binding:"required"`
+	Config        SimulationConfig       `json:"config" binding:"required"`
+	Parameters    BatchParametersRequest `json:"parameters"`
+	Instances     int                    `json:"instances" binding:"required"`
+	MaxConcurrent int                    `json:"max_concurrent"`
+	Tags          []string               `json:"tags"`
+}
+
+// BatchParametersRequest describes the sampled distributions of a batch
+type BatchParametersRequest struct {
+	LoadStdDev                    float64            `json:"load_std_dev"`
+	PlantFailureProbability       float64            `json:"plant_failure_probability"`
+	LineFailureProbability        float64            `json:"line_failure_probability"`
+	ComponentFailureProbabilities map[string]float64 `json:"component_failure_probabilities"`
+	FrequencyToleranceHz          float64            `json:"frequency_tolerance_hz"`
+	Seed                          uint64             `json:"seed"`
+}
+
+// createBatch handles Monte Carlo batch creation requests
+func (s *Server) createBatch(c *gin.Context) {
+	var req CreateBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.Instances < 1 || req.Instances > maxBatchInstances {
+		s.handleError(c, errors.New("instances must be between 1 and 10000"), http.StatusBadRequest)
+		return
+	}
+
+	tags, ok := s.normalizeTags(c, req.Tags)
+	if !ok {
+		return
+	}
+
+	if err := req.Config.ValidateTopology(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if s.engines != nil {
+		if _, err := s.engines.Place(req.Config.Requirements(), ""); err != nil {
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	params := orchestration.BatchParameters{
+		LoadStdDev:                    req.Parameters.LoadStdDev,
+		PlantFailureProbability:       req.Parameters.PlantFailureProbability,
+		LineFailureProbability:        req.Parameters.LineFailureProbability,
+		ComponentFailureProbabilities: req.Parameters.ComponentFailureProbabilities,
+		FrequencyToleranceHz:          req.Parameters.FrequencyToleranceHz,
+		Seed:                          req.Parameters.Seed,
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"name":      req.Name,
+		"instances": req.Instances,
+	}).Info("Creating Monte Carlo batch")
+
+	if !s.requireSimulationQuota(c, requesterOrganization(c)) {
+		return
+	}
+
+	batch, err := s.orchestrator.CreateBatch(req.Name, req.Config, params, req.Instances, req.MaxConcurrent, tags)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	s.syncTags(database.TagResourceBatch, batch.ID, batch.Tags)
+
+	s.handleSuccess(c, batch, "Batch created successfully")
+}
+
+// listBatches returns all Monte Carlo batches
+func (s *Server) listBatches(c *gin.Context) {
+	s.handleSuccess(c, s.orchestrator.ListBatches(), "Batches retrieved successfully")
+}
+
+// getBatch returns a batch with its instances and aggregated report
+func (s *Server) getBatch(c *gin.Context) {
+	batch, err := s.orchestrator.GetBatch(c.Param("id"))
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, batch, "Batch retrieved successfully")
+}
+
+// cancelBatch stops a running batch
+func (s *Server) cancelBatch(c *gin.Context) {
+	id := c.Param("id")
+	if err := s.orchestrator.CancelBatch(id); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, orchestration.ErrBatchNotFound) {
+			status = http.StatusNotFound
+		}
+		s.handleError(c, err, status)
+		return
+	}
+
+	batch, err := s.orchestrator.GetBatch(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, batch, "Batch cancelled successfully")
+}
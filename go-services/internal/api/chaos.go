@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/chaos"
+)
+
+// ChaosExperimentRequest starts injecting a fault
+type ChaosExperimentRequest struct {
+	Probability float64 `json:"probability" binding:"required"`
+	// db_delay only
+	DelayMS int64 `json:"delay_ms"`
+	// grpc_drop only; empty drops every engine call
+	Methods []string `json:"methods"`
+	// Zero uses the configured default
+	DurationSeconds int `json:"duration_seconds" binding:"gte=0"`
+}
+
+// ChaosResponse reports whether chaos experiments are enabled and which are running
+type ChaosResponse struct {
+	Enabled     bool               `json:"enabled"`
+	Experiments []chaos.Experiment `json:"experiments"`
+}
+
+// getChaos lists the running chaos experiments
+func (s *Server) getChaos(c *gin.Context) {
+	if s.chaos == nil {
+		s.handleError(c, errors.New("chaos experiments are not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	s.handleSuccess(c, ChaosResponse{
+		Enabled:     s.chaos.Enabled(),
+		Experiments: s.chaos.Experiments(),
+	}, "Chaos experiments retrieved successfully")
+}
+
+// startChaosExperiment starts injecting the fault named in the path,
+// replacing any experiment already running for it
+func (s *Server) startChaosExperiment(c *gin.Context) {
+	if s.chaos == nil {
+		s.handleError(c, errors.New("chaos experiments are not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	var req ChaosExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	experiment := chaos.Experiment{
+		Fault:       c.Param("fault"),
+		Probability: req.Probability,
+		DelayMS:     req.DelayMS,
+		Methods:     req.Methods,
+	}
+	if claims := currentClaims(c); claims != nil {
+		experiment.StartedBy = claims.Email
+	}
+
+	started, err := s.chaos.Start(experiment, time.Duration(req.DurationSeconds)*time.Second)
+	if err != nil {
+		switch {
+		case errors.Is(err, chaos.ErrDisabled):
+			s.handleError(c, err, http.StatusForbidden)
+		case errors.Is(err, chaos.ErrInvalidExperiment):
+			s.handleError(c, err, http.StatusBadRequest)
+		default:
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, started, "Chaos experiment started")
+}
+
+// stopChaosExperiment stops the experiment for the fault named in the path
+func (s *Server) stopChaosExperiment(c *gin.Context) {
+	if s.chaos == nil {
+		s.handleError(c, errors.New("chaos experiments are not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	if !s.chaos.Stop(c.Param("fault")) {
+		s.handleError(c, errors.New("no chaos experiment is running for this fault"), http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Chaos experiment stopped")
+}
+
+// stopChaosExperiments stops every running chaos experiment
+func (s *Server) stopChaosExperiments(c *gin.Context) {
+	if s.chaos == nil {
+		s.handleError(c, errors.New("chaos experiments are not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{"stopped": s.chaos.StopAll()}, "Chaos experiments stopped")
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/cluster"
+)
+
+// internalRequestKey marks requests forwarded by another replica
+const internalRequestKey = "internal_request"
+
+// forwardToOwner proxies a simulation request to the replica that owns the
+// simulation, reporting whether the request was handled. Forwarded requests
+// are never forwarded again, so a stale ownership view cannot loop.
+func (s *Server) forwardToOwner(c *gin.Context, simulationID string) bool {
+	if s.cluster == nil || c.GetBool(internalRequestKey) {
+		return false
+	}
+
+	owner, err := s.cluster.Owner(simulationID)
+	if err != nil {
+		if errors.Is(err, cluster.ErrOwnerUnavailable) {
+			c.Header("Retry-After", "5")
+			s.handleError(c, err, http.StatusServiceUnavailable)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return true
+	}
+	if owner == nil {
+		return false
+	}
+
+	path := "/internal/v1" + strings.TrimPrefix(c.Request.URL.Path, "/api/v1")
+	s.cluster.Forward(c.Writer, c.Request, owner, path)
+	return true
+}
+
+// getCluster returns this replica's view of the cluster
+func (s *Server) getCluster(c *gin.Context) {
+	if s.cluster == nil {
+		s.handleError(c, errors.New("clustering is not enabled"), http.StatusServiceUnavailable)
+		return
+	}
+
+	s.handleSuccess(c, s.cluster.Status(), "Cluster status retrieved successfully")
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/notifications"
+	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/internal/realtime"
+)
+
+// auditActionTopologyChanged is the audit action of a topology change made
+// during a run
+const auditActionTopologyChanged = "simulation.topology_changed"
+
+// modifySimulationComponents adds power plants and transmission lines to a
+// running simulation and removes them from it
+func (s *Server) modifySimulationComponents(c *gin.Context) {
+	id := c.Param("id")
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	var req TopologyChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	simulation, err := s.orchestrator.ModifyTopology(c.Request.Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestration.ErrSimulationNotFound):
+			s.handleError(c, err, http.StatusNotFound)
+		case errors.Is(err, orchestration.ErrInvalidTopology):
+			s.handleError(c, err, http.StatusBadRequest)
+		case errors.Is(err, engine.ErrUnsupportedConfig):
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+		case errors.Is(err, orchestration.ErrSimulationNotActive), errors.Is(err, orchestration.ErrVersionConflict):
+			s.handleError(c, err, http.StatusConflict)
+		case errors.Is(err, orchestration.ErrNoEngineController):
+			s.handleError(c, err, http.StatusServiceUnavailable)
+		default:
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if s.cluster != nil {
+		if err := s.cluster.SaveSpec(simulation); err != nil {
+			logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to save simulation spec")
+		}
+	}
+
+	s.auditTopologyChange(c, id, req)
+	s.publishSimulationEvent(notifications.EventTopologyChanged, id, topologyChangeMessage(req))
+	s.publishTopology(simulation)
+
+	setETag(c, simulation.Version)
+	s.handleSuccess(c, newSimulationResponse(simulation), "Simulation topology modified successfully")
+}
+
+// auditTopologyChange records which components a topology change added and
+// removed. Anonymous changes are recorded without an actor.
+func (s *Server) auditTopologyChange(c *gin.Context, simulationID string, change orchestration.TopologyChange) {
+	if s.auditService == nil {
+		return
+	}
+
+	addedPlants := make([]string, len(change.AddPowerPlants))
+	for i, plant := range change.AddPowerPlants {
+		addedPlants[i] = plant.ID
+	}
+	addedLines := make([]string, len(change.AddTransmissionLines))
+	for i, line := range change.AddTransmissionLines {
+		addedLines[i] = line.ID
+	}
+
+	entry := &database.AuditLog{
+		Action:     auditActionTopologyChanged,
+		Method:     c.Request.Method,
+		Path:       requestPath(c),
+		StatusCode: http.StatusOK,
+		ClientIP:   c.ClientIP(),
+		Details: map[string]any{
+			"simulation_id":              simulationID,
+			"added_power_plants":         addedPlants,
+			"removed_power_plants":       change.RemovePowerPlants,
+			"added_transmission_lines":   addedLines,
+			"removed_transmission_lines": change.RemoveTransmissionLines,
+		},
+	}
+	if claims := currentClaims(c); claims != nil {
+		entry.ActorID = &claims.UserID
+		entry.ActorEmail = claims.Email
+		entry.OrganizationID = claims.OrganizationID
+	}
+	s.auditService.Record(entry)
+}
+
+// publishTopology pushes a simulation's topology to its topology topic
+// after its config changed during a run
+func (s *Server) publishTopology(simulation *orchestration.Simulation) {
+	s.hub.Publish(realtime.Message{
+		Type:  realtime.MessageTopologySnapshot,
+		Topic: realtime.TopologyTopic(simulation.ID),
+		Data:  simulation.Config.BuildTopology(),
+	})
+}
+
+// topologyChangeMessage summarizes a topology change for notifications
+func topologyChangeMessage(change orchestration.TopologyChange) string {
+	return fmt.Sprintf("Topology changed: %d power plants added, %d removed; %d transmission lines added, %d removed",
+		len(change.AddPowerPlants), len(change.RemovePowerPlants),
+		len(change.AddTransmissionLines), len(change.RemoveTransmissionLines))
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// Supported response content codings, in order of preference
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	}}
+	brotliWriters = sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}}
+)
+
+// compressionMiddleware compresses responses with brotli or gzip, whichever
+// the client prefers. Responses under the minimum size, responses that are
+// already encoded or not worth compressing, and streaming routes are sent
+// as they are.
+func (s *Server) compressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.routeClass(c) == routeStream || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        s.config.Compression.MinSizeBytes,
+		}
+		c.Writer = writer
+		c.Next()
+
+		// gin writes its own 404/405 bodies after the middleware chain returns
+		writer.finish()
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+// negotiateEncoding picks the supported coding with the highest quality value
+// in an Accept-Encoding header, preferring brotli on ties
+func negotiateEncoding(header string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{encodingBrotli, encodingGzip} {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// compressWriter buffers a response until it reaches the minimum size, then
+// decides whether to compress it. Flushing ends buffering early so streamed
+// exports are compressed as they are written.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	buf         []byte
+	encoder     io.WriteCloser
+	passthrough bool
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.encoder != nil:
+		return w.encoder.Write(data)
+	case w.passthrough:
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+// Written reports buffered output as written so later handlers do not write a second response
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Flush() {
+	if w.encoder == nil && !w.passthrough {
+		w.start()
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start commits to compressing or passing through the response and writes out the buffer
+func (w *compressWriter) start() error {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || !compressible(header.Get("Content-Type")) ||
+		w.Status() == http.StatusNoContent || w.Status() == http.StatusNotModified {
+		w.passthrough = true
+	} else {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.encoder = w.newEncoder()
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// finish writes out a response that stayed under the minimum size and closes the encoder
+func (w *compressWriter) finish() {
+	if w.encoder == nil {
+		if len(w.buf) > 0 {
+			w.passthrough = true
+			w.ResponseWriter.Write(w.buf)
+			w.buf = nil
+		}
+		return
+	}
+
+	w.encoder.Close()
+	switch encoder := w.encoder.(type) {
+	case *gzip.Writer:
+		gzipWriters.Put(encoder)
+	case *brotli.Writer:
+		brotliWriters.Put(encoder)
+	}
+}
+
+func (w *compressWriter) newEncoder() io.WriteCloser {
+	if w.encoding == encodingBrotli {
+		encoder := brotliWriters.Get().(*brotli.Writer)
+		encoder.Reset(w.ResponseWriter)
+		return encoder
+	}
+	encoder := gzipWriters.Get().(*gzip.Writer)
+	encoder.Reset(w.ResponseWriter)
+	return encoder
+}
+
+// compressible reports whether a content type benefits from compression
+func compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+
+	switch {
+	case mediaType == "":
+		return true
+	case mediaType == "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return false
+	}
+
+	switch mediaType {
+	case "application/zip", "application/gzip", "application/x-gzip", "application/octet-stream", "application/pdf":
+		return false
+	}
+	return true
+}
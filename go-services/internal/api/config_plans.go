@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/orchestration"
+)
+
+// PlanConfigRequest is the config a running simulation should change to
+type PlanConfigRequest struct {
+	Config SimulationConfig `json:"config" binding:"required"`
+}
+
+// ApplyConfigPlanRequest names the plan to apply
+type ApplyConfigPlanRequest struct {
+	PlanID string `json:"plan_id" binding:"required"`
+}
+
+// planSimulationConfig computes the change set and estimated impact of
+// changing a running simulation's config, without changing anything
+func (s *Server) planSimulationConfig(c *gin.Context) {
+	id := c.Param("id")
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	var req PlanConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Config.ValidateTopology(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, orchestration.ErrSimulationNotFound) {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// The new config has to run on the engine the simulation runs on
+	if s.engines != nil && simulation.Engine != "" {
+		if _, err := s.engines.Place(req.Config.Requirements(), simulation.Engine); err != nil {
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	plan, err := s.orchestrator.PlanConfigChange(c.Request.Context(), id, req.Config)
+	if err != nil {
+		switch {
+		case errors.Is(err, orchestration.ErrSimulationNotFound):
+			s.handleError(c, err, http.StatusNotFound)
+		case errors.Is(err, orchestration.ErrSimulationNotActive):
+			s.handleError(c, err, http.StatusConflict)
+		default:
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, plan, "Config change planned successfully")
+}
+
+// applySimulationConfig applies a plan made by planSimulationConfig to the
+// running simulation on its engine
+func (s *Server) applySimulationConfig(c *gin.Context) {
+	id := c.Param("id")
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	var req ApplyConfigPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	simulation, err := s.orchestrator.ApplyConfigPlan(c.Request.Context(), id, req.PlanID)
+	if err != nil {
+		var stale *orchestration.StalePlanError
+		switch {
+		case errors.Is(err, orchestration.ErrSimulationNotFound), errors.Is(err, orchestration.ErrPlanNotFound):
+			s.handleError(c, err, http.StatusNotFound)
+		case errors.As(err, &stale):
+			s.handleVersionConflict(c, err, stale.Version)
+		case errors.Is(err, orchestration.ErrSimulationNotActive):
+			s.handleError(c, err, http.StatusConflict)
+		case errors.Is(err, orchestration.ErrNoEngineController):
+			s.handleError(c, err, http.StatusServiceUnavailable)
+		default:
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if s.cluster != nil {
+		if err := s.cluster.SaveSpec(simulation); err != nil {
+			logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to save simulation spec")
+		}
+	}
+
+	s.publishTopology(simulation)
+
+	setETag(c, simulation.Version)
+	s.handleSuccess(c, newSimulationResponse(simulation), "Config plan applied successfully")
+}
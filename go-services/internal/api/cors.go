@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/config"
+)
+
+// corsRoute is a route group's CORS handler
+type corsRoute struct {
+	prefix  string
+	handler gin.HandlerFunc
+}
+
+// corsMiddleware applies the CORS policy of the route group with the
+// longest path prefix matching the request, or the default policy
+func (s *Server) corsMiddleware() gin.HandlerFunc {
+	defaults := s.config.CORS.Default
+	if len(defaults.AllowOrigins) == 0 {
+		defaults.AllowOrigins = s.config.CORSOrigins
+	}
+	fallback := newCORSHandler(defaults)
+
+	routes := make([]corsRoute, 0, len(s.config.CORS.Routes))
+	for _, route := range s.config.CORS.Routes {
+		policy := route.CORSPolicy
+		if len(policy.AllowMethods) == 0 {
+			policy.AllowMethods = defaults.AllowMethods
+		}
+		if len(policy.AllowHeaders) == 0 {
+			policy.AllowHeaders = defaults.AllowHeaders
+		}
+		if len(policy.ExposeHeaders) == 0 {
+			policy.ExposeHeaders = defaults.ExposeHeaders
+		}
+		routes = append(routes, corsRoute{
+			prefix:  strings.TrimSuffix(route.PathPrefix, "/"),
+			handler: newCORSHandler(policy),
+		})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, route := range routes {
+			if path == route.prefix || strings.HasPrefix(path, route.prefix+"/") {
+				route.handler(c)
+				return
+			}
+		}
+		fallback(c)
+	}
+}
+
+// newCORSHandler creates the handler of a policy. Only a policy allowing
+// any origin answers with *; otherwise the request's origin is echoed when
+// it is allowed, which credentialed requests require.
+func newCORSHandler(policy config.CORSPolicy) gin.HandlerFunc {
+	cfg := cors.Config{
+		AllowMethods:     policy.AllowMethods,
+		AllowHeaders:     policy.AllowHeaders,
+		ExposeHeaders:    policy.ExposeHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           policy.MaxAge,
+	}
+	if slices.Contains(policy.AllowOrigins, "*") {
+		cfg.AllowAllOrigins = true
+	} else {
+		cfg.AllowOriginFunc = newOriginMatcher(policy.AllowOrigins).allows
+	}
+	return cors.New(cfg)
+}
+
+// originPattern is an allowed origin; with subdomains set it matches any
+// subdomain of host but not host itself
+type originPattern struct {
+	scheme     string
+	host       string
+	port       string
+	subdomains bool
+}
+
+// originMatcher matches request origins against allowed ones
+type originMatcher []originPattern
+
+// newOriginMatcher parses allowed origins; config validation rejects the
+// ones that cannot be parsed, which are skipped here
+func newOriginMatcher(origins []string) originMatcher {
+	matcher := make(originMatcher, 0, len(origins))
+	for _, origin := range origins {
+		subdomains := strings.Contains(origin, "://*.")
+		parsed, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		matcher = append(matcher, originPattern{
+			scheme:     strings.ToLower(parsed.Scheme),
+			host:       strings.ToLower(parsed.Hostname()),
+			port:       parsed.Port(),
+			subdomains: subdomains,
+		})
+	}
+	return matcher
+}
+
+// allows reports whether a request's Origin header is allowed
+func (m originMatcher) allows(origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" || parsed.Path != "" {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+
+	for _, pattern := range m {
+		if pattern.scheme != scheme || pattern.port != port {
+			continue
+		}
+		if pattern.subdomains {
+			if strings.HasSuffix(host, "."+pattern.host) {
+				return true
+			}
+		} else if host == pattern.host {
+			return true
+		}
+	}
+	return false
+}
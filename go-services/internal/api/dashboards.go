@@ -0,0 +1,376 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/database"
+)
+
+// DashboardRequest represents a request to create or update a dashboard. The
+// document holds the selected simulations, metrics and chart layout as the
+// UI defines them.
+type DashboardRequest struct {
+	Name        string         `json:"name" binding:"required,max=200"`
+	Description string         `json:"description" binding:"max=2000"`
+	Visibility  string         `json:"visibility" binding:"omitempty,oneof=private organization public"`
+	Document    map[string]any `json:"document" binding:"required"`
+}
+
+// DashboardSharesRequest replaces the users a dashboard is shared with
+type DashboardSharesRequest struct {
+	Shares []DashboardShareRequest `json:"shares" binding:"dive"`
+}
+
+// DashboardShareRequest grants a user view or edit access
+type DashboardShareRequest struct {
+	UserID     uuid.UUID `json:"user_id" binding:"required"`
+	Permission string    `json:"permission" binding:"required,oneof=view edit"`
+}
+
+// createDashboard handles dashboard creation requests. The caller owns the
+// new dashboard, which is private unless another visibility is given.
+func (s *Server) createDashboard(c *gin.Context) {
+	claims, ok := s.requireDashboardUser(c)
+	if !ok {
+		return
+	}
+
+	var req DashboardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	if req.Visibility == database.DashboardOrganization && claims.OrganizationID == nil {
+		s.handleError(c, errors.New("organization visibility requires an organization"), http.StatusBadRequest)
+		return
+	}
+
+	dashboard := &database.Dashboard{
+		OwnerID:        claims.UserID,
+		OrganizationID: claims.OrganizationID,
+		Name:           req.Name,
+		Description:    req.Description,
+		Visibility:     req.Visibility,
+		Document:       req.Document,
+	}
+	if dashboard.Visibility == "" {
+		dashboard.Visibility = database.DashboardPrivate
+	}
+
+	if err := s.dashboardService.CreateDashboard(dashboard); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, dashboard.Version)
+	s.handleSuccess(c, dashboard, "Dashboard created successfully")
+}
+
+// listDashboards returns the dashboards the caller can see
+func (s *Server) listDashboards(c *gin.Context) {
+	claims, ok := s.requireDashboardUser(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	dashboards, total, err := s.dashboardService.ListDashboards(claims.UserID, claims.OrganizationID, limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{
+		"dashboards": dashboards,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	}, "Dashboards retrieved successfully")
+}
+
+// getDashboard handles single dashboard retrieval requests
+func (s *Server) getDashboard(c *gin.Context) {
+	dashboard, _, ok := s.loadDashboard(c, false)
+	if !ok {
+		return
+	}
+
+	setETag(c, dashboard.Version)
+	s.handleSuccess(c, dashboard, "Dashboard retrieved successfully")
+}
+
+// updateDashboard saves a new revision of a dashboard. The If-Match header
+// must carry the version being updated. Only the owner may change the
+// visibility; users the dashboard is shared with for editing may change
+// everything else.
+func (s *Server) updateDashboard(c *gin.Context) {
+	expectedVersion, ok := s.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	dashboard, claims, ok := s.loadDashboard(c, true)
+	if !ok {
+		return
+	}
+
+	var req DashboardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.Visibility != "" && req.Visibility != dashboard.Visibility {
+		if !ownsDashboard(claims, dashboard) {
+			s.handleError(c, errors.New("only the owner can change a dashboard's visibility"), http.StatusForbidden)
+			return
+		}
+		if req.Visibility == database.DashboardOrganization && dashboard.OrganizationID == nil {
+			s.handleError(c, errors.New("organization visibility requires an organization"), http.StatusBadRequest)
+			return
+		}
+		dashboard.Visibility = req.Visibility
+	}
+
+	dashboard.Name = req.Name
+	dashboard.Description = req.Description
+	dashboard.Document = req.Document
+	s.saveDashboard(c, dashboard, expectedVersion, claims.UserID, "Dashboard updated successfully")
+}
+
+// deleteDashboard handles dashboard deletion requests from its owner
+func (s *Server) deleteDashboard(c *gin.Context) {
+	dashboard, claims, ok := s.loadDashboard(c, false)
+	if !ok {
+		return
+	}
+	if !ownsDashboard(claims, dashboard) {
+		s.handleError(c, errors.New("only the owner can delete a dashboard"), http.StatusForbidden)
+		return
+	}
+
+	if err := s.dashboardService.DeleteDashboard(dashboard.ID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Dashboard deleted successfully")
+}
+
+// setDashboardShares replaces the users a dashboard is shared with
+func (s *Server) setDashboardShares(c *gin.Context) {
+	dashboard, claims, ok := s.loadDashboard(c, false)
+	if !ok {
+		return
+	}
+	if !ownsDashboard(claims, dashboard) {
+		s.handleError(c, errors.New("only the owner can share a dashboard"), http.StatusForbidden)
+		return
+	}
+
+	var req DashboardSharesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	shares := make([]database.DashboardShare, 0, len(req.Shares))
+	seen := make(map[uuid.UUID]bool, len(req.Shares))
+	for _, share := range req.Shares {
+		if share.UserID == dashboard.OwnerID {
+			s.handleError(c, errors.New("a dashboard cannot be shared with its owner"), http.StatusBadRequest)
+			return
+		}
+		if seen[share.UserID] {
+			s.handleError(c, errors.New("each user can only be listed once"), http.StatusBadRequest)
+			return
+		}
+		seen[share.UserID] = true
+		shares = append(shares, database.DashboardShare{UserID: share.UserID, Permission: share.Permission})
+	}
+
+	if err := s.dashboardService.SetDashboardShares(dashboard.ID, shares); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	dashboard.Shares = shares
+
+	setETag(c, dashboard.Version)
+	s.handleSuccess(c, dashboard, "Dashboard shares updated successfully")
+}
+
+// listDashboardRevisions returns a dashboard's revisions, newest first
+func (s *Server) listDashboardRevisions(c *gin.Context) {
+	dashboard, _, ok := s.loadDashboard(c, false)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	revisions, total, err := s.dashboardService.ListDashboardRevisions(dashboard.ID, limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{
+		"revisions": revisions,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	}, "Dashboard revisions retrieved successfully")
+}
+
+// getDashboardRevision returns one revision of a dashboard with its document
+func (s *Server) getDashboardRevision(c *gin.Context) {
+	dashboard, _, ok := s.loadDashboard(c, false)
+	if !ok {
+		return
+	}
+
+	revision, ok := s.loadDashboardRevision(c, dashboard)
+	if !ok {
+		return
+	}
+
+	s.handleSuccess(c, revision, "Dashboard revision retrieved successfully")
+}
+
+// restoreDashboardRevision saves an earlier revision's name, description and
+// document as a new revision. The If-Match header must carry the current version.
+func (s *Server) restoreDashboardRevision(c *gin.Context) {
+	expectedVersion, ok := s.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	dashboard, claims, ok := s.loadDashboard(c, true)
+	if !ok {
+		return
+	}
+
+	revision, ok := s.loadDashboardRevision(c, dashboard)
+	if !ok {
+		return
+	}
+
+	dashboard.Name = revision.Name
+	dashboard.Description = revision.Description
+	dashboard.Document = revision.Document
+	s.saveDashboard(c, dashboard, expectedVersion, claims.UserID, "Dashboard revision restored successfully")
+}
+
+// saveDashboard stores an edited dashboard and writes the response
+func (s *Server) saveDashboard(c *gin.Context, dashboard *database.Dashboard, expectedVersion int64, editorID uuid.UUID, message string) {
+	if dashboard.Version != expectedVersion {
+		s.handleVersionConflict(c, database.ErrVersionConflict, dashboard.Version)
+		return
+	}
+
+	if err := s.dashboardService.UpdateDashboard(dashboard, expectedVersion, editorID); err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			current, getErr := s.dashboardService.GetDashboard(dashboard.ID)
+			if getErr != nil || current == nil {
+				s.handleError(c, err, http.StatusConflict)
+				return
+			}
+			s.handleVersionConflict(c, err, current.Version)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, dashboard.Version)
+	s.handleSuccess(c, dashboard, message)
+}
+
+// loadDashboard fetches the dashboard named by the id path parameter if the
+// caller may view it, or edit it when edit is set. It writes the error
+// response itself. Dashboards the caller cannot see are reported as missing.
+func (s *Server) loadDashboard(c *gin.Context, edit bool) (*database.Dashboard, *auth.Claims, bool) {
+	claims, ok := s.requireDashboardUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid dashboard id"), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	dashboard, err := s.dashboardService.GetDashboard(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if dashboard == nil || !(claims.IsAdmin() || dashboard.CanView(claims.UserID, claims.OrganizationID)) {
+		s.handleError(c, errors.New("dashboard not found"), http.StatusNotFound)
+		return nil, nil, false
+	}
+	if edit && !(claims.IsAdmin() || dashboard.CanEdit(claims.UserID)) {
+		s.handleError(c, errors.New("dashboard is not shared with you for editing"), http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	return dashboard, claims, true
+}
+
+// loadDashboardRevision fetches the revision named by the version path
+// parameter, writing the error response itself
+func (s *Server) loadDashboardRevision(c *gin.Context, dashboard *database.Dashboard) (*database.DashboardRevision, bool) {
+	version, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	if err != nil || version < 1 {
+		s.handleError(c, errors.New("invalid revision version"), http.StatusBadRequest)
+		return nil, false
+	}
+
+	revision, err := s.dashboardService.GetDashboardRevision(dashboard.ID, version)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	if revision == nil {
+		s.handleError(c, errors.New("dashboard revision not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return revision, true
+}
+
+// requireDashboardUser returns the caller's claims; dashboards belong to users
+func (s *Server) requireDashboardUser(c *gin.Context) (*auth.Claims, bool) {
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return nil, false
+	}
+	return claims, true
+}
+
+func ownsDashboard(claims *auth.Claims, dashboard *database.Dashboard) bool {
+	return claims.IsAdmin() || dashboard.OwnerID == claims.UserID
+}
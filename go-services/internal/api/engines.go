@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/engine"
+)
+
+// listEngines returns registered simulation engines with their capabilities and load
+func (s *Server) listEngines(c *gin.Context) {
+	logrus.Debug("Listing simulation engines")
+
+	engines := []engine.Status{}
+	if s.engines != nil {
+		engines = s.engines.List()
+	}
+
+	s.handleSuccess(c, engines, "Engines retrieved successfully")
+}
+
+// getEngine returns a single registered simulation engine
+func (s *Server) getEngine(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" || s.engines == nil {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	status, err := s.engines.Get(name)
+	if err != nil {
+		if errors.Is(err, engine.ErrEngineNotFound) {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, status, "Engine retrieved successfully")
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/observability"
+)
+
+// reportError sends a failed request's error to error tracking with the
+// route, simulation and user it concerns
+func reportError(c *gin.Context, err error, statusCode int, level string) {
+	route := c.FullPath()
+	tags := map[string]string{
+		"route":  route,
+		"status": strconv.Itoa(statusCode),
+	}
+	if id := c.Param("simulation_id"); id != "" {
+		tags["simulation_id"] = id
+	} else if strings.Contains(route, "/simulations/:id") {
+		tags["simulation_id"] = c.Param("id")
+	}
+	if claims := currentClaims(c); claims != nil {
+		tags["user_id"] = claims.UserID.String()
+	}
+	if service, ok := c.Get(serviceKey); ok {
+		tags["service"] = fmt.Sprint(service)
+	}
+
+	observability.ReportError(observability.ErrorEvent{
+		Err:    err,
+		Level:  level,
+		Source: "http",
+		Tags:   tags,
+		Request: &observability.ErrorRequest{
+			Method:    c.Request.Method,
+			URL:       redactPath(c.Request.URL.RequestURI()),
+			UserAgent: c.Request.UserAgent(),
+		},
+	})
+}
+
+// recoverPanic answers a request whose handler panicked with a 500 and
+// reports the panic
+func recoverPanic(c *gin.Context, value any) {
+	reportError(c, fmt.Errorf("handler panicked: %v", value), http.StatusInternalServerError, observability.ErrorLevelFatal)
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
@@ -0,0 +1,326 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	defaultEventPageSize = 100
+	maxEventPageSize     = 1000
+	exportPageSize       = 1000
+)
+
+// EventPage is a cursor-paginated page of fault events or alerts
+type EventPage struct {
+	Events     any    `json:"events"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// EventCounts summarizes matching events by severity
+type EventCounts struct {
+	Total      int64                    `json:"total"`
+	BySeverity []database.SeverityCount `json:"by_severity"`
+}
+
+// listFaultEvents returns a filtered page of fault events for a simulation
+func (s *Server) listFaultEvents(c *gin.Context) {
+	simulationID, filter, ok := s.parseEventQuery(c)
+	if !ok {
+		return
+	}
+	cursor, limit, ok := s.parseEventPage(c)
+	if !ok {
+		return
+	}
+
+	events, next, err := s.simulationService.ListFaultEvents(c.Request.Context(), simulationID, filter, cursor, limit)
+	if err != nil {
+		s.handleError(c, err, eventErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, newEventPage(events, len(events), next), "Fault events retrieved successfully")
+}
+
+// countFaultEvents returns matching fault event counts by severity
+func (s *Server) countFaultEvents(c *gin.Context) {
+	simulationID, filter, ok := s.parseEventQuery(c)
+	if !ok {
+		return
+	}
+
+	counts, err := s.simulationService.CountFaultEventsBySeverity(c.Request.Context(), simulationID, filter)
+	if err != nil {
+		s.handleError(c, err, eventErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, newEventCounts(counts), "Fault event counts retrieved successfully")
+}
+
+// exportFaultEvents streams every matching fault event as CSV or NDJSON
+func (s *Server) exportFaultEvents(c *gin.Context) {
+	simulationID, filter, ok := s.parseEventQuery(c)
+	if !ok {
+		return
+	}
+
+	header := []string{"id", "timestamp", "fault_type", "severity", "component_id", "component_type", "description", "resolved_at"}
+	row := func(event database.FaultEvent) []string {
+		return []string{
+			event.ID.String(),
+			event.Timestamp.UTC().Format(time.RFC3339Nano),
+			event.FaultType,
+			event.Severity,
+			strconv.Itoa(event.ComponentID),
+			event.ComponentType,
+			event.Description,
+			formatOptionalTime(event.ResolvedAt),
+		}
+	}
+	fetch := func(cursor *database.EventCursor) ([]database.FaultEvent, *database.EventCursor, error) {
+		return s.simulationService.ListFaultEvents(c.Request.Context(), simulationID, filter, cursor, exportPageSize)
+	}
+
+	streamEvents(s, c, "faults-"+simulationID.String(), header, row, fetch)
+}
+
+// listAlerts returns a filtered page of alerts for a simulation
+func (s *Server) listAlerts(c *gin.Context) {
+	simulationID, filter, ok := s.parseEventQuery(c)
+	if !ok {
+		return
+	}
+	cursor, limit, ok := s.parseEventPage(c)
+	if !ok {
+		return
+	}
+
+	alerts, next, err := s.simulationService.ListAlerts(c.Request.Context(), simulationID, filter, cursor, limit)
+	if err != nil {
+		s.handleError(c, err, eventErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, newEventPage(alerts, len(alerts), next), "Alerts retrieved successfully")
+}
+
+// countAlerts returns matching alert counts by severity
+func (s *Server) countAlerts(c *gin.Context) {
+	simulationID, filter, ok := s.parseEventQuery(c)
+	if !ok {
+		return
+	}
+
+	counts, err := s.simulationService.CountAlertsBySeverity(c.Request.Context(), simulationID, filter)
+	if err != nil {
+		s.handleError(c, err, eventErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, newEventCounts(counts), "Alert counts retrieved successfully")
+}
+
+// exportAlerts streams every matching alert as CSV or NDJSON
+func (s *Server) exportAlerts(c *gin.Context) {
+	simulationID, filter, ok := s.parseEventQuery(c)
+	if !ok {
+		return
+	}
+
+	header := []string{"id", "triggered_at", "alert_type", "severity", "message", "acknowledged_at", "resolved_at"}
+	row := func(alert database.Alert) []string {
+		return []string{
+			alert.ID.String(),
+			alert.TriggeredAt.UTC().Format(time.RFC3339Nano),
+			alert.AlertType,
+			alert.Severity,
+			alert.Message,
+			formatOptionalTime(alert.AcknowledgedAt),
+			formatOptionalTime(alert.ResolvedAt),
+		}
+	}
+	fetch := func(cursor *database.EventCursor) ([]database.Alert, *database.EventCursor, error) {
+		return s.simulationService.ListAlerts(c.Request.Context(), simulationID, filter, cursor, exportPageSize)
+	}
+
+	streamEvents(s, c, "alerts-"+simulationID.String(), header, row, fetch)
+}
+
+// streamEvents writes all pages returned by fetch to the response. Pages are
+// flushed as they are read so large exports never sit in memory.
+func streamEvents[T any](s *Server, c *gin.Context, filename string, header []string, row func(T) []string, fetch func(*database.EventCursor) ([]T, *database.EventCursor, error)) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		s.handleError(c, errors.New("format must be csv or ndjson"), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the first page before committing to a 200 so query errors can still be reported
+	items, next, err := fetch(nil)
+	if err != nil {
+		s.handleError(c, err, eventErrorStatus(err))
+		return
+	}
+
+	contentType := "text/csv"
+	if format == "ndjson" {
+		contentType = "application/x-ndjson"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+	c.Status(http.StatusOK)
+
+	csvWriter := csv.NewWriter(c.Writer)
+	encoder := json.NewEncoder(c.Writer)
+	if format == "csv" {
+		csvWriter.Write(header)
+	}
+
+	written := 0
+	for {
+		for _, item := range items {
+			if format == "csv" {
+				err = csvWriter.Write(row(item))
+			} else {
+				err = encoder.Encode(item)
+			}
+			if err != nil {
+				logrus.WithError(err).WithField("path", requestPath(c)).Warn("Event export aborted")
+				return
+			}
+		}
+		written += len(items)
+
+		csvWriter.Flush()
+		c.Writer.Flush()
+
+		if next == nil || c.Request.Context().Err() != nil {
+			break
+		}
+		if items, next, err = fetch(next); err != nil {
+			logrus.WithError(err).WithField("path", requestPath(c)).Error("Event export failed mid-stream")
+			return
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"path":   requestPath(c),
+		"format": format,
+		"rows":   written,
+	}).Info("Event export completed")
+}
+
+// parseEventQuery reads the simulation id and event filters shared by the
+// list, count and export endpoints. It writes the error response itself.
+func (s *Server) parseEventQuery(c *gin.Context) (uuid.UUID, database.EventFilter, bool) {
+	var filter database.EventFilter
+
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return uuid.Nil, filter, false
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return uuid.Nil, filter, false
+	}
+	filter.From, filter.To = from, to
+
+	filter.Severities = splitQueryList(c.Query("severity"))
+	filter.Types = splitQueryList(c.Query("type"))
+	filter.Status = c.Query("status")
+
+	if raw := c.Query("component_id"); raw != "" {
+		componentID, err := strconv.Atoi(raw)
+		if err != nil {
+			s.handleError(c, errors.New("invalid component_id"), http.StatusBadRequest)
+			return uuid.Nil, filter, false
+		}
+		filter.ComponentID = &componentID
+	}
+
+	return simulationID, filter, true
+}
+
+// parseEventPage reads the cursor and limit query parameters
+func (s *Server) parseEventPage(c *gin.Context) (*database.EventCursor, int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultEventPageSize)))
+	if err != nil || limit < 1 || limit > maxEventPageSize {
+		s.handleError(c, fmt.Errorf("limit must be between 1 and %d", maxEventPageSize), http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	raw := c.Query("cursor")
+	if raw == "" {
+		return nil, limit, true
+	}
+
+	cursor, err := database.DecodeEventCursor(raw)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	return cursor, limit, true
+}
+
+func newEventPage(events any, count int, next *database.EventCursor) EventPage {
+	page := EventPage{Events: events, Count: count}
+	if next != nil {
+		page.NextCursor = next.Encode()
+	}
+	return page
+}
+
+func newEventCounts(counts []database.SeverityCount) EventCounts {
+	result := EventCounts{BySeverity: counts}
+	if result.BySeverity == nil {
+		result.BySeverity = []database.SeverityCount{}
+	}
+	for _, count := range counts {
+		result.Total += count.Count
+	}
+	return result
+}
+
+func eventErrorStatus(err error) int {
+	if errors.Is(err, database.ErrInvalidEventStatus) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// splitQueryList splits a comma-separated query value, dropping empty entries
+func splitQueryList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
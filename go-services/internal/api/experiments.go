@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// CreateExperimentRequest represents a request to run a parameter sweep
+type CreateExperimentRequest struct {
+	Name          string                         `json:"name" binding:"required"`
+	Config        SimulationConfig               `json:"config" binding:"required"`
+	Dimensions    []orchestration.SweepDimension `json:"dimensions" binding:"required"`
+	MaxConcurrent int                            `json:"max_concurrent"`
+	Tags          []string                       `json:"tags"`
+}
+
+// createExperiment handles parameter sweep creation requests
+func (s *Server) createExperiment(c *gin.Context) {
+	var req CreateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	tags, ok := s.normalizeTags(c, req.Tags)
+	if !ok {
+		return
+	}
+
+	if err := req.Config.ValidateTopology(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if s.engines != nil {
+		if _, err := s.engines.Place(req.Config.Requirements(), ""); err != nil {
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"name":       req.Name,
+		"dimensions": len(req.Dimensions),
+	}).Info("Creating parameter sweep experiment")
+
+	if !s.requireSimulationQuota(c, requesterOrganization(c)) {
+		return
+	}
+
+	experiment, err := s.orchestrator.CreateExperiment(req.Name, req.Config, req.Dimensions, req.MaxConcurrent, tags)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	s.syncTags(database.TagResourceExperiment, experiment.ID, experiment.Tags)
+
+	s.handleSuccess(c, experiment, "Experiment created successfully")
+}
+
+// listExperiments returns all parameter sweep experiments
+func (s *Server) listExperiments(c *gin.Context) {
+	s.handleSuccess(c, s.orchestrator.ListExperiments(), "Experiments retrieved successfully")
+}
+
+// getExperiment returns an experiment with its runs and result matrix
+func (s *Server) getExperiment(c *gin.Context) {
+	experiment, err := s.orchestrator.GetExperiment(c.Param("id"))
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, experiment, "Experiment retrieved successfully")
+}
+
+// cancelExperiment stops a running experiment
+func (s *Server) cancelExperiment(c *gin.Context) {
+	id := c.Param("id")
+	if err := s.orchestrator.CancelExperiment(id); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, orchestration.ErrExperimentNotFound) {
+			status = http.StatusNotFound
+		}
+		s.handleError(c, err, status)
+		return
+	}
+
+	experiment, err := s.orchestrator.GetExperiment(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, experiment, "Experiment cancelled successfully")
+}
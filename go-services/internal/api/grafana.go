@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Grafana targets have the form "<simulation id>:<field>", where field is a
+// result column or grafanaFaultsField for the fault event table
+
+// grafanaFaultsField selects a simulation's fault events as a table
+const grafanaFaultsField = "faults"
+
+// grafanaSearchLimit bounds the simulations offered by search
+const grafanaSearchLimit = 100
+
+// grafanaMaxAnnotations bounds the fault events returned as annotations
+const grafanaMaxAnnotations = 1000
+
+// grafanaResultFields are the result columns that can be charted
+var grafanaResultFields = []struct {
+	name  string
+	value func(*database.SimulationResult) float64
+}{
+	{"total_generation_mw", func(r *database.SimulationResult) float64 { return r.TotalGenerationMW }},
+	{"total_consumption_mw", func(r *database.SimulationResult) float64 { return r.TotalConsumptionMW }},
+	{"grid_frequency_hz", func(r *database.SimulationResult) float64 { return r.GridFrequencyHz }},
+	{"grid_voltage_kv", func(r *database.SimulationResult) float64 { return r.GridVoltageKV }},
+	{"efficiency_percentage", func(r *database.SimulationResult) float64 { return r.EfficiencyPercentage }},
+	{"fault_count", func(r *database.SimulationResult) float64 { return float64(r.FaultCount) }},
+}
+
+// GrafanaRange is a dashboard time range
+type GrafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// GrafanaTarget is one query of a panel
+type GrafanaTarget struct {
+	Target string `json:"target"`
+	RefID  string `json:"refId"`
+	// "timeserie" or "table"; fault targets are always tables
+	Type string `json:"type"`
+}
+
+// GrafanaQueryRequest is sent by Grafana to fetch panel data
+type GrafanaQueryRequest struct {
+	Range         GrafanaRange    `json:"range"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int             `json:"maxDataPoints"`
+	Targets       []GrafanaTarget `json:"targets"`
+}
+
+// GrafanaSearchRequest is sent by Grafana to list targets
+type GrafanaSearchRequest struct {
+	Target string `json:"target"`
+}
+
+// GrafanaSearchResult is a selectable target
+type GrafanaSearchResult struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+// GrafanaAnnotationRequest is sent by Grafana to fetch annotations. The
+// query is a simulation ID, optionally followed by ":" and a comma-separated
+// list of severities.
+type GrafanaAnnotationRequest struct {
+	Range      GrafanaRange `json:"range"`
+	Annotation struct {
+		Name  string `json:"name"`
+		Query string `json:"query"`
+	} `json:"annotation"`
+}
+
+// GrafanaTimeSeries is a series of [value, unix milliseconds] points
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// GrafanaTable is tabular panel data
+type GrafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []GrafanaColumn `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// GrafanaColumn describes a table column
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// GrafanaAnnotation marks a fault event on a dashboard
+type GrafanaAnnotation struct {
+	Annotation interface{} `json:"annotation"`
+	Time       int64       `json:"time"`
+	TimeEnd    int64       `json:"timeEnd,omitempty"`
+	Title      string      `json:"title"`
+	Text       string      `json:"text"`
+	Tags       []string    `json:"tags"`
+}
+
+// GrafanaPoint is a time series point in the flat form used by /grafana/series
+type GrafanaPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// grafanaTest answers Grafana's datasource connection test
+func (s *Server) grafanaTest(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// grafanaSearch lists targets matching the search text
+func (s *Server) grafanaSearch(c *gin.Context) {
+	var req GrafanaSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	// Results are stored under database IDs, so search the database rather
+	// than the orchestrator. Signed-in users only see their own simulations.
+	var simulations []database.Simulation
+	var err error
+	if claims := currentClaims(c); claims != nil {
+		simulations, err = s.simulationService.GetSimulationsByUser(c.Request.Context(), claims.UserID, grafanaSearchLimit, 0)
+	} else {
+		simulations, err = s.simulationService.GetRecentSimulations(c.Request.Context(), grafanaSearchLimit)
+	}
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	search := strings.ToLower(req.Target)
+	results := []GrafanaSearchResult{}
+	for _, simulation := range simulations {
+		fields := make([]string, 0, len(grafanaResultFields)+1)
+		for _, field := range grafanaResultFields {
+			fields = append(fields, field.name)
+		}
+		fields = append(fields, grafanaFaultsField)
+
+		for _, field := range fields {
+			result := GrafanaSearchResult{
+				Text:  simulation.Name + ": " + field,
+				Value: simulation.ID.String() + ":" + field,
+			}
+			if search == "" || strings.Contains(strings.ToLower(result.Text), search) || strings.Contains(result.Value, search) {
+				results = append(results, result)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// grafanaQuery returns the data of each target over the dashboard range
+func (s *Server) grafanaQuery(c *gin.Context) {
+	var req GrafanaQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	from, to := optionalTime(req.Range.From), optionalTime(req.Range.To)
+	response := make([]interface{}, 0, len(req.Targets))
+
+	for _, target := range req.Targets {
+		if target.Target == "" {
+			continue
+		}
+		simulationID, field, err := parseGrafanaTarget(target.Target)
+		if err != nil {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+
+		if field == grafanaFaultsField {
+			table, err := s.grafanaFaultTable(c.Request.Context(), simulationID, from, to)
+			if err != nil {
+				s.handleError(c, err, http.StatusInternalServerError)
+				return
+			}
+			response = append(response, table)
+			continue
+		}
+
+		points, err := s.grafanaPoints(c.Request.Context(), simulationID, field, from, to)
+		if err != nil {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+		points = downsamplePoints(points, req.MaxDataPoints)
+
+		if target.Type == "table" {
+			table := GrafanaTable{
+				Type:    "table",
+				Columns: []GrafanaColumn{{Text: "Time", Type: "time"}, {Text: field, Type: "number"}},
+				Rows:    make([][]interface{}, len(points)),
+			}
+			for i, point := range points {
+				table.Rows[i] = []interface{}{int64(point[1]), point[0]}
+			}
+			response = append(response, table)
+			continue
+		}
+
+		response = append(response, GrafanaTimeSeries{Target: target.Target, Datapoints: points})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// grafanaAnnotations returns fault events in the dashboard range as annotations
+func (s *Server) grafanaAnnotations(c *gin.Context) {
+	var req GrafanaAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	query, severities, _ := strings.Cut(strings.TrimSpace(req.Annotation.Query), ":")
+	simulationID, err := uuid.Parse(query)
+	if err != nil {
+		s.handleError(c, errors.New("annotation query must be a simulation id, optionally followed by :severity,..."), http.StatusBadRequest)
+		return
+	}
+
+	filter := database.EventFilter{
+		From: optionalTime(req.Range.From),
+		To:   optionalTime(req.Range.To),
+	}
+	if severities != "" {
+		filter.Severities = strings.Split(severities, ",")
+	}
+
+	events, _, err := s.simulationService.ListFaultEvents(c.Request.Context(), simulationID, filter, nil, grafanaMaxAnnotations)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	annotations := make([]GrafanaAnnotation, len(events))
+	for i, event := range events {
+		annotations[i] = GrafanaAnnotation{
+			Annotation: req.Annotation,
+			Time:       event.Timestamp.UnixMilli(),
+			Title:      event.FaultType,
+			Text:       fmt.Sprintf("%s on %s %d", event.Description, event.ComponentType, event.ComponentID),
+			Tags:       []string{event.Severity, event.ComponentType},
+		}
+		if event.ResolvedAt != nil {
+			annotations[i].TimeEnd = event.ResolvedAt.UnixMilli()
+		}
+	}
+
+	c.JSON(http.StatusOK, annotations)
+}
+
+// grafanaSeries returns one target as a flat array of points, for datasources
+// such as Infinity that read plain JSON
+func (s *Server) grafanaSeries(c *gin.Context) {
+	simulationID, field, err := parseGrafanaTarget(c.Query("target"))
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	points, err := s.grafanaPoints(c.Request.Context(), simulationID, field, from, to)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	response := make([]GrafanaPoint, len(points))
+	for i, point := range points {
+		response[i] = GrafanaPoint{Time: time.UnixMilli(int64(point[1])).UTC(), Value: point[0]}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// grafanaPoints reads a result field as [value, unix milliseconds] points
+func (s *Server) grafanaPoints(ctx context.Context, simulationID uuid.UUID, field string, from, to *time.Time) ([][2]float64, error) {
+	var value func(*database.SimulationResult) float64
+	for _, candidate := range grafanaResultFields {
+		if candidate.name == field {
+			value = candidate.value
+		}
+	}
+	if value == nil {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+
+	results, err := s.resultsInRange(ctx, simulationID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([][2]float64, len(results))
+	for i := range results {
+		points[i] = [2]float64{value(&results[i]), float64(results[i].Timestamp.UnixMilli())}
+	}
+	return points, nil
+}
+
+// grafanaFaultTable returns a simulation's fault events as a table, newest first
+func (s *Server) grafanaFaultTable(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) (GrafanaTable, error) {
+	table := GrafanaTable{
+		Type: "table",
+		Columns: []GrafanaColumn{
+			{Text: "Time", Type: "time"},
+			{Text: "Type", Type: "string"},
+			{Text: "Severity", Type: "string"},
+			{Text: "Component", Type: "string"},
+			{Text: "Description", Type: "string"},
+			{Text: "Resolved", Type: "time"},
+		},
+		Rows: [][]interface{}{},
+	}
+
+	events, _, err := s.simulationService.ListFaultEvents(ctx, simulationID, database.EventFilter{From: from, To: to}, nil, grafanaMaxAnnotations)
+	if err != nil {
+		return table, err
+	}
+
+	for _, event := range events {
+		var resolved interface{}
+		if event.ResolvedAt != nil {
+			resolved = event.ResolvedAt.UnixMilli()
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			event.Timestamp.UnixMilli(),
+			event.FaultType,
+			event.Severity,
+			fmt.Sprintf("%s %d", event.ComponentType, event.ComponentID),
+			event.Description,
+			resolved,
+		})
+	}
+	return table, nil
+}
+
+// parseGrafanaTarget splits a "<simulation id>:<field>" target
+func parseGrafanaTarget(target string) (uuid.UUID, string, error) {
+	id, field, ok := strings.Cut(target, ":")
+	if !ok || field == "" {
+		return uuid.Nil, "", fmt.Errorf("target %q must have the form <simulation id>:<field>", target)
+	}
+	simulationID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("invalid simulation id in target %q", target)
+	}
+	return simulationID, field, nil
+}
+
+// downsamplePoints averages consecutive points so at most maxPoints remain.
+// Each average is placed at the first timestamp of its group.
+func downsamplePoints(points [][2]float64, maxPoints int) [][2]float64 {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+
+	size := (len(points) + maxPoints - 1) / maxPoints
+	sampled := make([][2]float64, 0, maxPoints)
+	for start := 0; start < len(points); start += size {
+		group := points[start:min(start+size, len(points))]
+		var sum float64
+		for _, point := range group {
+			sum += point[0]
+		}
+		sampled = append(sampled, [2]float64{sum / float64(len(group)), group[0][1]})
+	}
+	return sampled
+}
+
+// optionalTime returns nil for the zero time
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
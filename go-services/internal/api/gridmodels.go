@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/gridmodel"
+	"voltedge/go-services/internal/metadata"
+	"voltedge/go-services/internal/notifications"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// ImportSimulationResponse is a converted grid model and, when requested, the simulation created from it
+type ImportSimulationResponse struct {
+	Config     SimulationConfig       `json:"config"`
+	Report     gridmodel.ImportReport `json:"report"`
+	Simulation *SimulationResponse    `json:"simulation,omitempty"`
+}
+
+// importSimulation converts a MATPOWER or PSS/E case into a simulation config.
+// The case is sent as the request body or as a multipart "file" field; with
+// create=true a simulation is created from the converted config.
+func (s *Server) importSimulation(c *gin.Context) {
+	format := c.DefaultQuery("format", gridmodel.FormatMATPOWER)
+	create, _ := strconv.ParseBool(c.DefaultQuery("create", "false"))
+
+	data, err := readImportBody(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := gridmodel.Import(format, data)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	response := ImportSimulationResponse{
+		Config: result.Config,
+		Report: result.Report,
+	}
+
+	if !create {
+		s.handleSuccess(c, response, "Grid model converted successfully")
+		return
+	}
+
+	if err := result.Config.ValidateTopology(); err != nil {
+		s.handleError(c, err, http.StatusUnprocessableEntity)
+		return
+	}
+	if s.engines != nil {
+		if _, err := s.engines.Place(result.Config.Requirements(), ""); err != nil {
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	name := c.Query("name")
+	if name == "" {
+		name = result.Report.CaseName
+	}
+	if name == "" {
+		name = "Imported " + format + " case"
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"name":     name,
+		"format":   result.Report.Format,
+		"buses":    result.Report.Buses,
+		"branches": result.Report.Branches,
+		"unmapped": len(result.Report.Unmapped),
+	}).Info("Creating simulation from imported grid model")
+
+	if !s.requireSimulationQuota(c, requesterOrganization(c)) {
+		return
+	}
+
+	simulation, err := s.orchestrator.CreateSimulation(
+		c.Request.Context(),
+		name,
+		fmt.Sprintf("Imported from %s case %s", result.Report.Format, result.Report.CaseName),
+		result.Config,
+		[]string{"imported", "format:" + result.Report.Format},
+		map[string]interface{}{
+			metadata.KeyImportFormat: result.Report.Format,
+			metadata.KeyCaseName:     result.Report.CaseName,
+		},
+	)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	s.assignRequester(c, simulation.ID)
+
+	if s.cluster != nil {
+		if err := s.cluster.Claim(simulation); err != nil {
+			s.rollbackSimulation(c, simulation.ID)
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.syncTags(database.TagResourceSimulation, simulation.ID, simulation.Tags)
+
+	simulationResponse := newSimulationResponse(simulation)
+	response.Simulation = &simulationResponse
+
+	s.publishSimulationEvent(notifications.EventSimulationCreated, simulation.ID, "Simulation created")
+	s.handleSuccess(c, response, "Grid model imported successfully")
+}
+
+// exportSimulation writes a simulation's grid model in an interchange format
+func (s *Server) exportSimulation(c *gin.Context) {
+	id := c.Param("id")
+	format := c.DefaultQuery("format", gridmodel.FormatCIM)
+
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, orchestration.ErrSimulationNotFound) {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var document bytes.Buffer
+	if err := gridmodel.Export(format, &document, simulation); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+"_"+format+".xml"))
+	c.Data(http.StatusOK, "application/rdf+xml", document.Bytes())
+}
+
+// readImportBody returns the uploaded case from a multipart "file" field or the raw body
+func readImportBody(c *gin.Context) ([]byte, error) {
+	if c.ContentType() == "multipart/form-data" {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		upload, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer upload.Close()
+		return io.ReadAll(upload)
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("request body is empty")
+	}
+	return data, nil
+}
+
+// getGridGeoJSON returns the grid of a simulation as a GeoJSON FeatureCollection for map front-ends
+func (s *Server) getGridGeoJSON(c *gin.Context) {
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), c.Param("simulation_id"))
+	if err != nil {
+		if errors.Is(err, orchestration.ErrSimulationNotFound) {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Header("Content-Type", "application/geo+json")
+	c.JSON(http.StatusOK, gridmodel.GeoJSON(simulation))
+}
@@ -5,28 +5,28 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/orchestration"
 )
 
 // Grid state handlers
 
 func (s *Server) getGridState(c *gin.Context) {
-	simulationID := c.Param("simulation_id")
-	if simulationID == "" {
-		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+	simulationID, err := uuid.Parse(c.Param("simulation_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
 		return
 	}
 
 	logrus.WithField("simulation_id", simulationID).Debug("Getting grid state")
 
-	// TODO: Get actual grid state from orchestrator
-	state := map[string]interface{}{
-		"simulation_id":     simulationID,
-		"total_generation":  550.0,
-		"total_consumption": 400.0,
-		"frequency":         50.0,
-		"voltage_levels":    []float64{230.0, 229.5, 230.2},
-		"active_failures":   []int{},
+	state, err := s.cachedGridState(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
 	}
 
 	s.handleSuccess(c, state, "Grid state retrieved successfully")
@@ -77,6 +77,28 @@ func (s *Server) getGridComponents(c *gin.Context) {
 	s.handleSuccess(c, components, "Grid components retrieved successfully")
 }
 
+func (s *Server) getGridTopology(c *gin.Context) {
+	simulationID := c.Param("simulation_id")
+	if simulationID == "" {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithField("simulation_id", simulationID).Debug("Getting grid topology")
+
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), simulationID)
+	if err != nil {
+		if err == orchestration.ErrSimulationNotFound {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, simulation.Config.BuildTopology(), "Grid topology retrieved successfully")
+}
+
 func (s *Server) injectFailure(c *gin.Context) {
 	simulationID := c.Param("simulation_id")
 	if simulationID == "" {
@@ -100,7 +122,25 @@ func (s *Server) injectFailure(c *gin.Context) {
 		"failure_type":  req.FailureType,
 	}).Info("Injecting failure")
 
-	// TODO: Inject actual failure via orchestrator
+	if s.forwardToOwner(c, simulationID) {
+		return
+	}
+
+	fault := engine.Fault{ComponentID: req.ComponentID, Type: req.FailureType}
+	if err := s.orchestrator.InjectFault(c.Request.Context(), simulationID, fault); err != nil {
+		switch {
+		case errors.Is(err, orchestration.ErrSimulationNotFound):
+			s.handleError(c, err, http.StatusNotFound)
+		case errors.Is(err, engine.ErrInvalidFault):
+			s.handleError(c, err, http.StatusBadRequest)
+		case errors.Is(err, orchestration.ErrNoEngineRun), errors.Is(err, engine.ErrUnsupportedConfig):
+			s.handleError(c, err, http.StatusConflict)
+		default:
+			s.handleError(c, err, http.StatusBadGateway)
+		}
+		return
+	}
+
 	s.handleSuccess(c, nil, "Failure injected successfully")
 }
 
@@ -323,26 +363,6 @@ func (s *Server) getSimulationHistory(c *gin.Context) {
 	s.handleSuccess(c, history, "Simulation history retrieved successfully")
 }
 
-func (s *Server) getPredictions(c *gin.Context) {
-	simulationID := c.Param("simulation_id")
-	if simulationID == "" {
-		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
-		return
-	}
-
-	logrus.WithField("simulation_id", simulationID).Debug("Getting predictions")
-
-	// TODO: Get actual predictions from ML model
-	predictions := map[string]interface{}{
-		"next_hour_load":      420.0,
-		"failure_probability": 0.02,
-		"optimal_generation":  450.0,
-		"confidence":          0.85,
-	}
-
-	s.handleSuccess(c, predictions, "Predictions retrieved successfully")
-}
-
 // Streaming handlers
 
 func (s *Server) streamSimulationData(c *gin.Context) {
@@ -376,14 +396,3 @@ func (s *Server) streamGridData(c *gin.Context) {
 		"grid_id": gridID,
 	})
 }
-
-// WebSocket handler
-
-func (s *Server) handleWebSocket(c *gin.Context) {
-	logrus.Info("WebSocket connection requested")
-
-	// TODO: Implement WebSocket upgrade
-	c.JSON(http.StatusOK, gin.H{
-		"message": "WebSocket support not implemented yet",
-	})
-}
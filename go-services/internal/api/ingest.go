@@ -0,0 +1,231 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+// ingestTokenHeader carries the shared secret of engines pushing results
+const ingestTokenHeader = "X-VoltEdge-Ingest-Token"
+
+// ResultBatchRequest is a batch of engine output for one simulation. Entries
+// may arrive in any order and be resent; each tick's data replaces what was
+// previously ingested for it.
+type ResultBatchRequest struct {
+	SimulationID string         `json:"simulation_id" binding:"required,uuid"`
+	Results      []IngestResult `json:"results" binding:"dive"`
+	Metrics      []IngestMetric `json:"metrics" binding:"dive"`
+	Faults       []IngestFault  `json:"faults" binding:"dive"`
+}
+
+// IngestResult is the grid-wide result of one tick
+type IngestResult struct {
+	TickNumber           int            `json:"tick_number" binding:"gte=0"`
+	Timestamp            time.Time      `json:"timestamp" binding:"required"`
+	TotalGenerationMW    float64        `json:"total_generation_mw" binding:"gte=0"`
+	TotalConsumptionMW   float64        `json:"total_consumption_mw" binding:"gte=0"`
+	GridFrequencyHz      float64        `json:"grid_frequency_hz" binding:"gt=0"`
+	GridVoltageKV        float64        `json:"grid_voltage_kv" binding:"gte=0"`
+	EfficiencyPercentage float64        `json:"efficiency_percentage" binding:"gte=0,lte=100"`
+	FaultCount           int            `json:"fault_count" binding:"gte=0"`
+	Metadata             map[string]any `json:"metadata"`
+}
+
+// IngestMetric is a component reading taken during a tick
+type IngestMetric struct {
+	TickNumber    int            `json:"tick_number" binding:"gte=0"`
+	Timestamp     time.Time      `json:"timestamp" binding:"required"`
+	ComponentType string         `json:"component_type" binding:"required"`
+	ComponentID   int            `json:"component_id" binding:"gte=0"`
+	MetricName    string         `json:"metric_name" binding:"required"`
+	MetricValue   float64        `json:"metric_value"`
+	Unit          string         `json:"unit" binding:"required"`
+	Metadata      map[string]any `json:"metadata"`
+}
+
+// IngestFault is a fault raised during a tick
+type IngestFault struct {
+	TickNumber       int            `json:"tick_number" binding:"gte=0"`
+	Timestamp        time.Time      `json:"timestamp" binding:"required"`
+	FaultType        string         `json:"fault_type" binding:"required"`
+	ComponentID      int            `json:"component_id" binding:"gte=0"`
+	ComponentType    string         `json:"component_type" binding:"required"`
+	Severity         string         `json:"severity" binding:"required,oneof=info warning error critical"`
+	Description      string         `json:"description"`
+	ResolvedAt       *time.Time     `json:"resolved_at"`
+	ImpactAssessment map[string]any `json:"impact_assessment"`
+}
+
+// ingestMiddleware authenticates engines pushing results with the shared
+// ingest token. Ingest is disabled when no token is configured.
+func (s *Server) ingestMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.config.Ingest.Token == "" {
+			s.handleError(c, errors.New("result ingest is not enabled"), http.StatusNotFound)
+			c.Abort()
+			return
+		}
+
+		token := c.GetHeader(ingestTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Ingest.Token)) != 1 {
+			s.handleError(c, errors.New("invalid ingest token"), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ingestResultBatch stores a batch of results, metrics and faults pushed by
+// an engine or sidecar
+func (s *Server) ingestResultBatch(c *gin.Context) {
+	// Gin has no escaped colons, so results:batch registers as a parameter
+	// and any other suffix must be turned away here
+	if c.Param("batch") != ":batch" {
+		s.handleError(c, errors.New("not found"), http.StatusNotFound)
+		return
+	}
+
+	var req ResultBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	size := len(req.Results) + len(req.Metrics) + len(req.Faults)
+	if size == 0 {
+		s.handleError(c, errors.New("batch is empty"), http.StatusBadRequest)
+		return
+	}
+	if size > s.config.Ingest.MaxBatchSize {
+		s.handleError(c, fmt.Errorf("batch holds %d entries, at most %d are allowed", size, s.config.Ingest.MaxBatchSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	simulationID := uuid.MustParse(req.SimulationID)
+	simulation, err := s.simulationService.GetSimulation(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if simulation == nil {
+		s.handleError(c, errors.New("simulation not found"), http.StatusNotFound)
+		return
+	}
+
+	results := ingestResults(simulationID, req.Results)
+	metrics := make([]database.ComponentMetric, len(req.Metrics))
+	for i, metric := range req.Metrics {
+		metrics[i] = database.ComponentMetric{
+			SimulationID:  simulationID,
+			ComponentType: metric.ComponentType,
+			ComponentID:   metric.ComponentID,
+			Timestamp:     metric.Timestamp,
+			MetricName:    metric.MetricName,
+			MetricValue:   metric.MetricValue,
+			Unit:          metric.Unit,
+			Metadata:      metric.Metadata,
+			TickNumber:    &metric.TickNumber,
+		}
+	}
+	faults := make([]database.FaultEvent, len(req.Faults))
+	for i, fault := range req.Faults {
+		faults[i] = database.FaultEvent{
+			SimulationID:     simulationID,
+			Timestamp:        fault.Timestamp,
+			FaultType:        fault.FaultType,
+			ComponentID:      fault.ComponentID,
+			ComponentType:    fault.ComponentType,
+			Severity:         fault.Severity,
+			Description:      fault.Description,
+			ResolvedAt:       fault.ResolvedAt,
+			ImpactAssessment: fault.ImpactAssessment,
+			TickNumber:       &fault.TickNumber,
+		}
+	}
+
+	if err := s.simulationService.IngestResultBatch(c.Request.Context(), simulationID, results, metrics, faults); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.invalidateReadCaches(simulationID)
+
+	firstTick, lastTick := batchTickRange(req)
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulationID,
+		"results":       len(results),
+		"metrics":       len(metrics),
+		"faults":        len(faults),
+		"first_tick":    firstTick,
+		"last_tick":     lastTick,
+	}).Debug("Result batch ingested")
+
+	s.handleSuccess(c, gin.H{
+		"simulation_id": simulationID,
+		"results":       len(results),
+		"metrics":       len(metrics),
+		"faults":        len(faults),
+		"first_tick":    firstTick,
+		"last_tick":     lastTick,
+	}, "Result batch ingested successfully")
+}
+
+// ingestResults converts a batch's results, keeping the last entry sent for
+// a tick, ordered by tick
+func ingestResults(simulationID uuid.UUID, entries []IngestResult) []database.SimulationResult {
+	byTick := make(map[int]IngestResult, len(entries))
+	for _, entry := range entries {
+		byTick[entry.TickNumber] = entry
+	}
+
+	results := make([]database.SimulationResult, 0, len(byTick))
+	for _, entry := range byTick {
+		results = append(results, database.SimulationResult{
+			SimulationID:         simulationID,
+			Timestamp:            entry.Timestamp,
+			TickNumber:           entry.TickNumber,
+			TotalGenerationMW:    entry.TotalGenerationMW,
+			TotalConsumptionMW:   entry.TotalConsumptionMW,
+			GridFrequencyHz:      entry.GridFrequencyHz,
+			GridVoltageKV:        entry.GridVoltageKV,
+			EfficiencyPercentage: entry.EfficiencyPercentage,
+			FaultCount:           entry.FaultCount,
+			Metadata:             entry.Metadata,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].TickNumber < results[j].TickNumber
+	})
+	return results
+}
+
+// batchTickRange returns the lowest and highest tick in a batch
+func batchTickRange(req ResultBatchRequest) (int, int) {
+	var ticks []int
+	for _, result := range req.Results {
+		ticks = append(ticks, result.TickNumber)
+	}
+	for _, metric := range req.Metrics {
+		ticks = append(ticks, metric.TickNumber)
+	}
+	for _, fault := range req.Faults {
+		ticks = append(ticks, fault.TickNumber)
+	}
+	if len(ticks) == 0 {
+		return 0, 0
+	}
+	sort.Ints(ticks)
+	return ticks[0], ticks[len(ticks)-1]
+}
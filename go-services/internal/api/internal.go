@@ -0,0 +1,151 @@
+package api
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/cluster"
+	"voltedge/go-services/internal/config"
+)
+
+// serviceTokenHeader carries the signed token of a backend service calling
+// the internal API. It is apart from Authorization, which forwarded requests
+// use for the token of the user behind them.
+const serviceTokenHeader = "X-VoltEdge-Service-Token"
+
+// serviceKey is the gin context key holding the name of the calling service
+const serviceKey = "service"
+
+// setupInternalRoutes configures the service-to-service routes used by the
+// engine sidecar, other backend services and peer replicas
+func (s *Server) setupInternalRoutes(router *gin.Engine) {
+	internal := router.Group("/internal/v1", s.internalMiddleware())
+	{
+		// Engine result ingest
+		internal.POST("/results:batch", s.ingestResultBatch)
+
+		// Simulation control forwarded from other replicas to the owner
+		internal.GET("/simulations/:id", s.getSimulation)
+		internal.PATCH("/simulations/:id", s.updateSimulation)
+		internal.DELETE("/simulations/:id", s.deleteSimulation)
+		internal.POST("/simulations/:id/start", s.startSimulation)
+		internal.POST("/simulations/:id/stop", s.stopSimulation)
+		internal.POST("/simulations/:id/pause", s.pauseSimulation)
+		internal.POST("/simulations/:id/resume", s.resumeSimulation)
+		internal.POST("/simulations/:id/speed", s.setSimulationSpeed)
+		internal.POST("/simulations/:id/step", s.stepSimulation)
+		internal.GET("/simulations/:id/snapshot", s.takeSnapshot)
+		internal.POST("/simulations/:id/metrics", s.recordSimulationMetrics)
+	}
+}
+
+// setupInternalRouter configures the router of the internal listener, which
+// serves nothing of the public API
+func (s *Server) setupInternalRouter() {
+	s.internalRouter = gin.New()
+	s.internalRouter.Use(gin.LoggerWithFormatter(s.loggerFormatter))
+	s.internalRouter.Use(gin.CustomRecovery(recoverPanic))
+	s.internalRouter.Use(s.metricsMiddleware())
+	// Forwarded requests carry the token of the user behind them
+	s.internalRouter.Use(s.authMiddleware())
+	s.internalRouter.Use(s.limitsMiddleware())
+
+	s.internalRouter.GET("/health", s.healthCheck)
+	s.setupInternalRoutes(s.internalRouter)
+}
+
+// InternalHandler returns the HTTP handler of the internal listener, or nil
+// when the internal routes are served with the public API
+func (s *Server) InternalHandler() http.Handler {
+	if s.internalRouter == nil {
+		return nil
+	}
+	return s.internalRouter
+}
+
+// internalMiddleware admits only requests from backend services: replicas
+// carrying the cluster's internal token, callers presenting a client
+// certificate verified against the configured CA, and callers with a signed
+// service token
+func (s *Server) internalMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.cluster == nil && s.serviceTokens == nil && s.config.Internal.ClientCAFile == "" {
+			s.handleError(c, errors.New("internal API is not enabled"), http.StatusNotFound)
+			c.Abort()
+			return
+		}
+
+		if token := c.GetHeader(cluster.InternalTokenHeader); token != "" && s.cluster != nil {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.cluster.InternalToken())) != 1 {
+				s.handleError(c, errors.New("invalid internal token"), http.StatusUnauthorized)
+				c.Abort()
+				return
+			}
+			c.Set(internalRequestKey, true)
+			c.Set(serviceKey, "replica")
+			c.Next()
+			return
+		}
+
+		if state := c.Request.TLS; state != nil && len(state.VerifiedChains) > 0 && state.VerifiedChains[0][0].Subject.CommonName != "" {
+			c.Set(serviceKey, state.VerifiedChains[0][0].Subject.CommonName)
+			c.Next()
+			return
+		}
+
+		if token := c.GetHeader(serviceTokenHeader); token != "" && s.serviceTokens != nil {
+			service, err := s.serviceTokens.Parse(token)
+			if err != nil {
+				s.handleError(c, err, http.StatusUnauthorized)
+				c.Abort()
+				return
+			}
+			c.Set(serviceKey, service)
+			c.Next()
+			return
+		}
+
+		s.handleError(c, errors.New("service authentication required"), http.StatusUnauthorized)
+		c.Abort()
+	}
+}
+
+// InternalTLSConfig returns the TLS configuration of the internal listener,
+// or nil when it serves plain HTTP. With a client CA, client certificates
+// are verified when presented but not required, so services may use tokens
+// instead.
+func InternalTLSConfig(cfg config.InternalAPI) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load internal API certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read internal API client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("internal API client CA contains no certificates")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/orchestration"
+)
+
+// listDeadLetters returns the simulation jobs that failed for good, newest first
+func (s *Server) listDeadLetters(c *gin.Context) {
+	s.handleSuccess(c, s.orchestrator.ListDeadLetters(), "Failed jobs retrieved successfully")
+}
+
+// getDeadLetter returns a failed job with its config snapshot and engine diagnostics
+func (s *Server) getDeadLetter(c *gin.Context) {
+	letter, err := s.orchestrator.GetDeadLetter(c.Param("id"))
+	if err != nil {
+		if err == orchestration.ErrDeadLetterNotFound {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, letter, "Failed job retrieved successfully")
+}
+
+// retryDeadLetter starts a failed job's simulation again
+func (s *Server) retryDeadLetter(c *gin.Context) {
+	id := c.Param("id")
+
+	letter, err := s.orchestrator.RetryDeadLetter(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case err == orchestration.ErrDeadLetterNotFound || err == orchestration.ErrSimulationNotFound:
+			s.handleError(c, err, http.StatusNotFound)
+		case err == orchestration.ErrSimulationLocked || errors.Is(err, orchestration.ErrInvalidTransition):
+			s.handleError(c, err, http.StatusConflict)
+		case errors.Is(err, orchestration.ErrQueueFull):
+			s.handleError(c, err, http.StatusServiceUnavailable)
+		case errors.Is(err, orchestration.ErrConcurrencyLimit):
+			s.handleError(c, err, http.StatusTooManyRequests)
+		default:
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"dead_letter_id": id,
+		"simulation_id":  letter.SimulationID,
+	}).Info("Retrying failed simulation job")
+
+	s.handleSuccess(c, letter, "Failed job retried successfully")
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// routeClass groups routes that share a timeout and body size limit
+type routeClass int
+
+const (
+	routeDefault routeClass = iota
+	// Start/stop and other small control commands
+	routeControl
+	// Requests carrying a full grid config
+	routeConfig
+	// Grid model uploads
+	routeUpload
+	// Long-running exports
+	routeExport
+	// Server-sent event and WebSocket streams, which are never cut short
+	routeStream
+)
+
+// routeClasses assigns routes to a class by method and registered path.
+// Routes not listed use the default timeout and body size.
+var routeClasses = map[string]routeClass{
+	"POST /api/v1/auth/login":                   routeControl,
+	"POST /api/v1/simulations/:id/start":        routeControl,
+	"POST /api/v1/simulations/:id/stop":         routeControl,
+	"POST /api/v1/simulations/:id/pause":        routeControl,
+	"POST /api/v1/simulations/:id/resume":       routeControl,
+	"POST /api/v1/simulations/:id/speed":        routeControl,
+	"POST /api/v1/simulations/:id/step":         routeControl,
+	"POST /api/v1/batches/:id/cancel":           routeControl,
+	"POST /api/v1/experiments/:id/cancel":       routeControl,
+	"POST /api/v1/grid/failures/:simulation_id": routeControl,
+	"POST /api/v1/plants/:id/control":           routeControl,
+	"POST /api/v1/transmission/:id/control":     routeControl,
+	"POST /api/v1/playback/:id/control":         routeControl,
+	"POST /api/v1/alerts/:id/ack":               routeControl,
+	"POST /api/v1/alerts/:id/resolve":           routeControl,
+	"POST /api/v1/simulations":                  routeConfig,
+	"POST /api/v1/simulations/:id/redispatch":   routeConfig,
+	"POST /api/v1/simulations/:id/rerun":        routeConfig,
+	"POST /api/v1/simulations/:id/plan":         routeConfig,
+	"POST /api/v1/simulations/:id/apply":        routeConfig,
+	"POST /api/v1/simulations/:id/components":   routeConfig,
+	"POST /internal/v1/simulations/:id/metrics": routeConfig,
+	"POST /api/v1/internal/results:batch":       routeConfig,
+	"POST /internal/v1/results:batch":           routeConfig,
+	"POST /api/v1/batches":                      routeConfig,
+	"POST /api/v1/experiments":                  routeConfig,
+	"POST /api/v1/simulations/import":           routeUpload,
+	"GET /api/v1/simulations/:id/export":        routeExport,
+	"GET /api/v1/simulations/:id/faults/export": routeExport,
+	"GET /api/v1/simulations/:id/alerts/export": routeExport,
+	"GET /api/v1/playback/:id/stream":           routeStream,
+	"GET /api/v1/stream/simulation/:id":         routeStream,
+	"GET /api/v1/stream/grid/:id":               routeStream,
+}
+
+// routeLimit is the timeout and body size applied to a request. Zero means unlimited.
+type routeLimit struct {
+	timeout  time.Duration
+	maxBytes int64
+}
+
+// routeClass returns the class of the route matched by a request
+func (s *Server) routeClass(c *gin.Context) routeClass {
+	if c.FullPath() == s.config.WebSocketPath {
+		return routeStream
+	}
+	return routeClasses[c.Request.Method+" "+c.FullPath()]
+}
+
+// routeLimit resolves the limits for the route matched by a request
+func (s *Server) routeLimit(c *gin.Context) routeLimit {
+	limits := s.config.Limits
+
+	switch s.routeClass(c) {
+	case routeControl:
+		return routeLimit{timeout: limits.ControlTimeout, maxBytes: limits.MaxControlBytes}
+	case routeConfig:
+		return routeLimit{timeout: limits.DefaultTimeout, maxBytes: limits.MaxConfigBytes}
+	case routeUpload:
+		return routeLimit{timeout: limits.ExportTimeout, maxBytes: limits.MaxUploadBytes}
+	case routeExport:
+		return routeLimit{timeout: limits.ExportTimeout, maxBytes: limits.MaxBodyBytes}
+	case routeStream:
+		return routeLimit{}
+	}
+	return routeLimit{timeout: limits.DefaultTimeout, maxBytes: limits.MaxBodyBytes}
+}
+
+// limitsMiddleware enforces per-route request timeouts and body size limits.
+// Bodies are capped while they are read, so multipart uploads are limited as
+// they stream in; requests declaring a larger Content-Length are rejected
+// up front. Handlers observe the timeout through the request context.
+func (s *Server) limitsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := s.routeLimit(c)
+
+		if limit.maxBytes > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			if c.Request.ContentLength > limit.maxBytes {
+				s.handleError(c, &http.MaxBytesError{Limit: limit.maxBytes}, http.StatusRequestEntityTooLarge)
+				c.Abort()
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit.maxBytes)
+		}
+
+		// Streams and slow routes outlive the server-wide read and write timeouts
+		rc := http.NewResponseController(c.Writer)
+		var deadline time.Time
+		if limit.timeout > 0 {
+			deadline = time.Now().Add(limit.timeout)
+		}
+		if limit.timeout == 0 || limit.timeout > s.config.WriteTimeout {
+			if err := rc.SetWriteDeadline(deadline); err != nil {
+				logrus.WithError(err).Debug("Failed to extend write deadline")
+			}
+		}
+		if limit.timeout == 0 || limit.timeout > s.config.ReadTimeout {
+			if err := rc.SetReadDeadline(deadline); err != nil {
+				logrus.WithError(err).Debug("Failed to extend read deadline")
+			}
+		}
+
+		if limit.timeout == 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), limit.timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			s.handleError(c, errors.New("request timed out"), http.StatusGatewayTimeout)
+		}
+	}
+}
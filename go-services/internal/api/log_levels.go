@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/logging"
+)
+
+// LogLevelRequest changes the log levels. Subsystems are api,
+// orchestration, database and grpc; setting one to an empty level makes it
+// follow the gateway's level again.
+type LogLevelRequest struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// getLogLevel returns the gateway's log level and the subsystem overrides
+func (s *Server) getLogLevel(c *gin.Context) {
+	s.handleSuccess(c, logging.CurrentLevels(), "Log levels retrieved successfully")
+}
+
+// updateLogLevel changes the log levels at runtime, until the next restart
+func (s *Server) updateLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	levels, err := logging.SetLevels(logging.Levels{Level: req.Level, Modules: req.Modules})
+	if err != nil {
+		if errors.Is(err, logging.ErrInvalidLevel) {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, levels, "Log levels updated")
+}
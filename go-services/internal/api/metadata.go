@@ -0,0 +1,19 @@
+package api
+
+import (
+	"voltedge/go-services/internal/config"
+	"voltedge/go-services/internal/metadata"
+)
+
+// MetadataLimitError describes metadata that violates a configured limit
+type MetadataLimitError = metadata.LimitError
+
+// validateMetadata checks request metadata against the configured limits
+// and key rules, and rejects keys reserved for system metadata. A zero
+// limit disables that check.
+func validateMetadata(m map[string]interface{}, limits config.MetadataLimits) error {
+	if err := metadata.CheckReserved(m, limits.ReservedPrefixes); err != nil {
+		return err
+	}
+	return metadata.Validate(m, limits)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	defaultMetricsQueryLimit = 1000
+	maxMetricsQueryLimit     = 10000
+)
+
+// queryComponentMetrics runs a metrics query expression (?q, see
+// database.ParseMetricsQuery) over a simulation's component metrics. The
+// range comes from ?from and ?to, aggregation buckets from ?step and the
+// point budget from ?limit.
+func (s *Server) queryComponentMetrics(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	expr := c.Query("q")
+	if expr == "" {
+		s.handleError(c, errors.New("q is required"), http.StatusBadRequest)
+		return
+	}
+	query, err := database.ParseMetricsQuery(expr)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	query.From, query.To, err = parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if raw := c.Query("step"); raw != "" {
+		step, err := time.ParseDuration(raw)
+		if err != nil || step < time.Second {
+			s.handleError(c, errors.New("step must be a duration of at least 1s"), http.StatusBadRequest)
+			return
+		}
+		if query.Aggregation == "" {
+			s.handleError(c, errors.New("step requires an aggregation"), http.StatusBadRequest)
+			return
+		}
+		query.Step = step
+	}
+
+	query.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultMetricsQueryLimit)))
+	if query.Limit < 1 || query.Limit > maxMetricsQueryLimit {
+		query.Limit = defaultMetricsQueryLimit
+	}
+
+	result, err := s.simulationService.QueryComponentMetrics(c.Request.Context(), simulationID, query)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidMetricsQuery) {
+			s.handleError(c, err, http.StatusBadRequest)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, result, "Metrics query completed successfully")
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Multiplexer serves the REST API and metrics from one listener. Metrics
+// scrapes are told apart by their path; everything else goes to the REST
+// API.
+type Multiplexer struct {
+	REST http.Handler
+	// Serves MetricsPath when set
+	Metrics     http.Handler
+	MetricsPath string
+}
+
+// ServeHTTP routes a request to the handler of its path
+func (m *Multiplexer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.Metrics != nil && r.URL.Path == m.MetricsPath {
+		m.Metrics.ServeHTTP(w, r)
+		return
+	}
+	m.REST.ServeHTTP(w, r)
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/playback"
+	"voltedge/go-services/internal/realtime"
+)
+
+// CreatePlaybackRequest represents a request to replay a completed simulation
+type CreatePlaybackRequest struct {
+	SimulationID string     `json:"simulation_id" binding:"required,uuid"`
+	Speed        float64    `json:"speed" binding:"gte=0"`
+	From         *time.Time `json:"from"`
+	To           *time.Time `json:"to"`
+	// Start playing as soon as a stream attaches instead of waiting for a play command
+	Autoplay bool `json:"autoplay"`
+}
+
+// createPlayback opens a playback session over a simulation's stored results
+func (s *Server) createPlayback(c *gin.Context) {
+	var req CreatePlaybackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	session, err := s.playback.Create(c.Request.Context(), uuid.MustParse(req.SimulationID), req.Speed, req.From, req.To)
+	if err != nil {
+		s.handleError(c, err, playbackErrorStatus(err))
+		return
+	}
+
+	if req.Autoplay {
+		if session, err = s.playback.Control(session.ID, playback.Command{Action: playback.ActionPlay}); err != nil {
+			s.handleError(c, err, playbackErrorStatus(err))
+			return
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"session_id":    session.ID,
+		"simulation_id": session.SimulationID,
+		"frames":        session.Frames,
+	}).Info("Created playback session")
+
+	s.handleSuccess(c, session, "Playback session created successfully")
+}
+
+// getPlayback returns the state of a playback session
+func (s *Server) getPlayback(c *gin.Context) {
+	session, err := s.playback.Get(c.Param("id"))
+	if err != nil {
+		s.handleError(c, err, playbackErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, session, "Playback session retrieved successfully")
+}
+
+// deletePlayback ends a playback session
+func (s *Server) deletePlayback(c *gin.Context) {
+	if err := s.playback.Delete(c.Param("id")); err != nil {
+		s.handleError(c, err, playbackErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, nil, "Playback session deleted successfully")
+}
+
+// controlPlayback plays, pauses, steps, seeks or changes the speed of a session
+func (s *Server) controlPlayback(c *gin.Context) {
+	var cmd playback.Command
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	session, err := s.playback.Control(c.Param("id"), cmd)
+	if err != nil {
+		s.handleError(c, err, playbackErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, session, "Playback updated successfully")
+}
+
+// streamPlayback replays a session as server-sent events. Frames arrive as
+// "frame" events, control changes as "state" events and the last frame is
+// followed by an "end" event. With ?format=msgpack the events are instead
+// streamed as consecutive MessagePack values, each carrying its type.
+func (s *Server) streamPlayback(c *gin.Context) {
+	id := c.Param("id")
+	format, err := realtime.ParseFormat(c.Query("format"))
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	if _, err := s.playback.Get(id); err != nil {
+		s.handleError(c, err, playbackErrorStatus(err))
+		return
+	}
+
+	send := func(eventType string, data interface{}) error {
+		c.SSEvent(eventType, data)
+		return nil
+	}
+	if realtime.Binary(format) {
+		c.Header("Content-Type", realtime.ContentType(format))
+		encoder := realtime.NewEncoder(format)
+		send = func(_ string, data interface{}) error {
+			payload, err := encoder.Encode(data)
+			if err != nil {
+				return err
+			}
+			_, err = c.Writer.Write(payload)
+			return err
+		}
+	} else {
+		c.Header("Content-Type", "text/event-stream")
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	err = s.playback.Stream(c.Request.Context(), id, func(event playback.Event) error {
+		if err := send(event.Type, event); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+	if errors.Is(err, playback.ErrAlreadyStreaming) {
+		send("error", gin.H{"type": "error", "error": err.Error()})
+		c.Writer.Flush()
+		return
+	}
+	if err != nil && !errors.Is(err, c.Request.Context().Err()) {
+		logrus.WithError(err).WithField("session_id", id).Warn("Playback stream ended with error")
+	}
+}
+
+// playbackErrorStatus maps playback errors onto HTTP status codes
+func playbackErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, playback.ErrSessionNotFound), errors.Is(err, playback.ErrNoResults):
+		return http.StatusNotFound
+	case errors.Is(err, playback.ErrAlreadyStreaming):
+		return http.StatusConflict
+	case errors.Is(err, playback.ErrInvalidCommand), errors.Is(err, playback.ErrTooManyFrames):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/notifications"
+)
+
+// preferenceChannels are the channel types notification preferences may allow
+var preferenceChannels = []string{
+	database.ChannelWebhook,
+	database.ChannelSlack,
+	database.ChannelPagerDuty,
+	database.ChannelEmail,
+}
+
+// NotificationPreferenceRequest represents a request to set the caller's
+// notification preferences
+type NotificationPreferenceRequest struct {
+	EventTypes            []string `json:"event_types"`
+	Channels              []string `json:"channels"`
+	QuietHoursStart       string   `json:"quiet_hours_start"`
+	QuietHoursEnd         string   `json:"quiet_hours_end"`
+	Timezone              string   `json:"timezone"`
+	Delivery              string   `json:"delivery" binding:"omitempty,oneof=immediate digest"`
+	DigestIntervalMinutes int      `json:"digest_interval_minutes" binding:"omitempty,min=5,max=1440"`
+	// Emails a report of the organization's runs, experiments and alerts
+	// after every day or week; off when empty
+	ReportSchedule string `json:"report_schedule" binding:"omitempty,oneof=daily weekly"`
+}
+
+// validate checks the channels, quiet hours and timezone
+func (r *NotificationPreferenceRequest) validate() error {
+	for _, channel := range r.Channels {
+		if !slices.Contains(preferenceChannels, channel) {
+			return errors.New("channels must be webhook, slack, pagerduty or email")
+		}
+	}
+
+	if (r.QuietHoursStart == "") != (r.QuietHoursEnd == "") {
+		return errors.New("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	if r.QuietHoursStart != "" {
+		if _, err := notifications.ParseClock(r.QuietHoursStart); err != nil {
+			return err
+		}
+		if _, err := notifications.ParseClock(r.QuietHoursEnd); err != nil {
+			return err
+		}
+	}
+
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return errors.New("unknown timezone: " + r.Timezone)
+		}
+	}
+	return nil
+}
+
+// defaultNotificationPreference is what applies to users who never set preferences
+func defaultNotificationPreference() *database.NotificationPreference {
+	return &database.NotificationPreference{
+		EventTypes:            []string{},
+		Channels:              []string{},
+		Timezone:              "UTC",
+		Delivery:              database.DeliveryImmediate,
+		DigestIntervalMinutes: 60,
+	}
+}
+
+// getNotificationPreferences returns the caller's notification preferences,
+// or the defaults when they have none
+func (s *Server) getNotificationPreferences(c *gin.Context) {
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	preference, err := s.preferenceService.GetPreference(claims.UserID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if preference == nil {
+		preference = defaultNotificationPreference()
+		preference.UserID = claims.UserID
+	}
+
+	s.handleSuccess(c, preference, "Notification preferences retrieved successfully")
+}
+
+// setNotificationPreferences replaces the caller's notification preferences
+func (s *Server) setNotificationPreferences(c *gin.Context) {
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	var req NotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	preference := defaultNotificationPreference()
+	preference.UserID = claims.UserID
+	if req.EventTypes != nil {
+		preference.EventTypes = req.EventTypes
+	}
+	if req.Channels != nil {
+		preference.Channels = req.Channels
+	}
+	preference.QuietHoursStart = req.QuietHoursStart
+	preference.QuietHoursEnd = req.QuietHoursEnd
+	if req.Timezone != "" {
+		preference.Timezone = req.Timezone
+	}
+	if req.Delivery != "" {
+		preference.Delivery = req.Delivery
+	}
+	if req.DigestIntervalMinutes != 0 {
+		preference.DigestIntervalMinutes = req.DigestIntervalMinutes
+	}
+	preference.ReportSchedule = req.ReportSchedule
+
+	if err := s.preferenceService.SavePreference(preference); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, preference, "Notification preferences saved successfully")
+}
+
+// resetNotificationPreferences restores the caller's default notification preferences
+func (s *Server) resetNotificationPreferences(c *gin.Context) {
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := s.preferenceService.DeletePreference(claims.UserID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	preference := defaultNotificationPreference()
+	preference.UserID = claims.UserID
+	s.handleSuccess(c, preference, "Notification preferences reset successfully")
+}
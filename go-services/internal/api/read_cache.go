@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/cache"
+	"voltedge/go-services/internal/config"
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	// maxLatestResults bounds the latest results endpoint, which is meant for
+	// polling the newest ticks rather than paging through history
+	maxLatestResults = 100
+	// Unresolved faults read for a grid state
+	maxActiveFailures = 100
+)
+
+// readCaches hold the reads dashboards poll, so identical polls within the
+// TTL cost one query between them
+type readCaches struct {
+	gridState     *cache.Cache[*GridState]
+	latestResults *cache.Cache[[]database.SimulationResult]
+	statistics    *cache.Cache[*database.SimulationStatistics]
+}
+
+func newReadCaches(cfg config.ReadCache) readCaches {
+	opts := cache.Options{
+		TTL:        cfg.TTL,
+		Jitter:     cfg.Jitter,
+		MaxEntries: cfg.MaxEntries,
+	}
+	return readCaches{
+		gridState:     cache.New[*GridState]("grid_state", opts),
+		latestResults: cache.New[[]database.SimulationResult]("latest_results", opts),
+		statistics:    cache.New[*database.SimulationStatistics]("statistics", opts),
+	}
+}
+
+// invalidateReadCaches drops the cached reads of a simulation whose results
+// changed. Latest results are cached per limit, so only the default limit is
+// dropped; other limits expire with their TTL.
+func (s *Server) invalidateReadCaches(simulationID uuid.UUID) {
+	s.readCaches.gridState.Invalidate(simulationID.String())
+	s.readCaches.latestResults.Invalidate(latestResultsKey(simulationID, 1))
+	s.readCaches.statistics.Invalidate(simulationID.String())
+}
+
+// GridState is the latest recorded state of a simulation's grid
+type GridState struct {
+	SimulationID     string     `json:"simulation_id"`
+	TickNumber       int        `json:"tick_number"`
+	Timestamp        *time.Time `json:"timestamp,omitempty"`
+	TotalGeneration  float64    `json:"total_generation"`
+	TotalConsumption float64    `json:"total_consumption"`
+	Frequency        float64    `json:"frequency"`
+	VoltageLevels    []float64  `json:"voltage_levels"`
+	// Components with unresolved faults
+	ActiveFailures []int `json:"active_failures"`
+}
+
+// cachedGridState returns a simulation's grid state from its latest result
+// and unresolved faults
+func (s *Server) cachedGridState(ctx context.Context, simulationID uuid.UUID) (*GridState, error) {
+	return s.readCaches.gridState.Get(ctx, simulationID.String(), func(ctx context.Context) (*GridState, error) {
+		state := &GridState{
+			SimulationID:   simulationID.String(),
+			VoltageLevels:  []float64{},
+			ActiveFailures: []int{},
+		}
+
+		results, err := s.simulationService.GetLatestSimulationResults(ctx, simulationID, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) > 0 {
+			latest := results[0]
+			state.TickNumber = latest.TickNumber
+			state.Timestamp = &latest.Timestamp
+			state.TotalGeneration = latest.TotalGenerationMW
+			state.TotalConsumption = latest.TotalConsumptionMW
+			state.Frequency = latest.GridFrequencyHz
+			state.VoltageLevels = append(state.VoltageLevels, latest.GridVoltageKV)
+		}
+
+		faults, _, err := s.simulationService.ListFaultEvents(ctx, simulationID, database.EventFilter{Status: database.EventStatusActive}, nil, maxActiveFailures)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[int]bool)
+		for _, fault := range faults {
+			if !seen[fault.ComponentID] {
+				seen[fault.ComponentID] = true
+				state.ActiveFailures = append(state.ActiveFailures, fault.ComponentID)
+			}
+		}
+		return state, nil
+	})
+}
+
+// getLatestResults returns a simulation's newest results, newest first
+// (limit defaults to 1, at most 100)
+func (s *Server) getLatestResults(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "1"))
+	if err != nil || limit < 1 || limit > maxLatestResults {
+		s.handleError(c, fmt.Errorf("limit must be between 1 and %d", maxLatestResults), http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.readCaches.latestResults.Get(c.Request.Context(), latestResultsKey(simulationID, limit), func(ctx context.Context) ([]database.SimulationResult, error) {
+		return s.simulationService.GetLatestSimulationResults(ctx, simulationID, limit)
+	})
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, results, "Latest simulation results retrieved successfully")
+}
+
+// getSimulationStatistics returns a simulation's result, fault and alert
+// statistics
+func (s *Server) getSimulationStatistics(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.readCaches.statistics.Get(c.Request.Context(), simulationID.String(), func(ctx context.Context) (*database.SimulationStatistics, error) {
+		return s.simulationService.GetSimulationStatistics(ctx, simulationID)
+	})
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, stats, "Simulation statistics retrieved successfully")
+}
+
+func latestResultsKey(simulationID uuid.UUID, limit int) string {
+	return simulationID.String() + "/" + strconv.Itoa(limit)
+}
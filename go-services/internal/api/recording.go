@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/recording"
+)
+
+// recordingMiddleware records API requests and their responses for replay.
+// Streams and requests outside the API are not recorded.
+func (s *Server) recordingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") || s.routeClass(c) == routeStream {
+			c.Next()
+			return
+		}
+
+		started := time.Now()
+		limit := s.recorder.MaxBodyBytes()
+
+		// Read one byte past the limit so oversized bodies are recognized,
+		// and hand the handler the whole body either way
+		var body []byte
+		if c.Request.Body != nil {
+			head, _ := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+			body = head
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer, limit: limit}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		exchange := &recording.HTTPExchange{
+			Method:  c.Request.Method,
+			Path:    requestPath(c),
+			Query:   c.Request.URL.RawQuery,
+			Route:   c.FullPath(),
+			Headers: s.recorder.Headers(c.Request.Header),
+			Status:  c.Writer.Status(),
+		}
+		exchange.Body, exchange.BodyOmitted = s.recorder.Sanitize(body)
+		if !writer.overflow {
+			exchange.Response, _ = s.recorder.Sanitize(writer.body.Bytes())
+		}
+
+		s.recorder.Record(recording.Entry{
+			Kind:       recording.KindHTTP,
+			Time:       started.UTC(),
+			DurationMS: float64(time.Since(started).Microseconds()) / 1000,
+			HTTP:       exchange,
+		})
+	}
+}
+
+// readCloser reads from one reader and closes another
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// recordingWriter keeps a copy of the response body up to a limit
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture copies written bytes until the limit is passed
+func (w *recordingWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > w.limit {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/analytics"
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/metadata"
+	"voltedge/go-services/internal/notifications"
+)
+
+// RedispatchRequest represents a what-if run replaying a recorded load against a new grid
+type RedispatchRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Config      SimulationConfig       `json:"config" binding:"required"`
+	Tags        []string               `json:"tags"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	From        *time.Time             `json:"from"`
+	To          *time.Time             `json:"to"`
+	Resample    ResampleRequest        `json:"resample"`
+}
+
+// ResampleRequest controls how the recorded consumption series is resampled
+type ResampleRequest struct {
+	IntervalSeconds int     `json:"interval_seconds" binding:"gte=0"`
+	Method          string  `json:"method" binding:"omitempty,oneof=mean max last"`
+	Scale           float64 `json:"scale" binding:"gte=0"`
+}
+
+// redispatchSimulation creates a new simulation whose load profile replays a past run's recorded consumption
+func (s *Server) redispatchSimulation(c *gin.Context) {
+	sourceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid source simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	var req RedispatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := validateMetadata(req.Metadata, s.config.Metadata); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	tags, ok := s.normalizeTags(c, req.Tags)
+	if !ok {
+		return
+	}
+
+	// Refuse windows too large to resample before reading them
+	count, err := s.countResultsInRange(c.Request.Context(), sourceID, req.From, req.To)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if count > analytics.MaxRecordedLoadSamples {
+		s.handleError(c, fmt.Errorf("%w: the window holds %d results, at most %d can be resampled; narrow it with from and to",
+			analytics.ErrRecordedLoadTooLarge, count, analytics.MaxRecordedLoadSamples), http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.resultsInRange(c.Request.Context(), sourceID, req.From, req.To)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if len(results) == 0 {
+		s.handleError(c, errors.New("source simulation has no recorded results in the requested window"), http.StatusNotFound)
+		return
+	}
+
+	recorded, err := analytics.BuildRecordedLoad(results, analytics.ResampleOptions{
+		Interval: time.Duration(req.Resample.IntervalSeconds) * time.Second,
+		Method:   req.Resample.Method,
+		Scale:    req.Resample.Scale,
+	})
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Config.ValidateTopology(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	req.Config.LoadProfile.BaseLoadMW = recorded.BaseLoadMW
+	req.Config.LoadProfile.PeakMultiplier = recorded.PeakMultiplier
+	req.Config.LoadProfile.RecordedSeriesMW = recorded.SeriesMW
+	req.Config.LoadProfile.SeriesIntervalSeconds = recorded.IntervalSeconds
+
+	meta := req.Metadata
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	method := req.Resample.Method
+	if method == "" {
+		method = analytics.ResampleMean
+	}
+	meta[metadata.KeyLoadProvenance] = map[string]interface{}{
+		"source_simulation_id": sourceID.String(),
+		"from":                 recorded.From,
+		"to":                   recorded.To,
+		"source_samples":       recorded.SourceSamples,
+		"points":               len(recorded.SeriesMW),
+		"interval_seconds":     recorded.IntervalSeconds,
+		"resample_method":      method,
+		"scale":                req.Resample.Scale,
+		"created_at":           time.Now().UTC(),
+	}
+
+	if s.engines != nil {
+		if _, err := s.engines.Place(req.Config.Requirements(), ""); err != nil {
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	if !s.requireSimulationQuota(c, requesterOrganization(c)) {
+		return
+	}
+
+	simulation, err := s.orchestrator.CreateSimulation(c.Request.Context(), req.Name, req.Description, req.Config, tags, meta)
+	if err != nil {
+		if errors.Is(err, metadata.ErrInvalid) {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	s.assignRequester(c, simulation.ID)
+	s.syncTags(database.TagResourceSimulation, simulation.ID, simulation.Tags)
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id":        simulation.ID,
+		"source_simulation_id": sourceID,
+		"points":               len(recorded.SeriesMW),
+	}).Info("Created what-if simulation from recorded load")
+
+	s.publishSimulationEvent(notifications.EventSimulationCreated, simulation.ID, "Simulation created")
+	s.handleSuccess(c, newSimulationResponse(simulation), "What-if simulation created successfully")
+}
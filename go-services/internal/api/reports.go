@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/report"
+)
+
+// getSimulationReport renders a simulation's run report. HTML is shown inline
+// and PDF is downloaded as an attachment.
+func (s *Server) getSimulationReport(c *gin.Context) {
+	if s.reports == nil {
+		s.handleError(c, errors.New("report generation is not configured"), http.StatusServiceUnavailable)
+		return
+	}
+
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	format := c.DefaultQuery("format", report.FormatHTML)
+	if format != report.FormatHTML && format != report.FormatPDF {
+		s.handleError(c, errors.New("format must be html or pdf"), http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulationID,
+		"format":        format,
+	}).Debug("Rendering simulation report")
+
+	document, err := s.reports.Render(c.Request.Context(), simulationID, format)
+	if err != nil {
+		if errors.Is(err, report.ErrSimulationNotFound) {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	disposition := "inline"
+	if format == report.FormatPDF {
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, simulationID.String()+"_report."+format))
+	c.Data(http.StatusOK, report.ContentType(format), document)
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	defaultResultPageSize = 500
+	maxResultPageSize     = 10000
+	// Rows sent per chunk of a result export
+	resultExportChunkRows = 1000
+)
+
+// listSimulationResults returns a window of a simulation's results, so
+// charts can fetch exactly the range they display. Windows are selected by
+// from_tick/to_tick and/or from/to (RFC3339), ordered by order=asc|desc
+// (default desc) and paged with limit and offset.
+func (s *Server) listSimulationResults(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	window, err := parseResultWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	window.Limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultResultPageSize)))
+	if err != nil || window.Limit < 1 || window.Limit > maxResultPageSize {
+		s.handleError(c, fmt.Errorf("limit must be between 1 and %d", maxResultPageSize), http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.simulationService.GetSimulationResults(c.Request.Context(), simulationID, window)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, results, "Simulation results retrieved successfully")
+}
+
+// parseResultWindow reads the window, order and offset of a results request
+func parseResultWindow(c *gin.Context) (database.ResultWindow, error) {
+	var window database.ResultWindow
+
+	var err error
+	if window.FromTick, err = parseTickParam(c, "from_tick"); err != nil {
+		return window, err
+	}
+	if window.ToTick, err = parseTickParam(c, "to_tick"); err != nil {
+		return window, err
+	}
+	if window.FromTick != nil && window.ToTick != nil && *window.ToTick < *window.FromTick {
+		return window, errors.New("to_tick must not be before from_tick")
+	}
+
+	if window.From, window.To, err = parseTimeWindow(c); err != nil {
+		return window, err
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+	case "asc":
+		window.Ascending = true
+	default:
+		return window, errors.New("order must be asc or desc")
+	}
+
+	window.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || window.Offset < 0 {
+		return window, errors.New("offset must be a non-negative integer")
+	}
+
+	return window, nil
+}
+
+// exportSimulationResults streams the results in a window as CSV or NDJSON
+// (format=csv|ndjson). Rows are read from a database cursor and sent in
+// chunks as they arrive, so exports of any size run in constant memory. An
+// optional limit caps the number of rows.
+func (s *Server) exportSimulationResults(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		s.handleError(c, errors.New("format must be csv or ndjson"), http.StatusBadRequest)
+		return
+	}
+
+	window, err := parseResultWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		window.Limit, err = strconv.Atoi(raw)
+		if err != nil || window.Limit < 1 {
+			s.handleError(c, errors.New("limit must be a positive integer"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	csvWriter := csv.NewWriter(c.Writer)
+	encoder := json.NewEncoder(c.Writer)
+
+	// The response is committed with the first row, so a query that fails
+	// before then can still be reported
+	written := 0
+	start := func() {
+		contentType := "text/csv"
+		if format == "ndjson" {
+			contentType = "application/x-ndjson"
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "results-"+simulationID.String()+"."+format))
+		c.Status(http.StatusOK)
+		if format == "csv" {
+			csvWriter.Write(resultCSVHeader)
+		}
+	}
+	flush := func() {
+		csvWriter.Flush()
+		c.Writer.Flush()
+	}
+
+	err = s.simulationService.StreamResults(c.Request.Context(), simulationID, window, func(result *database.SimulationResult) error {
+		if written == 0 {
+			start()
+		}
+		var err error
+		if format == "csv" {
+			err = csvWriter.Write(resultCSVRow(result))
+		} else {
+			err = encoder.Encode(result)
+		}
+		if err != nil {
+			return err
+		}
+		written++
+		if written%resultExportChunkRows == 0 {
+			flush()
+		}
+		return nil
+	})
+	if err != nil && written == 0 {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if written == 0 {
+		start()
+	}
+	flush()
+
+	if err != nil {
+		logrus.WithError(err).WithField("path", requestPath(c)).Warn("Result export aborted")
+		return
+	}
+	logrus.WithFields(logrus.Fields{
+		"path":   requestPath(c),
+		"format": format,
+		"rows":   written,
+	}).Info("Result export completed")
+}
+
+var resultCSVHeader = []string{
+	"id", "tick_number", "timestamp", "total_generation_mw", "total_consumption_mw",
+	"grid_frequency_hz", "grid_voltage_kv", "efficiency_percentage", "fault_count",
+}
+
+func resultCSVRow(result *database.SimulationResult) []string {
+	return []string{
+		result.ID.String(),
+		strconv.Itoa(result.TickNumber),
+		result.Timestamp.UTC().Format(time.RFC3339Nano),
+		strconv.FormatFloat(result.TotalGenerationMW, 'f', -1, 64),
+		strconv.FormatFloat(result.TotalConsumptionMW, 'f', -1, 64),
+		strconv.FormatFloat(result.GridFrequencyHz, 'f', -1, 64),
+		strconv.FormatFloat(result.GridVoltageKV, 'f', -1, 64),
+		strconv.FormatFloat(result.EfficiencyPercentage, 'f', -1, 64),
+		strconv.Itoa(result.FaultCount),
+	}
+}
+
+// parseTickParam reads an optional non-negative tick number
+func parseTickParam(c *gin.Context, key string) (*int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	tick, err := strconv.Atoi(raw)
+	if err != nil || tick < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative tick number", key)
+	}
+	return &tick, nil
+}
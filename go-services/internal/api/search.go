@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"voltedge/go-services/internal/search"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// searchCandidates is how many rows each database source contributes
+	// before hits are ranked
+	searchCandidates = 200
+)
+
+// Weights of the fields a search matches, names counting the most
+const (
+	searchWeightName        = 1.0
+	searchWeightTags        = 0.8
+	searchWeightDescription = 0.5
+	searchWeightMetadata    = 0.2
+)
+
+// globalSearch handles full-text search over simulation, experiment and
+// batch names, descriptions, tags and metadata, and over tags themselves.
+// ?type limits the hit types and ?limit the number of hits.
+func (s *Server) globalSearch(c *gin.Context) {
+	query := c.Query("q")
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		s.handleError(c, errors.New("q must contain at least one word"), http.StatusBadRequest)
+		return
+	}
+
+	types := splitQueryList(c.Query("type"))
+	for _, hitType := range types {
+		if !isSearchType(hitType) {
+			s.handleError(c, errors.New("invalid search type: "+hitType), http.StatusBadRequest)
+			return
+		}
+	}
+	if len(types) == 0 {
+		types = search.Types
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+	if limit < 1 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	var hits []search.Hit
+	add := func(hitType, id, title string, fields []search.Field) {
+		if score, highlights, ok := search.Match(terms, fields); ok {
+			hits = append(hits, search.Hit{
+				Type:       hitType,
+				ID:         id,
+				Title:      title,
+				Score:      score,
+				Highlights: highlights,
+			})
+		}
+	}
+
+	tsquery := search.TSQuery(terms)
+	for _, hitType := range types {
+		switch hitType {
+		case search.TypeSimulation:
+			simulations, _, err := s.orchestrator.ListSimulations(1, math.MaxInt32, "", nil)
+			if err != nil {
+				s.handleError(c, err, http.StatusInternalServerError)
+				return
+			}
+			loaded := make(map[string]bool, len(simulations))
+			for _, simulation := range simulations {
+				loaded[simulation.ID] = true
+				add(hitType, simulation.ID, simulation.Name, []search.Field{
+					{Name: "name", Text: simulation.Name, Weight: searchWeightName},
+					{Name: "tags", Text: strings.Join(simulation.Tags, " "), Weight: searchWeightTags},
+					{Name: "description", Text: simulation.Description, Weight: searchWeightDescription},
+					{Name: "metadata", Text: searchableMetadata(simulation.Metadata), Weight: searchWeightMetadata},
+				})
+			}
+
+			// Stored simulations that are not loaded are matched by the
+			// database first
+			persisted, err := s.simulationService.SearchSimulations(c.Request.Context(), tsquery, searchCandidates)
+			if err != nil {
+				s.handleError(c, err, http.StatusInternalServerError)
+				return
+			}
+			for _, simulation := range persisted {
+				if loaded[simulation.ID.String()] {
+					continue
+				}
+				add(hitType, simulation.ID.String(), simulation.Name, []search.Field{
+					{Name: "name", Text: simulation.Name, Weight: searchWeightName},
+					{Name: "tags", Text: strings.Join(simulation.Tags, " "), Weight: searchWeightTags},
+					{Name: "description", Text: simulation.Description, Weight: searchWeightDescription},
+					{Name: "metadata", Text: searchableMetadata(simulation.Metadata), Weight: searchWeightMetadata},
+				})
+			}
+		case search.TypeExperiment:
+			for _, experiment := range s.orchestrator.ListExperiments() {
+				add(hitType, experiment.ID, experiment.Name, []search.Field{
+					{Name: "name", Text: experiment.Name, Weight: searchWeightName},
+					{Name: "tags", Text: strings.Join(experiment.Tags, " "), Weight: searchWeightTags},
+				})
+			}
+		case search.TypeBatch:
+			for _, batch := range s.orchestrator.ListBatches() {
+				add(hitType, batch.ID, batch.Name, []search.Field{
+					{Name: "name", Text: batch.Name, Weight: searchWeightName},
+					{Name: "tags", Text: strings.Join(batch.Tags, " "), Weight: searchWeightTags},
+				})
+			}
+		case search.TypeTag:
+			tags, err := s.tagService.SearchTags(tsquery, searchCandidates)
+			if err != nil {
+				s.handleError(c, err, http.StatusInternalServerError)
+				return
+			}
+			for _, tag := range tags {
+				add(hitType, tag.ID.String(), tag.Name, []search.Field{
+					{Name: "name", Text: tag.Name, Weight: searchWeightName},
+					{Name: "description", Text: tag.Description, Weight: searchWeightDescription},
+				})
+			}
+		}
+	}
+
+	search.Sort(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	if hits == nil {
+		hits = []search.Hit{}
+	}
+
+	s.handleSuccess(c, gin.H{
+		"query": query,
+		"terms": terms,
+		"hits":  hits,
+		"count": len(hits),
+	}, "Search completed successfully")
+}
+
+// searchableMetadata renders metadata as text for matching
+func searchableMetadata(metadata map[string]interface{}) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func isSearchType(hitType string) bool {
+	for _, known := range search.Types {
+		if hitType == known {
+			return true
+		}
+	}
+	return false
+}
@@ -1,37 +1,172 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"voltedge/go-services/internal/archive"
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/cache"
+	"voltedge/go-services/internal/chaos"
+	"voltedge/go-services/internal/cluster"
 	"voltedge/go-services/internal/config"
 	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/engine"
 	"voltedge/go-services/internal/grpc"
+	"voltedge/go-services/internal/notifications"
 	"voltedge/go-services/internal/observability"
 	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/internal/playback"
+	"voltedge/go-services/internal/prediction"
+	"voltedge/go-services/internal/realtime"
+	"voltedge/go-services/internal/reconcile"
+	"voltedge/go-services/internal/recording"
+	"voltedge/go-services/internal/report"
+	"voltedge/go-services/internal/selftest"
+	"voltedge/go-services/internal/snapshot"
+	"voltedge/go-services/internal/usage"
 )
 
+// Dependencies holds the services the API server is wired to
+type Dependencies struct {
+	Orchestrator      *orchestration.Orchestrator
+	GRPCClient        *grpc.Client
+	SimulationService *database.SimulationService
+	WebhookService    *database.WebhookService
+	MetadataService   *database.MetadataService
+	UserService       *database.UserService
+	AuditService      *database.AuditService
+	TagService        *database.TagService
+	DashboardService  *database.DashboardService
+	PreferenceService *database.NotificationPreferenceService
+	Tokens            *auth.TokenManager
+	Notifier          *notifications.Dispatcher
+	Engines           *engine.Registry
+	Predictions       *prediction.Service
+	Playback          *playback.Manager
+	Reconciler        *reconcile.Reconciler
+	Cluster           *cluster.Manager
+	Archiver          *archive.Archiver
+	AlertRouting      *database.AlertRoutingService
+	AlertRouter       *notifications.AlertRouter
+	Snapshots         *snapshot.Snapshots
+	// Optional; chaos experiments cannot be run when nil
+	Chaos *chaos.Controller
+	// Optional; API traffic is recorded for replay when set
+	Recorder *recording.Recorder
+	// Optional; usage is not metered when nil
+	Usage *usage.Meter
+	// Optional; the server creates its own hub when nil
+	Realtime *realtime.Hub
+	// Optional; database pool stats are not reported when nil
+	DatabasePool *database.PoolMonitor
+	// Optional; run reports cannot be rendered when nil
+	Reports *report.Generator
+	// Optional; simulations cannot be shared by link when nil
+	ShareLinks *database.ShareLinkService
+	// Optional; /readyz does not wait for a self-test when nil
+	SelfTest *selftest.SelfTest
+	// Optional; logins are not recorded, accounts are not locked and
+	// sessions cannot be listed or revoked when nil
+	UserSessions *database.SessionService
+}
+
 // Server represents the API server
 type Server struct {
 	config            *config.APIConfig
 	orchestrator      *orchestration.Orchestrator
 	grpcClient        *grpc.Client
 	simulationService *database.SimulationService
-	router            *gin.Engine
+	webhookService    *database.WebhookService
+	metadataService   *database.MetadataService
+	userService       *database.UserService
+	auditService      *database.AuditService
+	tagService        *database.TagService
+	dashboardService  *database.DashboardService
+	preferenceService *database.NotificationPreferenceService
+	tokens            *auth.TokenManager
+	notifier          *notifications.Dispatcher
+	engines           *engine.Registry
+	predictions       *prediction.Service
+	playback          *playback.Manager
+	reconciler        *reconcile.Reconciler
+	cluster           *cluster.Manager
+	archiver          *archive.Archiver
+	alertRouting      *database.AlertRoutingService
+	alertRouter       *notifications.AlertRouter
+	snapshots         *snapshot.Snapshots
+	chaos             *chaos.Controller
+	recorder          *recording.Recorder
+	meter             *usage.Meter
+	hub               *realtime.Hub
+	databasePool      *database.PoolMonitor
+	reports           *report.Generator
+	shareLinks        *database.ShareLinkService
+	selfTest          *selftest.SelfTest
+	userSessions      *database.SessionService
+	serviceTokens     *auth.ServiceTokens
+	readCaches        readCaches
+	// Whether sessions were revoked, by session ID
+	sessionRevocations *cache.Cache[bool]
+	router             *gin.Engine
+	// Serves the internal routes when they have a listener of their own
+	internalRouter *gin.Engine
 }
 
 // NewServer creates a new API server
-func NewServer(cfg *config.APIConfig, orchestrator *orchestration.Orchestrator, grpcClient *grpc.Client, simulationService *database.SimulationService) *Server {
+func NewServer(cfg *config.APIConfig, deps Dependencies) *Server {
 	server := &Server{
 		config:            cfg,
-		orchestrator:      orchestrator,
-		grpcClient:        grpcClient,
-		simulationService: simulationService,
+		orchestrator:      deps.Orchestrator,
+		grpcClient:        deps.GRPCClient,
+		simulationService: deps.SimulationService,
+		webhookService:    deps.WebhookService,
+		metadataService:   deps.MetadataService,
+		userService:       deps.UserService,
+		auditService:      deps.AuditService,
+		tagService:        deps.TagService,
+		dashboardService:  deps.DashboardService,
+		preferenceService: deps.PreferenceService,
+		tokens:            deps.Tokens,
+		notifier:          deps.Notifier,
+		engines:           deps.Engines,
+		predictions:       deps.Predictions,
+		playback:          deps.Playback,
+		reconciler:        deps.Reconciler,
+		cluster:           deps.Cluster,
+		archiver:          deps.Archiver,
+		alertRouting:      deps.AlertRouting,
+		alertRouter:       deps.AlertRouter,
+		snapshots:         deps.Snapshots,
+		chaos:             deps.Chaos,
+		recorder:          deps.Recorder,
+		meter:             deps.Usage,
+		hub:               deps.Realtime,
+		databasePool:      deps.DatabasePool,
+		reports:           deps.Reports,
+		shareLinks:        deps.ShareLinks,
+		selfTest:          deps.SelfTest,
+		userSessions:      deps.UserSessions,
+		readCaches:        newReadCaches(cfg.ReadCache),
+
+		sessionRevocations: cache.New[bool]("session_revocations", cache.Options{
+			TTL:        cfg.Login.RevocationCheckTTL,
+			MaxEntries: 10000,
+		}),
+	}
+	if cfg.Internal.ServiceSecret != "" {
+		server.serviceTokens = auth.NewServiceTokens(cfg.Internal.ServiceSecret)
+	}
+	if server.hub == nil {
+		server.hub = realtime.NewHub()
+	}
+	if server.orchestrator != nil {
+		server.orchestrator.OnTransition(server.publishTransition)
 	}
 
 	server.setupRouter()
@@ -46,35 +181,185 @@ func (s *Server) setupRouter() {
 	}
 
 	s.router = gin.New()
+	s.router.MaxMultipartMemory = s.config.Limits.MultipartMemoryBytes
 
 	// Add middleware
 	s.router.Use(gin.LoggerWithFormatter(s.loggerFormatter))
-	s.router.Use(gin.Recovery())
+	s.router.Use(gin.CustomRecovery(recoverPanic))
+	if s.config.SecurityHeaders.Enabled {
+		s.router.Use(s.securityHeadersMiddleware())
+	}
 	s.router.Use(s.metricsMiddleware())
 	s.router.Use(s.corsMiddleware())
+	s.router.Use(s.authMiddleware())
+	s.router.Use(s.csrfMiddleware())
+	s.router.Use(s.auditMiddleware())
+	s.router.Use(s.usageMiddleware())
+	if s.config.Compression.Enabled {
+		s.router.Use(s.compressionMiddleware())
+	}
+	// After compression, so responses are recorded before they are encoded
+	if s.recorder != nil {
+		s.router.Use(s.recordingMiddleware())
+	}
+	s.router.Use(s.limitsMiddleware())
 
 	// Add routes
 	s.setupRoutes()
+	if s.config.Internal.Port != "" {
+		s.setupInternalRouter()
+	}
 }
 
 // setupRoutes configures all API routes
 func (s *Server) setupRoutes() {
 	// Health check endpoint
 	s.router.GET("/health", s.healthCheck)
+	s.router.GET("/readyz", s.readinessCheck)
 
 	// API v1 routes
 	v1 := s.router.Group("/api/v1")
 	{
+		// Authentication
+		v1.POST("/auth/login", s.login)
+		v1.POST("/auth/logout", s.logout)
+
+		// The caller's login history and sessions
+		me := v1.Group("/users/me")
+		{
+			me.GET("/logins", s.listMyLogins)
+			me.GET("/sessions", s.listMySessions)
+			me.DELETE("/sessions/:id", s.revokeMySession)
+		}
+
 		// Simulation management
 		simulations := v1.Group("/simulations")
 		{
 			simulations.POST("", s.createSimulation)
 			simulations.GET("", s.listSimulations)
+			simulations.POST("/import", s.importSimulation)
 			simulations.GET("/:id", s.getSimulation)
+			simulations.PATCH("/:id", s.updateSimulation)
 			simulations.DELETE("/:id", s.deleteSimulation)
 			simulations.POST("/:id/start", s.startSimulation)
 			simulations.POST("/:id/stop", s.stopSimulation)
 			simulations.POST("/:id/pause", s.pauseSimulation)
+			simulations.POST("/:id/resume", s.resumeSimulation)
+			simulations.POST("/:id/speed", s.setSimulationSpeed)
+			simulations.POST("/:id/step", s.stepSimulation)
+			simulations.POST("/:id/plan", s.planSimulationConfig)
+			simulations.POST("/:id/apply", s.applySimulationConfig)
+			simulations.POST("/:id/components", s.modifySimulationComponents)
+			simulations.GET("/:id/snapshot", s.takeSnapshot)
+			simulations.GET("/:id/snapshots", s.listSnapshots)
+			simulations.GET("/:id/snapshots/:version", s.getSnapshot)
+			simulations.GET("/:id/snapshots/:version/diff/:other_version", s.diffSnapshots)
+			simulations.GET("/:id/pipeline", s.getSimulationPipeline)
+			simulations.GET("/:id/metrics/query", s.queryComponentMetrics)
+			simulations.GET("/:id/diff/:other_id", s.diffSimulations)
+			simulations.GET("/:id/export", s.exportSimulation)
+			simulations.POST("/:id/redispatch", s.redispatchSimulation)
+			simulations.POST("/:id/rerun", s.rerunSimulation)
+			simulations.GET("/:id/faults", s.listFaultEvents)
+			simulations.GET("/:id/faults/counts", s.countFaultEvents)
+			simulations.GET("/:id/faults/export", s.exportFaultEvents)
+			simulations.GET("/:id/alerts", s.listAlerts)
+			simulations.GET("/:id/alerts/counts", s.countAlerts)
+			simulations.GET("/:id/alerts/export", s.exportAlerts)
+			simulations.GET("/:id/timeline", s.getSimulationTimeline)
+			simulations.GET("/:id/statistics", s.getSimulationStatistics)
+			simulations.GET("/:id/report", s.getSimulationReport)
+			simulations.GET("/:id/results", s.listSimulationResults)
+			simulations.GET("/:id/results/latest", s.getLatestResults)
+			simulations.GET("/:id/results/export", s.exportSimulationResults)
+			simulations.POST("/:id/share-links", s.createShareLink)
+			simulations.GET("/:id/share-links", s.listShareLinks)
+			simulations.DELETE("/:id/share-links/:link_id", s.revokeShareLink)
+		}
+
+		// Read-only access to one simulation through a share link, without login
+		shared := v1.Group("/shared/:token", s.shareLinkMiddleware())
+		{
+			shared.GET("", s.getSharedSimulation)
+			shared.GET("/state", s.getGridState)
+			shared.GET("/statistics", s.getSimulationStatistics)
+			shared.GET("/results", s.listSimulationResults)
+			shared.GET("/results/latest", s.getLatestResults)
+			shared.GET("/report", s.getSimulationReport)
+		}
+
+		// Alert acknowledgement
+		alerts := v1.Group("/alerts")
+		{
+			alerts.POST("/ack", s.acknowledgeAlerts)
+			alerts.POST("/:id/ack", s.acknowledgeAlert)
+			alerts.POST("/:id/resolve", s.resolveAlert)
+		}
+
+		// Grafana JSON datasource
+		grafana := v1.Group("/grafana")
+		{
+			grafana.GET("", s.grafanaTest)
+			grafana.GET("/", s.grafanaTest)
+			grafana.POST("/search", s.grafanaSearch)
+			grafana.POST("/query", s.grafanaQuery)
+			grafana.POST("/annotations", s.grafanaAnnotations)
+			grafana.GET("/series", s.grafanaSeries)
+		}
+
+		// Monte Carlo batches
+		batches := v1.Group("/batches")
+		{
+			batches.POST("", s.createBatch)
+			batches.GET("", s.listBatches)
+			batches.GET("/:id", s.getBatch)
+			batches.POST("/:id/cancel", s.cancelBatch)
+		}
+
+		// Parameter sweep experiments
+		experiments := v1.Group("/experiments")
+		{
+			experiments.POST("", s.createExperiment)
+			experiments.GET("", s.listExperiments)
+			experiments.GET("/:id", s.getExperiment)
+			experiments.POST("/:id/cancel", s.cancelExperiment)
+		}
+
+		// Global full-text search
+		v1.GET("/search", s.globalSearch)
+
+		// Tags on simulations, experiments and batches
+		tags := v1.Group("/tags")
+		{
+			tags.POST("", s.createTag)
+			tags.GET("", s.listTags)
+			tags.GET("/autocomplete", s.autocompleteTags)
+			tags.GET("/search", s.searchTaggedResources)
+			tags.POST("/merge", s.mergeTags)
+			tags.GET("/:id", s.getTag)
+			tags.PUT("/:id", s.updateTag)
+			tags.DELETE("/:id", s.deleteTag)
+		}
+
+		// Saved views and dashboards
+		dashboards := v1.Group("/dashboards")
+		{
+			dashboards.POST("", s.createDashboard)
+			dashboards.GET("", s.listDashboards)
+			dashboards.GET("/:id", s.getDashboard)
+			dashboards.PUT("/:id", s.updateDashboard)
+			dashboards.DELETE("/:id", s.deleteDashboard)
+			dashboards.PUT("/:id/shares", s.setDashboardShares)
+			dashboards.GET("/:id/revisions", s.listDashboardRevisions)
+			dashboards.GET("/:id/revisions/:version", s.getDashboardRevision)
+			dashboards.POST("/:id/revisions/:version/restore", s.restoreDashboardRevision)
+		}
+
+		// Simulation engines
+		engines := v1.Group("/engines")
+		{
+			engines.GET("", s.listEngines)
+			engines.GET("/:name", s.getEngine)
 		}
 
 		// Grid management
@@ -82,6 +367,8 @@ func (s *Server) setupRoutes() {
 		{
 			grid.GET("/state/:simulation_id", s.getGridState)
 			grid.GET("/components/:simulation_id", s.getGridComponents)
+			grid.GET("/topology/:simulation_id", s.getGridTopology)
+			grid.GET("/geojson/:simulation_id", s.getGridGeoJSON)
 			grid.POST("/failures/:simulation_id", s.injectFailure)
 		}
 
@@ -107,6 +394,109 @@ func (s *Server) setupRoutes() {
 			analytics.GET("/performance/:simulation_id", s.getPerformanceMetrics)
 			analytics.GET("/history/:simulation_id", s.getSimulationHistory)
 			analytics.GET("/predictions/:simulation_id", s.getPredictions)
+			analytics.GET("/predictions/:simulation_id/accuracy", s.getPredictionAccuracy)
+			analytics.GET("/emissions/:simulation_id", s.getEmissions)
+			analytics.GET("/costs/:simulation_id", s.getCosts)
+			analytics.GET("/costs/:simulation_id/dispatch", s.getDispatchSuggestion)
+			analytics.GET("/energy/:simulation_id", s.getEnergyBalance)
+			analytics.GET("/reliability/:simulation_id", s.getReliability)
+			analytics.GET("/stability", s.rankStability)
+			analytics.GET("/stability/:simulation_id", s.getStabilityScore)
+			analytics.GET("/top/:simulation_id", s.getTopComponents)
+			analytics.GET("/percentiles/:simulation_id", s.getPercentiles)
+		}
+
+		// Historical playback
+		playbackRoutes := v1.Group("/playback")
+		{
+			playbackRoutes.POST("", s.createPlayback)
+			playbackRoutes.GET("/:id", s.getPlayback)
+			playbackRoutes.DELETE("/:id", s.deletePlayback)
+			playbackRoutes.POST("/:id/control", s.controlPlayback)
+			playbackRoutes.GET("/:id/stream", s.streamPlayback)
+		}
+
+		// Webhook subscriptions and notification templates
+		webhooks := v1.Group("/webhooks")
+		{
+			webhooks.POST("", s.createWebhook)
+			webhooks.GET("", s.listWebhooks)
+			webhooks.GET("/:id", s.getWebhook)
+			webhooks.PUT("/:id", s.updateWebhook)
+			webhooks.DELETE("/:id", s.deleteWebhook)
+			webhooks.POST("/:id/preview", s.previewWebhook)
+		}
+
+		notificationRoutes := v1.Group("/notifications")
+		{
+			notificationRoutes.GET("/templates", s.listNotificationTemplates)
+			notificationRoutes.POST("/templates/preview", s.previewNotificationTemplate)
+			notificationRoutes.GET("/preferences", s.getNotificationPreferences)
+			notificationRoutes.PUT("/preferences", s.setNotificationPreferences)
+			notificationRoutes.DELETE("/preferences", s.resetNotificationPreferences)
+		}
+
+		// Alert routing to Slack, PagerDuty and email, and silences muting it
+		alerting := v1.Group("/alerting")
+		{
+			alerting.POST("/channels", s.createChannel)
+			alerting.GET("/channels", s.listChannels)
+			alerting.GET("/channels/:id", s.getChannel)
+			alerting.PUT("/channels/:id", s.updateChannel)
+			alerting.DELETE("/channels/:id", s.deleteChannel)
+			alerting.POST("/channels/:id/test", s.testChannel)
+			alerting.POST("/routes", s.createAlertRoute)
+			alerting.GET("/routes", s.listAlertRoutes)
+			alerting.GET("/routes/:id", s.getAlertRoute)
+			alerting.PUT("/routes/:id", s.updateAlertRoute)
+			alerting.DELETE("/routes/:id", s.deleteAlertRoute)
+			alerting.GET("/notifications", s.listAlertNotifications)
+			alerting.POST("/silences", s.createSilence)
+			alerting.GET("/silences", s.listSilences)
+			alerting.GET("/silences/:id", s.getSilence)
+			alerting.PUT("/silences/:id", s.updateSilence)
+			alerting.DELETE("/silences/:id", s.deleteSilence)
+			alerting.POST("/silences/:id/expire", s.expireSilence)
+		}
+
+		// Stored artifacts
+		v1.GET("/artifacts/:id", s.getArtifact)
+
+		// Administration
+		admin := v1.Group("/admin", s.requireRole(auth.RoleAdmin))
+		{
+			admin.GET("/metadata/largest", s.getLargestMetadata)
+			admin.POST("/metadata/migrate", s.migrateOversizedMetadata)
+			admin.POST("/impersonate", s.impersonateUser)
+			admin.GET("/audit", s.listAuditLogs)
+			admin.GET("/db/stats", s.getDatabaseStats)
+			admin.GET("/log-level", s.getLogLevel)
+			admin.PUT("/log-level", s.updateLogLevel)
+			admin.GET("/reconciliation", s.getReconciliation)
+			admin.POST("/reconciliation/run", s.runReconciliation)
+			admin.GET("/cluster", s.getCluster)
+			admin.GET("/archive", s.getArchive)
+			admin.POST("/archive/run", s.runArchive)
+			admin.POST("/archive/simulations/:id", s.archiveSimulation)
+			admin.POST("/archive/simulations/:id/rehydrate", s.rehydrateSimulation)
+			admin.PUT("/organizations/:id/quotas", s.updateOrganizationQuotas)
+			admin.PUT("/organizations/:id/concurrency", s.updateOrganizationConcurrency)
+			admin.GET("/chaos", s.getChaos)
+			admin.PUT("/chaos/:fault", s.startChaosExperiment)
+			admin.DELETE("/chaos/:fault", s.stopChaosExperiment)
+			admin.DELETE("/chaos", s.stopChaosExperiments)
+			admin.GET("/jobs", s.listDeadLetters)
+			admin.GET("/jobs/:id", s.getDeadLetter)
+			admin.POST("/jobs/:id/retry", s.retryDeadLetter)
+		}
+
+		// Organizations
+		v1.PUT("/organizations/:id/impersonation-policy", s.updateImpersonationPolicy)
+		v1.GET("/organizations/:id/usage", s.getOrganizationUsage)
+
+		// Engine result ingest with the shared ingest token
+		if s.config.Internal.Port == "" {
+			v1.POST("/internal/results:batch", s.ingestMiddleware(), s.ingestResultBatch)
 		}
 
 		// Real-time data streaming
@@ -117,6 +507,11 @@ func (s *Server) setupRoutes() {
 		}
 	}
 
+	// Service-to-service routes, unless they have a listener of their own
+	if s.config.Internal.Port == "" {
+		s.setupInternalRoutes(s.router)
+	}
+
 	// WebSocket endpoint
 	s.router.GET(s.config.WebSocketPath, s.handleWebSocket)
 
@@ -137,7 +532,7 @@ func (s *Server) loggerFormatter(param gin.LogFormatterParams) string {
 	return fmt.Sprintf("%s [%s] %s %s %d %s %s %s\n",
 		param.TimeStamp.Format(time.RFC3339),
 		param.Method,
-		param.Path,
+		redactPath(param.Path),
 		param.Request.Proto,
 		param.StatusCode,
 		param.Latency,
@@ -154,34 +549,21 @@ func (s *Server) metricsMiddleware() gin.HandlerFunc {
 		c.Next()
 
 		duration := time.Since(start)
-		observability.RecordHTTPRequest(c.Request.Method, c.Request.URL.Path, fmt.Sprintf("%d", c.Writer.Status()), duration)
-	}
-}
-
-// corsMiddleware configures CORS
-func (s *Server) corsMiddleware() gin.HandlerFunc {
-	config := cors.Config{
-		AllowOrigins:     s.config.CORSOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
+		observability.RecordHTTPRequest(c.Request.Method, requestPath(c), fmt.Sprintf("%d", c.Writer.Status()), duration)
 	}
-
-	return cors.New(config)
 }
 
 // healthCheck handles health check requests
 func (s *Server) healthCheck(c *gin.Context) {
+	services := map[string]interface{}{
+		"orchestrator": s.orchestrator.Health(),
+		"grpc_client":  s.grpcClient.Health(),
+	}
 	health := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now().UTC(),
 		"version":   "1.0.0",
-		"services": map[string]interface{}{
-			"orchestrator": s.orchestrator.Health(),
-			"grpc_client":  s.grpcClient.Health(),
-		},
+		"services":  services,
 	}
 
 	// Check if any service is unhealthy
@@ -191,9 +573,49 @@ func (s *Server) healthCheck(c *gin.Context) {
 		return
 	}
 
+	// A saturated pool still serves requests, only slower
+	if s.databasePool != nil {
+		pool := s.databasePool.Health()
+		services["database_pool"] = pool
+		if !pool.IsHealthy {
+			health["status"] = "degraded"
+		}
+	}
+
 	c.JSON(http.StatusOK, health)
 }
 
+// ReadinessResponse is the gateway's readiness check
+type ReadinessResponse struct {
+	// ready or not_ready
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	// Last startup self-test; unset while it runs or when none is enabled
+	SelfTest        *selftest.Report `json:"self_test,omitempty"`
+	SelfTestPending bool             `json:"self_test_pending,omitempty"`
+}
+
+// readinessCheck reports whether the gateway should receive traffic: its
+// services are healthy and the startup self-test, if enabled, has passed
+func (s *Server) readinessCheck(c *gin.Context) {
+	ready := s.orchestrator.Health().IsHealthy && s.grpcClient.Health().IsHealthy
+	readiness := ReadinessResponse{Timestamp: time.Now().UTC()}
+
+	if s.selfTest != nil {
+		readiness.SelfTest = s.selfTest.LastReport()
+		readiness.SelfTestPending = readiness.SelfTest == nil
+		ready = ready && readiness.SelfTest != nil && readiness.SelfTest.Passed
+	}
+
+	if !ready {
+		readiness.Status = "not_ready"
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+	readiness.Status = "ready"
+	c.JSON(http.StatusOK, readiness)
+}
+
 // ErrorResponse represents an API error response
 type ErrorResponse struct {
 	Error   string                 `json:"error"`
@@ -211,7 +633,21 @@ type SuccessResponse struct {
 
 // handleError handles API errors consistently
 func (s *Server) handleError(c *gin.Context, err error, statusCode int) {
-	logrus.WithError(err).WithField("path", c.Request.URL.Path).Error("API error")
+	// Bodies over the route limit surface from whichever reader hit it
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		statusCode = http.StatusRequestEntityTooLarge
+	}
+	// A simulation that could not be loaded may well exist, whatever the
+	// handler expected the lookup to fail with
+	if errors.Is(err, orchestration.ErrStorageUnavailable) {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	logrus.WithError(err).WithField("path", requestPath(c)).Error("API error")
+	if statusCode >= http.StatusInternalServerError {
+		reportError(c, err, statusCode, observability.ErrorLevelError)
+	}
 
 	response := ErrorResponse{
 		Error:   http.StatusText(statusCode),
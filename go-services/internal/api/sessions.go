@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/auth"
+)
+
+// csrfHeader carries the CSRF token of a cookie session
+const csrfHeader = "X-CSRF-Token"
+
+// sessionKey marks requests authenticated by the session cookie rather than
+// a bearer token
+const sessionKey = "session_auth"
+
+// sessionAuth authenticates a request by its session cookie. A missing,
+// expired, revoked or invalid cookie leaves the request anonymous.
+func (s *Server) sessionAuth(c *gin.Context) {
+	if !s.config.Sessions.Enabled || s.tokens == nil {
+		return
+	}
+	token, err := c.Cookie(s.config.Sessions.CookieName)
+	if err != nil || token == "" {
+		return
+	}
+	claims, err := s.tokens.Parse(token)
+	if err != nil {
+		return
+	}
+	if revoked, err := s.sessionRevoked(c.Request.Context(), claims); err != nil || revoked {
+		return
+	}
+
+	c.Set(claimsKey, claims)
+	c.Set(sessionKey, true)
+}
+
+// csrfMiddleware requires the session's CSRF token on state-changing
+// requests authenticated by the session cookie. A cross-site page can make
+// the browser send the cookie but cannot read the token to send along.
+// Bearer token, service token and cluster requests are not cookie based and
+// are exempt.
+func (s *Server) csrfMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if !c.GetBool(sessionKey) {
+			c.Next()
+			return
+		}
+
+		if !s.tokens.VerifyCSRFToken(currentClaims(c), c.GetHeader(csrfHeader)) {
+			s.handleError(c, errors.New("missing or invalid CSRF token"), http.StatusForbidden)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// setSessionCookies starts a cookie session for a token: the session cookie
+// itself, which scripts cannot read, and the CSRF cookie, which the web UI
+// reads to send the CSRF header
+func (s *Server) setSessionCookies(c *gin.Context, token string, claims *auth.Claims) string {
+	csrfToken := s.tokens.CSRFToken(claims)
+	maxAge := int(time.Until(claims.ExpiresAt.Time).Seconds())
+
+	s.setCookie(c, s.config.Sessions.CookieName, token, maxAge, true)
+	s.setCookie(c, s.config.Sessions.CSRFCookieName, csrfToken, maxAge, false)
+	return csrfToken
+}
+
+// logout ends a cookie session and revokes its token. Tokens issued to API
+// clients stay valid until they expire or are revoked.
+func (s *Server) logout(c *gin.Context) {
+	if !s.config.Sessions.Enabled {
+		s.handleError(c, errors.New("cookie sessions are not enabled"), http.StatusNotFound)
+		return
+	}
+
+	if claims := currentClaims(c); claims != nil && c.GetBool(sessionKey) && s.userSessions != nil {
+		if id, err := uuid.Parse(claims.ID); err == nil {
+			if _, err := s.userSessions.RevokeSession(c.Request.Context(), claims.UserID, id); err != nil {
+				s.handleError(c, err, http.StatusInternalServerError)
+				return
+			}
+			s.sessionRevocations.Invalidate(claims.ID)
+		}
+	}
+
+	s.setCookie(c, s.config.Sessions.CookieName, "", -1, true)
+	s.setCookie(c, s.config.Sessions.CSRFCookieName, "", -1, false)
+	s.handleSuccess(c, nil, "Logged out")
+}
+
+func (s *Server) setCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	sessions := s.config.Sessions
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   sessions.Domain,
+		MaxAge:   maxAge,
+		Secure:   sessions.Secure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite(sessions.SameSite),
+	})
+}
+
+// sameSite converts a configured SameSite mode
+func sameSite(mode string) http.SameSite {
+	switch mode {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
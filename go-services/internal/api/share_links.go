@@ -0,0 +1,290 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// shareLinkKey is the gin context key holding the share link of a shared request
+const shareLinkKey = "share_link"
+
+// sharedTokenPattern matches the share token in shared paths, including
+// those forwarded to another replica
+var sharedTokenPattern = regexp.MustCompile(`^(/(?:api|internal)/v1/shared/)[^/]+`)
+
+// ShareLinkRequest represents a request to create a share link
+type ShareLinkRequest struct {
+	Label string `json:"label" binding:"max=200"`
+	// Lifetime of the link; the configured default when zero
+	ExpiresInHours int `json:"expires_in_hours" binding:"min=0"`
+}
+
+// ShareLinkResponse is a newly created share link with its token. The token
+// is not stored and cannot be retrieved again. The shared simulation is at
+// /api/v1/shared/{token}; the path is left out of the response so that only
+// the token field, which recordings redact, carries it.
+type ShareLinkResponse struct {
+	database.ShareLink
+	Token string `json:"token"`
+}
+
+// SharedSimulationResponse is what a share link shows of a simulation
+type SharedSimulationResponse struct {
+	Simulation SimulationResponse `json:"simulation"`
+	ExpiresAt  time.Time          `json:"expires_at"`
+	Label      string             `json:"label,omitempty"`
+}
+
+// createShareLink creates an expiring link that gives read-only access to a
+// simulation without logging in
+func (s *Server) createShareLink(c *gin.Context) {
+	claims, simulationID, ok := s.loadShareableSimulation(c)
+	if !ok {
+		return
+	}
+
+	var req ShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	limits := s.config.ShareLinks
+	ttl := limits.DefaultTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	if ttl > limits.MaxTTL {
+		s.handleError(c, fmt.Errorf("share links expire after at most %.0f hours", limits.MaxTTL.Hours()), http.StatusBadRequest)
+		return
+	}
+
+	link := &database.ShareLink{
+		ID:           database.NewID(),
+		SimulationID: simulationID,
+		Label:        req.Label,
+		CreatedBy:    claims.UserID,
+		ExpiresAt:    time.Now().UTC().Add(ttl),
+	}
+	token, err := s.tokens.IssueShare(link.ID, link.SimulationID, link.ExpiresAt)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if err := s.shareLinks.CreateShareLink(link); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": link.SimulationID,
+		"share_link_id": link.ID,
+		"expires_at":    link.ExpiresAt,
+	}).Info("Share link created")
+
+	s.handleSuccess(c, ShareLinkResponse{
+		ShareLink: *link,
+		Token:     token,
+	}, "Share link created successfully")
+}
+
+// listShareLinks returns a simulation's share links with their access counts
+func (s *Server) listShareLinks(c *gin.Context) {
+	_, simulationID, ok := s.loadShareableSimulation(c)
+	if !ok {
+		return
+	}
+
+	links, err := s.shareLinks.ListShareLinks(simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, links, "Share links retrieved successfully")
+}
+
+// revokeShareLink revokes a share link so its token stops working
+func (s *Server) revokeShareLink(c *gin.Context) {
+	claims, simulationID, ok := s.loadShareableSimulation(c)
+	if !ok {
+		return
+	}
+
+	linkID, err := uuid.Parse(c.Param("link_id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid share link id"), http.StatusBadRequest)
+		return
+	}
+
+	link, err := s.shareLinks.GetShareLink(linkID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if link == nil || link.SimulationID != simulationID {
+		s.handleError(c, errors.New("share link not found"), http.StatusNotFound)
+		return
+	}
+
+	if _, err := s.shareLinks.RevokeShareLink(linkID, claims.UserID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Share link revoked successfully")
+}
+
+// loadShareableSimulation fetches the simulation named by the id path
+// parameter if the caller may manage its share links, and returns its ID.
+// It writes the error response itself.
+func (s *Server) loadShareableSimulation(c *gin.Context) (*auth.Claims, uuid.UUID, bool) {
+	if s.shareLinks == nil || s.tokens == nil {
+		s.handleError(c, errors.New("share links are not configured"), http.StatusServiceUnavailable)
+		return nil, uuid.Nil, false
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return nil, uuid.Nil, false
+	}
+
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, orchestration.ErrSimulationNotFound) {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return nil, uuid.Nil, false
+	}
+	if !canShareSimulation(claims, simulation) {
+		s.handleError(c, errors.New("only the simulation's owner or organization can share it"), http.StatusForbidden)
+		return nil, uuid.Nil, false
+	}
+
+	// Share links are stored against the simulation's row, which legacy
+	// sim_* IDs do not have
+	simulationID, err := uuid.Parse(simulation.ID)
+	if err != nil {
+		s.handleError(c, fmt.Errorf("simulation %s has a legacy id and cannot be shared", simulation.ID), http.StatusUnprocessableEntity)
+		return nil, uuid.Nil, false
+	}
+
+	return claims, simulationID, true
+}
+
+// canShareSimulation reports whether a user may manage a simulation's share
+// links: admins, its owner and members of its organization. Simulations
+// created anonymously can be shared by anyone logged in.
+func canShareSimulation(claims *auth.Claims, simulation *orchestration.Simulation) bool {
+	switch {
+	case claims.IsAdmin():
+		return true
+	case simulation.OwnerID == nil && simulation.OrganizationID == nil:
+		return true
+	case simulation.OwnerID != nil && *simulation.OwnerID == claims.UserID:
+		return true
+	default:
+		return simulation.OrganizationID != nil && claims.OrganizationID != nil && *simulation.OrganizationID == *claims.OrganizationID
+	}
+}
+
+// requestPath returns the request's path with any share token redacted, for
+// logs, metrics, audit entries and recordings. The token grants access to
+// the simulation to whoever reads it.
+func requestPath(c *gin.Context) string {
+	return redactPath(c.Request.URL.Path)
+}
+
+// redactPath replaces the share token in a shared path
+func redactPath(path string) string {
+	return sharedTokenPattern.ReplaceAllString(path, "${1}redacted")
+}
+
+// shareLinkMiddleware admits requests whose token path parameter is an active
+// share link, counts the access and points the id and simulation_id path
+// parameters at the shared simulation, so the read handlers serve it as is
+func (s *Server) shareLinkMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.shareLinks == nil || s.tokens == nil {
+			s.handleError(c, errors.New("share links are not configured"), http.StatusServiceUnavailable)
+			c.Abort()
+			return
+		}
+
+		// The token is in the URL; keep it out of caches and referrers
+		c.Header("Cache-Control", "private, no-store")
+		c.Header("Referrer-Policy", "no-referrer")
+		c.Header("X-Robots-Tag", "noindex")
+
+		claims, err := s.tokens.ParseShare(c.Param("token"))
+		if err != nil {
+			s.handleError(c, errors.New("share link is invalid or has expired"), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+		linkID, err := uuid.Parse(claims.ID)
+		if err != nil {
+			s.handleError(c, errors.New("share link is invalid or has expired"), http.StatusUnauthorized)
+			c.Abort()
+			return
+		}
+
+		link, err := s.shareLinks.GetShareLink(linkID)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			c.Abort()
+			return
+		}
+		now := time.Now().UTC()
+		if link == nil || link.SimulationID != claims.SimulationID || !link.Active(now) {
+			s.handleError(c, errors.New("share link was revoked or has expired"), http.StatusGone)
+			c.Abort()
+			return
+		}
+
+		// A failed count must not deny access
+		_ = s.shareLinks.RecordShareAccess(link.ID, now)
+
+		c.Set(shareLinkKey, link)
+		simulationID := link.SimulationID.String()
+		c.Params = append(c.Params,
+			gin.Param{Key: "id", Value: simulationID},
+			gin.Param{Key: "simulation_id", Value: simulationID})
+		c.Next()
+	}
+}
+
+// getSharedSimulation returns the simulation behind a share link
+func (s *Server) getSharedSimulation(c *gin.Context) {
+	link := c.MustGet(shareLinkKey).(*database.ShareLink)
+
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), link.SimulationID.String())
+	if err != nil {
+		if errors.Is(err, orchestration.ErrSimulationNotFound) {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, SharedSimulationResponse{
+		Simulation: newSimulationResponse(simulation),
+		ExpiresAt:  link.ExpiresAt,
+		Label:      link.Label,
+	}, "Shared simulation retrieved successfully")
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/internal/reconcile"
+)
+
+// storedStatuses maps the status filter of the simulation listing onto the
+// statuses simulation rows are stored with. Orchestrator status names are
+// accepted alongside the stored ones.
+var storedStatuses = map[string][]string{
+	"idle":                           {database.SimulationStatusCreated},
+	"error":                          {reconcile.StatusFailed, reconcile.StatusInterrupted},
+	database.SimulationStatusCreated: {database.SimulationStatusCreated},
+	reconcile.StatusRunning:          {reconcile.StatusRunning},
+	reconcile.StatusPaused:           {reconcile.StatusPaused},
+	reconcile.StatusCompleted:        {reconcile.StatusCompleted},
+	reconcile.StatusStopped:          {reconcile.StatusStopped},
+	reconcile.StatusFailed:           {reconcile.StatusFailed},
+	reconcile.StatusInterrupted:      {reconcile.StatusInterrupted},
+}
+
+// parseSimulationQuery reads the filters of the simulation listing:
+// status and tags (repeatable or comma separated), owner, organization,
+// created_from/created_to and started_from/started_to (RFC3339), name
+// (substring), has_faults (bool) and min_duration (e.g. 90s, 2h)
+func parseSimulationQuery(c *gin.Context) (*database.SimulationQuery, error) {
+	query := database.NewSimulationQuery()
+
+	var statuses []string
+	for _, status := range queryList(c, "status") {
+		stored, ok := storedStatuses[status]
+		if !ok {
+			return nil, fmt.Errorf("unsupported status filter: %s", status)
+		}
+		statuses = append(statuses, stored...)
+	}
+	query.Status(statuses...)
+	query.Tags(queryList(c, "tags")...)
+
+	if raw := c.Query("owner"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, errors.New("owner must be a user ID")
+		}
+		query.Owner(id)
+	}
+	if raw := c.Query("organization"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, errors.New("organization must be an organization ID")
+		}
+		query.Organization(id)
+	}
+
+	from, to, err := parseTimeRange(c, "created_from", "created_to")
+	if err != nil {
+		return nil, err
+	}
+	query.CreatedBetween(from, to)
+	from, to, err = parseTimeRange(c, "started_from", "started_to")
+	if err != nil {
+		return nil, err
+	}
+	query.StartedBetween(from, to)
+
+	query.NameContains(strings.TrimSpace(c.Query("name")))
+
+	if raw := c.Query("has_faults"); raw != "" {
+		hasFaults, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("has_faults must be true or false")
+		}
+		query.HasFaults(hasFaults)
+	}
+	if raw := c.Query("min_duration"); raw != "" {
+		duration, err := time.ParseDuration(raw)
+		if err != nil || duration < 0 {
+			return nil, errors.New("min_duration must be a non-negative duration such as 90s or 2h")
+		}
+		query.MinDuration(duration)
+	}
+
+	return query, nil
+}
+
+// queryList reads a query parameter that may be repeated and comma separated
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		values = append(values, splitQueryList(raw)...)
+	}
+	return values
+}
+
+// newStoredSimulationResponse converts a simulation row that is not loaded
+// in the orchestrator to its API representation. Its status is reported in
+// orchestrator terms, as it would be once loaded.
+func newStoredSimulationResponse(row database.Simulation) SimulationResponse {
+	var config SimulationConfig
+	if encoded, err := json.Marshal(row.Config); err == nil {
+		_ = json.Unmarshal(encoded, &config)
+	}
+
+	response := SimulationResponse{
+		ID:          row.ID.String(),
+		Name:        row.Name,
+		Description: row.Description,
+		Status:      orchestration.StatusIdle.String(),
+		Engine:      row.Engine,
+		Config:      config,
+		Tags:        row.Tags,
+		Metadata:    row.Metadata,
+		CreatedAt:   row.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:   row.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		Version:     row.Version,
+		Speed:       orchestration.SpeedRealTime,
+		Error:       row.ErrorMessage,
+	}
+
+	switch row.Status {
+	case reconcile.StatusRunning:
+		response.Status = orchestration.StatusRunning.String()
+	case reconcile.StatusPaused:
+		response.Status = orchestration.StatusPaused.String()
+	case reconcile.StatusCompleted:
+		response.Status = orchestration.StatusCompleted.String()
+		response.StoppedReason = orchestration.StopReasonCompleted
+	case reconcile.StatusStopped:
+		response.Status = orchestration.StatusCompleted.String()
+		response.StoppedReason = orchestration.StopReasonStopped
+	case reconcile.StatusFailed, reconcile.StatusInterrupted:
+		response.Status = orchestration.StatusError.String()
+		response.StoppedReason = orchestration.StopReasonFailed
+	}
+
+	if row.StartedAt != nil {
+		response.StartedAt = row.StartedAt.Format("2006-01-02T15:04:05Z")
+	}
+	if row.CompletedAt != nil {
+		response.EndedAt = row.CompletedAt.Format("2006-01-02T15:04:05Z")
+	}
+	return response
+}
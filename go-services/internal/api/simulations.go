@@ -1,13 +1,19 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/metadata"
+	"voltedge/go-services/internal/notifications"
+	"voltedge/go-services/internal/observability"
 	"voltedge/go-services/internal/orchestration"
 )
 
@@ -18,55 +24,34 @@ type CreateSimulationRequest struct {
 	Config      SimulationConfig       `json:"config" binding:"required"`
 	Tags        []string               `json:"tags"`
 	Metadata    map[string]interface{} `json:"metadata"`
+	DependsOn   []DependencyRequest    `json:"depends_on"`
+	Engine      string                 `json:"engine"`
+	// Run a DC power flow over the initial dispatch and report problems as warnings
+	CheckPowerFlow bool `json:"check_power_flow"`
 }
 
-// SimulationConfig represents the configuration for a simulation
-type SimulationConfig struct {
-	PowerPlants       []PowerPlantConfig       `json:"power_plants" binding:"required"`
-	TransmissionLines []TransmissionLineConfig `json:"transmission_lines" binding:"required"`
-	BaseFrequency     float64                  `json:"base_frequency"`
-	BaseVoltage       float64                  `json:"base_voltage"`
-	LoadProfile       LoadProfile              `json:"load_profile"`
-}
-
-// PowerPlantConfig represents a power plant configuration
-type PowerPlantConfig struct {
-	ID              string   `json:"id" binding:"required"`
-	Name            string   `json:"name" binding:"required"`
-	Type            string   `json:"type" binding:"required"`
-	MaxCapacityMW   float64  `json:"max_capacity_mw" binding:"required"`
-	CurrentOutputMW float64  `json:"current_output_mw"`
-	Efficiency      float64  `json:"efficiency"`
-	Location        Location `json:"location" binding:"required"`
-	IsOperational   bool     `json:"is_operational"`
-}
-
-// TransmissionLineConfig represents a transmission line configuration
-type TransmissionLineConfig struct {
-	ID              string  `json:"id" binding:"required"`
-	FromNode        string  `json:"from_node" binding:"required"`
-	ToNode          string  `json:"to_node" binding:"required"`
-	CapacityMW      float64 `json:"capacity_mw" binding:"required"`
-	LengthKM        float64 `json:"length_km" binding:"required"`
-	ResistancePerKM float64 `json:"resistance_per_km"`
-	ReactancePerKM  float64 `json:"reactance_per_km"`
-	IsOperational   bool    `json:"is_operational"`
-}
-
-// LoadProfile represents the load profile configuration
-type LoadProfile struct {
-	BaseLoadMW      float64 `json:"base_load_mw" binding:"required"`
-	PeakMultiplier  float64 `json:"peak_multiplier"`
-	DailyVariation  float64 `json:"daily_variation"`
-	RandomVariation float64 `json:"random_variation"`
+// UpdateSimulationRequest represents a partial update of a simulation. The
+// version being updated is sent in the If-Match header.
+type UpdateSimulationRequest struct {
+	Name        *string                `json:"name" binding:"omitempty,min=1"`
+	Description *string                `json:"description"`
+	Tags        *[]string              `json:"tags"`
+	Metadata    map[string]interface{} `json:"metadata"`
 }
 
-// Location represents a geographical location
-type Location struct {
-	X    float64 `json:"x" binding:"required"`
-	Y    float64 `json:"y" binding:"required"`
-	Name string  `json:"name" binding:"required"`
-}
+// The API accepts and returns simulation configs and dependencies in the
+// orchestrator's own types; their binding tags validate requests
+type (
+	DependencyRequest      = orchestration.Dependency
+	SimulationConfig       = orchestration.SimulationConfig
+	PowerPlantConfig       = orchestration.PowerPlantConfig
+	TransmissionLineConfig = orchestration.TransmissionLineConfig
+	StorageUnitConfig      = orchestration.StorageUnitConfig
+	BusConfig              = orchestration.BusConfig
+	LoadProfile            = orchestration.LoadProfile
+	Location               = orchestration.Location
+	TopologyChangeRequest  = orchestration.TopologyChange
+)
 
 // SimulationResponse represents a simulation response
 type SimulationResponse struct {
@@ -74,11 +59,74 @@ type SimulationResponse struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	Status      string                 `json:"status"`
+	Engine      string                 `json:"engine,omitempty"`
 	Config      SimulationConfig       `json:"config"`
 	Tags        []string               `json:"tags"`
 	Metadata    map[string]interface{} `json:"metadata"`
+	DependsOn   []DependencyRequest    `json:"depends_on,omitempty"`
 	CreatedAt   string                 `json:"created_at"`
 	UpdatedAt   string                 `json:"updated_at"`
+	Version     int64                  `json:"version"`
+
+	// Runtime information
+	// Real-time factor; 0 runs as fast as possible
+	Speed     float64 `json:"speed"`
+	StartedAt string  `json:"started_at,omitempty"`
+	EndedAt   string  `json:"ended_at,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	// Why the last run ended: completed, stopped, killed or failed
+	StoppedReason   string  `json:"stopped_reason,omitempty"`
+	EventsProcessed int64   `json:"events_processed"`
+	AvgTickTimeMS   float64 `json:"avg_tick_time_ms"`
+	MemoryUsageMB   int64   `json:"memory_usage_mb"`
+	// The run kept a config change that could not be stored, so it no
+	// longer matches the config shown here
+	Diverged bool `json:"diverged,omitempty"`
+
+	// How far the run is towards the config's target, when it declares one
+	Progress *orchestration.Progress `json:"progress,omitempty"`
+	// Where the run stands while it waits for a worker
+	Queue *orchestration.QueueStatus `json:"queue,omitempty"`
+
+	// Feasibility problems found by the power flow check on create
+	Warnings []orchestration.PowerFlowWarning `json:"warnings,omitempty"`
+}
+
+// newSimulationResponse converts an orchestrator simulation to its API representation
+func newSimulationResponse(simulation *orchestration.Simulation) SimulationResponse {
+	response := SimulationResponse{
+		ID:          simulation.ID,
+		Name:        simulation.Name,
+		Description: simulation.Description,
+		Status:      simulation.Status.String(),
+		Engine:      simulation.Engine,
+		Config:      simulation.Config,
+		Tags:        simulation.Tags,
+		Metadata:    simulation.Metadata,
+		DependsOn:   simulation.DependsOn,
+		CreatedAt:   simulation.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:   simulation.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		Version:     simulation.Version,
+
+		Speed:           simulation.Speed,
+		StoppedReason:   simulation.StoppedReason,
+		Diverged:        simulation.Diverged,
+		EventsProcessed: simulation.EventsProcessed,
+		AvgTickTimeMS:   simulation.AvgTickTime,
+		MemoryUsageMB:   simulation.MemoryUsage,
+		Progress:        simulation.Progress(time.Now()),
+	}
+
+	if simulation.StartTime != nil {
+		response.StartedAt = simulation.StartTime.Format("2006-01-02T15:04:05Z")
+	}
+	if simulation.EndTime != nil {
+		response.EndedAt = simulation.EndTime.Format("2006-01-02T15:04:05Z")
+	}
+	if simulation.Error != nil {
+		response.Error = simulation.Error.Error()
+	}
+	return response
 }
 
 // createSimulation handles simulation creation requests
@@ -89,79 +137,162 @@ func (s *Server) createSimulation(c *gin.Context) {
 		return
 	}
 
+	if err := validateMetadata(req.Metadata, s.config.Metadata); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	tags, ok := s.normalizeTags(c, req.Tags)
+	if !ok {
+		return
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"name":         req.Name,
 		"plants_count": len(req.Config.PowerPlants),
 		"lines_count":  len(req.Config.TransmissionLines),
 	}).Info("Creating new simulation")
 
-	// Convert API config to orchestration config
-	orchConfig := orchestration.SimulationConfig{
-		PowerPlants:      convertPowerPlants(req.Config.PowerPlants),
-		TransmissionLines: convertTransmissionLines(req.Config.TransmissionLines),
-		BaseFrequency:    req.Config.BaseFrequency,
-		BaseVoltage:      req.Config.BaseVoltage,
-		LoadProfile:      convertLoadProfile(req.Config.LoadProfile),
+	if err := req.Config.ValidateTopology(); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	// Reject configs no registered engine can run before creating anything
+	if s.engines != nil {
+		if _, err := s.engines.Place(req.Config.Requirements(), req.Engine); err != nil {
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	if !s.requireSimulationQuota(c, requesterOrganization(c)) {
+		return
 	}
-	
+
 	// Create simulation through orchestrator
-	simulation, err := s.orchestrator.CreateSimulation(req.Name, req.Description, orchConfig, req.Tags, req.Metadata)
+	simulation, err := s.orchestrator.CreateSimulation(c.Request.Context(), req.Name, req.Description, req.Config, tags, req.Metadata)
 	if err != nil {
+		if errors.Is(err, metadata.ErrInvalid) {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
 		s.handleError(c, err, http.StatusInternalServerError)
 		return
 	}
+	s.assignRequester(c, simulation.ID)
 
-	response := SimulationResponse{
-		ID:          simulation.ID,
-		Name:        simulation.Name,
-		Description: simulation.Description,
-		Status:      simulation.Status.String(),
-		Config:      convertOrchConfigToAPI(simulation.Config),
-		Tags:        simulation.Tags,
-		Metadata:    simulation.Metadata,
-		CreatedAt:   simulation.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:   simulation.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	if req.Engine != "" {
+		if err := s.orchestrator.AssignEngine(c.Request.Context(), simulation.ID, req.Engine); err != nil {
+			s.rollbackSimulation(c, simulation.ID)
+			s.handleError(c, err, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	if len(req.DependsOn) > 0 {
+		if err := s.orchestrator.SetDependencies(c.Request.Context(), simulation.ID, req.DependsOn); err != nil {
+			s.rollbackSimulation(c, simulation.ID)
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if s.cluster != nil {
+		if err := s.cluster.Claim(simulation); err != nil {
+			s.rollbackSimulation(c, simulation.ID)
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
 	}
 
+	s.syncTags(database.TagResourceSimulation, simulation.ID, simulation.Tags)
+
+	response := newSimulationResponse(simulation)
+	if req.CheckPowerFlow {
+		response.Warnings = req.Config.CheckPowerFlow().Warnings
+	}
+
+	s.publishSimulationEvent(notifications.EventSimulationCreated, simulation.ID, "Simulation created")
 	s.handleSuccess(c, response, "Simulation created successfully")
 }
 
-// listSimulations handles simulation listing requests
+// queueStatus returns a queued simulation's place in the queue
+func (s *Server) queueStatus(simulation *orchestration.Simulation) *orchestration.QueueStatus {
+	if simulation.Status != orchestration.StatusQueued {
+		return nil
+	}
+	return s.orchestrator.QueueStatus(simulation.ID)
+}
+
+// rollbackSimulation removes a simulation whose creation could not be
+// completed. It goes ahead even if the client has gone away.
+func (s *Server) rollbackSimulation(c *gin.Context, id string) {
+	if err := s.orchestrator.DeleteSimulation(context.WithoutCancel(c.Request.Context()), id); err != nil {
+		logrus.WithError(err).WithField("simulation_id", id).Error("Failed to roll back simulation creation")
+	}
+}
+
+// listSimulations handles simulation listing requests. With a database the
+// stored simulations are listed and can be filtered as parseSimulationQuery
+// describes; otherwise the simulations in memory are, by status and tags.
 func (s *Server) listSimulations(c *gin.Context) {
 	// Parse query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")
-	tags := c.QueryArray("tags")
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
 
 	logrus.WithFields(logrus.Fields{
-		"page":   page,
-		"limit":  limit,
-		"status": status,
-		"tags":   tags,
+		"page":  page,
+		"limit": limit,
+		"query": c.Request.URL.RawQuery,
 	}).Debug("Listing simulations")
 
-	// Get simulations from orchestrator
-	simulations, total, err := s.orchestrator.ListSimulations(page, limit, status, tags)
-	if err != nil {
-		s.handleError(c, err, http.StatusInternalServerError)
-		return
-	}
+	var response []SimulationResponse
+	var total int64
+	if s.simulationService != nil {
+		query, err := parseSimulationQuery(c)
+		if err != nil {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+
+		rows, count, err := s.simulationService.ListSimulations(c.Request.Context(), query, limit, (page-1)*limit)
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
 
-	// Convert to response format
-	response := make([]SimulationResponse, len(simulations))
-	for i, sim := range simulations {
-		response[i] = SimulationResponse{
-			ID:          sim.ID,
-			Name:        sim.Name,
-			Description: sim.Description,
-			Status:      sim.Status.String(),
-			Config:      convertOrchConfigToAPI(sim.Config),
-			Tags:        sim.Tags,
-			Metadata:    sim.Metadata,
-			CreatedAt:   sim.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:   sim.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		// Loaded simulations report their live state
+		response = make([]SimulationResponse, len(rows))
+		for i, row := range rows {
+			if sim, ok := s.orchestrator.LoadedSimulation(row.ID.String()); ok {
+				response[i] = newSimulationResponse(sim)
+				response[i].Queue = s.queueStatus(sim)
+			} else {
+				response[i] = newStoredSimulationResponse(row)
+			}
 		}
+		total = count
+	} else {
+		simulations, count, err := s.orchestrator.ListSimulations(page, limit, c.Query("status"), c.QueryArray("tags"))
+		if err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+
+		// Convert to response format
+		response = make([]SimulationResponse, len(simulations))
+		for i, sim := range simulations {
+			response[i] = newSimulationResponse(sim)
+			response[i].Queue = s.queueStatus(sim)
+		}
+		total = int64(count)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -183,9 +314,13 @@ func (s *Server) getSimulation(c *gin.Context) {
 		return
 	}
 
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
 	logrus.WithField("simulation_id", id).Debug("Getting simulation")
 
-	simulation, err := s.orchestrator.GetSimulation(id)
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), id)
 	if err != nil {
 		if err == orchestration.ErrSimulationNotFound {
 			s.handleError(c, err, http.StatusNotFound)
@@ -195,21 +330,80 @@ func (s *Server) getSimulation(c *gin.Context) {
 		return
 	}
 
-	response := SimulationResponse{
-		ID:          simulation.ID,
-		Name:        simulation.Name,
-		Description: simulation.Description,
-		Status:      simulation.Status.String(),
-		Config:      convertOrchConfigToAPI(simulation.Config),
-		Tags:        simulation.Tags,
-		Metadata:    simulation.Metadata,
-		CreatedAt:   simulation.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:   simulation.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-	}
+	response := newSimulationResponse(simulation)
+	response.Queue = s.queueStatus(simulation)
 
+	setETag(c, simulation.Version)
 	s.handleSuccess(c, response, "Simulation retrieved successfully")
 }
 
+// updateSimulation handles partial simulation updates. The If-Match header
+// must carry the version being updated; stale versions are rejected with 409.
+func (s *Server) updateSimulation(c *gin.Context) {
+	id := c.Param("id")
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	expectedVersion, ok := s.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateSimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := validateMetadata(req.Metadata, s.config.Metadata); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.Tags != nil {
+		tags, ok := s.normalizeTags(c, *req.Tags)
+		if !ok {
+			return
+		}
+		req.Tags = &tags
+	}
+
+	simulation, err := s.orchestrator.UpdateSimulation(c.Request.Context(), id, expectedVersion, orchestration.SimulationUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+		Tags:        req.Tags,
+		Metadata:    req.Metadata,
+	})
+	if err != nil {
+		switch err {
+		case orchestration.ErrSimulationNotFound:
+			s.handleError(c, err, http.StatusNotFound)
+		case orchestration.ErrVersionConflict:
+			s.handleVersionConflict(c, err, simulation.Version)
+		default:
+			if errors.Is(err, metadata.ErrInvalid) {
+				s.handleError(c, err, http.StatusBadRequest)
+				return
+			}
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if s.cluster != nil {
+		if err := s.cluster.SaveSpec(simulation); err != nil {
+			logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to save simulation spec")
+		}
+	}
+	if req.Tags != nil {
+		s.syncTags(database.TagResourceSimulation, id, simulation.Tags)
+	}
+
+	setETag(c, simulation.Version)
+	s.handleSuccess(c, newSimulationResponse(simulation), "Simulation updated successfully")
+}
+
 // deleteSimulation handles simulation deletion requests
 func (s *Server) deleteSimulation(c *gin.Context) {
 	id := c.Param("id")
@@ -218,9 +412,13 @@ func (s *Server) deleteSimulation(c *gin.Context) {
 		return
 	}
 
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
 	logrus.WithField("simulation_id", id).Info("Deleting simulation")
 
-	err := s.orchestrator.DeleteSimulation(id)
+	err := s.orchestrator.DeleteSimulation(c.Request.Context(), id)
 	if err != nil {
 		if err == orchestration.ErrSimulationNotFound {
 			s.handleError(c, err, http.StatusNotFound)
@@ -230,6 +428,13 @@ func (s *Server) deleteSimulation(c *gin.Context) {
 		return
 	}
 
+	if s.cluster != nil {
+		if err := s.cluster.Release(id); err != nil {
+			logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to release simulation ownership")
+		}
+	}
+	s.syncTags(database.TagResourceSimulation, id, nil)
+
 	s.handleSuccess(c, nil, "Simulation deleted successfully")
 }
 
@@ -241,9 +446,69 @@ func (s *Server) startSimulation(c *gin.Context) {
 		return
 	}
 
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	if simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), id); err == nil && !s.requireSimulationQuota(c, simulation.OrganizationID) {
+		return
+	}
+
 	logrus.WithField("simulation_id", id).Info("Starting simulation")
 
-	err := s.orchestrator.StartSimulation(id)
+	err := s.orchestrator.StartSimulation(c.Request.Context(), id)
+	if err != nil {
+		if err == orchestration.ErrSimulationNotFound {
+			s.handleError(c, err, http.StatusNotFound)
+		} else if err == orchestration.ErrSimulationLocked || err == orchestration.ErrSimulationPaused || errors.Is(err, orchestration.ErrInvalidTransition) {
+			s.handleError(c, err, http.StatusConflict)
+		} else if errors.Is(err, orchestration.ErrQueueFull) {
+			s.handleError(c, err, http.StatusServiceUnavailable)
+		} else if errors.Is(err, orchestration.ErrConcurrencyLimit) {
+			s.handleError(c, err, http.StatusTooManyRequests)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// The run waits in the queue until a worker is free
+	s.handleSuccess(c, gin.H{"queue": s.orchestrator.QueueStatus(id)}, "Simulation started successfully")
+}
+
+// rerunSimulation creates a new simulation from an existing one's config.
+// With ?same_seed=true it reuses the recorded seed to reproduce the run.
+func (s *Server) rerunSimulation(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	sameSeed := false
+	if raw := c.Query("same_seed"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			s.handleError(c, errors.New("same_seed must be true or false"), http.StatusBadRequest)
+			return
+		}
+		sameSeed = parsed
+	}
+
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	source, err := s.orchestrator.GetSimulation(c.Request.Context(), id)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+	if !s.requireSimulationQuota(c, source.OrganizationID) {
+		return
+	}
+
+	simulation, err := s.orchestrator.RerunSimulation(c.Request.Context(), id, sameSeed)
 	if err != nil {
 		if err == orchestration.ErrSimulationNotFound {
 			s.handleError(c, err, http.StatusNotFound)
@@ -253,10 +518,22 @@ func (s *Server) startSimulation(c *gin.Context) {
 		return
 	}
 
-	s.handleSuccess(c, nil, "Simulation started successfully")
+	if s.cluster != nil {
+		if err := s.cluster.Claim(simulation); err != nil {
+			s.rollbackSimulation(c, simulation.ID)
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.syncTags(database.TagResourceSimulation, simulation.ID, simulation.Tags)
+
+	s.publishSimulationEvent(notifications.EventSimulationCreated, simulation.ID, "Simulation created")
+	s.handleSuccess(c, newSimulationResponse(simulation), "Simulation rerun created successfully")
 }
 
-// stopSimulation handles simulation stop requests
+// stopSimulation handles simulation stop requests. By default the run
+// finishes its current tick and reports its results; ?mode=force kills it.
 func (s *Server) stopSimulation(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -264,12 +541,27 @@ func (s *Server) stopSimulation(c *gin.Context) {
 		return
 	}
 
-	logrus.WithField("simulation_id", id).Info("Stopping simulation")
+	mode, err := orchestration.ParseStopMode(c.Query("mode"))
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if s.forwardToOwner(c, id) {
+		return
+	}
 
-	err := s.orchestrator.StopSimulation(id)
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"mode":          mode,
+	}).Info("Stopping simulation")
+
+	err = s.orchestrator.StopSimulation(c.Request.Context(), id, mode)
 	if err != nil {
 		if err == orchestration.ErrSimulationNotFound {
 			s.handleError(c, err, http.StatusNotFound)
+		} else if errors.Is(err, orchestration.ErrInvalidTransition) {
+			s.handleError(c, err, http.StatusConflict)
 		} else {
 			s.handleError(c, err, http.StatusInternalServerError)
 		}
@@ -287,12 +579,18 @@ func (s *Server) pauseSimulation(c *gin.Context) {
 		return
 	}
 
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
 	logrus.WithField("simulation_id", id).Info("Pausing simulation")
 
-	err := s.orchestrator.PauseSimulation(id)
+	err := s.orchestrator.PauseSimulation(c.Request.Context(), id)
 	if err != nil {
 		if err == orchestration.ErrSimulationNotFound {
 			s.handleError(c, err, http.StatusNotFound)
+		} else if errors.Is(err, orchestration.ErrInvalidTransition) {
+			s.handleError(c, err, http.StatusConflict)
 		} else {
 			s.handleError(c, err, http.StatusInternalServerError)
 		}
@@ -302,108 +600,271 @@ func (s *Server) pauseSimulation(c *gin.Context) {
 	s.handleSuccess(c, nil, "Simulation paused successfully")
 }
 
-// Conversion functions between API and orchestration types
-
-func convertPowerPlants(apiPlants []PowerPlantConfig) []orchestration.PowerPlantConfig {
-	orchPlants := make([]orchestration.PowerPlantConfig, len(apiPlants))
-	for i, plant := range apiPlants {
-		orchPlants[i] = orchestration.PowerPlantConfig{
-			ID:             plant.ID,
-			Name:           plant.Name,
-			Type:           plant.Type,
-			MaxCapacityMW:  plant.MaxCapacityMW,
-			CurrentOutputMW: plant.CurrentOutputMW,
-			Efficiency:     plant.Efficiency,
-			Location:       orchestration.Location{
-				X:    plant.Location.X,
-				Y:    plant.Location.Y,
-				Name: plant.Location.Name,
-			},
-			IsOperational:  plant.IsOperational,
-		}
-	}
-	return orchPlants
+// resumeSimulation handles requests to continue a paused simulation
+func (s *Server) resumeSimulation(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	logrus.WithField("simulation_id", id).Info("Resuming simulation")
+
+	err := s.orchestrator.ResumeSimulation(c.Request.Context(), id)
+	if err != nil {
+		if err == orchestration.ErrSimulationNotFound {
+			s.handleError(c, err, http.StatusNotFound)
+		} else if errors.Is(err, orchestration.ErrSimulationNotPaused) {
+			s.handleError(c, err, http.StatusConflict)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, nil, "Simulation resumed successfully")
+}
+
+// SpeedRequest sets a simulation's real-time factor. 0 runs as fast as
+// possible; otherwise the speed must be at least 0.1.
+type SpeedRequest struct {
+	Speed *float64 `json:"speed" binding:"required"`
+}
+
+// setSimulationSpeed changes how fast a simulation runs relative to wall-clock time
+func (s *Server) setSimulationSpeed(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	var req SpeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"speed":         *req.Speed,
+	}).Info("Changing simulation speed")
+
+	simulation, err := s.orchestrator.SetSimulationSpeed(c.Request.Context(), id, *req.Speed)
+	if err != nil {
+		if err == orchestration.ErrSimulationNotFound {
+			s.handleError(c, err, http.StatusNotFound)
+		} else if err == orchestration.ErrInvalidSpeed {
+			s.handleError(c, err, http.StatusBadRequest)
+		} else {
+			s.handleError(c, err, http.StatusConflict)
+		}
+		return
+	}
+
+	s.handleSuccess(c, newSimulationResponse(simulation), "Simulation speed changed successfully")
+}
+
+// stepSimulation advances a paused simulation by ?ticks (default 1) and
+// returns the resulting state
+func (s *Server) stepSimulation(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	ticks, err := strconv.Atoi(c.DefaultQuery("ticks", "1"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid ticks"), http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"ticks":         ticks,
+	}).Debug("Stepping simulation")
+
+	result, err := s.orchestrator.StepSimulation(c.Request.Context(), id, ticks)
+	if err != nil {
+		switch err {
+		case orchestration.ErrSimulationNotFound:
+			s.handleError(c, err, http.StatusNotFound)
+		case orchestration.ErrInvalidStep:
+			s.handleError(c, err, http.StatusBadRequest)
+		case orchestration.ErrNoEngineController:
+			s.handleError(c, err, http.StatusServiceUnavailable)
+		default:
+			s.handleError(c, err, http.StatusConflict)
+		}
+		return
+	}
+
+	s.handleSuccess(c, result, "Simulation stepped successfully")
 }
 
-func convertTransmissionLines(apiLines []TransmissionLineConfig) []orchestration.TransmissionLineConfig {
-	orchLines := make([]orchestration.TransmissionLineConfig, len(apiLines))
-	for i, line := range apiLines {
-		orchLines[i] = orchestration.TransmissionLineConfig{
-			ID:              line.ID,
-			FromNode:        line.FromNode,
-			ToNode:          line.ToNode,
-			CapacityMW:      line.CapacityMW,
-			LengthKM:        line.LengthKM,
-			ResistancePerKM: line.ResistancePerKM,
-			ReactancePerKM:  line.ReactancePerKM,
-			IsOperational:   line.IsOperational,
-		}
-	}
-	return orchLines
+// getSimulationPipeline returns the dependency graph a simulation belongs to
+func (s *Server) getSimulationPipeline(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	logrus.WithField("simulation_id", id).Debug("Getting simulation pipeline")
+
+	pipeline, err := s.orchestrator.Pipeline(c.Request.Context(), id)
+	if err != nil {
+		if err == orchestration.ErrSimulationNotFound {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, pipeline, "Simulation pipeline retrieved successfully")
 }
 
-func convertLoadProfile(apiProfile LoadProfile) orchestration.LoadProfile {
-	return orchestration.LoadProfile{
-		BaseLoadMW:       apiProfile.BaseLoadMW,
-		PeakMultiplier:   apiProfile.PeakMultiplier,
-		DailyVariation:   apiProfile.DailyVariation,
-		RandomVariation:  apiProfile.RandomVariation,
+// recordStorageGauges exports the storage unit readings of a metrics sample
+// to Prometheus
+func recordStorageGauges(simulationID string, sample orchestration.MetricsSample) {
+	for _, component := range sample.Components {
+		if component.Type != orchestration.ComponentTypeStorageUnit {
+			continue
+		}
+		unitID := strconv.Itoa(component.ID)
+		switch component.Metric {
+		case orchestration.MetricStateOfCharge:
+			observability.RecordStorageStateOfCharge(simulationID, unitID, component.Value)
+		case orchestration.MetricStoredEnergyMWh:
+			observability.RecordStorageStoredEnergy(simulationID, unitID, component.Value)
+		case orchestration.MetricPowerMW:
+			observability.RecordStoragePower(simulationID, unitID, component.Value)
+		}
 	}
 }
 
-func convertOrchConfigToAPI(orchConfig orchestration.SimulationConfig) SimulationConfig {
-	return SimulationConfig{
-		PowerPlants:      convertOrchPowerPlantsToAPI(orchConfig.PowerPlants),
-		TransmissionLines: convertOrchTransmissionLinesToAPI(orchConfig.TransmissionLines),
-		BaseFrequency:    orchConfig.BaseFrequency,
-		BaseVoltage:      orchConfig.BaseVoltage,
-		LoadProfile:      convertOrchLoadProfileToAPI(orchConfig.LoadProfile),
+// recordSimulationMetrics ingests a runtime metrics sample pushed by the engine
+func (s *Server) recordSimulationMetrics(c *gin.Context) {
+	id := c.Param("id")
+	if s.forwardToOwner(c, id) {
+		return
+	}
+
+	var sample orchestration.MetricsSample
+	if err := c.ShouldBindJSON(&sample); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.orchestrator.RecordMetrics(id, sample); err != nil {
+		if err == orchestration.ErrSimulationNotFound {
+			s.handleError(c, err, http.StatusNotFound)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	s.publishResults(id, sample)
+	recordStorageGauges(id, sample)
+
+	simulation, err := s.orchestrator.GetSimulation(c.Request.Context(), id)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
 	}
+
+	s.handleSuccess(c, gin.H{
+		"simulation_id":    simulation.ID,
+		"events_processed": simulation.EventsProcessed,
+		"avg_tick_time_ms": simulation.AvgTickTime,
+		"memory_usage_mb":  simulation.MemoryUsage,
+	}, "Metrics recorded successfully")
 }
 
-func convertOrchPowerPlantsToAPI(orchPlants []orchestration.PowerPlantConfig) []PowerPlantConfig {
-	apiPlants := make([]PowerPlantConfig, len(orchPlants))
-	for i, plant := range orchPlants {
-		apiPlants[i] = PowerPlantConfig{
-			ID:             plant.ID,
-			Name:           plant.Name,
-			Type:           plant.Type,
-			MaxCapacityMW:  plant.MaxCapacityMW,
-			CurrentOutputMW: plant.CurrentOutputMW,
-			Efficiency:     plant.Efficiency,
-			Location:       Location{
-				X:    plant.Location.X,
-				Y:    plant.Location.Y,
-				Name: plant.Location.Name,
-			},
-			IsOperational:  plant.IsOperational,
-		}
-	}
-	return apiPlants
+// diffSimulations returns a structured diff from one simulation's config to another's
+func (s *Server) diffSimulations(c *gin.Context) {
+	id, otherID := c.Param("id"), c.Param("other_id")
+
+	base, err := s.orchestrator.GetSimulation(c.Request.Context(), id)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+	other, err := s.orchestrator.GetSimulation(c.Request.Context(), otherID)
+	if err != nil {
+		s.handleError(c, err, http.StatusNotFound)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{
+		"simulation_id": id,
+		"other_id":      otherID,
+		"diff":          orchestration.DiffConfigs(base.Config, other.Config),
+	}, "Simulation diff computed successfully")
 }
 
-func convertOrchTransmissionLinesToAPI(orchLines []orchestration.TransmissionLineConfig) []TransmissionLineConfig {
-	apiLines := make([]TransmissionLineConfig, len(orchLines))
-	for i, line := range orchLines {
-		apiLines[i] = TransmissionLineConfig{
-			ID:              line.ID,
-			FromNode:        line.FromNode,
-			ToNode:          line.ToNode,
-			CapacityMW:      line.CapacityMW,
-			LengthKM:        line.LengthKM,
-			ResistancePerKM: line.ResistancePerKM,
-			ReactancePerKM:  line.ReactancePerKM,
-			IsOperational:   line.IsOperational,
-		}
-	}
-	return apiLines
+// publishTransition notifies webhook subscribers when a run starts, is
+// stopped (or canceled while queued), completes or fails, whatever moved it there
+func (s *Server) publishTransition(transition orchestration.Transition) {
+	ran := transition.From == orchestration.StatusRunning || transition.From == orchestration.StatusPaused
+	switch {
+	case transition.To == orchestration.StatusRunning && !ran:
+		s.publishSimulationEvent(notifications.EventSimulationStarted, transition.SimulationID, "Simulation started")
+	case transition.To == orchestration.StatusCompleted && errors.Is(transition.Err, orchestration.ErrStopRequested):
+		s.publishSimulationEvent(notifications.EventSimulationStopped, transition.SimulationID, "Simulation stopped")
+	case transition.From == orchestration.StatusQueued && transition.To == orchestration.StatusIdle:
+		s.publishSimulationEvent(notifications.EventSimulationStopped, transition.SimulationID, "Queued simulation canceled")
+	case transition.To == orchestration.StatusCompleted:
+		s.publishSimulationEvent(notifications.EventSimulationCompleted, transition.SimulationID, "Simulation completed")
+	case transition.To == orchestration.StatusError:
+		message := "Simulation failed"
+		if transition.Err != nil {
+			message += ": " + transition.Err.Error()
+		}
+		s.publishSimulationEvent(notifications.EventSimulationFailed, transition.SimulationID, message)
+	}
 }
 
-func convertOrchLoadProfileToAPI(orchProfile orchestration.LoadProfile) LoadProfile {
-	return LoadProfile{
-		BaseLoadMW:       orchProfile.BaseLoadMW,
-		PeakMultiplier:   orchProfile.PeakMultiplier,
-		DailyVariation:   orchProfile.DailyVariation,
-		RandomVariation:  orchProfile.RandomVariation,
+// publishSimulationEvent notifies webhook subscribers about a simulation lifecycle change
+func (s *Server) publishSimulationEvent(eventType, simulationID, message string) {
+	if s.notifier == nil {
+		return
+	}
+
+	severity := "info"
+	if eventType == notifications.EventSimulationFailed {
+		severity = "error"
 	}
+	event := notifications.Event{
+		Type:         eventType,
+		SimulationID: simulationID,
+		Severity:     severity,
+		Message:      message,
+	}
+
+	if simulation, err := s.orchestrator.GetSimulation(context.Background(), simulationID); err == nil {
+		event.Data = map[string]interface{}{
+			"name":   simulation.Name,
+			"status": simulation.Status.String(),
+		}
+		if simulation.StoppedReason != "" {
+			event.Data["stopped_reason"] = simulation.StoppedReason
+		}
+	}
+
+	s.notifier.Publish(event)
 }
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// SnapshotResponse is a stored snapshot together with the state it holds
+type SnapshotResponse struct {
+	database.SimulationSnapshot
+	State map[string]interface{} `json:"state"`
+}
+
+// takeSnapshot dumps a running or paused simulation's engine state and
+// stores it as the simulation's next snapshot version
+func (s *Server) takeSnapshot(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		s.handleError(c, errors.New("invalid parameter"), http.StatusBadRequest)
+		return
+	}
+
+	if s.forwardToOwner(c, id) {
+		return
+	}
+	if !s.requireSnapshots(c) {
+		return
+	}
+
+	state, err := s.orchestrator.DumpState(c.Request.Context(), id)
+	if err != nil {
+		switch err {
+		case orchestration.ErrSimulationNotFound:
+			s.handleError(c, err, http.StatusNotFound)
+		case orchestration.ErrNoEngineController:
+			s.handleError(c, err, http.StatusServiceUnavailable)
+		default:
+			s.handleError(c, err, http.StatusConflict)
+		}
+		return
+	}
+
+	snapshot, err := s.snapshots.Save(c.Request.Context(), id, state, actorID(c))
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"version":       snapshot.Version,
+		"size_bytes":    snapshot.SizeBytes,
+	}).Info("Simulation snapshot stored")
+
+	s.handleSuccess(c, SnapshotResponse{SimulationSnapshot: *snapshot, State: state}, "Snapshot taken successfully")
+}
+
+// listSnapshots lists a simulation's stored snapshots, newest first
+func (s *Server) listSnapshots(c *gin.Context) {
+	if !s.requireSnapshots(c) {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	snapshots, err := s.snapshots.List(c.Param("id"), limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	if snapshots == nil {
+		snapshots = []database.SimulationSnapshot{}
+	}
+
+	s.handleSuccess(c, snapshots, "Snapshots retrieved successfully")
+}
+
+// getSnapshot returns a stored snapshot with its state
+func (s *Server) getSnapshot(c *gin.Context) {
+	if !s.requireSnapshots(c) {
+		return
+	}
+
+	snapshot, state, ok := s.loadSnapshot(c, "version")
+	if !ok {
+		return
+	}
+
+	s.handleSuccess(c, SnapshotResponse{SimulationSnapshot: *snapshot, State: state}, "Snapshot retrieved successfully")
+}
+
+// diffSnapshots compares two stored snapshots of a simulation
+func (s *Server) diffSnapshots(c *gin.Context) {
+	if !s.requireSnapshots(c) {
+		return
+	}
+
+	base, baseState, ok := s.loadSnapshot(c, "version")
+	if !ok {
+		return
+	}
+	other, otherState, ok := s.loadSnapshot(c, "other_version")
+	if !ok {
+		return
+	}
+
+	s.handleSuccess(c, gin.H{
+		"simulation_id": base.SimulationID,
+		"version":       base.Version,
+		"other_version": other.Version,
+		"changes":       orchestration.DiffState(baseState, otherState),
+	}, "Snapshot diff computed successfully")
+}
+
+// requireSnapshots writes an error response when snapshot storage is not configured
+func (s *Server) requireSnapshots(c *gin.Context) bool {
+	if s.snapshots == nil {
+		s.handleError(c, errors.New("snapshots are not configured"), http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// loadSnapshot resolves the :id parameter and a version parameter to a stored
+// snapshot and its state, writing an error response on failure
+func (s *Server) loadSnapshot(c *gin.Context, param string) (*database.SimulationSnapshot, map[string]interface{}, bool) {
+	version, err := strconv.Atoi(c.Param(param))
+	if err != nil || version < 1 {
+		s.handleError(c, errors.New("invalid snapshot version"), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	snapshot, err := s.snapshots.Get(c.Param("id"), version)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if snapshot == nil {
+		s.handleError(c, errors.New("snapshot not found"), http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	state, err := s.snapshots.Load(c.Request.Context(), snapshot)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	return snapshot, state, true
+}
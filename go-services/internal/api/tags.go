@@ -0,0 +1,344 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	defaultTagSuggestions = 10
+	maxTagSuggestions     = 50
+)
+
+// TagRequest represents a request to create or update a tag
+type TagRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Color       string `json:"color" binding:"omitempty,hexcolor"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// MergeTagsRequest represents a request to fold tags into another one
+type MergeTagsRequest struct {
+	SourceIDs []uuid.UUID `json:"source_ids" binding:"required,min=1"`
+	TargetID  uuid.UUID   `json:"target_id" binding:"required"`
+}
+
+// createTag handles tag creation requests
+func (s *Server) createTag(c *gin.Context) {
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	name, err := database.NormalizeTagName(req.Name)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	tag := &database.Tag{
+		Name:        name,
+		Color:       req.Color,
+		Description: req.Description,
+	}
+	if claims := currentClaims(c); claims != nil {
+		tag.CreatedBy = &claims.UserID
+	}
+
+	if err := s.tagService.CreateTag(tag); err != nil {
+		s.handleError(c, err, tagErrorStatus(err))
+		return
+	}
+
+	s.handleSuccess(c, tag, "Tag created successfully")
+}
+
+// listTags handles tag listing requests, optionally filtered by a name prefix
+func (s *Server) listTags(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	tags, total, err := s.tagService.ListTags(c.Query("prefix"), limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{
+		"tags":  tags,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	}, "Tags retrieved successfully")
+}
+
+// autocompleteTags suggests the most used tags starting with ?prefix
+func (s *Server) autocompleteTags(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTagSuggestions)))
+	if limit < 1 || limit > maxTagSuggestions {
+		limit = defaultTagSuggestions
+	}
+
+	tags, err := s.tagService.AutocompleteTags(c.Query("prefix"), limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, tags, "Tag suggestions retrieved successfully")
+}
+
+// searchTaggedResources finds the simulations, experiments and batches
+// carrying any of ?tags, or all of them with ?match=all. ?type limits the
+// resource types searched.
+func (s *Server) searchTaggedResources(c *gin.Context) {
+	tags, err := database.NormalizeTagNames(splitQueryList(c.Query("tags")))
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	if len(tags) == 0 {
+		s.handleError(c, errors.New("tags is required"), http.StatusBadRequest)
+		return
+	}
+
+	search := database.TagSearch{
+		Tags:          tags,
+		ResourceTypes: splitQueryList(c.Query("type")),
+	}
+	for _, resourceType := range search.ResourceTypes {
+		if !isTagResourceType(resourceType) {
+			s.handleError(c, errors.New("invalid resource type: "+resourceType), http.StatusBadRequest)
+			return
+		}
+	}
+	switch c.DefaultQuery("match", "any") {
+	case "any":
+	case "all":
+		search.MatchAll = true
+	default:
+		s.handleError(c, errors.New("match must be any or all"), http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	search.Limit, search.Offset = limit, (page-1)*limit
+
+	resources, total, err := s.tagService.SearchResources(search)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, gin.H{
+		"resources": resources,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	}, "Tagged resources retrieved successfully")
+}
+
+// getTag handles single tag retrieval requests
+func (s *Server) getTag(c *gin.Context) {
+	tag, ok := s.loadTag(c)
+	if !ok {
+		return
+	}
+
+	s.handleSuccess(c, tag, "Tag retrieved successfully")
+}
+
+// updateTag handles tag updates. Renaming a tag also renames it on every
+// simulation, experiment and batch held by this replica.
+func (s *Server) updateTag(c *gin.Context) {
+	tag, ok := s.loadTag(c)
+	if !ok {
+		return
+	}
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	name, err := database.NormalizeTagName(req.Name)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	previousName := tag.Name
+	tag.Name = name
+	tag.Color = req.Color
+	tag.Description = req.Description
+
+	if err := s.tagService.UpdateTag(tag); err != nil {
+		s.handleError(c, err, tagErrorStatus(err))
+		return
+	}
+
+	if name != previousName {
+		s.orchestrator.ReplaceTags([]string{previousName}, name)
+	}
+
+	s.handleSuccess(c, tag, "Tag updated successfully")
+}
+
+// deleteTag handles tag deletion requests, removing the tag from every resource
+func (s *Server) deleteTag(c *gin.Context) {
+	tag, ok := s.loadTag(c)
+	if !ok {
+		return
+	}
+
+	if err := s.tagService.DeleteTag(tag.ID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	s.orchestrator.ReplaceTags([]string{tag.Name}, "")
+
+	s.handleSuccess(c, nil, "Tag deleted successfully")
+}
+
+// mergeTags folds the source tags into the target tag
+func (s *Server) mergeTags(c *gin.Context) {
+	var req MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.SourceIDs))
+	sources := make([]uuid.UUID, 0, len(req.SourceIDs))
+	for _, id := range req.SourceIDs {
+		if id == req.TargetID {
+			s.handleError(c, errors.New("a tag cannot be merged into itself"), http.StatusBadRequest)
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			sources = append(sources, id)
+		}
+	}
+
+	target, err := s.tagService.GetTag(req.TargetID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if target == nil {
+		s.handleError(c, errors.New("target tag not found"), http.StatusNotFound)
+		return
+	}
+
+	merged, err := s.tagService.MergeTags(sources, target.ID)
+	if err != nil {
+		s.handleError(c, err, tagErrorStatus(err))
+		return
+	}
+	s.orchestrator.ReplaceTags(merged, target.Name)
+
+	target, err = s.tagService.GetTag(target.ID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"tag_id": target.ID,
+		"merged": merged,
+	}).Info("Tags merged")
+
+	s.handleSuccess(c, target, "Tags merged successfully")
+}
+
+// loadTag fetches the tag named by the id path parameter, writing the error response itself
+func (s *Server) loadTag(c *gin.Context) (*database.Tag, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid tag id"), http.StatusBadRequest)
+		return nil, false
+	}
+
+	tag, err := s.tagService.GetTag(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	if tag == nil {
+		s.handleError(c, errors.New("tag not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return tag, true
+}
+
+// normalizeTags validates the tags of a create or update request, writing
+// the error response itself
+func (s *Server) normalizeTags(c *gin.Context, tags []string) ([]string, bool) {
+	normalized, err := database.NormalizeTagNames(tags)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return nil, false
+	}
+	return normalized, true
+}
+
+// syncTags records a resource's tags so they can be searched. Failures are
+// logged; the orchestrator keeps its own copy of the tags regardless.
+// Gateways without a database, such as the embedded one, skip this.
+func (s *Server) syncTags(resourceType, resourceID string, tags []string) {
+	if s.tagService == nil {
+		return
+	}
+	if err := s.tagService.SetResourceTags(resourceType, resourceID, tags); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"resource_type": resourceType,
+			"resource_id":   resourceID,
+		}).Warn("Failed to sync resource tags")
+	}
+}
+
+func isTagResourceType(resourceType string) bool {
+	for _, known := range database.TagResourceTypes {
+		if resourceType == known {
+			return true
+		}
+	}
+	return false
+}
+
+func tagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, database.ErrInvalidTagName):
+		return http.StatusBadRequest
+	case errors.Is(err, database.ErrTagExists):
+		return http.StatusConflict
+	case errors.Is(err, database.ErrTagNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+)
+
+// defaultExcursionThresholdHz is how far the grid frequency may drift from
+// nominal before the timeline marks an excursion
+const defaultExcursionThresholdHz = 0.2
+
+// getSimulationTimeline returns a page of a persisted simulation's timeline,
+// newest first. ?kind limits the entry kinds, ?from and ?to the range, and
+// ?nominal_hz and ?threshold_hz tune frequency excursion detection.
+func (s *Server) getSimulationTimeline(c *gin.Context) {
+	simulationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid simulation id"), http.StatusBadRequest)
+		return
+	}
+
+	filter := database.TimelineFilter{
+		Kinds:                splitQueryList(c.Query("kind")),
+		NominalFrequencyHz:   defaultNominalFrequencyHz,
+		ExcursionThresholdHz: defaultExcursionThresholdHz,
+	}
+	filter.From, filter.To, err = parseTimeWindow(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+	if raw := c.Query("nominal_hz"); raw != "" {
+		filter.NominalFrequencyHz, err = strconv.ParseFloat(raw, 64)
+		if err != nil || filter.NominalFrequencyHz <= 0 {
+			s.handleError(c, errors.New("nominal_hz must be a positive number"), http.StatusBadRequest)
+			return
+		}
+	}
+	if raw := c.Query("threshold_hz"); raw != "" {
+		filter.ExcursionThresholdHz, err = strconv.ParseFloat(raw, 64)
+		if err != nil || filter.ExcursionThresholdHz <= 0 {
+			s.handleError(c, errors.New("threshold_hz must be a positive number"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	cursor, limit, ok := s.parseEventPage(c)
+	if !ok {
+		return
+	}
+
+	simulation, err := s.simulationService.GetSimulation(c.Request.Context(), simulationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if simulation == nil {
+		s.handleError(c, errors.New("simulation not found"), http.StatusNotFound)
+		return
+	}
+
+	entries, next, err := s.simulationService.ListTimeline(c.Request.Context(), simulationID, filter, cursor, limit)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidTimelineKind) {
+			s.handleError(c, err, http.StatusBadRequest)
+		} else {
+			s.handleError(c, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.handleSuccess(c, newEventPage(entries, len(entries), next), "Simulation timeline retrieved successfully")
+}
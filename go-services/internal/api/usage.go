@@ -0,0 +1,271 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/usage"
+)
+
+// ConcurrencyLimitsRequest sets an organization's concurrency limit
+// overrides. Omitted limits fall back to the configured defaults; zero means
+// unlimited.
+type ConcurrencyLimitsRequest struct {
+	PerOrganization *int `json:"per_organization" binding:"omitempty,gte=0"`
+	PerUser         *int `json:"per_user" binding:"omitempty,gte=0"`
+}
+
+// QuotaRequest sets an organization's quota overrides. Omitted quotas fall
+// back to the configured defaults; zero means unlimited.
+type QuotaRequest struct {
+	APICallsPerDay          *int64   `json:"api_calls_per_day" binding:"omitempty,gte=0"`
+	SimulationHoursPerMonth *float64 `json:"simulation_hours_per_month" binding:"omitempty,gte=0"`
+	ResultRows              *int64   `json:"result_rows" binding:"omitempty,gte=0"`
+}
+
+// usageMiddleware meters API calls per organization and rejects calls once
+// the daily quota is used up. Requests forwarded by another replica were
+// metered there.
+func (s *Server) usageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID := requesterOrganization(c)
+		if s.meter == nil || organizationID == nil || strings.HasPrefix(c.Request.URL.Path, "/internal/") {
+			c.Next()
+			return
+		}
+
+		exceeded, err := s.meter.APICallsExceeded(*organizationID)
+		if err != nil {
+			// Metering problems never block requests
+			logrus.WithError(err).WithField("organization_id", *organizationID).Warn("Failed to check API call quota")
+		} else if exceeded {
+			retryAfter := int(time.Until(usage.ResetAt(time.Now())).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			s.handleError(c, errors.New("daily API call quota exceeded"), http.StatusTooManyRequests)
+			c.Abort()
+			return
+		}
+
+		s.meter.RecordAPICall(*organizationID)
+		c.Next()
+	}
+}
+
+// requireSimulationQuota writes a 402 response when an organization has used
+// up its simulation-hours or stored results. Simulations without an
+// organization are not metered.
+func (s *Server) requireSimulationQuota(c *gin.Context, organizationID *uuid.UUID) bool {
+	if s.meter == nil || organizationID == nil {
+		return true
+	}
+
+	err := s.meter.CheckSimulationQuota(*organizationID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, usage.ErrSimulationHoursExceeded), errors.Is(err, usage.ErrResultRowsExceeded):
+		s.handleError(c, err, http.StatusPaymentRequired)
+		return false
+	default:
+		logrus.WithError(err).WithField("organization_id", *organizationID).Warn("Failed to check simulation quota")
+		return true
+	}
+}
+
+// assignRequester meters a new simulation against the requester's
+// organization and counts it against the requester's concurrency limits
+func (s *Server) assignRequester(c *gin.Context, simulationID string) {
+	claims := currentClaims(c)
+	if claims == nil {
+		return
+	}
+
+	if err := s.orchestrator.AssignOwner(c.Request.Context(), simulationID, claims.UserID); err != nil {
+		logrus.WithError(err).WithField("simulation_id", simulationID).Warn("Failed to assign simulation owner")
+	}
+	if claims.OrganizationID == nil {
+		return
+	}
+	if err := s.orchestrator.AssignOrganization(c.Request.Context(), simulationID, *claims.OrganizationID); err != nil {
+		logrus.WithError(err).WithField("simulation_id", simulationID).Warn("Failed to assign simulation organization")
+	}
+}
+
+// getOrganizationUsage reports an organization's usage between ?from and ?to
+// (dates, default the current month) with its current usage and quotas
+func (s *Server) getOrganizationUsage(c *gin.Context) {
+	if s.meter == nil {
+		s.handleError(c, errors.New("usage metering is not enabled"), http.StatusNotFound)
+		return
+	}
+
+	organizationID, ok := s.authorizeOrganization(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now.AddDate(0, 0, 1)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			s.handleError(c, errors.New("from must be a date (YYYY-MM-DD)"), http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			s.handleError(c, errors.New("to must be a date (YYYY-MM-DD)"), http.StatusBadRequest)
+			return
+		}
+		// Include the whole of the last day
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		s.handleError(c, errors.New("from must not be after to"), http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.meter.Report(organizationID, from, to)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, report, "Usage retrieved successfully")
+}
+
+// updateOrganizationQuotas sets an organization's quota overrides
+func (s *Server) updateOrganizationQuotas(c *gin.Context) {
+	organizationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid organization id"), http.StatusBadRequest)
+		return
+	}
+
+	var req QuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	organization, err := s.userService.GetOrganization(organizationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if organization == nil {
+		s.handleError(c, errors.New("organization not found"), http.StatusNotFound)
+		return
+	}
+
+	overrides := map[string]float64{}
+	if req.APICallsPerDay != nil {
+		overrides[usage.QuotaAPICallsPerDay] = float64(*req.APICallsPerDay)
+	}
+	if req.SimulationHoursPerMonth != nil {
+		overrides[usage.QuotaSimulationHoursPerMonth] = *req.SimulationHoursPerMonth
+	}
+	if req.ResultRows != nil {
+		overrides[usage.QuotaResultRows] = float64(*req.ResultRows)
+	}
+
+	if err := s.userService.UpdateOrganizationSetting(organizationID, database.QuotaSetting, overrides); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := usage.Quotas{}
+	if s.meter != nil {
+		s.meter.Invalidate(organizationID)
+		if response, err = s.meter.Quotas(organizationID); err != nil {
+			s.handleError(c, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.handleSuccess(c, response, "Organization quotas updated")
+}
+
+// updateOrganizationConcurrency sets an organization's concurrency limit overrides
+func (s *Server) updateOrganizationConcurrency(c *gin.Context) {
+	organizationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid organization id"), http.StatusBadRequest)
+		return
+	}
+
+	var req ConcurrencyLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	organization, err := s.userService.GetOrganization(organizationID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if organization == nil {
+		s.handleError(c, errors.New("organization not found"), http.StatusNotFound)
+		return
+	}
+
+	overrides := map[string]int{}
+	if req.PerOrganization != nil {
+		overrides["per_organization"] = *req.PerOrganization
+	}
+	if req.PerUser != nil {
+		overrides["per_user"] = *req.PerUser
+	}
+
+	if err := s.userService.UpdateOrganizationSetting(organizationID, database.ConcurrencySetting, overrides); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.orchestrator.InvalidateConcurrencyLimits(organizationID)
+	s.handleSuccess(c, s.orchestrator.ConcurrencyLimits(organizationID), "Organization concurrency limits updated")
+}
+
+// authorizeOrganization resolves the :id parameter to an organization the
+// requester belongs to, or any organization for admins
+func (s *Server) authorizeOrganization(c *gin.Context) (uuid.UUID, bool) {
+	organizationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid organization id"), http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+	if !claims.IsAdmin() && (claims.OrganizationID == nil || *claims.OrganizationID != organizationID) {
+		s.handleError(c, errors.New("not a member of this organization"), http.StatusForbidden)
+		return uuid.Nil, false
+	}
+
+	return organizationID, true
+}
+
+// requesterOrganization returns the organization of the authenticated user, or nil
+func requesterOrganization(c *gin.Context) *uuid.UUID {
+	claims := currentClaims(c)
+	if claims == nil {
+		return nil
+	}
+	return claims.OrganizationID
+}
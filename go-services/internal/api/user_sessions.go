@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/database"
+)
+
+// UserSessionResponse is one of the caller's sessions
+type UserSessionResponse struct {
+	database.UserSession
+	// The session of the token the request was made with
+	Current bool `json:"current"`
+}
+
+// loginLocked reports whether a user's account is locked after too many
+// failed logins. It records the refused attempt and writes the error
+// response itself: the same as for invalid credentials, so a locked account
+// cannot be told apart from an unknown email.
+func (s *Server) loginLocked(c *gin.Context, email string, user *database.User) bool {
+	limits := s.config.Login
+	if s.userSessions == nil || limits.MaxFailedAttempts == 0 {
+		return false
+	}
+
+	now := time.Now().UTC()
+	failures, earliest, err := s.userSessions.FailedLogins(c.Request.Context(), user.ID, now.Add(-limits.LockoutDuration))
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return true
+	}
+	if failures < limits.MaxFailedAttempts {
+		return false
+	}
+
+	s.recordLogin(c, email, user, database.LoginFailureLocked)
+	logrus.WithFields(logrus.Fields{
+		"user_id":      user.ID,
+		"locked_until": earliest.Add(limits.LockoutDuration),
+	}).Warn("Login refused for locked account")
+	s.handleError(c, errInvalidCredentials, http.StatusUnauthorized)
+	return true
+}
+
+// recordLogin records a login attempt; an empty failure reason records a
+// successful one. A failure to record it does not fail the login.
+func (s *Server) recordLogin(c *gin.Context, email string, user *database.User, failureReason string) {
+	if s.userSessions == nil {
+		return
+	}
+
+	attempt := &database.LoginAttempt{
+		Email:         email,
+		Success:       failureReason == "",
+		FailureReason: failureReason,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+	_ = s.userSessions.RecordLoginAttempt(c.Request.Context(), attempt)
+}
+
+// startUserSession records the session of a token issued at login
+func (s *Server) startUserSession(c *gin.Context, claims *auth.Claims) error {
+	if s.userSessions == nil {
+		return nil
+	}
+
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return err
+	}
+	return s.userSessions.CreateSession(c.Request.Context(), &database.UserSession{
+		ID:        id,
+		UserID:    claims.UserID,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
+}
+
+// sessionRevoked reports whether the session of a token was revoked. A
+// session is known not to be revoked for the configured check TTL, so a
+// revocation made on another replica takes up to that long to apply there.
+func (s *Server) sessionRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	if s.userSessions == nil || claims.Impersonated() {
+		return false, nil
+	}
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return false, nil
+	}
+
+	return s.sessionRevocations.Get(ctx, claims.ID, func(ctx context.Context) (bool, error) {
+		return s.userSessions.SessionRevoked(ctx, id)
+	})
+}
+
+// listMyLogins returns the caller's recent login attempts
+func (s *Server) listMyLogins(c *gin.Context) {
+	claims, ok := s.requireUserSessions(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	attempts, err := s.userSessions.ListLoginAttempts(c.Request.Context(), claims.UserID, limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, attempts, "Login history retrieved successfully")
+}
+
+// listMySessions returns the caller's active sessions
+func (s *Server) listMySessions(c *gin.Context) {
+	claims, ok := s.requireUserSessions(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := s.userSessions.ListSessions(c.Request.Context(), claims.UserID)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]UserSessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = UserSessionResponse{
+			UserSession: session,
+			Current:     session.ID.String() == claims.ID,
+		}
+	}
+
+	s.handleSuccess(c, response, "Sessions retrieved successfully")
+}
+
+// revokeMySession revokes one of the caller's sessions so its token stops
+// working
+func (s *Server) revokeMySession(c *gin.Context) {
+	claims, ok := s.requireUserSessions(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid session id"), http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := s.userSessions.RevokeSession(c.Request.Context(), claims.UserID, id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if !revoked {
+		s.handleError(c, errors.New("session not found"), http.StatusNotFound)
+		return
+	}
+	s.sessionRevocations.Invalidate(id.String())
+
+	logrus.WithFields(logrus.Fields{
+		"user_id":    claims.UserID,
+		"session_id": id,
+	}).Info("User session revoked")
+
+	s.handleSuccess(c, nil, "Session revoked successfully")
+}
+
+// requireUserSessions returns the caller's claims when sessions are
+// tracked. Impersonation tokens cannot manage the impersonated user's
+// sessions. It writes the error response itself.
+func (s *Server) requireUserSessions(c *gin.Context) (*auth.Claims, bool) {
+	if s.userSessions == nil {
+		s.handleError(c, errors.New("user sessions are not configured"), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	claims := currentClaims(c)
+	if claims == nil {
+		s.handleError(c, errors.New("authentication required"), http.StatusUnauthorized)
+		return nil, false
+	}
+	if claims.Impersonated() {
+		s.handleError(c, errors.New("impersonation tokens cannot manage sessions"), http.StatusForbidden)
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag advertises a resource version for use in a later If-Match header
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", fmt.Sprintf("%q", strconv.FormatInt(version, 10)))
+}
+
+// requireIfMatch reads the version a client expects to update from the
+// If-Match header. Missing or malformed headers are answered here.
+func (s *Server) requireIfMatch(c *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		s.handleError(c, errors.New("If-Match header with the current version is required"), http.StatusPreconditionRequired)
+		return 0, false
+	}
+
+	// Versions are compared strongly, a weak tag names the same version
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		s.handleError(c, fmt.Errorf("invalid If-Match version: %s", header), http.StatusBadRequest)
+		return 0, false
+	}
+	return version, true
+}
+
+// handleVersionConflict reports a stale If-Match along with the current version
+func (s *Server) handleVersionConflict(c *gin.Context, err error, current int64) {
+	setETag(c, current)
+	c.JSON(http.StatusConflict, ErrorResponse{
+		Error:   http.StatusText(http.StatusConflict),
+		Message: err.Error(),
+		Code:    "VERSION_CONFLICT",
+		Details: map[string]interface{}{"current_version": current},
+	})
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/notifications"
+	"voltedge/go-services/internal/report"
+)
+
+// WebhookRequest represents a request to create or update a webhook subscription
+type WebhookRequest struct {
+	Name            string    `json:"name" binding:"required"`
+	URL             string    `json:"url" binding:"required"`
+	Secret          string    `json:"secret"`
+	OrganizationID  uuid.UUID `json:"organization_id"`
+	EventTypes      []string  `json:"event_types"`
+	PayloadTemplate string    `json:"payload_template"`
+	TemplateVersion int       `json:"template_version"`
+	ContentType     string    `json:"content_type"`
+	IsActive        *bool     `json:"is_active"`
+	// Run report attached to simulation completed, stopped and failed
+	// events: "html", "pdf" or empty for none
+	ReportFormat string `json:"report_format"`
+}
+
+// TemplatePreviewRequest represents a request to render a payload template
+type TemplatePreviewRequest struct {
+	PayloadTemplate string               `json:"payload_template"`
+	TemplateVersion int                  `json:"template_version"`
+	ContentType     string               `json:"content_type"`
+	EventType       string               `json:"event_type"`
+	Event           *notifications.Event `json:"event"`
+}
+
+// TemplatePreviewResponse represents a rendered payload preview
+type TemplatePreviewResponse struct {
+	ContentType string `json:"content_type"`
+	Payload     string `json:"payload"`
+}
+
+// validate checks the request, that its URL resolves to public addresses
+// only, and its payload template
+func (r *WebhookRequest) validate(ctx context.Context) error {
+	parsed, err := url.Parse(r.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+	if err := notifications.CheckDestination(ctx, r.URL); err != nil {
+		return err
+	}
+
+	if r.PayloadTemplate != "" {
+		if _, err := notifications.ParseTemplate(r.PayloadTemplate); err != nil {
+			return err
+		}
+	} else if _, err := notifications.GetDefaultTemplate(r.TemplateVersion); err != nil {
+		return err
+	}
+
+	if r.ReportFormat != "" && r.ReportFormat != report.FormatHTML && r.ReportFormat != report.FormatPDF {
+		return errors.New("report_format must be html or pdf")
+	}
+
+	return nil
+}
+
+// apply copies request fields onto a subscription
+func (r *WebhookRequest) apply(subscription *database.WebhookSubscription) {
+	subscription.Name = r.Name
+	subscription.URL = r.URL
+	subscription.OrganizationID = r.OrganizationID
+	subscription.EventTypes = r.EventTypes
+	subscription.PayloadTemplate = r.PayloadTemplate
+	subscription.TemplateVersion = r.TemplateVersion
+	subscription.ContentType = r.ContentType
+	subscription.ReportFormat = r.ReportFormat
+	if r.Secret != "" {
+		subscription.Secret = r.Secret
+	}
+	if r.IsActive != nil {
+		subscription.IsActive = *r.IsActive
+	}
+}
+
+// createWebhook handles webhook subscription creation requests
+func (s *Server) createWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(c.Request.Context()); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	subscription := &database.WebhookSubscription{IsActive: true, OwnerID: actorID(c)}
+	req.apply(subscription)
+
+	if err := s.webhookService.CreateSubscription(subscription); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, subscription.Version)
+	s.handleSuccess(c, subscription, "Webhook created successfully")
+}
+
+// listWebhooks handles webhook subscription listing requests
+func (s *Server) listWebhooks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+
+	subscriptions, err := s.webhookService.ListSubscriptions(limit, (page-1)*limit)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, subscriptions, "Webhooks retrieved successfully")
+}
+
+// getWebhook handles single webhook subscription retrieval requests
+func (s *Server) getWebhook(c *gin.Context) {
+	subscription, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	setETag(c, subscription.Version)
+	s.handleSuccess(c, subscription, "Webhook retrieved successfully")
+}
+
+// updateWebhook handles webhook subscription update requests. The If-Match
+// header must carry the version being updated; stale versions are rejected with 409.
+func (s *Server) updateWebhook(c *gin.Context) {
+	expectedVersion, ok := s.requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	subscription, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	if subscription.Version != expectedVersion {
+		s.handleVersionConflict(c, database.ErrVersionConflict, subscription.Version)
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(c.Request.Context()); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	req.apply(subscription)
+
+	if err := s.webhookService.UpdateSubscription(subscription, expectedVersion); err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			s.handleWebhookConflict(c, subscription.ID)
+			return
+		}
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	setETag(c, subscription.Version)
+	s.handleSuccess(c, subscription, "Webhook updated successfully")
+}
+
+// handleWebhookConflict reports a subscription updated between loading and saving it
+func (s *Server) handleWebhookConflict(c *gin.Context, id uuid.UUID) {
+	current, err := s.webhookService.GetSubscription(id)
+	if err != nil || current == nil {
+		s.handleError(c, database.ErrVersionConflict, http.StatusConflict)
+		return
+	}
+	s.handleVersionConflict(c, database.ErrVersionConflict, current.Version)
+}
+
+// deleteWebhook handles webhook subscription deletion requests
+func (s *Server) deleteWebhook(c *gin.Context) {
+	subscription, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := s.webhookService.DeleteSubscription(subscription.ID); err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.handleSuccess(c, nil, "Webhook deleted successfully")
+}
+
+// previewWebhook renders the payload a subscription would receive
+func (s *Server) previewWebhook(c *gin.Context) {
+	subscription, ok := s.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req TemplatePreviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			s.handleError(c, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	event := notifications.SampleEvent(req.EventType)
+	if req.Event != nil {
+		event = *req.Event
+	}
+
+	payload, contentType, err := notifications.RenderPayload(subscription, event)
+	if err != nil {
+		s.handleError(c, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	s.handleSuccess(c, TemplatePreviewResponse{
+		ContentType: contentType,
+		Payload:     string(payload),
+	}, "Webhook payload rendered successfully")
+}
+
+// listNotificationTemplates returns the versioned built-in payload templates
+func (s *Server) listNotificationTemplates(c *gin.Context) {
+	s.handleSuccess(c, gin.H{
+		"latest_version": notifications.LatestTemplateVersion(),
+		"templates":      notifications.DefaultTemplates(),
+	}, "Notification templates retrieved successfully")
+}
+
+// previewNotificationTemplate renders an ad-hoc template without a saved subscription
+func (s *Server) previewNotificationTemplate(c *gin.Context) {
+	var req TemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	event := notifications.SampleEvent(req.EventType)
+	if req.Event != nil {
+		event = *req.Event
+	}
+
+	subscription := &database.WebhookSubscription{
+		PayloadTemplate: req.PayloadTemplate,
+		TemplateVersion: req.TemplateVersion,
+		ContentType:     req.ContentType,
+	}
+
+	payload, contentType, err := notifications.RenderPayload(subscription, event)
+	if err != nil {
+		s.handleError(c, err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	s.handleSuccess(c, TemplatePreviewResponse{
+		ContentType: contentType,
+		Payload:     string(payload),
+	}, "Notification template rendered successfully")
+}
+
+// loadWebhook resolves the :id parameter to a subscription, writing an error response on failure
+func (s *Server) loadWebhook(c *gin.Context) (*database.WebhookSubscription, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		s.handleError(c, errors.New("invalid webhook id"), http.StatusBadRequest)
+		return nil, false
+	}
+
+	logrus.WithField("webhook_id", id).Debug("Loading webhook")
+
+	subscription, err := s.webhookService.GetSubscription(id)
+	if err != nil {
+		s.handleError(c, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	if subscription == nil {
+		s.handleError(c, errors.New("webhook not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return subscription, true
+}
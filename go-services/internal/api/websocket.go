@@ -0,0 +1,355 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/internal/realtime"
+)
+
+// websocketTopicBuffer is how many messages of one topic may queue for a
+// slow client before further ones are dropped
+const websocketTopicBuffer = 64
+
+// websocketMaxTopics caps the topics a single connection may subscribe to
+const websocketMaxTopics = 100
+
+// websocketWriteTimeout bounds a single write to a client
+const websocketWriteTimeout = 10 * time.Second
+
+// WebSocket subprotocols selecting the streaming format; they take
+// precedence over ?format
+var websocketSubprotocols = map[string]string{
+	"voltedge.msgpack": realtime.FormatMsgPack,
+	"voltedge.json":    realtime.FormatJSON,
+}
+
+// Subscription actions
+const (
+	actionSubscribe   = "subscribe"
+	actionUnsubscribe = "unsubscribe"
+)
+
+// SubscriptionRequest is sent by clients to change their topics, e.g.
+// {"action":"subscribe","id":"1","topics":["sim.sim_1.results"]}
+type SubscriptionRequest struct {
+	Action string   `json:"action"`
+	ID     string   `json:"id,omitempty"`
+	Topics []string `json:"topics"`
+}
+
+// SubscriptionAck answers a subscription request. Topics lists the
+// connection's topics after the request; rejected topics map to the reason.
+type SubscriptionAck struct {
+	Type     string            `json:"type"`
+	ID       string            `json:"id,omitempty"`
+	Action   string            `json:"action"`
+	Topics   []string          `json:"topics"`
+	Rejected map[string]string `json:"rejected,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Errors returned when authorizing topics
+var (
+	errInvalidTopic   = errors.New("invalid topic")
+	errUnknownTopic   = errors.New("simulation not found")
+	errTopicForbidden = errors.New("not authorized for this topic")
+	errTooManyTopics  = errors.New("too many topics")
+)
+
+// handleWebSocket upgrades the connection and streams the messages of the
+// topics the client subscribes to until the client goes away. Browsers that
+// cannot set headers may pass their token as ?access_token. Messages are JSON
+// text frames unless the client selects msgpack, which is sent as binary
+// frames; clients may send subscription requests in either format.
+func (s *Server) handleWebSocket(c *gin.Context) {
+	claims, err := s.websocketClaims(c)
+	if err != nil {
+		s.handleError(c, err, http.StatusUnauthorized)
+		return
+	}
+
+	format, err := realtime.ParseFormat(c.Query("format"))
+	if err != nil {
+		s.handleError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     s.checkWebSocketOrigin,
+		Subprotocols:    slices.Sorted(maps.Keys(websocketSubprotocols)),
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		logrus.WithError(err).Debug("WebSocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	if selected, ok := websocketSubprotocols[conn.Subprotocol()]; ok {
+		format = selected
+	}
+	encoder := realtime.NewEncoder(format)
+	frameType := websocket.TextMessage
+	if realtime.Binary(format) {
+		frameType = websocket.BinaryMessage
+	}
+	write := func(v interface{}) error {
+		data, err := encoder.Encode(v)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to encode WebSocket message")
+			return nil
+		}
+		conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+		return conn.WriteMessage(frameType, data)
+	}
+
+	subscriber := s.hub.Subscribe(websocketTopicBuffer)
+	defer func() {
+		s.hub.Unsubscribe(subscriber)
+		if dropped := subscriber.Dropped(); dropped > 0 {
+			logrus.WithFields(logrus.Fields{
+				"remote_addr": c.ClientIP(),
+				"dropped":     dropped,
+			}).Warn("WebSocket client dropped messages")
+		}
+	}()
+
+	logrus.WithFields(logrus.Fields{
+		"remote_addr": c.ClientIP(),
+		"format":      format,
+	}).Debug("WebSocket client connected")
+
+	timeout := s.config.WebSocketTimeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	// Only the loop below writes to the connection, so the reader hands it
+	// acks and initial topic state instead of writing them itself
+	replies := make(chan interface{}, 16)
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			requestFormat := realtime.FormatJSON
+			if messageType == websocket.BinaryMessage {
+				requestFormat = realtime.FormatMsgPack
+			}
+
+			var replyBatch []interface{}
+			var req SubscriptionRequest
+			if err := realtime.Decode(requestFormat, data, &req); err != nil {
+				replyBatch = []interface{}{SubscriptionAck{Type: "ack", Error: "invalid subscription request", Topics: subscriber.Topics()}}
+			} else {
+				replyBatch = s.handleSubscription(c.Request.Context(), claims, subscriber, req)
+			}
+
+			for _, reply := range replyBatch {
+				select {
+				case replies <- reply:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	ping := time.NewTicker(timeout * 9 / 10)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case reply := <-replies:
+			if err := write(reply); err != nil {
+				return
+			}
+		case <-subscriber.Ready():
+			for _, msg := range subscriber.Drain() {
+				if err := write(msg); err != nil {
+					return
+				}
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleSubscription applies a subscription request and returns the ack,
+// followed by the current state of newly subscribed topics that have one
+func (s *Server) handleSubscription(ctx context.Context, claims *auth.Claims, subscriber *realtime.Subscriber, req SubscriptionRequest) []interface{} {
+	ack := SubscriptionAck{Type: "ack", ID: req.ID, Action: req.Action}
+
+	switch req.Action {
+	case actionSubscribe:
+		current := subscriber.Topics()
+		var accepted []string
+		for _, topic := range req.Topics {
+			if slices.Contains(current, topic) {
+				continue
+			}
+			if len(current)+len(accepted) >= websocketMaxTopics {
+				ack.reject(topic, errTooManyTopics)
+				continue
+			}
+			if err := s.authorizeTopic(ctx, claims, topic); err != nil {
+				ack.reject(topic, err)
+				continue
+			}
+			accepted = append(accepted, topic)
+		}
+		subscriber.Add(accepted...)
+		ack.Topics = subscriber.Topics()
+
+		replies := []interface{}{ack}
+		for _, topic := range accepted {
+			if msg, ok := s.topicState(ctx, topic); ok {
+				replies = append(replies, msg)
+			}
+		}
+		return replies
+	case actionUnsubscribe:
+		subscriber.Remove(req.Topics...)
+		ack.Topics = subscriber.Topics()
+	default:
+		ack.Error = "action must be subscribe or unsubscribe"
+		ack.Topics = subscriber.Topics()
+	}
+
+	return []interface{}{ack}
+}
+
+// reject records why a topic was not subscribed to
+func (a *SubscriptionAck) reject(topic string, err error) {
+	if a.Rejected == nil {
+		a.Rejected = make(map[string]string)
+	}
+	a.Rejected[topic] = err.Error()
+}
+
+// authorizeTopic checks that a topic is valid and that the caller may read
+// it. Simulations without an organization are readable by anyone; others only
+// by members of their organization and admins.
+func (s *Server) authorizeTopic(ctx context.Context, claims *auth.Claims, topic string) error {
+	parsed, ok := realtime.ParseTopic(topic)
+	if !ok {
+		return errInvalidTopic
+	}
+
+	organizationID, found := s.simulationOrganization(ctx, parsed.ID)
+	if !found {
+		return errUnknownTopic
+	}
+	if organizationID == nil || (claims != nil && claims.IsAdmin()) {
+		return nil
+	}
+	if claims == nil || claims.OrganizationID == nil || *claims.OrganizationID != *organizationID {
+		return errTopicForbidden
+	}
+	return nil
+}
+
+// simulationOrganization looks a topic's simulation up and returns its
+// organization, if any
+func (s *Server) simulationOrganization(ctx context.Context, id string) (*uuid.UUID, bool) {
+	simulation, err := s.orchestrator.GetSimulation(ctx, id)
+	if err != nil {
+		return nil, false
+	}
+	return simulation.OrganizationID, true
+}
+
+// topicState returns the message describing a topic's current state, sent
+// right after subscribing. Only topology topics have one.
+func (s *Server) topicState(ctx context.Context, topic string) (realtime.Message, bool) {
+	parsed, ok := realtime.ParseTopic(topic)
+	if !ok || parsed.Channel != realtime.ChannelTopology {
+		return realtime.Message{}, false
+	}
+
+	simulation, err := s.orchestrator.GetSimulation(ctx, parsed.ID)
+	if err != nil {
+		return realtime.Message{}, false
+	}
+
+	return realtime.Message{
+		Type:      realtime.MessageTopologySnapshot,
+		Topic:     topic,
+		Timestamp: time.Now().UTC(),
+		Data:      simulation.Config.BuildTopology(),
+	}, true
+}
+
+// ResultsFrame is the data of a results topic message: the metrics sample
+// and the simulation's progress when its config declares a target
+type ResultsFrame struct {
+	orchestration.MetricsSample
+	Progress *orchestration.Progress `json:"progress,omitempty"`
+}
+
+// publishResults pushes a simulation's metrics sample to its results topic
+func (s *Server) publishResults(simulationID string, sample orchestration.MetricsSample) {
+	frame := ResultsFrame{MetricsSample: sample}
+	if progress, err := s.orchestrator.SimulationProgress(simulationID); err == nil {
+		frame.Progress = progress
+	}
+	s.hub.Publish(realtime.Message{
+		Type:  realtime.MessageResultsSample,
+		Topic: realtime.ResultsTopic(simulationID),
+		Data:  frame,
+	})
+}
+
+// websocketClaims returns the claims of the connecting client, accepting a
+// token in the access_token query parameter when no header was sent
+func (s *Server) websocketClaims(c *gin.Context) (*auth.Claims, error) {
+	if claims := currentClaims(c); claims != nil {
+		return claims, nil
+	}
+
+	token := c.Query("access_token")
+	if token == "" || s.tokens == nil {
+		return nil, nil
+	}
+	return s.tokens.Parse(token)
+}
+
+// checkWebSocketOrigin applies the CORS origins to browser connections
+func (s *Server) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	return slices.Contains(s.config.CORSOrigins, "*") || slices.Contains(s.config.CORSOrigins, origin)
+}
@@ -0,0 +1,526 @@
+// Package archive moves the results of long-finished simulations to object
+// storage as compressed Parquet and serves them back when they are read
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/backup"
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/lock"
+)
+
+// Read modes for archived results
+const (
+	// Archived results are copied back into the database on first read
+	ModeRehydrate = "rehydrate"
+	// Archived results are read from object storage on every read
+	ModeStream = "stream"
+)
+
+// FormatParquet is the only archive format written
+const FormatParquet = "parquet"
+
+// lockName guards passes so only one replica archives at a time
+const lockName = "voltedge:archive"
+
+// batchSize is how many results are read or restored at a time
+const batchSize = 1000
+
+// finishedStatuses are the simulation statuses eligible for archival
+var finishedStatuses = []string{"completed", "failed", "stopped", "interrupted"}
+
+// Store is the database side of archival
+type Store interface {
+	ArchiveCandidates(before time.Time, statuses []string, limit int) ([]uuid.UUID, error)
+	EachResultBatch(simulationID uuid.UUID, size int, fn func(results []database.SimulationResult) error) error
+	CompleteArchive(archive *database.ResultArchive) error
+	GetResultArchive(simulationID uuid.UUID) (*database.ResultArchive, error)
+	ListResultArchives(limit, offset int) ([]database.ResultArchive, error)
+	RestoreResults(results []database.SimulationResult) error
+	MarkRehydrated(simulationID uuid.UUID) error
+}
+
+// ResultSource reads results still held in the database
+type ResultSource interface {
+	GetSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]database.SimulationResult, error)
+	CountSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) (int64, error)
+}
+
+// Options configures the archiver
+type Options struct {
+	// Time between passes; zero disables the background loop
+	Interval time.Duration
+	// Simulations are archived this long after they finish
+	MinAge time.Duration
+	// Maximum simulations archived per pass
+	BatchSize int
+	// ModeRehydrate or ModeStream
+	ReadMode string
+	// Optional; when set, passes are skipped while another replica holds the lock
+	Locker lock.Locker
+}
+
+// Report summarizes an archival pass
+type Report struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Archived   int       `json:"archived"`
+	Rows       int64     `json:"rows"`
+	// Another replica was archiving, so this pass did nothing
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Archiver archives simulation results and reads them back. It implements
+// ResultSource, so readers of historical results can use it in place of the
+// database without knowing whether the results were archived.
+type Archiver struct {
+	store   Store
+	results ResultSource
+	storage backup.Storage
+	opts    Options
+
+	// Serializes passes
+	runMu sync.Mutex
+
+	mu          sync.Mutex
+	last        *Report
+	rehydrating map[uuid.UUID]*rehydration
+}
+
+// rehydration lets concurrent reads of an archived simulation wait for a
+// single copy back into the database
+type rehydration struct {
+	done chan struct{}
+	err  error
+}
+
+// New creates a new archiver
+func New(store Store, results ResultSource, storage backup.Storage, opts Options) *Archiver {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.ReadMode == "" {
+		opts.ReadMode = ModeRehydrate
+	}
+
+	return &Archiver{
+		store:       store,
+		results:     results,
+		storage:     storage,
+		opts:        opts,
+		rehydrating: make(map[uuid.UUID]*rehydration),
+	}
+}
+
+// Start runs a pass every interval until ctx is done
+func (a *Archiver) Start(ctx context.Context) {
+	if a.opts.Interval <= 0 {
+		logrus.Info("Result archival loop disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(a.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run performs a single archival pass
+func (a *Archiver) Run(ctx context.Context) *Report {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	report := &Report{StartedAt: time.Now()}
+
+	if a.opts.Locker == nil {
+		a.archiveDue(ctx, report)
+	} else {
+		ran, err := lock.RunExclusive(ctx, a.opts.Locker, lockName, func(ctx context.Context) {
+			a.archiveDue(ctx, report)
+		})
+		if err != nil {
+			report.Error = err.Error()
+		}
+		report.Skipped = !ran && err == nil
+	}
+
+	report.FinishedAt = time.Now()
+	if report.Skipped {
+		return report
+	}
+
+	a.mu.Lock()
+	a.last = report
+	a.mu.Unlock()
+
+	return report
+}
+
+// archiveDue archives simulations that finished more than MinAge ago
+func (a *Archiver) archiveDue(ctx context.Context, report *Report) {
+	ids, err := a.store.ArchiveCandidates(report.StartedAt.Add(-a.opts.MinAge), finishedStatuses, a.opts.BatchSize)
+	if err != nil {
+		report.Error = err.Error()
+		return
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+
+		archive, err := a.Archive(ctx, id)
+		if err != nil {
+			report.Error = err.Error()
+			logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to archive simulation results")
+			continue
+		}
+		report.Archived++
+		report.Rows += archive.Rows
+	}
+}
+
+// LastReport returns the report of the most recent pass, or nil before the first
+func (a *Archiver) LastReport() *Report {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.last
+}
+
+// Archives returns archive pointers, most recently archived first
+func (a *Archiver) Archives(limit, offset int) ([]database.ResultArchive, error) {
+	return a.store.ListResultArchives(limit, offset)
+}
+
+// Archive writes a simulation's results to object storage and replaces them
+// in the database with a pointer row. It does not check the simulation's
+// status or age.
+func (a *Archiver) Archive(ctx context.Context, simulationID uuid.UUID) (*database.ResultArchive, error) {
+	name := objectName(simulationID)
+	archive := &database.ResultArchive{
+		SimulationID: simulationID,
+		Object:       name,
+		Location:     strings.TrimSuffix(a.storage.String(), "/") + "/" + name,
+		Format:       FormatParquet,
+	}
+
+	w, err := a.storage.Create(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	writer := parquet.NewGenericWriter[archivedResult](w, parquet.Compression(&parquet.Zstd))
+
+	rows := make([]archivedResult, 0, batchSize)
+	err = a.store.EachResultBatch(simulationID, batchSize, func(results []database.SimulationResult) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rows = rows[:0]
+		for i := range results {
+			rows = append(rows, newArchivedResult(&results[i]))
+			archive.FirstTimestamp = earliest(archive.FirstTimestamp, results[i].Timestamp)
+			archive.LastTimestamp = latest(archive.LastTimestamp, results[i].Timestamp)
+		}
+		archive.Rows += int64(len(rows))
+
+		_, err := writer.Write(rows)
+		return err
+	})
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	archive.ArchivedAt = time.Now()
+	if err := a.store.CompleteArchive(archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// GetSimulationResultsInRange returns results between two optional
+// timestamps in chronological order, wherever they are stored. Archived
+// results are rehydrated or streamed depending on the read mode.
+func (a *Archiver) GetSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]database.SimulationResult, error) {
+	archive, err := a.store.GetResultArchive(simulationID)
+	if err != nil {
+		return nil, err
+	}
+	if archive == nil || archive.RehydratedAt != nil {
+		return a.results.GetSimulationResultsInRange(ctx, simulationID, from, to)
+	}
+
+	// Nothing to fetch when the range misses the archive entirely
+	if outsideArchive(archive, from, to) {
+		return []database.SimulationResult{}, nil
+	}
+
+	if a.opts.ReadMode == ModeStream {
+		return a.readArchive(ctx, archive, from, to)
+	}
+
+	if err := a.Rehydrate(ctx, simulationID); err != nil {
+		return nil, err
+	}
+	return a.results.GetSimulationResultsInRange(ctx, simulationID, from, to)
+}
+
+// CountSimulationResultsInRange counts the results GetSimulationResultsInRange
+// returns without reading them. Results still archived are counted by the
+// size of the archive, since only reading it tells which fall in the range.
+func (a *Archiver) CountSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) (int64, error) {
+	archive, err := a.store.GetResultArchive(simulationID)
+	if err != nil {
+		return 0, err
+	}
+	if archive == nil || archive.RehydratedAt != nil {
+		return a.results.CountSimulationResultsInRange(ctx, simulationID, from, to)
+	}
+	if outsideArchive(archive, from, to) {
+		return 0, nil
+	}
+	return archive.Rows, nil
+}
+
+// outsideArchive reports whether a range misses an archive's results entirely
+func outsideArchive(archive *database.ResultArchive, from, to *time.Time) bool {
+	return (from != nil && archive.LastTimestamp != nil && archive.LastTimestamp.Before(*from)) ||
+		(to != nil && archive.FirstTimestamp != nil && archive.FirstTimestamp.After(*to))
+}
+
+// Rehydrate copies a simulation's archived results back into the database.
+// The archive and its pointer are kept, so the results can be archived again
+// later without rewriting them from scratch.
+func (a *Archiver) Rehydrate(ctx context.Context, simulationID uuid.UUID) error {
+	a.mu.Lock()
+	if call, ok := a.rehydrating[simulationID]; ok {
+		a.mu.Unlock()
+		<-call.done
+		return call.err
+	}
+	call := &rehydration{done: make(chan struct{})}
+	a.rehydrating[simulationID] = call
+	a.mu.Unlock()
+
+	call.err = a.rehydrate(ctx, simulationID)
+
+	a.mu.Lock()
+	delete(a.rehydrating, simulationID)
+	a.mu.Unlock()
+	close(call.done)
+
+	return call.err
+}
+
+func (a *Archiver) rehydrate(ctx context.Context, simulationID uuid.UUID) error {
+	archive, err := a.store.GetResultArchive(simulationID)
+	if err != nil {
+		return err
+	}
+	if archive == nil {
+		return ErrNotArchived
+	}
+	// Another reader finished first
+	if archive.RehydratedAt != nil {
+		return nil
+	}
+
+	err = a.eachArchivedBatch(ctx, archive, func(results []database.SimulationResult) error {
+		return a.store.RestoreResults(results)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rehydrate results: %w", err)
+	}
+	if err := a.store.MarkRehydrated(simulationID); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulationID,
+		"rows":          archive.Rows,
+	}).Info("Rehydrated archived simulation results")
+	return nil
+}
+
+// readArchive reads the archived results within the range straight from storage
+func (a *Archiver) readArchive(ctx context.Context, archive *database.ResultArchive, from, to *time.Time) ([]database.SimulationResult, error) {
+	results := []database.SimulationResult{}
+
+	err := a.eachArchivedBatch(ctx, archive, func(batch []database.SimulationResult) error {
+		for _, result := range batch {
+			if from != nil && result.Timestamp.Before(*from) {
+				continue
+			}
+			if to != nil && result.Timestamp.After(*to) {
+				continue
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived results: %w", err)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.Before(results[j].Timestamp)
+	})
+	return results, nil
+}
+
+// eachArchivedBatch reads an archive in batches, passing each to fn
+func (a *Archiver) eachArchivedBatch(ctx context.Context, archive *database.ResultArchive, fn func(results []database.SimulationResult) error) error {
+	file, err := a.storage.OpenFile(ctx, archive.Object)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := parquet.NewGenericReader[archivedResult](file)
+	defer reader.Close()
+
+	rows := make([]archivedResult, batchSize)
+	results := make([]database.SimulationResult, 0, batchSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := reader.Read(rows)
+		if n > 0 {
+			results = results[:0]
+			for i := range rows[:n] {
+				result, convErr := rows[i].result(archive.SimulationID)
+				if convErr != nil {
+					return convErr
+				}
+				results = append(results, result)
+			}
+			if err := fn(results); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// objectName is where a simulation's results are archived, relative to the storage root
+func objectName(simulationID uuid.UUID) string {
+	return simulationID.String() + "/results.parquet"
+}
+
+// archivedResult is the Parquet row of an archived result. It keeps the ID
+// so rehydrated results are identical to the originals.
+type archivedResult struct {
+	ID                   string    `parquet:"id"`
+	Timestamp            time.Time `parquet:"timestamp"`
+	TickNumber           int64     `parquet:"tick_number"`
+	TotalGenerationMW    float64   `parquet:"total_generation_mw"`
+	TotalConsumptionMW   float64   `parquet:"total_consumption_mw"`
+	GridFrequencyHz      float64   `parquet:"grid_frequency_hz"`
+	GridVoltageKV        float64   `parquet:"grid_voltage_kv"`
+	EfficiencyPercentage float64   `parquet:"efficiency_percentage"`
+	FaultCount           int64     `parquet:"fault_count"`
+	// JSON encoded; empty when unset
+	Metadata string `parquet:"metadata"`
+}
+
+func newArchivedResult(r *database.SimulationResult) archivedResult {
+	row := archivedResult{
+		ID:                   r.ID.String(),
+		Timestamp:            r.Timestamp,
+		TickNumber:           int64(r.TickNumber),
+		TotalGenerationMW:    r.TotalGenerationMW,
+		TotalConsumptionMW:   r.TotalConsumptionMW,
+		GridFrequencyHz:      r.GridFrequencyHz,
+		GridVoltageKV:        r.GridVoltageKV,
+		EfficiencyPercentage: r.EfficiencyPercentage,
+		FaultCount:           int64(r.FaultCount),
+	}
+	if r.Metadata != nil {
+		if encoded, err := json.Marshal(r.Metadata); err == nil {
+			row.Metadata = string(encoded)
+		}
+	}
+	return row
+}
+
+func (row *archivedResult) result(simulationID uuid.UUID) (database.SimulationResult, error) {
+	id, err := uuid.Parse(row.ID)
+	if err != nil {
+		return database.SimulationResult{}, fmt.Errorf("invalid archived result ID %q: %w", row.ID, err)
+	}
+
+	result := database.SimulationResult{
+		ID:                   id,
+		SimulationID:         simulationID,
+		Timestamp:            row.Timestamp,
+		TickNumber:           int(row.TickNumber),
+		TotalGenerationMW:    row.TotalGenerationMW,
+		TotalConsumptionMW:   row.TotalConsumptionMW,
+		GridFrequencyHz:      row.GridFrequencyHz,
+		GridVoltageKV:        row.GridVoltageKV,
+		EfficiencyPercentage: row.EfficiencyPercentage,
+		FaultCount:           int(row.FaultCount),
+	}
+	if row.Metadata != "" {
+		if err := json.Unmarshal([]byte(row.Metadata), &result.Metadata); err != nil {
+			return result, fmt.Errorf("invalid archived result metadata: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func earliest(current *time.Time, t time.Time) *time.Time {
+	if current == nil || t.Before(*current) {
+		return &t
+	}
+	return current
+}
+
+func latest(current *time.Time, t time.Time) *time.Time {
+	if current == nil || t.After(*current) {
+		return &t
+	}
+	return current
+}
+
+// Errors
+var (
+	ErrNotArchived = fmt.Errorf("simulation results are not archived")
+)
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// shareAudience marks share link tokens
+const shareAudience = "share"
+
+// ShareClaims are the claims of a share link token. The token ID is the share
+// link's ID, so revoking the link revokes the token.
+type ShareClaims struct {
+	SimulationID uuid.UUID `json:"sim"`
+	jwt.RegisteredClaims
+}
+
+// IssueShare creates the token of a share link to a simulation
+func (m *TokenManager) IssueShare(linkID, simulationID uuid.UUID, expiresAt time.Time) (string, error) {
+	now := time.Now().UTC()
+	claims := &ShareClaims{
+		SimulationID: simulationID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        linkID.String(),
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{shareAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.shareKey())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign share token: %w", err)
+	}
+	return token, nil
+}
+
+// ParseShare verifies a share link token and returns its claims. It does not
+// check whether the link was revoked.
+func (m *TokenManager) ParseShare(token string) (*ShareClaims, error) {
+	claims := &ShareClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return m.shareKey(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer), jwt.WithAudience(shareAudience))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+// shareKey derives the share token key from the secret, so share tokens and
+// session tokens cannot stand in for each other
+func (m *TokenManager) shareKey() []byte {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte("share-links"))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,272 @@
+// Package backup snapshots simulations to a directory or S3 bucket, restores
+// those snapshots and exports simulation results for offline analysis
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ManifestName is the file describing a backup. It is written last, so a
+// backup without one is incomplete.
+const ManifestName = "manifest.json"
+
+// FormatVersion is the backup layout version written to the manifest
+const FormatVersion = 1
+
+// batchSize is how many rows are read or restored at a time
+const batchSize = 500
+
+// Store is the database side of backup, restore and export
+type Store interface {
+	SimulationIDs() ([]uuid.UUID, error)
+	// NewRows returns a pointer to an empty slice of the table's model
+	NewRows(table string) (interface{}, error)
+	EachBatch(table string, simulationIDs []uuid.UUID, size int, fn func(rows interface{}) error) error
+	RestoreRows(rows interface{}) (int64, error)
+}
+
+// Manifest describes the contents of a backup
+type Manifest struct {
+	Version     int           `json:"version"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Simulations []uuid.UUID   `json:"simulations"`
+	Tables      []TableBackup `json:"tables"`
+}
+
+// TableBackup is one table's file within a backup
+type TableBackup struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Rows int64  `json:"rows"`
+}
+
+// TableRestore reports how much of a table was restored. Rows whose primary
+// key already exists are skipped, so Inserted may be less than Rows.
+type TableRestore struct {
+	Name     string `json:"name"`
+	Rows     int64  `json:"rows"`
+	Inserted int64  `json:"inserted"`
+}
+
+// Backup writes the given tables for the given simulations, or for every
+// simulation when simulationIDs is empty. Tables are written as gzipped
+// NDJSON, one file per table, followed by the manifest.
+func Backup(ctx context.Context, store Store, storage Storage, tables []string, simulationIDs []uuid.UUID) (*Manifest, error) {
+	if len(simulationIDs) == 0 {
+		ids, err := store.SimulationIDs()
+		if err != nil {
+			return nil, err
+		}
+		simulationIDs = ids
+	}
+
+	manifest := &Manifest{
+		Version:     FormatVersion,
+		CreatedAt:   time.Now().UTC(),
+		Simulations: simulationIDs,
+		Tables:      make([]TableBackup, 0, len(tables)),
+	}
+
+	for _, table := range tables {
+		backup, err := backupTable(ctx, store, storage, table, simulationIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", table, err)
+		}
+		manifest.Tables = append(manifest.Tables, backup)
+	}
+
+	if err := writeManifest(ctx, storage, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// backupTable writes one table's rows to its file
+func backupTable(ctx context.Context, store Store, storage Storage, table string, simulationIDs []uuid.UUID) (TableBackup, error) {
+	backup := TableBackup{Name: table, File: table + ".ndjson.gz"}
+
+	w, err := storage.Create(ctx, backup.File)
+	if err != nil {
+		return backup, err
+	}
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+
+	err = store.EachBatch(table, simulationIDs, batchSize, func(rows interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		slice := reflect.ValueOf(rows).Elem()
+		for i := 0; i < slice.Len(); i++ {
+			if err := encoder.Encode(record(slice.Index(i))); err != nil {
+				return err
+			}
+		}
+		backup.Rows += int64(slice.Len())
+		return nil
+	})
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return backup, err
+}
+
+// record returns a row's columns keyed by their JSON names, leaving out
+// associations so each row is written once, in its own table
+func record(row reflect.Value) map[string]interface{} {
+	t := row.Type()
+	columns := make(map[string]interface{}, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Contains(field.Tag.Get("gorm"), "foreignKey:") {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns[name] = row.Field(i).Interface()
+	}
+	return columns
+}
+
+func writeManifest(ctx context.Context, storage Storage, manifest *Manifest) error {
+	w, err := storage.Create(ctx, ManifestName)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(manifest)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest reads and checks the manifest of a backup
+func ReadManifest(ctx context.Context, storage Storage) (*Manifest, error) {
+	r, err := storage.Open(ctx, ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoManifest, err)
+	}
+	defer r.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if manifest.Version != FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// Restore loads a backup written by Backup. Tables are restored in manifest
+// order, which puts parents before children. Rows that already exist are
+// left untouched, so restoring the same backup twice is harmless.
+func Restore(ctx context.Context, store Store, storage Storage) ([]TableRestore, error) {
+	manifest, err := ReadManifest(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := make([]TableRestore, 0, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		result, err := restoreTable(ctx, store, storage, table)
+		restored = append(restored, result)
+		if err != nil {
+			return restored, fmt.Errorf("failed to restore %s: %w", table.Name, err)
+		}
+	}
+	return restored, nil
+}
+
+// restoreTable inserts one table's rows in batches
+func restoreTable(ctx context.Context, store Store, storage Storage, table TableBackup) (TableRestore, error) {
+	result := TableRestore{Name: table.Name}
+
+	rows, err := store.NewRows(table.Name)
+	if err != nil {
+		return result, err
+	}
+	slice := reflect.ValueOf(rows).Elem()
+
+	r, err := storage.Open(ctx, table.File)
+	if err != nil {
+		return result, err
+	}
+	defer r.Close()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return result, err
+	}
+	decoder := json.NewDecoder(gz)
+
+	flush := func() error {
+		if slice.Len() == 0 {
+			return nil
+		}
+		inserted, err := store.RestoreRows(rows)
+		if err != nil {
+			return err
+		}
+		result.Inserted += inserted
+		slice.SetLen(0)
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		row := reflect.New(slice.Type().Elem())
+		if err := decoder.Decode(row.Interface()); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return result, fmt.Errorf("row %d: %w", result.Rows+1, err)
+		}
+		slice.Set(reflect.Append(slice, row.Elem()))
+		result.Rows++
+
+		if slice.Len() >= batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, flush()
+}
+
+// Errors
+var (
+	ErrInvalidTarget      = fmt.Errorf("invalid storage target")
+	ErrNoManifest         = fmt.Errorf("backup manifest not found")
+	ErrUnsupportedVersion = fmt.Errorf("unsupported backup format version")
+	ErrSimulationNotFound = fmt.Errorf("simulation not found")
+	ErrUnsupportedFormat  = fmt.Errorf("unsupported export format")
+)
@@ -0,0 +1,358 @@
+package backup
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Export formats
+const (
+	FormatParquet = "parquet"
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+)
+
+// ExportedFile is one file written by Export
+type ExportedFile struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// Export writes a simulation's definition as simulation.json and its
+// results, component metrics, fault events and alerts as one file each in
+// the given format
+func Export(ctx context.Context, store Store, storage Storage, simulationID uuid.UUID, format string) ([]ExportedFile, error) {
+	if format != FormatParquet && format != FormatCSV && format != FormatNDJSON {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	ids := []uuid.UUID{simulationID}
+
+	simulation, err := exportSimulation(ctx, store, storage, simulationID)
+	if err != nil {
+		return nil, err
+	}
+	files := []ExportedFile{simulation}
+
+	exports := []func() (ExportedFile, error){
+		func() (ExportedFile, error) {
+			return exportTable(ctx, store, storage, "simulation_results", "results", format, ids, newResultRow)
+		},
+		func() (ExportedFile, error) {
+			return exportTable(ctx, store, storage, "component_metrics", "component_metrics", format, ids, newMetricRow)
+		},
+		func() (ExportedFile, error) {
+			return exportTable(ctx, store, storage, "fault_events", "fault_events", format, ids, newFaultRow)
+		},
+		func() (ExportedFile, error) {
+			return exportTable(ctx, store, storage, "alerts", "alerts", format, ids, newAlertRow)
+		},
+	}
+	for _, export := range exports {
+		file, err := export()
+		if err != nil {
+			return files, fmt.Errorf("failed to export %s: %w", file.Name, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// exportSimulation writes the simulation row, including its configuration
+func exportSimulation(ctx context.Context, store Store, storage Storage, simulationID uuid.UUID) (ExportedFile, error) {
+	file := ExportedFile{Name: "simulation.json"}
+
+	var simulation map[string]interface{}
+	err := store.EachBatch("simulations", []uuid.UUID{simulationID}, 1, func(rows interface{}) error {
+		slice := reflect.ValueOf(rows).Elem()
+		if slice.Len() > 0 {
+			simulation = record(slice.Index(0))
+		}
+		return nil
+	})
+	if err != nil {
+		return file, err
+	}
+	if simulation == nil {
+		return file, fmt.Errorf("%w: %s", ErrSimulationNotFound, simulationID)
+	}
+
+	w, err := storage.Create(ctx, file.Name)
+	if err != nil {
+		return file, err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(simulation)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	file.Rows = 1
+	return file, err
+}
+
+// exportTable converts a table's model rows to flat export rows and writes
+// them to name with the format's extension
+func exportTable[M any, R any](ctx context.Context, store Store, storage Storage, table, name, format string, ids []uuid.UUID, convert func(*M) R) (ExportedFile, error) {
+	file := ExportedFile{Name: name + "." + format}
+
+	w, err := storage.Create(ctx, file.Name)
+	if err != nil {
+		return file, err
+	}
+
+	writer := newRowWriter[R](w, format)
+	batch := make([]R, 0, batchSize)
+
+	err = store.EachBatch(table, ids, batchSize, func(rows interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		models := *rows.(*[]M)
+		batch = batch[:0]
+		for i := range models {
+			batch = append(batch, convert(&models[i]))
+		}
+		file.Rows += int64(len(batch))
+		return writer.Write(batch)
+	})
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return file, err
+}
+
+// rowWriter writes export rows in one of the export formats
+type rowWriter[R any] interface {
+	Write(rows []R) error
+	// Close flushes buffered rows; it does not close the underlying writer
+	Close() error
+}
+
+func newRowWriter[R any](w io.Writer, format string) rowWriter[R] {
+	switch format {
+	case FormatParquet:
+		return &parquetWriter[R]{writer: parquet.NewGenericWriter[R](w, parquet.Compression(&parquet.Zstd))}
+	case FormatCSV:
+		return &csvWriter[R]{writer: csv.NewWriter(w)}
+	default:
+		return &ndjsonWriter[R]{encoder: json.NewEncoder(w)}
+	}
+}
+
+type parquetWriter[R any] struct {
+	writer *parquet.GenericWriter[R]
+}
+
+func (p *parquetWriter[R]) Write(rows []R) error {
+	_, err := p.writer.Write(rows)
+	return err
+}
+
+func (p *parquetWriter[R]) Close() error {
+	return p.writer.Close()
+}
+
+type ndjsonWriter[R any] struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter[R]) Write(rows []R) error {
+	for i := range rows {
+		if err := n.encoder.Encode(rows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonWriter[R]) Close() error {
+	return nil
+}
+
+// csvWriter writes a header from the row type's parquet column names,
+// followed by one record per row
+type csvWriter[R any] struct {
+	writer *csv.Writer
+	header bool
+}
+
+func (c *csvWriter[R]) Write(rows []R) error {
+	if !c.header {
+		c.header = true
+		var row R
+		t := reflect.TypeOf(row)
+		names := make([]string, t.NumField())
+		for i := range names {
+			names[i], _, _ = strings.Cut(t.Field(i).Tag.Get("parquet"), ",")
+		}
+		if err := c.writer.Write(names); err != nil {
+			return err
+		}
+	}
+
+	for i := range rows {
+		v := reflect.ValueOf(rows[i])
+		fields := make([]string, v.NumField())
+		for j := range fields {
+			fields[j] = csvValue(v.Field(j).Interface())
+		}
+		if err := c.writer.Write(fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter[R]) Close() error {
+	// Write the header even when there were no rows
+	if !c.header {
+		if err := c.Write(nil); err != nil {
+			return err
+		}
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+// Export row types flatten the models into scalar columns. JSON columns are
+// kept as encoded strings.
+
+type resultRow struct {
+	Timestamp            time.Time `parquet:"timestamp,timestamp(millisecond)" json:"timestamp"`
+	TickNumber           int64     `parquet:"tick_number" json:"tick_number"`
+	TotalGenerationMW    float64   `parquet:"total_generation_mw" json:"total_generation_mw"`
+	TotalConsumptionMW   float64   `parquet:"total_consumption_mw" json:"total_consumption_mw"`
+	GridFrequencyHz      float64   `parquet:"grid_frequency_hz" json:"grid_frequency_hz"`
+	GridVoltageKV        float64   `parquet:"grid_voltage_kv" json:"grid_voltage_kv"`
+	EfficiencyPercentage float64   `parquet:"efficiency_percentage" json:"efficiency_percentage"`
+	FaultCount           int64     `parquet:"fault_count" json:"fault_count"`
+	Metadata             string    `parquet:"metadata" json:"metadata"`
+}
+
+func newResultRow(r *database.SimulationResult) resultRow {
+	return resultRow{
+		Timestamp:            r.Timestamp,
+		TickNumber:           int64(r.TickNumber),
+		TotalGenerationMW:    r.TotalGenerationMW,
+		TotalConsumptionMW:   r.TotalConsumptionMW,
+		GridFrequencyHz:      r.GridFrequencyHz,
+		GridVoltageKV:        r.GridVoltageKV,
+		EfficiencyPercentage: r.EfficiencyPercentage,
+		FaultCount:           int64(r.FaultCount),
+		Metadata:             jsonString(r.Metadata),
+	}
+}
+
+type metricRow struct {
+	Timestamp     time.Time `parquet:"timestamp,timestamp(millisecond)" json:"timestamp"`
+	ComponentType string    `parquet:"component_type,dict" json:"component_type"`
+	ComponentID   int64     `parquet:"component_id" json:"component_id"`
+	MetricName    string    `parquet:"metric_name,dict" json:"metric_name"`
+	MetricValue   float64   `parquet:"metric_value" json:"metric_value"`
+	Unit          string    `parquet:"unit,dict" json:"unit"`
+	Metadata      string    `parquet:"metadata" json:"metadata"`
+}
+
+func newMetricRow(m *database.ComponentMetric) metricRow {
+	return metricRow{
+		Timestamp:     m.Timestamp,
+		ComponentType: m.ComponentType,
+		ComponentID:   int64(m.ComponentID),
+		MetricName:    m.MetricName,
+		MetricValue:   m.MetricValue,
+		Unit:          m.Unit,
+		Metadata:      jsonString(m.Metadata),
+	}
+}
+
+type faultRow struct {
+	Timestamp        time.Time  `parquet:"timestamp,timestamp(millisecond)" json:"timestamp"`
+	FaultType        string     `parquet:"fault_type,dict" json:"fault_type"`
+	ComponentID      int64      `parquet:"component_id" json:"component_id"`
+	ComponentType    string     `parquet:"component_type,dict" json:"component_type"`
+	Severity         string     `parquet:"severity,dict" json:"severity"`
+	Description      string     `parquet:"description" json:"description"`
+	ResolvedAt       *time.Time `parquet:"resolved_at,optional" json:"resolved_at"`
+	ImpactAssessment string     `parquet:"impact_assessment" json:"impact_assessment"`
+}
+
+func newFaultRow(f *database.FaultEvent) faultRow {
+	return faultRow{
+		Timestamp:        f.Timestamp,
+		FaultType:        f.FaultType,
+		ComponentID:      int64(f.ComponentID),
+		ComponentType:    f.ComponentType,
+		Severity:         f.Severity,
+		Description:      f.Description,
+		ResolvedAt:       f.ResolvedAt,
+		ImpactAssessment: jsonString(f.ImpactAssessment),
+	}
+}
+
+type alertRow struct {
+	TriggeredAt    time.Time  `parquet:"triggered_at,timestamp(millisecond)" json:"triggered_at"`
+	AlertType      string     `parquet:"alert_type,dict" json:"alert_type"`
+	Severity       string     `parquet:"severity,dict" json:"severity"`
+	Message        string     `parquet:"message" json:"message"`
+	AcknowledgedAt *time.Time `parquet:"acknowledged_at,optional" json:"acknowledged_at"`
+	ResolvedAt     *time.Time `parquet:"resolved_at,optional" json:"resolved_at"`
+	Metadata       string     `parquet:"metadata" json:"metadata"`
+}
+
+func newAlertRow(a *database.Alert) alertRow {
+	return alertRow{
+		TriggeredAt:    a.TriggeredAt,
+		AlertType:      a.AlertType,
+		Severity:       a.Severity,
+		Message:        a.Message,
+		AcknowledgedAt: a.AcknowledgedAt,
+		ResolvedAt:     a.ResolvedAt,
+		Metadata:       jsonString(a.Metadata),
+	}
+}
+
+// jsonString encodes a JSON column, returning an empty string when it is unset
+func jsonString(value map[string]any) string {
+	if len(value) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Storage is where backups and exports are written, either a local
+// directory or an S3 bucket prefix
+type Storage interface {
+	// Create opens name for writing; the object is complete once the writer is closed
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// OpenFile opens name for random access, such as reading Parquet footers
+	OpenFile(ctx context.Context, name string) (File, error)
+	// String describes the location for log and CLI output
+	String() string
+}
+
+// File is a stored object opened for random access. Reads from S3 are
+// ranged requests, so only the parts read are transferred.
+type File interface {
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+// S3Options holds the credentials for s3:// targets
+type S3Options struct {
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+}
+
+// OpenStorage returns the storage for target, which is either a directory
+// path or an s3://bucket/prefix URL
+func OpenStorage(target string, opts S3Options) (Storage, error) {
+	if !strings.HasPrefix(target, "s3://") {
+		return NewDirStorage(target), nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTarget, target)
+	}
+	return NewS3Storage(u.Host, strings.Trim(u.Path, "/"), opts)
+}
+
+// DirStorage stores files in a local directory
+type DirStorage struct {
+	dir string
+}
+
+// NewDirStorage creates storage rooted at dir, which is created on first write
+func NewDirStorage(dir string) *DirStorage {
+	return &DirStorage{dir: dir}
+}
+
+// Create creates or truncates a file in the directory
+func (s *DirStorage) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	file := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(file)
+}
+
+// Open opens a file in the directory
+func (s *DirStorage) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, filepath.FromSlash(name)))
+}
+
+// OpenFile opens a file in the directory for random access
+func (s *DirStorage) OpenFile(ctx context.Context, name string) (File, error) {
+	file, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &sizedFile{readCloserAt: file, size: info.Size()}, nil
+}
+
+func (s *DirStorage) String() string {
+	return s.dir
+}
+
+// S3Storage stores objects under a prefix of an S3 bucket
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Storage creates storage for objects under prefix in bucket
+func NewS3Storage(bucket, prefix string, opts S3Options) (*S3Storage, error) {
+	var creds *credentials.Credentials
+	if opts.AccessKeyID != "" {
+		creds = credentials.NewStaticV4(opts.AccessKeyID, opts.SecretAccessKey, "")
+	} else {
+		// Fall back to the standard AWS environment and instance credentials
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	return &S3Storage{client: client, bucket: bucket, prefix: prefix}, nil
+}
+
+// Create streams an object to S3; the upload finishes when the writer is closed
+func (s *S3Storage) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	reader, writer := io.Pipe()
+	w := &s3Writer{pipe: writer, done: make(chan error, 1)}
+
+	go func() {
+		// An unknown size makes the client upload in multipart chunks
+		_, err := s.client.PutObject(ctx, s.bucket, s.key(name), reader, -1, minio.PutObjectOptions{})
+		reader.CloseWithError(err)
+		w.done <- err
+	}()
+
+	return w, nil
+}
+
+// Open reads an object from S3
+func (s *S3Storage) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, s.key(name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy, so surface a missing object here rather than on first read
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, err
+	}
+	return object, nil
+}
+
+// OpenFile opens an object for ranged reads
+func (s *S3Storage) OpenFile(ctx context.Context, name string) (File, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, s.key(name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, err
+	}
+	return &sizedFile{readCloserAt: object, size: info.Size}, nil
+}
+
+func (s *S3Storage) String() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+func (s *S3Storage) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+// s3Writer feeds an in-flight PutObject and reports its result on Close
+type s3Writer struct {
+	pipe *io.PipeWriter
+	done chan error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	return w.pipe.Write(p)
+}
+
+func (w *s3Writer) Close() error {
+	w.pipe.Close()
+	return <-w.done
+}
+
+// readCloserAt is implemented by both local files and S3 objects
+type readCloserAt interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// sizedFile adds the size learned when opening to a readCloserAt
+type sizedFile struct {
+	readCloserAt
+	size int64
+}
+
+func (f *sizedFile) Size() int64 {
+	return f.size
+}
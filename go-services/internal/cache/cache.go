@@ -0,0 +1,153 @@
+// Package cache keeps hot reads in memory for a short time, loading each key
+// once however many callers ask for it at the same moment
+package cache
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"voltedge/go-services/internal/observability"
+)
+
+// Options configures a cache
+type Options struct {
+	// How long a loaded value is served; zero only coalesces concurrent loads
+	TTL time.Duration
+	// Spreads each entry's TTL by up to this fraction either way, so keys
+	// loaded together do not all expire together
+	Jitter float64
+	// Most entries kept; zero keeps any number
+	MaxEntries int
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// call is a load in progress that other callers of the same key wait for
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache is a cache-aside store of values by key. A missing or expired key is
+// loaded by the first caller; callers arriving while it loads share its
+// result instead of loading again. Errors are not cached.
+type Cache[V any] struct {
+	name string
+	opts Options
+
+	mu      sync.Mutex
+	entries map[string]entry[V]
+	calls   map[string]*call[V]
+}
+
+// New creates a cache. The name labels its hit and miss metrics.
+func New[V any](name string, opts Options) *Cache[V] {
+	if opts.Jitter < 0 {
+		opts.Jitter = 0
+	}
+	if opts.Jitter > 1 {
+		opts.Jitter = 1
+	}
+
+	return &Cache[V]{
+		name:    name,
+		opts:    opts,
+		entries: make(map[string]entry[V]),
+		calls:   make(map[string]*call[V]),
+	}
+}
+
+// Get returns the value of key, calling load when it is not cached. load
+// runs without the caller's cancellation, as other callers may be waiting
+// for it; each caller stops waiting when its own ctx is done.
+func (c *Cache[V]) Get(ctx context.Context, key string, load func(context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	if cached, ok := c.entries[key]; ok {
+		if time.Now().Before(cached.expiresAt) {
+			c.mu.Unlock()
+			observability.RecordCacheLookup(c.name, "hit")
+			return cached.value, nil
+		}
+		delete(c.entries, key)
+	}
+
+	if pending, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		observability.RecordCacheLookup(c.name, "shared")
+		return c.wait(ctx, pending)
+	}
+
+	pending := &call[V]{done: make(chan struct{})}
+	c.calls[key] = pending
+	c.mu.Unlock()
+	observability.RecordCacheLookup(c.name, "miss")
+
+	go func() {
+		pending.value, pending.err = load(context.WithoutCancel(ctx))
+
+		c.mu.Lock()
+		delete(c.calls, key)
+		if pending.err == nil && c.opts.TTL > 0 {
+			c.storeLocked(key, pending.value)
+		}
+		c.mu.Unlock()
+		close(pending.done)
+	}()
+
+	return c.wait(ctx, pending)
+}
+
+// Invalidate drops a key, so the next Get loads it again. A load already in
+// progress is still shared with the callers waiting for it.
+func (c *Cache[V]) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+func (c *Cache[V]) wait(ctx context.Context, pending *call[V]) (V, error) {
+	select {
+	case <-pending.done:
+		return pending.value, pending.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// storeLocked caches a value, making room when the cache is full
+// (must be called with lock held)
+func (c *Cache[V]) storeLocked(key string, value V) {
+	now := time.Now()
+	if c.opts.MaxEntries > 0 && len(c.entries) >= c.opts.MaxEntries {
+		for k, cached := range c.entries {
+			if !now.Before(cached.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		// Still full: drop the entry closest to expiring
+		if len(c.entries) >= c.opts.MaxEntries {
+			var oldest string
+			var oldestAt time.Time
+			for k, cached := range c.entries {
+				if oldest == "" || cached.expiresAt.Before(oldestAt) {
+					oldest, oldestAt = k, cached.expiresAt
+				}
+			}
+			delete(c.entries, oldest)
+		}
+	}
+
+	ttl := c.opts.TTL
+	if c.opts.Jitter > 0 {
+		ttl += time.Duration((rand.Float64()*2 - 1) * c.opts.Jitter * float64(ttl))
+	}
+	c.entries[key] = entry[V]{value: value, expiresAt: now.Add(ttl)}
+}
@@ -0,0 +1,320 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"voltedge/go-services/internal/observability"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// Faults an experiment can inject
+const (
+	// Calls to the simulation engine fail before they are sent
+	FaultGRPCDrop = "grpc_drop"
+	// Database writes wait before they run
+	FaultDBDelay = "db_delay"
+	// Simulation jobs fail part way through, as if their worker crashed
+	FaultWorkerCrash = "worker_crash"
+)
+
+// maxDelay bounds how long a delayed database write waits
+const maxDelay = time.Minute
+
+// Options configures the controller
+type Options struct {
+	// Experiments cannot be started unless enabled
+	Enabled bool
+	// Experiments started without a duration end after this long
+	DefaultDuration time.Duration
+	// Longest an experiment may run
+	MaxDuration time.Duration
+}
+
+// Experiment is a fault being injected
+type Experiment struct {
+	Fault string `json:"fault"`
+	// Chance that each operation the fault applies to is hit, in (0, 1]
+	Probability float64 `json:"probability"`
+	// How long delayed writes wait; db_delay only
+	DelayMS int64 `json:"delay_ms,omitempty"`
+	// Engine calls to drop, by method name; empty drops every call.
+	// grpc_drop only.
+	Methods   []string  `json:"methods,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Who started the experiment, for the record
+	StartedBy string `json:"started_by,omitempty"`
+	// Operations hit so far
+	Injected int64 `json:"injected"`
+}
+
+// Controller injects failures into the gateway while experiments run. It
+// is inert until an admin starts an experiment, and experiments always end
+// on their own when their duration runs out.
+type Controller struct {
+	opts Options
+
+	mu          sync.Mutex
+	experiments map[string]*Experiment
+	rng         *rand.Rand
+}
+
+// New creates a chaos controller
+func New(opts Options) *Controller {
+	if opts.DefaultDuration <= 0 {
+		opts.DefaultDuration = 5 * time.Minute
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = time.Hour
+	}
+	if opts.DefaultDuration > opts.MaxDuration {
+		opts.DefaultDuration = opts.MaxDuration
+	}
+
+	seed := uint64(time.Now().UnixNano())
+	return &Controller{
+		opts:        opts,
+		experiments: make(map[string]*Experiment),
+		rng:         rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+	}
+}
+
+// Enabled reports whether experiments may be started
+func (c *Controller) Enabled() bool {
+	return c.opts.Enabled
+}
+
+// Start begins injecting a fault for the given duration, or the default
+// duration when zero. An experiment already running for the fault is
+// replaced.
+func (c *Controller) Start(experiment Experiment, duration time.Duration) (Experiment, error) {
+	if !c.opts.Enabled {
+		return Experiment{}, ErrDisabled
+	}
+	if err := validate(experiment); err != nil {
+		return Experiment{}, err
+	}
+	if duration == 0 {
+		duration = c.opts.DefaultDuration
+	}
+	if duration < 0 || duration > c.opts.MaxDuration {
+		return Experiment{}, fmt.Errorf("%w: duration must be positive and at most %s", ErrInvalidExperiment, c.opts.MaxDuration)
+	}
+
+	now := time.Now().UTC()
+	experiment.StartedAt = now
+	experiment.ExpiresAt = now.Add(duration)
+	experiment.Injected = 0
+	experiment.Methods = slices.Clone(experiment.Methods)
+
+	c.mu.Lock()
+	c.experiments[experiment.Fault] = &experiment
+	c.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"fault":       experiment.Fault,
+		"probability": experiment.Probability,
+		"delay_ms":    experiment.DelayMS,
+		"methods":     experiment.Methods,
+		"expires_at":  experiment.ExpiresAt,
+		"started_by":  experiment.StartedBy,
+	}).Warn("Chaos experiment started")
+
+	return experiment, nil
+}
+
+// Stop ends the experiment for a fault. It reports whether one was running.
+func (c *Controller) Stop(fault string) bool {
+	c.mu.Lock()
+	experiment, ok := c.experiments[fault]
+	if ok && !c.expired(experiment, time.Now()) {
+		delete(c.experiments, fault)
+	} else {
+		ok = false
+	}
+	c.mu.Unlock()
+
+	if ok {
+		logrus.WithFields(logrus.Fields{
+			"fault":    fault,
+			"injected": experiment.Injected,
+		}).Warn("Chaos experiment stopped")
+	}
+	return ok
+}
+
+// StopAll ends every experiment and returns how many were running
+func (c *Controller) StopAll() int {
+	c.mu.Lock()
+	now := time.Now()
+	stopped := 0
+	for fault, experiment := range c.experiments {
+		if !c.expired(experiment, now) {
+			stopped++
+		}
+		delete(c.experiments, fault)
+	}
+	c.mu.Unlock()
+
+	if stopped > 0 {
+		logrus.WithField("stopped", stopped).Warn("All chaos experiments stopped")
+	}
+	return stopped
+}
+
+// Experiments returns the running experiments ordered by fault
+func (c *Controller) Experiments() []Experiment {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	experiments := make([]Experiment, 0, len(c.experiments))
+	for _, experiment := range c.experiments {
+		if c.expired(experiment, now) {
+			continue
+		}
+		copied := *experiment
+		copied.Methods = slices.Clone(experiment.Methods)
+		experiments = append(experiments, copied)
+	}
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].Fault < experiments[j].Fault
+	})
+	return experiments
+}
+
+// GRPCFault fails an engine call while a grpc_drop experiment hits it.
+// It is meant for grpc.Client.SetFaultInjector.
+func (c *Controller) GRPCFault(method string) error {
+	_, hit := c.hit(FaultGRPCDrop, func(experiment *Experiment) bool {
+		return len(experiment.Methods) == 0 || slices.Contains(experiment.Methods, method)
+	})
+	if !hit {
+		return nil
+	}
+
+	logrus.WithField("method", method).Debug("Chaos dropped engine call")
+	return fmt.Errorf("%w: engine call %s dropped", ErrInjected, method)
+}
+
+// WorkerFault fails a simulation job while a worker_crash experiment hits
+// it. It is meant for orchestration.Orchestrator.SetWorkerFaultInjector.
+func (c *Controller) WorkerFault(simulationID string) error {
+	if _, hit := c.hit(FaultWorkerCrash, nil); !hit {
+		return nil
+	}
+
+	logrus.WithField("simulation_id", simulationID).Debug("Chaos crashed simulation worker")
+	// A crash is transient, so the orchestrator retries the job
+	return fmt.Errorf("%w: worker crashed (%w)", ErrInjected, orchestration.ErrTransient)
+}
+
+// InstrumentDB registers callbacks that delay creates, updates and deletes
+// while a db_delay experiment hits them
+func (c *Controller) InstrumentDB(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("chaos:delay_create", c.delayWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("chaos:delay_update", c.delayWrite); err != nil {
+		return err
+	}
+	return callbacks.Delete().Before("gorm:delete").Register("chaos:delay_delete", c.delayWrite)
+}
+
+// delayWrite holds a write back for the experiment's delay, or until the
+// statement's context is done
+func (c *Controller) delayWrite(db *gorm.DB) {
+	experiment, hit := c.hit(FaultDBDelay, nil)
+	if !hit {
+		return
+	}
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"table":    db.Statement.Table,
+		"delay_ms": experiment.DelayMS,
+	}).Debug("Chaos delayed database write")
+
+	timer := time.NewTimer(time.Duration(experiment.DelayMS) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// hit reports whether an operation is hit by the experiment running for a
+// fault, counting it if so. applies narrows the operations the experiment
+// covers; nil covers all of them.
+func (c *Controller) hit(fault string, applies func(*Experiment) bool) (Experiment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	experiment, ok := c.experiments[fault]
+	if !ok {
+		return Experiment{}, false
+	}
+	if c.expired(experiment, time.Now()) {
+		delete(c.experiments, fault)
+		logrus.WithFields(logrus.Fields{
+			"fault":    fault,
+			"injected": experiment.Injected,
+		}).Warn("Chaos experiment expired")
+		return Experiment{}, false
+	}
+	if applies != nil && !applies(experiment) {
+		return Experiment{}, false
+	}
+	if c.rng.Float64() >= experiment.Probability {
+		return Experiment{}, false
+	}
+
+	experiment.Injected++
+	observability.RecordChaosFault(fault)
+	return *experiment, true
+}
+
+// expired reports whether an experiment has run out (must be called with lock held)
+func (c *Controller) expired(experiment *Experiment, now time.Time) bool {
+	return !now.Before(experiment.ExpiresAt)
+}
+
+// validate checks an experiment's fault and parameters
+func validate(experiment Experiment) error {
+	switch experiment.Fault {
+	case FaultGRPCDrop, FaultWorkerCrash:
+	case FaultDBDelay:
+		delay := time.Duration(experiment.DelayMS) * time.Millisecond
+		if delay <= 0 || delay > maxDelay {
+			return fmt.Errorf("%w: delay_ms must be between 1 and %d", ErrInvalidExperiment, maxDelay.Milliseconds())
+		}
+	default:
+		return fmt.Errorf("%w: unknown fault %q", ErrInvalidExperiment, experiment.Fault)
+	}
+
+	if experiment.Probability <= 0 || experiment.Probability > 1 {
+		return fmt.Errorf("%w: probability must be greater than 0 and at most 1", ErrInvalidExperiment)
+	}
+	return nil
+}
+
+// Errors
+var (
+	ErrDisabled          = fmt.Errorf("chaos experiments are disabled")
+	ErrInvalidExperiment = fmt.Errorf("invalid chaos experiment")
+	ErrInjected          = fmt.Errorf("chaos fault injected")
+)
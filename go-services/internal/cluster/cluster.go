@@ -0,0 +1,363 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/lock"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// leaderLockName is held by the replica that reassigns orphaned simulations
+const leaderLockName = "voltedge:leader"
+
+// Store is the database side of membership and ownership
+type Store interface {
+	Heartbeat(node *database.ClusterNode) error
+	RemoveNode(id string) error
+	LiveNodes(ttl time.Duration) ([]database.ClusterNode, error)
+	ClaimSimulation(owner *database.SimulationOwner) error
+	SaveSimulationSpec(simulationID, nodeID string, spec json.RawMessage) error
+	GetSimulationOwner(simulationID string) (*database.SimulationOwner, error)
+	ReleaseSimulation(simulationID, nodeID string) error
+	OrphanedSimulations(liveNodeIDs []string) ([]database.SimulationOwner, error)
+	TransferSimulation(simulationID, fromNode, toNode string) (bool, error)
+	PendingAdoptions(nodeID string) ([]database.SimulationOwner, error)
+	MarkAdopted(simulationID, nodeID string, epoch int64) error
+}
+
+// Runtime is the local orchestrator that adopted simulations are loaded into
+type Runtime interface {
+	RestoreSimulation(spec orchestration.SimulationSpec) error
+}
+
+// Options configures cluster membership
+type Options struct {
+	// Unique per process; generated when empty
+	NodeID string
+	// Base URL other replicas use to reach this node's internal API
+	Address string
+	// Time between heartbeats
+	HeartbeatInterval time.Duration
+	// Nodes without a heartbeat for this long are considered dead
+	NodeTTL time.Duration
+	// Shared secret sent with forwarded requests
+	InternalToken string
+	// Elects the leader; without one every node acts as leader
+	Locker lock.Locker
+}
+
+// Status describes the cluster as seen by this node
+type Status struct {
+	NodeID string                 `json:"node_id"`
+	Leader bool                   `json:"leader"`
+	Nodes  []database.ClusterNode `json:"nodes"`
+}
+
+// Manager keeps this replica registered with the cluster and tracks which
+// replica owns each simulation. The elected leader hands the simulations of
+// dead replicas to live ones, which restore them from their stored spec.
+type Manager struct {
+	store   Store
+	runtime Runtime
+	opts    Options
+	node    database.ClusterNode
+	proxy   *http.Transport
+
+	mu     sync.RWMutex
+	leader lock.Lease
+	live   map[string]database.ClusterNode
+	// Round-robin position for reassigning orphans
+	next int
+}
+
+// New creates a new cluster manager
+func New(store Store, runtime Runtime, opts Options) *Manager {
+	if opts.NodeID == "" {
+		host, _ := os.Hostname()
+		opts.NodeID = fmt.Sprintf("%s-%s", host, uuid.New().String()[:8])
+	}
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = 5 * time.Second
+	}
+	if opts.NodeTTL <= opts.HeartbeatInterval {
+		opts.NodeTTL = 4 * opts.HeartbeatInterval
+	}
+
+	return &Manager{
+		store:   store,
+		runtime: runtime,
+		opts:    opts,
+		node: database.ClusterNode{
+			ID:        opts.NodeID,
+			Address:   opts.Address,
+			StartedAt: time.Now().UTC(),
+		},
+		proxy: http.DefaultTransport.(*http.Transport).Clone(),
+		live:  make(map[string]database.ClusterNode),
+	}
+}
+
+// NodeID returns this replica's node ID
+func (m *Manager) NodeID() string {
+	return m.opts.NodeID
+}
+
+// InternalToken returns the secret replicas authenticate forwarded requests with
+func (m *Manager) InternalToken() string {
+	return m.opts.InternalToken
+}
+
+// Start registers the node and keeps it alive until ctx is done
+func (m *Manager) Start(ctx context.Context) {
+	m.tick(ctx)
+
+	go func() {
+		ticker := time.NewTicker(m.opts.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.tick(ctx)
+			}
+		}
+	}()
+
+	logrus.WithFields(logrus.Fields{
+		"node_id": m.opts.NodeID,
+		"address": m.opts.Address,
+	}).Info("Joined cluster")
+}
+
+// Stop gives up leadership and deregisters the node so its simulations are
+// reassigned without waiting for the TTL
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	if m.leader != nil {
+		m.leader.Release()
+		m.leader = nil
+	}
+	m.mu.Unlock()
+
+	if err := m.store.RemoveNode(m.opts.NodeID); err == nil {
+		logrus.WithField("node_id", m.opts.NodeID).Info("Left cluster")
+	}
+}
+
+// IsLeader reports whether this node currently holds leadership
+func (m *Manager) IsLeader() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.isLeaderLocked()
+}
+
+// isLeaderLocked reports leadership (must be called with lock held)
+func (m *Manager) isLeaderLocked() bool {
+	if m.opts.Locker == nil {
+		return true
+	}
+	if m.leader == nil {
+		return false
+	}
+
+	select {
+	case <-m.leader.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+// Status returns this node's view of the cluster
+func (m *Manager) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := Status{
+		NodeID: m.opts.NodeID,
+		Leader: m.isLeaderLocked(),
+		Nodes:  make([]database.ClusterNode, 0, len(m.live)),
+	}
+	for _, node := range m.live {
+		status.Nodes = append(status.Nodes, node)
+	}
+	return status
+}
+
+// Claim records this node as the owner of a new simulation
+func (m *Manager) Claim(simulation *orchestration.Simulation) error {
+	spec, err := json.Marshal(simulation.Spec())
+	if err != nil {
+		return fmt.Errorf("failed to encode simulation spec: %w", err)
+	}
+
+	return m.store.ClaimSimulation(&database.SimulationOwner{
+		SimulationID: simulation.ID,
+		NodeID:       m.opts.NodeID,
+		Epoch:        1,
+		Spec:         spec,
+		Adopted:      true,
+	})
+}
+
+// SaveSpec stores a simulation's current definition for a future takeover
+func (m *Manager) SaveSpec(simulation *orchestration.Simulation) error {
+	spec, err := json.Marshal(simulation.Spec())
+	if err != nil {
+		return fmt.Errorf("failed to encode simulation spec: %w", err)
+	}
+
+	return m.store.SaveSimulationSpec(simulation.ID, m.opts.NodeID, spec)
+}
+
+// Release drops this node's ownership of a deleted simulation
+func (m *Manager) Release(simulationID string) error {
+	return m.store.ReleaseSimulation(simulationID, m.opts.NodeID)
+}
+
+// Owner returns the node that controls a simulation, or nil when it is this
+// node or the simulation has no recorded owner
+func (m *Manager) Owner(simulationID string) (*database.ClusterNode, error) {
+	owner, err := m.store.GetSimulationOwner(simulationID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil || owner.NodeID == m.opts.NodeID {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	node, live := m.live[owner.NodeID]
+	m.mu.RUnlock()
+
+	// The owner died and the leader has not reassigned its simulations yet
+	if !live {
+		return nil, ErrOwnerUnavailable
+	}
+	return &node, nil
+}
+
+// tick sends a heartbeat, adopts simulations assigned to this node and, on
+// the leader, reassigns simulations whose owner died
+func (m *Manager) tick(ctx context.Context) {
+	if err := m.store.Heartbeat(&m.node); err != nil {
+		return
+	}
+
+	nodes, err := m.store.LiveNodes(m.opts.NodeTTL)
+	if err != nil {
+		return
+	}
+
+	live := make(map[string]database.ClusterNode, len(nodes))
+	for _, node := range nodes {
+		live[node.ID] = node
+	}
+	m.mu.Lock()
+	m.live = live
+	m.mu.Unlock()
+
+	m.adopt()
+
+	if m.elect(ctx) {
+		m.reassign(nodes)
+	}
+}
+
+// elect takes leadership if it is free, reporting whether this node leads
+func (m *Manager) elect(ctx context.Context) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.isLeaderLocked() {
+		return true
+	}
+
+	lease, ok, err := m.opts.Locker.TryAcquire(ctx, leaderLockName)
+	if err != nil || !ok {
+		m.leader = nil
+		return false
+	}
+
+	m.leader = lease
+	logrus.WithField("node_id", m.opts.NodeID).Info("Elected cluster leader")
+	return true
+}
+
+// reassign hands simulations owned by dead nodes to live ones in turn
+func (m *Manager) reassign(nodes []database.ClusterNode) {
+	if len(nodes) == 0 {
+		return
+	}
+
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+
+	orphans, err := m.store.OrphanedSimulations(ids)
+	if err != nil {
+		return
+	}
+
+	for _, orphan := range orphans {
+		m.next = (m.next + 1) % len(ids)
+		to := ids[m.next]
+
+		moved, err := m.store.TransferSimulation(orphan.SimulationID, orphan.NodeID, to)
+		if err != nil || !moved {
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"simulation_id": orphan.SimulationID,
+			"from":          orphan.NodeID,
+			"to":            to,
+		}).Warn("Reassigned simulation from dead replica")
+	}
+}
+
+// adopt restores simulations transferred to this node into the local orchestrator
+func (m *Manager) adopt() {
+	pending, err := m.store.PendingAdoptions(m.opts.NodeID)
+	if err != nil {
+		return
+	}
+
+	for _, owner := range pending {
+		var spec orchestration.SimulationSpec
+		if err := json.Unmarshal(owner.Spec, &spec); err != nil {
+			logrus.WithError(err).WithField("simulation_id", owner.SimulationID).Error("Failed to decode simulation spec")
+			continue
+		}
+		spec.ID = owner.SimulationID
+
+		if err := m.runtime.RestoreSimulation(spec); err != nil {
+			logrus.WithError(err).WithField("simulation_id", owner.SimulationID).Error("Failed to adopt simulation")
+			continue
+		}
+		if err := m.store.MarkAdopted(owner.SimulationID, m.opts.NodeID, owner.Epoch); err != nil {
+			continue
+		}
+
+		logrus.WithField("simulation_id", owner.SimulationID).Info("Adopted simulation")
+	}
+}
+
+// Errors
+var (
+	ErrOwnerUnavailable = fmt.Errorf("simulation owner is unavailable, takeover pending")
+)
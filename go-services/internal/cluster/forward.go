@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Headers set on requests forwarded between replicas
+const (
+	InternalTokenHeader = "X-VoltEdge-Internal-Token"
+	ForwardedByHeader   = "X-VoltEdge-Forwarded-By"
+)
+
+// Forward proxies a request to path on the owning node's internal API and
+// relays its response
+func (m *Manager) Forward(w http.ResponseWriter, r *http.Request, node *database.ClusterNode, path string) {
+	target, err := url.Parse(node.Address)
+	if err != nil {
+		writeProxyError(w, http.StatusBadGateway, "invalid owner address")
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.URL.Path = target.Path + path
+			pr.Out.URL.RawPath = ""
+			pr.Out.Header.Set(InternalTokenHeader, m.opts.InternalToken)
+			pr.Out.Header.Set(ForwardedByHeader, m.opts.NodeID)
+			pr.SetXForwarded()
+		},
+		Transport: m.proxy,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logrus.WithError(err).WithField("owner", node.ID).Error("Failed to forward request to owner")
+			writeProxyError(w, http.StatusBadGateway, "simulation owner did not respond")
+		},
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"owner": node.ID,
+		"path":  path,
+	}).Debug("Forwarding request to owner")
+	proxy.ServeHTTP(w, r)
+}
+
+// writeProxyError writes an error in the API's error response shape
+func writeProxyError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   http.StatusText(status),
+		"message": message,
+		"code":    "FORWARD_FAILED",
+	})
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -18,21 +19,218 @@ type Config struct {
 	Cache         CacheConfig         `mapstructure:"cache"`
 	Log           LogConfig           `mapstructure:"log"`
 	Security      SecurityConfig      `mapstructure:"security"`
+	Prediction    PredictionConfig    `mapstructure:"prediction"`
+	Playback      PlaybackConfig      `mapstructure:"playback"`
+	Outbox        OutboxConfig        `mapstructure:"outbox"`
+	Lock          LockConfig          `mapstructure:"lock"`
+	Cluster       ClusterConfig       `mapstructure:"cluster"`
+	ObjectStorage ObjectStorageConfig `mapstructure:"object_storage"`
+	Archive       ArchiveConfig       `mapstructure:"archive"`
+	Alerting      AlertingConfig      `mapstructure:"alerting"`
+	Snapshots     SnapshotConfig      `mapstructure:"snapshots"`
+	Usage         UsageConfig         `mapstructure:"usage"`
+	Chaos         ChaosConfig         `mapstructure:"chaos"`
+	Recording     RecordingConfig     `mapstructure:"recording"`
+	Reports       ReportsConfig       `mapstructure:"reports"`
+	SelfTest      SelfTestConfig      `mapstructure:"self_test"`
 }
 
 // APIConfig holds HTTP API server configuration
 type APIConfig struct {
-	Port             string        `mapstructure:"port"`
-	Host             string        `mapstructure:"host"`
-	ReadTimeout      time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout     time.Duration `mapstructure:"write_timeout"`
-	IdleTimeout      time.Duration `mapstructure:"idle_timeout"`
-	MaxHeaderBytes   int           `mapstructure:"max_header_bytes"`
-	CORSOrigins      []string      `mapstructure:"cors_origins"`
-	RateLimitRPS     int           `mapstructure:"rate_limit_rps"`
-	RateLimitBurst   int           `mapstructure:"rate_limit_burst"`
-	WebSocketPath    string        `mapstructure:"websocket_path"`
-	WebSocketTimeout time.Duration `mapstructure:"websocket_timeout"`
+	Port             string          `mapstructure:"port"`
+	Host             string          `mapstructure:"host"`
+	ReadTimeout      time.Duration   `mapstructure:"read_timeout"`
+	WriteTimeout     time.Duration   `mapstructure:"write_timeout"`
+	IdleTimeout      time.Duration   `mapstructure:"idle_timeout"`
+	MaxHeaderBytes   int             `mapstructure:"max_header_bytes"`
+	CORSOrigins      []string        `mapstructure:"cors_origins"`
+	RateLimitRPS     int             `mapstructure:"rate_limit_rps"`
+	RateLimitBurst   int             `mapstructure:"rate_limit_burst"`
+	WebSocketPath    string          `mapstructure:"websocket_path"`
+	WebSocketTimeout time.Duration   `mapstructure:"websocket_timeout"`
+	Metadata         MetadataLimits  `mapstructure:"metadata"`
+	Limits           RequestLimits   `mapstructure:"limits"`
+	Compression      Compression     `mapstructure:"compression"`
+	Ingest           IngestConfig    `mapstructure:"ingest"`
+	ReadCache        ReadCache       `mapstructure:"read_cache"`
+	ShareLinks       ShareLinks      `mapstructure:"share_links"`
+	Internal         InternalAPI     `mapstructure:"internal"`
+	Multiplex        Multiplex       `mapstructure:"multiplex"`
+	CORS             CORSConfig      `mapstructure:"cors"`
+	Sessions         Sessions        `mapstructure:"sessions"`
+	SecurityHeaders  SecurityHeaders `mapstructure:"security_headers"`
+	Login            LoginSecurity   `mapstructure:"login"`
+}
+
+// LoginSecurity controls account lockout and the login history
+type LoginSecurity struct {
+	// Failed logins within lockout_duration that lock an account until the
+	// earliest of them is that old; zero disables lockout
+	MaxFailedAttempts int           `mapstructure:"max_failed_attempts"`
+	LockoutDuration   time.Duration `mapstructure:"lockout_duration"`
+	// Login attempts older than this are deleted
+	HistoryRetention time.Duration `mapstructure:"history_retention"`
+	// How long a token's session is known not to be revoked before it is
+	// checked again
+	RevocationCheckTTL time.Duration `mapstructure:"revocation_check_ttl"`
+}
+
+// SecurityHeaders controls the security headers sent with every response.
+// An empty header value leaves that header out.
+type SecurityHeaders struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Strict-Transport-Security max-age, sent on HTTPS requests only,
+	// including ones a proxy terminated; 0 leaves it out
+	HSTSMaxAge            time.Duration `mapstructure:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `mapstructure:"hsts_include_subdomains"`
+	// Requires hsts_include_subdomains and an hsts_max_age of a year or more
+	HSTSPreload bool `mapstructure:"hsts_preload"`
+	// X-Content-Type-Options: nosniff
+	NoSniff bool `mapstructure:"no_sniff"`
+	// X-Frame-Options: DENY or SAMEORIGIN
+	FrameOptions   string `mapstructure:"frame_options"`
+	ReferrerPolicy string `mapstructure:"referrer_policy"`
+	// Content-Security-Policy of API responses
+	ContentSecurityPolicy string `mapstructure:"content_security_policy"`
+	// Content-Security-Policy of the /docs UI, which loads scripts and
+	// styles the API policy forbids
+	DocsContentSecurityPolicy string `mapstructure:"docs_content_security_policy"`
+}
+
+// Sessions controls cookie sessions for the web UI. Bearer tokens work
+// either way; requests authenticated by the session cookie must send the
+// session's CSRF token to change anything.
+type Sessions struct {
+	// Login also sets an HttpOnly cookie holding the token
+	Enabled    bool   `mapstructure:"enabled"`
+	CookieName string `mapstructure:"cookie_name"`
+	// Cookie the web UI reads the CSRF token from and echoes in the
+	// X-CSRF-Token header
+	CSRFCookieName string `mapstructure:"csrf_cookie_name"`
+	// Empty scopes the cookies to the API's host
+	Domain string `mapstructure:"domain"`
+	// Send the cookies over HTTPS only
+	Secure bool `mapstructure:"secure"`
+	// lax, strict or none; none requires secure
+	SameSite string `mapstructure:"same_site"`
+}
+
+// CORSConfig holds the cross-origin policies of the API
+type CORSConfig struct {
+	// Policy of the routes no route policy covers; its origins are
+	// api.cors_origins when it lists none
+	Default CORSPolicy `mapstructure:"default"`
+	// Policies of route groups; the one with the longest matching path
+	// prefix applies
+	Routes []CORSRoutePolicy `mapstructure:"routes"`
+}
+
+// CORSPolicy is a cross-origin policy
+type CORSPolicy struct {
+	// Exact origins such as https://app.example.com, https://*.example.com
+	// for any subdomain of example.com, or * for any origin
+	AllowOrigins  []string `mapstructure:"allow_origins"`
+	AllowMethods  []string `mapstructure:"allow_methods"`
+	AllowHeaders  []string `mapstructure:"allow_headers"`
+	ExposeHeaders []string `mapstructure:"expose_headers"`
+	// Let browsers send cookies and other credentials; browsers refuse
+	// credentialed responses to any origin, so * cannot be used with it
+	AllowCredentials bool `mapstructure:"allow_credentials"`
+	// How long browsers may cache a preflight response; zero leaves it to
+	// the browser
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// CORSRoutePolicy applies a policy to the routes under a path prefix, e.g.
+// /api/v1/shared. Methods and headers left empty are the default policy's.
+type CORSRoutePolicy struct {
+	PathPrefix string `mapstructure:"path_prefix"`
+	CORSPolicy `mapstructure:",squash"`
+}
+
+// IngestConfig controls the service endpoint engines push result batches to
+type IngestConfig struct {
+	// Shared secret sent by engines; ingest is disabled when empty
+	Token string `mapstructure:"token"`
+	// Most results, metrics and faults accepted in one batch
+	MaxBatchSize int `mapstructure:"max_batch_size"`
+}
+
+// ReadCache controls the in-memory cache of endpoints dashboards poll: grid
+// state, latest results and statistics
+type ReadCache struct {
+	// How long a read is served from memory; zero only merges identical
+	// concurrent reads
+	TTL time.Duration `mapstructure:"ttl"`
+	// Fraction by which each entry's TTL is randomly spread
+	Jitter     float64 `mapstructure:"jitter"`
+	MaxEntries int     `mapstructure:"max_entries"`
+}
+
+// ShareLinks controls the signed links that give read-only access to one
+// simulation without logging in
+type ShareLinks struct {
+	// Lifetime of links created without one
+	DefaultTTL time.Duration `mapstructure:"default_ttl"`
+	MaxTTL     time.Duration `mapstructure:"max_ttl"`
+}
+
+// InternalAPI controls the listener serving /internal/v1 to the engine
+// sidecar, other backend services and peer replicas, apart from the public API
+type InternalAPI struct {
+	// Port of the internal listener; when empty the internal routes are
+	// served on the public port
+	Port string `mapstructure:"port"`
+	// Secret signing service tokens; service tokens are refused when empty
+	ServiceSecret string `mapstructure:"service_secret"`
+	// Certificate and key to serve TLS with
+	TLSCertFile string `mapstructure:"tls_cert_file"`
+	TLSKeyFile  string `mapstructure:"tls_key_file"`
+	// CA verifying client certificates. A verified certificate authenticates
+	// the service named by its common name.
+	ClientCAFile string `mapstructure:"client_ca_file"`
+}
+
+// Multiplex controls sharing api.port between the REST API and metrics
+type Multiplex struct {
+	// Serve metrics on api.port at observability.metrics_path rather than on
+	// observability.metrics_port
+	Metrics bool `mapstructure:"metrics"`
+}
+
+// Compression controls gzip/brotli compression of API responses
+type Compression struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Smaller responses are sent uncompressed
+	MinSizeBytes int `mapstructure:"min_size_bytes"`
+}
+
+// RequestLimits bounds request duration and body size per class of route
+type RequestLimits struct {
+	DefaultTimeout time.Duration `mapstructure:"default_timeout"`
+	// Start/stop and other control commands
+	ControlTimeout time.Duration `mapstructure:"control_timeout"`
+	// Exports and model uploads
+	ExportTimeout   time.Duration `mapstructure:"export_timeout"`
+	MaxBodyBytes    int64         `mapstructure:"max_body_bytes"`
+	MaxControlBytes int64         `mapstructure:"max_control_bytes"`
+	// Grid configs in simulation, batch and experiment requests
+	MaxConfigBytes int64 `mapstructure:"max_config_bytes"`
+	MaxUploadBytes int64 `mapstructure:"max_upload_bytes"`
+	// Multipart data held in memory before spilling to temporary files
+	MultipartMemoryBytes int64 `mapstructure:"multipart_memory_bytes"`
+}
+
+// MetadataLimits bounds the size and shape of user-supplied metadata
+type MetadataLimits struct {
+	MaxBytes int `mapstructure:"max_bytes"`
+	MaxKeys  int `mapstructure:"max_keys"`
+	MaxDepth int `mapstructure:"max_depth"`
+	// Longest key at any level
+	MaxKeyLength int `mapstructure:"max_key_length"`
+	// Top-level keys starting with one of these are reserved for metadata
+	// the gateway writes itself
+	ReservedPrefixes []string `mapstructure:"reserved_prefixes"`
 }
 
 // ZigConfig holds Zig simulation engine configuration
@@ -56,6 +254,45 @@ type ObservabilityConfig struct {
 	HealthCheckPath  string  `mapstructure:"health_check_path"`
 	ProfilingEnabled bool    `mapstructure:"profiling_enabled"`
 	ProfilingPort    string  `mapstructure:"profiling_port"`
+	// Pushes per-simulation telemetry to a remote-write endpoint
+	RemoteWrite RemoteWriteConfig `mapstructure:"remote_write"`
+	// Reports handler errors, worker panics and engine call failures
+	Sentry SentryConfig `mapstructure:"sentry"`
+}
+
+// RemoteWriteConfig holds the Prometheus remote-write exporter configuration
+type RemoteWriteConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// e.g. http://prometheus:9090/api/v1/write or http://victoria:8428/api/v1/write
+	URL         string `mapstructure:"url"`
+	BearerToken string `mapstructure:"bearer_token"`
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	// Added to every series, e.g. to tell environments apart
+	ExternalLabels map[string]string `mapstructure:"external_labels"`
+	// Series per request
+	BatchSize     int           `mapstructure:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// Series buffered while the endpoint is slow or down; more are dropped
+	QueueSize    int           `mapstructure:"queue_size"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	MaxRetries   int           `mapstructure:"max_retries"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+}
+
+// SentryConfig holds the Sentry error reporting configuration. Errors are
+// not reported when DSN is empty.
+type SentryConfig struct {
+	// e.g. https://<key>@o0.ingest.sentry.io/<project>
+	DSN string `mapstructure:"dsn"`
+	// e.g. production or staging
+	Environment string `mapstructure:"environment"`
+	// Fraction of errors reported, between 0 and 1
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// Errors waiting to be sent; more are dropped
+	QueueSize int           `mapstructure:"queue_size"`
+	Timeout   time.Duration `mapstructure:"timeout"`
 }
 
 // OrchestrationConfig holds job orchestration configuration
@@ -63,10 +300,32 @@ type OrchestrationConfig struct {
 	MaxConcurrentSimulations int           `mapstructure:"max_concurrent_simulations"`
 	SimulationTimeout        time.Duration `mapstructure:"simulation_timeout"`
 	CleanupInterval          time.Duration `mapstructure:"cleanup_interval"`
-	JobQueueSize             int           `mapstructure:"job_queue_size"`
-	WorkerPoolSize           int           `mapstructure:"worker_pool_size"`
-	EnableAutoScaling        bool          `mapstructure:"enable_auto_scaling"`
-	ScalingThreshold         float64       `mapstructure:"scaling_threshold"`
+	// Simulations one organization or one user may have queued, running or
+	// paused at once; zero is unlimited. Organizations can override them.
+	MaxConcurrentPerOrganization int     `mapstructure:"max_concurrent_per_organization"`
+	MaxConcurrentPerUser         int     `mapstructure:"max_concurrent_per_user"`
+	JobQueueSize                 int     `mapstructure:"job_queue_size"`
+	WorkerPoolSize               int     `mapstructure:"worker_pool_size"`
+	EnableAutoScaling            bool    `mapstructure:"enable_auto_scaling"`
+	ScalingThreshold             float64 `mapstructure:"scaling_threshold"`
+	// Minimum time between persisted runtime metrics samples of a simulation
+	MetricsPersistInterval time.Duration `mapstructure:"metrics_persist_interval"`
+	// Time between passes correcting database rows that disagree with the
+	// orchestrator; zero disables the loop
+	ReconcileInterval time.Duration `mapstructure:"reconcile_interval"`
+	// Recently started rows are not reconciled until this has passed
+	ReconcileGracePeriod time.Duration `mapstructure:"reconcile_grace_period"`
+	// Jobs failing with a transient error are retried this many times,
+	// backing off exponentially from JobRetryBackoff up to JobMaxBackoff
+	JobMaxRetries   int           `mapstructure:"job_max_retries"`
+	JobRetryBackoff time.Duration `mapstructure:"job_retry_backoff"`
+	JobMaxBackoff   time.Duration `mapstructure:"job_max_backoff"`
+	// How many failed jobs the dead letter store keeps
+	DeadLetterSize int `mapstructure:"dead_letter_size"`
+	// zig runs simulations on the Zig engine over gRPC; mock runs them on an
+	// in-process engine for tests and local development without the binary;
+	// demo is the mock engine with load noise and random plant faults
+	EngineBackend string `mapstructure:"engine_backend"`
 }
 
 // DatabaseConfig holds database configuration
@@ -82,6 +341,32 @@ type DatabaseConfig struct {
 	MaxLifetime  time.Duration `mapstructure:"max_lifetime"`
 	MaxIdleTime  time.Duration `mapstructure:"max_idle_time"`
 	QueryTimeout time.Duration `mapstructure:"query_timeout"`
+	IDFormat     string        `mapstructure:"id_format"`
+	// Query tuning for high ingest rates, see database.Config
+	PreparedStatements     bool `mapstructure:"prepared_statements"`
+	StatementCacheCapacity int  `mapstructure:"statement_cache_capacity"`
+	SkipDefaultTransaction bool `mapstructure:"skip_default_transaction"`
+	CreateBatchSize        int  `mapstructure:"create_batch_size"`
+	// The connection pool is sampled for metrics this often
+	StatsInterval time.Duration `mapstructure:"stats_interval"`
+	// Waits for a free connection per second at which the pool is reported
+	// saturated and health degrades; zero disables the check
+	WaitRateThreshold float64 `mapstructure:"wait_rate_threshold"`
+	// How result batches and component metrics are written: insert or copy,
+	// which falls back to inserts for any batch it cannot load
+	BulkLoad string `mapstructure:"bulk_load"`
+	// Simulations missing a summary, or with a stale one, are summarized
+	// this often
+	SummaryBackfillInterval time.Duration `mapstructure:"summary_backfill_interval"`
+	// Keys encrypting webhook secrets, channel credentials and user metadata
+	// at rest, as id:base64 AES key; the first one encrypts. Rotate by
+	// adding a new key in front and removing the old one once stored values
+	// were re-encrypted. Unset stores those fields unencrypted.
+	EncryptionKeys []string `mapstructure:"encryption_keys"`
+	// File with further keys, one per line, such as a mounted secret
+	EncryptionKeysFile string `mapstructure:"encryption_keys_file"`
+	// Re-encrypt values stored unencrypted or with an older key on startup
+	ReencryptOnStart bool `mapstructure:"reencrypt_on_start"`
 }
 
 // CacheConfig holds cache configuration
@@ -98,26 +383,192 @@ type CacheConfig struct {
 
 // LogConfig holds logging configuration
 type LogConfig struct {
-	Level      string `mapstructure:"level"`
-	Format     string `mapstructure:"format"`
-	Output     string `mapstructure:"output"`
-	MaxSize    int    `mapstructure:"max_size"`
-	MaxAge     int    `mapstructure:"max_age"`
-	MaxBackups int    `mapstructure:"max_backups"`
-	Compress   bool   `mapstructure:"compress"`
+	Level string `mapstructure:"level"`
+	// json or text
+	Format string `mapstructure:"format"`
+	// stdout, stderr, syslog, syslog://host:port or the path of a log file
+	Output string `mapstructure:"output"`
+	// Rotation of a log file: megabytes before rotating, days and number of
+	// rotated files kept, and whether they are gzipped
+	MaxSize    int  `mapstructure:"max_size"`
+	MaxAge     int  `mapstructure:"max_age"`
+	MaxBackups int  `mapstructure:"max_backups"`
+	Compress   bool `mapstructure:"compress"`
 }
 
 // SecurityConfig holds security configuration
 type SecurityConfig struct {
-	JWTSecret       string        `mapstructure:"jwt_secret"`
-	JWTExpiry       time.Duration `mapstructure:"jwt_expiry"`
-	RefreshExpiry   time.Duration `mapstructure:"refresh_expiry"`
-	EnableHTTPS     bool          `mapstructure:"enable_https"`
-	CertFile        string        `mapstructure:"cert_file"`
-	KeyFile         string        `mapstructure:"key_file"`
-	EnableRateLimit bool          `mapstructure:"enable_rate_limit"`
-	TrustedProxies  []string      `mapstructure:"trusted_proxies"`
-	EnableCORS      bool          `mapstructure:"enable_cors"`
+	JWTSecret        string        `mapstructure:"jwt_secret"`
+	JWTExpiry        time.Duration `mapstructure:"jwt_expiry"`
+	RefreshExpiry    time.Duration `mapstructure:"refresh_expiry"`
+	ImpersonationTTL time.Duration `mapstructure:"impersonation_ttl"`
+	EnableHTTPS      bool          `mapstructure:"enable_https"`
+	CertFile         string        `mapstructure:"cert_file"`
+	KeyFile          string        `mapstructure:"key_file"`
+	EnableRateLimit  bool          `mapstructure:"enable_rate_limit"`
+	TrustedProxies   []string      `mapstructure:"trusted_proxies"`
+	EnableCORS       bool          `mapstructure:"enable_cors"`
+}
+
+// PredictionConfig holds forecasting backend configuration
+type PredictionConfig struct {
+	Backend     string        `mapstructure:"backend"`
+	Endpoint    string        `mapstructure:"endpoint"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	Horizon     time.Duration `mapstructure:"horizon"`
+	HistorySize int           `mapstructure:"history_size"`
+	// Use the built-in forecaster when the external backend is unavailable
+	Fallback bool `mapstructure:"fallback"`
+}
+
+// PlaybackConfig holds historical playback configuration
+type PlaybackConfig struct {
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	MaxFrames   int           `mapstructure:"max_frames"`
+	MaxSpeed    float64       `mapstructure:"max_speed"`
+	MaxFrameGap time.Duration `mapstructure:"max_frame_gap"`
+}
+
+// OutboxConfig holds transactional outbox delivery configuration
+type OutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	// Events still undelivered after this many attempts are marked failed
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+	// Delivered events are purged after this long
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// LockConfig holds distributed locking configuration
+type LockConfig struct {
+	// "database" coordinates replicas through the database; "local" only
+	// guards a single process
+	Backend string `mapstructure:"backend"`
+	// Locks held by a replica that stops renewing them are freed after this long
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// ClusterConfig holds multi-replica ownership configuration
+type ClusterConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Must be unique per process; generated from the hostname when empty
+	NodeID string `mapstructure:"node_id"`
+	// Base URL other replicas use to reach this replica; that of the
+	// internal listener when api.internal.port is set
+	AdvertiseURL      string        `mapstructure:"advertise_url"`
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	// Replicas without a heartbeat for this long are taken over
+	NodeTTL time.Duration `mapstructure:"node_ttl"`
+	// Shared secret authenticating requests forwarded between replicas
+	InternalToken string `mapstructure:"internal_token"`
+}
+
+// ObjectStorageConfig holds credentials for S3-compatible object storage,
+// used for s3:// backup and export targets
+type ObjectStorageConfig struct {
+	// Host and optional port, e.g. s3.amazonaws.com or minio:9000
+	Endpoint        string `mapstructure:"endpoint"`
+	Region          string `mapstructure:"region"`
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	UseSSL          bool   `mapstructure:"use_ssl"`
+}
+
+// ArchiveConfig holds archival of finished simulations' results
+type ArchiveConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Directory or s3://bucket/prefix; S3 credentials come from object_storage
+	Target string `mapstructure:"target"`
+	// Results are archived this long after their simulation finished
+	MinAge    time.Duration `mapstructure:"min_age"`
+	Interval  time.Duration `mapstructure:"interval"`
+	BatchSize int           `mapstructure:"batch_size"`
+	// "rehydrate" copies archived results back into the database when read;
+	// "stream" reads them from the archive every time
+	ReadMode string `mapstructure:"read_mode"`
+}
+
+// SnapshotConfig holds where engine state snapshots are stored
+type SnapshotConfig struct {
+	// Directory or s3://bucket/prefix; S3 credentials come from object_storage
+	Target string `mapstructure:"target"`
+}
+
+// UsageConfig holds per-organization usage metering and default quotas
+type UsageConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Metered usage is written to the database this often
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// Applied to organizations without their own quotas
+	Quotas QuotaConfig `mapstructure:"quotas"`
+}
+
+// QuotaConfig holds usage limits; zero means unlimited
+type QuotaConfig struct {
+	APICallsPerDay          int64   `mapstructure:"api_calls_per_day"`
+	SimulationHoursPerMonth float64 `mapstructure:"simulation_hours_per_month"`
+	ResultRows              int64   `mapstructure:"result_rows"`
+}
+
+// ChaosConfig holds the failure injection admins can turn on to test
+// resilience and alerting
+type ChaosConfig struct {
+	// Off by default; experiments cannot be started unless enabled
+	Enabled bool `mapstructure:"enabled"`
+	// Experiments started without a duration end after this long
+	DefaultDuration time.Duration `mapstructure:"default_duration"`
+	// Longest an experiment may run
+	MaxDuration time.Duration `mapstructure:"max_duration"`
+}
+
+// RecordingConfig holds the capture of API traffic and engine calls for
+// replay testing
+type RecordingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// JSON lines file the recording is appended to
+	Path string `mapstructure:"path"`
+	// Request and response bodies larger than this are not recorded
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+	// JSON keys whose values are redacted; a built-in list when empty
+	RedactFields []string `mapstructure:"redact_fields"`
+}
+
+// AlertingConfig holds delivery settings for alert notification channels
+type AlertingConfig struct {
+	// PagerDuty Events API v2 endpoint
+	PagerDutyURL string     `mapstructure:"pagerduty_url"`
+	SMTP         SMTPConfig `mapstructure:"smtp"`
+	// Silences are deleted this long after they expire
+	SilenceRetention time.Duration `mapstructure:"silence_retention"`
+}
+
+// SMTPConfig holds the mail server email channels send through
+type SMTPConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	// Authentication is skipped when empty
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// SelfTestConfig holds the startup self-test settings. The self-test runs a
+// tiny built-in simulation through the database, the engine and the realtime
+// hub; /readyz reports not ready until it passes.
+type SelfTestConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Shut the gateway down when the self-test fails
+	Required bool `mapstructure:"required"`
+}
+
+// ReportsConfig holds scheduled report settings. Reports are emailed through
+// the alerting SMTP server and are not sent without one.
+type ReportsConfig struct {
+	// How often users' report schedules are checked for due reports
+	ScheduleInterval time.Duration `mapstructure:"schedule_interval"`
 }
 
 // Load loads configuration from file and environment variables
@@ -169,6 +620,60 @@ func setDefaults() {
 	viper.SetDefault("api.rate_limit_burst", 200)
 	viper.SetDefault("api.websocket_path", "/ws")
 	viper.SetDefault("api.websocket_timeout", "60s")
+	viper.SetDefault("api.metadata.max_bytes", 16384) // 16KB
+	viper.SetDefault("api.metadata.max_keys", 64)
+	viper.SetDefault("api.metadata.max_depth", 4)
+	viper.SetDefault("api.metadata.max_key_length", 128)
+	viper.SetDefault("api.metadata.reserved_prefixes", []string{"voltedge.", "system."})
+	viper.SetDefault("api.limits.default_timeout", "30s")
+	viper.SetDefault("api.limits.control_timeout", "5s")
+	viper.SetDefault("api.limits.export_timeout", "5m")
+	viper.SetDefault("api.limits.max_body_bytes", 1048576)         // 1MB
+	viper.SetDefault("api.limits.max_control_bytes", 65536)        // 64KB
+	viper.SetDefault("api.limits.max_config_bytes", 16777216)      // 16MB
+	viper.SetDefault("api.limits.max_upload_bytes", 67108864)      // 64MB
+	viper.SetDefault("api.limits.multipart_memory_bytes", 8388608) // 8MB
+	viper.SetDefault("api.compression.enabled", true)
+	viper.SetDefault("api.compression.min_size_bytes", 1024)
+	viper.SetDefault("api.ingest.token", "")
+	viper.SetDefault("api.ingest.max_batch_size", 10000)
+	viper.SetDefault("api.read_cache.ttl", "2s")
+	viper.SetDefault("api.read_cache.jitter", 0.2)
+	viper.SetDefault("api.read_cache.max_entries", 10000)
+	viper.SetDefault("api.share_links.default_ttl", "168h")
+	viper.SetDefault("api.share_links.max_ttl", "720h")
+	viper.SetDefault("api.multiplex.metrics", false)
+	viper.SetDefault("api.internal.port", "")
+	viper.SetDefault("api.cors.default.allow_origins", []string{})
+	viper.SetDefault("api.cors.default.allow_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	viper.SetDefault("api.cors.default.allow_headers", []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "X-CSRF-Token"})
+	viper.SetDefault("api.cors.default.expose_headers", []string{"Content-Length", "ETag"})
+	viper.SetDefault("api.cors.default.allow_credentials", false)
+	viper.SetDefault("api.cors.default.max_age", "12h")
+	viper.SetDefault("api.sessions.enabled", false)
+	viper.SetDefault("api.sessions.cookie_name", "voltedge_session")
+	viper.SetDefault("api.sessions.csrf_cookie_name", "voltedge_csrf")
+	viper.SetDefault("api.sessions.domain", "")
+	viper.SetDefault("api.sessions.secure", true)
+	viper.SetDefault("api.sessions.same_site", "lax")
+	viper.SetDefault("api.login.max_failed_attempts", 5)
+	viper.SetDefault("api.login.lockout_duration", "15m")
+	viper.SetDefault("api.login.history_retention", "2160h") // 90 days
+	viper.SetDefault("api.login.revocation_check_ttl", "10s")
+	viper.SetDefault("api.security_headers.enabled", true)
+	viper.SetDefault("api.security_headers.hsts_max_age", "8760h") // 1 year
+	viper.SetDefault("api.security_headers.hsts_include_subdomains", true)
+	viper.SetDefault("api.security_headers.hsts_preload", false)
+	viper.SetDefault("api.security_headers.no_sniff", true)
+	viper.SetDefault("api.security_headers.frame_options", "DENY")
+	viper.SetDefault("api.security_headers.referrer_policy", "strict-origin-when-cross-origin")
+	viper.SetDefault("api.security_headers.content_security_policy", "default-src 'none'; frame-ancestors 'none'")
+	viper.SetDefault("api.security_headers.docs_content_security_policy",
+		"default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'")
+	viper.SetDefault("api.internal.service_secret", "")
+	viper.SetDefault("api.internal.tls_cert_file", "")
+	viper.SetDefault("api.internal.tls_key_file", "")
+	viper.SetDefault("api.internal.client_ca_file", "")
 
 	// Zig defaults
 	viper.SetDefault("zig.endpoint", "localhost:9091")
@@ -188,15 +693,40 @@ func setDefaults() {
 	viper.SetDefault("observability.health_check_path", "/health")
 	viper.SetDefault("observability.profiling_enabled", false)
 	viper.SetDefault("observability.profiling_port", "6060")
+	viper.SetDefault("observability.remote_write.enabled", false)
+	viper.SetDefault("observability.remote_write.url", "")
+	viper.SetDefault("observability.remote_write.bearer_token", "")
+	viper.SetDefault("observability.remote_write.batch_size", 500)
+	viper.SetDefault("observability.remote_write.flush_interval", "5s")
+	viper.SetDefault("observability.remote_write.queue_size", 10000)
+	viper.SetDefault("observability.remote_write.timeout", "10s")
+	viper.SetDefault("observability.remote_write.max_retries", 5)
+	viper.SetDefault("observability.remote_write.retry_backoff", "1s")
+	viper.SetDefault("observability.remote_write.max_backoff", "30s")
+	viper.SetDefault("observability.sentry.dsn", "")
+	viper.SetDefault("observability.sentry.environment", "production")
+	viper.SetDefault("observability.sentry.sample_rate", 1.0)
+	viper.SetDefault("observability.sentry.queue_size", 100)
+	viper.SetDefault("observability.sentry.timeout", "5s")
 
 	// Orchestration defaults
 	viper.SetDefault("orchestration.max_concurrent_simulations", 10)
 	viper.SetDefault("orchestration.simulation_timeout", "10m")
 	viper.SetDefault("orchestration.cleanup_interval", "5m")
+	viper.SetDefault("orchestration.max_concurrent_per_organization", 5)
+	viper.SetDefault("orchestration.max_concurrent_per_user", 3)
 	viper.SetDefault("orchestration.job_queue_size", 1000)
 	viper.SetDefault("orchestration.worker_pool_size", 5)
 	viper.SetDefault("orchestration.enable_auto_scaling", true)
 	viper.SetDefault("orchestration.scaling_threshold", 0.8)
+	viper.SetDefault("orchestration.metrics_persist_interval", "10s")
+	viper.SetDefault("orchestration.reconcile_interval", "1m")
+	viper.SetDefault("orchestration.reconcile_grace_period", "2m")
+	viper.SetDefault("orchestration.job_max_retries", 3)
+	viper.SetDefault("orchestration.job_retry_backoff", "2s")
+	viper.SetDefault("orchestration.job_max_backoff", "1m")
+	viper.SetDefault("orchestration.dead_letter_size", 500)
+	viper.SetDefault("orchestration.engine_backend", "zig")
 
 	// Database defaults (CockroachDB)
 	viper.SetDefault("database.host", "cockroachdb")
@@ -210,6 +740,18 @@ func setDefaults() {
 	viper.SetDefault("database.max_lifetime", "5m")
 	viper.SetDefault("database.max_idle_time", "1m")
 	viper.SetDefault("database.query_timeout", "30s")
+	viper.SetDefault("database.id_format", "uuidv7") // uuidv4, uuidv7 or ulid
+	viper.SetDefault("database.prepared_statements", true)
+	viper.SetDefault("database.statement_cache_capacity", 512)
+	viper.SetDefault("database.skip_default_transaction", false)
+	viper.SetDefault("database.create_batch_size", 500)
+	viper.SetDefault("database.bulk_load", "insert")
+	viper.SetDefault("database.stats_interval", "15s")
+	viper.SetDefault("database.wait_rate_threshold", 10)
+	viper.SetDefault("database.summary_backfill_interval", "10m")
+	viper.SetDefault("database.encryption_keys", []string{})
+	viper.SetDefault("database.encryption_keys_file", "")
+	viper.SetDefault("database.reencrypt_on_start", true)
 
 	// Cache defaults
 	viper.SetDefault("cache.type", "redis")
@@ -222,6 +764,11 @@ func setDefaults() {
 	viper.SetDefault("cache.pool_size", 10)
 
 	// Log defaults
+	// Self-test defaults
+	viper.SetDefault("self_test.enabled", false)
+	viper.SetDefault("self_test.timeout", "30s")
+	viper.SetDefault("self_test.required", false)
+
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
 	viper.SetDefault("log.output", "stdout")
@@ -234,12 +781,91 @@ func setDefaults() {
 	viper.SetDefault("security.jwt_secret", "voltedge-secret-key-change-in-production")
 	viper.SetDefault("security.jwt_expiry", "1h")
 	viper.SetDefault("security.refresh_expiry", "24h")
+	viper.SetDefault("security.impersonation_ttl", "30m")
 	viper.SetDefault("security.enable_https", false)
 	viper.SetDefault("security.cert_file", "")
 	viper.SetDefault("security.key_file", "")
 	viper.SetDefault("security.enable_rate_limit", true)
 	viper.SetDefault("security.trusted_proxies", []string{})
 	viper.SetDefault("security.enable_cors", true)
+
+	// Prediction defaults
+	viper.SetDefault("prediction.backend", "statistical") // statistical or http
+	viper.SetDefault("prediction.endpoint", "")
+	viper.SetDefault("prediction.timeout", "10s")
+	viper.SetDefault("prediction.horizon", "1h")
+	viper.SetDefault("prediction.history_size", 288)
+	viper.SetDefault("prediction.fallback", true)
+
+	// Playback defaults
+	viper.SetDefault("playback.idle_timeout", "30m")
+	viper.SetDefault("playback.max_frames", 100000)
+	viper.SetDefault("playback.max_speed", 1000.0)
+	viper.SetDefault("playback.max_frame_gap", "5s")
+
+	// Outbox defaults
+	viper.SetDefault("outbox.poll_interval", "2s")
+	viper.SetDefault("outbox.batch_size", 50)
+	viper.SetDefault("outbox.max_attempts", 10)
+	viper.SetDefault("outbox.retry_backoff", "5s")
+	viper.SetDefault("outbox.max_backoff", "10m")
+	viper.SetDefault("outbox.retention", "168h")
+
+	// Lock defaults
+	viper.SetDefault("lock.backend", "database")
+	viper.SetDefault("lock.ttl", "30s")
+
+	// Cluster defaults
+	viper.SetDefault("cluster.enabled", false)
+	viper.SetDefault("cluster.heartbeat_interval", "5s")
+	viper.SetDefault("cluster.node_ttl", "20s")
+
+	// Object storage defaults; empty values are declared so environment
+	// variables can supply credentials
+	viper.SetDefault("object_storage.endpoint", "s3.amazonaws.com")
+	viper.SetDefault("object_storage.region", "")
+	viper.SetDefault("object_storage.access_key_id", "")
+	viper.SetDefault("object_storage.secret_access_key", "")
+	viper.SetDefault("object_storage.use_ssl", true)
+
+	// Archive defaults
+	viper.SetDefault("archive.enabled", false)
+	viper.SetDefault("archive.target", "")
+	viper.SetDefault("archive.min_age", "720h")
+	viper.SetDefault("archive.interval", "1h")
+	viper.SetDefault("archive.batch_size", 20)
+	viper.SetDefault("archive.read_mode", "rehydrate")
+
+	// Alerting defaults
+	viper.SetDefault("alerting.pagerduty_url", "https://events.pagerduty.com/v2/enqueue")
+	viper.SetDefault("alerting.silence_retention", "168h")
+	viper.SetDefault("alerting.smtp.host", "")
+	viper.SetDefault("alerting.smtp.port", 587)
+	viper.SetDefault("alerting.smtp.username", "")
+	viper.SetDefault("alerting.smtp.password", "")
+	viper.SetDefault("alerting.smtp.from", "")
+	viper.SetDefault("reports.schedule_interval", "5m")
+
+	// Snapshot defaults
+	viper.SetDefault("snapshots.target", "data/snapshots")
+
+	// Usage defaults; quotas are unlimited until configured
+	viper.SetDefault("usage.enabled", true)
+	viper.SetDefault("usage.flush_interval", "30s")
+	viper.SetDefault("usage.quotas.api_calls_per_day", 0)
+	viper.SetDefault("usage.quotas.simulation_hours_per_month", 0)
+	viper.SetDefault("usage.quotas.result_rows", 0)
+
+	// Chaos defaults
+	viper.SetDefault("chaos.enabled", false)
+	viper.SetDefault("chaos.default_duration", "5m")
+	viper.SetDefault("chaos.max_duration", "1h")
+
+	// Recording defaults
+	viper.SetDefault("recording.enabled", false)
+	viper.SetDefault("recording.path", "data/recordings/traffic.jsonl")
+	viper.SetDefault("recording.max_body_bytes", 65536) // 64KB
+	viper.SetDefault("recording.redact_fields", []string{})
 }
 
 // Validate validates the configuration
@@ -256,9 +882,283 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("observability.service_name is required")
 	}
 
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		return fmt.Errorf("log.format must be json or text")
+	}
+	if c.Log.MaxSize < 0 || c.Log.MaxAge < 0 || c.Log.MaxBackups < 0 {
+		return fmt.Errorf("log max_size, max_age and max_backups must not be negative")
+	}
+
 	if c.Security.EnableHTTPS && (c.Security.CertFile == "" || c.Security.KeyFile == "") {
 		return fmt.Errorf("cert_file and key_file are required when HTTPS is enabled")
 	}
 
+	if c.Prediction.Backend != "statistical" && c.Prediction.Backend != "http" {
+		return fmt.Errorf("prediction.backend must be statistical or http")
+	}
+
+	if c.Prediction.Backend == "http" && c.Prediction.Endpoint == "" {
+		return fmt.Errorf("prediction.endpoint is required when the http prediction backend is used")
+	}
+
+	limits := c.API.Limits
+	if limits.DefaultTimeout <= 0 || limits.ControlTimeout <= 0 || limits.ExportTimeout <= 0 {
+		return fmt.Errorf("api.limits timeouts must be positive")
+	}
+
+	if limits.MaxBodyBytes <= 0 || limits.MaxControlBytes <= 0 || limits.MaxConfigBytes <= 0 || limits.MaxUploadBytes <= 0 {
+		return fmt.Errorf("api.limits body sizes must be positive")
+	}
+
+	if c.API.Compression.MinSizeBytes < 0 {
+		return fmt.Errorf("api.compression.min_size_bytes must not be negative")
+	}
+
+	if c.Database.StatementCacheCapacity < 0 || c.Database.CreateBatchSize <= 0 {
+		return fmt.Errorf("database.statement_cache_capacity must not be negative and database.create_batch_size must be positive")
+	}
+
+	if c.Database.StatsInterval <= 0 || c.Database.WaitRateThreshold < 0 {
+		return fmt.Errorf("database.stats_interval must be positive and database.wait_rate_threshold must not be negative")
+	}
+
+	if c.Database.BulkLoad != "insert" && c.Database.BulkLoad != "copy" {
+		return fmt.Errorf("database.bulk_load must be insert or copy")
+	}
+
+	if c.Database.SummaryBackfillInterval <= 0 {
+		return fmt.Errorf("database.summary_backfill_interval must be positive")
+	}
+
+	if c.API.Ingest.MaxBatchSize <= 0 {
+		return fmt.Errorf("api.ingest.max_batch_size must be positive")
+	}
+
+	if rc := c.API.ReadCache; rc.TTL < 0 || rc.Jitter < 0 || rc.Jitter > 1 || rc.MaxEntries < 0 {
+		return fmt.Errorf("api.read_cache.ttl and api.read_cache.max_entries must not be negative and api.read_cache.jitter must be between 0 and 1")
+	}
+
+	if sl := c.API.ShareLinks; sl.DefaultTTL <= 0 || sl.MaxTTL < sl.DefaultTTL {
+		return fmt.Errorf("api.share_links.default_ttl must be positive and at most api.share_links.max_ttl")
+	}
+
+	if err := validateCORSPolicy("api.cors.default", c.API.CORS.Default, c.API.CORSOrigins); err != nil {
+		return err
+	}
+	prefixes := make(map[string]bool, len(c.API.CORS.Routes))
+	for _, route := range c.API.CORS.Routes {
+		if !strings.HasPrefix(route.PathPrefix, "/") || prefixes[route.PathPrefix] {
+			return fmt.Errorf("api.cors.routes path_prefix %q must start with / and be unique", route.PathPrefix)
+		}
+		prefixes[route.PathPrefix] = true
+		if err := validateCORSPolicy("api.cors.routes "+route.PathPrefix, route.CORSPolicy, nil); err != nil {
+			return err
+		}
+	}
+
+	if ss := c.API.Sessions; ss.Enabled {
+		if ss.CookieName == "" || ss.CSRFCookieName == "" || ss.CookieName == ss.CSRFCookieName {
+			return fmt.Errorf("api.sessions.cookie_name and api.sessions.csrf_cookie_name must be set and differ")
+		}
+		switch ss.SameSite {
+		case "lax", "strict":
+		case "none":
+			if !ss.Secure {
+				return fmt.Errorf("api.sessions.same_site none requires api.sessions.secure")
+			}
+		default:
+			return fmt.Errorf("api.sessions.same_site must be lax, strict or none")
+		}
+	}
+
+	if md := c.API.Metadata; md.MaxBytes < 0 || md.MaxKeys < 0 || md.MaxDepth < 0 || md.MaxKeyLength < 0 {
+		return fmt.Errorf("api.metadata limits must not be negative")
+	}
+	for _, prefix := range c.API.Metadata.ReservedPrefixes {
+		if prefix == "" {
+			return fmt.Errorf("api.metadata.reserved_prefixes must not contain an empty prefix")
+		}
+	}
+
+	if login := c.API.Login; login.MaxFailedAttempts < 0 || (login.MaxFailedAttempts > 0 && login.LockoutDuration <= 0) {
+		return fmt.Errorf("api.login.max_failed_attempts must not be negative and api.login.lockout_duration must be positive")
+	}
+	if c.API.Login.HistoryRetention <= 0 || c.API.Login.RevocationCheckTTL < 0 {
+		return fmt.Errorf("api.login.history_retention must be positive and api.login.revocation_check_ttl must not be negative")
+	}
+
+	if sh := c.API.SecurityHeaders; sh.Enabled {
+		if sh.HSTSMaxAge < 0 {
+			return fmt.Errorf("api.security_headers.hsts_max_age must not be negative")
+		}
+		if sh.HSTSPreload && (!sh.HSTSIncludeSubdomains || sh.HSTSMaxAge < 365*24*time.Hour) {
+			return fmt.Errorf("api.security_headers.hsts_preload requires hsts_include_subdomains and an hsts_max_age of at least a year")
+		}
+		switch sh.FrameOptions {
+		case "", "DENY", "SAMEORIGIN":
+		default:
+			return fmt.Errorf("api.security_headers.frame_options must be DENY, SAMEORIGIN or empty")
+		}
+	}
+
+	if in := c.API.Internal; in.Port != "" {
+		if in.Port == c.API.Port {
+			return fmt.Errorf("api.internal.port must differ from api.port")
+		}
+		if (in.TLSCertFile == "") != (in.TLSKeyFile == "") {
+			return fmt.Errorf("api.internal.tls_cert_file and api.internal.tls_key_file must be set together")
+		}
+		if in.ClientCAFile != "" && in.TLSCertFile == "" {
+			return fmt.Errorf("api.internal.tls_cert_file is required when api.internal.client_ca_file is set")
+		}
+	}
+
+	if c.Playback.MaxFrames <= 0 || c.Playback.MaxSpeed <= 0 {
+		return fmt.Errorf("playback.max_frames and playback.max_speed must be positive")
+	}
+
+	if c.Outbox.PollInterval <= 0 || c.Outbox.BatchSize <= 0 || c.Outbox.MaxAttempts <= 0 {
+		return fmt.Errorf("outbox.poll_interval, outbox.batch_size and outbox.max_attempts must be positive")
+	}
+
+	if c.Orchestration.MaxConcurrentPerOrganization < 0 || c.Orchestration.MaxConcurrentPerUser < 0 {
+		return fmt.Errorf("orchestration.max_concurrent_per_organization and orchestration.max_concurrent_per_user must not be negative")
+	}
+
+	if c.Orchestration.JobMaxRetries < 0 || c.Orchestration.DeadLetterSize <= 0 {
+		return fmt.Errorf("orchestration.job_max_retries must not be negative and orchestration.dead_letter_size must be positive")
+	}
+
+	if c.Orchestration.JobRetryBackoff <= 0 || c.Orchestration.JobMaxBackoff < c.Orchestration.JobRetryBackoff {
+		return fmt.Errorf("orchestration.job_retry_backoff must be positive and no greater than orchestration.job_max_backoff")
+	}
+
+	switch c.Orchestration.EngineBackend {
+	case "zig", "mock", "demo":
+	default:
+		return fmt.Errorf("orchestration.engine_backend must be zig, mock or demo")
+	}
+
+	if c.Lock.Backend != "database" && c.Lock.Backend != "local" {
+		return fmt.Errorf("lock.backend must be database or local")
+	}
+
+	if c.Lock.TTL < 3*time.Second {
+		return fmt.Errorf("lock.ttl must be at least 3s")
+	}
+
+	if c.Cluster.Enabled {
+		if c.Cluster.AdvertiseURL == "" || c.Cluster.InternalToken == "" {
+			return fmt.Errorf("cluster.advertise_url and cluster.internal_token are required when clustering is enabled")
+		}
+		if c.Cluster.HeartbeatInterval <= 0 || c.Cluster.NodeTTL < 2*c.Cluster.HeartbeatInterval {
+			return fmt.Errorf("cluster.node_ttl must be at least twice cluster.heartbeat_interval")
+		}
+		if c.Lock.Backend != "database" {
+			return fmt.Errorf("clustering requires lock.backend database")
+		}
+	}
+
+	if rw := c.Observability.RemoteWrite; rw.Enabled {
+		if rw.URL == "" {
+			return fmt.Errorf("observability.remote_write.url is required when remote write is enabled")
+		}
+		if rw.BatchSize <= 0 || rw.QueueSize < rw.BatchSize || rw.FlushInterval <= 0 {
+			return fmt.Errorf("observability.remote_write batch_size and flush_interval must be positive and queue_size at least batch_size")
+		}
+	}
+
+	if sentry := c.Observability.Sentry; sentry.DSN != "" {
+		if sentry.SampleRate < 0 || sentry.SampleRate > 1 {
+			return fmt.Errorf("observability.sentry.sample_rate must be between 0 and 1")
+		}
+		if sentry.QueueSize <= 0 || sentry.Timeout <= 0 {
+			return fmt.Errorf("observability.sentry queue_size and timeout must be positive")
+		}
+	}
+
+	if c.SelfTest.Enabled && c.SelfTest.Timeout <= 0 {
+		return fmt.Errorf("self_test.timeout must be positive")
+	}
+
+	if c.Archive.Enabled {
+		if c.Archive.Target == "" {
+			return fmt.Errorf("archive.target is required when archival is enabled")
+		}
+		if c.Archive.MinAge <= 0 || c.Archive.BatchSize <= 0 {
+			return fmt.Errorf("archive.min_age and archive.batch_size must be positive")
+		}
+		if c.Archive.ReadMode != "rehydrate" && c.Archive.ReadMode != "stream" {
+			return fmt.Errorf("archive.read_mode must be rehydrate or stream")
+		}
+	}
+
+	if c.Alerting.SMTP.Host != "" && c.Alerting.SMTP.From == "" {
+		return fmt.Errorf("alerting.smtp.from is required when an SMTP host is set")
+	}
+	if c.Reports.ScheduleInterval <= 0 {
+		return fmt.Errorf("reports.schedule_interval must be positive")
+	}
+
+	if c.Snapshots.Target == "" {
+		return fmt.Errorf("snapshots.target is required")
+	}
+
+	if c.Usage.Enabled {
+		if c.Usage.FlushInterval <= 0 {
+			return fmt.Errorf("usage.flush_interval must be positive")
+		}
+		if q := c.Usage.Quotas; q.APICallsPerDay < 0 || q.SimulationHoursPerMonth < 0 || q.ResultRows < 0 {
+			return fmt.Errorf("usage.quotas must not be negative")
+		}
+	}
+
+	if c.Chaos.Enabled {
+		if c.Chaos.DefaultDuration <= 0 || c.Chaos.MaxDuration <= 0 {
+			return fmt.Errorf("chaos.default_duration and chaos.max_duration must be positive")
+		}
+		if c.Chaos.DefaultDuration > c.Chaos.MaxDuration {
+			return fmt.Errorf("chaos.default_duration must not exceed chaos.max_duration")
+		}
+	}
+
+	if c.Recording.Enabled {
+		if c.Recording.Path == "" {
+			return fmt.Errorf("recording.path is required when recording is enabled")
+		}
+		if c.Recording.MaxBodyBytes <= 0 {
+			return fmt.Errorf("recording.max_body_bytes must be positive")
+		}
+	}
+
+	return nil
+}
+
+// validateCORSPolicy checks a policy's origins, falling back to fallback
+// when it has none
+func validateCORSPolicy(name string, policy CORSPolicy, fallback []string) error {
+	origins := policy.AllowOrigins
+	if len(origins) == 0 {
+		origins = fallback
+	}
+	if len(origins) == 0 {
+		return fmt.Errorf("%s.allow_origins must not be empty", name)
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			if policy.AllowCredentials {
+				return fmt.Errorf("%s.allow_credentials cannot be used with origin *; list the allowed origins instead", name)
+			}
+			continue
+		}
+		// A leading *. in the host matches any subdomain
+		parsed, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.Path != "" || parsed.RawQuery != "" || parsed.User != nil {
+			return fmt.Errorf("%s origin %q must be *, scheme://host[:port] or scheme://*.domain[:port]", name, origin)
+		}
+	}
+	if policy.MaxAge < 0 {
+		return fmt.Errorf("%s.max_age must not be negative", name)
+	}
 	return nil
 }
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ActivityScope selects the simulations of an organization, or of a single
+// user when OrganizationID is nil
+type ActivityScope struct {
+	OrganizationID *uuid.UUID
+	UserID         uuid.UUID
+}
+
+// where restricts a query on simulations to the scope
+func (a ActivityScope) where(query *gorm.DB) *gorm.DB {
+	if a.OrganizationID != nil {
+		return query.Where("simulations.organization_id = ?", *a.OrganizationID)
+	}
+	return query.Where("simulations.user_id = ?", a.UserID)
+}
+
+// ListRunsInPeriod retrieves up to limit simulations in scope that started or
+// finished in [from, to), most recently updated first, and how many there
+// are in all
+func (s *SimulationService) ListRunsInPeriod(ctx context.Context, scope ActivityScope, from, to time.Time, limit int) ([]Simulation, int64, error) {
+	query := func() *gorm.DB {
+		return scope.where(s.db.WithContext(ctx).Model(&Simulation{})).
+			Where("((started_at >= ? AND started_at < ?) OR (completed_at >= ? AND completed_at < ?))", from, to, from, to)
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count runs in period")
+		return nil, 0, err
+	}
+
+	var simulations []Simulation
+	if err := query().Order("updated_at DESC").Limit(limit).Find(&simulations).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to list runs in period")
+		return nil, 0, err
+	}
+	return simulations, total, nil
+}
+
+// ListAlertsInPeriod retrieves up to limit alerts triggered in [from, to) on
+// simulations in scope, newest first, with the number triggered per severity
+func (s *SimulationService) ListAlertsInPeriod(ctx context.Context, scope ActivityScope, from, to time.Time, limit int) ([]Alert, map[string]int64, error) {
+	query := func() *gorm.DB {
+		return scope.where(s.db.WithContext(ctx).Model(&Alert{}).
+			Joins("JOIN simulations ON simulations.id = alerts.simulation_id")).
+			Where("alerts.triggered_at >= ? AND alerts.triggered_at < ?", from, to)
+	}
+
+	var rows []struct {
+		Severity string
+		Count    int64
+	}
+	if err := query().Select("alerts.severity, COUNT(*) AS count").Group("alerts.severity").Scan(&rows).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count alerts in period")
+		return nil, nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Severity] = row.Count
+	}
+
+	var alerts []Alert
+	if err := query().Select("alerts.*").
+		Preload("Simulation", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
+		Order("alerts.triggered_at DESC").Limit(limit).Find(&alerts).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to list alerts in period")
+		return nil, nil, err
+	}
+	return alerts, counts, nil
+}
+
+// SimulationsInScope returns which of the given simulations are in scope
+func (s *SimulationService) SimulationsInScope(ctx context.Context, scope ActivityScope, simulationIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if len(simulationIDs) == 0 {
+		return nil, nil
+	}
+
+	var ids []uuid.UUID
+	err := scope.where(s.db.WithContext(ctx).Model(&Simulation{})).
+		Where("simulations.id IN ?", simulationIDs).
+		Pluck("simulations.id", &ids).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to check simulations in scope")
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,349 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AlertRoutingService provides notification channel and alert route database operations
+type AlertRoutingService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewAlertRoutingService creates a new alert routing service
+func NewAlertRoutingService(db *gorm.DB, logger *logrus.Logger) *AlertRoutingService {
+	return &AlertRoutingService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateChannel creates a new notification channel
+func (s *AlertRoutingService) CreateChannel(channel *NotificationChannel) error {
+	if err := s.db.Create(channel).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to create notification channel")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"channel_id": channel.ID,
+		"type":       channel.Type,
+	}).Info("Notification channel created")
+
+	return nil
+}
+
+// GetChannel retrieves a notification channel by ID
+func (s *AlertRoutingService) GetChannel(id uuid.UUID) (*NotificationChannel, error) {
+	var channel NotificationChannel
+
+	err := s.db.First(&channel, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get notification channel")
+		return nil, err
+	}
+
+	return &channel, nil
+}
+
+// ListChannels retrieves notification channels with pagination
+func (s *AlertRoutingService) ListChannels(limit, offset int) ([]NotificationChannel, error) {
+	var channels []NotificationChannel
+
+	err := s.db.Order(recentFirst("created_at")).
+		Limit(limit).
+		Offset(offset).
+		Find(&channels).Error
+
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list notification channels")
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// UpdateChannel saves changes to a notification channel that is still at
+// expectedVersion, returning ErrVersionConflict when it has moved on
+func (s *AlertRoutingService) UpdateChannel(channel *NotificationChannel, expectedVersion int64) error {
+	channel.UpdatedAt = time.Now()
+	if err := updateVersioned(s.db, channel, &channel.Version, expectedVersion); err != nil {
+		if err != ErrVersionConflict {
+			s.logger.WithError(err).Error("Failed to update notification channel")
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteChannel deletes a notification channel along with the routes using it
+func (s *AlertRoutingService) DeleteChannel(id uuid.UUID) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&AlertRoute{}, "channel_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&NotificationChannel{}, "id = ?", id).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to delete notification channel")
+		return err
+	}
+
+	s.logger.WithField("channel_id", id).Info("Notification channel deleted")
+	return nil
+}
+
+// CreateRoute creates a new alert route
+func (s *AlertRoutingService) CreateRoute(route *AlertRoute) error {
+	if err := s.db.Omit(clause.Associations).Create(route).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to create alert route")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"route_id":   route.ID,
+		"channel_id": route.ChannelID,
+	}).Info("Alert route created")
+
+	return nil
+}
+
+// GetRoute retrieves an alert route by ID
+func (s *AlertRoutingService) GetRoute(id uuid.UUID) (*AlertRoute, error) {
+	var route AlertRoute
+
+	err := s.db.First(&route, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get alert route")
+		return nil, err
+	}
+
+	return &route, nil
+}
+
+// ListRoutes retrieves alert routes with pagination
+func (s *AlertRoutingService) ListRoutes(limit, offset int) ([]AlertRoute, error) {
+	var routes []AlertRoute
+
+	err := s.db.Order(recentFirst("created_at")).
+		Limit(limit).
+		Offset(offset).
+		Find(&routes).Error
+
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list alert routes")
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// UpdateRoute saves changes to an alert route that is still at
+// expectedVersion, returning ErrVersionConflict when it has moved on
+func (s *AlertRoutingService) UpdateRoute(route *AlertRoute, expectedVersion int64) error {
+	route.UpdatedAt = time.Now()
+	if err := updateVersioned(s.db, route, &route.Version, expectedVersion); err != nil {
+		if err != ErrVersionConflict {
+			s.logger.WithError(err).Error("Failed to update alert route")
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteRoute deletes an alert route
+func (s *AlertRoutingService) DeleteRoute(id uuid.UUID) error {
+	if err := s.db.Delete(&AlertRoute{}, "id = ?", id).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to delete alert route")
+		return err
+	}
+
+	s.logger.WithField("route_id", id).Info("Alert route deleted")
+	return nil
+}
+
+// GetActiveAlertRoutes retrieves active routes whose channel is also active,
+// with the channel loaded
+func (s *AlertRoutingService) GetActiveAlertRoutes() ([]AlertRoute, error) {
+	var routes []AlertRoute
+
+	err := s.db.Joins("Channel").
+		Where("alert_routes.is_active = ? AND \"Channel\".is_active = ?", true, true).
+		Find(&routes).Error
+
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get active alert routes")
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// CountSentNotifications counts notifications a route sent since a time.
+// With a dedupe key only notifications for that key are counted.
+func (s *AlertRoutingService) CountSentNotifications(routeID uuid.UUID, dedupeKey string, since time.Time) (int64, error) {
+	query := s.db.Model(&AlertNotification{}).
+		Where("route_id = ? AND status = ? AND created_at >= ?", routeID, NotificationSent, since)
+	if dedupeKey != "" {
+		query = query.Where("dedupe_key = ?", dedupeKey)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count alert notifications")
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RecordNotification records the outcome of notifying a channel
+func (s *AlertRoutingService) RecordNotification(notification *AlertNotification) error {
+	if err := s.db.Create(notification).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to record alert notification")
+		return err
+	}
+	return nil
+}
+
+// ListNotifications retrieves recent alert notifications, optionally for one route
+func (s *AlertRoutingService) ListNotifications(routeID *uuid.UUID, limit, offset int) ([]AlertNotification, error) {
+	var notifications []AlertNotification
+
+	query := s.db.Order(recentFirst("created_at"))
+	if routeID != nil {
+		query = query.Where("route_id = ?", *routeID)
+	}
+
+	err := query.Limit(limit).Offset(offset).Find(&notifications).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list alert notifications")
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// CreateSilence creates a new alert silence
+func (s *AlertRoutingService) CreateSilence(silence *AlertSilence) error {
+	if err := s.db.Create(silence).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to create alert silence")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"silence_id": silence.ID,
+		"starts_at":  silence.StartsAt,
+		"ends_at":    silence.EndsAt,
+	}).Info("Alert silence created")
+
+	return nil
+}
+
+// GetSilence retrieves an alert silence by ID
+func (s *AlertRoutingService) GetSilence(id uuid.UUID) (*AlertSilence, error) {
+	var silence AlertSilence
+
+	err := s.db.First(&silence, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get alert silence")
+		return nil, err
+	}
+
+	return &silence, nil
+}
+
+// ListSilences retrieves alert silences with pagination, optionally only
+// those in a given state at the current time
+func (s *AlertRoutingService) ListSilences(state string, limit, offset int) ([]AlertSilence, error) {
+	var silences []AlertSilence
+
+	now := time.Now()
+	query := s.db.Order(recentFirst("created_at"))
+	switch state {
+	case "":
+	case SilencePending:
+		query = query.Where("starts_at > ?", now)
+	case SilenceActive:
+		query = query.Where("starts_at <= ? AND ends_at > ?", now, now)
+	case SilenceExpired:
+		query = query.Where("ends_at <= ?", now)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSilenceState, state)
+	}
+
+	err := query.Limit(limit).Offset(offset).Find(&silences).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list alert silences")
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+// UpdateSilence saves changes to an alert silence that is still at
+// expectedVersion, returning ErrVersionConflict when it has moved on
+func (s *AlertRoutingService) UpdateSilence(silence *AlertSilence, expectedVersion int64) error {
+	silence.UpdatedAt = time.Now()
+	if err := updateVersioned(s.db, silence, &silence.Version, expectedVersion); err != nil {
+		if err != ErrVersionConflict {
+			s.logger.WithError(err).Error("Failed to update alert silence")
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteSilence deletes an alert silence
+func (s *AlertRoutingService) DeleteSilence(id uuid.UUID) error {
+	if err := s.db.Delete(&AlertSilence{}, "id = ?", id).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to delete alert silence")
+		return err
+	}
+
+	s.logger.WithField("silence_id", id).Info("Alert silence deleted")
+	return nil
+}
+
+// GetActiveSilences retrieves the silences in effect at a time
+func (s *AlertRoutingService) GetActiveSilences(at time.Time) ([]AlertSilence, error) {
+	var silences []AlertSilence
+
+	err := s.db.Where("starts_at <= ? AND ends_at > ?", at, at).Find(&silences).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get active alert silences")
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+// PurgeExpiredSilences deletes silences that ended before the cutoff
+func (s *AlertRoutingService) PurgeExpiredSilences(before time.Time) (int64, error) {
+	result := s.db.Where("ends_at < ?", before).Delete(&AlertSilence{})
+	if result.Error != nil {
+		s.logger.WithError(result.Error).Error("Failed to purge expired alert silences")
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// Errors
+var (
+	ErrInvalidSilenceState = fmt.Errorf("invalid silence state")
+)
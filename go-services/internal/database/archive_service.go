@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ArchiveService provides result archival database operations
+type ArchiveService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewArchiveService creates a new archive service
+func NewArchiveService(db *gorm.DB, logger *logrus.Logger) *ArchiveService {
+	return &ArchiveService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ArchiveCandidates returns finished simulations that completed before the
+// cutoff and still have results in the database, oldest first. Rehydrated
+// simulations become candidates again once they were rehydrated before the
+// cutoff.
+func (s *ArchiveService) ArchiveCandidates(before time.Time, statuses []string, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	err := s.db.Table("simulations AS s").
+		Joins("LEFT JOIN result_archives AS a ON a.simulation_id = s.id").
+		Where("s.status IN ? AND s.completed_at < ?", statuses, before).
+		Where("a.simulation_id IS NULL OR a.rehydrated_at < ?", before).
+		Where("EXISTS (SELECT 1 FROM simulation_results AS r WHERE r.simulation_id = s.id)").
+		Order("s.completed_at").
+		Limit(limit).
+		Pluck("s.id", &ids).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to find archive candidates")
+		return nil, err
+	}
+	return ids, nil
+}
+
+// EachResultBatch reads a simulation's results in batches of size
+func (s *ArchiveService) EachResultBatch(simulationID uuid.UUID, size int, fn func(results []SimulationResult) error) error {
+	var results []SimulationResult
+
+	err := s.db.Where("simulation_id = ?", simulationID).
+		FindInBatches(&results, size, func(tx *gorm.DB, batch int) error {
+			return fn(results)
+		}).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to read results for archival")
+		return err
+	}
+	return nil
+}
+
+// CompleteArchive records the archive's pointer row and deletes the archived
+// results, keeping the simulation's summary of them. It fails with ErrArchiveChanged, leaving the results in place, if
+// the number of results no longer matches the archive.
+func (s *ArchiveService) CompleteArchive(archive *ResultArchive) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&SimulationResult{}).Where("simulation_id = ?", archive.SimulationID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != archive.Rows {
+			return ErrArchiveChanged
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "simulation_id"}},
+			UpdateAll: true,
+		}).Create(archive).Error
+		if err != nil {
+			return err
+		}
+
+		// The summary keeps describing the archived results once they are gone
+		if err := refreshSummary(context.Background(), gormSummaryExec(tx), archive.SimulationID); err != nil {
+			return err
+		}
+
+		return tx.Where("simulation_id = ?", archive.SimulationID).Delete(&SimulationResult{}).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", archive.SimulationID).Error("Failed to complete result archive")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"simulation_id": archive.SimulationID,
+		"rows":          archive.Rows,
+		"location":      archive.Location,
+	}).Info("Simulation results archived")
+	return nil
+}
+
+// GetResultArchive returns a simulation's archive pointer, or nil if its
+// results were never archived
+func (s *ArchiveService) GetResultArchive(simulationID uuid.UUID) (*ResultArchive, error) {
+	var archive ResultArchive
+	if err := s.db.First(&archive, "simulation_id = ?", simulationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get result archive")
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// ListResultArchives returns archive pointers, most recently archived first
+func (s *ArchiveService) ListResultArchives(limit, offset int) ([]ResultArchive, error) {
+	var archives []ResultArchive
+	if err := s.db.Order("archived_at DESC").Limit(limit).Offset(offset).Find(&archives).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to list result archives")
+		return nil, err
+	}
+	return archives, nil
+}
+
+// RestoreResults inserts results read back from an archive. Results already
+// present are skipped, so an interrupted rehydration can be repeated.
+func (s *ArchiveService) RestoreResults(results []SimulationResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	err := s.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&results).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to restore archived results")
+		return err
+	}
+	return nil
+}
+
+// MarkRehydrated records that an archive's results are back in the database
+func (s *ArchiveService) MarkRehydrated(simulationID uuid.UUID) error {
+	err := s.db.Model(&ResultArchive{}).
+		Where("simulation_id = ?", simulationID).
+		Update("rehydrated_at", time.Now()).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to mark results rehydrated")
+		return err
+	}
+	return nil
+}
+
+// Errors
+var (
+	ErrArchiveChanged = fmt.Errorf("results changed while being archived")
+)
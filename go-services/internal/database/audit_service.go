@@ -0,0 +1,81 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AuditFilter narrows audit log queries
+type AuditFilter struct {
+	ActorID        *uuid.UUID
+	ImpersonatorID *uuid.UUID
+	Impersonated   *bool
+	From           *time.Time
+	To             *time.Time
+}
+
+// AuditService provides audit log database operations
+type AuditService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(db *gorm.DB, logger *logrus.Logger) *AuditService {
+	return &AuditService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Record stores an audit log entry
+func (s *AuditService) Record(entry *AuditLog) error {
+	if err := s.db.Create(entry).Error; err != nil {
+		s.logger.WithError(err).WithField("action", entry.Action).Error("Failed to record audit log")
+		return err
+	}
+
+	return nil
+}
+
+// ListAuditLogs retrieves audit log entries newest first
+func (s *AuditService) ListAuditLogs(filter AuditFilter, limit, offset int) ([]AuditLog, int64, error) {
+	query := s.db.Model(&AuditLog{})
+
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.ImpersonatorID != nil {
+		query = query.Where("impersonator_id = ?", *filter.ImpersonatorID)
+	}
+	if filter.Impersonated != nil {
+		query = query.Where("impersonated = ?", *filter.Impersonated)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count audit logs")
+		return nil, 0, err
+	}
+
+	var entries []AuditLog
+	err := query.Order(recentFirst("created_at")).
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list audit logs")
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
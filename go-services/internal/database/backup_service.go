@@ -0,0 +1,123 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BackupTables lists the tables a backup contains, parents before children
+// so a restore satisfies foreign keys
+var BackupTables = []string{
+	"simulations",
+	"power_plants",
+	"buses",
+	"transmission_lines",
+	"storage_units",
+	"simulation_results",
+	"component_metrics",
+	"fault_events",
+	"alerts",
+	"emission_summaries",
+	"result_archives",
+}
+
+// BackupService reads and writes whole simulations for backup and restore
+type BackupService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewBackupService creates a new backup service
+func NewBackupService(db *gorm.DB, logger *logrus.Logger) *BackupService {
+	return &BackupService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SimulationIDs returns the IDs of every simulation
+func (s *BackupService) SimulationIDs() ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := s.db.Model(&Simulation{}).Order("created_at").Pluck("id", &ids).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to list simulation IDs")
+		return nil, err
+	}
+	return ids, nil
+}
+
+// NewRows returns a pointer to an empty slice of the model stored in table
+func (s *BackupService) NewRows(table string) (interface{}, error) {
+	switch table {
+	case "simulations":
+		return &[]Simulation{}, nil
+	case "power_plants":
+		return &[]PowerPlant{}, nil
+	case "buses":
+		return &[]Bus{}, nil
+	case "transmission_lines":
+		return &[]TransmissionLine{}, nil
+	case "storage_units":
+		return &[]StorageUnit{}, nil
+	case "simulation_results":
+		return &[]SimulationResult{}, nil
+	case "component_metrics":
+		return &[]ComponentMetric{}, nil
+	case "fault_events":
+		return &[]FaultEvent{}, nil
+	case "alerts":
+		return &[]Alert{}, nil
+	case "emission_summaries":
+		return &[]EmissionSummary{}, nil
+	case "result_archives":
+		return &[]ResultArchive{}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownTable, table)
+}
+
+// EachBatch reads the rows of table belonging to the given simulations in
+// batches of size, passing each batch to fn as a pointer to a model slice
+func (s *BackupService) EachBatch(table string, simulationIDs []uuid.UUID, size int, fn func(rows interface{}) error) error {
+	rows, err := s.NewRows(table)
+	if err != nil {
+		return err
+	}
+	if len(simulationIDs) == 0 {
+		return nil
+	}
+
+	column := "simulation_id"
+	if table == "simulations" {
+		column = "id"
+	}
+
+	result := s.db.Where(column+" IN ?", simulationIDs).FindInBatches(rows, size, func(tx *gorm.DB, batch int) error {
+		return fn(rows)
+	})
+	if result.Error != nil {
+		s.logger.WithError(result.Error).WithField("table", table).Error("Failed to read backup rows")
+		return result.Error
+	}
+	return nil
+}
+
+// RestoreRows inserts a batch returned by NewRows, skipping rows whose
+// primary key already exists
+func (s *BackupService) RestoreRows(rows interface{}) (int64, error) {
+	result := s.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(rows)
+	if result.Error != nil {
+		s.logger.WithError(result.Error).Error("Failed to restore rows")
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// Errors
+var (
+	ErrUnknownTable = fmt.Errorf("unknown backup table")
+)
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Ways of writing result batches, see SimulationService.SetBulkLoad
+const (
+	// BulkLoadInsert writes batches with multi-row INSERTs
+	BulkLoadInsert = "insert"
+	// BulkLoadCopy streams batches with COPY, which is several times faster
+	// at high tick rates
+	BulkLoadCopy = "copy"
+)
+
+// errCopyUnsupported is returned when the connection is not a pgx one
+var errCopyUnsupported = errors.New("COPY requires the pgx driver")
+
+var (
+	resultCopyColumns = []string{
+		"id", "simulation_id", "timestamp", "tick_number", "total_generation_mw", "total_consumption_mw",
+		"grid_frequency_hz", "grid_voltage_kv", "efficiency_percentage", "fault_count", "metadata",
+	}
+	metricCopyColumns = []string{
+		"id", "simulation_id", "component_type", "component_id", "timestamp",
+		"metric_name", "metric_value", "unit", "metadata", "tick_number",
+	}
+	faultCopyColumns = []string{
+		"id", "simulation_id", "timestamp", "fault_type", "component_id", "component_type",
+		"severity", "description", "resolved_at", "impact_assessment", "tick_number",
+	}
+)
+
+// SetBulkLoad selects how result batches and component metrics are written:
+// BulkLoadInsert (the default) or BulkLoadCopy. Each batch is loaded on its
+// own, and a batch COPY cannot load, such as one resending ticks that are
+// already stored, is written again with inserts without affecting others.
+func (s *SimulationService) SetBulkLoad(mode string) {
+	s.bulkLoad = mode
+}
+
+// copyResultBatch is IngestResultBatch over COPY. COPY cannot upsert, so a
+// batch whose results were ingested before fails on the tick index and is
+// left to the insert path.
+func (s *SimulationService) copyResultBatch(ctx context.Context, simulationID uuid.UUID, results []SimulationResult, metrics []ComponentMetric, faults []FaultEvent, ticks []int) error {
+	return s.copyTx(ctx, func(tx pgx.Tx) error {
+		var faultsRemoved int64
+		if len(ticks) > 0 {
+			if _, err := tx.Exec(ctx, "DELETE FROM component_metrics WHERE simulation_id = $1 AND tick_number = ANY($2)", simulationID, ticks); err != nil {
+				return err
+			}
+			tag, err := tx.Exec(ctx, "DELETE FROM fault_events WHERE simulation_id = $1 AND tick_number = ANY($2)", simulationID, ticks)
+			if err != nil {
+				return err
+			}
+			faultsRemoved = tag.RowsAffected()
+		}
+		if err := copyResults(ctx, tx, results); err != nil {
+			return err
+		}
+		if err := copyMetrics(ctx, tx, metrics); err != nil {
+			return err
+		}
+		if err := copyFaults(ctx, tx, faults); err != nil {
+			return err
+		}
+
+		delta := newSummaryDelta(results, nil, int64(len(faults)), faultsRemoved)
+		return applySummaryDelta(ctx, pgxSummaryExec(tx), simulationID, delta)
+	})
+}
+
+// copyTx runs fn in a transaction on a pgx connection from the pool
+func (s *SimulationService) copyTx(ctx context.Context, fn func(pgx.Tx) error) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errCopyUnsupported
+		}
+		return pgx.BeginFunc(ctx, pgxConn.Conn(), fn)
+	})
+}
+
+func copyResults(ctx context.Context, tx pgx.Tx, results []SimulationResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"simulation_results"}, resultCopyColumns, pgx.CopyFromSlice(len(results), func(i int) ([]any, error) {
+		r := &results[i]
+		if r.ID == uuid.Nil {
+			r.ID = NewID()
+		}
+		return []any{
+			r.ID, r.SimulationID, r.Timestamp, r.TickNumber, r.TotalGenerationMW, r.TotalConsumptionMW,
+			r.GridFrequencyHz, r.GridVoltageKV, r.EfficiencyPercentage, r.FaultCount, r.Metadata,
+		}, nil
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to copy simulation results: %w", err)
+	}
+	return nil
+}
+
+func copyMetrics(ctx context.Context, tx pgx.Tx, metrics []ComponentMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"component_metrics"}, metricCopyColumns, pgx.CopyFromSlice(len(metrics), func(i int) ([]any, error) {
+		m := &metrics[i]
+		if m.ID == uuid.Nil {
+			m.ID = NewID()
+		}
+		return []any{
+			m.ID, m.SimulationID, m.ComponentType, m.ComponentID, m.Timestamp,
+			m.MetricName, m.MetricValue, m.Unit, m.Metadata, m.TickNumber,
+		}, nil
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to copy component metrics: %w", err)
+	}
+	return nil
+}
+
+func copyFaults(ctx context.Context, tx pgx.Tx, faults []FaultEvent) error {
+	if len(faults) == 0 {
+		return nil
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"fault_events"}, faultCopyColumns, pgx.CopyFromSlice(len(faults), func(i int) ([]any, error) {
+		f := &faults[i]
+		if f.ID == uuid.Nil {
+			f.ID = NewID()
+		}
+		return []any{
+			f.ID, f.SimulationID, f.Timestamp, f.FaultType, f.ComponentID, f.ComponentType,
+			f.Severity, f.Description, f.ResolvedAt, f.ImpactAssessment, f.TickNumber,
+		}, nil
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to copy fault events: %w", err)
+	}
+	return nil
+}
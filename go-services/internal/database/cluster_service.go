@@ -0,0 +1,180 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ClusterService provides replica membership and simulation ownership operations
+type ClusterService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewClusterService creates a new cluster service
+func NewClusterService(db *gorm.DB, logger *logrus.Logger) *ClusterService {
+	return &ClusterService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Heartbeat registers a node or refreshes its heartbeat. Heartbeats use the
+// database clock so replicas with skewed clocks agree on liveness.
+func (s *ClusterService) Heartbeat(node *ClusterNode) error {
+	err := s.db.Exec(`
+		INSERT INTO cluster_nodes (id, address, started_at, heartbeat_at)
+		VALUES (?, ?, ?, now())
+		ON CONFLICT (id) DO UPDATE SET
+			address = excluded.address,
+			started_at = excluded.started_at,
+			heartbeat_at = now()`,
+		node.ID, node.Address, node.StartedAt).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("node_id", node.ID).Error("Failed to record heartbeat")
+		return err
+	}
+	return nil
+}
+
+// RemoveNode deletes a node, orphaning the simulations it owns
+func (s *ClusterService) RemoveNode(id string) error {
+	if err := s.db.Where("id = ?", id).Delete(&ClusterNode{}).Error; err != nil {
+		s.logger.WithError(err).WithField("node_id", id).Error("Failed to remove node")
+		return err
+	}
+	return nil
+}
+
+// LiveNodes returns the nodes that sent a heartbeat within ttl
+func (s *ClusterService) LiveNodes(ttl time.Duration) ([]ClusterNode, error) {
+	var nodes []ClusterNode
+	err := s.db.Where("heartbeat_at > now() - ? * interval '1 second'", ttl.Seconds()).
+		Order("id").
+		Find(&nodes).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list live nodes")
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// GetNode returns a node by ID
+func (s *ClusterService) GetNode(id string) (*ClusterNode, error) {
+	var node ClusterNode
+	if err := s.db.Where("id = ?", id).First(&node).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("node_id", id).Error("Failed to get node")
+		return nil, err
+	}
+	return &node, nil
+}
+
+// ClaimSimulation records a node as the owner of a new simulation
+func (s *ClusterService) ClaimSimulation(owner *SimulationOwner) error {
+	if owner.AssignedAt.IsZero() {
+		owner.AssignedAt = time.Now()
+	}
+	if err := s.db.Create(owner).Error; err != nil {
+		s.logger.WithError(err).WithField("simulation_id", owner.SimulationID).Error("Failed to claim simulation")
+		return err
+	}
+	return nil
+}
+
+// SaveSimulationSpec replaces the stored definition of a simulation, provided
+// nodeID still owns it
+func (s *ClusterService) SaveSimulationSpec(simulationID, nodeID string, spec json.RawMessage) error {
+	err := s.db.Model(&SimulationOwner{}).
+		Where("simulation_id = ? AND node_id = ?", simulationID, nodeID).
+		Update("spec", string(spec)).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to save simulation spec")
+		return err
+	}
+	return nil
+}
+
+// GetSimulationOwner returns the ownership record of a simulation
+func (s *ClusterService) GetSimulationOwner(simulationID string) (*SimulationOwner, error) {
+	var owner SimulationOwner
+	if err := s.db.Where("simulation_id = ?", simulationID).First(&owner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to get simulation owner")
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// ReleaseSimulation deletes the ownership record, provided nodeID owns it
+func (s *ClusterService) ReleaseSimulation(simulationID, nodeID string) error {
+	err := s.db.Where("simulation_id = ? AND node_id = ?", simulationID, nodeID).Delete(&SimulationOwner{}).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to release simulation")
+		return err
+	}
+	return nil
+}
+
+// OrphanedSimulations returns simulations owned by nodes outside liveNodeIDs
+func (s *ClusterService) OrphanedSimulations(liveNodeIDs []string) ([]SimulationOwner, error) {
+	var owners []SimulationOwner
+	if len(liveNodeIDs) == 0 {
+		return owners, nil
+	}
+
+	if err := s.db.Where("node_id NOT IN ?", liveNodeIDs).Find(&owners).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to list orphaned simulations")
+		return nil, err
+	}
+	return owners, nil
+}
+
+// TransferSimulation moves a simulation from one node to another, reporting
+// false if fromNode no longer owns it
+func (s *ClusterService) TransferSimulation(simulationID, fromNode, toNode string) (bool, error) {
+	result := s.db.Model(&SimulationOwner{}).
+		Where("simulation_id = ? AND node_id = ?", simulationID, fromNode).
+		Updates(map[string]interface{}{
+			"node_id":     toNode,
+			"epoch":       gorm.Expr("epoch + 1"),
+			"adopted":     false,
+			"assigned_at": time.Now(),
+		})
+	if result.Error != nil {
+		s.logger.WithError(result.Error).WithField("simulation_id", simulationID).Error("Failed to transfer simulation")
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// PendingAdoptions returns simulations transferred to a node that it has not loaded yet
+func (s *ClusterService) PendingAdoptions(nodeID string) ([]SimulationOwner, error) {
+	var owners []SimulationOwner
+	if err := s.db.Where("node_id = ? AND adopted = ?", nodeID, false).Find(&owners).Error; err != nil {
+		s.logger.WithError(err).WithField("node_id", nodeID).Error("Failed to list pending adoptions")
+		return nil, err
+	}
+	return owners, nil
+}
+
+// MarkAdopted records that the owner loaded the simulation. The epoch guards
+// against marking a later transfer.
+func (s *ClusterService) MarkAdopted(simulationID, nodeID string, epoch int64) error {
+	err := s.db.Model(&SimulationOwner{}).
+		Where("simulation_id = ? AND node_id = ? AND epoch = ?", simulationID, nodeID, epoch).
+		Update("adopted", true).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to mark simulation adopted")
+		return err
+	}
+	return nil
+}
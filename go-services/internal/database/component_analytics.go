@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TopComponentsQuery ranks components by an aggregate of one metric
+type TopComponentsQuery struct {
+	Metric        string
+	ComponentType string
+	// One of the Aggregate constants; defaults to max
+	Aggregation string
+	// Rank the lowest values first instead of the highest
+	Ascending bool
+	N         int
+	From      *time.Time
+	To        *time.Time
+}
+
+// ComponentRank is a component's aggregated metric value
+type ComponentRank struct {
+	Rank          int       `json:"rank"`
+	ComponentType string    `json:"component_type"`
+	ComponentID   int       `json:"component_id"`
+	Value         float64   `json:"value"`
+	Samples       int64     `json:"samples"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// PercentileSummary describes the distribution of a result field or metric
+type PercentileSummary struct {
+	Field       string             `json:"field"`
+	Count       int64              `json:"count"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+// ResultPercentileFields lists the result fields percentiles can be computed
+// over. Frequency deviation is measured from the nominal frequency passed to
+// ResultPercentiles.
+var ResultPercentileFields = []string{
+	"grid_frequency_hz",
+	"frequency_deviation_hz",
+	"grid_voltage_kv",
+	"total_generation_mw",
+	"total_consumption_mw",
+	"imbalance_mw",
+	"efficiency_percentage",
+	"fault_count",
+}
+
+// TopComponents returns the n components with the highest (or lowest)
+// aggregated value of a metric
+func (s *SimulationService) TopComponents(ctx context.Context, simulationID uuid.UUID, q TopComponentsQuery) ([]ComponentRank, error) {
+	aggregation := q.Aggregation
+	if aggregation == "" {
+		aggregation = AggregateMax
+	}
+	function, ok := aggregateFunctions[aggregation]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAggregation, aggregation)
+	}
+
+	direction := "DESC"
+	if q.Ascending {
+		direction = "ASC"
+	}
+
+	query := s.db.WithContext(ctx).Model(&ComponentMetric{}).
+		Where("simulation_id = ? AND metric_name = ?", simulationID, q.Metric)
+	if q.ComponentType != "" {
+		query = query.Where("component_type = ?", q.ComponentType)
+	}
+	query = applyTimeRange(query, "timestamp", q.From, q.To)
+
+	var ranks []ComponentRank
+	err := query.Select("component_type, component_id, " + function + "(metric_value) AS value, COUNT(*) AS samples, MAX(timestamp) AS last_seen").
+		Group("component_type, component_id").
+		Order("value " + direction + ", component_type, component_id").
+		Limit(q.N).
+		Scan(&ranks).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to rank components")
+		return nil, err
+	}
+
+	for i := range ranks {
+		ranks[i].Rank = i + 1
+		ranks[i].LastSeen = ranks[i].LastSeen.UTC()
+	}
+	return ranks, nil
+}
+
+// ResultPercentiles summarizes the distribution of a result field over the
+// results still held in the database
+func (s *SimulationService) ResultPercentiles(ctx context.Context, simulationID uuid.UUID, field string, percentiles []float64, nominalFrequencyHz float64, from, to *time.Time) (*PercentileSummary, error) {
+	var column string
+	switch field {
+	case "frequency_deviation_hz":
+		column = "ABS(grid_frequency_hz - " + strconv.FormatFloat(nominalFrequencyHz, 'f', -1, 64) + ")"
+	case "imbalance_mw":
+		column = "(total_generation_mw - total_consumption_mw)"
+	case "grid_frequency_hz", "grid_voltage_kv", "total_generation_mw", "total_consumption_mw", "efficiency_percentage", "fault_count":
+		column = field
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPercentileField, field)
+	}
+
+	query := s.db.WithContext(ctx).Model(&SimulationResult{}).Where("simulation_id = ?", simulationID)
+	query = applyTimeRange(query, "timestamp", from, to)
+
+	summary, err := percentileSummary(query, column, percentiles)
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to compute result percentiles")
+		return nil, err
+	}
+	summary.Field = field
+	return summary, nil
+}
+
+// MetricPercentiles summarizes the distribution of a component metric,
+// optionally limited to one component type
+func (s *SimulationService) MetricPercentiles(ctx context.Context, simulationID uuid.UUID, metric, componentType string, percentiles []float64, from, to *time.Time) (*PercentileSummary, error) {
+	query := s.db.WithContext(ctx).Model(&ComponentMetric{}).
+		Where("simulation_id = ? AND metric_name = ?", simulationID, metric)
+	if componentType != "" {
+		query = query.Where("component_type = ?", componentType)
+	}
+	query = applyTimeRange(query, "timestamp", from, to)
+
+	summary, err := percentileSummary(query, "metric_value", percentiles)
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to compute metric percentiles")
+		return nil, err
+	}
+	summary.Field = metric
+	return summary, nil
+}
+
+// PercentileLabel names a percentile, e.g. 0.95 is p95 and 0.999 is p99.9
+func PercentileLabel(p float64) string {
+	return "p" + strconv.FormatFloat(math.Round(p*1e6)/1e4, 'f', -1, 64)
+}
+
+// percentileSummary computes the count, range, mean and percentiles of a
+// column in one query. Percentiles must be fractions in [0, 1].
+func percentileSummary(query *gorm.DB, column string, percentiles []float64) (*PercentileSummary, error) {
+	selects := []string{"COUNT(" + column + ")", "MIN(" + column + ")", "MAX(" + column + ")", "AVG(" + column + ")"}
+	args := make([]interface{}, 0, len(percentiles))
+	for _, p := range percentiles {
+		if p < 0 || p > 1 || math.IsNaN(p) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPercentile, p)
+		}
+		selects = append(selects, "percentile_cont(?) WITHIN GROUP (ORDER BY "+column+")")
+		args = append(args, p)
+	}
+
+	var count int64
+	var minimum, maximum, mean sql.NullFloat64
+	values := make([]sql.NullFloat64, len(percentiles))
+	dest := []interface{}{&count, &minimum, &maximum, &mean}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+
+	if err := query.Select(strings.Join(selects, ", "), args...).Row().Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	summary := &PercentileSummary{
+		Count:       count,
+		Min:         minimum.Float64,
+		Max:         maximum.Float64,
+		Mean:        mean.Float64,
+		Percentiles: make(map[string]float64, len(percentiles)),
+	}
+	for i, p := range percentiles {
+		summary.Percentiles[PercentileLabel(p)] = values[i].Float64
+	}
+	return summary, nil
+}
+
+// applyTimeRange limits a query to rows between two optional times
+func applyTimeRange(query *gorm.DB, column string, from, to *time.Time) *gorm.DB {
+	if from != nil {
+		query = query.Where(column+" >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where(column+" <= ?", *to)
+	}
+	return query
+}
+
+// Errors
+var (
+	ErrInvalidAggregation     = fmt.Errorf("invalid aggregation")
+	ErrInvalidPercentile      = fmt.Errorf("percentile must be a fraction between 0 and 1")
+	ErrInvalidPercentileField = fmt.Errorf("invalid percentile field")
+)
@@ -0,0 +1,300 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// componentConfig is the part of a simulation's JSON config that is
+// normalized into component tables
+type componentConfig struct {
+	PowerPlants []struct {
+		ID                     string         `json:"id"`
+		Name                   string         `json:"name"`
+		Type                   string         `json:"type"`
+		MaxCapacityMW          float64        `json:"max_capacity_mw"`
+		CurrentOutputMW        float64        `json:"current_output_mw"`
+		Efficiency             float64        `json:"efficiency"`
+		EmissionFactorKgPerMWh float64        `json:"emission_factor_kg_per_mwh"`
+		FuelCostPerMWh         float64        `json:"fuel_cost_per_mwh"`
+		StartupCost            float64        `json:"startup_cost"`
+		OMCostPerMWh           float64        `json:"om_cost_per_mwh"`
+		Location               map[string]any `json:"location"`
+		BusID                  string         `json:"bus_id"`
+		IsOperational          bool           `json:"is_operational"`
+	} `json:"power_plants"`
+	TransmissionLines []struct {
+		ID              string  `json:"id"`
+		FromNode        string  `json:"from_node"`
+		ToNode          string  `json:"to_node"`
+		CapacityMW      float64 `json:"capacity_mw"`
+		LengthKM        float64 `json:"length_km"`
+		ResistancePerKM float64 `json:"resistance_per_km"`
+		ReactancePerKM  float64 `json:"reactance_per_km"`
+		IsOperational   bool    `json:"is_operational"`
+	} `json:"transmission_lines"`
+	StorageUnits []struct {
+		ID                  string         `json:"id"`
+		Name                string         `json:"name"`
+		CapacityMWh         float64        `json:"capacity_mwh"`
+		MaxChargeMW         float64        `json:"max_charge_mw"`
+		MaxDischargeMW      float64        `json:"max_discharge_mw"`
+		RoundTripEfficiency float64        `json:"round_trip_efficiency"`
+		StateOfCharge       float64        `json:"state_of_charge"`
+		Location            map[string]any `json:"location"`
+		BusID               string         `json:"bus_id"`
+		IsOperational       bool           `json:"is_operational"`
+	} `json:"storage_units"`
+	Buses []struct {
+		ID        string         `json:"id"`
+		Name      string         `json:"name"`
+		Type      string         `json:"type"`
+		VoltageKV float64        `json:"voltage_kv"`
+		Location  map[string]any `json:"location"`
+	} `json:"buses"`
+}
+
+// simulationComponents holds the component rows of a simulation
+type simulationComponents struct {
+	PowerPlants       []PowerPlant
+	TransmissionLines []TransmissionLine
+	StorageUnits      []StorageUnit
+	Buses             []Bus
+
+	// Components the config marks as out of service, see markNotOperational
+	offlinePlants []uuid.UUID
+	offlineLines  []uuid.UUID
+	offlineUnits  []uuid.UUID
+}
+
+// normalizeComponents builds the component rows described by a simulation's
+// config. The config names components with strings while the tables number
+// them: plants, lines, storage units and buses are numbered by their
+// position in the config, starting at 1. Line endpoints share one numbering
+// in which buses come first, then plants, then storage units, then any other
+// node a line mentions, so a bus's node number is its bus number.
+func normalizeComponents(simulationID uuid.UUID, config map[string]any) (*simulationComponents, error) {
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var parsed componentConfig
+	if err := json.Unmarshal(encoded, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid simulation config: %w", err)
+	}
+
+	nodes := make(map[string]int)
+	node := func(id string) int {
+		if n, ok := nodes[id]; ok {
+			return n
+		}
+		nodes[id] = len(nodes) + 1
+		return nodes[id]
+	}
+	busNumber := func(id string) *int {
+		if id == "" {
+			return nil
+		}
+		n, ok := nodes[id]
+		if !ok || n > len(parsed.Buses) {
+			return nil
+		}
+		return &n
+	}
+	location := func(location map[string]any) map[string]any {
+		if location == nil {
+			return map[string]any{}
+		}
+		return location
+	}
+
+	for _, bus := range parsed.Buses {
+		node(bus.ID)
+	}
+	for _, plant := range parsed.PowerPlants {
+		node(plant.ID)
+	}
+	for _, unit := range parsed.StorageUnits {
+		node(unit.ID)
+	}
+
+	components := &simulationComponents{}
+	for i, bus := range parsed.Buses {
+		busType := bus.Type
+		if busType == "" {
+			busType = "bus"
+		}
+		components.Buses = append(components.Buses, Bus{
+			SimulationID: simulationID,
+			BusID:        i + 1,
+			Name:         bus.Name,
+			BusType:      busType,
+			VoltageKV:    bus.VoltageKV,
+			Location:     bus.Location,
+		})
+	}
+	for i, plant := range parsed.PowerPlants {
+		components.PowerPlants = append(components.PowerPlants, PowerPlant{
+			ID:                     NewID(),
+			SimulationID:           simulationID,
+			PlantID:                i + 1,
+			Name:                   plant.Name,
+			PlantType:              plant.Type,
+			MaxCapacityMW:          plant.MaxCapacityMW,
+			CurrentOutputMW:        plant.CurrentOutputMW,
+			Efficiency:             plant.Efficiency,
+			Location:               location(plant.Location),
+			BusID:                  busNumber(plant.BusID),
+			EmissionFactorKgPerMWh: plant.EmissionFactorKgPerMWh,
+			FuelCostPerMWh:         plant.FuelCostPerMWh,
+			StartupCost:            plant.StartupCost,
+			OMCostPerMWh:           plant.OMCostPerMWh,
+			IsOperational:          plant.IsOperational,
+		})
+		if !plant.IsOperational {
+			components.offlinePlants = append(components.offlinePlants, components.PowerPlants[i].ID)
+		}
+	}
+	for i, unit := range parsed.StorageUnits {
+		components.StorageUnits = append(components.StorageUnits, StorageUnit{
+			ID:                  NewID(),
+			SimulationID:        simulationID,
+			UnitID:              i + 1,
+			Name:                unit.Name,
+			CapacityMWh:         unit.CapacityMWh,
+			MaxChargeMW:         unit.MaxChargeMW,
+			MaxDischargeMW:      unit.MaxDischargeMW,
+			RoundTripEfficiency: unit.RoundTripEfficiency,
+			StateOfCharge:       unit.StateOfCharge,
+			Location:            location(unit.Location),
+			BusID:               busNumber(unit.BusID),
+			IsOperational:       unit.IsOperational,
+		})
+		if !unit.IsOperational {
+			components.offlineUnits = append(components.offlineUnits, components.StorageUnits[i].ID)
+		}
+	}
+	for i, line := range parsed.TransmissionLines {
+		components.TransmissionLines = append(components.TransmissionLines, TransmissionLine{
+			ID:              NewID(),
+			SimulationID:    simulationID,
+			LineID:          i + 1,
+			FromNode:        node(line.FromNode),
+			ToNode:          node(line.ToNode),
+			CapacityMW:      line.CapacityMW,
+			LengthKM:        line.LengthKM,
+			ResistancePerKM: line.ResistancePerKM,
+			ReactancePerKM:  line.ReactancePerKM,
+			IsOperational:   line.IsOperational,
+		})
+		if !line.IsOperational {
+			components.offlineLines = append(components.offlineLines, components.TransmissionLines[i].ID)
+		}
+	}
+	connectBuses(components)
+
+	return components, nil
+}
+
+// connectBuses lists on each bus the numbers of the plants, storage units
+// and transmission lines attached to it
+func connectBuses(components *simulationComponents) {
+	plants := make(map[int][]int)
+	units := make(map[int][]int)
+	lines := make(map[int][]int)
+	for _, plant := range components.PowerPlants {
+		if plant.BusID != nil {
+			plants[*plant.BusID] = append(plants[*plant.BusID], plant.PlantID)
+		}
+	}
+	for _, unit := range components.StorageUnits {
+		if unit.BusID != nil {
+			units[*unit.BusID] = append(units[*unit.BusID], unit.UnitID)
+		}
+	}
+	// A bus's node number is its bus number
+	for _, line := range components.TransmissionLines {
+		lines[line.FromNode] = append(lines[line.FromNode], line.LineID)
+		if line.ToNode != line.FromNode {
+			lines[line.ToNode] = append(lines[line.ToNode], line.LineID)
+		}
+	}
+
+	numbers := func(n []int) []int {
+		if n == nil {
+			return []int{}
+		}
+		return n
+	}
+	for i := range components.Buses {
+		bus := components.Buses[i].BusID
+		components.Buses[i].ConnectedComponents = map[string]any{
+			"power_plants":       numbers(plants[bus]),
+			"storage_units":      numbers(units[bus]),
+			"transmission_lines": numbers(lines[bus]),
+		}
+	}
+}
+
+// create inserts the component rows. Children are created without
+// associations, which would otherwise save a blank parent simulation for
+// each of them.
+func (c *simulationComponents) create(tx *gorm.DB) error {
+	if len(c.Buses) > 0 {
+		if err := tx.Omit(clause.Associations).Create(&c.Buses).Error; err != nil {
+			return fmt.Errorf("failed to create buses: %w", err)
+		}
+	}
+	if len(c.PowerPlants) > 0 {
+		if err := tx.Omit(clause.Associations).Create(&c.PowerPlants).Error; err != nil {
+			return fmt.Errorf("failed to create power plants: %w", err)
+		}
+	}
+	if len(c.StorageUnits) > 0 {
+		if err := tx.Omit(clause.Associations).Create(&c.StorageUnits).Error; err != nil {
+			return fmt.Errorf("failed to create storage units: %w", err)
+		}
+	}
+	if len(c.TransmissionLines) > 0 {
+		if err := tx.Omit(clause.Associations).Create(&c.TransmissionLines).Error; err != nil {
+			return fmt.Errorf("failed to create transmission lines: %w", err)
+		}
+	}
+	return c.markNotOperational(tx)
+}
+
+// deleteComponents removes all component rows of a simulation
+func deleteComponents(tx *gorm.DB, simulationID uuid.UUID) error {
+	for _, component := range []any{&TransmissionLine{}, &StorageUnit{}, &PowerPlant{}, &Bus{}} {
+		if err := tx.Where("simulation_id = ?", simulationID).Delete(component).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markNotOperational clears is_operational on the components the config
+// marks as out of service. gorm writes the column default in place of false,
+// so they are stored as operational at first.
+func (c *simulationComponents) markNotOperational(tx *gorm.DB) error {
+	if len(c.offlinePlants) > 0 {
+		if err := tx.Model(&PowerPlant{}).Where("id IN ?", c.offlinePlants).Update("is_operational", false).Error; err != nil {
+			return err
+		}
+	}
+	if len(c.offlineLines) > 0 {
+		if err := tx.Model(&TransmissionLine{}).Where("id IN ?", c.offlineLines).Update("is_operational", false).Error; err != nil {
+			return err
+		}
+	}
+	if len(c.offlineUnits) > 0 {
+		if err := tx.Model(&StorageUnit{}).Where("id IN ?", c.offlineUnits).Update("is_operational", false).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -31,8 +31,37 @@ type Config struct {
 	MaxIdleConns int           `mapstructure:"max_idle_conns"`
 	MaxLifetime  time.Duration `mapstructure:"max_lifetime"`
 	MaxIdleTime  time.Duration `mapstructure:"max_idle_time"`
+	QueryTimeout time.Duration `mapstructure:"query_timeout"`
+	IDFormat     string        `mapstructure:"id_format"`
+
+	// PreparedStmt prepares each distinct statement once per connection and
+	// reuses it, saving a parse and plan on every repeated query
+	PreparedStmt bool `mapstructure:"prepared_statements"`
+	// StatementCacheCapacity is how many statements the driver caches per
+	// connection for queries gorm does not prepare itself; zero keeps the
+	// driver's default
+	StatementCacheCapacity int `mapstructure:"statement_cache_capacity"`
+	// SkipDefaultTransaction stops gorm wrapping each single create, update
+	// and delete in a transaction of its own. Multi-statement writes use
+	// explicit transactions either way.
+	SkipDefaultTransaction bool `mapstructure:"skip_default_transaction"`
+	// CreateBatchSize is how many rows a single insert writes when a slice is
+	// created; larger slices are split into several inserts
+	CreateBatchSize int `mapstructure:"create_batch_size"`
+
+	// EncryptionKeys encrypt sensitive fields at rest, given as id:base64
+	// key; the first one encrypts and all of them decrypt, see NewKeyring.
+	// Fields are stored unencrypted when there are none.
+	EncryptionKeys []string `mapstructure:"encryption_keys"`
+	// EncryptionKeysFile holds further keys, one per line, after the ones
+	// in EncryptionKeys
+	EncryptionKeysFile string `mapstructure:"encryption_keys_file"`
 }
 
+// defaultCreateBatchSize keeps inserts of large slices well below the
+// protocol's limit on bind parameters
+const defaultCreateBatchSize = 500
+
 // DefaultConfig returns default database configuration
 func DefaultConfig() Config {
 	return Config{
@@ -46,6 +75,11 @@ func DefaultConfig() Config {
 		MaxIdleConns: 5,
 		MaxLifetime:  time.Hour,
 		MaxIdleTime:  time.Minute * 30,
+		QueryTimeout: 30 * time.Second,
+		IDFormat:     IDFormatUUIDv7,
+
+		PreparedStmt:    true,
+		CreateBatchSize: defaultCreateBatchSize,
 	}
 }
 
@@ -58,6 +92,13 @@ type Connection struct {
 
 // NewConnection creates a new database connection
 func NewConnection(config Config, logger *logrus.Logger) (*Connection, error) {
+	if err := SetIDFormat(config.IDFormat); err != nil {
+		return nil, err
+	}
+	if err := setEncryptionKeys(config); err != nil {
+		return nil, err
+	}
+
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		config.Host,
@@ -67,6 +108,13 @@ func NewConnection(config Config, logger *logrus.Logger) (*Connection, error) {
 		config.Database,
 		config.SSLMode,
 	)
+	if config.StatementCacheCapacity > 0 {
+		dsn += fmt.Sprintf(" statement_cache_capacity=%d", config.StatementCacheCapacity)
+	}
+	createBatchSize := config.CreateBatchSize
+	if createBatchSize <= 0 {
+		createBatchSize = defaultCreateBatchSize
+	}
 
 	// Configure GORM logger
 	var gormLogger gormlogger.Interface
@@ -90,10 +138,18 @@ func NewConnection(config Config, logger *logrus.Logger) (*Connection, error) {
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
+		PrepareStmt:            config.PreparedStmt,
+		SkipDefaultTransaction: config.SkipDefaultTransaction,
+		CreateBatchSize:        createBatchSize,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if config.QueryTimeout > 0 {
+		if err := registerQueryTimeout(db, config.QueryTimeout); err != nil {
+			return nil, fmt.Errorf("failed to register query timeout: %w", err)
+		}
+	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -126,11 +182,41 @@ func (c *Connection) Migrate() error {
 		&Organization{},
 		&Simulation{},
 		&PowerPlant{},
+		&Bus{},
 		&TransmissionLine{},
+		&StorageUnit{},
 		&SimulationResult{},
+		&SimulationSummary{},
+		&StabilityScore{},
 		&ComponentMetric{},
 		&FaultEvent{},
 		&Alert{},
+		&EmissionSummary{},
+		&WebhookSubscription{},
+		&NotificationChannel{},
+		&AlertRoute{},
+		&AlertNotification{},
+		&AlertSilence{},
+		&NotificationPreference{},
+		&NotificationDigestItem{},
+		&Artifact{},
+		&AuditLog{},
+		&PredictionSnapshot{},
+		&OutboxEvent{},
+		&DistributedLock{},
+		&ClusterNode{},
+		&SimulationOwner{},
+		&ResultArchive{},
+		&SimulationSnapshot{},
+		&UsageCounter{},
+		&Tag{},
+		&TagAssignment{},
+		&Dashboard{},
+		&DashboardShare{},
+		&DashboardRevision{},
+		&ShareLink{},
+		&LoginAttempt{},
+		&UserSession{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
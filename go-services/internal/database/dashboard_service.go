@@ -0,0 +1,218 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Dashboard visibilities
+const (
+	// Only the owner and users it is shared with
+	DashboardPrivate = "private"
+	// Every member of the owner's organization
+	DashboardOrganization = "organization"
+	// Every authenticated user
+	DashboardPublic = "public"
+)
+
+// Dashboard share permissions
+const (
+	DashboardView = "view"
+	DashboardEdit = "edit"
+)
+
+// DashboardService provides saved view and dashboard database operations
+type DashboardService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewDashboardService creates a new dashboard service
+func NewDashboardService(db *gorm.DB, logger *logrus.Logger) *DashboardService {
+	return &DashboardService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateDashboard creates a dashboard with its shares and records it as revision 1
+func (s *DashboardService) CreateDashboard(dashboard *Dashboard) error {
+	dashboard.Version = 1
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dashboard).Error; err != nil {
+			return err
+		}
+		return tx.Create(newDashboardRevision(dashboard, dashboard.OwnerID)).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to create dashboard")
+		return err
+	}
+
+	return nil
+}
+
+// GetDashboard retrieves a dashboard and its shares by ID
+func (s *DashboardService) GetDashboard(id uuid.UUID) (*Dashboard, error) {
+	var dashboard Dashboard
+	if err := s.db.Preload("Shares").Where("id = ?", id).Take(&dashboard).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("dashboard_id", id).Error("Failed to get dashboard")
+		return nil, err
+	}
+
+	return &dashboard, nil
+}
+
+// ListDashboards retrieves the dashboards a user can see, most recently
+// updated first: their own, those shared with them, those of their
+// organization and public ones
+func (s *DashboardService) ListDashboards(userID uuid.UUID, organizationID *uuid.UUID, limit, offset int) ([]Dashboard, int64, error) {
+	shared := s.db.Model(&DashboardShare{}).
+		Select("dashboard_id").
+		Where("user_id = ?", userID)
+	visible := s.db.Where("owner_id = ?", userID).
+		Or("visibility = ?", DashboardPublic).
+		Or("id IN (?)", shared)
+	if organizationID != nil {
+		visible = visible.Or("visibility = ? AND organization_id = ?", DashboardOrganization, *organizationID)
+	}
+	query := s.db.Model(&Dashboard{}).Where(visible)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count dashboards")
+		return nil, 0, err
+	}
+
+	var dashboards []Dashboard
+	err := query.Preload("Shares").
+		Order("updated_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&dashboards).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list dashboards")
+		return nil, 0, err
+	}
+
+	return dashboards, total, nil
+}
+
+// UpdateDashboard saves a dashboard that is still at expectedVersion and
+// records the result as a new revision. Shares are not changed; see
+// SetDashboardShares.
+func (s *DashboardService) UpdateDashboard(dashboard *Dashboard, expectedVersion int64, editorID uuid.UUID) error {
+	dashboard.UpdatedAt = time.Now()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, dashboard, &dashboard.Version, expectedVersion); err != nil {
+			return err
+		}
+		return tx.Create(newDashboardRevision(dashboard, editorID)).Error
+	})
+	if err != nil {
+		if err != ErrVersionConflict {
+			s.logger.WithError(err).WithField("dashboard_id", dashboard.ID).Error("Failed to update dashboard")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetDashboardShares replaces the users a dashboard is shared with
+func (s *DashboardService) SetDashboardShares(id uuid.UUID, shares []DashboardShare) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dashboard_id = ?", id).Delete(&DashboardShare{}).Error; err != nil {
+			return err
+		}
+		if len(shares) == 0 {
+			return nil
+		}
+		for i := range shares {
+			shares[i].DashboardID = id
+		}
+		return tx.Create(&shares).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("dashboard_id", id).Error("Failed to set dashboard shares")
+		return err
+	}
+
+	return nil
+}
+
+// DeleteDashboard deletes a dashboard with its shares and revisions
+func (s *DashboardService) DeleteDashboard(id uuid.UUID) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dashboard_id = ?", id).Delete(&DashboardShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("dashboard_id = ?", id).Delete(&DashboardRevision{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Dashboard{}, "id = ?", id).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("dashboard_id", id).Error("Failed to delete dashboard")
+		return err
+	}
+
+	s.logger.WithField("dashboard_id", id).Info("Dashboard deleted")
+	return nil
+}
+
+// ListDashboardRevisions retrieves a dashboard's revisions, newest first,
+// without their documents
+func (s *DashboardService) ListDashboardRevisions(id uuid.UUID, limit, offset int) ([]DashboardRevision, int64, error) {
+	query := s.db.Model(&DashboardRevision{}).Where("dashboard_id = ?", id)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		s.logger.WithError(err).WithField("dashboard_id", id).Error("Failed to count dashboard revisions")
+		return nil, 0, err
+	}
+
+	var revisions []DashboardRevision
+	err := query.Omit("document").
+		Order("version DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&revisions).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("dashboard_id", id).Error("Failed to list dashboard revisions")
+		return nil, 0, err
+	}
+
+	return revisions, total, nil
+}
+
+// GetDashboardRevision retrieves one revision of a dashboard
+func (s *DashboardService) GetDashboardRevision(id uuid.UUID, version int64) (*DashboardRevision, error) {
+	var revision DashboardRevision
+	if err := s.db.Where("dashboard_id = ? AND version = ?", id, version).Take(&revision).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("dashboard_id", id).Error("Failed to get dashboard revision")
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+func newDashboardRevision(dashboard *Dashboard, editorID uuid.UUID) *DashboardRevision {
+	return &DashboardRevision{
+		DashboardID: dashboard.ID,
+		Version:     dashboard.Version,
+		Name:        dashboard.Name,
+		Description: dashboard.Description,
+		Document:    dashboard.Document,
+		EditedBy:    editorID,
+	}
+}
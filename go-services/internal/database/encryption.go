@@ -0,0 +1,388 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Fields tagged with serializer:encrypted are encrypted with AES-GCM before
+// they are written and decrypted when they are read. Values written before
+// encryption was configured are read as they are and encrypted by
+// ReencryptFields.
+
+// encryptedPrefix starts every encrypted value, followed by the key ID,
+// a colon and the base64 nonce and ciphertext
+const encryptedPrefix = "enc:v1:"
+
+// ErrEncryptionKeyMissing is returned when a value was encrypted with a key
+// that is not configured
+var ErrEncryptionKeyMissing = errors.New("encryption key is not configured")
+
+// encryptedColumn is a column holding an encrypted field
+type encryptedColumn struct {
+	table  string
+	column string
+	// jsonb columns hold the ciphertext as a JSON string
+	json bool
+}
+
+// encryptedColumns lists every field tagged with serializer:encrypted
+var encryptedColumns = []encryptedColumn{
+	{table: "users", column: "metadata", json: true},
+	{table: "webhook_subscriptions", column: "secret"},
+	{table: "notification_channels", column: "webhook_url"},
+	{table: "notification_channels", column: "routing_key"},
+}
+
+// Keyring holds the keys fields are encrypted with. The active key
+// encrypts; every key decrypts, so values written before a rotation stay
+// readable until they are re-encrypted.
+type Keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+var keyring atomic.Pointer[Keyring]
+
+func init() {
+	schema.RegisterSerializer("encrypted", encryptedSerializer{})
+}
+
+// NewKeyring parses keys given as "id:base64 key", with 16, 24 or 32 byte
+// AES keys. The first key is the active one; rotate by adding a new key in
+// front of the old ones. Without keys the keyring is nil, which leaves
+// fields unencrypted.
+func NewKeyring(keys []string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	ring := &Keyring{keys: make(map[string]cipher.AEAD, len(keys))}
+	for i, entry := range keys {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("encryption key %d must be given as id:base64 key", i+1)
+		}
+		if _, exists := ring.keys[id]; exists {
+			return nil, fmt.Errorf("encryption key %s is given twice", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s is not valid base64: %w", id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s: %w", id, err)
+		}
+		ring.keys[id] = aead
+		if i == 0 {
+			ring.active = id
+		}
+	}
+	return ring, nil
+}
+
+// ReadKeyFile reads encryption keys from a file with one id:base64 key per
+// line, such as a mounted secret. Blank lines and lines starting with # are
+// skipped.
+func ReadKeyFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open encryption key file: %w", err)
+	}
+	defer file.Close()
+
+	var keys []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read encryption key file: %w", err)
+	}
+	return keys, nil
+}
+
+// SetKeyring sets the keys encrypted fields are written and read with. A
+// nil keyring writes fields unencrypted.
+func SetKeyring(ring *Keyring) {
+	keyring.Store(ring)
+}
+
+// FieldEncryption reports whether encrypted fields are written encrypted
+func FieldEncryption() bool {
+	return keyring.Load() != nil
+}
+
+// encrypt seals a plaintext with the active key
+func (k *Keyring) encrypt(plaintext []byte) (string, error) {
+	aead := k.keys[k.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedPrefix + k.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt opens a value written by encrypt
+func (k *Keyring) decrypt(value string) ([]byte, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return nil, errors.New("malformed encrypted value")
+	}
+	if k == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptionKeyMissing, id)
+	}
+	aead, exists := k.keys[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptionKeyMissing, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value with key %s: %w", id, err)
+	}
+	return plaintext, nil
+}
+
+// encryptedSerializer encrypts string fields as they are and other fields
+// as JSON
+type encryptedSerializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	if dbValue != nil {
+		var stored string
+		switch v := dbValue.(type) {
+		case []byte:
+			stored = string(v)
+		case string:
+			stored = v
+		default:
+			return fmt.Errorf("failed to scan encrypted value: %#v", dbValue)
+		}
+
+		isString := field.FieldType.Kind() == reflect.String
+		// Encrypted jsonb values are JSON strings
+		if !isString && strings.HasPrefix(stored, `"`+encryptedPrefix) {
+			if err := json.Unmarshal([]byte(stored), &stored); err != nil {
+				return fmt.Errorf("failed to scan encrypted value: %w", err)
+			}
+		}
+
+		plaintext := []byte(stored)
+		if strings.HasPrefix(stored, encryptedPrefix) {
+			var err error
+			if plaintext, err = keyring.Load().decrypt(stored); err != nil {
+				return err
+			}
+		}
+
+		if isString {
+			fieldValue.Elem().SetString(string(plaintext))
+		} else if len(plaintext) > 0 {
+			if err := json.Unmarshal(plaintext, fieldValue.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface. Empty values are
+// stored as they are, so they still read as unset.
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	ring := keyring.Load()
+
+	if text, ok := fieldValue.(string); ok {
+		if text == "" || ring == nil {
+			return text, nil
+		}
+		return ring.encrypt([]byte(text))
+	}
+
+	plaintext, err := json.Marshal(fieldValue)
+	if err != nil {
+		return nil, err
+	}
+	if string(plaintext) == "null" {
+		return nil, nil
+	}
+	if ring == nil {
+		return string(plaintext), nil
+	}
+	sealed, err := ring.encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	quoted, err := json.Marshal(sealed)
+	return string(quoted), err
+}
+
+// EncryptionService re-encrypts stored fields after encryption is enabled
+// or its key rotated
+type EncryptionService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewEncryptionService creates a new encryption service
+func NewEncryptionService(db *gorm.DB, logger *logrus.Logger) *EncryptionService {
+	return &EncryptionService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Reencryption counts the values a re-encryption rewrote per table and column
+type Reencryption struct {
+	Rewritten map[string]int `json:"rewritten"`
+}
+
+// ReencryptFields encrypts every stored value of an encrypted field that is
+// unencrypted or encrypted with a key other than the active one. Once it
+// finished, keys other than the active one can be removed.
+func (s *EncryptionService) ReencryptFields(ctx context.Context) (*Reencryption, error) {
+	ring := keyring.Load()
+	if ring == nil {
+		return nil, errors.New("field encryption is not configured")
+	}
+
+	report := &Reencryption{Rewritten: make(map[string]int)}
+	for _, col := range encryptedColumns {
+		count, err := s.reencryptColumn(ctx, ring, col)
+		report.Rewritten[col.table+"."+col.column] = count
+		if err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"table":  col.table,
+				"column": col.column,
+			}).Error("Failed to re-encrypt column")
+			return report, err
+		}
+	}
+
+	s.logger.WithField("rewritten", report.Rewritten).Info("Encrypted fields re-encrypted")
+	return report, nil
+}
+
+// reencryptBatchSize is how many rows are read per query while re-encrypting
+const reencryptBatchSize = 500
+
+func (s *EncryptionService) reencryptColumn(ctx context.Context, ring *Keyring, col encryptedColumn) (int, error) {
+	current := encryptedPrefix + ring.active + ":%"
+	stale := fmt.Sprintf("%s <> '' AND %s NOT LIKE ?", col.column, col.column)
+	if col.json {
+		current = `"` + current
+		stale = fmt.Sprintf("jsonb_typeof(%s) <> 'null' AND %s::text NOT LIKE ?", col.column, col.column)
+	}
+
+	rewritten := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return rewritten, err
+		}
+
+		var rows []struct {
+			ID    uuid.UUID
+			Value string
+		}
+		err := s.db.WithContext(ctx).Table(col.table).
+			Select(fmt.Sprintf("id, %s::text AS value", col.column)).
+			Where(col.column+" IS NOT NULL AND "+stale, current).
+			Limit(reencryptBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return rewritten, err
+		}
+		if len(rows) == 0 {
+			return rewritten, nil
+		}
+
+		for _, row := range rows {
+			stored := row.Value
+			if col.json && strings.HasPrefix(stored, `"`+encryptedPrefix) {
+				if err := json.Unmarshal([]byte(stored), &stored); err != nil {
+					return rewritten, err
+				}
+			}
+			plaintext := []byte(stored)
+			if strings.HasPrefix(stored, encryptedPrefix) {
+				if plaintext, err = ring.decrypt(stored); err != nil {
+					return rewritten, fmt.Errorf("row %s: %w", row.ID, err)
+				}
+			}
+
+			sealed, err := ring.encrypt(plaintext)
+			if err != nil {
+				return rewritten, err
+			}
+			var value interface{} = sealed
+			if col.json {
+				quoted, err := json.Marshal(sealed)
+				if err != nil {
+					return rewritten, err
+				}
+				value = gorm.Expr("?::jsonb", string(quoted))
+			}
+
+			err = s.db.WithContext(ctx).Table(col.table).
+				Where("id = ? AND "+col.column+"::text = ?", row.ID, row.Value).
+				UpdateColumn(col.column, value).Error
+			if err != nil {
+				return rewritten, err
+			}
+			rewritten++
+		}
+	}
+}
+
+// setEncryptionKeys sets the keyring from a connection config
+func setEncryptionKeys(config Config) error {
+	keys := config.EncryptionKeys
+	if config.EncryptionKeysFile != "" {
+		fromFile, err := ReadKeyFile(config.EncryptionKeysFile)
+		if err != nil {
+			return err
+		}
+		keys = append(append([]string(nil), keys...), fromFile...)
+	}
+
+	ring, err := NewKeyring(keys)
+	if err != nil {
+		return err
+	}
+	SetKeyring(ring)
+	return nil
+}
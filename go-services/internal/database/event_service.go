@@ -0,0 +1,342 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Event statuses accepted by EventFilter.Status. Fault events are never acknowledged.
+const (
+	EventStatusActive       = "active"
+	EventStatusAcknowledged = "acknowledged"
+	EventStatusResolved     = "resolved"
+)
+
+// EventFilter narrows fault event and alert queries
+type EventFilter struct {
+	Severities  []string
+	Types       []string
+	ComponentID *int // fault events only
+	Status      string
+	From        *time.Time
+	To          *time.Time
+}
+
+// EventCursor marks a position in a newest-first event listing. Pages are
+// keyed on (time, id) so they stay stable while new events are appended.
+type EventCursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+// Encode returns the opaque string form of the cursor
+func (c EventCursor) Encode() string {
+	raw := c.Time.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeEventCursor parses a cursor produced by EventCursor.Encode
+func DecodeEventCursor(value string) (*EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	timestamp, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &EventCursor{Time: t, ID: parsedID}, nil
+}
+
+// SeverityCount is the number of events with a given severity
+type SeverityCount struct {
+	Severity string `json:"severity"`
+	Count    int64  `json:"count"`
+}
+
+// ListFaultEvents retrieves a page of fault events newest first. The returned
+// cursor is nil when there are no further pages.
+func (s *SimulationService) ListFaultEvents(ctx context.Context, simulationID uuid.UUID, filter EventFilter, cursor *EventCursor, limit int) ([]FaultEvent, *EventCursor, error) {
+	query, err := s.faultEventQuery(ctx, simulationID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var events []FaultEvent
+	err = pageQuery(query, "timestamp", cursor, limit).Find(&events).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list fault events")
+		return nil, nil, err
+	}
+
+	if len(events) <= limit {
+		return events, nil, nil
+	}
+	events = events[:limit]
+	last := events[limit-1]
+	return events, &EventCursor{Time: last.Timestamp, ID: last.ID}, nil
+}
+
+// CountFaultEventsBySeverity counts matching fault events grouped by severity
+func (s *SimulationService) CountFaultEventsBySeverity(ctx context.Context, simulationID uuid.UUID, filter EventFilter) ([]SeverityCount, error) {
+	query, err := s.faultEventQuery(ctx, simulationID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := countBySeverity(query)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to count fault events")
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// FaultEventsOverlapping returns the fault events that were active at any
+// point within a window, oldest first: those raised before its end and not
+// resolved before its start. Nil bounds leave that side open.
+func (s *SimulationService) FaultEventsOverlapping(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]FaultEvent, error) {
+	query := s.db.WithContext(ctx).Where("simulation_id = ?", simulationID)
+	if from != nil {
+		query = query.Where("resolved_at IS NULL OR resolved_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("timestamp <= ?", *to)
+	}
+
+	var events []FaultEvent
+	if err := query.Order("timestamp ASC, id ASC").Find(&events).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to get fault events")
+		return nil, err
+	}
+	return events, nil
+}
+
+// ListAlerts retrieves a page of alerts newest first. The returned cursor is
+// nil when there are no further pages.
+func (s *SimulationService) ListAlerts(ctx context.Context, simulationID uuid.UUID, filter EventFilter, cursor *EventCursor, limit int) ([]Alert, *EventCursor, error) {
+	query, err := s.alertQuery(ctx, simulationID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var alerts []Alert
+	err = pageQuery(query, "triggered_at", cursor, limit).Find(&alerts).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list alerts")
+		return nil, nil, err
+	}
+
+	if len(alerts) <= limit {
+		return alerts, nil, nil
+	}
+	alerts = alerts[:limit]
+	last := alerts[limit-1]
+	return alerts, &EventCursor{Time: last.TriggeredAt, ID: last.ID}, nil
+}
+
+// CountAlertsBySeverity counts matching alerts grouped by severity
+func (s *SimulationService) CountAlertsBySeverity(ctx context.Context, simulationID uuid.UUID, filter EventFilter) ([]SeverityCount, error) {
+	query, err := s.alertQuery(ctx, simulationID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := countBySeverity(query)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to count alerts")
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetAlert retrieves an alert by ID
+func (s *SimulationService) GetAlert(ctx context.Context, id uuid.UUID) (*Alert, error) {
+	var alert Alert
+
+	err := s.db.WithContext(ctx).First(&alert, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get alert")
+		return nil, err
+	}
+
+	return &alert, nil
+}
+
+// AcknowledgeAlerts acknowledges the alerts among ids that are neither
+// acknowledged nor resolved yet, returning the ones it changed. actor is nil
+// for anonymous requests.
+func (s *SimulationService) AcknowledgeAlerts(ctx context.Context, ids []uuid.UUID, actor *uuid.UUID) ([]Alert, error) {
+	var alerts []Alert
+	now := time.Now()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND acknowledged_at IS NULL AND resolved_at IS NULL", ids).
+			Find(&alerts).Error
+		if err != nil || len(alerts) == 0 {
+			return err
+		}
+
+		acknowledged := make([]uuid.UUID, len(alerts))
+		for i := range alerts {
+			alerts[i].AcknowledgedAt = &now
+			alerts[i].AcknowledgedBy = actor
+			acknowledged[i] = alerts[i].ID
+		}
+
+		return tx.Model(&Alert{}).
+			Where("id IN ?", acknowledged).
+			Updates(map[string]interface{}{"acknowledged_at": now, "acknowledged_by": actor}).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to acknowledge alerts")
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// ResolveAlert resolves an alert, returning nil when it does not exist and
+// ErrAlertResolved when it was already resolved
+func (s *SimulationService) ResolveAlert(ctx context.Context, id uuid.UUID, actor *uuid.UUID) (*Alert, error) {
+	var alert Alert
+	now := time.Now()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&alert, "id = ?", id).Error
+		if err != nil {
+			return err
+		}
+		if alert.ResolvedAt != nil {
+			return ErrAlertResolved
+		}
+
+		alert.ResolvedAt = &now
+		alert.ResolvedBy = actor
+		return tx.Model(&Alert{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"resolved_at": now, "resolved_by": actor}).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != ErrAlertResolved {
+			s.logger.WithError(err).Error("Failed to resolve alert")
+		}
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"alert_id":      alert.ID,
+		"simulation_id": alert.SimulationID,
+	}).Info("Alert resolved")
+
+	return &alert, nil
+}
+
+func (s *SimulationService) faultEventQuery(ctx context.Context, simulationID uuid.UUID, filter EventFilter) (*gorm.DB, error) {
+	query := s.db.WithContext(ctx).Model(&FaultEvent{}).Where("simulation_id = ?", simulationID)
+	query = applyEventFilter(query, filter, "fault_type", "timestamp")
+
+	if filter.ComponentID != nil {
+		query = query.Where("component_id = ?", *filter.ComponentID)
+	}
+
+	switch filter.Status {
+	case "":
+	case EventStatusActive:
+		query = query.Where("resolved_at IS NULL")
+	case EventStatusResolved:
+		query = query.Where("resolved_at IS NOT NULL")
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEventStatus, filter.Status)
+	}
+
+	return query, nil
+}
+
+func (s *SimulationService) alertQuery(ctx context.Context, simulationID uuid.UUID, filter EventFilter) (*gorm.DB, error) {
+	query := s.db.WithContext(ctx).Model(&Alert{}).Where("simulation_id = ?", simulationID)
+	query = applyEventFilter(query, filter, "alert_type", "triggered_at")
+
+	switch filter.Status {
+	case "":
+	case EventStatusActive:
+		query = query.Where("resolved_at IS NULL")
+	case EventStatusAcknowledged:
+		query = query.Where("resolved_at IS NULL AND acknowledged_at IS NOT NULL")
+	case EventStatusResolved:
+		query = query.Where("resolved_at IS NOT NULL")
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEventStatus, filter.Status)
+	}
+
+	return query, nil
+}
+
+func applyEventFilter(query *gorm.DB, filter EventFilter, typeColumn, timeColumn string) *gorm.DB {
+	if len(filter.Severities) > 0 {
+		query = query.Where("severity IN ?", filter.Severities)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where(typeColumn+" IN ?", filter.Types)
+	}
+	if filter.From != nil {
+		query = query.Where(timeColumn+" >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where(timeColumn+" <= ?", *filter.To)
+	}
+	return query
+}
+
+// pageQuery orders newest first and fetches one row past the limit so callers
+// can tell whether another page exists
+func pageQuery(query *gorm.DB, timeColumn string, cursor *EventCursor, limit int) *gorm.DB {
+	if cursor != nil {
+		query = query.Where("("+timeColumn+", id) < (?, ?)", cursor.Time, cursor.ID)
+	}
+	return query.Order(timeColumn + " DESC").Order("id DESC").Limit(limit + 1)
+}
+
+func countBySeverity(query *gorm.DB) ([]SeverityCount, error) {
+	var counts []SeverityCount
+	err := query.Select("severity, COUNT(*) AS count").
+		Group("severity").
+		Order("count DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Errors
+var (
+	ErrInvalidCursor      = fmt.Errorf("invalid cursor")
+	ErrInvalidEventStatus = fmt.Errorf("invalid status filter")
+	ErrAlertResolved      = fmt.Errorf("alert is already resolved")
+)
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"sync/atomic"
+
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/ids"
+)
+
+// Supported primary key formats. All formats are stored in uuid columns, so
+// existing UUIDv4 rows remain valid after switching.
+const (
+	IDFormatUUIDv4 = ids.FormatUUIDv4
+	IDFormatUUIDv7 = ids.FormatUUIDv7
+	IDFormatULID   = ids.FormatULID
+)
+
+var idFormat atomic.Value
+
+func init() {
+	idFormat.Store(IDFormatUUIDv7)
+}
+
+// SetIDFormat selects the generator used for new primary keys. It is also
+// the default generator of the ids package, so simulations created in the
+// orchestrator get IDs in the same format as the rows stored for them.
+func SetIDFormat(format string) error {
+	if format == "" {
+		format = IDFormatUUIDv7
+	}
+	generator, err := ids.ForFormat(format)
+	if err != nil {
+		return err
+	}
+
+	idFormat.Store(format)
+	ids.SetDefault(generator)
+	return nil
+}
+
+// IDFormat returns the configured primary key format
+func IDFormat() string {
+	return idFormat.Load().(string)
+}
+
+// SortableIDs reports whether new IDs sort by creation time
+func SortableIDs() bool {
+	return ids.Default().Sortable()
+}
+
+// NewID generates a primary key in the configured format
+func NewID() uuid.UUID {
+	return ids.New()
+}
+
+// recentFirst returns the ordering for newest-first listings. With sortable IDs
+// the primary key index already follows creation order. Rows created before the
+// switch keep their random v4 keys and do not sort by age.
+func recentFirst(timestampColumn string) string {
+	if SortableIDs() {
+		return "id DESC"
+	}
+	return timestampColumn + " DESC"
+}
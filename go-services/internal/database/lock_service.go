@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"voltedge/go-services/internal/lock"
+)
+
+// LeaseLocker implements lock.Locker with expiring rows in the
+// distributed_locks table. Held leases are renewed in the background, so a
+// replica that dies loses its locks once the TTL runs out. Row leases are used
+// rather than advisory locks because CockroachDB does not enforce the latter.
+type LeaseLocker struct {
+	db       *gorm.DB
+	logger   *logrus.Logger
+	ttl      time.Duration
+	instance string
+}
+
+// NewLeaseLocker creates a new database-backed locker
+func NewLeaseLocker(db *gorm.DB, logger *logrus.Logger, ttl time.Duration) *LeaseLocker {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+
+	host, _ := os.Hostname()
+	return &LeaseLocker{
+		db:       db,
+		logger:   logger,
+		ttl:      ttl,
+		instance: fmt.Sprintf("%s/%d", host, os.Getpid()),
+	}
+}
+
+// TryAcquire takes the named lock if it is free or its previous lease expired
+func (l *LeaseLocker) TryAcquire(ctx context.Context, name string) (lock.Lease, bool, error) {
+	token := uuid.New().String()
+
+	// Expiry is computed from the database clock so replicas with skewed
+	// clocks agree on when a lease runs out
+	result := l.db.WithContext(ctx).Exec(`
+		INSERT INTO distributed_locks (name, holder, instance, acquired_at, expires_at)
+		VALUES (?, ?, ?, now(), now() + ? * interval '1 second')
+		ON CONFLICT (name) DO UPDATE SET
+			holder = excluded.holder,
+			instance = excluded.instance,
+			acquired_at = excluded.acquired_at,
+			expires_at = excluded.expires_at
+		WHERE distributed_locks.expires_at < now()`,
+		name, token, l.instance, l.ttl.Seconds())
+	if result.Error != nil {
+		l.logger.WithError(result.Error).WithField("lock", name).Error("Failed to acquire lock")
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, nil
+	}
+
+	lease := &dbLease{
+		locker:  l,
+		name:    name,
+		token:   token,
+		renewed: time.Now(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go lease.heartbeat()
+	return lease, true, nil
+}
+
+// dbLease is a lock held in the distributed_locks table
+type dbLease struct {
+	locker  *LeaseLocker
+	name    string
+	token   string
+	renewed time.Time
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// heartbeat extends the lease every third of the TTL until it is released,
+// giving it up if the row was taken over or could not be renewed in time
+func (l *dbLease) heartbeat() {
+	ticker := time.NewTicker(l.locker.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		held, err := l.renew()
+		switch {
+		case err == nil && held:
+			l.renewed = time.Now()
+			continue
+		case err == nil:
+			l.locker.logger.WithField("lock", l.name).Warn("Lock was taken over by another instance")
+		case time.Since(l.renewed) < l.locker.ttl:
+			l.locker.logger.WithError(err).WithField("lock", l.name).Warn("Failed to renew lock, retrying")
+			continue
+		default:
+			l.locker.logger.WithError(err).WithField("lock", l.name).Error("Lock expired before it could be renewed")
+		}
+
+		l.once.Do(func() { close(l.done) })
+		return
+	}
+}
+
+func (l *dbLease) renew() (bool, error) {
+	result := l.locker.db.Exec(
+		"UPDATE distributed_locks SET expires_at = now() + ? * interval '1 second' WHERE name = ? AND holder = ?",
+		l.locker.ttl.Seconds(), l.name, l.token)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Release deletes the lock row if this lease still holds it
+func (l *dbLease) Release() error {
+	var err error
+	l.once.Do(func() {
+		close(l.stop)
+		close(l.done)
+
+		err = l.locker.db.Where("name = ? AND holder = ?", l.name, l.token).Delete(&DistributedLock{}).Error
+		if err != nil {
+			l.locker.logger.WithError(err).WithField("lock", l.name).Error("Failed to release lock")
+		}
+	})
+	return err
+}
+
+func (l *dbLease) Done() <-chan struct{} {
+	return l.done
+}
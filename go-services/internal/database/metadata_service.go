@@ -0,0 +1,204 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// metadataTables lists the tables with a jsonb metadata column. Encrypted
+// metadata, such as that of users, is left out: its column holds ciphertext,
+// whose size says nothing about the metadata and which cannot be moved to an
+// artifact as JSON.
+var metadataTables = []string{
+	"simulations",
+	"simulation_results",
+	"component_metrics",
+	"alerts",
+}
+
+// MetadataTables returns the tables whose metadata can be inspected and migrated
+func MetadataTables() []string {
+	return append([]string(nil), metadataTables...)
+}
+
+// MetadataSize describes the size of a single row's metadata
+type MetadataSize struct {
+	Table     string    `gorm:"-" json:"table"`
+	ID        uuid.UUID `json:"id"`
+	SizeBytes int       `json:"size_bytes"`
+	KeyCount  int       `json:"key_count"`
+}
+
+// MetadataMigration summarizes moving oversized metadata into artifacts
+type MetadataMigration struct {
+	Table       string      `json:"table"`
+	MaxBytes    int         `json:"max_bytes"`
+	Migrated    int         `json:"migrated"`
+	BytesMoved  int         `json:"bytes_moved"`
+	ArtifactIDs []uuid.UUID `json:"artifact_ids"`
+	DryRun      bool        `json:"dry_run"`
+}
+
+// MetadataService provides metadata inspection and artifact operations
+type MetadataService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewMetadataService creates a new metadata service
+func NewMetadataService(db *gorm.DB, logger *logrus.Logger) *MetadataService {
+	return &MetadataService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// LargestMetadata returns the rows of a table with the largest metadata payloads
+func (s *MetadataService) LargestMetadata(table string, limit int) ([]MetadataSize, error) {
+	if err := checkMetadataTable(table); err != nil {
+		return nil, err
+	}
+
+	var sizes []MetadataSize
+	err := s.db.Table(table).
+		Select("id, octet_length(metadata::text) AS size_bytes, " +
+			"CASE WHEN jsonb_typeof(metadata) = 'object' THEN (SELECT count(*) FROM jsonb_object_keys(metadata)) ELSE 0 END AS key_count").
+		Where("metadata IS NOT NULL").
+		Order("size_bytes DESC").
+		Limit(limit).
+		Scan(&sizes).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("table", table).Error("Failed to get largest metadata")
+		return nil, err
+	}
+
+	for i := range sizes {
+		sizes[i].Table = table
+	}
+
+	return sizes, nil
+}
+
+// MigrateOversizedMetadata moves metadata larger than maxBytes into artifacts and
+// replaces it with a reference to the artifact
+func (s *MetadataService) MigrateOversizedMetadata(table string, maxBytes int, dryRun bool) (*MetadataMigration, error) {
+	if err := checkMetadataTable(table); err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("max bytes must be positive")
+	}
+
+	var rows []struct {
+		ID       uuid.UUID
+		Metadata string
+	}
+	err := s.db.Table(table).
+		Select("id, metadata::text AS metadata").
+		Where("octet_length(metadata::text) > ?", maxBytes).
+		Scan(&rows).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("table", table).Error("Failed to find oversized metadata")
+		return nil, err
+	}
+
+	migration := &MetadataMigration{
+		Table:       table,
+		MaxBytes:    maxBytes,
+		ArtifactIDs: []uuid.UUID{},
+		DryRun:      dryRun,
+	}
+
+	for _, row := range rows {
+		migration.Migrated++
+		migration.BytesMoved += len(row.Metadata)
+		if dryRun {
+			continue
+		}
+
+		artifact := Artifact{
+			OwnerTable:  table,
+			OwnerID:     row.ID,
+			Field:       "metadata",
+			ContentType: "application/json",
+			SizeBytes:   len(row.Metadata),
+			Data:        []byte(row.Metadata),
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&artifact).Error; err != nil {
+				return err
+			}
+
+			reference, err := json.Marshal(map[string]any{
+				"_artifact": map[string]any{
+					"id":          artifact.ID,
+					"size_bytes":  artifact.SizeBytes,
+					"migrated_at": time.Now().UTC(),
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			return tx.Table(table).
+				Where("id = ?", row.ID).
+				Update("metadata", gorm.Expr("?::jsonb", string(reference))).Error
+		})
+		if err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"table": table,
+				"id":    row.ID,
+			}).Error("Failed to migrate metadata to artifact")
+			return nil, err
+		}
+
+		migration.ArtifactIDs = append(migration.ArtifactIDs, artifact.ID)
+	}
+
+	if !dryRun {
+		s.logger.WithFields(logrus.Fields{
+			"table":       table,
+			"migrated":    migration.Migrated,
+			"bytes_moved": migration.BytesMoved,
+		}).Info("Oversized metadata migrated to artifacts")
+	}
+
+	return migration, nil
+}
+
+// GetArtifact retrieves an artifact by ID
+func (s *MetadataService) GetArtifact(id uuid.UUID) (*Artifact, error) {
+	var artifact Artifact
+
+	err := s.db.First(&artifact, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get artifact")
+		return nil, err
+	}
+
+	return &artifact, nil
+}
+
+// checkMetadataTable guards table names interpolated into queries
+func checkMetadataTable(table string) error {
+	for _, column := range encryptedColumns {
+		if column.table == table && column.column == "metadata" {
+			return fmt.Errorf("metadata of %s is encrypted and cannot be inspected", table)
+		}
+	}
+	for _, t := range metadataTables {
+		if t == table {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported metadata table: %s", table)
+}
@@ -0,0 +1,394 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Metrics query aggregations
+const (
+	AggregateAvg   = "avg"
+	AggregateMin   = "min"
+	AggregateMax   = "max"
+	AggregateSum   = "sum"
+	AggregateCount = "count"
+)
+
+// aggregateFunctions maps query aggregations onto SQL functions
+var aggregateFunctions = map[string]string{
+	AggregateAvg:   "AVG",
+	AggregateMin:   "MIN",
+	AggregateMax:   "MAX",
+	AggregateSum:   "SUM",
+	AggregateCount: "COUNT",
+}
+
+// Labels matching component metric columns rather than metadata keys
+const (
+	LabelComponentType = "component_type"
+	LabelComponentID   = "component_id"
+)
+
+// MetricsQuery selects and optionally aggregates component metrics. It is
+// usually parsed from an expression by ParseMetricsQuery, e.g.
+//
+//	avg(power_output|temperature{component_type="plant",region!="north"}) by (component)
+//
+// Without an aggregation the matching readings are returned as they are.
+type MetricsQuery struct {
+	// Metric names to select; empty selects all
+	Metrics  []string
+	Matchers []LabelMatcher
+	// One of the Aggregate constants, or empty for raw readings
+	Aggregation string
+	// Keep a series per component instead of aggregating across components
+	ByComponent bool
+	From        *time.Time
+	To          *time.Time
+	// Width of the aggregation buckets; zero aggregates the whole range
+	Step time.Duration
+	// Most points returned over all series
+	Limit int
+}
+
+// LabelMatcher filters metrics on a column or metadata label
+type LabelMatcher struct {
+	Label    string `json:"label"`
+	Value    string `json:"value"`
+	NotEqual bool   `json:"not_equal,omitempty"`
+}
+
+// MetricSeries is one metric's readings, of one component unless aggregated across them
+type MetricSeries struct {
+	MetricName    string        `json:"metric_name"`
+	Unit          string        `json:"unit"`
+	ComponentType string        `json:"component_type,omitempty"`
+	ComponentID   *int          `json:"component_id,omitempty"`
+	Points        []MetricPoint `json:"points"`
+}
+
+// MetricPoint is a reading or an aggregated bucket. Buckets are stamped with
+// their start, or with the latest reading when the whole range is aggregated.
+type MetricPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricsQueryResult holds a query's series and whether the point limit cut it short
+type MetricsQueryResult struct {
+	Series    []MetricSeries `json:"series"`
+	Truncated bool           `json:"truncated"`
+}
+
+// metricsQueryRow is a raw or aggregated row of a metrics query
+type metricsQueryRow struct {
+	MetricName    string
+	Unit          string
+	ComponentType string
+	ComponentID   *int
+	Timestamp     time.Time
+	Value         float64
+}
+
+// QueryComponentMetrics runs a metrics query against a simulation's component metrics
+func (s *SimulationService) QueryComponentMetrics(ctx context.Context, simulationID uuid.UUID, q MetricsQuery) (*MetricsQueryResult, error) {
+	query := s.db.WithContext(ctx).Model(&ComponentMetric{}).Where("simulation_id = ?", simulationID)
+
+	if len(q.Metrics) > 0 {
+		query = query.Where("metric_name IN ?", q.Metrics)
+	}
+	for _, matcher := range q.Matchers {
+		operator := "="
+		if matcher.NotEqual {
+			operator = "IS DISTINCT FROM"
+		}
+		switch matcher.Label {
+		case LabelComponentType:
+			query = query.Where("component_type "+operator+" ?", matcher.Value)
+		case LabelComponentID:
+			id, err := strconv.Atoi(matcher.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: component_id must be an integer", ErrInvalidMetricsQuery)
+			}
+			query = query.Where("component_id "+operator+" ?", id)
+		default:
+			query = query.Where("metadata->>CAST(? AS TEXT) "+operator+" ?", matcher.Label, matcher.Value)
+		}
+	}
+	if q.From != nil {
+		query = query.Where("timestamp >= ?", *q.From)
+	}
+	if q.To != nil {
+		query = query.Where("timestamp <= ?", *q.To)
+	}
+
+	var rows []metricsQueryRow
+	if q.Aggregation == "" {
+		query = query.Select("metric_name, unit, component_type, component_id, timestamp, metric_value AS value").
+			Order("metric_name, unit, component_type, component_id, timestamp")
+	} else {
+		function, ok := aggregateFunctions[q.Aggregation]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown aggregation %q", ErrInvalidMetricsQuery, q.Aggregation)
+		}
+
+		columns := "metric_name, unit"
+		if q.ByComponent {
+			columns += ", component_type, component_id"
+		}
+		// Grouped columns are referenced by position so the bucket
+		// expression is not repeated
+		groups := strings.Count(columns, ",") + 1
+		positions := make([]string, 0, groups+1)
+		for i := 1; i <= groups; i++ {
+			positions = append(positions, strconv.Itoa(i))
+		}
+
+		if q.Step > 0 {
+			seconds := q.Step.Seconds()
+			query = query.Select(columns+", to_timestamp(floor(extract(epoch FROM timestamp) / ?) * ?) AS timestamp, "+function+"(metric_value) AS value", seconds, seconds)
+			positions = append(positions, strconv.Itoa(groups+1))
+		} else {
+			query = query.Select(columns + ", MAX(timestamp) AS timestamp, " + function + "(metric_value) AS value")
+		}
+
+		order := strings.Join(positions, ", ")
+		query = query.Group(order).Order(order)
+	}
+
+	if err := query.Limit(q.Limit + 1).Scan(&rows).Error; err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to query component metrics")
+		return nil, err
+	}
+
+	result := &MetricsQueryResult{Series: []MetricSeries{}}
+	if len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		result.Truncated = true
+	}
+
+	for _, row := range rows {
+		last := len(result.Series) - 1
+		if last < 0 || !result.Series[last].matches(row) {
+			result.Series = append(result.Series, MetricSeries{
+				MetricName:    row.MetricName,
+				Unit:          row.Unit,
+				ComponentType: row.ComponentType,
+				ComponentID:   row.ComponentID,
+			})
+			last++
+		}
+		result.Series[last].Points = append(result.Series[last].Points, MetricPoint{
+			Timestamp: row.Timestamp.UTC(),
+			Value:     row.Value,
+		})
+	}
+
+	return result, nil
+}
+
+// matches reports whether a row belongs to the series
+func (m MetricSeries) matches(row metricsQueryRow) bool {
+	if m.MetricName != row.MetricName || m.Unit != row.Unit || m.ComponentType != row.ComponentType {
+		return false
+	}
+	if m.ComponentID == nil || row.ComponentID == nil {
+		return m.ComponentID == nil && row.ComponentID == nil
+	}
+	return *m.ComponentID == *row.ComponentID
+}
+
+// ParseMetricsQuery parses a metrics query expression:
+//
+//	query    = aggregation "(" selector ")" [ "by" "(" "component" ")" ] | selector
+//	selector = ( name { "|" name } | "*" ) [ "{" matcher { "," matcher } "}" ]
+//	matcher  = label ( "=" | "!=" ) quoted-string
+//
+// The time range, step and limit are not part of the expression.
+func ParseMetricsQuery(expr string) (MetricsQuery, error) {
+	p := &metricsQueryParser{input: expr}
+	q, err := p.parse()
+	if err != nil {
+		return MetricsQuery{}, fmt.Errorf("%w: %v", ErrInvalidMetricsQuery, err)
+	}
+	return q, nil
+}
+
+// metricsQueryParser is a recursive descent parser over a query expression
+type metricsQueryParser struct {
+	input string
+	pos   int
+}
+
+func (p *metricsQueryParser) parse() (MetricsQuery, error) {
+	var q MetricsQuery
+
+	name := p.identifier()
+	p.skipSpace()
+	if _, ok := aggregateFunctions[name]; ok && p.peek() == '(' {
+		p.pos++
+		q.Aggregation = name
+		if err := p.selector(&q, ""); err != nil {
+			return q, err
+		}
+		if err := p.expect(')'); err != nil {
+			return q, err
+		}
+		if word := p.identifier(); word != "" && word != "by" {
+			return q, fmt.Errorf("unexpected %q, expected by", word)
+		} else if word == "by" {
+			if err := p.expect('('); err != nil {
+				return q, err
+			}
+			p.skipSpace()
+			if grouping := p.identifier(); grouping != "component" {
+				return q, fmt.Errorf("can only group by component, not %q", grouping)
+			}
+			if err := p.expect(')'); err != nil {
+				return q, err
+			}
+			q.ByComponent = true
+		}
+	} else if err := p.selector(&q, name); err != nil {
+		return q, err
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return q, fmt.Errorf("unexpected %q at offset %d", p.input[p.pos:], p.pos)
+	}
+	return q, nil
+}
+
+// selector parses metric names and matchers; name is a metric name already read
+func (p *metricsQueryParser) selector(q *MetricsQuery, name string) error {
+	p.skipSpace()
+	if name == "" {
+		if p.peek() == '*' {
+			p.pos++
+		} else if name = p.identifier(); name == "" {
+			return fmt.Errorf("expected a metric name at offset %d", p.pos)
+		}
+	}
+
+	for name != "" {
+		q.Metrics = append(q.Metrics, name)
+		p.skipSpace()
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+		p.skipSpace()
+		if name = p.identifier(); name == "" {
+			return fmt.Errorf("expected a metric name at offset %d", p.pos)
+		}
+	}
+	sort.Strings(q.Metrics)
+
+	p.skipSpace()
+	if p.peek() != '{' {
+		return nil
+	}
+	p.pos++
+
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			return nil
+		}
+
+		label := p.identifier()
+		if label == "" {
+			return fmt.Errorf("expected a label at offset %d", p.pos)
+		}
+		p.skipSpace()
+
+		matcher := LabelMatcher{Label: label}
+		if strings.HasPrefix(p.input[p.pos:], "!=") {
+			matcher.NotEqual = true
+			p.pos += 2
+		} else if err := p.expect('='); err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		value, err := p.quoted()
+		if err != nil {
+			return err
+		}
+		matcher.Value = value
+		q.Matchers = append(q.Matchers, matcher)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+		default:
+			return fmt.Errorf("expected , or } at offset %d", p.pos)
+		}
+	}
+}
+
+// identifier reads a name made of letters, digits, underscores and dots
+func (p *metricsQueryParser) identifier() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) {
+		r := rune(p.input[p.pos])
+		if r != '_' && r != '.' && !unicode.IsLetter(r) && !(unicode.IsDigit(r) && p.pos > start) {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// quoted reads a double-quoted string
+func (p *metricsQueryParser) quoted() (string, error) {
+	if p.peek() != '"' {
+		return "", fmt.Errorf("expected a quoted value at offset %d", p.pos)
+	}
+	end := strings.IndexByte(p.input[p.pos+1:], '"')
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at offset %d", p.pos)
+	}
+	value := p.input[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+	return value, nil
+}
+
+func (p *metricsQueryParser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		return fmt.Errorf("expected %q at offset %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *metricsQueryParser) peek() byte {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *metricsQueryParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// Errors
+var (
+	ErrInvalidMetricsQuery = fmt.Errorf("invalid metrics query")
+)
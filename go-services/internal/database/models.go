@@ -1,23 +1,27 @@
 package database
 
 import (
+	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// User represents a system user
+// User represents a system user. Metadata may hold personal data and is
+// encrypted at rest.
 type User struct {
-	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Email        string         `gorm:"uniqueIndex;not null" json:"email"`
-	Username     string         `gorm:"uniqueIndex;not null" json:"username"`
-	PasswordHash string         `gorm:"not null" json:"-"`
-	Role         string         `gorm:"default:user" json:"role"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	IsActive     bool           `gorm:"default:true" json:"is_active"`
-	Metadata     map[string]any `gorm:"type:jsonb" json:"metadata"`
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email          string         `gorm:"uniqueIndex;not null" json:"email"`
+	Username       string         `gorm:"uniqueIndex;not null" json:"username"`
+	PasswordHash   string         `gorm:"not null" json:"-"`
+	Role           string         `gorm:"default:user" json:"role"`
+	OrganizationID *uuid.UUID     `gorm:"type:uuid;index" json:"organization_id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	IsActive       bool           `gorm:"default:true" json:"is_active"`
+	Metadata       map[string]any `gorm:"type:jsonb;serializer:encrypted" json:"metadata"`
 }
 
 // Organization represents an organization/tenant
@@ -37,42 +41,79 @@ type Simulation struct {
 	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Name           string         `gorm:"not null" json:"name"`
 	Description    string         `json:"description"`
-	UserID         uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	User           User           `gorm:"foreignKey:UserID" json:"user"`
-	OrganizationID uuid.UUID      `gorm:"type:uuid" json:"organization_id"`
-	Organization   Organization   `gorm:"foreignKey:OrganizationID" json:"organization"`
+	UserID         *uuid.UUID     `gorm:"type:uuid" json:"user_id,omitempty"`
+	User           *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	OrganizationID *uuid.UUID     `gorm:"type:uuid" json:"organization_id,omitempty"`
+	Organization   *Organization  `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Engine         string         `json:"engine"`
 	Config         map[string]any `gorm:"type:jsonb;not null" json:"config"`
+	Tags           []string       `gorm:"type:jsonb;serializer:json" json:"tags"`
 	Status         string         `gorm:"default:created" json:"status"`
 	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 	StartedAt      *time.Time     `json:"started_at"`
 	CompletedAt    *time.Time     `json:"completed_at"`
 	ErrorMessage   string         `json:"error_message"`
 	Metadata       map[string]any `gorm:"type:jsonb" json:"metadata"`
+	// Incremented on every update, see UpdateSimulation
+	Version int64 `gorm:"not null;default:1" json:"version"`
+	// Simulations that must complete before this one starts
+	DependsOn []SimulationDependency `gorm:"type:jsonb;serializer:json" json:"depends_on,omitempty"`
 
 	// Relationships
 	PowerPlants       []PowerPlant       `gorm:"foreignKey:SimulationID" json:"power_plants"`
 	TransmissionLines []TransmissionLine `gorm:"foreignKey:SimulationID" json:"transmission_lines"`
+	StorageUnits      []StorageUnit      `gorm:"foreignKey:SimulationID" json:"storage_units"`
+	Buses             []Bus              `gorm:"foreignKey:SimulationID" json:"buses"`
 	Results           []SimulationResult `gorm:"foreignKey:SimulationID" json:"results"`
 	ComponentMetrics  []ComponentMetric  `gorm:"foreignKey:SimulationID" json:"component_metrics"`
 	FaultEvents       []FaultEvent       `gorm:"foreignKey:SimulationID" json:"fault_events"`
 	Alerts            []Alert            `gorm:"foreignKey:SimulationID" json:"alerts"`
 }
 
+// SimulationDependency is a simulation that has to complete before the one
+// depending on it starts
+type SimulationDependency struct {
+	SimulationID string `json:"simulation_id"`
+	HandoffState bool   `json:"handoff_state"`
+}
+
 // PowerPlant represents a power generation unit
 type PowerPlant struct {
-	ID              uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	SimulationID    uuid.UUID      `gorm:"type:uuid;not null" json:"simulation_id"`
-	Simulation      Simulation     `gorm:"foreignKey:SimulationID" json:"simulation"`
-	PlantID         int            `gorm:"not null" json:"plant_id"`
-	Name            string         `gorm:"not null" json:"name"`
-	PlantType       string         `gorm:"not null" json:"plant_type"`
-	MaxCapacityMW   float64        `gorm:"not null" json:"max_capacity_mw"`
-	CurrentOutputMW float64        `gorm:"not null" json:"current_output_mw"`
-	Efficiency      float64        `gorm:"not null" json:"efficiency"`
-	Location        map[string]any `gorm:"type:jsonb;not null" json:"location"`
-	IsOperational   bool           `gorm:"default:true" json:"is_operational"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
+	ID                     uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SimulationID           uuid.UUID      `gorm:"type:uuid;not null" json:"simulation_id"`
+	Simulation             Simulation     `gorm:"foreignKey:SimulationID" json:"simulation"`
+	PlantID                int            `gorm:"not null" json:"plant_id"`
+	Name                   string         `gorm:"not null" json:"name"`
+	PlantType              string         `gorm:"not null" json:"plant_type"`
+	MaxCapacityMW          float64        `gorm:"not null" json:"max_capacity_mw"`
+	CurrentOutputMW        float64        `gorm:"not null" json:"current_output_mw"`
+	Efficiency             float64        `gorm:"not null" json:"efficiency"`
+	Location               map[string]any `gorm:"type:jsonb;not null" json:"location"`
+	BusID                  *int           `json:"bus_id"`
+	EmissionFactorKgPerMWh float64        `gorm:"default:0" json:"emission_factor_kg_per_mwh"`
+	FuelCostPerMWh         float64        `gorm:"default:0" json:"fuel_cost_per_mwh"`
+	StartupCost            float64        `gorm:"default:0" json:"startup_cost"`
+	OMCostPerMWh           float64        `gorm:"default:0" json:"om_cost_per_mwh"`
+
+	IsOperational bool      `gorm:"default:true" json:"is_operational"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Bus represents an electrical bus or substation connecting grid components
+type Bus struct {
+	ID                  uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SimulationID        uuid.UUID      `gorm:"type:uuid;not null;index" json:"simulation_id"`
+	Simulation          Simulation     `gorm:"foreignKey:SimulationID" json:"simulation"`
+	BusID               int            `gorm:"not null" json:"bus_id"`
+	Name                string         `gorm:"not null" json:"name"`
+	BusType             string         `gorm:"not null;default:bus" json:"bus_type"`
+	VoltageKV           float64        `gorm:"not null" json:"voltage_kv"`
+	Location            map[string]any `gorm:"type:jsonb" json:"location"`
+	ConnectedComponents map[string]any `gorm:"type:jsonb" json:"connected_components"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
 }
 
 // TransmissionLine represents a power transmission line
@@ -92,13 +133,32 @@ type TransmissionLine struct {
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
+// StorageUnit represents a battery/energy storage unit
+type StorageUnit struct {
+	ID                  uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SimulationID        uuid.UUID      `gorm:"type:uuid;not null" json:"simulation_id"`
+	Simulation          Simulation     `gorm:"foreignKey:SimulationID" json:"simulation"`
+	UnitID              int            `gorm:"not null" json:"unit_id"`
+	Name                string         `gorm:"not null" json:"name"`
+	CapacityMWh         float64        `gorm:"not null" json:"capacity_mwh"`
+	MaxChargeMW         float64        `gorm:"not null" json:"max_charge_mw"`
+	MaxDischargeMW      float64        `gorm:"not null" json:"max_discharge_mw"`
+	RoundTripEfficiency float64        `gorm:"not null" json:"round_trip_efficiency"`
+	StateOfCharge       float64        `gorm:"not null" json:"state_of_charge"`
+	Location            map[string]any `gorm:"type:jsonb;not null" json:"location"`
+	BusID               *int           `json:"bus_id"`
+	IsOperational       bool           `gorm:"default:true" json:"is_operational"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+}
+
 // SimulationResult represents time-series simulation data
 type SimulationResult struct {
 	ID                   uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	SimulationID         uuid.UUID      `gorm:"type:uuid;not null" json:"simulation_id"`
+	SimulationID         uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_result_tick,priority:1;index:idx_simulation_timestamp,priority:1" json:"simulation_id"`
 	Simulation           Simulation     `gorm:"foreignKey:SimulationID" json:"simulation"`
-	Timestamp            time.Time      `gorm:"not null;index:idx_simulation_timestamp,priority:1" json:"timestamp"`
-	TickNumber           int            `gorm:"not null" json:"tick_number"`
+	Timestamp            time.Time      `gorm:"not null;index:idx_simulation_timestamp,priority:2" json:"timestamp"`
+	TickNumber           int            `gorm:"not null;uniqueIndex:idx_result_tick,priority:2" json:"tick_number"`
 	TotalGenerationMW    float64        `gorm:"not null" json:"total_generation_mw"`
 	TotalConsumptionMW   float64        `gorm:"not null" json:"total_consumption_mw"`
 	GridFrequencyHz      float64        `gorm:"not null" json:"grid_frequency_hz"`
@@ -108,6 +168,46 @@ type SimulationResult struct {
 	Metadata             map[string]any `gorm:"type:jsonb" json:"metadata"`
 }
 
+// SimulationSummary holds running aggregates of a simulation's results and
+// faults. Ingest keeps it up to date batch by batch, so statistics read one
+// row however many results a simulation has. See summaries.go.
+type SimulationSummary struct {
+	SimulationID   uuid.UUID  `gorm:"type:uuid;primary_key" json:"simulation_id"`
+	ResultCount    int64      `gorm:"not null;default:0" json:"result_count"`
+	FaultCount     int64      `gorm:"not null;default:0" json:"fault_count"`
+	FirstTick      *int       `json:"first_tick"`
+	LastTick       *int       `json:"last_tick"`
+	FirstTimestamp *time.Time `json:"first_timestamp"`
+	LastTimestamp  *time.Time `json:"last_timestamp"`
+
+	SumGenerationMW          float64  `gorm:"not null;default:0" json:"sum_generation_mw"`
+	MinGenerationMW          *float64 `json:"min_generation_mw"`
+	MaxGenerationMW          *float64 `json:"max_generation_mw"`
+	LastGenerationMW         *float64 `json:"last_generation_mw"`
+	SumConsumptionMW         float64  `gorm:"not null;default:0" json:"sum_consumption_mw"`
+	MinConsumptionMW         *float64 `json:"min_consumption_mw"`
+	MaxConsumptionMW         *float64 `json:"max_consumption_mw"`
+	LastConsumptionMW        *float64 `json:"last_consumption_mw"`
+	SumFrequencyHz           float64  `gorm:"not null;default:0" json:"sum_frequency_hz"`
+	MinFrequencyHz           *float64 `json:"min_frequency_hz"`
+	MaxFrequencyHz           *float64 `json:"max_frequency_hz"`
+	LastFrequencyHz          *float64 `json:"last_frequency_hz"`
+	SumVoltageKV             float64  `gorm:"not null;default:0" json:"sum_voltage_kv"`
+	MinVoltageKV             *float64 `json:"min_voltage_kv"`
+	MaxVoltageKV             *float64 `json:"max_voltage_kv"`
+	LastVoltageKV            *float64 `json:"last_voltage_kv"`
+	SumEfficiencyPercentage  float64  `gorm:"not null;default:0" json:"sum_efficiency_percentage"`
+	MinEfficiencyPercentage  *float64 `json:"min_efficiency_percentage"`
+	MaxEfficiencyPercentage  *float64 `json:"max_efficiency_percentage"`
+	LastEfficiencyPercentage *float64 `json:"last_efficiency_percentage"`
+
+	// Set when resent ticks replaced results, which may leave the minimums
+	// and maximums wider than the stored results; the summary is recomputed
+	// before it is next read
+	Stale     bool      `gorm:"not null;default:false" json:"stale"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // ComponentMetric represents detailed metrics for individual components
 type ComponentMetric struct {
 	ID            uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -120,37 +220,641 @@ type ComponentMetric struct {
 	MetricValue   float64        `gorm:"not null" json:"metric_value"`
 	Unit          string         `gorm:"not null" json:"unit"`
 	Metadata      map[string]any `gorm:"type:jsonb" json:"metadata"`
+	// Set for metrics ingested per tick, see IngestResultBatch
+	TickNumber *int `gorm:"index:idx_component_metric_tick" json:"tick_number,omitempty"`
 }
 
 // FaultEvent represents a fault event in the grid
 type FaultEvent struct {
 	ID               uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	SimulationID     uuid.UUID      `gorm:"type:uuid;not null" json:"simulation_id"`
+	SimulationID     uuid.UUID      `gorm:"type:uuid;not null;index:idx_simulation_faults,priority:1;index:idx_simulation_fault_severity,priority:1" json:"simulation_id"`
 	Simulation       Simulation     `gorm:"foreignKey:SimulationID" json:"simulation"`
-	Timestamp        time.Time      `gorm:"not null;index:idx_simulation_faults,priority:2" json:"timestamp"`
+	Timestamp        time.Time      `gorm:"not null;index:idx_simulation_faults,priority:2;index:idx_simulation_fault_severity,priority:3" json:"timestamp"`
 	FaultType        string         `gorm:"not null;index:idx_fault_type" json:"fault_type"`
 	ComponentID      int            `gorm:"not null" json:"component_id"`
 	ComponentType    string         `gorm:"not null" json:"component_type"`
-	Severity         string         `gorm:"not null" json:"severity"`
+	Severity         string         `gorm:"not null;index:idx_simulation_fault_severity,priority:2" json:"severity"`
 	Description      string         `json:"description"`
 	ResolvedAt       *time.Time     `json:"resolved_at"`
 	ImpactAssessment map[string]any `gorm:"type:jsonb" json:"impact_assessment"`
+	// Set for faults ingested per tick, see IngestResultBatch
+	TickNumber *int `gorm:"index:idx_fault_tick" json:"tick_number,omitempty"`
 }
 
 // Alert represents a system alert
 type Alert struct {
 	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	SimulationID   uuid.UUID      `gorm:"type:uuid" json:"simulation_id"`
+	SimulationID   uuid.UUID      `gorm:"type:uuid;index:idx_simulation_alerts,priority:1;index:idx_simulation_alert_severity,priority:1" json:"simulation_id"`
 	Simulation     Simulation     `gorm:"foreignKey:SimulationID" json:"simulation"`
 	AlertType      string         `gorm:"not null;index:idx_alert_type" json:"alert_type"`
-	Severity       string         `gorm:"not null" json:"severity"`
+	Severity       string         `gorm:"not null;index:idx_simulation_alert_severity,priority:2" json:"severity"`
 	Message        string         `gorm:"not null" json:"message"`
-	TriggeredAt    time.Time      `gorm:"default:now();index:idx_simulation_alerts,priority:2" json:"triggered_at"`
+	TriggeredAt    time.Time      `gorm:"default:now();index:idx_simulation_alerts,priority:2;index:idx_simulation_alert_severity,priority:3" json:"triggered_at"`
 	AcknowledgedAt *time.Time     `json:"acknowledged_at"`
+	AcknowledgedBy *uuid.UUID     `gorm:"type:uuid" json:"acknowledged_by"`
 	ResolvedAt     *time.Time     `json:"resolved_at"`
+	ResolvedBy     *uuid.UUID     `gorm:"type:uuid" json:"resolved_by"`
 	Metadata       map[string]any `gorm:"type:jsonb" json:"metadata"`
 }
 
+// StabilityScore holds the frequency stability KPIs of a run, computed when
+// it ends so scenarios can be ranked by how well they held frequency
+type StabilityScore struct {
+	SimulationID       uuid.UUID `gorm:"type:uuid;primary_key" json:"simulation_id"`
+	NominalFrequencyHz float64   `gorm:"not null" json:"nominal_frequency_hz"`
+	// Deviation from nominal counted as outside the band
+	BandHz             float64 `gorm:"not null" json:"band_hz"`
+	Samples            int64   `gorm:"not null;default:0" json:"samples"`
+	DurationSeconds    float64 `gorm:"not null;default:0" json:"duration_seconds"`
+	SecondsOutsideBand float64 `gorm:"not null;default:0" json:"seconds_outside_band"`
+	PercentOutsideBand float64 `gorm:"not null;default:0" json:"percent_outside_band"`
+	// Lowest and highest frequency recorded
+	NadirHz        *float64   `json:"nadir_hz"`
+	NadirAt        *time.Time `json:"nadir_at"`
+	ZenithHz       *float64   `json:"zenith_hz"`
+	MaxDeviationHz float64    `gorm:"not null;default:0" json:"max_deviation_hz"`
+	// Rate of change of frequency between consecutive results
+	MaxRoCoFHzPerS  float64 `gorm:"column:max_rocof_hz_per_s;not null;default:0" json:"max_rocof_hz_per_s"`
+	MeanRoCoFHzPerS float64 `gorm:"column:mean_rocof_hz_per_s;not null;default:0" json:"mean_rocof_hz_per_s"`
+	// 0-100, higher is more stable
+	Score      float64   `gorm:"not null;default:0;index:idx_stability_score,sort:desc" json:"score"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// EmissionSummary holds persisted emissions totals for a simulation per fuel type
+type EmissionSummary struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SimulationID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_emission_sim_fuel,priority:1" json:"simulation_id"`
+	FuelType          string    `gorm:"not null;uniqueIndex:idx_emission_sim_fuel,priority:2" json:"fuel_type"`
+	EnergyMWh         float64   `gorm:"not null" json:"energy_mwh"`
+	EmissionsKg       float64   `gorm:"not null" json:"emissions_kg"`
+	IntensityKgPerMWh float64   `gorm:"not null" json:"intensity_kg_per_mwh"`
+	CurrentKgPerHour  float64   `gorm:"not null" json:"current_kg_per_hour"`
+	PeriodStart       time.Time `json:"period_start"`
+	PeriodEnd         time.Time `json:"period_end"`
+	ComputedAt        time.Time `json:"computed_at"`
+}
+
+// WebhookSubscription represents a webhook endpoint subscribed to events
+type WebhookSubscription struct {
+	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID  uuid.UUID `gorm:"type:uuid;index" json:"organization_id"`
+	Name            string    `gorm:"not null" json:"name"`
+	URL             string    `gorm:"not null" json:"url"`
+	Secret          string    `gorm:"serializer:encrypted" json:"-"`
+	EventTypes      []string  `gorm:"type:jsonb;serializer:json" json:"event_types"`
+	PayloadTemplate string    `json:"payload_template"`
+	TemplateVersion int       `gorm:"default:0" json:"template_version"`
+	ContentType     string    `gorm:"default:application/json" json:"content_type"`
+	IsActive        bool      `gorm:"default:true" json:"is_active"`
+	// The owner's notification preferences apply to deliveries when set
+	OwnerID *uuid.UUID `gorm:"type:uuid;index" json:"owner_id"`
+	// Run report format ("html" or "pdf") attached to simulation completed,
+	// stopped and failed deliveries; none when empty
+	ReportFormat string `json:"report_format"`
+	// Incremented on every update, see UpdateSubscription
+	Version   int64     `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the subscription wants the given event type
+func (w *WebhookSubscription) Matches(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == "*" || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification channel types
+const (
+	ChannelSlack     = "slack"
+	ChannelPagerDuty = "pagerduty"
+	ChannelEmail     = "email"
+)
+
+// NotificationChannel is a destination alerts are routed to
+type NotificationChannel struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;index" json:"organization_id"`
+	Name           string    `gorm:"not null" json:"name"`
+	Type           string    `gorm:"not null" json:"type"`
+	// Slack incoming webhook URL, encrypted at rest
+	WebhookURL string `gorm:"serializer:encrypted" json:"-"`
+	// PagerDuty Events API v2 integration key, encrypted at rest
+	RoutingKey string `gorm:"serializer:encrypted" json:"-"`
+	// Email recipients
+	Recipients []string `gorm:"type:jsonb;serializer:json" json:"recipients"`
+	IsActive   bool     `gorm:"default:true" json:"is_active"`
+	// The owner's notification preferences apply to deliveries when set.
+	// Email recipients who are users have their own preferences applied too.
+	OwnerID *uuid.UUID `gorm:"type:uuid;index" json:"owner_id"`
+	// Incremented on every update, see UpdateChannel
+	Version   int64     `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AlertRoute sends matching alerts to a notification channel
+type AlertRoute struct {
+	ID             uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID           `gorm:"type:uuid;index" json:"organization_id"`
+	Name           string              `gorm:"not null" json:"name"`
+	ChannelID      uuid.UUID           `gorm:"type:uuid;not null;index" json:"channel_id"`
+	Channel        NotificationChannel `gorm:"foreignKey:ChannelID" json:"-"`
+	// Alert types to route; empty routes every type
+	AlertTypes []string `gorm:"type:jsonb;serializer:json" json:"alert_types"`
+	// Alerts below this severity are ignored; empty routes every severity
+	MinSeverity string `json:"min_severity"`
+	// Limits the route to one simulation when set
+	SimulationID *uuid.UUID `gorm:"type:uuid" json:"simulation_id"`
+	// Alerts with the same dedupe key are sent once per window
+	DedupeWindowSeconds int `gorm:"not null;default:0" json:"dedupe_window_seconds"`
+	// At most ThrottleLimit notifications are sent per throttle window
+	ThrottleLimit         int  `gorm:"not null;default:0" json:"throttle_limit"`
+	ThrottleWindowSeconds int  `gorm:"not null;default:0" json:"throttle_window_seconds"`
+	IsActive              bool `gorm:"default:true" json:"is_active"`
+	// Incremented on every update, see UpdateRoute
+	Version   int64     `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Alert notification statuses
+const (
+	NotificationSent       = "sent"
+	NotificationSuppressed = "suppressed"
+	NotificationFailed     = "failed"
+	// Held for a digest by the recipient's notification preferences
+	NotificationHeld = "held"
+)
+
+// AlertNotification records one attempt to notify a channel about an alert
+type AlertNotification struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RouteID   uuid.UUID `gorm:"type:uuid;not null;index:idx_route_notifications,priority:1" json:"route_id"`
+	ChannelID uuid.UUID `gorm:"type:uuid;not null" json:"channel_id"`
+	EventID   string    `gorm:"not null" json:"event_id"`
+	DedupeKey string    `gorm:"not null" json:"dedupe_key"`
+	Status    string    `gorm:"not null" json:"status"`
+	// Why the notification was suppressed or failed
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `gorm:"index:idx_route_notifications,priority:2" json:"created_at"`
+}
+
+// Alert silence states, derived from the current time
+const (
+	SilencePending = "pending"
+	SilenceActive  = "active"
+	SilenceExpired = "expired"
+)
+
+// AlertSilence mutes alert notifications that match it during a time range,
+// such as a maintenance window or a planned fault injection experiment.
+// Empty matchers match everything.
+type AlertSilence struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index" json:"organization_id"`
+	Comment        string     `gorm:"not null" json:"comment"`
+	StartsAt       time.Time  `gorm:"not null;index:idx_silence_window,priority:2" json:"starts_at"`
+	EndsAt         time.Time  `gorm:"not null;index:idx_silence_window,priority:1" json:"ends_at"`
+	SimulationID   *uuid.UUID `gorm:"type:uuid" json:"simulation_id"`
+	ComponentType  string     `json:"component_type"`
+	ComponentID    *int       `json:"component_id"`
+	Severities     []string   `gorm:"type:jsonb;serializer:json" json:"severities"`
+	AlertTypes     []string   `gorm:"type:jsonb;serializer:json" json:"alert_types"`
+	CreatedBy      *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	// Incremented on every update, see UpdateSilence
+	Version   int64     `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// State reports whether the silence is pending, active or expired at a time
+func (s *AlertSilence) State(at time.Time) string {
+	switch {
+	case at.Before(s.StartsAt):
+		return SilencePending
+	case at.Before(s.EndsAt):
+		return SilenceActive
+	default:
+		return SilenceExpired
+	}
+}
+
+// ChannelWebhook names webhook subscriptions in notification preferences,
+// alongside the notification channel types
+const ChannelWebhook = "webhook"
+
+// Notification delivery modes
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryDigest    = "digest"
+)
+
+// Scheduled report frequencies
+const (
+	ReportScheduleDaily  = "daily"
+	ReportScheduleWeekly = "weekly"
+)
+
+// NotificationPreference is a user's notification settings. They apply to
+// webhook subscriptions and notification channels the user owns and to email
+// channels listing the user's address.
+type NotificationPreference struct {
+	UserID uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`
+	// Event types to notify about; empty notifies about every type
+	EventTypes []string `gorm:"type:jsonb;serializer:json" json:"event_types"`
+	// Channel types to notify through; empty allows every channel
+	Channels []string `gorm:"type:jsonb;serializer:json" json:"channels"`
+	// Notifications between the start and end, as "15:04" in Timezone, are
+	// held until the end. Quiet hours may span midnight.
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	Timezone        string `gorm:"not null;default:UTC" json:"timezone"`
+	// Digest delivery collects notifications and sends them together every
+	// DigestIntervalMinutes
+	Delivery              string `gorm:"not null;default:immediate" json:"delivery"`
+	DigestIntervalMinutes int    `gorm:"not null;default:60" json:"digest_interval_minutes"`
+	// Scheduled report emails, ReportScheduleDaily or ReportScheduleWeekly;
+	// none when empty
+	ReportSchedule string `json:"report_schedule"`
+	// End of the period the last scheduled report covered
+	LastReportAt *time.Time `json:"last_report_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Wants reports whether the user allows an event type through a channel type
+func (p *NotificationPreference) Wants(channel, eventType string) bool {
+	if len(p.Channels) > 0 && !slices.Contains(p.Channels, channel) {
+		return false
+	}
+	return len(p.EventTypes) == 0 || slices.Contains(p.EventTypes, eventType)
+}
+
+// NotificationDigestItem is an event held by notification preferences until
+// it is sent as part of a digest. Exactly one of SubscriptionID and ChannelID
+// is set; Recipient narrows an email channel to one address.
+type NotificationDigestItem struct {
+	ID             uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID            `gorm:"type:uuid;not null;index" json:"user_id"`
+	SubscriptionID *uuid.UUID           `gorm:"type:uuid" json:"subscription_id"`
+	Subscription   *WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+	ChannelID      *uuid.UUID           `gorm:"type:uuid" json:"channel_id"`
+	Channel        *NotificationChannel `gorm:"foreignKey:ChannelID" json:"-"`
+	Recipient      string               `json:"recipient,omitempty"`
+	EventType      string               `gorm:"not null" json:"event_type"`
+	Payload        json.RawMessage      `gorm:"type:jsonb;serializer:json;not null" json:"payload"`
+	// The item is not sent before this time
+	DeliverAfter time.Time `gorm:"not null;index" json:"deliver_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ImpersonationSetting is the organization setting controlling support impersonation
+const ImpersonationSetting = "allow_impersonation"
+
+// CanView reports whether a user may read a dashboard
+func (d *Dashboard) CanView(userID uuid.UUID, organizationID *uuid.UUID) bool {
+	switch {
+	case d.OwnerID == userID, d.Visibility == DashboardPublic:
+		return true
+	case d.Visibility == DashboardOrganization && d.OrganizationID != nil && organizationID != nil &&
+		*d.OrganizationID == *organizationID:
+		return true
+	}
+	return d.sharePermission(userID) != ""
+}
+
+// CanEdit reports whether a user may change a dashboard's name, description
+// and document. Only the owner manages its visibility and shares.
+func (d *Dashboard) CanEdit(userID uuid.UUID) bool {
+	return d.OwnerID == userID || d.sharePermission(userID) == DashboardEdit
+}
+
+func (d *Dashboard) sharePermission(userID uuid.UUID) string {
+	for _, share := range d.Shares {
+		if share.UserID == userID {
+			return share.Permission
+		}
+	}
+	return ""
+}
+
+// AllowsImpersonation reports whether support staff may impersonate the
+// organization's users. Organizations opt out by setting it to false.
+func (o *Organization) AllowsImpersonation() bool {
+	allowed, ok := o.Settings[ImpersonationSetting].(bool)
+	return !ok || allowed
+}
+
+// QuotaSetting is the organization setting holding quota overrides, keyed by quota name
+const QuotaSetting = "quotas"
+
+// QuotaOverrides returns the organization's quota overrides by quota name
+func (o *Organization) QuotaOverrides() map[string]float64 {
+	raw, _ := o.Settings[QuotaSetting].(map[string]any)
+	overrides := make(map[string]float64, len(raw))
+	for name, value := range raw {
+		if limit, ok := value.(float64); ok {
+			overrides[name] = limit
+		}
+	}
+	return overrides
+}
+
+// ConcurrencySetting is the organization setting holding its concurrency
+// limit overrides, keyed "per_organization" and "per_user"
+const ConcurrencySetting = "concurrency_limits"
+
+// ConcurrencyOverrides returns the organization's concurrency limit
+// overrides; nil means the configured default applies
+func (o *Organization) ConcurrencyOverrides() (perOrganization, perUser *int) {
+	raw, _ := o.Settings[ConcurrencySetting].(map[string]any)
+	limit := func(key string) *int {
+		value, ok := raw[key].(float64)
+		if !ok {
+			return nil
+		}
+		n := int(value)
+		return &n
+	}
+	return limit("per_organization"), limit("per_user")
+}
+
+// Usage metrics metered per organization
+const (
+	UsageAPICalls          = "api_calls"
+	UsageSimulationSeconds = "simulation_seconds"
+)
+
+// UsageCounter accumulates one usage metric of an organization over a UTC day
+type UsageCounter struct {
+	OrganizationID uuid.UUID `gorm:"type:uuid;primary_key" json:"organization_id"`
+	Day            time.Time `gorm:"type:date;primary_key" json:"day"`
+	Metric         string    `gorm:"primary_key" json:"metric"`
+	Value          float64   `gorm:"not null" json:"value"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Artifact stores a large payload outside of its owning row
+type Artifact struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OwnerTable  string    `gorm:"not null;index:idx_artifact_owner,priority:1" json:"owner_table"`
+	OwnerID     uuid.UUID `gorm:"type:uuid;not null;index:idx_artifact_owner,priority:2" json:"owner_id"`
+	Field       string    `gorm:"not null" json:"field"`
+	ContentType string    `gorm:"not null" json:"content_type"`
+	SizeBytes   int       `gorm:"not null" json:"size_bytes"`
+	Data        []byte    `gorm:"type:bytea;not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AuditLog records an action, including impersonated ones. Actions that
+// are audited whoever makes them have no actor when made anonymously.
+type AuditLog struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID        *uuid.UUID     `gorm:"type:uuid;index:idx_audit_actor" json:"actor_id,omitempty"`
+	ActorEmail     string         `json:"actor_email"`
+	ImpersonatorID *uuid.UUID     `gorm:"type:uuid;index:idx_audit_impersonator" json:"impersonator_id,omitempty"`
+	Impersonated   bool           `gorm:"default:false;index:idx_audit_impersonated" json:"impersonated"`
+	OrganizationID *uuid.UUID     `gorm:"type:uuid" json:"organization_id,omitempty"`
+	Action         string         `gorm:"not null" json:"action"`
+	Method         string         `json:"method"`
+	Path           string         `json:"path"`
+	StatusCode     int            `json:"status_code"`
+	ClientIP       string         `json:"client_ip"`
+	Details        map[string]any `gorm:"type:jsonb" json:"details"`
+	CreatedAt      time.Time      `gorm:"index:idx_audit_created" json:"created_at"`
+}
+
+// PredictionSnapshot records a forecast so it can be scored once the target time is reached
+type PredictionSnapshot struct {
+	ID                  uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SimulationID        uuid.UUID  `gorm:"type:uuid;not null;index:idx_prediction_simulation,priority:1" json:"simulation_id"`
+	Backend             string     `gorm:"not null" json:"backend"`
+	Model               string     `json:"model"`
+	GeneratedAt         time.Time  `gorm:"not null;index:idx_prediction_simulation,priority:2" json:"generated_at"`
+	TargetTime          time.Time  `gorm:"not null" json:"target_time"`
+	HorizonSeconds      float64    `gorm:"not null" json:"horizon_seconds"`
+	PredictedLoadMW     float64    `gorm:"not null" json:"predicted_load_mw"`
+	FailureProbability  float64    `json:"failure_probability"`
+	OptimalGenerationMW float64    `json:"optimal_generation_mw"`
+	Confidence          float64    `json:"confidence"`
+	ActualLoadMW        *float64   `json:"actual_load_mw,omitempty"`
+	AbsoluteErrorMW     *float64   `json:"absolute_error_mw,omitempty"`
+	EvaluatedAt         *time.Time `gorm:"index:idx_prediction_evaluated" json:"evaluated_at,omitempty"`
+}
+
+// Outbox event statuses
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxFailed    = "failed"
+)
+
+// OutboxEvent is an event awaiting delivery, written in the same transaction
+// as the state change it announces
+type OutboxEvent struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	// Events with a key already in the outbox are dropped, so retried writes publish once
+	DedupeKey string          `gorm:"not null;uniqueIndex" json:"dedupe_key"`
+	EventType string          `gorm:"not null" json:"event_type"`
+	Payload   json.RawMessage `gorm:"type:jsonb;serializer:json;not null" json:"payload"`
+	Status    string          `gorm:"not null;default:pending;index:idx_outbox_due,priority:1" json:"status"`
+	Attempts  int             `gorm:"not null;default:0" json:"attempts"`
+	// Pending events are not picked up before this time
+	NextAttemptAt time.Time `gorm:"not null;default:now();index:idx_outbox_due,priority:2" json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	// Subscriptions that already received the event and are skipped on retry
+	DeliveredTo []string   `gorm:"type:jsonb;serializer:json" json:"delivered_to"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `gorm:"index" json:"delivered_at,omitempty"`
+}
+
+// DistributedLock is a named lease shared by all replicas. A lease whose
+// expiry has passed may be taken over by another holder.
+type DistributedLock struct {
+	Name string `gorm:"primary_key" json:"name"`
+	// Token of the lease currently holding the lock
+	Holder     string    `gorm:"not null" json:"holder"`
+	Instance   string    `json:"instance"`
+	AcquiredAt time.Time `gorm:"not null" json:"acquired_at"`
+	ExpiresAt  time.Time `gorm:"not null" json:"expires_at"`
+}
+
+// ClusterNode is a gateway replica, kept alive by periodic heartbeats
+type ClusterNode struct {
+	ID string `gorm:"primary_key" json:"id"`
+	// Base URL other replicas use to reach the node's internal API
+	Address     string    `gorm:"not null" json:"address"`
+	StartedAt   time.Time `gorm:"not null" json:"started_at"`
+	HeartbeatAt time.Time `gorm:"not null;index" json:"heartbeat_at"`
+}
+
+// SimulationOwner records the replica that controls a simulation
+type SimulationOwner struct {
+	SimulationID string `gorm:"primary_key" json:"simulation_id"`
+	NodeID       string `gorm:"not null;index" json:"node_id"`
+	// Incremented on every transfer
+	Epoch int64 `gorm:"not null;default:1" json:"epoch"`
+	// Definition the new owner restores the simulation from after a takeover
+	Spec json.RawMessage `gorm:"type:jsonb;serializer:json;not null" json:"spec"`
+	// False until the owner has loaded the simulation
+	Adopted    bool      `gorm:"not null;default:false" json:"adopted"`
+	AssignedAt time.Time `gorm:"not null" json:"assigned_at"`
+}
+
+// ResultArchive points to a simulation's results after they were moved to
+// object storage
+type ResultArchive struct {
+	SimulationID uuid.UUID `gorm:"type:uuid;primary_key" json:"simulation_id"`
+	// Object name relative to the archive storage root
+	Object string `gorm:"not null" json:"object"`
+	// Full location, for operators
+	Location       string     `gorm:"not null" json:"location"`
+	Format         string     `gorm:"not null" json:"format"`
+	Rows           int64      `gorm:"not null" json:"rows"`
+	FirstTimestamp *time.Time `json:"first_timestamp"`
+	LastTimestamp  *time.Time `json:"last_timestamp"`
+	ArchivedAt     time.Time  `gorm:"not null" json:"archived_at"`
+	// Set while the results are back in the database; cleared when they
+	// are archived again
+	RehydratedAt *time.Time `gorm:"index" json:"rehydrated_at"`
+}
+
+// SimulationSnapshot points to a dump of a simulation's engine state in
+// object storage. Versions count up from 1 for each simulation.
+type SimulationSnapshot struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	// Orchestrator ID of the simulation the state was taken from
+	SimulationID string `gorm:"not null;uniqueIndex:idx_snapshot_version,priority:1" json:"simulation_id"`
+	Version      int    `gorm:"not null;uniqueIndex:idx_snapshot_version,priority:2" json:"version"`
+	// Object name relative to the snapshot storage root
+	Object    string     `gorm:"not null" json:"object"`
+	SizeBytes int64      `gorm:"not null" json:"size_bytes"`
+	Checksum  string     `gorm:"not null" json:"checksum"`
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Tag is a named label attached to simulations, experiments and batches
+type Tag struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string     `gorm:"not null;uniqueIndex:idx_tag_name" json:"name"`
+	Color       string     `json:"color"`
+	Description string     `json:"description"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// Number of resources carrying the tag; only filled in when reading
+	UsageCount int64 `gorm:"->;-:migration" json:"usage_count"`
+}
+
+// TagAssignment attaches a tag to a resource. Resources live in the
+// orchestrator, so they are referenced by type and ID rather than by key.
+type TagAssignment struct {
+	TagID        uuid.UUID `gorm:"type:uuid;primary_key" json:"tag_id"`
+	ResourceType string    `gorm:"primary_key;index:idx_tag_resource,priority:1" json:"resource_type"`
+	ResourceID   string    `gorm:"primary_key;index:idx_tag_resource,priority:2" json:"resource_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Dashboard is a saved view: the simulations, metrics and chart layout a
+// user arranged, kept as a JSON document the API does not interpret
+type Dashboard struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OwnerID        uuid.UUID      `gorm:"type:uuid;not null;index:idx_dashboard_owner" json:"owner_id"`
+	OrganizationID *uuid.UUID     `gorm:"type:uuid;index:idx_dashboard_organization" json:"organization_id,omitempty"`
+	Name           string         `gorm:"not null" json:"name"`
+	Description    string         `json:"description"`
+	Visibility     string         `gorm:"not null;default:private" json:"visibility"`
+	Document       map[string]any `gorm:"type:jsonb;not null" json:"document"`
+	// Users given access beyond what the visibility allows
+	Shares []DashboardShare `gorm:"foreignKey:DashboardID" json:"shares"`
+	// Incremented on every update, see UpdateDashboard
+	Version   int64     `gorm:"not null;default:1" json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DashboardShare grants a user view or edit access to a dashboard
+type DashboardShare struct {
+	DashboardID uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	UserID      uuid.UUID `gorm:"type:uuid;primary_key;index:idx_dashboard_share_user" json:"user_id"`
+	Permission  string    `gorm:"not null" json:"permission"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DashboardRevision is a dashboard as saved at one version
+type DashboardRevision struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DashboardID uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_dashboard_revision,priority:1" json:"dashboard_id"`
+	Version     int64          `gorm:"not null;uniqueIndex:idx_dashboard_revision,priority:2" json:"version"`
+	Name        string         `gorm:"not null" json:"name"`
+	Description string         `json:"description"`
+	Document    map[string]any `gorm:"type:jsonb;not null" json:"document"`
+	EditedBy    uuid.UUID      `gorm:"type:uuid;not null" json:"edited_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// ShareLink gives read-only access to a simulation without logging in, through
+// a signed token carrying the link's ID, until it expires or is revoked
+type ShareLink struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SimulationID uuid.UUID  `gorm:"type:uuid;not null;index:idx_share_link_simulation" json:"simulation_id"`
+	Label        string     `json:"label"`
+	CreatedBy    uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy    *uuid.UUID `gorm:"type:uuid" json:"revoked_by,omitempty"`
+	// Requests made with the link
+	AccessCount    int64      `gorm:"not null;default:0" json:"access_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Active reports whether the link still grants access at a time
+func (l *ShareLink) Active(at time.Time) bool {
+	return l.RevokedAt == nil && at.Before(l.ExpiresAt)
+}
+
+// Login attempt failure reasons
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureLocked             = "locked"
+)
+
+// LoginAttempt records a login, successful or not. Attempts for unknown
+// emails have no user.
+type LoginAttempt struct {
+	ID      uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID  *uuid.UUID `gorm:"type:uuid;index:idx_login_attempt_user,priority:1" json:"user_id,omitempty"`
+	Email   string     `gorm:"not null" json:"email"`
+	Success bool       `gorm:"not null" json:"success"`
+	// invalid_credentials or locked when the attempt failed
+	FailureReason string    `json:"failure_reason,omitempty"`
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	CreatedAt     time.Time `gorm:"index:idx_login_attempt_user,priority:2" json:"created_at"`
+}
+
+// UserSession is a token issued at login. Its ID is the token's ID, so the
+// token stops working once the session is revoked.
+type UserSession struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	IPAddress string     `json:"ip_address"`
+	UserAgent string     `json:"user_agent"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
 // TableName returns the table name for GORM
 func (User) TableName() string {
 	return "users"
@@ -168,10 +872,18 @@ func (PowerPlant) TableName() string {
 	return "power_plants"
 }
 
+func (Bus) TableName() string {
+	return "buses"
+}
+
 func (TransmissionLine) TableName() string {
 	return "transmission_lines"
 }
 
+func (StorageUnit) TableName() string {
+	return "storage_units"
+}
+
 func (SimulationResult) TableName() string {
 	return "simulation_results"
 }
@@ -188,68 +900,307 @@ func (Alert) TableName() string {
 	return "alerts"
 }
 
+func (StabilityScore) TableName() string {
+	return "stability_scores"
+}
+
+func (EmissionSummary) TableName() string {
+	return "emission_summaries"
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+func (AlertRoute) TableName() string {
+	return "alert_routes"
+}
+
+func (AlertNotification) TableName() string {
+	return "alert_notifications"
+}
+
+func (AlertSilence) TableName() string {
+	return "alert_silences"
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+func (NotificationDigestItem) TableName() string {
+	return "notification_digest_items"
+}
+
+func (Artifact) TableName() string {
+	return "artifacts"
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (PredictionSnapshot) TableName() string {
+	return "prediction_snapshots"
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+func (DistributedLock) TableName() string {
+	return "distributed_locks"
+}
+
+func (ClusterNode) TableName() string {
+	return "cluster_nodes"
+}
+
+func (SimulationOwner) TableName() string {
+	return "simulation_owners"
+}
+
+func (ResultArchive) TableName() string {
+	return "result_archives"
+}
+
+func (SimulationSnapshot) TableName() string {
+	return "simulation_snapshots"
+}
+
+func (UsageCounter) TableName() string {
+	return "usage_counters"
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+func (TagAssignment) TableName() string {
+	return "tag_assignments"
+}
+
+func (Dashboard) TableName() string {
+	return "dashboards"
+}
+
+func (DashboardShare) TableName() string {
+	return "dashboard_shares"
+}
+
+func (DashboardRevision) TableName() string {
+	return "dashboard_revisions"
+}
+
+func (ShareLink) TableName() string {
+	return "share_links"
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
 // BeforeCreate hook for UUID generation
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
-		u.ID = uuid.New()
+		u.ID = NewID()
 	}
 	return nil
 }
 
 func (o *Organization) BeforeCreate(tx *gorm.DB) error {
 	if o.ID == uuid.Nil {
-		o.ID = uuid.New()
+		o.ID = NewID()
 	}
 	return nil
 }
 
 func (s *Simulation) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == uuid.Nil {
-		s.ID = uuid.New()
+		s.ID = NewID()
 	}
 	return nil
 }
 
 func (pp *PowerPlant) BeforeCreate(tx *gorm.DB) error {
 	if pp.ID == uuid.Nil {
-		pp.ID = uuid.New()
+		pp.ID = NewID()
+	}
+	return nil
+}
+
+func (b *Bus) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = NewID()
 	}
 	return nil
 }
 
 func (tl *TransmissionLine) BeforeCreate(tx *gorm.DB) error {
 	if tl.ID == uuid.Nil {
-		tl.ID = uuid.New()
+		tl.ID = NewID()
+	}
+	return nil
+}
+
+func (su *StorageUnit) BeforeCreate(tx *gorm.DB) error {
+	if su.ID == uuid.Nil {
+		su.ID = NewID()
 	}
 	return nil
 }
 
 func (sr *SimulationResult) BeforeCreate(tx *gorm.DB) error {
 	if sr.ID == uuid.Nil {
-		sr.ID = uuid.New()
+		sr.ID = NewID()
 	}
 	return nil
 }
 
 func (cm *ComponentMetric) BeforeCreate(tx *gorm.DB) error {
 	if cm.ID == uuid.Nil {
-		cm.ID = uuid.New()
+		cm.ID = NewID()
 	}
 	return nil
 }
 
 func (fe *FaultEvent) BeforeCreate(tx *gorm.DB) error {
 	if fe.ID == uuid.Nil {
-		fe.ID = uuid.New()
+		fe.ID = NewID()
 	}
 	return nil
 }
 
 func (a *Alert) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
-		a.ID = uuid.New()
+		a.ID = NewID()
+	}
+	return nil
+}
+
+func (es *EmissionSummary) BeforeCreate(tx *gorm.DB) error {
+	if es.ID == uuid.Nil {
+		es.ID = NewID()
+	}
+	return nil
+}
+
+func (w *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = NewID()
+	}
+	return nil
+}
+
+func (nc *NotificationChannel) BeforeCreate(tx *gorm.DB) error {
+	if nc.ID == uuid.Nil {
+		nc.ID = NewID()
+	}
+	return nil
+}
+
+func (ar *AlertRoute) BeforeCreate(tx *gorm.DB) error {
+	if ar.ID == uuid.Nil {
+		ar.ID = NewID()
+	}
+	return nil
+}
+
+func (an *AlertNotification) BeforeCreate(tx *gorm.DB) error {
+	if an.ID == uuid.Nil {
+		an.ID = NewID()
+	}
+	return nil
+}
+
+func (as *AlertSilence) BeforeCreate(tx *gorm.DB) error {
+	if as.ID == uuid.Nil {
+		as.ID = NewID()
+	}
+	return nil
+}
+
+func (di *NotificationDigestItem) BeforeCreate(tx *gorm.DB) error {
+	if di.ID == uuid.Nil {
+		di.ID = NewID()
+	}
+	return nil
+}
+
+func (a *Artifact) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = NewID()
+	}
+	return nil
+}
+
+func (al *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if al.ID == uuid.Nil {
+		al.ID = NewID()
+	}
+	return nil
+}
+
+func (ps *PredictionSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if ps.ID == uuid.Nil {
+		ps.ID = NewID()
+	}
+	return nil
+}
+
+func (oe *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if oe.ID == uuid.Nil {
+		oe.ID = NewID()
+	}
+	return nil
+}
+
+func (ss *SimulationSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if ss.ID == uuid.Nil {
+		ss.ID = NewID()
+	}
+	return nil
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = NewID()
+	}
+	return nil
+}
+
+func (d *Dashboard) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = NewID()
+	}
+	return nil
+}
+
+func (dr *DashboardRevision) BeforeCreate(tx *gorm.DB) error {
+	if dr.ID == uuid.Nil {
+		dr.ID = NewID()
 	}
 	return nil
 }
 
+func (sl *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if sl.ID == uuid.Nil {
+		sl.ID = NewID()
+	}
+	return nil
+}
 
+func (la *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if la.ID == uuid.Nil {
+		la.ID = NewID()
+	}
+	return nil
+}
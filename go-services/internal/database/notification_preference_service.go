@@ -0,0 +1,239 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationPreferenceService provides notification preference and digest database operations
+type NotificationPreferenceService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewNotificationPreferenceService creates a new notification preference service
+func NewNotificationPreferenceService(db *gorm.DB, logger *logrus.Logger) *NotificationPreferenceService {
+	return &NotificationPreferenceService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetPreference retrieves a user's notification preferences
+func (s *NotificationPreferenceService) GetPreference(userID uuid.UUID) (*NotificationPreference, error) {
+	var preference NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Take(&preference).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to get notification preferences")
+		return nil, err
+	}
+
+	return &preference, nil
+}
+
+// SavePreference creates or replaces a user's notification preferences
+func (s *NotificationPreferenceService) SavePreference(preference *NotificationPreference) error {
+	preference.UpdatedAt = time.Now()
+	err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"event_types", "channels", "quiet_hours_start", "quiet_hours_end",
+			"timezone", "delivery", "digest_interval_minutes", "report_schedule", "updated_at",
+		}),
+	}).Create(preference).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", preference.UserID).Error("Failed to save notification preferences")
+		return err
+	}
+
+	return nil
+}
+
+// DeletePreference removes a user's notification preferences, so every
+// notification is delivered immediately again. Digest items already held
+// for the user are still sent when due.
+func (s *NotificationPreferenceService) DeletePreference(userID uuid.UUID) error {
+	if err := s.db.Delete(&NotificationPreference{}, "user_id = ?", userID).Error; err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to delete notification preferences")
+		return err
+	}
+
+	return nil
+}
+
+// GetNotificationPreferences retrieves the preferences of several users by
+// user ID. Users without preferences are absent from the map.
+func (s *NotificationPreferenceService) GetNotificationPreferences(userIDs []uuid.UUID) (map[uuid.UUID]NotificationPreference, error) {
+	result := make(map[uuid.UUID]NotificationPreference)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	var preferences []NotificationPreference
+	if err := s.db.Where("user_id IN ?", userIDs).Find(&preferences).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to load notification preferences")
+		return nil, err
+	}
+
+	for _, preference := range preferences {
+		result[preference.UserID] = preference
+	}
+	return result, nil
+}
+
+// GetNotificationPreferencesByEmail retrieves the preferences of the users
+// with the given email addresses, keyed by lowercased address
+func (s *NotificationPreferenceService) GetNotificationPreferencesByEmail(emails []string) (map[string]NotificationPreference, error) {
+	result := make(map[string]NotificationPreference)
+	if len(emails) == 0 {
+		return result, nil
+	}
+
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+
+	var rows []struct {
+		NotificationPreference
+		Email string
+	}
+	err := s.db.Model(&NotificationPreference{}).
+		Select("notification_preferences.*, LOWER(users.email) AS email").
+		Joins("JOIN users ON users.id = notification_preferences.user_id").
+		Where("LOWER(users.email) IN ?", lowered).
+		Find(&rows).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load notification preferences by email")
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.Email] = row.NotificationPreference
+	}
+	return result, nil
+}
+
+// ScheduledReportRecipient is an active user who opted in to scheduled reports
+type ScheduledReportRecipient struct {
+	NotificationPreference
+	Email          string
+	OrganizationID *uuid.UUID
+}
+
+// GetScheduledReportRecipients retrieves the active users with a report
+// schedule and their preferences
+func (s *NotificationPreferenceService) GetScheduledReportRecipients() ([]ScheduledReportRecipient, error) {
+	var recipients []ScheduledReportRecipient
+	err := s.db.Model(&NotificationPreference{}).
+		Select("notification_preferences.*, users.email, users.organization_id").
+		Joins("JOIN users ON users.id = notification_preferences.user_id").
+		Where("notification_preferences.report_schedule <> '' AND users.is_active").
+		Find(&recipients).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load scheduled report recipients")
+		return nil, err
+	}
+	return recipients, nil
+}
+
+// ClaimScheduledReport records that a user's report for the period ending at
+// periodEnd is being sent. It returns false when another sender already
+// claimed it.
+func (s *NotificationPreferenceService) ClaimScheduledReport(userID uuid.UUID, periodEnd time.Time) (bool, error) {
+	result := s.db.Model(&NotificationPreference{}).
+		Where("user_id = ? AND (last_report_at IS NULL OR last_report_at < ?)", userID, periodEnd).
+		Update("last_report_at", periodEnd)
+	if result.Error != nil {
+		s.logger.WithError(result.Error).WithField("user_id", userID).Error("Failed to claim scheduled report")
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseScheduledReport restores a user's last report period after their
+// claimed report failed to send, so it is retried
+func (s *NotificationPreferenceService) ReleaseScheduledReport(userID uuid.UUID, periodEnd time.Time, previous *time.Time) error {
+	err := s.db.Model(&NotificationPreference{}).
+		Where("user_id = ? AND last_report_at = ?", userID, periodEnd).
+		Update("last_report_at", previous).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to release scheduled report")
+		return err
+	}
+	return nil
+}
+
+// EnqueueDigestItems holds events for a later digest
+func (s *NotificationPreferenceService) EnqueueDigestItems(items ...*NotificationDigestItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := s.db.Create(items).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to hold notifications for digest")
+		return err
+	}
+	return nil
+}
+
+// ClaimDueDigestItems returns held events whose digest is due, with their
+// subscription or channel, and leases them so other dispatchers skip them
+// until the lease runs out
+func (s *NotificationPreferenceService) ClaimDueDigestItems(limit int, lease time.Duration) ([]NotificationDigestItem, error) {
+	var ids []uuid.UUID
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&NotificationDigestItem{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("deliver_after <= ?", time.Now()).
+			Order("deliver_after").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		return tx.Model(&NotificationDigestItem{}).
+			Where("id IN ?", ids).
+			Update("deliver_after", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to claim notification digest items")
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var items []NotificationDigestItem
+	err = s.db.Preload("Subscription").
+		Preload("Channel").
+		Where("id IN ?", ids).
+		Order("created_at").
+		Find(&items).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load notification digest items")
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// DeleteDigestItems removes held events once their digest is sent
+func (s *NotificationPreferenceService) DeleteDigestItems(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.db.Delete(&NotificationDigestItem{}, "id IN ?", ids).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to delete notification digest items")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxService provides transactional outbox database operations
+type OutboxService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewOutboxService creates a new outbox service
+func NewOutboxService(db *gorm.DB, logger *logrus.Logger) *OutboxService {
+	return &OutboxService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Enqueue adds events to the outbox outside of any other state change
+func (s *OutboxService) Enqueue(events ...*OutboxEvent) error {
+	if err := enqueueOutbox(s.db, events); err != nil {
+		s.logger.WithError(err).Error("Failed to enqueue outbox events")
+		return err
+	}
+	return nil
+}
+
+// ClaimDue returns pending events whose next attempt is due and leases them
+// so other dispatchers skip them until the lease runs out
+func (s *OutboxService) ClaimDue(limit int, lease time.Duration) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", OutboxPending, time.Now()).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+		return tx.Model(&OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to claim outbox events")
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkDelivered records that an event reached every subscriber
+func (s *OutboxService) MarkDelivered(event *OutboxEvent) error {
+	now := time.Now()
+	event.Status = OutboxDelivered
+	event.Attempts++
+	event.DeliveredAt = &now
+	event.LastError = ""
+
+	err := s.db.Model(event).
+		Select("status", "attempts", "delivered_to", "delivered_at", "last_error").
+		Updates(event).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to mark outbox event delivered")
+		return err
+	}
+	return nil
+}
+
+// RecordFailure records a failed delivery attempt. The event is retried at
+// retryAt, or marked failed for good when giveUp is set.
+func (s *OutboxService) RecordFailure(event *OutboxEvent, deliveryErr error, retryAt time.Time, giveUp bool) error {
+	event.Status = OutboxPending
+	if giveUp {
+		event.Status = OutboxFailed
+	}
+	event.Attempts++
+	event.LastError = deliveryErr.Error()
+	event.NextAttemptAt = retryAt
+
+	err := s.db.Model(event).
+		Select("status", "attempts", "delivered_to", "last_error", "next_attempt_at").
+		Updates(event).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to record outbox delivery failure")
+		return err
+	}
+	return nil
+}
+
+// PurgeDelivered deletes events delivered before the cutoff
+func (s *OutboxService) PurgeDelivered(before time.Time) (int64, error) {
+	result := s.db.Where("status = ? AND delivered_at < ?", OutboxDelivered, before).Delete(&OutboxEvent{})
+	if result.Error != nil {
+		s.logger.WithError(result.Error).Error("Failed to purge delivered outbox events")
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// enqueueOutbox writes events through db, which may be a transaction. Events
+// whose dedupe key is already present are skipped.
+func enqueueOutbox(db *gorm.DB, events []*OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dedupe_key"}},
+		DoNothing: true,
+	}).Create(events).Error
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Component metric rows carrying a power plant's output. Plants are numbered
+// as in the power_plants table, by their position in the config.
+const (
+	ComponentTypePowerPlant = "power_plant"
+	MetricOutputMW          = "output_mw"
+)
+
+// GetPlantOutputs retrieves the output readings of a simulation's power
+// plants between two optional timestamps, oldest first
+func (s *SimulationService) GetPlantOutputs(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]ComponentMetric, error) {
+	var readings []ComponentMetric
+
+	query := s.db.WithContext(ctx).
+		Where("simulation_id = ? AND component_type = ? AND metric_name = ?", simulationID, ComponentTypePowerPlant, MetricOutputMW)
+	err := applyTimeRange(query, "timestamp", from, to).
+		Order("timestamp ASC, component_id ASC").
+		Find(&readings).Error
+
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to get plant outputs")
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+// GetLatestPlantOutputs retrieves the most recent output reading of each of
+// a simulation's power plants, by plant ID
+func (s *SimulationService) GetLatestPlantOutputs(ctx context.Context, simulationID uuid.UUID) (map[int]float64, error) {
+	var rows []struct {
+		ComponentID int
+		MetricValue float64
+	}
+
+	err := s.db.WithContext(ctx).Model(&ComponentMetric{}).
+		Select("DISTINCT ON (component_id) component_id, metric_value").
+		Where("simulation_id = ? AND component_type = ? AND metric_name = ?", simulationID, ComponentTypePowerPlant, MetricOutputMW).
+		Order("component_id, timestamp DESC").
+		Scan(&rows).Error
+
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to get latest plant outputs")
+		return nil, err
+	}
+
+	outputs := make(map[int]float64, len(rows))
+	for _, row := range rows {
+		outputs[row.ComponentID] = row.MetricValue
+	}
+	return outputs, nil
+}
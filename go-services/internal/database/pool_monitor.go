@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/observability"
+)
+
+// PoolMonitorOptions configures a PoolMonitor
+type PoolMonitorOptions struct {
+	// The pool is sampled this often
+	Interval time.Duration
+	// Waits for a free connection per second, averaged over an interval, at
+	// which the pool counts as saturated
+	WaitRateThreshold float64
+}
+
+// PoolStats is a sample of the connection pool
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+	// Waits for a free connection per second since the previous sample
+	WaitRate  float64   `json:"wait_rate"`
+	Saturated bool      `json:"saturated"`
+	SampledAt time.Time `json:"sampled_at"`
+}
+
+// PoolHealth reports whether the pool keeps up with its callers
+type PoolHealth struct {
+	IsHealthy bool      `json:"is_healthy"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PoolMonitor samples the connection pool on a ticker, publishes its stats
+// as metrics and reports the pool saturated while queries queue for
+// connections faster than the threshold
+type PoolMonitor struct {
+	conn *Connection
+	opts PoolMonitorOptions
+
+	mu        sync.RWMutex
+	last      sql.DBStats
+	sampledAt time.Time
+	waitRate  float64
+	saturated bool
+}
+
+// NewPoolMonitor creates a monitor of a connection's pool
+func NewPoolMonitor(conn *Connection, opts PoolMonitorOptions) *PoolMonitor {
+	if opts.Interval <= 0 {
+		opts.Interval = 15 * time.Second
+	}
+	return &PoolMonitor{
+		conn: conn,
+		opts: opts,
+	}
+}
+
+// Start samples the pool immediately and then every interval until ctx is
+// done
+func (m *PoolMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := m.Sample(); err != nil {
+				logrus.WithError(err).Warn("Failed to sample database pool")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Sample reads the pool's stats, records them and updates the saturation
+// state
+func (m *PoolMonitor) Sample() error {
+	sqlDB, err := m.conn.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	stats := sqlDB.Stats()
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.last
+	waitRate := 0.0
+	if !m.sampledAt.IsZero() {
+		if elapsed := now.Sub(m.sampledAt).Seconds(); elapsed > 0 {
+			waitRate = float64(stats.WaitCount-previous.WaitCount) / elapsed
+		}
+	}
+	saturated := m.opts.WaitRateThreshold > 0 && waitRate >= m.opts.WaitRateThreshold
+
+	if saturated && !m.saturated {
+		logrus.WithFields(logrus.Fields{
+			"wait_rate": waitRate,
+			"in_use":    stats.InUse,
+			"max_open":  stats.MaxOpenConnections,
+		}).Warn("Database pool saturated")
+	} else if !saturated && m.saturated {
+		logrus.Info("Database pool no longer saturated")
+	}
+
+	m.last = stats
+	m.sampledAt = now
+	m.waitRate = waitRate
+	m.saturated = saturated
+
+	observability.RecordDatabasePool(stats, previous, saturated)
+	return nil
+}
+
+// Stats returns the latest sample
+func (m *PoolMonitor) Stats() PoolStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return PoolStats{
+		MaxOpenConnections: m.last.MaxOpenConnections,
+		OpenConnections:    m.last.OpenConnections,
+		InUse:              m.last.InUse,
+		Idle:               m.last.Idle,
+		WaitCount:          m.last.WaitCount,
+		WaitDuration:       m.last.WaitDuration.String(),
+		MaxIdleClosed:      m.last.MaxIdleClosed,
+		MaxIdleTimeClosed:  m.last.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  m.last.MaxLifetimeClosed,
+		WaitRate:           m.waitRate,
+		Saturated:          m.saturated,
+		SampledAt:          m.sampledAt,
+	}
+}
+
+// Health reports the pool degraded while it is saturated
+func (m *PoolMonitor) Health() PoolHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	health := PoolHealth{
+		IsHealthy: true,
+		Message:   "Database pool is healthy",
+		Timestamp: time.Now(),
+	}
+	if m.saturated {
+		health.IsHealthy = false
+		health.Message = fmt.Sprintf("Database pool saturated: %.1f waits/s for a free connection", m.waitRate)
+	}
+	return health
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PredictionService provides prediction snapshot database operations
+type PredictionService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewPredictionService creates a new prediction service
+func NewPredictionService(db *gorm.DB, logger *logrus.Logger) *PredictionService {
+	return &PredictionService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SavePredictionSnapshot stores a forecast for later scoring
+func (s *PredictionService) SavePredictionSnapshot(snapshot *PredictionSnapshot) error {
+	if err := s.db.Create(snapshot).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to save prediction snapshot")
+		return err
+	}
+
+	return nil
+}
+
+// GetDuePredictionSnapshots retrieves unscored snapshots whose target time has passed
+func (s *PredictionService) GetDuePredictionSnapshots(simulationID uuid.UUID, until time.Time) ([]PredictionSnapshot, error) {
+	var snapshots []PredictionSnapshot
+
+	err := s.db.Where("simulation_id = ? AND evaluated_at IS NULL AND target_time <= ?", simulationID, until).
+		Order("target_time ASC").
+		Find(&snapshots).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get due prediction snapshots")
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// ScorePredictionSnapshot records the observed value for a snapshot
+func (s *PredictionService) ScorePredictionSnapshot(id uuid.UUID, actualLoadMW, absoluteErrorMW float64) error {
+	err := s.db.Model(&PredictionSnapshot{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"actual_load_mw":    actualLoadMW,
+		"absolute_error_mw": absoluteErrorMW,
+		"evaluated_at":      time.Now().UTC(),
+	}).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("snapshot_id", id).Error("Failed to score prediction snapshot")
+		return err
+	}
+
+	return nil
+}
+
+// GetScoredPredictionSnapshots retrieves the most recently generated scored snapshots
+func (s *PredictionService) GetScoredPredictionSnapshots(simulationID uuid.UUID, limit int) ([]PredictionSnapshot, error) {
+	var snapshots []PredictionSnapshot
+
+	err := s.db.Where("simulation_id = ? AND evaluated_at IS NOT NULL", simulationID).
+		Order("generated_at DESC").
+		Limit(limit).
+		Find(&snapshots).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get scored prediction snapshots")
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// GetResultAt retrieves the first result recorded at or after a timestamp
+func (s *PredictionService) GetResultAt(simulationID uuid.UUID, at time.Time) (*SimulationResult, error) {
+	var result SimulationResult
+
+	err := s.db.Where("simulation_id = ? AND timestamp >= ?", simulationID, at).
+		Order("timestamp ASC").
+		First(&result).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get simulation result")
+		return nil, err
+	}
+
+	return &result, nil
+}
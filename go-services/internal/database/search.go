@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// searchConfig is the text search configuration documents and queries are
+// parsed with. The simple configuration lowercases words without stemming,
+// so prefixes typed into a search bar match the way they read.
+const searchConfig = "simple"
+
+// textSearch returns a condition matching rows whose document, an SQL
+// expression, matches a text search query
+func textSearch(document string) string {
+	return fmt.Sprintf("to_tsvector('%s', %s) @@ to_tsquery('%s', ?)", searchConfig, document, searchConfig)
+}
+
+// SearchSimulations retrieves simulations whose name, description or
+// metadata match a text search query, newest first
+func (s *SimulationService) SearchSimulations(ctx context.Context, tsquery string, limit int) ([]Simulation, error) {
+	var simulations []Simulation
+	err := s.db.WithContext(ctx).
+		Where(textSearch("name || ' ' || COALESCE(description, '') || ' ' || COALESCE(metadata::text, '')"), tsquery).
+		Order(recentFirst("created_at")).
+		Limit(limit).
+		Find(&simulations).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to search simulations")
+		return nil, err
+	}
+
+	return simulations, nil
+}
+
+// SearchTags retrieves tags whose name or description match a text search
+// query, most used first
+func (s *TagService) SearchTags(tsquery string, limit int) ([]Tag, error) {
+	var tags []Tag
+	err := s.withUsage(s.db.Model(&Tag{})).
+		Where(textSearch("tags.name || ' ' || COALESCE(tags.description, '')"), tsquery).
+		Order("usage_count DESC").
+		Order("tags.name").
+		Limit(limit).
+		Find(&tags).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to search tags")
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SessionService provides login history and user session database operations
+type SessionService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(db *gorm.DB, logger *logrus.Logger) *SessionService {
+	return &SessionService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RecordLoginAttempt stores a login attempt
+func (s *SessionService) RecordLoginAttempt(ctx context.Context, attempt *LoginAttempt) error {
+	if err := s.db.WithContext(ctx).Create(attempt).Error; err != nil {
+		s.logger.WithError(err).WithField("email", attempt.Email).Error("Failed to record login attempt")
+		return err
+	}
+	return nil
+}
+
+// ListLoginAttempts retrieves a user's most recent login attempts, newest first
+func (s *SessionService) ListLoginAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to list login attempts")
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// FailedLogins counts a user's logins that failed on invalid credentials
+// since a time and since their last successful login, and returns when the
+// earliest of them was made
+func (s *SessionService) FailedLogins(ctx context.Context, userID uuid.UUID, since time.Time) (int, time.Time, error) {
+	var result struct {
+		Count    int
+		Earliest *time.Time
+	}
+	lastSuccess := s.db.WithContext(ctx).Model(&LoginAttempt{}).
+		Select("COALESCE(MAX(created_at), ?)", since).
+		Where("user_id = ? AND success", userID)
+	err := s.db.WithContext(ctx).Model(&LoginAttempt{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS earliest").
+		Where("user_id = ? AND NOT success AND failure_reason = ?", userID, LoginFailureInvalidCredentials).
+		Where("created_at >= ? AND created_at > (?)", since, lastSuccess).
+		Scan(&result).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to count failed logins")
+		return 0, time.Time{}, err
+	}
+
+	var earliest time.Time
+	if result.Earliest != nil {
+		earliest = *result.Earliest
+	}
+	return result.Count, earliest, nil
+}
+
+// PruneLoginAttempts deletes login attempts made before a time
+func (s *SessionService) PruneLoginAttempts(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("created_at < ?", before).Delete(&LoginAttempt{})
+	if result.Error != nil {
+		s.logger.WithError(result.Error).Error("Failed to prune login attempts")
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// loginHistoryPruneInterval is how often expired login attempts are deleted
+const loginHistoryPruneInterval = time.Hour
+
+// RunLoginHistoryPruning deletes login attempts older than retention, and
+// sessions that have since expired, every hour until ctx is done
+func (s *SessionService) RunLoginHistoryPruning(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(loginHistoryPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().UTC().Add(-retention)
+		attempts, err := s.PruneLoginAttempts(ctx, cutoff)
+		if err == nil {
+			var sessions int64
+			sessions, err = s.PruneSessions(ctx, cutoff)
+			if attempts > 0 || sessions > 0 {
+				s.logger.WithFields(logrus.Fields{
+					"login_attempts": attempts,
+					"sessions":       sessions,
+				}).Info("Login history pruned")
+			}
+		}
+		if err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).Warn("Login history pruning failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CreateSession stores a session started at login
+func (s *SessionService) CreateSession(ctx context.Context, session *UserSession) error {
+	if err := s.db.WithContext(ctx).Create(session).Error; err != nil {
+		s.logger.WithError(err).WithField("user_id", session.UserID).Error("Failed to create user session")
+		return err
+	}
+	return nil
+}
+
+// ListSessions retrieves a user's sessions that are neither expired nor
+// revoked, newest first
+func (s *SessionService) ListSessions(ctx context.Context, userID uuid.UUID) ([]UserSession, error) {
+	var sessions []UserSession
+	err := s.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC()).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID).Error("Failed to list user sessions")
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of a user's sessions, reporting false when the
+// user has no such session or it was already revoked
+func (s *SessionService) RevokeSession(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		s.logger.WithError(result.Error).WithField("session_id", id).Error("Failed to revoke user session")
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// PruneSessions deletes sessions that expired before a time
+func (s *SessionService) PruneSessions(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&UserSession{})
+	if result.Error != nil {
+		s.logger.WithError(result.Error).Error("Failed to prune user sessions")
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// SessionRevoked reports whether a session was revoked. Tokens without a
+// session, such as impersonation tokens, are not revoked.
+func (s *SessionService) SessionRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&UserSession{}).
+		Where("id = ? AND revoked_at IS NOT NULL", id).
+		Count(&count).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("session_id", id).Error("Failed to check user session")
+		return false, err
+	}
+	return count > 0, nil
+}
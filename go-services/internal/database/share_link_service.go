@@ -0,0 +1,84 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ShareLinkService provides simulation share link database operations
+type ShareLinkService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewShareLinkService creates a new share link service
+func NewShareLinkService(db *gorm.DB, logger *logrus.Logger) *ShareLinkService {
+	return &ShareLinkService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateShareLink creates a share link
+func (s *ShareLinkService) CreateShareLink(link *ShareLink) error {
+	if err := s.db.Create(link).Error; err != nil {
+		s.logger.WithError(err).WithField("simulation_id", link.SimulationID).Error("Failed to create share link")
+		return err
+	}
+	return nil
+}
+
+// GetShareLink retrieves a share link by ID
+func (s *ShareLinkService) GetShareLink(id uuid.UUID) (*ShareLink, error) {
+	var link ShareLink
+	if err := s.db.Where("id = ?", id).Take(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("share_link_id", id).Error("Failed to get share link")
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListShareLinks retrieves a simulation's share links, newest first,
+// including expired and revoked ones
+func (s *ShareLinkService) ListShareLinks(simulationID uuid.UUID) ([]ShareLink, error) {
+	var links []ShareLink
+	if err := s.db.Where("simulation_id = ?", simulationID).Order("created_at DESC").Find(&links).Error; err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to list share links")
+		return nil, err
+	}
+	return links, nil
+}
+
+// RevokeShareLink revokes a share link, reporting false when it was already
+// revoked
+func (s *ShareLinkService) RevokeShareLink(id, revokedBy uuid.UUID) (bool, error) {
+	result := s.db.Model(&ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]any{"revoked_at": time.Now().UTC(), "revoked_by": revokedBy})
+	if result.Error != nil {
+		s.logger.WithError(result.Error).WithField("share_link_id", id).Error("Failed to revoke share link")
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordShareAccess counts a request made with a share link
+func (s *ShareLinkService) RecordShareAccess(id uuid.UUID, at time.Time) error {
+	err := s.db.Model(&ShareLink{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"access_count":     gorm.Expr("access_count + 1"),
+			"last_accessed_at": at,
+		}).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("share_link_id", id).Error("Failed to record share link access")
+		return err
+	}
+	return nil
+}
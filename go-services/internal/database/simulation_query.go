@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SimulationStatusCreated is the status of a simulation row that has never run
+const SimulationStatusCreated = "created"
+
+// SimulationQuery builds a filtered simulation listing. Each filter narrows
+// the ones before it, and a query can be extended with custom scopes:
+//
+//	query := NewSimulationQuery().
+//		Status("completed", "stopped").
+//		Organization(organizationID).
+//		NameContains("winter").
+//		HasFaults(true)
+//	simulations, total, err := service.ListSimulations(ctx, query, 20, 0)
+type SimulationQuery struct {
+	scopes []func(*gorm.DB) *gorm.DB
+}
+
+// NewSimulationQuery returns a query matching every simulation
+func NewSimulationQuery() *SimulationQuery {
+	return &SimulationQuery{}
+}
+
+// Where adds a custom scope to the query
+func (q *SimulationQuery) Where(scope func(*gorm.DB) *gorm.DB) *SimulationQuery {
+	q.scopes = append(q.scopes, scope)
+	return q
+}
+
+// Status keeps simulations whose row has one of the statuses
+func (q *SimulationQuery) Status(statuses ...string) *SimulationQuery {
+	if len(statuses) == 0 {
+		return q
+	}
+	return q.Where(func(db *gorm.DB) *gorm.DB {
+		return db.Where("simulations.status IN ?", statuses)
+	})
+}
+
+// Tags keeps simulations carrying any of the tags
+func (q *SimulationQuery) Tags(tags ...string) *SimulationQuery {
+	if len(tags) == 0 {
+		return q
+	}
+	return q.Where(func(db *gorm.DB) *gorm.DB {
+		// Simulations without tags store a JSON null
+		return db.Where(`EXISTS (SELECT 1 FROM jsonb_array_elements_text(
+			CASE WHEN jsonb_typeof(simulations.tags) = 'array' THEN simulations.tags ELSE '[]' END
+		) AS tag WHERE tag IN ?)`, tags)
+	})
+}
+
+// Owner keeps simulations created by a user
+func (q *SimulationQuery) Owner(userID uuid.UUID) *SimulationQuery {
+	return q.Where(func(db *gorm.DB) *gorm.DB {
+		return db.Where("simulations.user_id = ?", userID)
+	})
+}
+
+// Organization keeps simulations metered against an organization
+func (q *SimulationQuery) Organization(organizationID uuid.UUID) *SimulationQuery {
+	return q.Where(func(db *gorm.DB) *gorm.DB {
+		return db.Where("simulations.organization_id = ?", organizationID)
+	})
+}
+
+// CreatedBetween keeps simulations created within the range; either end
+// may be nil
+func (q *SimulationQuery) CreatedBetween(from, to *time.Time) *SimulationQuery {
+	return q.between("simulations.created_at", from, to)
+}
+
+// StartedBetween keeps simulations whose last run started within the range;
+// either end may be nil
+func (q *SimulationQuery) StartedBetween(from, to *time.Time) *SimulationQuery {
+	return q.between("simulations.started_at", from, to)
+}
+
+// CompletedBetween keeps simulations whose last run ended within the range;
+// either end may be nil
+func (q *SimulationQuery) CompletedBetween(from, to *time.Time) *SimulationQuery {
+	return q.between("simulations.completed_at", from, to)
+}
+
+func (q *SimulationQuery) between(column string, from, to *time.Time) *SimulationQuery {
+	if from != nil {
+		q.Where(func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" >= ?", *from)
+		})
+	}
+	if to != nil {
+		q.Where(func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" <= ?", *to)
+		})
+	}
+	return q
+}
+
+// NameContains keeps simulations whose name contains text, ignoring case
+func (q *SimulationQuery) NameContains(text string) *SimulationQuery {
+	if text == "" {
+		return q
+	}
+	pattern := likeContains(text)
+	return q.Where(func(db *gorm.DB) *gorm.DB {
+		return db.Where("LOWER(simulations.name) LIKE ? ESCAPE '\\'", pattern)
+	})
+}
+
+// HasFaults keeps simulations that recorded at least one fault event, or
+// with false those that recorded none
+func (q *SimulationQuery) HasFaults(hasFaults bool) *SimulationQuery {
+	exists := "EXISTS (SELECT 1 FROM fault_events WHERE fault_events.simulation_id = simulations.id)"
+	if !hasFaults {
+		exists = "NOT " + exists
+	}
+	return q.Where(func(db *gorm.DB) *gorm.DB {
+		return db.Where(exists)
+	})
+}
+
+// MinDuration keeps simulations whose last run lasted at least d. Runs that
+// are still going count the time so far.
+func (q *SimulationQuery) MinDuration(d time.Duration) *SimulationQuery {
+	return q.Where(func(db *gorm.DB) *gorm.DB {
+		return db.Where("simulations.started_at IS NOT NULL").
+			Where("EXTRACT(EPOCH FROM COALESCE(simulations.completed_at, NOW()) - simulations.started_at) >= ?", d.Seconds())
+	})
+}
+
+// apply adds the query's filters to db
+func (q *SimulationQuery) apply(db *gorm.DB) *gorm.DB {
+	if q == nil {
+		return db
+	}
+	return db.Scopes(q.scopes...)
+}
+
+// ListSimulations returns a page of the simulations matching query, newest
+// first, with the number of matches across all pages. A nil query matches
+// every simulation.
+func (s *SimulationService) ListSimulations(ctx context.Context, query *SimulationQuery, limit, offset int) ([]Simulation, int64, error) {
+	var total int64
+	if err := query.apply(s.db.WithContext(ctx).Model(&Simulation{})).Count(&total).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count simulations")
+		return nil, 0, err
+	}
+
+	var simulations []Simulation
+	err := query.apply(s.db.WithContext(ctx).Model(&Simulation{})).
+		Order(recentFirst("simulations.created_at")).
+		Limit(limit).
+		Offset(offset).
+		Find(&simulations).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list simulations")
+		return nil, 0, err
+	}
+
+	return simulations, total, nil
+}
+
+// likeContains turns text into a case-insensitive LIKE pattern matching it
+// anywhere
+func likeContains(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(text))
+	return "%" + escaped + "%"
+}
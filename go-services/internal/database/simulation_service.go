@@ -1,17 +1,22 @@
 package database
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SimulationService provides simulation-specific database operations
 type SimulationService struct {
-	db     *gorm.DB
-	logger *logrus.Logger
+	db       *gorm.DB
+	logger   *logrus.Logger
+	bulkLoad string
 }
 
 // NewSimulationService creates a new simulation service
@@ -22,30 +27,132 @@ func NewSimulationService(db *gorm.DB, logger *logrus.Logger) *SimulationService
 	}
 }
 
-// CreateSimulation creates a new simulation
-func (s *SimulationService) CreateSimulation(simulation *Simulation) error {
-	if err := s.db.Create(simulation).Error; err != nil {
-		s.logger.WithError(err).Error("Failed to create simulation")
+// CreateSimulation creates a new simulation together with the power plants,
+// transmission lines, storage units and buses of its config. Either all rows
+// are written or, on any failure, none are.
+func (s *SimulationService) CreateSimulation(ctx context.Context, simulation *Simulation) error {
+	if simulation.ID == uuid.Nil {
+		simulation.ID = NewID()
+	}
+	components, err := normalizeComponents(simulation.ID, simulation.Config)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(simulation).Error; err != nil {
+			return err
+		}
+
+		return components.create(tx)
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulation.ID).Error("Failed to create simulation")
 		return err
 	}
 
 	s.logger.WithFields(logrus.Fields{
-		"simulation_id": simulation.ID,
-		"name":          simulation.Name,
-		"user_id":       simulation.UserID,
+		"simulation_id":      simulation.ID,
+		"name":               simulation.Name,
+		"user_id":            simulation.UserID,
+		"power_plants":       len(components.PowerPlants),
+		"transmission_lines": len(components.TransmissionLines),
 	}).Info("Simulation created successfully")
 
 	return nil
 }
 
+// SaveSimulation creates a simulation or replaces its definition. The status
+// and run times are left to the status updates.
+func (s *SimulationService) SaveSimulation(ctx context.Context, simulation *Simulation) error {
+	if err := upsertSimulation(s.db.WithContext(ctx), simulation); err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulation.ID).Error("Failed to save simulation")
+		return err
+	}
+	return nil
+}
+
+// SaveSimulationConfig saves a simulation like SaveSimulation and replaces
+// its component rows with those of its config, all or nothing. It is used
+// when the config of an existing simulation changed.
+func (s *SimulationService) SaveSimulationConfig(ctx context.Context, simulation *Simulation) error {
+	components, err := normalizeComponents(simulation.ID, simulation.Config)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := upsertSimulation(tx, simulation); err != nil {
+			return err
+		}
+		if err := deleteComponents(tx, simulation.ID); err != nil {
+			return fmt.Errorf("failed to delete components: %w", err)
+		}
+		return components.create(tx)
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulation.ID).Error("Failed to save simulation config")
+		return err
+	}
+	return nil
+}
+
+// upsertSimulation creates a simulation's row or replaces its definition,
+// leaving its components as they are
+func upsertSimulation(tx *gorm.DB, simulation *Simulation) error {
+	simulation.UpdatedAt = time.Now()
+	return tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "description", "user_id", "organization_id", "engine",
+			"config", "tags", "metadata", "version", "depends_on", "updated_at",
+		}),
+	}).Create(simulation).Error
+}
+
+// FindSimulation retrieves a simulation by ID without its relationships,
+// returning nil when there is none
+func (s *SimulationService) FindSimulation(ctx context.Context, id uuid.UUID) (*Simulation, error) {
+	var simulation Simulation
+	if err := s.db.WithContext(ctx).First(&simulation, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to find simulation")
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// GetWaitingSimulations retrieves the simulations without their
+// relationships that have dependencies and have never run. With a node ID
+// only those owned by that cluster node are returned.
+func (s *SimulationService) GetWaitingSimulations(ctx context.Context, nodeID string) ([]Simulation, error) {
+	var simulations []Simulation
+
+	query := s.db.WithContext(ctx).
+		Where("status = ? AND depends_on IS NOT NULL AND depends_on <> 'null'::jsonb AND depends_on <> '[]'::jsonb", SimulationStatusCreated)
+	if nodeID != "" {
+		query = query.Where("id::text IN (?)", s.db.Model(&SimulationOwner{}).Select("simulation_id").Where("node_id = ?", nodeID))
+	}
+
+	if err := query.Find(&simulations).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to get waiting simulations")
+		return nil, err
+	}
+	return simulations, nil
+}
+
 // GetSimulation retrieves a simulation by ID with all relationships
-func (s *SimulationService) GetSimulation(id uuid.UUID) (*Simulation, error) {
+func (s *SimulationService) GetSimulation(ctx context.Context, id uuid.UUID) (*Simulation, error) {
 	var simulation Simulation
 
-	err := s.db.Preload("User").
+	err := s.db.WithContext(ctx).Preload("User").
 		Preload("Organization").
 		Preload("PowerPlants").
 		Preload("TransmissionLines").
+		Preload("StorageUnits").
+		Preload("Buses").
 		First(&simulation, id).Error
 
 	if err != nil {
@@ -59,16 +166,25 @@ func (s *SimulationService) GetSimulation(id uuid.UUID) (*Simulation, error) {
 	return &simulation, nil
 }
 
+// SimulationExists reports whether a simulation row exists
+func (s *SimulationService) SimulationExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&Simulation{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // GetSimulationsByUser retrieves simulations for a specific user
-func (s *SimulationService) GetSimulationsByUser(userID uuid.UUID, limit, offset int) ([]Simulation, error) {
+func (s *SimulationService) GetSimulationsByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]Simulation, error) {
 	var simulations []Simulation
 
-	err := s.db.Where("user_id = ?", userID).
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).
 		Preload("User").
 		Preload("Organization").
 		Limit(limit).
 		Offset(offset).
-		Order("created_at DESC").
+		Order(recentFirst("created_at")).
 		Find(&simulations).Error
 
 	if err != nil {
@@ -79,8 +195,42 @@ func (s *SimulationService) GetSimulationsByUser(userID uuid.UUID, limit, offset
 	return simulations, nil
 }
 
+// GetRecentSimulations retrieves the most recently created simulations
+func (s *SimulationService) GetRecentSimulations(ctx context.Context, limit int) ([]Simulation, error) {
+	var simulations []Simulation
+
+	err := s.db.WithContext(ctx).Limit(limit).
+		Order(recentFirst("created_at")).
+		Find(&simulations).Error
+
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get recent simulations")
+		return nil, err
+	}
+
+	return simulations, nil
+}
+
+// UpdateSimulation saves changes to a simulation that is still at
+// expectedVersion, returning ErrVersionConflict when it has moved on
+func (s *SimulationService) UpdateSimulation(ctx context.Context, simulation *Simulation, expectedVersion int64) error {
+	if err := updateVersioned(s.db.WithContext(ctx), simulation, &simulation.Version, expectedVersion); err != nil {
+		if err != ErrVersionConflict {
+			s.logger.WithError(err).Error("Failed to update simulation")
+		}
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"simulation_id": simulation.ID,
+		"version":       simulation.Version,
+	}).Info("Simulation updated")
+
+	return nil
+}
+
 // UpdateSimulationStatus updates the status of a simulation
-func (s *SimulationService) UpdateSimulationStatus(id uuid.UUID, status string) error {
+func (s *SimulationService) UpdateSimulationStatus(ctx context.Context, id uuid.UUID, status string) error {
 	updates := map[string]interface{}{
 		"status":     status,
 		"updated_at": time.Now(),
@@ -94,7 +244,7 @@ func (s *SimulationService) UpdateSimulationStatus(id uuid.UUID, status string)
 		updates["completed_at"] = &now
 	}
 
-	err := s.db.Model(&Simulation{}).Where("id = ?", id).Updates(updates).Error
+	err := s.db.WithContext(ctx).Model(&Simulation{}).Where("id = ?", id).Updates(updates).Error
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to update simulation status")
 		return err
@@ -108,24 +258,106 @@ func (s *SimulationService) UpdateSimulationStatus(id uuid.UUID, status string)
 	return nil
 }
 
+// GetSimulationsByStatus retrieves all simulations in any of the given statuses
+func (s *SimulationService) GetSimulationsByStatus(ctx context.Context, statuses ...string) ([]Simulation, error) {
+	var simulations []Simulation
+
+	err := s.db.WithContext(ctx).Where("status IN ?", statuses).Find(&simulations).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get simulations by status")
+		return nil, err
+	}
+
+	return simulations, nil
+}
+
+// GetSimulationOwnersByStatus retrieves the ID, user and organization of
+// all simulations in any of the given statuses, leaving out their configs
+func (s *SimulationService) GetSimulationOwnersByStatus(ctx context.Context, statuses ...string) ([]Simulation, error) {
+	var simulations []Simulation
+
+	err := s.db.WithContext(ctx).Select("id", "user_id", "organization_id").
+		Where("status IN ?", statuses).
+		Find(&simulations).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get simulation owners by status")
+		return nil, err
+	}
+
+	return simulations, nil
+}
+
+// TransitionSimulationStatus moves a simulation from one status to another,
+// recording a reason in its error message when given. Events are added to
+// the outbox in the same transaction. It reports false, writing nothing,
+// when the simulation was no longer in the expected status.
+func (s *SimulationService) TransitionSimulationStatus(ctx context.Context, id uuid.UUID, from, to, reason string, events ...*OutboxEvent) (bool, error) {
+	updates := map[string]interface{}{
+		"status": to,
+	}
+	if reason != "" {
+		updates["error_message"] = reason
+	}
+	if to != "running" && to != "paused" {
+		updates["completed_at"] = time.Now()
+	}
+
+	changed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Simulation{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+		return enqueueOutbox(tx, events)
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to transition simulation status")
+		return false, err
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"from":          from,
+		"to":            to,
+	}).Info("Simulation status transitioned")
+
+	return true, nil
+}
+
 // AddSimulationResult adds a new simulation result
-func (s *SimulationService) AddSimulationResult(result *SimulationResult) error {
-	if err := s.db.Create(result).Error; err != nil {
+func (s *SimulationService) AddSimulationResult(ctx context.Context, result *SimulationResult) error {
+	if err := s.db.WithContext(ctx).Create(result).Error; err != nil {
 		s.logger.WithError(err).Error("Failed to add simulation result")
 		return err
 	}
 	return nil
 }
 
-// GetSimulationResults retrieves simulation results with pagination
-func (s *SimulationService) GetSimulationResults(simulationID uuid.UUID, limit, offset int) ([]SimulationResult, error) {
+// ResultWindow selects the results of a simulation between ticks and/or
+// timestamps, both ends inclusive and nil for open
+type ResultWindow struct {
+	FromTick *int
+	ToTick   *int
+	From     *time.Time
+	To       *time.Time
+	// Oldest first instead of newest first
+	Ascending bool
+	Limit     int
+	Offset    int
+}
+
+// GetSimulationResults retrieves a window of simulation results. Tick
+// windows are ordered by tick and served by the (simulation_id, tick_number)
+// index, time windows by the (simulation_id, timestamp) one.
+func (s *SimulationService) GetSimulationResults(ctx context.Context, simulationID uuid.UUID, window ResultWindow) ([]SimulationResult, error) {
 	var results []SimulationResult
 
-	err := s.db.Where("simulation_id = ?", simulationID).
-		Order("timestamp DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&results).Error
+	err := s.resultWindowQuery(ctx, simulationID, window).Find(&results).Error
 
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to get simulation results")
@@ -135,11 +367,106 @@ func (s *SimulationService) GetSimulationResults(simulationID uuid.UUID, limit,
 	return results, nil
 }
 
+// StreamResults calls fn with each result in a window, reading them from a
+// database cursor instead of loading them all. It stops at the first error
+// fn returns, or when ctx is done. The query timeout does not apply, so
+// streams can run for as long as their reader keeps up.
+func (s *SimulationService) StreamResults(ctx context.Context, simulationID uuid.UUID, window ResultWindow, fn func(*SimulationResult) error) error {
+	rows, err := s.resultWindowQuery(ctx, simulationID, window).Model(&SimulationResult{}).Rows()
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to stream simulation results")
+		return err
+	}
+	defer rows.Close()
+
+	scanner := s.db.WithContext(ctx)
+	for rows.Next() {
+		var result SimulationResult
+		if err := scanner.ScanRows(rows, &result); err != nil {
+			return err
+		}
+		if err := fn(&result); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// resultWindowQuery selects and orders the results in a window
+func (s *SimulationService) resultWindowQuery(ctx context.Context, simulationID uuid.UUID, window ResultWindow) *gorm.DB {
+	query := s.db.WithContext(ctx).Where("simulation_id = ?", simulationID)
+	if window.FromTick != nil {
+		query = query.Where("tick_number >= ?", *window.FromTick)
+	}
+	if window.ToTick != nil {
+		query = query.Where("tick_number <= ?", *window.ToTick)
+	}
+	if window.From != nil {
+		query = query.Where("timestamp >= ?", *window.From)
+	}
+	if window.To != nil {
+		query = query.Where("timestamp <= ?", *window.To)
+	}
+
+	column := "timestamp"
+	if window.FromTick != nil || window.ToTick != nil {
+		column = "tick_number"
+	}
+	direction := " DESC"
+	if window.Ascending {
+		direction = " ASC"
+	}
+	query = query.Order(column + direction)
+
+	if window.Limit > 0 {
+		query = query.Limit(window.Limit)
+	}
+	if window.Offset > 0 {
+		query = query.Offset(window.Offset)
+	}
+	return query
+}
+
+// GetSimulationResultsInRange retrieves results between two optional timestamps in chronological order
+func (s *SimulationService) GetSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]SimulationResult, error) {
+	var results []SimulationResult
+
+	query := s.db.WithContext(ctx).Where("simulation_id = ?", simulationID)
+	if from != nil {
+		query = query.Where("timestamp >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("timestamp <= ?", *to)
+	}
+
+	err := query.Order("timestamp ASC").Find(&results).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get simulation results in range")
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// CountSimulationResultsInRange counts the results between two optional
+// timestamps
+func (s *SimulationService) CountSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) (int64, error) {
+	var count int64
+
+	query := s.db.WithContext(ctx).Model(&SimulationResult{}).Where("simulation_id = ?", simulationID)
+	if err := applyTimeRange(query, "timestamp", from, to).Count(&count).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count simulation results in range")
+		return 0, err
+	}
+
+	return count, nil
+}
+
 // GetLatestSimulationResults retrieves the latest N results for a simulation
-func (s *SimulationService) GetLatestSimulationResults(simulationID uuid.UUID, limit int) ([]SimulationResult, error) {
+func (s *SimulationService) GetLatestSimulationResults(ctx context.Context, simulationID uuid.UUID, limit int) ([]SimulationResult, error) {
 	var results []SimulationResult
 
-	err := s.db.Where("simulation_id = ?", simulationID).
+	err := s.db.WithContext(ctx).Where("simulation_id = ?", simulationID).
 		Order("timestamp DESC").
 		Limit(limit).
 		Find(&results).Error
@@ -152,20 +479,165 @@ func (s *SimulationService) GetLatestSimulationResults(simulationID uuid.UUID, l
 	return results, nil
 }
 
+// GetPowerPlants retrieves the power plants of a simulation
+func (s *SimulationService) GetPowerPlants(ctx context.Context, simulationID uuid.UUID) ([]PowerPlant, error) {
+	var plants []PowerPlant
+
+	err := s.db.WithContext(ctx).Where("simulation_id = ?", simulationID).
+		Order("plant_id ASC").
+		Find(&plants).Error
+
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get power plants")
+		return nil, err
+	}
+
+	return plants, nil
+}
+
+// SaveEmissionSummaries replaces the stored emissions summaries of a simulation
+func (s *SimulationService) SaveEmissionSummaries(ctx context.Context, simulationID uuid.UUID, summaries []EmissionSummary) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("simulation_id = ?", simulationID).Delete(&EmissionSummary{}).Error; err != nil {
+			return err
+		}
+
+		if len(summaries) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&summaries).Error; err != nil {
+			s.logger.WithError(err).Error("Failed to save emission summaries")
+			return err
+		}
+
+		return nil
+	})
+}
+
 // AddComponentMetric adds a component metric
-func (s *SimulationService) AddComponentMetric(metric *ComponentMetric) error {
-	if err := s.db.Create(metric).Error; err != nil {
+func (s *SimulationService) AddComponentMetric(ctx context.Context, metric *ComponentMetric) error {
+	if err := s.db.WithContext(ctx).Create(metric).Error; err != nil {
 		s.logger.WithError(err).Error("Failed to add component metric")
 		return err
 	}
 	return nil
 }
 
+// AddComponentMetrics adds a batch of component metrics in one transaction
+func (s *SimulationService) AddComponentMetrics(ctx context.Context, metrics []ComponentMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	if s.bulkLoad == BulkLoadCopy {
+		err := s.copyTx(ctx, func(tx pgx.Tx) error {
+			return copyMetrics(ctx, tx, metrics)
+		})
+		if err == nil {
+			return nil
+		}
+		s.logger.WithError(err).Warn("Failed to copy component metrics, inserting them instead")
+	}
+	// Create splits large batches into several inserts, which must not be
+	// left to the default transaction as it may be skipped
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(metrics).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to add component metrics")
+		return err
+	}
+	return nil
+}
+
+// IngestResultBatch stores a batch of engine output for one simulation in a
+// single transaction. Results are upserted by tick; the metrics and faults of
+// every tick in the batch replace those previously ingested for that tick, so
+// a batch can be retried or backfilled in any order. The simulation's summary
+// is updated in the same transaction.
+func (s *SimulationService) IngestResultBatch(ctx context.Context, simulationID uuid.UUID, results []SimulationResult, metrics []ComponentMetric, faults []FaultEvent) error {
+	ticks := make(map[int]struct{})
+	for _, metric := range metrics {
+		ticks[*metric.TickNumber] = struct{}{}
+	}
+	for _, fault := range faults {
+		ticks[*fault.TickNumber] = struct{}{}
+	}
+	tickList := make([]int, 0, len(ticks))
+	for tick := range ticks {
+		tickList = append(tickList, tick)
+	}
+
+	if s.bulkLoad == BulkLoadCopy {
+		err := s.copyResultBatch(ctx, simulationID, results, metrics, faults, tickList)
+		if err == nil {
+			return nil
+		}
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Warn("Failed to copy result batch, inserting it instead")
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Results resent for stored ticks are taken out of the summary
+		var replaced []SimulationResult
+		if len(results) > 0 {
+			resultTicks := make([]int, len(results))
+			for i, result := range results {
+				resultTicks[i] = result.TickNumber
+			}
+			if err := tx.Where("simulation_id = ? AND tick_number IN ?", simulationID, resultTicks).Find(&replaced).Error; err != nil {
+				return err
+			}
+
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "simulation_id"}, {Name: "tick_number"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"timestamp", "total_generation_mw", "total_consumption_mw", "grid_frequency_hz",
+					"grid_voltage_kv", "efficiency_percentage", "fault_count", "metadata",
+				}),
+			}).Create(results).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var faultsRemoved int64
+		if len(tickList) > 0 {
+			if err := tx.Where("simulation_id = ? AND tick_number IN ?", simulationID, tickList).Delete(&ComponentMetric{}).Error; err != nil {
+				return err
+			}
+			deleted := tx.Where("simulation_id = ? AND tick_number IN ?", simulationID, tickList).Delete(&FaultEvent{})
+			if deleted.Error != nil {
+				return deleted.Error
+			}
+			faultsRemoved = deleted.RowsAffected
+			if len(metrics) > 0 {
+				if err := tx.Create(metrics).Error; err != nil {
+					return err
+				}
+			}
+			if len(faults) > 0 {
+				if err := tx.Create(faults).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		delta := newSummaryDelta(results, replaced, int64(len(faults)), faultsRemoved)
+		return applySummaryDelta(ctx, gormSummaryExec(tx), simulationID, delta)
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to ingest result batch")
+		return err
+	}
+
+	return nil
+}
+
 // GetComponentMetrics retrieves component metrics
-func (s *SimulationService) GetComponentMetrics(simulationID uuid.UUID, componentType string, componentID int, limit int) ([]ComponentMetric, error) {
+func (s *SimulationService) GetComponentMetrics(ctx context.Context, simulationID uuid.UUID, componentType string, componentID int, limit int) ([]ComponentMetric, error) {
 	var metrics []ComponentMetric
 
-	query := s.db.Where("simulation_id = ?", simulationID)
+	query := s.db.WithContext(ctx).Where("simulation_id = ?", simulationID)
 
 	if componentType != "" {
 		query = query.Where("component_type = ?", componentType)
@@ -188,8 +660,14 @@ func (s *SimulationService) GetComponentMetrics(simulationID uuid.UUID, componen
 }
 
 // AddFaultEvent adds a fault event
-func (s *SimulationService) AddFaultEvent(event *FaultEvent) error {
-	if err := s.db.Create(event).Error; err != nil {
+func (s *SimulationService) AddFaultEvent(ctx context.Context, event *FaultEvent) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return applySummaryDelta(ctx, gormSummaryExec(tx), event.SimulationID, newSummaryDelta(nil, nil, 1, 0))
+	})
+	if err != nil {
 		s.logger.WithError(err).Error("Failed to add fault event")
 		return err
 	}
@@ -205,10 +683,10 @@ func (s *SimulationService) AddFaultEvent(event *FaultEvent) error {
 }
 
 // GetFaultEvents retrieves fault events for a simulation
-func (s *SimulationService) GetFaultEvents(simulationID uuid.UUID, limit, offset int) ([]FaultEvent, error) {
+func (s *SimulationService) GetFaultEvents(ctx context.Context, simulationID uuid.UUID, limit, offset int) ([]FaultEvent, error) {
 	var events []FaultEvent
 
-	err := s.db.Where("simulation_id = ?", simulationID).
+	err := s.db.WithContext(ctx).Where("simulation_id = ?", simulationID).
 		Order("timestamp DESC").
 		Limit(limit).
 		Offset(offset).
@@ -222,9 +700,15 @@ func (s *SimulationService) GetFaultEvents(simulationID uuid.UUID, limit, offset
 	return events, nil
 }
 
-// AddAlert adds an alert
-func (s *SimulationService) AddAlert(alert *Alert) error {
-	if err := s.db.Create(alert).Error; err != nil {
+// AddAlert adds an alert, along with outbox events announcing it in the same transaction
+func (s *SimulationService) AddAlert(ctx context.Context, alert *Alert, events ...*OutboxEvent) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(alert).Error; err != nil {
+			return err
+		}
+		return enqueueOutbox(tx, events)
+	})
+	if err != nil {
 		s.logger.WithError(err).Error("Failed to add alert")
 		return err
 	}
@@ -240,10 +724,10 @@ func (s *SimulationService) AddAlert(alert *Alert) error {
 }
 
 // GetActiveAlerts retrieves active alerts for a simulation
-func (s *SimulationService) GetActiveAlerts(simulationID uuid.UUID) ([]Alert, error) {
+func (s *SimulationService) GetActiveAlerts(ctx context.Context, simulationID uuid.UUID) ([]Alert, error) {
 	var alerts []Alert
 
-	err := s.db.Where("simulation_id = ? AND resolved_at IS NULL", simulationID).
+	err := s.db.WithContext(ctx).Where("simulation_id = ? AND resolved_at IS NULL", simulationID).
 		Order("triggered_at DESC").
 		Find(&alerts).Error
 
@@ -255,69 +739,10 @@ func (s *SimulationService) GetActiveAlerts(simulationID uuid.UUID) ([]Alert, er
 	return alerts, nil
 }
 
-// GetSimulationStatistics retrieves statistics for a simulation
-func (s *SimulationService) GetSimulationStatistics(simulationID uuid.UUID) (map[string]interface{}, error) {
-	var stats map[string]interface{} = make(map[string]interface{})
-
-	// Get total results count
-	var totalResults int64
-	if err := s.db.Model(&SimulationResult{}).Where("simulation_id = ?", simulationID).Count(&totalResults).Error; err != nil {
-		s.logger.WithError(err).Error("Failed to count simulation results")
-		return nil, err
-	}
-	stats["total_results"] = totalResults
-
-	// Get latest result
-	var latestResult SimulationResult
-	err := s.db.Where("simulation_id = ?", simulationID).
-		Order("timestamp DESC").
-		First(&latestResult).Error
-	if err == nil {
-		stats["latest_result"] = latestResult
-	}
-
-	// Get fault count
-	var faultCount int64
-	if err := s.db.Model(&FaultEvent{}).Where("simulation_id = ?", simulationID).Count(&faultCount).Error; err != nil {
-		s.logger.WithError(err).Error("Failed to count fault events")
-		return nil, err
-	}
-	stats["fault_count"] = faultCount
-
-	// Get active alerts count
-	var activeAlertsCount int64
-	if err := s.db.Model(&Alert{}).Where("simulation_id = ? AND resolved_at IS NULL", simulationID).Count(&activeAlertsCount).Error; err != nil {
-		s.logger.WithError(err).Error("Failed to count active alerts")
-		return nil, err
-	}
-	stats["active_alerts"] = activeAlertsCount
-
-	// Get average metrics
-	var avgMetrics struct {
-		AvgGenerationMW    float64 `json:"avg_generation_mw"`
-		AvgConsumptionMW   float64 `json:"avg_consumption_mw"`
-		AvgEfficiency      float64 `json:"avg_efficiency"`
-		AvgGridFrequencyHz float64 `json:"avg_grid_frequency_hz"`
-	}
-
-	err = s.db.Model(&SimulationResult{}).
-		Where("simulation_id = ?", simulationID).
-		Select("AVG(total_generation_mw) as avg_generation_mw, AVG(total_consumption_mw) as avg_consumption_mw, AVG(efficiency_percentage) as avg_efficiency, AVG(grid_frequency_hz) as avg_grid_frequency_hz").
-		Scan(&avgMetrics).Error
-
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to calculate average metrics")
-	} else {
-		stats["average_metrics"] = avgMetrics
-	}
-
-	return stats, nil
-}
-
 // DeleteSimulation deletes a simulation and all related data
-func (s *SimulationService) DeleteSimulation(id uuid.UUID) error {
+func (s *SimulationService) DeleteSimulation(ctx context.Context, id uuid.UUID) error {
 	// Use transaction to ensure data consistency
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Delete in reverse order of dependencies
 		if err := tx.Where("simulation_id = ?", id).Delete(&Alert{}).Error; err != nil {
 			return err
@@ -327,6 +752,10 @@ func (s *SimulationService) DeleteSimulation(id uuid.UUID) error {
 			return err
 		}
 
+		if err := tx.Where("simulation_id = ?", id).Delete(&EmissionSummary{}).Error; err != nil {
+			return err
+		}
+
 		if err := tx.Where("simulation_id = ?", id).Delete(&ComponentMetric{}).Error; err != nil {
 			return err
 		}
@@ -335,10 +764,34 @@ func (s *SimulationService) DeleteSimulation(id uuid.UUID) error {
 			return err
 		}
 
+		if err := tx.Where("simulation_id = ?", id).Delete(&ResultArchive{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("simulation_id = ?", id).Delete(&SimulationSummary{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("simulation_id = ?", id).Delete(&StabilityScore{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("simulation_id = ?", id).Delete(&ShareLink{}).Error; err != nil {
+			return err
+		}
+
 		if err := tx.Where("simulation_id = ?", id).Delete(&TransmissionLine{}).Error; err != nil {
 			return err
 		}
 
+		if err := tx.Where("simulation_id = ?", id).Delete(&Bus{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("simulation_id = ?", id).Delete(&StorageUnit{}).Error; err != nil {
+			return err
+		}
+
 		if err := tx.Where("simulation_id = ?", id).Delete(&PowerPlant{}).Error; err != nil {
 			return err
 		}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SnapshotService provides simulation snapshot database operations
+type SnapshotService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewSnapshotService creates a new snapshot service
+func NewSnapshotService(db *gorm.DB, logger *logrus.Logger) *SnapshotService {
+	return &SnapshotService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateSnapshot records a snapshot as the next version for its simulation.
+// Concurrent snapshots of the same simulation may fail on the version's
+// unique index rather than share a version.
+func (s *SnapshotService) CreateSnapshot(snapshot *SimulationSnapshot) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		err := tx.Model(&SimulationSnapshot{}).
+			Where("simulation_id = ?", snapshot.SimulationID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error
+		if err != nil {
+			return err
+		}
+
+		snapshot.Version = latest + 1
+		return tx.Create(snapshot).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", snapshot.SimulationID).Error("Failed to create snapshot")
+		return err
+	}
+	return nil
+}
+
+// GetSnapshot retrieves a version of a simulation's snapshots
+func (s *SnapshotService) GetSnapshot(simulationID string, version int) (*SimulationSnapshot, error) {
+	var snapshot SimulationSnapshot
+	err := s.db.Where("simulation_id = ? AND version = ?", simulationID, version).First(&snapshot).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to get snapshot")
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots lists a simulation's snapshots, newest first
+func (s *SnapshotService) ListSnapshots(simulationID string, limit, offset int) ([]SimulationSnapshot, error) {
+	var snapshots []SimulationSnapshot
+	err := s.db.Where("simulation_id = ?", simulationID).
+		Order("version DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&snapshots).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to list snapshots")
+		return nil, err
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StabilityRank is a run's stability score with the simulation it belongs to
+type StabilityRank struct {
+	StabilityScore
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// SaveStabilityScore stores a run's stability score, replacing any previous one
+func (s *SimulationService) SaveStabilityScore(ctx context.Context, score *StabilityScore) error {
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "simulation_id"}},
+		UpdateAll: true,
+	}).Create(score).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", score.SimulationID).Error("Failed to save stability score")
+		return err
+	}
+	return nil
+}
+
+// GetStabilityScore returns a run's stability score, or nil if it has not
+// been computed
+func (s *SimulationService) GetStabilityScore(ctx context.Context, simulationID uuid.UUID) (*StabilityScore, error) {
+	var score StabilityScore
+	if err := s.db.WithContext(ctx).First(&score, "simulation_id = ?", simulationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get stability score")
+		return nil, err
+	}
+	return &score, nil
+}
+
+// RankStability returns stability scores most stable first, optionally only
+// those of the given simulations
+func (s *SimulationService) RankStability(ctx context.Context, simulationIDs []uuid.UUID, limit, offset int) ([]StabilityRank, error) {
+	query := s.db.WithContext(ctx).Model(&StabilityScore{}).
+		Select("stability_scores.*, simulations.name, simulations.status").
+		Joins("JOIN simulations ON simulations.id = stability_scores.simulation_id")
+	if len(simulationIDs) > 0 {
+		query = query.Where("stability_scores.simulation_id IN ?", simulationIDs)
+	}
+
+	var ranks []StabilityRank
+	err := query.Order("stability_scores.score DESC, stability_scores.simulation_id").
+		Limit(limit).
+		Offset(offset).
+		Scan(&ranks).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to rank stability scores")
+		return nil, err
+	}
+	return ranks, nil
+}
@@ -0,0 +1,372 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// summaryMetrics are the result columns a simulation summary aggregates,
+// each under its summary prefix: sum_<name>, min_<name>, max_<name> and
+// last_<name>
+var summaryMetrics = []struct {
+	name   string
+	column string
+	value  func(*SimulationResult) float64
+}{
+	{"generation_mw", "total_generation_mw", func(r *SimulationResult) float64 { return r.TotalGenerationMW }},
+	{"consumption_mw", "total_consumption_mw", func(r *SimulationResult) float64 { return r.TotalConsumptionMW }},
+	{"frequency_hz", "grid_frequency_hz", func(r *SimulationResult) float64 { return r.GridFrequencyHz }},
+	{"voltage_kv", "grid_voltage_kv", func(r *SimulationResult) float64 { return r.GridVoltageKV }},
+	{"efficiency_percentage", "efficiency_percentage", func(r *SimulationResult) float64 { return r.EfficiencyPercentage }},
+}
+
+// Statements on simulation_summaries. They use $n placeholders and run on
+// the transaction's connection directly, so the pgx COPY path can share them.
+var (
+	summaryUpdateSQL  = buildSummaryUpdateSQL()
+	summaryRefreshSQL = buildSummaryRefreshSQL()
+)
+
+// buildSummaryUpdateSQL folds a summaryDelta into an existing summary:
+// $1 simulation, $2 results added, $3 faults added, $4/$5 tick range,
+// $6/$7 time range, $8 stale, $9 updated at, then sum, min, max and last per
+// metric. The last values only apply when the delta reaches the last tick.
+func buildSummaryUpdateSQL() string {
+	set := []string{
+		"result_count = result_count + $2",
+		"fault_count = fault_count + $3",
+		"first_tick = COALESCE(LEAST(first_tick, $4), first_tick, $4)",
+		"last_tick = COALESCE(GREATEST(last_tick, $5), last_tick, $5)",
+		"first_timestamp = COALESCE(LEAST(first_timestamp, $6), first_timestamp, $6)",
+		"last_timestamp = COALESCE(GREATEST(last_timestamp, $7), last_timestamp, $7)",
+		"stale = stale OR $8",
+		"updated_at = $9",
+	}
+	for i, metric := range summaryMetrics {
+		n := 10 + 4*i
+		set = append(set,
+			fmt.Sprintf("sum_%s = sum_%s + $%d", metric.name, metric.name, n),
+			fmt.Sprintf("min_%s = COALESCE(LEAST(min_%s, $%d), min_%s, $%d)", metric.name, metric.name, n+1, metric.name, n+1),
+			fmt.Sprintf("max_%s = COALESCE(GREATEST(max_%s, $%d), max_%s, $%d)", metric.name, metric.name, n+2, metric.name, n+2),
+			fmt.Sprintf("last_%s = CASE WHEN $5::INT IS NOT NULL AND (last_tick IS NULL OR $5 >= last_tick) THEN $%d ELSE last_%s END", metric.name, n+3, metric.name),
+		)
+	}
+	return "UPDATE simulation_summaries SET " + strings.Join(set, ", ") + " WHERE simulation_id = $1"
+}
+
+// buildSummaryRefreshSQL recomputes a summary from the stored results and
+// faults: $1 simulation, $2 updated at
+func buildSummaryRefreshSQL() string {
+	columns := []string{"result_count", "fault_count", "first_tick", "last_tick", "first_timestamp", "last_timestamp"}
+	aggregates := []string{"COUNT(*) AS result_count", "MIN(tick_number) AS first_tick", "MAX(tick_number) AS last_tick", "MIN(timestamp) AS first_timestamp", "MAX(timestamp) AS last_timestamp"}
+	values := []string{"agg.result_count", "faults.fault_count", "agg.first_tick", "agg.last_tick", "agg.first_timestamp", "agg.last_timestamp"}
+	var latest []string
+	for _, metric := range summaryMetrics {
+		columns = append(columns, "sum_"+metric.name, "min_"+metric.name, "max_"+metric.name, "last_"+metric.name)
+		aggregates = append(aggregates,
+			fmt.Sprintf("COALESCE(SUM(%s), 0) AS sum_%s", metric.column, metric.name),
+			fmt.Sprintf("MIN(%s) AS min_%s", metric.column, metric.name),
+			fmt.Sprintf("MAX(%s) AS max_%s", metric.column, metric.name),
+		)
+		latest = append(latest, fmt.Sprintf("%s AS last_%s", metric.column, metric.name))
+		values = append(values, "agg.sum_"+metric.name, "agg.min_"+metric.name, "agg.max_"+metric.name, "latest.last_"+metric.name)
+	}
+
+	updates := make([]string, 0, len(columns)+2)
+	for _, column := range append(columns, "stale", "updated_at") {
+		updates = append(updates, column+" = EXCLUDED."+column)
+	}
+
+	return "WITH agg AS (SELECT " + strings.Join(aggregates, ", ") + " FROM simulation_results WHERE simulation_id = $1), " +
+		"latest AS (SELECT " + strings.Join(latest, ", ") + " FROM simulation_results WHERE simulation_id = $1 ORDER BY tick_number DESC LIMIT 1), " +
+		"faults AS (SELECT COUNT(*) AS fault_count FROM fault_events WHERE simulation_id = $1) " +
+		"INSERT INTO simulation_summaries (simulation_id, " + strings.Join(columns, ", ") + ", stale, updated_at) " +
+		"SELECT $1::UUID, " + strings.Join(values, ", ") + ", false, $2 FROM agg CROSS JOIN faults LEFT JOIN latest ON true " +
+		"ON CONFLICT (simulation_id) DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// summaryExec runs a statement with $n placeholders in a transaction and
+// returns the number of rows it affected
+type summaryExec func(ctx context.Context, sql string, args ...any) (int64, error)
+
+// gormSummaryExec runs statements on a gorm transaction's connection
+func gormSummaryExec(tx *gorm.DB) summaryExec {
+	return func(ctx context.Context, sql string, args ...any) (int64, error) {
+		result, err := tx.Statement.ConnPool.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+}
+
+// pgxSummaryExec runs statements on a pgx transaction
+func pgxSummaryExec(tx pgx.Tx) summaryExec {
+	return func(ctx context.Context, sql string, args ...any) (int64, error) {
+		tag, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return 0, err
+		}
+		return tag.RowsAffected(), nil
+	}
+}
+
+// metricDelta is the change a batch makes to one aggregated metric
+type metricDelta struct {
+	sum      float64
+	min, max *float64
+	last     *float64
+}
+
+// summaryDelta is the change a batch makes to a simulation's summary
+type summaryDelta struct {
+	results, faults     int64
+	firstTick, lastTick *int
+	from, to            *time.Time
+	metrics             []metricDelta
+	stale               bool
+}
+
+// newSummaryDelta describes writing results, replacing the stored results
+// for the same ticks, and adding and removing faults
+func newSummaryDelta(results, replaced []SimulationResult, faultsAdded, faultsRemoved int64) summaryDelta {
+	delta := summaryDelta{
+		results: int64(len(results) - len(replaced)),
+		faults:  faultsAdded - faultsRemoved,
+		metrics: make([]metricDelta, len(summaryMetrics)),
+		// A replaced result may have held a minimum or maximum
+		stale: len(replaced) > 0,
+	}
+
+	var last *SimulationResult
+	for i := range results {
+		result := &results[i]
+		if delta.firstTick == nil || result.TickNumber < *delta.firstTick {
+			delta.firstTick = &result.TickNumber
+		}
+		if delta.lastTick == nil || result.TickNumber > *delta.lastTick {
+			delta.lastTick = &result.TickNumber
+			last = result
+		}
+		if delta.from == nil || result.Timestamp.Before(*delta.from) {
+			delta.from = &result.Timestamp
+		}
+		if delta.to == nil || result.Timestamp.After(*delta.to) {
+			delta.to = &result.Timestamp
+		}
+
+		for j, metric := range summaryMetrics {
+			value := metric.value(result)
+			m := &delta.metrics[j]
+			m.sum += value
+			if m.min == nil || value < *m.min {
+				m.min = &value
+			}
+			if m.max == nil || value > *m.max {
+				m.max = &value
+			}
+		}
+	}
+	for i := range replaced {
+		for j, metric := range summaryMetrics {
+			delta.metrics[j].sum -= metric.value(&replaced[i])
+		}
+	}
+	if last != nil {
+		for j, metric := range summaryMetrics {
+			value := metric.value(last)
+			delta.metrics[j].last = &value
+		}
+	}
+	return delta
+}
+
+// applySummaryDelta folds a batch into its simulation's summary. A
+// simulation without a summary yet, such as one whose results predate
+// summaries, has it computed in full instead.
+func applySummaryDelta(ctx context.Context, exec summaryExec, simulationID uuid.UUID, delta summaryDelta) error {
+	now := time.Now().UTC()
+	args := []any{simulationID, delta.results, delta.faults, delta.firstTick, delta.lastTick, delta.from, delta.to, delta.stale, now}
+	for _, m := range delta.metrics {
+		args = append(args, m.sum, m.min, m.max, m.last)
+	}
+
+	updated, err := exec(ctx, summaryUpdateSQL, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update simulation summary: %w", err)
+	}
+	if updated > 0 {
+		return nil
+	}
+	return refreshSummary(ctx, exec, simulationID)
+}
+
+// refreshSummary recomputes a simulation's summary from its stored results
+// and faults
+func refreshSummary(ctx context.Context, exec summaryExec, simulationID uuid.UUID) error {
+	if _, err := exec(ctx, summaryRefreshSQL, simulationID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to refresh simulation summary: %w", err)
+	}
+	return nil
+}
+
+// RefreshSimulationSummary recomputes a simulation's summary from its stored
+// results and faults
+func (s *SimulationService) RefreshSimulationSummary(ctx context.Context, simulationID uuid.UUID) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return refreshSummary(ctx, gormSummaryExec(tx), simulationID)
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to refresh simulation summary")
+		return err
+	}
+	return nil
+}
+
+// MetricStatistics summarizes one result metric over a simulation. All
+// values are nil until the simulation has results.
+type MetricStatistics struct {
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+	Avg  *float64 `json:"avg,omitempty"`
+	Last *float64 `json:"last,omitempty"`
+}
+
+// SimulationStatistics summarizes a simulation's results, faults and alerts
+type SimulationStatistics struct {
+	TotalResults int64      `json:"total_results"`
+	FaultCount   int64      `json:"fault_count"`
+	ActiveAlerts int64      `json:"active_alerts"`
+	FirstTick    *int       `json:"first_tick,omitempty"`
+	LastTick     *int       `json:"last_tick,omitempty"`
+	From         *time.Time `json:"from,omitempty"`
+	To           *time.Time `json:"to,omitempty"`
+
+	Generation  MetricStatistics `json:"generation_mw"`
+	Consumption MetricStatistics `json:"consumption_mw"`
+	Frequency   MetricStatistics `json:"frequency_hz"`
+	Voltage     MetricStatistics `json:"voltage_kv"`
+	Efficiency  MetricStatistics `json:"efficiency_percentage"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetSimulationStatistics returns a simulation's statistics from its
+// summary, computing the summary first when it is missing or stale
+func (s *SimulationService) GetSimulationStatistics(ctx context.Context, simulationID uuid.UUID) (*SimulationStatistics, error) {
+	var summary SimulationSummary
+	err := s.db.WithContext(ctx).First(&summary, "simulation_id = ?", simulationID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.WithError(err).Error("Failed to get simulation summary")
+		return nil, err
+	}
+	if err != nil || summary.Stale {
+		if err := s.RefreshSimulationSummary(ctx, simulationID); err != nil {
+			return nil, err
+		}
+		if err := s.db.WithContext(ctx).First(&summary, "simulation_id = ?", simulationID).Error; err != nil {
+			s.logger.WithError(err).Error("Failed to get simulation summary")
+			return nil, err
+		}
+	}
+
+	var activeAlerts int64
+	if err := s.db.WithContext(ctx).Model(&Alert{}).Where("simulation_id = ? AND resolved_at IS NULL", simulationID).Count(&activeAlerts).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count active alerts")
+		return nil, err
+	}
+
+	metric := func(sum float64, min, max, last *float64) MetricStatistics {
+		stats := MetricStatistics{Min: min, Max: max, Last: last}
+		if summary.ResultCount > 0 {
+			avg := sum / float64(summary.ResultCount)
+			stats.Avg = &avg
+		}
+		return stats
+	}
+
+	return &SimulationStatistics{
+		TotalResults: summary.ResultCount,
+		FaultCount:   summary.FaultCount,
+		ActiveAlerts: activeAlerts,
+		FirstTick:    summary.FirstTick,
+		LastTick:     summary.LastTick,
+		From:         summary.FirstTimestamp,
+		To:           summary.LastTimestamp,
+		Generation:   metric(summary.SumGenerationMW, summary.MinGenerationMW, summary.MaxGenerationMW, summary.LastGenerationMW),
+		Consumption:  metric(summary.SumConsumptionMW, summary.MinConsumptionMW, summary.MaxConsumptionMW, summary.LastConsumptionMW),
+		Frequency:    metric(summary.SumFrequencyHz, summary.MinFrequencyHz, summary.MaxFrequencyHz, summary.LastFrequencyHz),
+		Voltage:      metric(summary.SumVoltageKV, summary.MinVoltageKV, summary.MaxVoltageKV, summary.LastVoltageKV),
+		Efficiency:   metric(summary.SumEfficiencyPercentage, summary.MinEfficiencyPercentage, summary.MaxEfficiencyPercentage, summary.LastEfficiencyPercentage),
+		UpdatedAt:    summary.UpdatedAt,
+	}, nil
+}
+
+// defaultSummaryBackfillBatch is how many simulations a backfill pass reads
+// at a time
+const defaultSummaryBackfillBatch = 100
+
+// BackfillSummaries computes the summaries of simulations that have results
+// but no summary, such as those ingested before summaries existed, and
+// recomputes stale ones. A simulation that fails is logged and skipped. It
+// returns how many summaries were computed.
+func (s *SimulationService) BackfillSummaries(ctx context.Context) (int, error) {
+	refreshed := 0
+	after := uuid.Nil
+	for {
+		var ids []uuid.UUID
+		err := s.db.WithContext(ctx).Model(&Simulation{}).
+			Where("simulations.id > ?", after).
+			Where("EXISTS (SELECT 1 FROM simulation_results WHERE simulation_results.simulation_id = simulations.id)").
+			Where("NOT EXISTS (SELECT 1 FROM simulation_summaries WHERE simulation_summaries.simulation_id = simulations.id AND NOT simulation_summaries.stale)").
+			Order("simulations.id").
+			Limit(defaultSummaryBackfillBatch).
+			Pluck("simulations.id", &ids).Error
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to find simulations to summarize")
+			return refreshed, err
+		}
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return refreshed, err
+			}
+			if err := s.RefreshSimulationSummary(ctx, id); err == nil {
+				refreshed++
+			}
+		}
+		if len(ids) < defaultSummaryBackfillBatch {
+			return refreshed, nil
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// RunSummaryBackfill backfills summaries now and then every interval until
+// ctx is done
+func (s *SimulationService) RunSummaryBackfill(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		refreshed, err := s.BackfillSummaries(ctx)
+		if err == nil && refreshed > 0 {
+			s.logger.WithField("summaries", refreshed).Info("Simulation summaries backfilled")
+		} else if err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).WithField("summaries", refreshed).Warn("Simulation summary backfill stopped early")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Resource types tags can be attached to
+const (
+	TagResourceSimulation = "simulation"
+	TagResourceExperiment = "experiment"
+	TagResourceBatch      = "batch"
+)
+
+// TagResourceTypes lists every taggable resource type
+var TagResourceTypes = []string{TagResourceSimulation, TagResourceExperiment, TagResourceBatch}
+
+// maxTagNameLength is the longest tag name accepted, in characters
+const maxTagNameLength = 64
+
+// TaggedResource is a resource found by a tag search, with all of its tags
+type TaggedResource struct {
+	ResourceType string   `json:"resource_type"`
+	ResourceID   string   `json:"resource_id"`
+	Tags         []string `json:"tags"`
+}
+
+// TagSearch selects resources by their tags
+type TagSearch struct {
+	Tags []string
+	// Resource types to search; empty searches all
+	ResourceTypes []string
+	// Require every tag instead of any of them
+	MatchAll bool
+	Limit    int
+	Offset   int
+}
+
+// TagService provides tag database operations
+type TagService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewTagService creates a new tag service
+func NewTagService(db *gorm.DB, logger *logrus.Logger) *TagService {
+	return &TagService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// NormalizeTagName trims a tag name and checks it is usable. Commas are
+// rejected since tag lists are passed comma separated in query strings.
+func NormalizeTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("%w: name is empty", ErrInvalidTagName)
+	case utf8.RuneCountInString(name) > maxTagNameLength:
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTagName, name, maxTagNameLength)
+	case strings.ContainsRune(name, ','):
+		return "", fmt.Errorf("%w: %q contains a comma", ErrInvalidTagName, name)
+	}
+	return name, nil
+}
+
+// NormalizeTagNames normalizes a list of tag names, dropping duplicates
+func NormalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name, err := NormalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized, nil
+}
+
+// CreateTag creates a tag, failing with ErrTagExists when the name is taken
+func (s *TagService) CreateTag(tag *Tag) error {
+	if err := s.checkNameFree(tag.Name, uuid.Nil); err != nil {
+		return err
+	}
+
+	if err := s.db.Create(tag).Error; err != nil {
+		s.logger.WithError(err).WithField("tag", tag.Name).Error("Failed to create tag")
+		return err
+	}
+
+	return nil
+}
+
+// GetTag retrieves a tag and its usage count by ID
+func (s *TagService) GetTag(id uuid.UUID) (*Tag, error) {
+	var tag Tag
+	err := s.withUsage(s.db.Model(&Tag{})).Where("tags.id = ?", id).Take(&tag).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("tag_id", id).Error("Failed to get tag")
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// GetTagByName retrieves a tag by its exact name
+func (s *TagService) GetTagByName(name string) (*Tag, error) {
+	var tag Tag
+	err := s.withUsage(s.db.Model(&Tag{})).Where("tags.name = ?", name).Take(&tag).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).WithField("tag", name).Error("Failed to get tag")
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// ListTags retrieves tags with their usage counts ordered by name,
+// optionally only those whose name starts with prefix
+func (s *TagService) ListTags(prefix string, limit, offset int) ([]Tag, int64, error) {
+	query := s.db.Model(&Tag{})
+	if prefix != "" {
+		query = query.Where("LOWER(tags.name) LIKE ? ESCAPE '\\'", likePrefix(prefix))
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to count tags")
+		return nil, 0, err
+	}
+
+	var tags []Tag
+	err := s.withUsage(query).
+		Order("tags.name").
+		Limit(limit).
+		Offset(offset).
+		Find(&tags).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list tags")
+		return nil, 0, err
+	}
+
+	return tags, total, nil
+}
+
+// AutocompleteTags suggests tags whose name starts with prefix, most used first
+func (s *TagService) AutocompleteTags(prefix string, limit int) ([]Tag, error) {
+	var tags []Tag
+	err := s.withUsage(s.db.Model(&Tag{})).
+		Where("LOWER(tags.name) LIKE ? ESCAPE '\\'", likePrefix(prefix)).
+		Order("usage_count DESC").
+		Order("tags.name").
+		Limit(limit).
+		Find(&tags).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("prefix", prefix).Error("Failed to autocomplete tags")
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// UpdateTag saves a tag's name, color and description. Renaming onto the
+// name of another tag fails with ErrTagExists; merge the tags instead.
+func (s *TagService) UpdateTag(tag *Tag) error {
+	if err := s.checkNameFree(tag.Name, tag.ID); err != nil {
+		return err
+	}
+
+	err := s.db.Model(tag).Select("name", "color", "description").Updates(tag).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("tag_id", tag.ID).Error("Failed to update tag")
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTag deletes a tag and detaches it from every resource
+func (s *TagService) DeleteTag(id uuid.UUID) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&TagAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Tag{}, "id = ?", id).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("tag_id", id).Error("Failed to delete tag")
+		return err
+	}
+
+	return nil
+}
+
+// MergeTags moves every resource carrying one of the source tags onto the
+// target tag and deletes the sources. It returns the names of the merged
+// tags, so copies held elsewhere can be rewritten.
+func (s *TagService) MergeTags(sourceIDs []uuid.UUID, targetID uuid.UUID) ([]string, error) {
+	var merged []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var sources []Tag
+		if err := tx.Where("id IN ? AND id <> ?", sourceIDs, targetID).Find(&sources).Error; err != nil {
+			return err
+		}
+		if len(sources) != len(sourceIDs) {
+			return ErrTagNotFound
+		}
+
+		for _, source := range sources {
+			merged = append(merged, source.Name)
+		}
+
+		// Resources already carrying the target keep a single assignment
+		err := tx.Exec(`INSERT INTO tag_assignments (tag_id, resource_type, resource_id, created_at)
+			SELECT ?, resource_type, resource_id, created_at FROM tag_assignments WHERE tag_id IN ?
+			ON CONFLICT DO NOTHING`, targetID, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id IN ?", sourceIDs).Delete(&TagAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Tag{}, "id IN ?", sourceIDs).Error
+	})
+	if err != nil {
+		if err != ErrTagNotFound {
+			s.logger.WithError(err).WithField("tag_id", targetID).Error("Failed to merge tags")
+		}
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// SetResourceTags replaces the tags of a resource, creating tags that do not
+// exist yet. Names must already be normalized.
+func (s *TagService) SetResourceTags(resourceType, resourceID string, names []string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var tagIDs []uuid.UUID
+		if len(names) > 0 {
+			tags := make([]Tag, len(names))
+			for i, name := range names {
+				tags[i] = Tag{Name: name}
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&Tag{}).Where("name IN ?", names).Pluck("id", &tagIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		stale := tx.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID)
+		if len(tagIDs) > 0 {
+			stale = stale.Where("tag_id NOT IN ?", tagIDs)
+		}
+		if err := stale.Delete(&TagAssignment{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+
+		assignments := make([]TagAssignment, len(tagIDs))
+		for i, id := range tagIDs {
+			assignments[i] = TagAssignment{TagID: id, ResourceType: resourceType, ResourceID: resourceID}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignments).Error
+	})
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"resource_type": resourceType,
+			"resource_id":   resourceID,
+		}).Error("Failed to set resource tags")
+		return err
+	}
+
+	return nil
+}
+
+// RemoveResource detaches every tag from a deleted resource
+func (s *TagService) RemoveResource(resourceType, resourceID string) error {
+	return s.SetResourceTags(resourceType, resourceID, nil)
+}
+
+// SearchResources finds resources carrying any, or all, of the given tags,
+// ordered by type and ID
+func (s *TagService) SearchResources(search TagSearch) ([]TaggedResource, int64, error) {
+	query := s.db.Table("tag_assignments").
+		Joins("JOIN tags ON tags.id = tag_assignments.tag_id").
+		Where("tags.name IN ?", search.Tags)
+	if len(search.ResourceTypes) > 0 {
+		query = query.Where("tag_assignments.resource_type IN ?", search.ResourceTypes)
+	}
+	query = query.Group("tag_assignments.resource_type, tag_assignments.resource_id")
+	if search.MatchAll {
+		query = query.Having("COUNT(DISTINCT tags.id) = ?", len(search.Tags))
+	}
+
+	var total int64
+	err := s.db.Table("(?) AS matches", query.Session(&gorm.Session{}).Select("tag_assignments.resource_type")).Count(&total).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to count tagged resources")
+		return nil, 0, err
+	}
+
+	var resources []TaggedResource
+	err = query.Select("tag_assignments.resource_type, tag_assignments.resource_id").
+		Order("tag_assignments.resource_type, tag_assignments.resource_id").
+		Limit(search.Limit).
+		Offset(search.Offset).
+		Scan(&resources).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to search tagged resources")
+		return nil, 0, err
+	}
+	if len(resources) == 0 {
+		return []TaggedResource{}, total, nil
+	}
+
+	// Load every tag of the matching resources, not just the searched ones
+	keys := make([][]interface{}, len(resources))
+	for i, resource := range resources {
+		keys[i] = []interface{}{resource.ResourceType, resource.ResourceID}
+	}
+	var rows []struct {
+		ResourceType string
+		ResourceID   string
+		Name         string
+	}
+	err = s.db.Table("tag_assignments").
+		Joins("JOIN tags ON tags.id = tag_assignments.tag_id").
+		Where("(tag_assignments.resource_type, tag_assignments.resource_id) IN ?", keys).
+		Select("tag_assignments.resource_type, tag_assignments.resource_id, tags.name").
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load tags of tagged resources")
+		return nil, 0, err
+	}
+
+	tags := make(map[[2]string][]string, len(resources))
+	for _, row := range rows {
+		key := [2]string{row.ResourceType, row.ResourceID}
+		tags[key] = append(tags[key], row.Name)
+	}
+	for i := range resources {
+		resources[i].Tags = tags[[2]string{resources[i].ResourceType, resources[i].ResourceID}]
+		sort.Strings(resources[i].Tags)
+	}
+
+	return resources, total, nil
+}
+
+// checkNameFree fails with ErrTagExists when a tag other than id has the name
+func (s *TagService) checkNameFree(name string, id uuid.UUID) error {
+	var count int64
+	if err := s.db.Model(&Tag{}).Where("name = ? AND id <> ?", name, id).Count(&count).Error; err != nil {
+		s.logger.WithError(err).WithField("tag", name).Error("Failed to check tag name")
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %q", ErrTagExists, name)
+	}
+	return nil
+}
+
+// withUsage selects tag columns together with the number of resources carrying each tag
+func (s *TagService) withUsage(query *gorm.DB) *gorm.DB {
+	usage := s.db.Model(&TagAssignment{}).
+		Select("COUNT(*)").
+		Where("tag_assignments.tag_id = tags.id")
+	return query.Select("tags.*, (?) AS usage_count", usage)
+}
+
+// likePrefix turns a prefix into a case-insensitive LIKE pattern
+func likePrefix(prefix string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(prefix))
+	return escaped + "%"
+}
+
+// Errors
+var (
+	ErrInvalidTagName = fmt.Errorf("invalid tag name")
+	ErrTagExists      = fmt.Errorf("tag already exists")
+	ErrTagNotFound    = fmt.Errorf("tag not found")
+)
@@ -0,0 +1,290 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Timeline entry kinds
+const (
+	TimelineLifecycle = "lifecycle"
+	TimelineControl   = "control"
+	TimelineFault     = "fault"
+	TimelineAlert     = "alert"
+	TimelineExcursion = "excursion"
+)
+
+// TimelineKinds lists every timeline entry kind
+var TimelineKinds = []string{TimelineLifecycle, TimelineControl, TimelineFault, TimelineAlert, TimelineExcursion}
+
+// TimelineFilter narrows a simulation timeline
+type TimelineFilter struct {
+	// Kinds to include; empty includes all
+	Kinds []string
+	From  *time.Time
+	To    *time.Time
+	// Frequency excursions start when the grid frequency moves further than
+	// ExcursionThresholdHz from NominalFrequencyHz
+	NominalFrequencyHz   float64
+	ExcursionThresholdHz float64
+}
+
+// TimelineEntry is one item of a simulation's timeline
+type TimelineEntry struct {
+	Time     time.Time      `json:"time"`
+	ID       uuid.UUID      `json:"id"`
+	Kind     string         `json:"kind"`
+	Type     string         `json:"type"`
+	Severity string         `json:"severity"`
+	Message  string         `json:"message"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// timelineRow is an entry as selected by the timeline union, before its details are loaded
+type timelineRow struct {
+	OccurredAt time.Time
+	ID         uuid.UUID
+	Kind       string
+	Type       string
+}
+
+// ListTimeline retrieves a page of a simulation's timeline newest first,
+// merging lifecycle changes, control actions from the audit log, fault
+// events, alerts and the start of each frequency excursion. The returned
+// cursor is nil when there are no further pages.
+func (s *SimulationService) ListTimeline(ctx context.Context, simulationID uuid.UUID, filter TimelineFilter, cursor *EventCursor, limit int) ([]TimelineEntry, *EventCursor, error) {
+	kinds := filter.Kinds
+	if len(kinds) == 0 {
+		kinds = TimelineKinds
+	}
+
+	var parts []interface{}
+	placeholders := ""
+	for _, kind := range kinds {
+		queries, err := s.timelineQueries(ctx, simulationID, kind, filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, query := range queries {
+			if placeholders != "" {
+				placeholders += " UNION ALL "
+			}
+			placeholders += "?"
+			parts = append(parts, query)
+		}
+	}
+
+	query := s.db.WithContext(ctx).Table("(?) AS timeline", s.db.WithContext(ctx).Raw(placeholders, parts...))
+	query = applyTimeRange(query, "occurred_at", filter.From, filter.To)
+
+	var rows []timelineRow
+	if err := pageQuery(query, "occurred_at", cursor, limit).Scan(&rows).Error; err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to list timeline")
+		return nil, nil, err
+	}
+
+	var next *EventCursor
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		next = &EventCursor{Time: last.OccurredAt, ID: last.ID}
+	}
+
+	entries, err := s.timelineEntries(ctx, simulationID, rows, filter)
+	if err != nil {
+		s.logger.WithError(err).WithField("simulation_id", simulationID).Error("Failed to load timeline entries")
+		return nil, nil, err
+	}
+	return entries, next, nil
+}
+
+// timelineQueries returns the queries selecting a kind's timeline rows
+func (s *SimulationService) timelineQueries(ctx context.Context, simulationID uuid.UUID, kind string, filter TimelineFilter) ([]*gorm.DB, error) {
+	switch kind {
+	case TimelineLifecycle:
+		simulations := s.db.WithContext(ctx).Model(&Simulation{}).Where("id = ?", simulationID)
+		return []*gorm.DB{
+			simulations.Session(&gorm.Session{}).Select("created_at AS occurred_at, id, 'lifecycle' AS kind, 'created' AS type"),
+			simulations.Session(&gorm.Session{}).Select("started_at AS occurred_at, id, 'lifecycle' AS kind, 'started' AS type").
+				Where("started_at IS NOT NULL"),
+			simulations.Session(&gorm.Session{}).Select("completed_at AS occurred_at, id, 'lifecycle' AS kind, status AS type").
+				Where("completed_at IS NOT NULL"),
+		}, nil
+	case TimelineControl:
+		return []*gorm.DB{
+			s.db.WithContext(ctx).Model(&AuditLog{}).Select("created_at AS occurred_at, id, 'control' AS kind, action AS type").
+				Where("path LIKE ?", "%/simulations/"+simulationID.String()+"%"),
+		}, nil
+	case TimelineFault:
+		return []*gorm.DB{
+			s.db.WithContext(ctx).Model(&FaultEvent{}).Select("timestamp AS occurred_at, id, 'fault' AS kind, fault_type AS type").
+				Where("simulation_id = ?", simulationID),
+		}, nil
+	case TimelineAlert:
+		return []*gorm.DB{
+			s.db.WithContext(ctx).Model(&Alert{}).Select("triggered_at AS occurred_at, id, 'alert' AS kind, alert_type AS type").
+				Where("simulation_id = ?", simulationID),
+		}, nil
+	case TimelineExcursion:
+		// Only the first tick of each run outside the threshold is an entry
+		deviations := s.db.WithContext(ctx).Model(&SimulationResult{}).
+			Select("id, timestamp, ABS(grid_frequency_hz - ?) AS deviation, LAG(ABS(grid_frequency_hz - ?)) OVER (ORDER BY tick_number) AS previous",
+				filter.NominalFrequencyHz, filter.NominalFrequencyHz).
+			Where("simulation_id = ?", simulationID)
+		return []*gorm.DB{
+			s.db.WithContext(ctx).Table("(?) AS deviations", deviations).
+				Select("timestamp AS occurred_at, id, 'excursion' AS kind, 'frequency_excursion' AS type").
+				Where("deviation > ? AND (previous IS NULL OR previous <= ?)", filter.ExcursionThresholdHz, filter.ExcursionThresholdHz),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimelineKind, kind)
+	}
+}
+
+// timelineEntries loads the details of timeline rows, keeping their order
+func (s *SimulationService) timelineEntries(ctx context.Context, simulationID uuid.UUID, rows []timelineRow, filter TimelineFilter) ([]TimelineEntry, error) {
+	ids := make(map[string][]uuid.UUID)
+	for _, row := range rows {
+		ids[row.Kind] = append(ids[row.Kind], row.ID)
+	}
+
+	details := make(map[uuid.UUID]func(row timelineRow) TimelineEntry)
+
+	if len(ids[TimelineLifecycle]) > 0 {
+		var simulation Simulation
+		if err := s.db.WithContext(ctx).Where("id = ?", simulationID).Take(&simulation).Error; err != nil {
+			return nil, err
+		}
+		details[simulation.ID] = func(row timelineRow) TimelineEntry {
+			entry := TimelineEntry{Severity: "info", Message: "Simulation " + row.Type}
+			if row.Type == "failed" || row.Type == "error" {
+				entry.Severity = "error"
+			}
+			if row.Type != "created" && row.Type != "started" && simulation.ErrorMessage != "" {
+				entry.Message += ": " + simulation.ErrorMessage
+			}
+			return entry
+		}
+	}
+
+	if len(ids[TimelineControl]) > 0 {
+		var logs []AuditLog
+		if err := s.db.WithContext(ctx).Where("id IN ?", ids[TimelineControl]).Find(&logs).Error; err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			details[log.ID] = func(timelineRow) TimelineEntry {
+				actor := log.ActorEmail
+				if log.ActorID == nil {
+					actor = "anonymous"
+				}
+				entry := TimelineEntry{
+					Severity: "info",
+					Message:  actor + " " + log.Method + " " + log.Path,
+					Data: map[string]any{
+						"actor_id":     log.ActorID,
+						"status_code":  log.StatusCode,
+						"impersonated": log.Impersonated,
+					},
+				}
+				if log.StatusCode >= http.StatusBadRequest {
+					entry.Severity = "warning"
+				}
+				return entry
+			}
+		}
+	}
+
+	if len(ids[TimelineFault]) > 0 {
+		var faults []FaultEvent
+		if err := s.db.WithContext(ctx).Where("id IN ?", ids[TimelineFault]).Find(&faults).Error; err != nil {
+			return nil, err
+		}
+		for _, fault := range faults {
+			details[fault.ID] = func(timelineRow) TimelineEntry {
+				message := fault.Description
+				if message == "" {
+					message = fmt.Sprintf("%s on %s %d", fault.FaultType, fault.ComponentType, fault.ComponentID)
+				}
+				return TimelineEntry{
+					Severity: fault.Severity,
+					Message:  message,
+					Data: map[string]any{
+						"component_type": fault.ComponentType,
+						"component_id":   fault.ComponentID,
+						"resolved_at":    fault.ResolvedAt,
+					},
+				}
+			}
+		}
+	}
+
+	if len(ids[TimelineAlert]) > 0 {
+		var alerts []Alert
+		if err := s.db.WithContext(ctx).Where("id IN ?", ids[TimelineAlert]).Find(&alerts).Error; err != nil {
+			return nil, err
+		}
+		for _, alert := range alerts {
+			details[alert.ID] = func(timelineRow) TimelineEntry {
+				return TimelineEntry{
+					Severity: alert.Severity,
+					Message:  alert.Message,
+					Data: map[string]any{
+						"acknowledged_at": alert.AcknowledgedAt,
+						"resolved_at":     alert.ResolvedAt,
+					},
+				}
+			}
+		}
+	}
+
+	if len(ids[TimelineExcursion]) > 0 {
+		var results []SimulationResult
+		if err := s.db.WithContext(ctx).Where("id IN ?", ids[TimelineExcursion]).Find(&results).Error; err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			details[result.ID] = func(timelineRow) TimelineEntry {
+				deviation := result.GridFrequencyHz - filter.NominalFrequencyHz
+				severity := "warning"
+				if math.Abs(deviation) > 2*filter.ExcursionThresholdHz {
+					severity = "critical"
+				}
+				return TimelineEntry{
+					Severity: severity,
+					Message:  fmt.Sprintf("Grid frequency %.3f Hz deviates %+.3f Hz from nominal", result.GridFrequencyHz, deviation),
+					Data: map[string]any{
+						"tick_number":       result.TickNumber,
+						"grid_frequency_hz": result.GridFrequencyHz,
+						"deviation_hz":      deviation,
+					},
+				}
+			}
+		}
+	}
+
+	entries := make([]TimelineEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := TimelineEntry{Severity: "info"}
+		if detail, ok := details[row.ID]; ok {
+			entry = detail(row)
+		}
+		entry.Time = row.OccurredAt.UTC()
+		entry.ID = row.ID
+		entry.Kind = row.Kind
+		entry.Type = row.Type
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Errors
+var (
+	ErrInvalidTimelineKind = fmt.Errorf("invalid timeline kind")
+)
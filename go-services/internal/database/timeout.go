@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// queryCancelKey is where a statement's timeout is kept until it finishes
+const queryCancelKey = "voltedge:query_cancel"
+
+// registerQueryTimeout bounds every create, query, update, delete and raw
+// statement by timeout; statements whose context ends sooner keep it.
+// Row and Rows are left alone, since their results are read after the
+// callbacks return.
+func registerQueryTimeout(db *gorm.DB, timeout time.Duration) error {
+	start := func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		db.Statement.Context = ctx
+		db.InstanceSet(queryCancelKey, cancel)
+	}
+	finish := func(db *gorm.DB) {
+		if cancel, ok := db.InstanceGet(queryCancelKey); ok {
+			cancel.(context.CancelFunc)()
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("*").Register("voltedge:timeout_create", start); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("*").Register("voltedge:timeout_create_done", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("*").Register("voltedge:timeout_query", start); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("*").Register("voltedge:timeout_query_done", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("*").Register("voltedge:timeout_update", start); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("*").Register("voltedge:timeout_update_done", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("*").Register("voltedge:timeout_delete", start); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("*").Register("voltedge:timeout_delete_done", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("*").Register("voltedge:timeout_raw", start); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("*").Register("voltedge:timeout_raw_done", finish)
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UsageService provides usage metering database operations
+type UsageService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(db *gorm.DB, logger *logrus.Logger) *UsageService {
+	return &UsageService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// AddUsage adds each counter's value to the stored counter for its
+// organization, day and metric, creating it when missing
+func (s *UsageService) AddUsage(counters []UsageCounter) error {
+	if len(counters) == 0 {
+		return nil
+	}
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "organization_id"}, {Name: "day"}, {Name: "metric"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"value":      gorm.Expr("usage_counters.value + excluded.value"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&counters).Error
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to add usage")
+		return err
+	}
+	return nil
+}
+
+// SumUsage totals a metric of an organization over the days in [from, to)
+func (s *UsageService) SumUsage(organizationID uuid.UUID, metric string, from, to time.Time) (float64, error) {
+	var total float64
+	err := s.db.Model(&UsageCounter{}).
+		Where("organization_id = ? AND metric = ? AND day >= ? AND day < ?", organizationID, metric, from, to).
+		Select("COALESCE(SUM(value), 0)").
+		Scan(&total).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("organization_id", organizationID).Error("Failed to sum usage")
+		return 0, err
+	}
+	return total, nil
+}
+
+// DailyUsage returns an organization's counters for the days in [from, to), oldest first
+func (s *UsageService) DailyUsage(organizationID uuid.UUID, from, to time.Time) ([]UsageCounter, error) {
+	var counters []UsageCounter
+	err := s.db.Where("organization_id = ? AND day >= ? AND day < ?", organizationID, from, to).
+		Order("day, metric").
+		Find(&counters).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("organization_id", organizationID).Error("Failed to get daily usage")
+		return nil, err
+	}
+	return counters, nil
+}
+
+// CountResultRows counts the stored results of an organization's simulations
+func (s *UsageService) CountResultRows(organizationID uuid.UUID) (int64, error) {
+	var count int64
+	err := s.db.Table("simulation_results AS r").
+		Joins("JOIN simulations AS s ON s.id = r.simulation_id").
+		Where("s.organization_id = ?", organizationID).
+		Count(&count).Error
+	if err != nil {
+		s.logger.WithError(err).WithField("organization_id", organizationID).Error("Failed to count result rows")
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// UserService provides user and organization database operations
+type UserService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewUserService creates a new user service
+func NewUserService(db *gorm.DB, logger *logrus.Logger) *UserService {
+	return &UserService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetUser retrieves a user by ID
+func (s *UserService) GetUser(id uuid.UUID) (*User, error) {
+	var user User
+
+	err := s.db.First(&user, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get user")
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetUserByEmail retrieves a user by email address
+func (s *UserService) GetUserByEmail(email string) (*User, error) {
+	var user User
+
+	err := s.db.First(&user, "email = ?", email).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get user by email")
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetOrganization retrieves an organization by ID
+func (s *UserService) GetOrganization(id uuid.UUID) (*Organization, error) {
+	var organization Organization
+
+	err := s.db.First(&organization, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get organization")
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+// UpdateOrganizationSetting sets a single key in an organization's settings
+func (s *UserService) UpdateOrganizationSetting(id uuid.UUID, key string, value any) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var organization Organization
+		if err := tx.First(&organization, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if organization.Settings == nil {
+			organization.Settings = make(map[string]any)
+		}
+		organization.Settings[key] = value
+
+		if err := tx.Model(&organization).Update("settings", organization.Settings).Error; err != nil {
+			s.logger.WithError(err).Error("Failed to update organization settings")
+			return err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"organization_id": id,
+			"setting":         key,
+			"value":           value,
+		}).Info("Organization setting updated")
+
+		return nil
+	})
+}
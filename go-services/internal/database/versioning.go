@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// updateVersioned saves every column of a row whose version is still
+// expectedVersion and advances its version. Rows changed by someone else in
+// the meantime are left untouched and ErrVersionConflict is returned.
+func updateVersioned(db *gorm.DB, model interface{}, version *int64, expectedVersion int64) error {
+	*version = expectedVersion + 1
+
+	result := db.Model(model).
+		Where("version = ?", expectedVersion).
+		Select("*").
+		Omit("id", "created_at", clause.Associations).
+		Updates(model)
+	if result.Error != nil {
+		*version = expectedVersion
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		*version = expectedVersion
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Errors
+var (
+	ErrVersionConflict = fmt.Errorf("record was modified by another request")
+)
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// WebhookService provides webhook subscription database operations
+type WebhookService struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(db *gorm.DB, logger *logrus.Logger) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateSubscription creates a new webhook subscription
+func (s *WebhookService) CreateSubscription(subscription *WebhookSubscription) error {
+	if err := s.db.Create(subscription).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to create webhook subscription")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"subscription_id": subscription.ID,
+		"url":             subscription.URL,
+	}).Info("Webhook subscription created")
+
+	return nil
+}
+
+// GetSubscription retrieves a webhook subscription by ID
+func (s *WebhookService) GetSubscription(id uuid.UUID) (*WebhookSubscription, error) {
+	var subscription WebhookSubscription
+
+	err := s.db.First(&subscription, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		s.logger.WithError(err).Error("Failed to get webhook subscription")
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// ListSubscriptions retrieves webhook subscriptions with pagination
+func (s *WebhookService) ListSubscriptions(limit, offset int) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	err := s.db.Order(recentFirst("created_at")).
+		Limit(limit).
+		Offset(offset).
+		Find(&subscriptions).Error
+
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list webhook subscriptions")
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// UpdateSubscription saves changes to a webhook subscription that is still at
+// expectedVersion, returning ErrVersionConflict when it has moved on
+func (s *WebhookService) UpdateSubscription(subscription *WebhookSubscription, expectedVersion int64) error {
+	subscription.UpdatedAt = time.Now()
+	if err := updateVersioned(s.db, subscription, &subscription.Version, expectedVersion); err != nil {
+		if err != ErrVersionConflict {
+			s.logger.WithError(err).Error("Failed to update webhook subscription")
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteSubscription deletes a webhook subscription
+func (s *WebhookService) DeleteSubscription(id uuid.UUID) error {
+	if err := s.db.Delete(&WebhookSubscription{}, "id = ?", id).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to delete webhook subscription")
+		return err
+	}
+
+	s.logger.WithField("subscription_id", id).Info("Webhook subscription deleted")
+	return nil
+}
+
+// GetActiveSubscriptions retrieves active subscriptions interested in an event type
+func (s *WebhookService) GetActiveSubscriptions(eventType string) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+
+	if err := s.db.Where("is_active = ?", true).Find(&subscriptions).Error; err != nil {
+		s.logger.WithError(err).Error("Failed to get active webhook subscriptions")
+		return nil, err
+	}
+
+	matching := subscriptions[:0]
+	for _, subscription := range subscriptions {
+		if subscription.Matches(eventType) {
+			matching = append(matching, subscription)
+		}
+	}
+
+	return matching, nil
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// Defaults for demo options left at zero
+const (
+	demoLoadNoise      = 0.03
+	demoFrequencyNoise = 0.02
+	demoFaultRate      = 0.02
+	demoRepairTicks    = 90
+)
+
+// Load noise is interpolated between random points this many ticks apart,
+// so it wanders rather than jumping every tick
+const demoNoiseSpan = 15
+
+// DemoOptions configure the demo engine
+type DemoOptions struct {
+	// Wall time of a tick at speed 1
+	TickInterval time.Duration
+	// Seed of the noise and faults of simulations created without one;
+	// zero picks one from the clock. Each such simulation draws from its
+	// own stream derived from it.
+	Seed uint64
+	// Largest fraction the load strays from its daily profile
+	LoadNoise float64
+	// Largest deviation of the frequency, in Hz, on top of any imbalance
+	FrequencyNoiseHz float64
+	// Chance that an operational plant trips in a simulated hour
+	FaultRate float64
+	// Ticks a tripped plant stays out before it recovers
+	RepairTicks int64
+}
+
+// DemoDescriptor returns the descriptor of the demo engine
+func DemoDescriptor() Descriptor {
+	descriptor := MockDescriptor()
+	descriptor.Name = "demo"
+	return descriptor
+}
+
+// NewDemoEngine creates an in-process engine for demos and frontend work.
+// It simulates like the mock engine, and on top of that the load and
+// frequency wander and plants trip now and then and come back after a
+// while. The disturbances are drawn from the simulation's seed, so runs
+// with the same config and seed give the same figures.
+func NewDemoEngine(opts DemoOptions) *MockEngine {
+	if opts.Seed == 0 {
+		opts.Seed = uint64(time.Now().UnixNano())
+	}
+	if opts.LoadNoise <= 0 {
+		opts.LoadNoise = demoLoadNoise
+	}
+	if opts.FrequencyNoiseHz <= 0 {
+		opts.FrequencyNoiseHz = demoFrequencyNoise
+	}
+	if opts.FaultRate <= 0 {
+		opts.FaultRate = demoFaultRate
+	}
+	if opts.RepairTicks <= 0 {
+		opts.RepairTicks = demoRepairTicks
+	}
+
+	m := NewMockEngine(opts.TickInterval)
+	m.descriptor = DemoDescriptor()
+	m.demo = &opts
+	return m
+}
+
+// loadFactor is the factor the demo noise applies to a run's load at a tick
+func (o *DemoOptions) loadFactor(seed uint64, tick int64) float64 {
+	span := tick / demoNoiseSpan
+	from := demoNoise(seed, "load", span)
+	to := demoNoise(seed, "load", span+1)
+	t := float64(tick%demoNoiseSpan) / demoNoiseSpan
+	return 1 + o.LoadNoise*(from+(to-from)*t)
+}
+
+// frequencyOffset is the demo noise on a run's frequency at a tick
+func (o *DemoOptions) frequencyOffset(seed uint64, tick int64) float64 {
+	return o.FrequencyNoiseHz * demoNoise(seed, "frequency", tick)
+}
+
+// tripped reports whether a plant is out at a tick because of a random
+// fault. Each simulated hour the plant may trip at a random minute; it
+// stays out for RepairTicks.
+func (o *DemoOptions) tripped(seed uint64, plantID string, tick int64) bool {
+	first := (tick - o.RepairTicks) / 60
+	if first < 0 {
+		first = 0
+	}
+	for hour := first; hour <= tick/60; hour++ {
+		if demoUniform(seed, "fault:"+plantID, hour) >= o.FaultRate {
+			continue
+		}
+		at := hour*60 + int64(demoUniform(seed, "fault-minute:"+plantID, hour)*60)
+		if at <= tick && tick < at+o.RepairTicks {
+			return true
+		}
+	}
+	return false
+}
+
+// demoUniform returns a number in [0, 1) fixed by the seed, stream and index
+func demoUniform(seed uint64, stream string, index int64) float64 {
+	h := fnv.New64a()
+	var buf [16]byte
+	for i := 0; i < 8; i++ {
+		buf[i] = byte(seed >> (8 * i))
+		buf[8+i] = byte(index >> (8 * i))
+	}
+	h.Write(buf[:])
+	h.Write([]byte(stream))
+	return float64(splitmix(h.Sum64())>>11) / (1 << 53)
+}
+
+// demoNoise returns a number in [-1, 1) fixed by the seed, stream and index
+func demoNoise(seed uint64, stream string, index int64) float64 {
+	return 2*demoUniform(seed, stream, index) - 1
+}
+
+// splitmix scrambles the bits of a hash so nearby inputs land far apart
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
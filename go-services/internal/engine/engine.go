@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Engine runs simulations. The Zig engine is reached over gRPC; other
+// implementations run in process. Simulations are identified by the ID the
+// engine returns from Create.
+type Engine interface {
+	// Descriptor describes the engine for placement
+	Descriptor() Descriptor
+	// Create prepares a simulation and returns the engine's ID for it
+	Create(ctx context.Context, spec Spec) (string, error)
+	// Start runs a created or stopped simulation
+	Start(ctx context.Context, id string) error
+	// Stop halts a running simulation; it may be started again
+	Stop(ctx context.Context, id string) error
+	// Stream sends the simulation's state as it advances. The channel is
+	// closed when the simulation stops or ctx is done.
+	Stream(ctx context.Context, id string) (<-chan State, error)
+	// Inject fails a component of a running simulation
+	Inject(ctx context.Context, id string, fault Fault) error
+	// Close releases the engine's resources
+	Close() error
+}
+
+// Spec is what an engine needs to create a simulation
+type Spec struct {
+	Name string
+	// Simulation config as JSON, in the form the API accepts
+	Config json.RawMessage
+	// Real-time factor; 0 runs as fast as possible
+	Speed float64
+	// Seeds the engine's randomness so a run can be reproduced
+	Seed uint64
+}
+
+// State is a snapshot of a simulation on an engine
+type State struct {
+	SimulationID       string    `json:"simulation_id"`
+	Tick               int64     `json:"tick"`
+	Timestamp          time.Time `json:"timestamp"`
+	TotalGenerationMW  float64   `json:"total_generation_mw"`
+	TotalConsumptionMW float64   `json:"total_consumption_mw"`
+	FrequencyHz        float64   `json:"frequency_hz"`
+	VoltageLevels      []float64 `json:"voltage_levels"`
+	// IDs of the components currently failed
+	ActiveFailures []string `json:"active_failures"`
+}
+
+// Fault is a component failure to inject
+type Fault struct {
+	ComponentID string `json:"component_id"`
+	Type        string `json:"type"`
+}
+
+// Validate checks that the fault names a component
+func (f Fault) Validate() error {
+	if f.ComponentID == "" {
+		return fmt.Errorf("%w: component_id is required", ErrInvalidFault)
+	}
+	if f.Type == "" {
+		return fmt.Errorf("%w: type is required", ErrInvalidFault)
+	}
+	return nil
+}
+
+// RegisterEngine registers an engine implementation under its descriptor's
+// name, replacing any engine of that name
+func (r *Registry) RegisterEngine(e Engine) {
+	descriptor := e.Descriptor()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.engines[descriptor.Name] = descriptor
+	r.implementations[descriptor.Name] = e
+}
+
+// Engine returns the implementation registered for an engine. Engines
+// registered by descriptor alone have none.
+func (r *Registry) Engine(name string) (Engine, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.implementations[name]
+	return e, ok
+}
@@ -0,0 +1,350 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// mockTicksPerDay is how many ticks make a simulated day; a tick is a minute
+const mockTicksPerDay = 1440
+
+// Defaults for configs that leave them out
+const (
+	mockBaseFrequencyHz = 50
+	mockBaseVoltageKV   = 230
+)
+
+// MockEngine is an in-process engine for tests and local development
+// without the Zig binary. A simulation's state is computed from its config,
+// its tick and the faults injected so far, so the same inputs always give
+// the same figures.
+type MockEngine struct {
+	descriptor Descriptor
+	// Wall time of a tick at speed 1
+	tickInterval time.Duration
+	// Noise and random faults of the demo engine; nil for the mock engine
+	demo *DemoOptions
+
+	mu   sync.Mutex
+	runs map[string]*mockRun
+	next int64
+}
+
+// mockRun is a simulation created on a mock engine
+type mockRun struct {
+	id     string
+	speed  float64
+	config mockConfig
+	// Ticks reached before the current start, and when it happened
+	baseTick  int64
+	startedAt time.Time
+	running   bool
+	// Tick each failed component failed at
+	failures map[string]int64
+	// Seed of the run's demo noise and faults
+	seed uint64
+}
+
+// mockConfig is the part of a simulation config the mock engine simulates
+type mockConfig struct {
+	PowerPlants []struct {
+		ID            string  `json:"id"`
+		MaxCapacityMW float64 `json:"max_capacity_mw"`
+		IsOperational bool    `json:"is_operational"`
+	} `json:"power_plants"`
+	BaseFrequency float64 `json:"base_frequency"`
+	BaseVoltage   float64 `json:"base_voltage"`
+	LoadProfile   struct {
+		BaseLoadMW     float64 `json:"base_load_mw"`
+		DailyVariation float64 `json:"daily_variation"`
+	} `json:"load_profile"`
+}
+
+// MockDescriptor returns the descriptor of the mock engine
+func MockDescriptor() Descriptor {
+	return Descriptor{
+		Name:    "mock",
+		Version: "1.0.0",
+		Capabilities: []string{
+			CapabilityFaultInjection,
+			CapabilityStreaming,
+			CapabilityStorage,
+		},
+		ComponentTypes: []string{"*"},
+	}
+}
+
+// NewMockEngine creates a mock engine advancing one tick per tickInterval at speed 1
+func NewMockEngine(tickInterval time.Duration) *MockEngine {
+	if tickInterval <= 0 {
+		tickInterval = time.Second
+	}
+	return &MockEngine{
+		descriptor:   MockDescriptor(),
+		tickInterval: tickInterval,
+		runs:         make(map[string]*mockRun),
+	}
+}
+
+// Descriptor describes the mock engine
+func (m *MockEngine) Descriptor() Descriptor {
+	return m.descriptor
+}
+
+// Create prepares a simulation at tick 0
+func (m *MockEngine) Create(ctx context.Context, spec Spec) (string, error) {
+	var config mockConfig
+	if len(spec.Config) > 0 {
+		if err := json.Unmarshal(spec.Config, &config); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrUnsupportedConfig, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.next++
+	run := &mockRun{
+		id:       fmt.Sprintf("mock_%d", m.next),
+		speed:    spec.Speed,
+		config:   config,
+		failures: make(map[string]int64),
+		seed:     spec.Seed,
+	}
+	if run.seed == 0 && m.demo != nil {
+		run.seed = m.demo.Seed + uint64(m.next)
+	}
+	m.runs[run.id] = run
+	return run.id, nil
+}
+
+// Start runs a simulation from the tick it was stopped at
+func (m *MockEngine) Start(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run, ok := m.runs[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	if !run.running {
+		run.running = true
+		run.startedAt = time.Now()
+	}
+	return nil
+}
+
+// Stop freezes a simulation at its current tick
+func (m *MockEngine) Stop(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run, ok := m.runs[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	if run.running {
+		run.baseTick = m.tickLocked(run, time.Now())
+		run.running = false
+	}
+	return nil
+}
+
+// Stream sends the state of a running simulation once per tick. When
+// ticks pass faster than the receiver reads, only the latest is sent.
+func (m *MockEngine) Stream(ctx context.Context, id string) (<-chan State, error) {
+	m.mu.Lock()
+	run, ok := m.runs[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	interval := m.intervalLocked(run)
+	m.mu.Unlock()
+
+	states := make(chan State)
+	go func() {
+		defer close(states)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := int64(-1)
+		for {
+			m.mu.Lock()
+			running := run.running
+			tick := m.tickLocked(run, time.Now())
+			var state State
+			if tick > last {
+				state = m.stateLocked(run, tick)
+			}
+			m.mu.Unlock()
+
+			if !running {
+				return
+			}
+			if tick > last {
+				select {
+				case states <- state:
+					last = tick
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return states, nil
+}
+
+// Inject fails a component from the current tick on. Failing a power plant
+// takes its capacity away.
+func (m *MockEngine) Inject(ctx context.Context, id string, fault Fault) error {
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run, ok := m.runs[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	if _, failed := run.failures[fault.ComponentID]; !failed {
+		run.failures[fault.ComponentID] = m.tickLocked(run, time.Now())
+	}
+	return nil
+}
+
+// StateAt computes a simulation's state at a tick, whether or not it has
+// been reached
+func (m *MockEngine) StateAt(id string, tick int64) (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run, ok := m.runs[id]
+	if !ok {
+		return State{}, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	return m.stateLocked(run, tick), nil
+}
+
+// Close forgets every simulation
+func (m *MockEngine) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.runs = make(map[string]*mockRun)
+	return nil
+}
+
+// intervalLocked is the wall time of one of the run's ticks; speed 0 runs
+// a hundred times faster than real time
+func (m *MockEngine) intervalLocked(run *mockRun) time.Duration {
+	speed := run.speed
+	if speed <= 0 {
+		speed = 100
+	}
+	interval := time.Duration(float64(m.tickInterval) / speed)
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	return interval
+}
+
+// tickLocked is the tick a run has reached at a time (must be called with lock held)
+func (m *MockEngine) tickLocked(run *mockRun, now time.Time) int64 {
+	if !run.running {
+		return run.baseTick
+	}
+	return run.baseTick + int64(now.Sub(run.startedAt)/m.intervalLocked(run))
+}
+
+// stateLocked computes a run's state at a tick (must be called with lock held).
+// Load follows a daily sine around the base load; plants cover it up to
+// their available capacity, and any shortfall pulls frequency and voltage
+// down in proportion. The demo engine adds its noise and random trips.
+func (m *MockEngine) stateLocked(run *mockRun, tick int64) State {
+	config := run.config
+
+	var failed []string
+	for componentID, at := range run.failures {
+		if at <= tick {
+			failed = append(failed, componentID)
+		}
+	}
+	if m.demo != nil {
+		for _, plant := range config.PowerPlants {
+			if at, injected := run.failures[plant.ID]; injected && at <= tick {
+				continue
+			}
+			if plant.IsOperational && m.demo.tripped(run.seed, plant.ID, tick) {
+				failed = append(failed, plant.ID)
+			}
+		}
+	}
+	sort.Strings(failed)
+	isFailed := func(componentID string) bool {
+		i := sort.SearchStrings(failed, componentID)
+		return i < len(failed) && failed[i] == componentID
+	}
+
+	load := config.LoadProfile.BaseLoadMW *
+		(1 + config.LoadProfile.DailyVariation*math.Sin(2*math.Pi*float64(tick%mockTicksPerDay)/mockTicksPerDay))
+	if m.demo != nil {
+		load *= m.demo.loadFactor(run.seed, tick)
+	}
+
+	available := 0.0
+	for _, plant := range config.PowerPlants {
+		if plant.IsOperational && !isFailed(plant.ID) {
+			available += plant.MaxCapacityMW
+		}
+	}
+	generation := math.Min(load, available)
+
+	imbalance := 0.0
+	if load > 0 {
+		imbalance = (generation - load) / load
+	}
+
+	baseFrequency := config.BaseFrequency
+	if baseFrequency <= 0 {
+		baseFrequency = mockBaseFrequencyHz
+	}
+	baseVoltage := config.BaseVoltage
+	if baseVoltage <= 0 {
+		baseVoltage = mockBaseVoltageKV
+	}
+
+	frequency := baseFrequency * (1 + 0.04*imbalance)
+	if m.demo != nil {
+		frequency += m.demo.frequencyOffset(run.seed, tick)
+	}
+
+	if failed == nil {
+		failed = []string{}
+	}
+	return State{
+		SimulationID:       run.id,
+		Tick:               tick,
+		Timestamp:          time.Now().UTC(),
+		TotalGenerationMW:  generation,
+		TotalConsumptionMW: load,
+		FrequencyHz:        frequency,
+		VoltageLevels:      []float64{baseVoltage * (1 + 0.05*imbalance)},
+		ActiveFailures:     failed,
+	}
+}
@@ -0,0 +1,284 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Capabilities advertised by simulation engines
+const (
+	CapabilityFaultInjection = "fault_injection"
+	CapabilityPause          = "pause"
+	CapabilityStreaming      = "streaming"
+	CapabilityStorage        = "storage"
+)
+
+// Component types understood by simulation engines
+const (
+	ComponentTransmissionLine = "transmission_line"
+	ComponentStorageUnit      = "storage_unit"
+)
+
+// PlantComponent returns the component type identifier for a power plant type
+func PlantComponent(plantType string) string {
+	return "power_plant:" + strings.ToLower(plantType)
+}
+
+// Descriptor describes a registered simulation engine
+type Descriptor struct {
+	Name           string   `json:"name"`
+	Version        string   `json:"version"`
+	Capabilities   []string `json:"capabilities"`
+	ComponentTypes []string `json:"component_types"`
+	MaxConcurrent  int      `json:"max_concurrent"`
+
+	// Internal address of the engine, never included in API responses
+	Endpoint string `json:"-"`
+}
+
+// Supports reports whether the engine can run a component type
+func (d Descriptor) Supports(componentType string) bool {
+	for _, supported := range d.ComponentTypes {
+		if supported == componentType || supported == "*" {
+			return true
+		}
+		// "power_plant:*" accepts every plant type
+		if strings.HasSuffix(supported, ":*") && strings.HasPrefix(componentType, strings.TrimSuffix(supported, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCapability reports whether the engine advertises a capability
+func (d Descriptor) HasCapability(capability string) bool {
+	for _, c := range d.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Status is a descriptor with the engine's current load
+type Status struct {
+	Descriptor
+	ActiveSimulations int     `json:"active_simulations"`
+	Load              float64 `json:"load"`
+}
+
+// Requirements lists what a simulation needs from an engine
+type Requirements struct {
+	ComponentTypes []string
+	Capabilities   []string
+}
+
+// Registry tracks available simulation engines and their load
+type Registry struct {
+	mu      sync.RWMutex
+	engines map[string]Descriptor
+	active  map[string]int
+	// Engines registered with RegisterEngine
+	implementations map[string]Engine
+}
+
+// NewRegistry creates an empty engine registry
+func NewRegistry() *Registry {
+	return &Registry{
+		engines:         make(map[string]Descriptor),
+		active:          make(map[string]int),
+		implementations: make(map[string]Engine),
+	}
+}
+
+// Register adds or replaces an engine descriptor. Simulations placed on an
+// engine registered this way are not driven through an Engine; see
+// RegisterEngine.
+func (r *Registry) Register(descriptor Descriptor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.engines[descriptor.Name] = descriptor
+	delete(r.implementations, descriptor.Name)
+}
+
+// Get returns the status of a registered engine
+func (r *Registry) Get(name string) (Status, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	descriptor, ok := r.engines[name]
+	if !ok {
+		return Status{}, fmt.Errorf("%w: %s", ErrEngineNotFound, name)
+	}
+
+	return r.statusLocked(descriptor), nil
+}
+
+// List returns the status of all registered engines sorted by name
+func (r *Registry) List() []Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.listLocked()
+}
+
+// Validate checks that an engine satisfies the requirements
+func (r *Registry) Validate(name string, req Requirements) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	descriptor, ok := r.engines[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrEngineNotFound, name)
+	}
+
+	return checkRequirements(descriptor, req)
+}
+
+// Place selects the least loaded engine satisfying the requirements.
+// When preferred is set only that engine is considered.
+func (r *Registry) Place(req Requirements, preferred string) (string, error) {
+	if preferred != "" {
+		if err := r.Validate(preferred, req); err != nil {
+			return "", err
+		}
+		return preferred, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		best     string
+		bestLoad float64
+		lastErr  error
+	)
+
+	for _, status := range r.listLocked() {
+		if err := checkRequirements(status.Descriptor, req); err != nil {
+			lastErr = err
+			continue
+		}
+		if status.MaxConcurrent > 0 && status.ActiveSimulations >= status.MaxConcurrent {
+			lastErr = fmt.Errorf("engine %s is at capacity", status.Name)
+			continue
+		}
+		if best == "" || status.Load < bestLoad {
+			best = status.Name
+			bestLoad = status.Load
+		}
+	}
+
+	if best == "" {
+		if lastErr == nil {
+			lastErr = ErrNoEngines
+		}
+		return "", fmt.Errorf("no engine can run this simulation: %w", lastErr)
+	}
+
+	return best, nil
+}
+
+// Acquire records a simulation starting on an engine
+func (r *Registry) Acquire(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.active[name]++
+}
+
+// Release records a simulation leaving an engine
+func (r *Registry) Release(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active[name] > 0 {
+		r.active[name]--
+	}
+}
+
+// listLocked returns engine statuses sorted by name (must be called with lock held)
+func (r *Registry) listLocked() []Status {
+	statuses := make([]Status, 0, len(r.engines))
+	for _, descriptor := range r.engines {
+		statuses = append(statuses, r.statusLocked(descriptor))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// statusLocked builds the status of an engine (must be called with lock held)
+func (r *Registry) statusLocked(descriptor Descriptor) Status {
+	active := r.active[descriptor.Name]
+	status := Status{
+		Descriptor:        descriptor,
+		ActiveSimulations: active,
+	}
+	if descriptor.MaxConcurrent > 0 {
+		status.Load = float64(active) / float64(descriptor.MaxConcurrent)
+	}
+	return status
+}
+
+// checkRequirements verifies an engine descriptor against requirements
+func checkRequirements(descriptor Descriptor, req Requirements) error {
+	var unsupported []string
+	for _, componentType := range req.ComponentTypes {
+		if !descriptor.Supports(componentType) {
+			unsupported = append(unsupported, componentType)
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%w: engine %s does not support %s", ErrUnsupportedConfig, descriptor.Name, strings.Join(unsupported, ", "))
+	}
+
+	for _, capability := range req.Capabilities {
+		if !descriptor.HasCapability(capability) {
+			return fmt.Errorf("%w: engine %s lacks capability %s", ErrUnsupportedConfig, descriptor.Name, capability)
+		}
+	}
+
+	return nil
+}
+
+// ZigDescriptor returns the descriptor of the Zig simulation engine
+func ZigDescriptor(endpoint string, maxConcurrent int) Descriptor {
+	return Descriptor{
+		Name:     "zig",
+		Version:  "0.1.0",
+		Endpoint: endpoint,
+		Capabilities: []string{
+			CapabilityFaultInjection,
+			CapabilityPause,
+			CapabilityStreaming,
+			CapabilityStorage,
+		},
+		ComponentTypes: []string{
+			PlantComponent("coal"),
+			PlantComponent("gas"),
+			PlantComponent("nuclear"),
+			PlantComponent("hydro"),
+			PlantComponent("wind"),
+			PlantComponent("solar"),
+			PlantComponent("battery_storage"),
+			PlantComponent("geothermal"),
+			ComponentTransmissionLine,
+			ComponentStorageUnit,
+		},
+		MaxConcurrent: maxConcurrent,
+	}
+}
+
+// Errors
+var (
+	ErrEngineNotFound    = fmt.Errorf("engine not found")
+	ErrNoEngines         = fmt.Errorf("no engines registered")
+	ErrUnsupportedConfig = fmt.Errorf("unsupported simulation config")
+	ErrRunNotFound       = fmt.Errorf("engine simulation not found")
+	ErrInvalidFault      = fmt.Errorf("invalid fault")
+)
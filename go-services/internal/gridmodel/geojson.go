@@ -0,0 +1,149 @@
+package gridmodel
+
+import (
+	"voltedge/go-services/internal/orchestration"
+)
+
+// GeoJSON geometry types
+const (
+	geometryPoint      = "Point"
+	geometryLineString = "LineString"
+)
+
+// FeatureCollection is a GeoJSON feature collection of a simulation's grid
+type FeatureCollection struct {
+	Type         string    `json:"type"`
+	Features     []Feature `json:"features"`
+	SimulationID string    `json:"simulation_id"`
+	Status       string    `json:"status"`
+	// Components left out because they (or a line endpoint) have no lat/lon
+	Skipped int `json:"skipped"`
+}
+
+// Feature is a single GeoJSON feature
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON point or line string. Positions are [lon, lat].
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// GeoJSON converts the buses, plants and lines of a simulation into map
+// features carrying their current status. Only components with lat/lon (or on
+// a bus with lat/lon) are placed; lines take their coordinates from their
+// endpoints.
+func GeoJSON(simulation *orchestration.Simulation) FeatureCollection {
+	config := simulation.Config
+	collection := FeatureCollection{
+		Type:         "FeatureCollection",
+		Features:     []Feature{},
+		SimulationID: simulation.ID,
+		Status:       simulation.Status.String(),
+	}
+
+	// Line endpoints are buses, or plants and storage units in configs without buses
+	positions := make(map[string][]float64)
+	// Components without coordinates of their own fall back to their bus
+	place := func(id string, location orchestration.Location, busID string) ([]float64, bool) {
+		if !location.HasCoordinates() {
+			position, ok := positions[busID]
+			if !ok {
+				collection.Skipped++
+			}
+			return position, ok
+		}
+		position := []float64{*location.Longitude, *location.Latitude}
+		positions[id] = position
+		return position, true
+	}
+
+	for _, bus := range config.Buses {
+		position, ok := place(bus.ID, bus.Location, "")
+		if !ok {
+			continue
+		}
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			ID:       bus.ID,
+			Geometry: Geometry{Type: geometryPoint, Coordinates: position},
+			Properties: map[string]interface{}{
+				"kind":       "bus",
+				"name":       bus.Name,
+				"bus_type":   bus.Type,
+				"voltage_kv": bus.VoltageKV,
+			},
+		})
+	}
+
+	for _, plant := range config.PowerPlants {
+		position, ok := place(plant.ID, plant.Location, plant.BusID)
+		if !ok {
+			continue
+		}
+		var utilization float64
+		if plant.MaxCapacityMW > 0 {
+			utilization = plant.CurrentOutputMW / plant.MaxCapacityMW
+		}
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			ID:       plant.ID,
+			Geometry: Geometry{Type: geometryPoint, Coordinates: position},
+			Properties: map[string]interface{}{
+				"kind":              "power_plant",
+				"name":              plant.Name,
+				"plant_type":        plant.Type,
+				"bus_id":            plant.BusID,
+				"max_capacity_mw":   plant.MaxCapacityMW,
+				"current_output_mw": plant.CurrentOutputMW,
+				"utilization":       utilization,
+				"is_operational":    plant.IsOperational,
+				"status":            componentStatus(plant.IsOperational),
+			},
+		})
+	}
+
+	// Storage units are not mapped but can terminate lines in configs without buses
+	for _, unit := range config.StorageUnits {
+		if unit.Location.HasCoordinates() {
+			positions[unit.ID] = []float64{*unit.Location.Longitude, *unit.Location.Latitude}
+		}
+	}
+
+	for _, line := range config.TransmissionLines {
+		from, fromOK := positions[line.FromNode]
+		to, toOK := positions[line.ToNode]
+		if !fromOK || !toOK {
+			collection.Skipped++
+			continue
+		}
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			ID:       line.ID,
+			Geometry: Geometry{Type: geometryLineString, Coordinates: [][]float64{from, to}},
+			Properties: map[string]interface{}{
+				"kind":           "transmission_line",
+				"from_node":      line.FromNode,
+				"to_node":        line.ToNode,
+				"capacity_mw":    line.CapacityMW,
+				"length_km":      line.LengthKM,
+				"is_operational": line.IsOperational,
+				"status":         componentStatus(line.IsOperational),
+			},
+		})
+	}
+
+	return collection
+}
+
+func componentStatus(operational bool) string {
+	if operational {
+		return "operational"
+	}
+	return "offline"
+}
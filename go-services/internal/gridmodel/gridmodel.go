@@ -0,0 +1,175 @@
+package gridmodel
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"voltedge/go-services/internal/orchestration"
+)
+
+// Supported import formats
+const (
+	FormatMATPOWER = "matpower"
+	FormatPSSE     = "psse"
+)
+
+// Import defaults for values the source formats do not carry
+const (
+	defaultBusVoltageKV = 230
+	// Rating used for branches declared unlimited (a zero rating in both formats)
+	unlimitedRatingMW = 9999
+	defaultPlantType  = "gas"
+	layoutRadius      = 100
+)
+
+// Unmapped is a source field with no VoltEdge equivalent that held data
+type Unmapped struct {
+	Field  string `json:"field"`
+	Rows   int    `json:"rows"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport describes what was converted and what was lost
+type ImportReport struct {
+	Format     string     `json:"format"`
+	CaseName   string     `json:"case_name"`
+	BaseMVA    float64    `json:"base_mva"`
+	Buses      int        `json:"buses"`
+	Generators int        `json:"generators"`
+	Branches   int        `json:"branches"`
+	Unmapped   []Unmapped `json:"unmapped"`
+	Warnings   []string   `json:"warnings"`
+}
+
+// ImportResult is a converted grid model
+type ImportResult struct {
+	Config orchestration.SimulationConfig `json:"config"`
+	Report ImportReport                   `json:"report"`
+}
+
+// Import parses a grid model in the given format
+func Import(format string, data []byte) (*ImportResult, error) {
+	switch strings.ToLower(format) {
+	case FormatMATPOWER:
+		return ImportMATPOWER(data)
+	case FormatPSSE:
+		return ImportPSSE(data)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// unmappedTracker counts rows that carried data in fields VoltEdge cannot represent
+type unmappedTracker struct {
+	rows    map[string]int
+	reasons map[string]string
+}
+
+func newUnmappedTracker() *unmappedTracker {
+	return &unmappedTracker{
+		rows:    make(map[string]int),
+		reasons: make(map[string]string),
+	}
+}
+
+// note records a field when its value is set
+func (t *unmappedTracker) note(field string, value float64, reason string) {
+	if value == 0 || math.IsNaN(value) {
+		return
+	}
+	t.add(field, reason)
+}
+
+func (t *unmappedTracker) add(field, reason string) {
+	t.rows[field]++
+	t.reasons[field] = reason
+}
+
+func (t *unmappedTracker) list() []Unmapped {
+	unmapped := make([]Unmapped, 0, len(t.rows))
+	for field, rows := range t.rows {
+		unmapped = append(unmapped, Unmapped{Field: field, Rows: rows, Reason: t.reasons[field]})
+	}
+	sort.Slice(unmapped, func(i, j int) bool { return unmapped[i].Field < unmapped[j].Field })
+	return unmapped
+}
+
+// layoutBuses places buses evenly on a circle since neither format carries
+// coordinates, and attaches plants to the location of their bus
+func layoutBuses(config *orchestration.SimulationConfig) {
+	locations := make(map[string]orchestration.Location, len(config.Buses))
+	for i := range config.Buses {
+		bus := &config.Buses[i]
+		angle := 2 * math.Pi * float64(i) / float64(len(config.Buses))
+		bus.Location = orchestration.Location{
+			X:    math.Round(layoutRadius*math.Cos(angle)*100) / 100,
+			Y:    math.Round(layoutRadius*math.Sin(angle)*100) / 100,
+			Name: bus.Name,
+		}
+		locations[bus.ID] = bus.Location
+	}
+	for i := range config.PowerPlants {
+		config.PowerPlants[i].Location = locations[config.PowerPlants[i].BusID]
+	}
+}
+
+// finish fills config-wide values and the common warnings of both importers
+func finish(config *orchestration.SimulationConfig, report *ImportReport, totalLoadMW float64) {
+	layoutBuses(config)
+
+	for _, bus := range config.Buses {
+		config.BaseVoltage = math.Max(config.BaseVoltage, bus.VoltageKV)
+	}
+	config.LoadProfile = orchestration.LoadProfile{
+		BaseLoadMW:     totalLoadMW,
+		PeakMultiplier: 1,
+	}
+
+	report.Buses = len(config.Buses)
+	report.Generators = len(config.PowerPlants)
+	report.Branches = len(config.TransmissionLines)
+	report.Warnings = append(report.Warnings,
+		"bus loads are aggregated into load_profile.base_load_mw",
+		"the source has no coordinates; buses are laid out on a circle",
+		"branch lengths are unknown unless given; impedances are stored per 1 km of a 1 km line",
+	)
+}
+
+// busID returns the VoltEdge ID of a numbered bus
+func busID(number int) string {
+	return fmt.Sprintf("bus_%d", number)
+}
+
+// plantType maps a source fuel name onto a VoltEdge plant type
+func plantType(fuel string) string {
+	switch strings.ToLower(strings.TrimSpace(fuel)) {
+	case "coal", "lignite":
+		return "coal"
+	case "ng", "gas", "natural gas", "ccgt", "ocgt":
+		return "gas"
+	case "oil", "dfo", "rfo":
+		return "oil"
+	case "nuclear":
+		return "nuclear"
+	case "hydro", "water":
+		return "hydro"
+	case "wind":
+		return "wind"
+	case "solar", "pv":
+		return "solar"
+	case "geothermal":
+		return "geothermal"
+	case "biomass":
+		return "biomass"
+	case "storage", "battery":
+		return "battery_storage"
+	}
+	return ""
+}
+
+// Errors
+var (
+	ErrUnsupportedFormat = fmt.Errorf("unsupported grid model format")
+	ErrInvalidModel      = fmt.Errorf("invalid grid model")
+)
@@ -0,0 +1,374 @@
+package gridmodel
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"voltedge/go-services/internal/orchestration"
+)
+
+// MATPOWER column indices (version 2 case format)
+const (
+	mpBusI, mpBusType, mpPD, mpQD, mpGS, mpBS, mpBusArea, mpVM, mpVA, mpBaseKV, mpZone, mpVMax, mpVMin = 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12
+
+	mpGenBus, mpPG, mpQG, mpQMax, mpQMin, mpVG, mpMBase, mpGenStatus, mpPMax, mpPMin = 0, 1, 2, 3, 4, 5, 6, 7, 8, 9
+
+	mpFBus, mpTBus, mpBrR, mpBrX, mpBrB, mpRateA, mpRateB, mpRateC, mpTap, mpShift, mpBrStatus, mpAngMin, mpAngMax = 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12
+)
+
+// Gen cost models
+const (
+	mpCostPiecewise  = 1
+	mpCostPolynomial = 2
+)
+
+var (
+	mpFunctionPattern   = regexp.MustCompile(`function\s+(?:\w+\s*=\s*)?(\w+)`)
+	mpAssignmentPattern = regexp.MustCompile(`mpc\.(\w+)\s*=\s*`)
+	mpQuotedPattern     = regexp.MustCompile(`'([^']*)'`)
+)
+
+// matpowerCase is the raw content of a MATPOWER case file
+type matpowerCase struct {
+	name     string
+	scalars  map[string]string
+	matrices map[string][][]float64
+	cells    map[string][]string
+}
+
+// ImportMATPOWER converts a MATPOWER case file into a simulation config
+func ImportMATPOWER(data []byte) (*ImportResult, error) {
+	mpc, err := parseMATPOWER(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if version := strings.Trim(mpc.scalars["version"], "'\""); version == "1" {
+		return nil, fmt.Errorf("%w: MATPOWER version 1 case files are not supported", ErrInvalidModel)
+	}
+
+	baseMVA := 100.0
+	if raw, ok := mpc.scalars["baseMVA"]; ok {
+		if baseMVA, err = strconv.ParseFloat(raw, 64); err != nil || baseMVA <= 0 {
+			return nil, fmt.Errorf("%w: invalid baseMVA %q", ErrInvalidModel, raw)
+		}
+	}
+
+	buses, gens, branches := mpc.matrices["bus"], mpc.matrices["gen"], mpc.matrices["branch"]
+	if len(buses) == 0 {
+		return nil, fmt.Errorf("%w: case has no mpc.bus data", ErrInvalidModel)
+	}
+	if err := checkColumns("bus", buses, mpVMin+1); err != nil {
+		return nil, err
+	}
+	if err := checkColumns("gen", gens, mpPMin+1); err != nil {
+		return nil, err
+	}
+	if err := checkColumns("branch", branches, mpBrStatus+1); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{
+		Report: ImportReport{
+			Format:   FormatMATPOWER,
+			CaseName: mpc.name,
+			BaseMVA:  baseMVA,
+		},
+	}
+	config := &result.Config
+	unmapped := newUnmappedTracker()
+
+	busNames := mpc.cells["bus_name"]
+	busKV := make(map[int]float64, len(buses))
+	var totalLoad float64
+	for i, row := range buses {
+		number := int(row[mpBusI])
+		if _, dup := busKV[number]; dup {
+			return nil, fmt.Errorf("%w: duplicate bus number %d", ErrInvalidModel, number)
+		}
+
+		kv := row[mpBaseKV]
+		if kv <= 0 {
+			kv = defaultBusVoltageKV
+			result.Report.Warnings = append(result.Report.Warnings, fmt.Sprintf("bus %d has no base kV; assumed %d kV", number, defaultBusVoltageKV))
+		}
+		busKV[number] = kv
+
+		name := fmt.Sprintf("Bus %d", number)
+		if i < len(busNames) && strings.TrimSpace(busNames[i]) != "" {
+			name = strings.TrimSpace(busNames[i])
+		}
+		config.Buses = append(config.Buses, orchestration.BusConfig{
+			ID:        busID(number),
+			Name:      name,
+			Type:      orchestration.BusTypeBus,
+			VoltageKV: kv,
+		})
+
+		totalLoad += row[mpPD]
+		unmapped.note("bus.QD", row[mpQD], "reactive load is not modelled")
+		unmapped.note("bus.GS", row[mpGS], "shunts are not modelled")
+		unmapped.note("bus.BS", row[mpBS], "shunts are not modelled")
+		unmapped.note("bus.VA", row[mpVA], "voltage angles are solved by the engine")
+		unmapped.note("bus.VMAX", row[mpVMax], "voltage limits are not modelled")
+		unmapped.note("bus.VMIN", row[mpVMin], "voltage limits are not modelled")
+		if row[mpBusType] == 4 {
+			unmapped.add("bus.BUS_TYPE", "isolated buses are imported as regular buses")
+		}
+	}
+
+	fuels := mpc.cells["genfuel"]
+	if len(gens) > 0 && len(fuels) == 0 {
+		result.Report.Warnings = append(result.Report.Warnings, fmt.Sprintf("no mpc.genfuel data; generators imported as %s plants", defaultPlantType))
+	}
+	costs := mpc.matrices["gencost"]
+	for i, row := range gens {
+		number := int(row[mpGenBus])
+		if _, ok := busKV[number]; !ok {
+			return nil, fmt.Errorf("%w: generator %d references unknown bus %d", ErrInvalidModel, i+1, number)
+		}
+
+		kind := defaultPlantType
+		if i < len(fuels) {
+			if mapped := plantType(fuels[i]); mapped != "" {
+				kind = mapped
+			} else {
+				unmapped.add("genfuel", "unknown fuel types are imported as "+defaultPlantType)
+			}
+		}
+
+		plant := orchestration.PowerPlantConfig{
+			ID:              fmt.Sprintf("gen_%d", i+1),
+			Name:            fmt.Sprintf("Generator %d at bus %d", i+1, number),
+			Type:            kind,
+			MaxCapacityMW:   row[mpPMax],
+			CurrentOutputMW: row[mpPG],
+			BusID:           busID(number),
+			IsOperational:   row[mpGenStatus] > 0,
+		}
+		if i < len(costs) {
+			applyGenCost(&plant, costs[i], unmapped)
+		}
+		config.PowerPlants = append(config.PowerPlants, plant)
+
+		unmapped.note("gen.QG", row[mpQG], "reactive power is not modelled")
+		unmapped.note("gen.QMAX", row[mpQMax], "reactive power is not modelled")
+		unmapped.note("gen.QMIN", row[mpQMin], "reactive power is not modelled")
+		unmapped.note("gen.VG", row[mpVG], "voltage setpoints are not modelled")
+		unmapped.note("gen.PMIN", row[mpPMin], "minimum output is not modelled")
+		if len(row) > mpPMin+1 {
+			for _, value := range row[mpPMin+1:] {
+				if value != 0 {
+					unmapped.add("gen.PC1-APF", "capability curves, ramp rates and participation factors are not modelled")
+					break
+				}
+			}
+		}
+	}
+	if len(costs) > len(gens) {
+		unmapped.add("gencost", "reactive power costs are not modelled")
+	}
+
+	for i, row := range branches {
+		from, to := int(row[mpFBus]), int(row[mpTBus])
+		kv, ok := busKV[from]
+		if !ok {
+			return nil, fmt.Errorf("%w: branch %d references unknown bus %d", ErrInvalidModel, i+1, from)
+		}
+		if _, ok := busKV[to]; !ok {
+			return nil, fmt.Errorf("%w: branch %d references unknown bus %d", ErrInvalidModel, i+1, to)
+		}
+
+		rating := row[mpRateA]
+		if rating <= 0 {
+			rating = unlimitedRatingMW
+			unmapped.add("branch.RATE_A", fmt.Sprintf("unlimited ratings are imported as %d MW", unlimitedRatingMW))
+		}
+
+		// Per-unit impedances are converted to ohms on the from-bus voltage base
+		zBase := kv * kv / baseMVA
+		config.TransmissionLines = append(config.TransmissionLines, orchestration.TransmissionLineConfig{
+			ID:              fmt.Sprintf("branch_%d", i+1),
+			FromNode:        busID(from),
+			ToNode:          busID(to),
+			CapacityMW:      rating,
+			LengthKM:        1,
+			ResistancePerKM: row[mpBrR] * zBase,
+			ReactancePerKM:  row[mpBrX] * zBase,
+			IsOperational:   row[mpBrStatus] > 0,
+		})
+
+		unmapped.note("branch.BR_B", row[mpBrB], "line charging is not modelled")
+		unmapped.note("branch.RATE_B", row[mpRateB], "only the normal (RATE_A) rating is used")
+		unmapped.note("branch.RATE_C", row[mpRateC], "only the normal (RATE_A) rating is used")
+		unmapped.note("branch.TAP", row[mpTap], "transformer taps are not modelled")
+		unmapped.note("branch.SHIFT", row[mpShift], "phase shifters are not modelled")
+		if len(row) > mpAngMax && (row[mpAngMin] > -360 || row[mpAngMax] < 360) {
+			unmapped.add("branch.ANGMIN/ANGMAX", "angle limits are not modelled")
+		}
+	}
+
+	for field, rows := range mpc.matrices {
+		switch field {
+		case "bus", "gen", "branch", "gencost":
+		default:
+			result.Report.Unmapped = append(result.Report.Unmapped, Unmapped{Field: "mpc." + field, Rows: len(rows), Reason: "section is not supported"})
+		}
+	}
+
+	finish(config, &result.Report, totalLoad)
+	result.Report.Unmapped = append(unmapped.list(), result.Report.Unmapped...)
+	result.Report.Warnings = append(result.Report.Warnings, "MATPOWER cases carry no system frequency; base_frequency is left unset")
+
+	return result, nil
+}
+
+// applyGenCost sets the marginal and startup cost of a plant from its gencost row
+func applyGenCost(plant *orchestration.PowerPlantConfig, row []float64, unmapped *unmappedTracker) {
+	if len(row) < 4 {
+		return
+	}
+	model, startup, n := int(row[0]), row[1], int(row[3])
+	coefficients := row[4:]
+	plant.StartupCost = startup
+
+	switch {
+	case model == mpCostPolynomial && n > 0 && len(coefficients) >= n:
+		// Coefficients are highest order first; the marginal cost is the derivative at current output
+		var marginal float64
+		for k := 1; k < n; k++ {
+			marginal += float64(k) * coefficients[n-1-k] * math.Pow(plant.CurrentOutputMW, float64(k-1))
+		}
+		plant.FuelCostPerMWh = marginal
+	case model == mpCostPiecewise && n >= 2 && len(coefficients) >= 2*n:
+		// Slope of the segment containing the current output
+		for k := 1; k < n; k++ {
+			p0, f0 := coefficients[2*(k-1)], coefficients[2*(k-1)+1]
+			p1, f1 := coefficients[2*k], coefficients[2*k+1]
+			if p1 > p0 {
+				plant.FuelCostPerMWh = (f1 - f0) / (p1 - p0)
+			}
+			if plant.CurrentOutputMW <= p1 {
+				break
+			}
+		}
+	default:
+		unmapped.add("gencost", "unrecognized cost model rows are ignored")
+		return
+	}
+
+	unmapped.note("gencost.SHUTDOWN", row[2], "shutdown costs are not modelled")
+}
+
+// checkColumns verifies every row of a matrix has at least n columns
+func checkColumns(name string, rows [][]float64, n int) error {
+	for i, row := range rows {
+		if len(row) < n {
+			return fmt.Errorf("%w: mpc.%s row %d has %d columns, expected at least %d", ErrInvalidModel, name, i+1, len(row), n)
+		}
+	}
+	return nil
+}
+
+// parseMATPOWER reads the mpc assignments of a case file
+func parseMATPOWER(source string) (*matpowerCase, error) {
+	mpc := &matpowerCase{
+		scalars:  make(map[string]string),
+		matrices: make(map[string][][]float64),
+		cells:    make(map[string][]string),
+	}
+
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		lines = append(lines, stripMATLABComment(line))
+	}
+	text := strings.Join(lines, "\n")
+
+	if match := mpFunctionPattern.FindStringSubmatch(text); match != nil {
+		mpc.name = match[1]
+	}
+
+	assignments := mpAssignmentPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("%w: no mpc assignments found", ErrInvalidModel)
+	}
+
+	for _, match := range assignments {
+		field := text[match[2]:match[3]]
+		rest := text[match[1]:]
+
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated matrix for mpc.%s", ErrInvalidModel, field)
+			}
+			matrix, err := parseMATLABMatrix(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%w: mpc.%s: %v", ErrInvalidModel, field, err)
+			}
+			mpc.matrices[field] = matrix
+
+		case strings.HasPrefix(rest, "{"):
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated cell array for mpc.%s", ErrInvalidModel, field)
+			}
+			var values []string
+			for _, quoted := range mpQuotedPattern.FindAllStringSubmatch(rest[1:end], -1) {
+				values = append(values, quoted[1])
+			}
+			mpc.cells[field] = values
+
+		default:
+			end := strings.IndexAny(rest, ";\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			mpc.scalars[field] = strings.TrimSpace(rest[:end])
+		}
+	}
+
+	return mpc, nil
+}
+
+// parseMATLABMatrix parses rows separated by semicolons or newlines
+func parseMATLABMatrix(body string) ([][]float64, error) {
+	var matrix [][]float64
+	for _, line := range strings.FieldsFunc(body, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' })
+		if len(fields) == 0 {
+			continue
+		}
+
+		row := make([]float64, len(fields))
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid number %q", len(matrix)+1, field)
+			}
+			row[i] = value
+		}
+		matrix = append(matrix, row)
+	}
+	return matrix, nil
+}
+
+// stripMATLABComment removes a trailing % comment outside of quoted strings
+func stripMATLABComment(line string) string {
+	quoted := false
+	for i, r := range line {
+		switch r {
+		case '\'':
+			quoted = !quoted
+		case '%':
+			if !quoted {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
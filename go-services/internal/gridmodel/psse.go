@@ -0,0 +1,346 @@
+package gridmodel
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"voltedge/go-services/internal/orchestration"
+)
+
+// PSS/E RAW sections in file order (revisions 32 and 33)
+const (
+	psseBus = iota
+	psseLoad
+	psseFixedShunt
+	psseGenerator
+	psseBranch
+	psseTransformer
+)
+
+var psseSectionNames = []string{
+	"bus", "load", "fixed shunt", "generator", "branch", "transformer", "area",
+	"two-terminal dc", "vsc dc", "impedance correction", "multi-terminal dc",
+	"multi-section line", "zone", "inter-area transfer", "owner", "facts",
+	"switched shunt", "gne", "induction machine",
+}
+
+// ImportPSSE converts a PSS/E RAW file (revision 32 or 33) into a simulation config
+func ImportPSSE(data []byte) (*ImportResult, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) < 3 {
+		return nil, fmt.Errorf("%w: RAW file is missing its case identification", ErrInvalidModel)
+	}
+
+	header := splitPSSERecord(lines[0])
+	if len(header) < 2 {
+		return nil, fmt.Errorf("%w: invalid case identification record", ErrInvalidModel)
+	}
+	baseMVA := psseFloat(header, 1)
+	if baseMVA <= 0 {
+		baseMVA = 100
+	}
+
+	result := &ImportResult{
+		Report: ImportReport{
+			Format:   FormatPSSE,
+			CaseName: strings.TrimSpace(lines[1]),
+			BaseMVA:  baseMVA,
+		},
+	}
+	config := &result.Config
+	unmapped := newUnmappedTracker()
+
+	if revision := int(psseFloat(header, 2)); revision != 0 && revision != 32 && revision != 33 {
+		result.Report.Warnings = append(result.Report.Warnings, fmt.Sprintf("RAW revision %d is read with the revision 33 layout", revision))
+	}
+	if frequency := psseFloat(header, 5); frequency > 0 {
+		config.BaseFrequency = frequency
+	}
+
+	sections := make([][][]string, len(psseSectionNames))
+	section := psseBus
+	for _, line := range lines[3:] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "Q") {
+			break
+		}
+		record := splitPSSERecord(trimmed)
+		if len(record) == 0 {
+			continue
+		}
+		if len(record) == 1 && record[0] == "0" {
+			section++
+			if section >= len(psseSectionNames) {
+				break
+			}
+			continue
+		}
+		sections[section] = append(sections[section], record)
+	}
+
+	busKV := make(map[int]float64)
+	for _, record := range sections[psseBus] {
+		number := psseInt(record, 0)
+		if _, dup := busKV[number]; dup {
+			return nil, fmt.Errorf("%w: duplicate bus number %d", ErrInvalidModel, number)
+		}
+
+		kv := psseFloat(record, 2)
+		if kv <= 0 {
+			kv = defaultBusVoltageKV
+			result.Report.Warnings = append(result.Report.Warnings, fmt.Sprintf("bus %d has no base kV; assumed %d kV", number, defaultBusVoltageKV))
+		}
+		busKV[number] = kv
+
+		name := psseString(record, 1)
+		if name == "" {
+			name = fmt.Sprintf("Bus %d", number)
+		}
+		config.Buses = append(config.Buses, orchestration.BusConfig{
+			ID:        busID(number),
+			Name:      name,
+			Type:      orchestration.BusTypeBus,
+			VoltageKV: kv,
+		})
+
+		unmapped.note("bus.VA", psseFloat(record, 8), "voltage angles are solved by the engine")
+		if psseInt(record, 3) == 4 {
+			unmapped.add("bus.IDE", "isolated buses are imported as regular buses")
+		}
+	}
+	if len(config.Buses) == 0 {
+		return nil, fmt.Errorf("%w: RAW file has no bus data", ErrInvalidModel)
+	}
+
+	var totalLoad float64
+	for _, record := range sections[psseLoad] {
+		if psseInt(record, 2) == 0 {
+			continue
+		}
+		totalLoad += psseFloat(record, 5)
+		unmapped.note("load.QL", psseFloat(record, 6), "reactive load is not modelled")
+		unmapped.note("load.IP", psseFloat(record, 7), "constant current loads are not modelled")
+		unmapped.note("load.YP", psseFloat(record, 9), "constant admittance loads are not modelled")
+	}
+
+	for i, record := range sections[psseGenerator] {
+		number := psseInt(record, 0)
+		if _, ok := busKV[number]; !ok {
+			return nil, fmt.Errorf("%w: generator %d references unknown bus %d", ErrInvalidModel, i+1, number)
+		}
+		id := psseString(record, 1)
+
+		config.PowerPlants = append(config.PowerPlants, orchestration.PowerPlantConfig{
+			ID:              fmt.Sprintf("gen_%d_%s", number, id),
+			Name:            fmt.Sprintf("Generator %s at bus %d", id, number),
+			Type:            defaultPlantType,
+			MaxCapacityMW:   psseFloat(record, 16),
+			CurrentOutputMW: psseFloat(record, 2),
+			BusID:           busID(number),
+			IsOperational:   psseInt(record, 14) > 0,
+		})
+
+		unmapped.note("generator.QG", psseFloat(record, 3), "reactive power is not modelled")
+		unmapped.note("generator.PB", psseFloat(record, 17), "minimum output is not modelled")
+	}
+	if len(config.PowerPlants) > 0 {
+		result.Report.Warnings = append(result.Report.Warnings, fmt.Sprintf("RAW files carry no fuel types; generators imported as %s plants", defaultPlantType))
+	}
+
+	for i, record := range sections[psseBranch] {
+		from, to := psseInt(record, 0), absInt(psseInt(record, 1))
+		kv, ok := busKV[from]
+		if !ok {
+			return nil, fmt.Errorf("%w: branch %d references unknown bus %d", ErrInvalidModel, i+1, from)
+		}
+		if _, ok := busKV[to]; !ok {
+			return nil, fmt.Errorf("%w: branch %d references unknown bus %d", ErrInvalidModel, i+1, to)
+		}
+
+		line := psseLine(fmt.Sprintf("branch_%d_%d_%s", from, to, psseString(record, 2)), from, to,
+			psseFloat(record, 3), psseFloat(record, 4), kv, baseMVA, psseFloat(record, 6), unmapped)
+		line.IsOperational = psseInt(record, 13) > 0
+		if length := psseFloat(record, 15); length > 0 {
+			line.ResistancePerKM /= length
+			line.ReactancePerKM /= length
+			line.LengthKM = length
+		}
+		config.TransmissionLines = append(config.TransmissionLines, line)
+
+		unmapped.note("branch.B", psseFloat(record, 5), "line charging is not modelled")
+		unmapped.note("branch.RATEB", psseFloat(record, 7), "only the normal (RATEA) rating is used")
+	}
+
+	if err := importPSSETransformers(sections[psseTransformer], busKV, baseMVA, config, unmapped); err != nil {
+		return nil, err
+	}
+
+	if rows := len(sections[psseFixedShunt]); rows > 0 {
+		result.Report.Unmapped = append(result.Report.Unmapped, Unmapped{Field: "fixed shunt", Rows: rows, Reason: "shunts are not modelled"})
+	}
+	for section := psseTransformer + 1; section < len(psseSectionNames); section++ {
+		if rows := len(sections[section]); rows > 0 {
+			result.Report.Unmapped = append(result.Report.Unmapped, Unmapped{Field: psseSectionNames[section], Rows: rows, Reason: "section is not supported"})
+		}
+	}
+
+	finish(config, &result.Report, totalLoad)
+	result.Report.Unmapped = append(unmapped.list(), result.Report.Unmapped...)
+
+	return result, nil
+}
+
+// importPSSETransformers converts transformer records into lines. Two-winding
+// transformers span four records and three-winding transformers five.
+func importPSSETransformers(records [][]string, busKV map[int]float64, baseMVA float64, config *orchestration.SimulationConfig, unmapped *unmappedTracker) error {
+	for i := 0; i < len(records); {
+		first := records[i]
+		from, to, third := psseInt(first, 0), psseInt(first, 1), psseInt(first, 2)
+
+		size := 4
+		if third != 0 {
+			size = 5
+		}
+		if i+size > len(records) {
+			return fmt.Errorf("%w: truncated transformer record for buses %d-%d", ErrInvalidModel, from, to)
+		}
+		impedance, winding := records[i+1], records[i+2]
+		i += size
+
+		for _, bus := range []int{from, to, third} {
+			if _, ok := busKV[bus]; !ok && bus != 0 {
+				return fmt.Errorf("%w: transformer references unknown bus %d", ErrInvalidModel, bus)
+			}
+		}
+
+		// CZ 2 gives impedances on the winding base; convert them to the system base
+		scale := 1.0
+		switch psseInt(first, 5) {
+		case 2:
+			if windingBase := psseFloat(impedance, 2); windingBase > 0 {
+				scale = baseMVA / windingBase
+			}
+		case 3:
+			unmapped.add("transformer.CZ", "load-loss impedance data is approximated as per unit on the system base")
+		}
+
+		circuit := psseString(first, 3)
+		rating := psseFloat(winding, 3)
+		operational := psseInt(first, 11) != 0
+		kv := busKV[from]
+
+		pairs := [][2]int{{from, to}}
+		impedances := [][2]float64{{psseFloat(impedance, 0), psseFloat(impedance, 1)}}
+		if third != 0 {
+			pairs = append(pairs, [2]int{to, third}, [2]int{third, from})
+			impedances = append(impedances,
+				[2]float64{psseFloat(impedance, 3), psseFloat(impedance, 4)},
+				[2]float64{psseFloat(impedance, 6), psseFloat(impedance, 7)},
+			)
+			unmapped.add("transformer.K", "three-winding transformers are imported as a delta of lines")
+		}
+
+		for p, pair := range pairs {
+			line := psseLine(fmt.Sprintf("xfmr_%d_%d_%s", pair[0], pair[1], circuit), pair[0], pair[1],
+				impedances[p][0]*scale, impedances[p][1]*scale, kv, baseMVA, rating, unmapped)
+			line.IsOperational = operational
+			config.TransmissionLines = append(config.TransmissionLines, line)
+		}
+
+		unmapped.add("transformer.WINDV", "transformer ratios and phase shifts are not modelled")
+	}
+	return nil
+}
+
+// psseLine builds a 1 km line from per-unit impedances on the system base
+func psseLine(id string, from, to int, r, x, kv, baseMVA, rating float64, unmapped *unmappedTracker) orchestration.TransmissionLineConfig {
+	if rating <= 0 {
+		rating = unlimitedRatingMW
+		unmapped.add("RATEA", fmt.Sprintf("unlimited ratings are imported as %d MW", unlimitedRatingMW))
+	}
+
+	zBase := kv * kv / baseMVA
+	return orchestration.TransmissionLineConfig{
+		ID:              id,
+		FromNode:        busID(from),
+		ToNode:          busID(to),
+		CapacityMW:      rating,
+		LengthKM:        1,
+		ResistancePerKM: r * zBase,
+		ReactancePerKM:  x * zBase,
+	}
+}
+
+// splitPSSERecord splits a record on commas or whitespace, honouring single
+// quotes and dropping a trailing / comment
+func splitPSSERecord(line string) []string {
+	var fields []string
+	var current strings.Builder
+	quoted, pending := false, false
+
+	flush := func() {
+		if pending || current.Len() > 0 {
+			fields = append(fields, current.String())
+		}
+		current.Reset()
+		pending = false
+	}
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '\'':
+			quoted = !quoted
+			pending = true
+		case quoted:
+			current.WriteByte(ch)
+		case ch == '/':
+			flush()
+			return fields
+		case ch == ',':
+			flush()
+			pending = true
+		case ch == ' ' || ch == '\t':
+			if current.Len() > 0 {
+				flush()
+			}
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	if current.Len() > 0 {
+		flush()
+	}
+	return fields
+}
+
+func psseString(record []string, index int) string {
+	if index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+func psseFloat(record []string, index int) float64 {
+	value, err := strconv.ParseFloat(psseString(record, index), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+func psseInt(record []string, index int) int {
+	return int(math.Round(psseFloat(record, index)))
+}
+
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -2,16 +2,19 @@ package grpc
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/ids"
 )
 
 // Client represents a gRPC client for communicating with Zig simulation engine
 type Client struct {
 	endpoint string
 	timeout  time.Duration
+	// Optional; fails calls before they are sent, see SetFaultInjector
+	faults func(method string) error
 	// TODO: Add actual gRPC client connection
 }
 
@@ -36,6 +39,21 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// SetFaultInjector sets a function consulted before every call to the
+// engine; a call fails with its error instead of being sent. Chaos
+// experiments use it to drop calls.
+func (c *Client) SetFaultInjector(faults func(method string) error) {
+	c.faults = faults
+}
+
+// injectFault returns the error a call to method should fail with, if any
+func (c *Client) injectFault(method string) error {
+	if c.faults == nil {
+		return nil
+	}
+	return c.faults(method)
+}
+
 // Health represents the health status of a service
 type HealthStatus struct {
 	IsHealthy bool      `json:"is_healthy"`
@@ -54,8 +72,28 @@ func (c *Client) Health() HealthStatus {
 
 // SimulationRequest represents a request to create a simulation
 type SimulationRequest struct {
-	Name   string `json:"name"`
-	Config string `json:"config"`
+	Name         string            `json:"name"`
+	Config       string            `json:"config"`
+	StorageUnits []StorageUnitSpec `json:"storage_units,omitempty"`
+	RandomSeed   uint64            `json:"random_seed,omitempty"`
+}
+
+// StorageUnitSpec describes a storage unit sent to the engine
+type StorageUnitSpec struct {
+	ID                  string  `json:"id"`
+	CapacityMWh         float64 `json:"capacity_mwh"`
+	MaxChargeMW         float64 `json:"max_charge_mw"`
+	MaxDischargeMW      float64 `json:"max_discharge_mw"`
+	RoundTripEfficiency float64 `json:"round_trip_efficiency"`
+	StateOfCharge       float64 `json:"state_of_charge"`
+}
+
+// StorageUnitState represents the runtime state of a storage unit reported by the engine
+type StorageUnitState struct {
+	ID              string  `json:"id"`
+	StateOfCharge   float64 `json:"state_of_charge"`
+	StoredEnergyMWh float64 `json:"stored_energy_mwh"`
+	PowerMW         float64 `json:"power_mw"`
 }
 
 // SimulationResponse represents a response from creating a simulation
@@ -67,14 +105,19 @@ type SimulationResponse struct {
 // CreateSimulation creates a new simulation via gRPC
 func (c *Client) CreateSimulation(ctx context.Context, req *SimulationRequest) (*SimulationResponse, error) {
 	logrus.WithFields(logrus.Fields{
-		"name":   req.Name,
-		"config": req.Config,
+		"name":          req.Name,
+		"config":        req.Config,
+		"storage_units": len(req.StorageUnits),
 	}).Info("Creating simulation via gRPC")
+
+	if err := c.injectFault("CreateSimulation"); err != nil {
+		return nil, err
+	}
 	
 	// TODO: Implement actual gRPC call to Zig engine
 	// For now, return a mock response
 	response := &SimulationResponse{
-		ID:   fmt.Sprintf("sim_%d", time.Now().UnixNano()),
+		ID:   ids.New().String(),
 		Name: req.Name,
 	}
 	
@@ -84,6 +127,10 @@ func (c *Client) CreateSimulation(ctx context.Context, req *SimulationRequest) (
 // StartSimulation starts a simulation via gRPC
 func (c *Client) StartSimulation(ctx context.Context, simulationID string) error {
 	logrus.WithField("simulation_id", simulationID).Info("Starting simulation via gRPC")
+
+	if err := c.injectFault("StartSimulation"); err != nil {
+		return err
+	}
 	
 	// TODO: Implement actual gRPC call to Zig engine
 	return nil
@@ -92,6 +139,10 @@ func (c *Client) StartSimulation(ctx context.Context, simulationID string) error
 // StopSimulation stops a simulation via gRPC
 func (c *Client) StopSimulation(ctx context.Context, simulationID string) error {
 	logrus.WithField("simulation_id", simulationID).Info("Stopping simulation via gRPC")
+
+	if err := c.injectFault("StopSimulation"); err != nil {
+		return err
+	}
 	
 	// TODO: Implement actual gRPC call to Zig engine
 	return nil
@@ -100,20 +151,108 @@ func (c *Client) StopSimulation(ctx context.Context, simulationID string) error
 // GetSimulationState gets the current state of a simulation via gRPC
 func (c *Client) GetSimulationState(ctx context.Context, simulationID string) (map[string]interface{}, error) {
 	logrus.WithField("simulation_id", simulationID).Info("Getting simulation state via gRPC")
+
+	if err := c.injectFault("GetSimulationState"); err != nil {
+		return nil, err
+	}
 	
 	// TODO: Implement actual gRPC call to Zig engine
+	return c.simulationState(simulationID), nil
+}
+
+// simulationState returns the state of a simulation
+func (c *Client) simulationState(simulationID string) map[string]interface{} {
 	// For now, return mock data
-	state := map[string]interface{}{
+	return map[string]interface{}{
 		"id":                simulationID,
 		"total_generation":  550.0,
 		"total_consumption": 400.0,
 		"frequency":         50.0,
 		"voltage_levels":    []float64{230.0, 229.5, 230.2},
 		"active_failures":   []int{},
+		"storage_units":     []StorageUnitState{},
 		"timestamp":         time.Now().Unix(),
 	}
-	
-	return state, nil
+}
+
+// SetSimulationSpeed sets the real-time factor of a running simulation via gRPC.
+// A speed of 0 runs ticks back to back.
+func (c *Client) SetSimulationSpeed(ctx context.Context, simulationID string, speed float64) error {
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulationID,
+		"speed":         speed,
+	}).Info("Setting simulation speed via gRPC")
+
+	if err := c.injectFault("SetSimulationSpeed"); err != nil {
+		return err
+	}
+
+	// TODO: Implement actual gRPC call to Zig engine
+	return nil
+}
+
+// StepSimulation advances a paused simulation by the given number of ticks via
+// gRPC and returns its state afterwards
+func (c *Client) StepSimulation(ctx context.Context, simulationID string, ticks int) (map[string]interface{}, error) {
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulationID,
+		"ticks":         ticks,
+	}).Info("Stepping simulation via gRPC")
+
+	if err := c.injectFault("StepSimulation"); err != nil {
+		return nil, err
+	}
+
+	// TODO: Implement actual gRPC call to Zig engine
+	return c.simulationState(simulationID), nil
+}
+
+// DumpSimulationState gets the internal state of every component of a
+// simulation via gRPC
+func (c *Client) DumpSimulationState(ctx context.Context, simulationID string) (map[string]interface{}, error) {
+	logrus.WithField("simulation_id", simulationID).Info("Dumping simulation state via gRPC")
+
+	if err := c.injectFault("DumpSimulationState"); err != nil {
+		return nil, err
+	}
+
+	// TODO: Implement actual gRPC call to Zig engine
+	// For now, return the summary state
+	return c.simulationState(simulationID), nil
+}
+
+// UpdateSimulationConfig replaces the JSON config of a running or paused
+// simulation via gRPC. The engine adds, removes and changes components to
+// match it without restarting the run.
+func (c *Client) UpdateSimulationConfig(ctx context.Context, simulationID string, config string) error {
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulationID,
+		"config_bytes":  len(config),
+	}).Info("Updating simulation config via gRPC")
+
+	if err := c.injectFault("UpdateSimulationConfig"); err != nil {
+		return err
+	}
+
+	// TODO: Implement actual gRPC call to Zig engine
+	return nil
+}
+
+// ModifySimulationTopology adds and removes power plants and transmission
+// lines of a running or paused simulation via gRPC. The change is JSON
+// encoded; components are added after the removed ones are taken out.
+func (c *Client) ModifySimulationTopology(ctx context.Context, simulationID string, change string) error {
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulationID,
+		"change_bytes":  len(change),
+	}).Info("Modifying simulation topology via gRPC")
+
+	if err := c.injectFault("ModifySimulationTopology"); err != nil {
+		return err
+	}
+
+	// TODO: Implement actual gRPC call to Zig engine
+	return nil
 }
 
 // InjectFailure injects a failure into a simulation via gRPC
@@ -123,6 +262,10 @@ func (c *Client) InjectFailure(ctx context.Context, simulationID string, compone
 		"component_id":  componentID,
 		"failure_type":  failureType,
 	}).Info("Injecting failure via gRPC")
+
+	if err := c.injectFault("InjectFailure"); err != nil {
+		return err
+	}
 	
 	// TODO: Implement actual gRPC call to Zig engine
 	return nil
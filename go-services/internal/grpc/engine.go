@@ -0,0 +1,174 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/observability"
+)
+
+// statePollInterval is how often Stream asks the Zig engine for state
+const statePollInterval = time.Second
+
+// Engine runs simulations on the Zig engine through a gRPC client
+type Engine struct {
+	client     *Client
+	descriptor engine.Descriptor
+}
+
+// NewEngine wraps a client as the engine described by descriptor
+func NewEngine(client *Client, descriptor engine.Descriptor) *Engine {
+	return &Engine{
+		client:     client,
+		descriptor: descriptor,
+	}
+}
+
+// Descriptor describes the Zig engine
+func (e *Engine) Descriptor() engine.Descriptor {
+	return e.descriptor
+}
+
+// Create sends the simulation config and its storage units to the engine
+func (e *Engine) Create(ctx context.Context, spec engine.Spec) (string, error) {
+	var config struct {
+		StorageUnits []StorageUnitSpec `json:"storage_units"`
+	}
+	if len(spec.Config) > 0 {
+		if err := json.Unmarshal(spec.Config, &config); err != nil {
+			return "", fmt.Errorf("%w: %v", engine.ErrUnsupportedConfig, err)
+		}
+	}
+
+	response, err := e.client.CreateSimulation(ctx, &SimulationRequest{
+		Name:         spec.Name,
+		Config:       string(spec.Config),
+		StorageUnits: config.StorageUnits,
+		RandomSeed:   spec.Seed,
+	})
+	if err != nil {
+		return "", failed("CreateSimulation", "", err)
+	}
+
+	if spec.Speed != 1 {
+		if err := e.client.SetSimulationSpeed(ctx, response.ID, spec.Speed); err != nil {
+			return "", failed("SetSimulationSpeed", response.ID, err)
+		}
+	}
+	return response.ID, nil
+}
+
+// Start starts a simulation on the engine
+func (e *Engine) Start(ctx context.Context, id string) error {
+	return failed("StartSimulation", id, e.client.StartSimulation(ctx, id))
+}
+
+// Stop stops a simulation on the engine
+func (e *Engine) Stop(ctx context.Context, id string) error {
+	return failed("StopSimulation", id, e.client.StopSimulation(ctx, id))
+}
+
+// Stream polls the engine for the simulation's state. The channel is closed
+// when a poll fails or ctx is done.
+func (e *Engine) Stream(ctx context.Context, id string) (<-chan engine.State, error) {
+	first, err := e.client.GetSimulationState(ctx, id)
+	if err != nil {
+		return nil, failed("GetSimulationState", id, err)
+	}
+
+	states := make(chan engine.State)
+	go func() {
+		defer close(states)
+
+		ticker := time.NewTicker(statePollInterval)
+		defer ticker.Stop()
+
+		raw := first
+		for tick := int64(0); ; tick++ {
+			select {
+			case states <- stateFromMap(id, tick, raw):
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if raw, err = e.client.GetSimulationState(ctx, id); err != nil {
+				failed("GetSimulationState", id, err)
+				return
+			}
+		}
+	}()
+
+	return states, nil
+}
+
+// Inject injects a component failure into a simulation on the engine
+func (e *Engine) Inject(ctx context.Context, id string, fault engine.Fault) error {
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+	return failed("InjectFailure", id, e.client.InjectFailure(ctx, id, fault.ComponentID, fault.Type))
+}
+
+// Close closes the client connection
+func (e *Engine) Close() error {
+	return e.client.Close()
+}
+
+// failed reports a failed engine call to error tracking and returns its
+// error. Calls cancelled by their caller are not failures of the engine.
+func failed(method, simulationID string, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+	observability.ReportError(observability.ErrorEvent{
+		Err:    err,
+		Source: "grpc",
+		Tags: map[string]string{
+			"grpc_method":   method,
+			"simulation_id": simulationID,
+		},
+	})
+	return err
+}
+
+// stateFromMap converts the state the engine reports. The engine does not
+// number its states, so polls are counted instead.
+func stateFromMap(id string, tick int64, raw map[string]interface{}) engine.State {
+	state := engine.State{
+		SimulationID:   id,
+		Tick:           tick,
+		Timestamp:      time.Now().UTC(),
+		VoltageLevels:  []float64{},
+		ActiveFailures: []string{},
+	}
+
+	state.TotalGenerationMW, _ = raw["total_generation"].(float64)
+	state.TotalConsumptionMW, _ = raw["total_consumption"].(float64)
+	state.FrequencyHz, _ = raw["frequency"].(float64)
+	if unix, ok := raw["timestamp"].(int64); ok {
+		state.Timestamp = time.Unix(unix, 0).UTC()
+	}
+	if levels, ok := raw["voltage_levels"].([]float64); ok {
+		state.VoltageLevels = levels
+	}
+	switch failures := raw["active_failures"].(type) {
+	case []string:
+		state.ActiveFailures = failures
+	case []int:
+		for _, failure := range failures {
+			state.ActiveFailures = append(state.ActiveFailures, fmt.Sprint(failure))
+		}
+	}
+
+	return state
+}
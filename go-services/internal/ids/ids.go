@@ -0,0 +1,125 @@
+// Package ids generates the identifiers shared by simulations in the
+// orchestrator and rows in the database
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported ID formats. All of them fit a uuid column, so IDs in one format
+// remain valid after switching to another.
+const (
+	FormatUUIDv4 = "uuidv4"
+	FormatUUIDv7 = "uuidv7"
+	FormatULID   = "ulid"
+)
+
+// Generator creates new IDs
+type Generator interface {
+	New() uuid.UUID
+	// Sortable reports whether IDs sort by creation time
+	Sortable() bool
+}
+
+// ForFormat returns the generator for an ID format; empty means UUIDv7
+func ForFormat(format string) (Generator, error) {
+	switch format {
+	case FormatUUIDv4:
+		return uuidV4{}, nil
+	case "", FormatUUIDv7:
+		return uuidV7{}, nil
+	case FormatULID:
+		return ulid{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported id format: %s", format)
+	}
+}
+
+var defaultGenerator atomic.Value
+
+func init() {
+	defaultGenerator.Store(generatorBox{uuidV7{}})
+}
+
+// generatorBox keeps the stored type the same across generators
+type generatorBox struct {
+	Generator
+}
+
+// SetDefault replaces the generator New uses
+func SetDefault(generator Generator) {
+	defaultGenerator.Store(generatorBox{generator})
+}
+
+// Default returns the generator New uses
+func Default() Generator {
+	return defaultGenerator.Load().(generatorBox).Generator
+}
+
+// New generates an ID with the default generator
+func New() uuid.UUID {
+	return Default().New()
+}
+
+type uuidV4 struct{}
+
+func (uuidV4) New() uuid.UUID { return uuid.New() }
+func (uuidV4) Sortable() bool { return false }
+
+type uuidV7 struct{}
+
+func (uuidV7) New() uuid.UUID {
+	id := timeOrdered()
+	id[6] = (id[6] & 0x0f) | 0x70 // version 7
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return id
+}
+func (uuidV7) Sortable() bool { return true }
+
+type ulid struct{}
+
+func (ulid) New() uuid.UUID { return timeOrdered() }
+func (ulid) Sortable() bool { return true }
+
+// timeOrdered returns 48 bits of millisecond timestamp followed by 80 random
+// bits, which is the binary layout of a ULID
+func timeOrdered() uuid.UUID {
+	var id uuid.UUID
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], ts[2:])
+
+	if _, err := rand.Read(id[6:]); err != nil {
+		// crypto/rand does not fail on supported platforms
+		return uuid.New()
+	}
+
+	return id
+}
+
+// legacySimulationID matches the timestamp IDs simulations were given before
+// they shared the database's IDs
+var legacySimulationID = regexp.MustCompile(`^sim_[0-9]+$`)
+
+// IsLegacySimulationID reports whether id is a pre-UUID "sim_<nanoseconds>"
+// simulation ID. Simulations restored or imported with one keep it.
+func IsLegacySimulationID(id string) bool {
+	return legacySimulationID.MatchString(id)
+}
+
+// ValidSimulationID reports whether id is a UUID or a legacy simulation ID
+func ValidSimulationID(id string) bool {
+	if IsLegacySimulationID(id) {
+		return true
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
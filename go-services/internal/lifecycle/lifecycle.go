@@ -0,0 +1,239 @@
+// Package lifecycle starts the gateway's components in dependency order and
+// stops them in reverse, so nothing is closed while something started after
+// it still uses it.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Component is a part of the process with a start and stop. Every field but
+// Name is optional.
+type Component struct {
+	Name string
+	// Components that must start before this one and stop after it
+	DependsOn []string
+	// Called on start with a context that is cancelled when the component
+	// stops; an error aborts startup
+	OnStart func(ctx context.Context) error
+	// Runs in its own goroutine once the component started, until it returns
+	// or its context is cancelled. Stopping waits for it to return. An error
+	// returned before stopping is reported by Failed.
+	Run func(ctx context.Context) error
+	// Called on stop before the component's context is cancelled
+	OnStop func(ctx context.Context) error
+}
+
+// Background adapts a function that runs until its context is done for use
+// as a component's Run
+func Background(run func(ctx context.Context)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		run(ctx)
+		return nil
+	}
+}
+
+// component is a registered component and its running state
+type component struct {
+	Component
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager starts and stops registered components. Components start in the
+// order they were registered unless a dependency requires otherwise.
+type Manager struct {
+	mu         sync.Mutex
+	components []*component
+	byName     map[string]*component
+	started    []*component
+	stopping   bool
+	failed     chan error
+}
+
+// New creates an empty lifecycle manager
+func New() *Manager {
+	return &Manager{
+		byName: make(map[string]*component),
+		failed: make(chan error, 1),
+	}
+}
+
+// Register adds a component. Components must be registered before Start and
+// names must be unique.
+func (m *Manager) Register(c Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.byName[c.Name]; exists {
+		panic(fmt.Sprintf("lifecycle: component %q registered twice", c.Name))
+	}
+	registered := &component{Component: c}
+	m.components = append(m.components, registered)
+	m.byName[c.Name] = registered
+}
+
+// Start starts every component after its dependencies. Each component's
+// context derives from ctx. When a component fails to start, those already
+// started are stopped again and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	order, err := m.order()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range order {
+		if err := m.start(ctx, c); err != nil {
+			stopCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if stopErr := m.Stop(stopCtx); stopErr != nil {
+				logrus.WithError(stopErr).Error("Failed to stop components after a failed start")
+			}
+			return fmt.Errorf("failed to start %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) start(ctx context.Context, c *component) error {
+	logger := logrus.WithField("component", c.Name)
+	logger.Debug("Starting component")
+
+	componentCtx, cancel := context.WithCancel(ctx)
+	if c.OnStart != nil {
+		if err := c.OnStart(componentCtx); err != nil {
+			cancel()
+			return err
+		}
+	}
+
+	c.cancel = cancel
+	m.mu.Lock()
+	m.started = append(m.started, c)
+	m.mu.Unlock()
+
+	if c.Run != nil {
+		c.done = make(chan struct{})
+		go func() {
+			defer close(c.done)
+			if err := c.Run(componentCtx); err != nil && componentCtx.Err() == nil {
+				m.fail(fmt.Errorf("%s failed: %w", c.Name, err))
+			}
+		}()
+	}
+	return nil
+}
+
+// fail reports the first component that failed while running, unless the
+// manager is already stopping
+func (m *Manager) fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopping {
+		return
+	}
+	select {
+	case m.failed <- err:
+	default:
+	}
+}
+
+// Failed receives the error of the first component whose Run failed
+func (m *Manager) Failed() <-chan error {
+	return m.failed
+}
+
+// Stop stops the started components in the reverse of their start order.
+// Each one's OnStop is called, its context cancelled and its Run waited for,
+// until ctx is done. All components are stopped even when some fail to.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopping = true
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		if err := stop(ctx, started[i]); err != nil {
+			logrus.WithError(err).WithField("component", started[i].Name).Error("Failed to stop component")
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", started[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func stop(ctx context.Context, c *component) error {
+	logrus.WithField("component", c.Name).Debug("Stopping component")
+
+	var err error
+	if c.OnStop != nil {
+		err = c.OnStop(ctx)
+	}
+	c.cancel()
+
+	if c.done != nil {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+	return err
+}
+
+// order returns the components sorted so each follows its dependencies,
+// otherwise keeping registration order
+func (m *Manager) order() ([]*component, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.components {
+		for _, dependency := range c.DependsOn {
+			if _, ok := m.byName[dependency]; !ok {
+				return nil, fmt.Errorf("component %s depends on unknown component %s", c.Name, dependency)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(m.components))
+	order := make([]*component, 0, len(m.components))
+
+	var visit func(c *component) error
+	visit = func(c *component) error {
+		switch state[c.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("component %s is part of a dependency cycle", c.Name)
+		}
+		state[c.Name] = visiting
+		for _, dependency := range c.DependsOn {
+			if err := visit(m.byName[dependency]); err != nil {
+				return err
+			}
+		}
+		state[c.Name] = visited
+		order = append(order, c)
+		return nil
+	}
+
+	for _, c := range m.components {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
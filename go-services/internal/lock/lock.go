@@ -0,0 +1,97 @@
+package lock
+
+import (
+	"context"
+	"sync"
+)
+
+// Locker hands out named leases. With a shared backend such as Postgres
+// advisory locks, at most one replica holds a given name at a time.
+type Locker interface {
+	// TryAcquire takes the named lock without waiting, reporting false when
+	// someone else holds it
+	TryAcquire(ctx context.Context, name string) (Lease, bool, error)
+}
+
+// Lease is a held lock
+type Lease interface {
+	// Release gives the lock up; releasing twice is a no-op
+	Release() error
+	// Done is closed once the lease is released or lost, e.g. when the
+	// connection backing it drops
+	Done() <-chan struct{}
+}
+
+// RunExclusive runs fn while holding the named lock, reporting false without
+// running it when the lock is held elsewhere. The context passed to fn is
+// cancelled if the lease is lost.
+func RunExclusive(ctx context.Context, locker Locker, name string, fn func(ctx context.Context)) (bool, error) {
+	lease, ok, err := locker.TryAcquire(ctx, name)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer lease.Release()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lease.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fn(ctx)
+	return true, nil
+}
+
+// Local is an in-process Locker for single-instance deployments
+type Local struct {
+	mu   sync.Mutex
+	held map[string]*localLease
+}
+
+// NewLocal creates a new in-process locker
+func NewLocal() *Local {
+	return &Local{held: make(map[string]*localLease)}
+}
+
+// TryAcquire takes the named lock if no other lease in this process holds it
+func (l *Local) TryAcquire(ctx context.Context, name string) (Lease, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, held := l.held[name]; held {
+		return nil, false, nil
+	}
+
+	lease := &localLease{locker: l, name: name, done: make(chan struct{})}
+	l.held[name] = lease
+	return lease, true, nil
+}
+
+type localLease struct {
+	locker *Local
+	name   string
+	once   sync.Once
+	done   chan struct{}
+}
+
+func (l *localLease) Release() error {
+	l.once.Do(func() {
+		l.locker.mu.Lock()
+		delete(l.locker.held, l.name)
+		l.locker.mu.Unlock()
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *localLease) Done() <-chan struct{} {
+	return l.done
+}
@@ -0,0 +1,197 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Subsystems whose log level can be set apart from the rest of the gateway
+const (
+	ModuleAPI           = "api"
+	ModuleOrchestration = "orchestration"
+	ModuleDatabase      = "database"
+	ModuleGRPC          = "grpc"
+)
+
+// Modules lists the subsystems with their own log level
+var Modules = []string{ModuleAPI, ModuleOrchestration, ModuleDatabase, ModuleGRPC}
+
+// modulePrefix is the import path prefix of the subsystems' packages
+const modulePrefix = "voltedge/go-services/internal/"
+
+// ErrInvalidLevel is returned for an unknown level or subsystem
+var ErrInvalidLevel = errors.New("invalid log level")
+
+// Levels is the gateway's log level and the subsystems logging at another
+type Levels struct {
+	Level string `json:"level"`
+	// Subsystem levels overriding Level
+	Modules map[string]string `json:"modules"`
+}
+
+// levels holds the levels applied to the configured loggers
+var levels = struct {
+	sync.RWMutex
+	base    logrus.Level
+	modules map[string]logrus.Level
+	loggers []*logrus.Logger
+}{
+	base:    logrus.InfoLevel,
+	modules: make(map[string]logrus.Level),
+}
+
+// CurrentLevels returns the log levels in effect
+func CurrentLevels() Levels {
+	levels.RLock()
+	defer levels.RUnlock()
+
+	current := Levels{Level: levels.base.String(), Modules: make(map[string]string, len(levels.modules))}
+	for module, level := range levels.modules {
+		current.Modules[module] = level.String()
+	}
+	return current
+}
+
+// SetLevels changes the log levels at runtime. An empty level keeps the
+// current one; a subsystem set to an empty level follows the gateway's again.
+func SetLevels(change Levels) (Levels, error) {
+	base := logrus.Level(0)
+	if change.Level != "" {
+		parsed, err := logrus.ParseLevel(change.Level)
+		if err != nil {
+			return Levels{}, fmt.Errorf("%w: %q", ErrInvalidLevel, change.Level)
+		}
+		base = parsed
+	}
+	modules := make(map[string]*logrus.Level, len(change.Modules))
+	for module, level := range change.Modules {
+		if !knownModule(module) {
+			return Levels{}, fmt.Errorf("%w: unknown subsystem %q, expected one of %s", ErrInvalidLevel, module, strings.Join(Modules, ", "))
+		}
+		if level == "" {
+			modules[module] = nil
+			continue
+		}
+		parsed, err := logrus.ParseLevel(level)
+		if err != nil {
+			return Levels{}, fmt.Errorf("%w: %q", ErrInvalidLevel, level)
+		}
+		modules[module] = &parsed
+	}
+
+	levels.Lock()
+	if change.Level != "" {
+		levels.base = base
+	}
+	for module, level := range modules {
+		if level == nil {
+			delete(levels.modules, module)
+		} else {
+			levels.modules[module] = *level
+		}
+	}
+	applyLevels()
+	levels.Unlock()
+
+	logrus.WithField("levels", CurrentLevels()).Info("Log levels changed")
+	return CurrentLevels(), nil
+}
+
+// setBaseLevel sets the level of the loggers being configured, dropping any
+// subsystem levels
+func setBaseLevel(level logrus.Level, loggers []*logrus.Logger) {
+	levels.Lock()
+	defer levels.Unlock()
+
+	levels.base = level
+	levels.modules = make(map[string]logrus.Level)
+	levels.loggers = loggers
+	applyLevels()
+}
+
+// applyLevels lets the loggers through at the most verbose level in use; the
+// formatter drops entries below their subsystem's level. Called with the
+// levels locked.
+func applyLevels() {
+	verbose := levels.base
+	for _, level := range levels.modules {
+		if level > verbose {
+			verbose = level
+		}
+	}
+	for _, logger := range levels.loggers {
+		logger.SetLevel(verbose)
+	}
+}
+
+// enabled reports whether an entry is at or above its subsystem's level
+func enabled(entry *logrus.Entry) bool {
+	levels.RLock()
+	defer levels.RUnlock()
+
+	if len(levels.modules) == 0 {
+		return entry.Level <= levels.base
+	}
+	level, ok := levels.modules[callerModule()]
+	if !ok {
+		level = levels.base
+	}
+	return entry.Level <= level
+}
+
+// callerModule returns the subsystem of the code that logged, found as the
+// first caller outside logrus and this package
+func callerModule() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		function := frame.Function
+		if !strings.HasPrefix(function, "github.com/sirupsen/logrus") && !strings.HasPrefix(function, modulePrefix+"logging.") {
+			module, _, _ := strings.Cut(strings.TrimPrefix(function, modulePrefix), ".")
+			module, _, _ = strings.Cut(module, "/")
+			return module
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func knownModule(module string) bool {
+	for _, known := range Modules {
+		if module == known {
+			return true
+		}
+	}
+	return false
+}
+
+// levelFormatter formats only the entries enabled for their subsystem
+type levelFormatter struct {
+	logrus.Formatter
+}
+
+func (f levelFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !enabled(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// levelHook fires only for the entries enabled for their subsystem
+type levelHook struct {
+	logrus.Hook
+}
+
+func (h levelHook) Fire(entry *logrus.Entry) error {
+	if !enabled(entry) {
+		return nil
+	}
+	return h.Hook.Fire(entry)
+}
@@ -0,0 +1,117 @@
+// Package logging sends the gateway's logs to the output and in the format
+// chosen by the log configuration.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logrussyslog "github.com/sirupsen/logrus/hooks/syslog"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"voltedge/go-services/internal/config"
+)
+
+// Log outputs other than a file path
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	// The local syslog daemon; syslog://host:port sends to a remote one over UDP
+	OutputSyslog = "syslog"
+)
+
+// syslogTag names the gateway in syslog messages
+const syslogTag = "voltedge-api"
+
+// Configure applies the configured level, format and output to loggers. They
+// share one output, which the returned closer closes, and the levels changed
+// by SetLevels.
+func Configure(cfg config.LogConfig, loggers ...*logrus.Logger) (io.Closer, error) {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level: %w", err)
+	}
+	formatter, err := newFormatter(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out    io.Writer
+		closer io.Closer = nopCloser{}
+		hook   logrus.Hook
+	)
+	switch {
+	case cfg.Output == "" || cfg.Output == OutputStdout:
+		out = os.Stdout
+	case cfg.Output == OutputStderr:
+		out = os.Stderr
+	case cfg.Output == OutputSyslog || strings.HasPrefix(cfg.Output, OutputSyslog+"://"):
+		syslogHook, err := newSyslogHook(cfg.Output)
+		if err != nil {
+			return nil, err
+		}
+		// The hook writes every entry, with its level as the syslog severity
+		out, hook, closer = io.Discard, syslogHook, syslogHook.Writer
+	default:
+		file := &lumberjack.Logger{
+			Filename:   cfg.Output,
+			MaxSize:    cfg.MaxSize,
+			MaxAge:     cfg.MaxAge,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+		}
+		out, closer = file, file
+	}
+
+	for _, logger := range loggers {
+		logger.SetFormatter(levelFormatter{formatter})
+		logger.SetOutput(out)
+		if hook != nil {
+			logger.AddHook(levelHook{hook})
+		}
+	}
+	setBaseLevel(level, loggers)
+	return closer, nil
+}
+
+// newFormatter returns the formatter for json or text logs
+func newFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", "json":
+		return &logrus.JSONFormatter{TimestampFormat: time.RFC3339}, nil
+	case "text":
+		return &logrus.TextFormatter{FullTimestamp: true, TimestampFormat: time.RFC3339}, nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", format)
+	}
+}
+
+// newSyslogHook connects to the local syslog daemon, or the one at a
+// syslog://host:port output
+func newSyslogHook(output string) (*logrussyslog.SyslogHook, error) {
+	network, address := "", ""
+	if output != OutputSyslog {
+		target, err := url.Parse(output)
+		if err != nil || target.Host == "" {
+			return nil, fmt.Errorf("invalid syslog output %q: expected syslog://host:port", output)
+		}
+		network, address = "udp", target.Host
+	}
+
+	hook, err := logrussyslog.NewSyslogHook(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, syslogTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return hook, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
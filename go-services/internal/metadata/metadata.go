@@ -0,0 +1,181 @@
+// Package metadata validates the free-form metadata stored with simulations.
+// The API checks it when binding requests and the orchestrator again before
+// storing it, so no caller can store metadata past the configured limits.
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"voltedge/go-services/internal/config"
+)
+
+// Keys the gateway writes itself
+const (
+	// The simulation a rerun was created from
+	KeyRerunOf = "rerun_of"
+	// Where a what-if simulation's recorded load came from
+	KeyLoadProvenance = "load_provenance"
+	// The format and case of an imported grid model
+	KeyImportFormat = "import_format"
+	KeyCaseName     = "case_name"
+)
+
+// systemKeys are reserved whatever prefixes are configured
+var systemKeys = map[string]bool{
+	KeyRerunOf:        true,
+	KeyLoadProvenance: true,
+	KeyImportFormat:   true,
+	KeyCaseName:       true,
+}
+
+// keyPattern is what keys may look like: letters, digits and _ . : -, not
+// starting with punctuation other than _
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.:-]*$`)
+
+// ErrInvalid is wrapped by every error metadata validation returns
+var ErrInvalid = errors.New("invalid metadata")
+
+// LimitError describes metadata that violates a configured limit
+type LimitError struct {
+	Path   string
+	Limit  string
+	Max    int
+	Actual int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s exceeds the maximum %s of %d (got %d)", e.Path, e.Limit, e.Max, e.Actual)
+}
+
+func (e *LimitError) Unwrap() error { return ErrInvalid }
+
+// KeyError describes a key that is malformed or reserved
+type KeyError struct {
+	Path   string
+	Key    string
+	Reason string
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s key %q %s", e.Path, e.Key, e.Reason)
+}
+
+func (e *KeyError) Unwrap() error { return ErrInvalid }
+
+// Validate checks metadata against the configured size, key-count, depth
+// and key-length limits and checks that every key is well formed. A zero
+// limit disables that check. Reserved keys are allowed; see CheckReserved.
+func Validate(metadata map[string]interface{}, limits config.MetadataLimits) error {
+	if metadata == nil {
+		return nil
+	}
+
+	if limits.MaxBytes > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("%w: not valid JSON: %v", ErrInvalid, err)
+		}
+		if len(encoded) > limits.MaxBytes {
+			return &LimitError{Path: "metadata", Limit: "size in bytes", Max: limits.MaxBytes, Actual: len(encoded)}
+		}
+	}
+
+	keys := 0
+	if err := walk("metadata", metadata, 1, &keys, limits); err != nil {
+		return err
+	}
+	if limits.MaxKeys > 0 && keys > limits.MaxKeys {
+		return &LimitError{Path: "metadata", Limit: "key count", Max: limits.MaxKeys, Actual: keys}
+	}
+
+	return nil
+}
+
+// CheckReserved rejects top-level keys reserved for the gateway's own
+// metadata
+func CheckReserved(metadata map[string]interface{}, prefixes []string) error {
+	for _, key := range sortedKeys(metadata) {
+		if Reserved(key, prefixes) {
+			return &KeyError{Path: "metadata", Key: key, Reason: "is reserved for system metadata"}
+		}
+	}
+	return nil
+}
+
+// Reserved reports whether a top-level key is reserved for the gateway's
+// own metadata
+func Reserved(key string, prefixes []string) bool {
+	if systemKeys[key] {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeepReserved returns updated with the reserved keys of current added, so
+// replacing a simulation's metadata keeps what the gateway recorded
+func KeepReserved(updated, current map[string]interface{}, prefixes []string) map[string]interface{} {
+	merged := make(map[string]interface{}, len(updated))
+	for key, value := range updated {
+		merged[key] = value
+	}
+	for key, value := range current {
+		if Reserved(key, prefixes) {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// walk descends into nested objects and arrays counting keys and depth and
+// checking each key
+func walk(path string, value interface{}, depth int, keys *int, limits config.MetadataLimits) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return &LimitError{Path: path, Limit: "depth", Max: limits.MaxDepth, Actual: depth}
+		}
+		*keys += len(v)
+		// Visit keys in order so the reported path is deterministic
+		for _, key := range sortedKeys(v) {
+			if limits.MaxKeyLength > 0 && len(key) > limits.MaxKeyLength {
+				return &LimitError{Path: path + "." + key, Limit: "key length", Max: limits.MaxKeyLength, Actual: len(key)}
+			}
+			if !keyPattern.MatchString(key) {
+				return &KeyError{Path: path, Key: key, Reason: "must contain only letters, digits and _ . : - and not start with . : or -"}
+			}
+			if err := walk(path+"."+key, v[key], depth+1, keys, limits); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+			return &LimitError{Path: path, Limit: "depth", Max: limits.MaxDepth, Actual: depth}
+		}
+		for i, child := range v {
+			if err := walk(fmt.Sprintf("%s[%d]", path, i), child, depth+1, keys, limits); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	names := make([]string, 0, len(m))
+	for key := range m {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,487 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Alert severities from least to most severe. They match PagerDuty's.
+var severities = []string{"info", "warning", "error", "critical"}
+
+// ValidSeverity reports whether a severity is one alert routes filter on
+func ValidSeverity(severity string) bool {
+	return slices.Contains(severities, severity)
+}
+
+// severityRank orders severities; unknown ones rank below info
+func severityRank(severity string) int {
+	return slices.Index(severities, strings.ToLower(severity))
+}
+
+// AlertRouteStore provides alert routes and remembers what they sent
+type AlertRouteStore interface {
+	GetActiveAlertRoutes() ([]database.AlertRoute, error)
+	CountSentNotifications(routeID uuid.UUID, dedupeKey string, since time.Time) (int64, error)
+	RecordNotification(notification *database.AlertNotification) error
+	GetActiveSilences(at time.Time) ([]database.AlertSilence, error)
+	PurgeExpiredSilences(before time.Time) (int64, error)
+}
+
+// SMTPOptions configures the mail server email channels send through
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// AlertRouterOptions configures channel delivery
+type AlertRouterOptions struct {
+	PagerDutyURL string
+	SMTP         SMTPOptions
+	// Expired silences are deleted after this long
+	SilenceRetention time.Duration
+}
+
+// AlertRouter delivers alert.triggered events to the channels of matching routes
+type AlertRouter struct {
+	store  AlertRouteStore
+	client *http.Client
+	opts   AlertRouterOptions
+
+	// Channel owners' and email recipients' notification preferences apply when set
+	preferences PreferenceStore
+}
+
+// NewAlertRouter creates a new alert router
+func NewAlertRouter(store AlertRouteStore, opts AlertRouterOptions) *AlertRouter {
+	return &AlertRouter{
+		store:  store,
+		client: newWebhookClient(10 * time.Second),
+		opts:   opts,
+	}
+}
+
+// routeKey identifies a route in an outbox event's delivered list
+func routeKey(route *database.AlertRoute) string {
+	return "route:" + route.ID.String()
+}
+
+// Route delivers an alert event to every matching route not listed in
+// delivered, returning the keys of the routes it handled. Suppressed
+// notifications count as handled so they are not retried.
+func (r *AlertRouter) Route(ctx context.Context, event Event, delivered []string) ([]string, error) {
+	routes, err := r.store.GetActiveAlertRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert routes: %w", err)
+	}
+
+	silences, err := r.store.GetActiveSilences(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert silences: %w", err)
+	}
+	silence := matchingSilence(silences, event)
+
+	var handled []string
+	var errs []error
+	for i := range routes {
+		route := &routes[i]
+		key := routeKey(route)
+		if slices.Contains(delivered, key) || !RouteMatches(route, event) {
+			continue
+		}
+
+		if err := r.deliver(ctx, route, event, silence); err != nil {
+			errs = append(errs, fmt.Errorf("route %s: %w", route.ID, err))
+			continue
+		}
+		handled = append(handled, key)
+	}
+
+	return handled, errors.Join(errs...)
+}
+
+// RouteMatches reports whether a route wants an alert event
+func RouteMatches(route *database.AlertRoute, event Event) bool {
+	if route.SimulationID != nil && route.SimulationID.String() != event.SimulationID {
+		return false
+	}
+	if len(route.AlertTypes) > 0 && !slices.Contains(route.AlertTypes, alertType(event)) {
+		return false
+	}
+	if route.MinSeverity != "" && severityRank(event.Severity) < severityRank(route.MinSeverity) {
+		return false
+	}
+	return true
+}
+
+// SilenceMatches reports whether a silence covers an alert event. Only the
+// matchers are checked, not the silence's time range.
+func SilenceMatches(silence *database.AlertSilence, event Event) bool {
+	if silence.SimulationID != nil && silence.SimulationID.String() != event.SimulationID {
+		return false
+	}
+	if silence.ComponentType != "" && silence.ComponentType != dataString(event, "component_type") {
+		return false
+	}
+	if silence.ComponentID != nil {
+		id, ok := dataInt(event, "component_id")
+		if !ok || id != *silence.ComponentID {
+			return false
+		}
+	}
+	if len(silence.Severities) > 0 && !slices.Contains(silence.Severities, strings.ToLower(event.Severity)) {
+		return false
+	}
+	if len(silence.AlertTypes) > 0 && !slices.Contains(silence.AlertTypes, alertType(event)) {
+		return false
+	}
+	return true
+}
+
+// matchingSilence returns the first silence covering an event, if any
+func matchingSilence(silences []database.AlertSilence, event Event) *database.AlertSilence {
+	for i := range silences {
+		if SilenceMatches(&silences[i], event) {
+			return &silences[i]
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredSilences deletes silences that expired longer ago than the retention
+func (r *AlertRouter) PurgeExpiredSilences() {
+	if r.opts.SilenceRetention <= 0 {
+		return
+	}
+	if purged, err := r.store.PurgeExpiredSilences(time.Now().Add(-r.opts.SilenceRetention)); err == nil && purged > 0 {
+		logrus.WithField("silences", purged).Info("Purged expired alert silences")
+	}
+}
+
+// deliver applies the silence and the route's dedupe and throttle windows,
+// then notifies its channel
+func (r *AlertRouter) deliver(ctx context.Context, route *database.AlertRoute, event Event, silence *database.AlertSilence) error {
+	notification := &database.AlertNotification{
+		RouteID:   route.ID,
+		ChannelID: route.ChannelID,
+		EventID:   event.ID,
+		DedupeKey: dedupeKey(event),
+	}
+
+	reason := ""
+	if silence != nil {
+		reason = "silenced by " + silence.ID.String()
+	} else {
+		var err error
+		if reason, err = r.suppression(route, notification.DedupeKey); err != nil {
+			return err
+		}
+	}
+
+	if reason != "" {
+		notification.Status = database.NotificationSuppressed
+		notification.Reason = reason
+	} else if status, reason, err := r.notify(ctx, &route.Channel, event); err != nil {
+		notification.Status = database.NotificationFailed
+		notification.Reason = err.Error()
+		r.store.RecordNotification(notification)
+		return err
+	} else {
+		notification.Status = status
+		notification.Reason = reason
+	}
+
+	if err := r.store.RecordNotification(notification); err != nil {
+		// The notification went out, so do not fail the event over it
+		logrus.WithError(err).WithField("route_id", route.ID).Warn("Failed to record alert notification")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"route_id":   route.ID,
+		"channel_id": route.ChannelID,
+		"event_id":   event.ID,
+		"status":     notification.Status,
+	}).Debug("Alert routed")
+
+	return nil
+}
+
+// suppression returns why a notification should not be sent, or "" to send it
+func (r *AlertRouter) suppression(route *database.AlertRoute, dedupeKey string) (string, error) {
+	now := time.Now()
+
+	if route.DedupeWindowSeconds > 0 {
+		window := time.Duration(route.DedupeWindowSeconds) * time.Second
+		sent, err := r.store.CountSentNotifications(route.ID, dedupeKey, now.Add(-window))
+		if err != nil {
+			return "", err
+		}
+		if sent > 0 {
+			return "duplicate within " + window.String(), nil
+		}
+	}
+
+	if route.ThrottleLimit > 0 && route.ThrottleWindowSeconds > 0 {
+		window := time.Duration(route.ThrottleWindowSeconds) * time.Second
+		sent, err := r.store.CountSentNotifications(route.ID, "", now.Add(-window))
+		if err != nil {
+			return "", err
+		}
+		if sent >= int64(route.ThrottleLimit) {
+			return fmt.Sprintf("throttled, %d sent within %s", sent, window), nil
+		}
+	}
+
+	return "", nil
+}
+
+// Send notifies a single channel about an event
+func (r *AlertRouter) Send(ctx context.Context, channel *database.NotificationChannel, event Event) error {
+	switch channel.Type {
+	case database.ChannelSlack:
+		return r.sendSlack(ctx, channel, event)
+	case database.ChannelPagerDuty:
+		return r.sendPagerDuty(ctx, channel, event)
+	case database.ChannelEmail:
+		return r.sendEmail(channel, event)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownChannelType, channel.Type)
+	}
+}
+
+// slackColors colours Slack attachments by severity
+var slackColors = map[string]string{
+	"info":     "#439fe0",
+	"warning":  "#daa038",
+	"error":    "#d00000",
+	"critical": "#7a0000",
+}
+
+func (r *AlertRouter) sendSlack(ctx context.Context, channel *database.NotificationChannel, event Event) error {
+	fields := []map[string]interface{}{
+		{"title": "Severity", "value": event.Severity, "short": true},
+		{"title": "Type", "value": alertType(event), "short": true},
+	}
+	if event.SimulationID != "" {
+		fields = append(fields, map[string]interface{}{"title": "Simulation", "value": event.SimulationID, "short": false})
+	}
+
+	text := summary(event)
+	if event.Type == EventNotificationDigest {
+		text += "\n" + strings.Join(digestLines(event), "\n")
+	}
+
+	payload := map[string]interface{}{
+		"text": text,
+		"attachments": []map[string]interface{}{{
+			"color":  slackColors[strings.ToLower(event.Severity)],
+			"fields": fields,
+			"ts":     event.Timestamp.Unix(),
+		}},
+	}
+
+	if err := CheckDestination(ctx, channel.WebhookURL); err != nil {
+		return err
+	}
+	return r.postJSON(ctx, channel.WebhookURL, payload)
+}
+
+func (r *AlertRouter) sendPagerDuty(ctx context.Context, channel *database.NotificationChannel, event Event) error {
+	severity := strings.ToLower(event.Severity)
+	if !ValidSeverity(severity) {
+		severity = "error"
+	}
+
+	payload := map[string]interface{}{
+		"routing_key":  channel.RoutingKey,
+		"event_action": "trigger",
+		// Repeats of an alert update the open PagerDuty incident
+		"dedup_key": dedupeKey(event),
+		"payload": map[string]interface{}{
+			"summary":        summary(event),
+			"source":         "voltedge",
+			"severity":       severity,
+			"timestamp":      event.Timestamp.UTC().Format(time.RFC3339),
+			"class":          alertType(event),
+			"custom_details": event.Data,
+		},
+	}
+
+	return r.postJSON(ctx, r.opts.PagerDutyURL, payload)
+}
+
+func (r *AlertRouter) sendEmail(channel *database.NotificationChannel, event Event) error {
+	if r.opts.SMTP.Host == "" {
+		return ErrSMTPNotConfigured
+	}
+	if len(channel.Recipients) == 0 {
+		return fmt.Errorf("channel has no recipients")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\r\n\r\n", event.Message)
+	if event.Type == EventNotificationDigest {
+		for _, line := range digestLines(event) {
+			fmt.Fprintf(&body, "%s\r\n", line)
+		}
+		body.WriteString("\r\n")
+	}
+	fmt.Fprintf(&body, "Severity: %s\r\n", event.Severity)
+	fmt.Fprintf(&body, "Type: %s\r\n", alertType(event))
+	if event.SimulationID != "" {
+		fmt.Fprintf(&body, "Simulation: %s\r\n", event.SimulationID)
+	}
+	fmt.Fprintf(&body, "Triggered: %s\r\n", event.Timestamp.UTC().Format(time.RFC3339))
+
+	return r.SendMail(channel.Recipients, summary(event), "text/plain; charset=UTF-8", []byte(body.String()))
+}
+
+// SendMail sends an email through the configured mail server
+func (r *AlertRouter) SendMail(recipients []string, subject, contentType string, body []byte) error {
+	cfg := r.opts.SMTP
+	if cfg.Host == "" {
+		return ErrSMTPNotConfigured
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerSafe(subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n\r\n", contentType)
+	msg.Write(body)
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	// SendMail upgrades to TLS when the server offers STARTTLS
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	if err := smtp.SendMail(addr, auth, cfg.From, recipients, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// postJSON posts a JSON payload and fails on non-2xx responses
+func (r *AlertRouter) postJSON(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AlertEvent builds the alert.triggered event announcing an alert. The alert
+// must already have its ID so the event can be deduplicated by it.
+func AlertEvent(alert *database.Alert) Event {
+	data := map[string]interface{}{
+		"alert_id":   alert.ID.String(),
+		"alert_type": alert.AlertType,
+	}
+	for key, value := range alert.Metadata {
+		if _, reserved := data[key]; !reserved {
+			data[key] = value
+		}
+	}
+
+	return Event{
+		ID:           "alert:" + alert.ID.String(),
+		Type:         EventAlertTriggered,
+		SimulationID: alert.SimulationID.String(),
+		Severity:     alert.Severity,
+		Message:      alert.Message,
+		Timestamp:    alert.TriggeredAt,
+		Data:         data,
+	}
+}
+
+// alertType reads the alert type carried by an alert event
+func alertType(event Event) string {
+	return dataString(event, "alert_type")
+}
+
+// dataString reads a string from an event's data
+func dataString(event Event, key string) string {
+	value, _ := event.Data[key].(string)
+	return value
+}
+
+// dataInt reads an integer from an event's data. Events read back from the
+// outbox carry numbers as float64.
+func dataInt(event Event, key string) (int, bool) {
+	switch value := event.Data[key].(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		return int(value), value == float64(int(value))
+	default:
+		return 0, false
+	}
+}
+
+// dedupeKey groups repeats of the same alert. Alerts may set their own
+// dedupe_key in metadata; otherwise simulation and alert type are used.
+func dedupeKey(event Event) string {
+	if key, ok := event.Data["dedupe_key"].(string); ok && key != "" {
+		return key
+	}
+	return event.SimulationID + ":" + alertType(event)
+}
+
+// summary is the one-line description used as Slack text, PagerDuty summary and email subject
+func summary(event Event) string {
+	text := []rune(fmt.Sprintf("[%s] %s", strings.ToUpper(event.Severity), event.Message))
+	if len(text) > 1024 {
+		text = text[:1024]
+	}
+	return string(text)
+}
+
+// headerSafe strips line breaks so a message cannot inject mail headers
+func headerSafe(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+// Errors
+var (
+	ErrUnknownChannelType = fmt.Errorf("unknown notification channel type")
+	ErrSMTPNotConfigured  = fmt.Errorf("SMTP is not configured")
+)
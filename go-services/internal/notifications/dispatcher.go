@@ -0,0 +1,218 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Event types
+const (
+	EventSimulationCreated   = "simulation.created"
+	EventSimulationStarted   = "simulation.started"
+	EventSimulationStopped   = "simulation.stopped"
+	EventSimulationCompleted = "simulation.completed"
+	EventSimulationFailed    = "simulation.failed"
+	EventTopologyChanged     = "simulation.topology_changed"
+	EventAlertTriggered      = "alert.triggered"
+	// Sent in place of events held back by notification preferences
+	EventNotificationDigest = "notification.digest"
+)
+
+// SubscriptionStore provides the subscriptions an event should be delivered to
+type SubscriptionStore interface {
+	GetActiveSubscriptions(eventType string) ([]database.WebhookSubscription, error)
+}
+
+// Dispatcher renders and delivers events to webhook subscriptions
+type Dispatcher struct {
+	store  SubscriptionStore
+	client *http.Client
+
+	// Published events go through the outbox when set
+	outbox     OutboxStore
+	outboxOpts OutboxOptions
+
+	// Alert events are also routed to notification channels when set
+	alerts *AlertRouter
+
+	// Subscription owners' notification preferences apply when set
+	preferences PreferenceStore
+
+	// Run reports are attached for subscriptions that ask for them when set
+	reports ReportRenderer
+}
+
+// NewDispatcher creates a new notification dispatcher
+func NewDispatcher(store SubscriptionStore) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: newWebhookClient(10 * time.Second),
+	}
+}
+
+// SetAlertRouter routes alert.triggered events to notification channels in
+// addition to webhook subscriptions
+func (d *Dispatcher) SetAlertRouter(router *AlertRouter) {
+	d.alerts = router
+}
+
+// Publish delivers an event to all matching subscriptions in the background.
+// With an outbox configured the event is stored first and delivered by
+// RunOutbox, so it survives a crash.
+func (d *Dispatcher) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	if d.outbox != nil {
+		row, err := NewOutboxEvent(event)
+		if err == nil {
+			err = d.outbox.Enqueue(row)
+		}
+		if err == nil {
+			return
+		}
+		logrus.WithError(err).WithField("event_type", event.Type).Warn("Failed to store event in outbox, delivering directly")
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := d.Dispatch(ctx, event); err != nil {
+			logrus.WithError(err).WithField("event_type", event.Type).Error("Failed to dispatch event")
+		}
+	}()
+}
+
+// Dispatch synchronously delivers an event to all matching subscriptions
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) error {
+	subscriptions, err := d.store.GetActiveSubscriptions(event.Type)
+	if err != nil {
+		return fmt.Errorf("failed to load subscriptions: %w", err)
+	}
+	preferences, err := d.ownerPreferences(subscriptions)
+	if err != nil {
+		return err
+	}
+
+	for i := range subscriptions {
+		if err := d.deliverPreferred(ctx, &subscriptions[i], event, preferences); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"subscription_id": subscriptions[i].ID,
+				"event_type":      event.Type,
+			}).Warn("Webhook delivery failed")
+		}
+	}
+
+	if d.routesAlerts(event) {
+		if _, err := d.alerts.Route(ctx, event, nil); err != nil {
+			logrus.WithError(err).WithField("event_id", event.ID).Warn("Alert routing failed")
+		}
+	}
+
+	return nil
+}
+
+// routesAlerts reports whether an event goes to the alert router
+func (d *Dispatcher) routesAlerts(event Event) bool {
+	return d.alerts != nil && event.Type == EventAlertTriggered
+}
+
+// RenderPayload renders the payload a subscription would receive for an event
+func RenderPayload(subscription *database.WebhookSubscription, event Event) ([]byte, string, error) {
+	body := subscription.PayloadTemplate
+	contentType := subscription.ContentType
+
+	if body == "" {
+		tmpl, err := GetDefaultTemplate(subscription.TemplateVersion)
+		if err != nil {
+			return nil, "", err
+		}
+		body = tmpl.Body
+		if contentType == "" {
+			contentType = tmpl.ContentType
+		}
+	}
+
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	payload, err := Render(body, contentType, event)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return payload, contentType, nil
+}
+
+// Deliver sends a single event to a subscription
+func (d *Dispatcher) Deliver(ctx context.Context, subscription *database.WebhookSubscription, event Event) error {
+	payload, contentType, err := RenderPayload(subscription, event)
+	if err != nil {
+		return err
+	}
+
+	if attachment := d.renderReport(ctx, subscription, event); attachment != nil {
+		payload, contentType, err = withReport(payload, contentType, attachment, subscription.ReportFormat)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := CheckDestination(ctx, subscription.URL); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-VoltEdge-Event", event.Type)
+	req.Header.Set("X-VoltEdge-Event-ID", event.ID)
+	if subscription.Secret != "" {
+		req.Header.Set("X-VoltEdge-Signature", "sha256="+sign(subscription.Secret, payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"subscription_id": subscription.ID,
+		"event_type":      event.Type,
+		"status":          resp.StatusCode,
+	}).Debug("Webhook delivered")
+
+	return nil
+}
+
+// sign computes the HMAC-SHA256 signature of a payload
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,226 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/lock"
+)
+
+// outboxLease is how long a claimed event is hidden from other dispatchers
+const outboxLease = 5 * time.Minute
+
+// purgeLockName guards purging so replicas do not all purge at once
+const purgeLockName = "voltedge:outbox-purge"
+
+// OutboxStore persists events until every subscriber has received them
+type OutboxStore interface {
+	Enqueue(events ...*database.OutboxEvent) error
+	ClaimDue(limit int, lease time.Duration) ([]database.OutboxEvent, error)
+	MarkDelivered(event *database.OutboxEvent) error
+	RecordFailure(event *database.OutboxEvent, deliveryErr error, retryAt time.Time, giveUp bool) error
+	PurgeDelivered(before time.Time) (int64, error)
+}
+
+// OutboxOptions configures outbox delivery
+type OutboxOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+	Retention    time.Duration
+	// Optional; when set, only the replica holding the lock purges
+	Locker lock.Locker
+}
+
+// NewOutboxEvent builds the outbox row for an event. The event ID doubles as
+// the dedupe key, so callers retrying a state change should use a stable ID.
+func NewOutboxEvent(event Event) (*database.OutboxEvent, error) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	return &database.OutboxEvent{
+		DedupeKey:     event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        database.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// SetOutbox routes published events through a transactional outbox. It must
+// be called before events are published.
+func (d *Dispatcher) SetOutbox(store OutboxStore, opts OutboxOptions) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 5 * time.Second
+	}
+	if opts.MaxBackoff < opts.RetryBackoff {
+		opts.MaxBackoff = opts.RetryBackoff
+	}
+
+	d.outbox = store
+	d.outboxOpts = opts
+}
+
+// RunOutbox delivers outbox events until ctx is done
+func (d *Dispatcher) RunOutbox(ctx context.Context) {
+	if d.outbox == nil {
+		return
+	}
+
+	ticker := time.NewTicker(d.outboxOpts.PollInterval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		// Keep draining while full batches come back
+		for d.deliverOutboxBatch(ctx) == d.outboxOpts.BatchSize && ctx.Err() == nil {
+		}
+
+		if d.outboxOpts.Retention > 0 && time.Since(lastPurge) >= time.Hour {
+			lastPurge = time.Now()
+			d.purgeOutbox(ctx, lastPurge.Add(-d.outboxOpts.Retention))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeOutbox deletes events delivered before the cutoff, along with
+// expired alert silences
+func (d *Dispatcher) purgeOutbox(ctx context.Context, before time.Time) {
+	purge := func(context.Context) {
+		if purged, err := d.outbox.PurgeDelivered(before); err == nil && purged > 0 {
+			logrus.WithField("events", purged).Info("Purged delivered outbox events")
+		}
+		if d.alerts != nil {
+			d.alerts.PurgeExpiredSilences()
+		}
+	}
+
+	if d.outboxOpts.Locker == nil {
+		purge(ctx)
+		return
+	}
+	if _, err := lock.RunExclusive(ctx, d.outboxOpts.Locker, purgeLockName, purge); err != nil {
+		logrus.WithError(err).Warn("Failed to acquire outbox purge lock")
+	}
+}
+
+// deliverOutboxBatch claims and delivers one batch of due events, returning how many were claimed
+func (d *Dispatcher) deliverOutboxBatch(ctx context.Context) int {
+	events, err := d.outbox.ClaimDue(d.outboxOpts.BatchSize, outboxLease)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to claim outbox events")
+		return 0
+	}
+
+	for i := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		d.deliverOutboxEvent(ctx, &events[i])
+	}
+	return len(events)
+}
+
+// deliverOutboxEvent delivers an event to the subscriptions that have not received it yet
+func (d *Dispatcher) deliverOutboxEvent(ctx context.Context, row *database.OutboxEvent) {
+	var event Event
+	err := json.Unmarshal(row.Payload, &event)
+	if err == nil {
+		err = d.deliverPending(ctx, row, event)
+	}
+
+	if err == nil {
+		if err := d.outbox.MarkDelivered(row); err != nil {
+			logrus.WithError(err).WithField("event_id", row.DedupeKey).Warn("Failed to mark outbox event delivered")
+		}
+		return
+	}
+
+	// Exponential backoff from the configured base, capped at the maximum
+	backoff := d.outboxOpts.RetryBackoff << min(row.Attempts, 20)
+	if backoff <= 0 || backoff > d.outboxOpts.MaxBackoff {
+		backoff = d.outboxOpts.MaxBackoff
+	}
+	giveUp := row.Attempts+1 >= d.outboxOpts.MaxAttempts
+
+	logrus.WithError(err).WithFields(logrus.Fields{
+		"event_id":   row.DedupeKey,
+		"event_type": row.EventType,
+		"attempt":    row.Attempts + 1,
+		"give_up":    giveUp,
+	}).Warn("Outbox event delivery failed")
+
+	if err := d.outbox.RecordFailure(row, err, time.Now().Add(backoff), giveUp); err != nil {
+		logrus.WithError(err).WithField("event_id", row.DedupeKey).Warn("Failed to record outbox delivery failure")
+	}
+}
+
+// deliverPending delivers an event to each matching subscription and alert
+// route not yet in row.DeliveredTo, recording the ones that succeed
+func (d *Dispatcher) deliverPending(ctx context.Context, row *database.OutboxEvent, event Event) error {
+	subscriptions, err := d.store.GetActiveSubscriptions(event.Type)
+	if err != nil {
+		return fmt.Errorf("failed to load subscriptions: %w", err)
+	}
+	preferences, err := d.ownerPreferences(subscriptions)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range subscriptions {
+		id := subscriptions[i].ID.String()
+		if slices.Contains(row.DeliveredTo, id) {
+			continue
+		}
+		if err := d.deliverPreferred(ctx, &subscriptions[i], event, preferences); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", id, err))
+			continue
+		}
+		row.DeliveredTo = append(row.DeliveredTo, id)
+	}
+
+	if d.routesAlerts(event) {
+		routed, err := d.alerts.Route(ctx, event, row.DeliveredTo)
+		row.DeliveredTo = append(row.DeliveredTo, routed...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,442 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	// digestPollInterval is how often held notifications are checked for due digests
+	digestPollInterval = time.Minute
+	// digestBatchSize caps the held notifications claimed per poll
+	digestBatchSize = 500
+	// digestLease is how long claimed notifications are hidden from other
+	// dispatchers; a digest that fails to send is retried after it
+	digestLease = 5 * time.Minute
+)
+
+// PreferenceStore provides users' notification preferences and holds the
+// notifications they defer to a digest
+type PreferenceStore interface {
+	GetNotificationPreferences(userIDs []uuid.UUID) (map[uuid.UUID]database.NotificationPreference, error)
+	GetNotificationPreferencesByEmail(emails []string) (map[string]database.NotificationPreference, error)
+	EnqueueDigestItems(items ...*database.NotificationDigestItem) error
+	ClaimDueDigestItems(limit int, lease time.Duration) ([]database.NotificationDigestItem, error)
+	DeleteDigestItems(ids []uuid.UUID) error
+}
+
+// What a user's preferences do with a notification
+type preferenceAction int
+
+const (
+	sendNow preferenceAction = iota
+	dropNotification
+	holdNotification
+)
+
+// applyPreference decides what a user's preferences do with an event sent
+// through a channel type. Held events come with the time their digest is due.
+func applyPreference(preference *database.NotificationPreference, channel string, event Event, now time.Time) (preferenceAction, time.Time) {
+	if preference == nil {
+		return sendNow, time.Time{}
+	}
+	if !preference.Wants(channel, event.Type) {
+		return dropNotification, time.Time{}
+	}
+
+	due := now
+	if preference.Delivery == database.DeliveryDigest {
+		due = nextDigest(preference, now)
+	}
+	// A digest falling in quiet hours waits for them to end
+	if end, quiet := quietHoursEnd(preference, due); quiet {
+		due = end
+	}
+
+	if due.After(now) {
+		return holdNotification, due
+	}
+	return sendNow, time.Time{}
+}
+
+// nextDigest returns when the digest following now is sent. Digests are
+// aligned to the interval from midnight in the user's timezone.
+func nextDigest(preference *database.NotificationPreference, now time.Time) time.Time {
+	interval := time.Duration(preference.DigestIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	local := now.In(preferenceLocation(preference))
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	return midnight.Add((local.Sub(midnight)/interval + 1) * interval)
+}
+
+// quietHoursEnd reports whether a time falls in the user's quiet hours and
+// when they end
+func quietHoursEnd(preference *database.NotificationPreference, at time.Time) (time.Time, bool) {
+	start, err := ParseClock(preference.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := ParseClock(preference.QuietHoursEnd)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+
+	local := at.In(preferenceLocation(preference))
+	minute := local.Hour()*60 + local.Minute()
+	quiet := minute >= start && minute < end
+	if start > end {
+		// Quiet hours spanning midnight
+		quiet = minute >= start || minute < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, local.Location())
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// ParseClock parses a "15:04" time of day into minutes after midnight
+func ParseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// preferenceLocation loads the user's timezone, falling back to UTC
+func preferenceLocation(preference *database.NotificationPreference) *time.Location {
+	if preference.Timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(preference.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// newDigestItem builds the row holding an event for a user's digest
+func newDigestItem(userID uuid.UUID, event Event, deliverAfter time.Time) (*database.NotificationDigestItem, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	return &database.NotificationDigestItem{
+		UserID:       userID,
+		EventType:    event.Type,
+		Payload:      payload,
+		DeliverAfter: deliverAfter,
+	}, nil
+}
+
+// SetPreferences applies the owners' notification preferences to webhook
+// deliveries and sends the digests they hold back. The alert router needs
+// its own call to apply them to notification channels.
+func (d *Dispatcher) SetPreferences(store PreferenceStore) {
+	d.preferences = store
+}
+
+// ownerPreferences loads the preferences of the subscriptions' owners
+func (d *Dispatcher) ownerPreferences(subscriptions []database.WebhookSubscription) (map[uuid.UUID]database.NotificationPreference, error) {
+	if d.preferences == nil {
+		return nil, nil
+	}
+
+	var owners []uuid.UUID
+	for i := range subscriptions {
+		if subscriptions[i].OwnerID != nil {
+			owners = append(owners, *subscriptions[i].OwnerID)
+		}
+	}
+	if len(owners) == 0 {
+		return nil, nil
+	}
+
+	preferences, err := d.preferences.GetNotificationPreferences(owners)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+	return preferences, nil
+}
+
+// deliverPreferred delivers an event to a subscription unless its owner's
+// preferences drop it or hold it for a digest
+func (d *Dispatcher) deliverPreferred(ctx context.Context, subscription *database.WebhookSubscription, event Event, preferences map[uuid.UUID]database.NotificationPreference) error {
+	if subscription.OwnerID == nil {
+		return d.Deliver(ctx, subscription, event)
+	}
+	preference, ok := preferences[*subscription.OwnerID]
+	if !ok {
+		return d.Deliver(ctx, subscription, event)
+	}
+
+	action, due := applyPreference(&preference, database.ChannelWebhook, event, time.Now())
+	switch action {
+	case dropNotification:
+		return nil
+	case holdNotification:
+		item, err := newDigestItem(preference.UserID, event, due)
+		if err != nil {
+			return err
+		}
+		item.SubscriptionID = &subscription.ID
+		return d.preferences.EnqueueDigestItems(item)
+	default:
+		return d.Deliver(ctx, subscription, event)
+	}
+}
+
+// SetPreferences applies the owners' and email recipients' notification
+// preferences to channel notifications
+func (r *AlertRouter) SetPreferences(store PreferenceStore) {
+	r.preferences = store
+}
+
+// notify sends an event through a channel as its owner's and recipients'
+// preferences allow, holding the rest for a digest. It returns the
+// notification status and, when nothing was sent, why.
+func (r *AlertRouter) notify(ctx context.Context, channel *database.NotificationChannel, event Event) (string, string, error) {
+	if r.preferences == nil {
+		return database.NotificationSent, "", r.Send(ctx, channel, event)
+	}
+
+	now := time.Now()
+	if channel.OwnerID != nil {
+		preferences, err := r.preferences.GetNotificationPreferences([]uuid.UUID{*channel.OwnerID})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load notification preferences: %w", err)
+		}
+		if preference, ok := preferences[*channel.OwnerID]; ok {
+			action, due := applyPreference(&preference, channel.Type, event, now)
+			switch action {
+			case dropNotification:
+				return database.NotificationSuppressed, "muted by the owner's notification preferences", nil
+			case holdNotification:
+				item, err := newDigestItem(preference.UserID, event, due)
+				if err != nil {
+					return "", "", err
+				}
+				item.ChannelID = &channel.ID
+				if err := r.preferences.EnqueueDigestItems(item); err != nil {
+					return "", "", err
+				}
+				return database.NotificationHeld, "held for digest until " + due.UTC().Format(time.RFC3339), nil
+			}
+		}
+	}
+
+	if channel.Type != database.ChannelEmail || len(channel.Recipients) == 0 {
+		return database.NotificationSent, "", r.Send(ctx, channel, event)
+	}
+
+	// Each recipient who is a user gets their own preferences applied
+	preferences, err := r.preferences.GetNotificationPreferencesByEmail(channel.Recipients)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+
+	var held []*database.NotificationDigestItem
+	var recipients []string
+	for _, recipient := range channel.Recipients {
+		preference, ok := preferences[strings.ToLower(recipient)]
+		if !ok {
+			recipients = append(recipients, recipient)
+			continue
+		}
+		action, due := applyPreference(&preference, channel.Type, event, now)
+		switch action {
+		case sendNow:
+			recipients = append(recipients, recipient)
+		case holdNotification:
+			item, err := newDigestItem(preference.UserID, event, due)
+			if err != nil {
+				return "", "", err
+			}
+			item.ChannelID = &channel.ID
+			item.Recipient = recipient
+			held = append(held, item)
+		}
+	}
+	if err := r.preferences.EnqueueDigestItems(held...); err != nil {
+		return "", "", err
+	}
+	if len(recipients) == 0 {
+		if len(held) > 0 {
+			return database.NotificationHeld, "held for digest by every recipient", nil
+		}
+		return database.NotificationSuppressed, "muted by every recipient's notification preferences", nil
+	}
+
+	filtered := *channel
+	filtered.Recipients = recipients
+	return database.NotificationSent, "", r.Send(ctx, &filtered, event)
+}
+
+// RunDigests sends held notifications as digests when they are due, until
+// ctx is done
+func (d *Dispatcher) RunDigests(ctx context.Context) {
+	if d.preferences == nil {
+		return
+	}
+
+	ticker := time.NewTicker(digestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep draining while full batches come back
+		for d.sendDueDigests(ctx) == digestBatchSize && ctx.Err() == nil {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// digestTarget identifies where a group of held notifications goes
+type digestTarget struct {
+	subscriptionID uuid.UUID
+	channelID      uuid.UUID
+	recipient      string
+}
+
+// sendDueDigests claims due held notifications and sends one digest per
+// subscription, channel or email recipient, returning how many were claimed
+func (d *Dispatcher) sendDueDigests(ctx context.Context) int {
+	items, err := d.preferences.ClaimDueDigestItems(digestBatchSize, digestLease)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to claim notification digests")
+		return 0
+	}
+
+	var order []digestTarget
+	groups := make(map[digestTarget][]database.NotificationDigestItem)
+	for _, item := range items {
+		var target digestTarget
+		if item.SubscriptionID != nil {
+			target.subscriptionID = *item.SubscriptionID
+		}
+		if item.ChannelID != nil {
+			target.channelID = *item.ChannelID
+			target.recipient = strings.ToLower(item.Recipient)
+		}
+		if _, seen := groups[target]; !seen {
+			order = append(order, target)
+		}
+		groups[target] = append(groups[target], item)
+	}
+
+	for _, target := range order {
+		if ctx.Err() != nil {
+			break
+		}
+		group := groups[target]
+		if err := d.sendDigest(ctx, group); err != nil {
+			// The items stay leased and are retried when the lease runs out
+			logrus.WithError(err).WithField("notifications", len(group)).Warn("Failed to send notification digest")
+			continue
+		}
+
+		ids := make([]uuid.UUID, len(group))
+		for i := range group {
+			ids[i] = group[i].ID
+		}
+		if err := d.preferences.DeleteDigestItems(ids); err != nil {
+			logrus.WithError(err).Warn("Failed to delete sent notification digest items")
+		}
+	}
+	return len(items)
+}
+
+// sendDigest sends held notifications for one target as a single digest
+// event. Notifications whose subscription or channel is gone or inactive
+// are dropped.
+func (d *Dispatcher) sendDigest(ctx context.Context, items []database.NotificationDigestItem) error {
+	digest, err := DigestEvent(items)
+	if err != nil {
+		return err
+	}
+
+	first := items[0]
+	switch {
+	case first.Subscription != nil:
+		if !first.Subscription.IsActive {
+			return nil
+		}
+		return d.Deliver(ctx, first.Subscription, digest)
+	case first.Channel != nil:
+		if !first.Channel.IsActive || d.alerts == nil {
+			return nil
+		}
+		channel := *first.Channel
+		if first.Recipient != "" {
+			channel.Recipients = []string{first.Recipient}
+		}
+		return d.alerts.Send(ctx, &channel, digest)
+	default:
+		return nil
+	}
+}
+
+// DigestEvent builds the notification.digest event summarizing held events.
+// Its data lists the events, and its severity is the highest among them.
+func DigestEvent(items []database.NotificationDigestItem) (Event, error) {
+	events := make([]Event, len(items))
+	severity := ""
+	for i := range items {
+		if err := json.Unmarshal(items[i].Payload, &events[i]); err != nil {
+			return Event{}, fmt.Errorf("failed to decode held event: %w", err)
+		}
+		if severityRank(events[i].Severity) > severityRank(severity) {
+			severity = strings.ToLower(events[i].Severity)
+		}
+	}
+
+	return Event{
+		ID:        uuid.New().String(),
+		Type:      EventNotificationDigest,
+		Severity:  severity,
+		Message:   fmt.Sprintf("%d notifications since %s", len(events), items[0].CreatedAt.UTC().Format(time.RFC3339)),
+		Timestamp: time.Now().UTC(),
+		Data: map[string]interface{}{
+			"count":  len(events),
+			"events": events,
+		},
+	}, nil
+}
+
+// digestLines lists the events of a digest one per line
+func digestLines(event Event) []string {
+	events, _ := event.Data["events"].([]Event)
+	lines := make([]string, len(events))
+	for i, held := range events {
+		line := held.Timestamp.UTC().Format(time.RFC3339) + " " + held.Type
+		if held.Severity != "" {
+			line += " [" + strings.ToUpper(held.Severity) + "]"
+		}
+		if held.Message != "" {
+			line += " " + held.Message
+		}
+		lines[i] = line
+	}
+	return lines
+}
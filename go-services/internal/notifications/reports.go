@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+// ReportRenderer renders a simulation's run report in a format
+type ReportRenderer interface {
+	Render(ctx context.Context, simulationID uuid.UUID, format string) ([]byte, error)
+}
+
+// SetReports attaches run reports to simulation completed, stopped and failed
+// deliveries for subscriptions with a report format
+func (d *Dispatcher) SetReports(renderer ReportRenderer) {
+	d.reports = renderer
+}
+
+// renderReport returns the report attached to a delivery, or nil. A report
+// that fails to render is left out rather than holding back the event.
+func (d *Dispatcher) renderReport(ctx context.Context, subscription *database.WebhookSubscription, event Event) []byte {
+	if d.reports == nil || subscription.ReportFormat == "" {
+		return nil
+	}
+	switch event.Type {
+	case EventSimulationCompleted, EventSimulationStopped, EventSimulationFailed:
+	default:
+		return nil
+	}
+
+	simulationID, err := uuid.Parse(event.SimulationID)
+	if err != nil {
+		return nil
+	}
+
+	report, err := d.reports.Render(ctx, simulationID, subscription.ReportFormat)
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"subscription_id": subscription.ID,
+			"simulation_id":   simulationID,
+		}).Warn("Failed to render report for webhook")
+		return nil
+	}
+	return report
+}
+
+// withReport wraps a payload and report into a multipart/form-data body with
+// "payload" and "report" parts. The signature covers the whole body.
+func withReport(payload []byte, contentType string, report []byte, format string) ([]byte, string, error) {
+	reportType := "text/html; charset=utf-8"
+	if format == "pdf" {
+		reportType = "application/pdf"
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	parts := []struct {
+		header  textproto.MIMEHeader
+		content []byte
+	}{
+		{textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="payload"`},
+			"Content-Type":        {contentType},
+		}, payload},
+		{textproto.MIMEHeader{
+			"Content-Disposition": {fmt.Sprintf(`form-data; name="report"; filename="report.%s"`, format)},
+			"Content-Type":        {reportType},
+		}, report},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(part.header)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to build webhook body: %w", err)
+		}
+		if _, err := w.Write(part.content); err != nil {
+			return nil, "", fmt.Errorf("failed to build webhook body: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to build webhook body: %w", err)
+	}
+
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
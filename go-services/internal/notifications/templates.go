@@ -0,0 +1,152 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Event represents a notification-worthy occurrence in the system
+type Event struct {
+	ID           string                 `json:"id"`
+	Type         string                 `json:"type"`
+	SimulationID string                 `json:"simulation_id,omitempty"`
+	Severity     string                 `json:"severity,omitempty"`
+	Message      string                 `json:"message,omitempty"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Data         map[string]interface{} `json:"data,omitempty"`
+}
+
+// DefaultTemplate is a versioned built-in payload template
+type DefaultTemplate struct {
+	Version     int    `json:"version"`
+	ContentType string `json:"content_type"`
+	Body        string `json:"body"`
+	Description string `json:"description"`
+}
+
+// defaultTemplates holds built-in webhook payload templates ordered by version.
+// Existing versions must never change so subscriptions pinned to them stay stable.
+var defaultTemplates = []DefaultTemplate{
+	{
+		Version:     1,
+		ContentType: "application/json",
+		Description: "Event type and data only",
+		Body:        `{"event":{{json .Type}},"data":{{json .Data}}}`,
+	},
+	{
+		Version:     2,
+		ContentType: "application/json",
+		Description: "Full event envelope with identifiers and timestamp",
+		Body: `{"id":{{json .ID}},"event":{{json .Type}},"simulation_id":{{json .SimulationID}},` +
+			`"severity":{{json .Severity}},"message":{{json .Message}},` +
+			`"timestamp":{{json (formatTime .Timestamp)}},"data":{{json .Data}}}`,
+	},
+}
+
+// DefaultTemplates returns all built-in templates
+func DefaultTemplates() []DefaultTemplate {
+	templates := make([]DefaultTemplate, len(defaultTemplates))
+	copy(templates, defaultTemplates)
+	return templates
+}
+
+// LatestTemplateVersion returns the newest built-in template version
+func LatestTemplateVersion() int {
+	return defaultTemplates[len(defaultTemplates)-1].Version
+}
+
+// GetDefaultTemplate returns the built-in template for a version (0 selects the latest)
+func GetDefaultTemplate(version int) (DefaultTemplate, error) {
+	if version == 0 {
+		version = LatestTemplateVersion()
+	}
+
+	for _, tmpl := range defaultTemplates {
+		if tmpl.Version == version {
+			return tmpl, nil
+		}
+	}
+
+	return DefaultTemplate{}, fmt.Errorf("unknown default template version: %d", version)
+}
+
+// templateFuncs are the helpers available to payload templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"formatTime": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+	"get": func(data map[string]interface{}, key string) interface{} {
+		return data[key]
+	},
+	"keys": func(data map[string]interface{}) []string {
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	},
+}
+
+// ParseTemplate validates a payload template
+func ParseTemplate(body string) (*template.Template, error) {
+	tmpl, err := template.New("payload").Funcs(templateFuncs).Option("missingkey=zero").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Render renders a payload template for an event. JSON content types are
+// validated so a broken template cannot deliver malformed payloads.
+func Render(body, contentType string, event Event) ([]byte, error) {
+	tmpl, err := ParseTemplate(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render payload template: %w", err)
+	}
+
+	if strings.Contains(contentType, "json") && !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("rendered payload is not valid JSON")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SampleEvent returns an example event used for template previews
+func SampleEvent(eventType string) Event {
+	if eventType == "" {
+		eventType = EventSimulationCompleted
+	}
+
+	return Event{
+		ID:           "evt_preview",
+		Type:         eventType,
+		SimulationID: "00000000-0000-0000-0000-000000000000",
+		Severity:     "info",
+		Message:      "Preview notification",
+		Timestamp:    time.Now().UTC(),
+		Data: map[string]interface{}{
+			"name":   "Preview Simulation",
+			"status": "completed",
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Error levels
+const (
+	ErrorLevelError = "error"
+	// Panics and other failures that took down what they happened in
+	ErrorLevelFatal = "fatal"
+)
+
+// ErrorEvent is an error reported to error tracking
+type ErrorEvent struct {
+	Err error
+	// ErrorLevelError when empty
+	Level string
+	// Part of the gateway the error occurred in, e.g. http, worker or grpc
+	Source string
+	// Indexed context such as the simulation, route or user
+	Tags map[string]string
+	// Unindexed context
+	Extra map[string]any
+	// The API request that failed, if any
+	Request *ErrorRequest
+	// Stack trace as formatted by runtime/debug.Stack; the reporting
+	// goroutine's stack when empty
+	Stack []byte
+}
+
+// ErrorRequest is the API request an error occurred in. It carries no
+// credentials.
+type ErrorRequest struct {
+	Method    string
+	URL       string
+	UserAgent string
+}
+
+// ErrorReporter sends errors to an error tracking service. Report must not
+// block.
+type ErrorReporter interface {
+	Report(event ErrorEvent)
+}
+
+var (
+	errorReporterMu sync.RWMutex
+	errorReporter   ErrorReporter
+)
+
+// SetErrorReporter sets where ReportError sends errors; nil stops reporting
+func SetErrorReporter(reporter ErrorReporter) {
+	errorReporterMu.Lock()
+	defer errorReporterMu.Unlock()
+
+	errorReporter = reporter
+}
+
+// ReportError sends an error to error tracking, if a reporter is set
+func ReportError(event ErrorEvent) {
+	errorReporterMu.RLock()
+	reporter := errorReporter
+	errorReporterMu.RUnlock()
+
+	if reporter == nil || event.Err == nil {
+		return
+	}
+	if event.Level == "" {
+		event.Level = ErrorLevelError
+	}
+	if event.Stack == nil {
+		event.Stack = debug.Stack()
+	}
+	reporter.Report(event)
+}
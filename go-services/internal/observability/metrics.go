@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"database/sql"
 	"net/http"
 	"time"
 
@@ -139,6 +140,31 @@ var (
 		[]string{"simulation_id", "line_id"},
 	)
 
+	// Storage unit metrics
+	storageStateOfCharge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "voltedge_storage_state_of_charge_ratio",
+			Help: "Storage unit state of charge ratio",
+		},
+		[]string{"simulation_id", "unit_id"},
+	)
+
+	storageStoredEnergy = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "voltedge_storage_stored_energy_mwh",
+			Help: "Storage unit stored energy in MWh",
+		},
+		[]string{"simulation_id", "unit_id"},
+	)
+
+	storagePower = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "voltedge_storage_power_mw",
+			Help: "Storage unit power in MW (positive when discharging, negative when charging)",
+		},
+		[]string{"simulation_id", "unit_id"},
+	)
+
 	// System metrics
 	systemMemoryUsage = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -178,6 +204,134 @@ var (
 			Help: "Number of active gRPC connections",
 		},
 	)
+
+	// Reconciliation metrics
+	reconcileRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "voltedge_reconcile_runs_total",
+			Help: "Total number of orchestrator/database reconciliation passes",
+		},
+		[]string{"result"},
+	)
+
+	reconcileActionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "voltedge_reconcile_actions_total",
+			Help: "Total number of simulation rows corrected by reconciliation",
+		},
+		[]string{"action"},
+	)
+
+	reconcileDivergent = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "voltedge_reconcile_divergent_simulations",
+			Help: "Simulations whose database state diverged from the orchestrator in the last reconciliation pass",
+		},
+	)
+
+	// Remote-write metrics
+	remoteWriteSamplesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "voltedge_remote_write_samples_total",
+			Help: "Total number of simulation telemetry samples handled by the remote-write exporter",
+		},
+		[]string{"result"},
+	)
+
+	remoteWriteQueueLength = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "voltedge_remote_write_queue_length",
+			Help: "Time series waiting to be sent by the remote-write exporter",
+		},
+	)
+
+	// Database pool metrics
+	dbConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "voltedge_db_connections",
+			Help: "Database connections in the pool by state",
+		},
+		[]string{"state"},
+	)
+
+	dbMaxOpenConnections = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "voltedge_db_max_open_connections",
+			Help: "Most database connections the pool may open",
+		},
+	)
+
+	dbWaitsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "voltedge_db_waits_total",
+			Help: "Total number of times a query waited for a free database connection",
+		},
+	)
+
+	dbWaitSeconds = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "voltedge_db_wait_seconds_total",
+			Help: "Total time queries waited for a free database connection",
+		},
+	)
+
+	dbConnectionsClosed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "voltedge_db_connections_closed_total",
+			Help: "Total number of database connections closed by the pool",
+		},
+		[]string{"reason"},
+	)
+
+	dbPoolSaturated = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "voltedge_db_pool_saturated",
+			Help: "Whether queries are waiting for database connections faster than the alert threshold",
+		},
+	)
+
+	// Read cache metrics
+	cacheLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "voltedge_cache_lookups_total",
+			Help: "Total number of read cache lookups by result: hit, miss, or shared with a load in progress",
+		},
+		[]string{"cache", "result"},
+	)
+
+	// Chaos metrics
+	chaosFaultsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "voltedge_chaos_faults_injected_total",
+			Help: "Total number of failures injected by chaos experiments",
+		},
+		[]string{"fault"},
+	)
+
+	// Worker metrics
+	workerPanicsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "voltedge_worker_panics_total",
+			Help: "Total number of panics recovered in simulation workers, by scope: a job or the worker loop",
+		},
+		[]string{"scope"},
+	)
+
+	// Startup self-test metrics
+	selfTestCheck = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "voltedge_self_test_passed",
+			Help: "Whether the startup self-test passed (1) or failed (0), by check; check all covers the whole test",
+		},
+		[]string{"check"},
+	)
+
+	selfTestDuration = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "voltedge_self_test_duration_seconds",
+			Help: "How long the startup self-test took",
+		},
+	)
 )
 
 // Config holds observability configuration
@@ -256,6 +410,11 @@ func RecordPowerPlantMetrics(simulationID, plantID, plantType string, output, ef
 	powerPlantCO2Emissions.WithLabelValues(simulationID, plantID, plantType).Set(co2Emissions)
 }
 
+// RecordPowerPlantEmissions records the instantaneous CO2 emission rate of a power plant
+func RecordPowerPlantEmissions(simulationID, plantID, plantType string, co2KgPerHour float64) {
+	powerPlantCO2Emissions.WithLabelValues(simulationID, plantID, plantType).Set(co2KgPerHour)
+}
+
 // RecordTransmissionLineMetrics records transmission line metrics
 func RecordTransmissionLineMetrics(simulationID, lineID string, flow, utilization, losses float64) {
 	transmissionLineFlow.WithLabelValues(simulationID, lineID).Set(flow)
@@ -263,6 +422,21 @@ func RecordTransmissionLineMetrics(simulationID, lineID string, flow, utilizatio
 	transmissionLineLosses.WithLabelValues(simulationID, lineID).Set(losses)
 }
 
+// RecordStorageStateOfCharge records the state of charge of a storage unit
+func RecordStorageStateOfCharge(simulationID, unitID string, stateOfCharge float64) {
+	storageStateOfCharge.WithLabelValues(simulationID, unitID).Set(stateOfCharge)
+}
+
+// RecordStorageStoredEnergy records the energy stored in a storage unit
+func RecordStorageStoredEnergy(simulationID, unitID string, storedEnergy float64) {
+	storageStoredEnergy.WithLabelValues(simulationID, unitID).Set(storedEnergy)
+}
+
+// RecordStoragePower records the power of a storage unit
+func RecordStoragePower(simulationID, unitID string, power float64) {
+	storagePower.WithLabelValues(simulationID, unitID).Set(power)
+}
+
 // RecordSystemMetrics records system metrics
 func RecordSystemMetrics(memoryUsage int64, cpuUsage float64) {
 	systemMemoryUsage.Set(float64(memoryUsage))
@@ -284,6 +458,80 @@ func RecordGRPCConnection(connected bool) {
 	}
 }
 
+// RecordReconcileRun records the outcome of a reconciliation pass
+func RecordReconcileRun(err error, divergent int) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	reconcileRunsTotal.WithLabelValues(result).Inc()
+	reconcileDivergent.Set(float64(divergent))
+}
+
+// RecordReconcileAction records a correction made by reconciliation
+func RecordReconcileAction(action string) {
+	reconcileActionsTotal.WithLabelValues(action).Inc()
+}
+
+// RecordRemoteWriteSamples records samples that were sent, failed or dropped by the remote-write exporter
+func RecordRemoteWriteSamples(result string, count int) {
+	remoteWriteSamplesTotal.WithLabelValues(result).Add(float64(count))
+}
+
+// SetRemoteWriteQueueLength records the remote-write exporter's queue length
+func SetRemoteWriteQueueLength(length int) {
+	remoteWriteQueueLength.Set(float64(length))
+}
+
+// RecordChaosFault records a failure injected by a chaos experiment
+func RecordChaosFault(fault string) {
+	chaosFaultsTotal.WithLabelValues(fault).Inc()
+}
+
+// RecordWorkerPanic records a panic recovered in a simulation worker
+func RecordWorkerPanic(scope string) {
+	workerPanicsTotal.WithLabelValues(scope).Inc()
+}
+
+// RecordSelfTestCheck records the outcome of a startup self-test check
+func RecordSelfTestCheck(check string, passed bool) {
+	value := 0.0
+	if passed {
+		value = 1
+	}
+	selfTestCheck.WithLabelValues(check).Set(value)
+}
+
+// RecordSelfTest records the outcome and duration of the startup self-test
+func RecordSelfTest(passed bool, duration time.Duration) {
+	RecordSelfTestCheck("all", passed)
+	selfTestDuration.Set(duration.Seconds())
+}
+
+// RecordDatabasePool records a sample of the database pool; counters grow by
+// the difference from the previous sample
+func RecordDatabasePool(stats, previous sql.DBStats, saturated bool) {
+	dbConnections.WithLabelValues("open").Set(float64(stats.OpenConnections))
+	dbConnections.WithLabelValues("in_use").Set(float64(stats.InUse))
+	dbConnections.WithLabelValues("idle").Set(float64(stats.Idle))
+	dbMaxOpenConnections.Set(float64(stats.MaxOpenConnections))
+	dbWaitsTotal.Add(float64(stats.WaitCount - previous.WaitCount))
+	dbWaitSeconds.Add((stats.WaitDuration - previous.WaitDuration).Seconds())
+	dbConnectionsClosed.WithLabelValues("max_idle").Add(float64(stats.MaxIdleClosed - previous.MaxIdleClosed))
+	dbConnectionsClosed.WithLabelValues("max_idle_time").Add(float64(stats.MaxIdleTimeClosed - previous.MaxIdleTimeClosed))
+	dbConnectionsClosed.WithLabelValues("max_lifetime").Add(float64(stats.MaxLifetimeClosed - previous.MaxLifetimeClosed))
+	if saturated {
+		dbPoolSaturated.Set(1)
+	} else {
+		dbPoolSaturated.Set(0)
+	}
+}
+
+// RecordCacheLookup records a read cache lookup
+func RecordCacheLookup(cache, result string) {
+	cacheLookupsTotal.WithLabelValues(cache, result).Inc()
+}
+
 // initCustomMetrics initializes custom metrics
 func initCustomMetrics() {
 	// Register any additional custom metrics here
@@ -0,0 +1,277 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sentryClient identifies the reporter to Sentry
+const sentryClient = "voltedge-go/1.0"
+
+// inAppPrefix marks stack frames of the gateway's own code
+const inAppPrefix = "voltedge/"
+
+// SentryOptions configures a SentryReporter
+type SentryOptions struct {
+	// e.g. https://<key>@o0.ingest.sentry.io/<project>
+	DSN         string
+	Environment string
+	Release     string
+	ServerName  string
+	// Fraction of errors sent, between 0 and 1
+	SampleRate float64
+	// Errors waiting to be sent; more are dropped
+	QueueSize int
+	Timeout   time.Duration
+}
+
+// SentryReporter sends errors to Sentry in the background
+type SentryReporter struct {
+	opts     SentryOptions
+	endpoint string
+	auth     string
+	http     *http.Client
+
+	mu     sync.RWMutex
+	queue  chan ErrorEvent
+	closed bool
+	done   chan struct{}
+}
+
+// NewSentryReporter creates a reporter for the project named by the DSN and
+// starts sending
+func NewSentryReporter(opts SentryOptions) (*SentryReporter, error) {
+	dsn, err := url.Parse(opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	key := dsn.User.Username()
+	project := path.Base(dsn.Path)
+	if dsn.Scheme == "" || dsn.Host == "" || key == "" || project == "." || project == "/" {
+		return nil, fmt.Errorf("invalid Sentry DSN: expected scheme://key@host/project")
+	}
+
+	endpoint := url.URL{
+		Scheme: dsn.Scheme,
+		Host:   dsn.Host,
+		Path:   path.Join(path.Dir(dsn.Path), "api", project, "envelope") + "/",
+	}
+	reporter := &SentryReporter{
+		opts:     opts,
+		endpoint: endpoint.String(),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, key),
+		http:     &http.Client{Timeout: opts.Timeout},
+		queue:    make(chan ErrorEvent, opts.QueueSize),
+		done:     make(chan struct{}),
+	}
+	go reporter.run()
+
+	logrus.WithField("environment", opts.Environment).Info("Sentry error reporting enabled")
+	return reporter, nil
+}
+
+// Report queues an error to be sent, dropping it when the queue is full or
+// it is not sampled
+func (r *SentryReporter) Report(event ErrorEvent) {
+	if r.opts.SampleRate < 1 && mathrand.Float64() >= r.opts.SampleRate {
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- event:
+	default:
+		logrus.WithError(event.Err).Warn("Sentry queue is full, error not reported")
+	}
+}
+
+// Close sends the errors still queued and stops the reporter, waiting until
+// ctx is done at most
+func (r *SentryReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *SentryReporter) run() {
+	defer close(r.done)
+
+	for event := range r.queue {
+		if err := r.send(event); err != nil {
+			logrus.WithError(err).Warn("Failed to send error to Sentry")
+		}
+	}
+}
+
+// send posts an event to the project's envelope endpoint
+func (r *SentryReporter) send(event ErrorEvent) error {
+	id := newEventID()
+	payload, err := json.Marshal(r.sentryEvent(id, event))
+	if err != nil {
+		return err
+	}
+	header, err := json.Marshal(map[string]string{
+		"event_id": id,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+	item, err := json.Marshal(map[string]any{
+		"type":         "event",
+		"length":       len(payload),
+		"content_type": "application/json",
+	})
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	for _, line := range [][]byte{header, item, payload} {
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// sentryEvent converts an error event to Sentry's event payload
+func (r *SentryReporter) sentryEvent(id string, event ErrorEvent) map[string]any {
+	tags := make(map[string]string, len(event.Tags)+1)
+	for key, value := range event.Tags {
+		tags[key] = value
+	}
+	if event.Source != "" {
+		tags["source"] = event.Source
+	}
+
+	payload := map[string]any{
+		"event_id":    id,
+		"timestamp":   time.Now().UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       event.Level,
+		"logger":      event.Source,
+		"server_name": r.opts.ServerName,
+		"release":     r.opts.Release,
+		"environment": r.opts.Environment,
+		"tags":        tags,
+		"exception": map[string]any{
+			"values": []map[string]any{{
+				"type":       fmt.Sprintf("%T", event.Err),
+				"value":      event.Err.Error(),
+				"stacktrace": map[string]any{"frames": stackFrames(event.Stack)},
+			}},
+		},
+	}
+	if len(event.Extra) > 0 {
+		payload["extra"] = event.Extra
+	}
+	if event.Request != nil {
+		payload["request"] = map[string]any{
+			"method":  event.Request.Method,
+			"url":     event.Request.URL,
+			"headers": map[string]string{"User-Agent": event.Request.UserAgent},
+		}
+	}
+	return payload
+}
+
+// stackFrames parses a stack trace formatted by runtime/debug.Stack into
+// Sentry frames, oldest call first
+func stackFrames(stack []byte) []map[string]any {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "goroutine ") {
+		lines = lines[1:]
+	}
+
+	var frames []map[string]any
+	for i := 0; i+1 < len(lines); i += 2 {
+		function := lines[i]
+		if strings.HasPrefix(function, "created by ") {
+			function = strings.TrimPrefix(function, "created by ")
+			if at := strings.Index(function, " in goroutine "); at >= 0 {
+				function = function[:at]
+			}
+		} else if open := strings.LastIndex(function, "("); open > 0 && strings.HasSuffix(function, ")") {
+			function = function[:open]
+		}
+
+		location := strings.TrimSpace(lines[i+1])
+		if space := strings.LastIndex(location, " +0x"); space >= 0 {
+			location = location[:space]
+		}
+		file, line := location, 0
+		if colon := strings.LastIndex(location, ":"); colon >= 0 {
+			file = location[:colon]
+			line, _ = strconv.Atoi(location[colon+1:])
+		}
+
+		frames = append(frames, map[string]any{
+			"function": function,
+			"abs_path": file,
+			"filename": path.Base(file),
+			"lineno":   line,
+			"in_app":   strings.HasPrefix(function, inAppPrefix),
+		})
+	}
+
+	// Sentry lists the outermost call first
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// newEventID returns a random 32-character hex event ID
+func newEventID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,548 @@
+package orchestration
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Batch statuses
+const (
+	BatchRunning   = "running"
+	BatchCompleted = "completed"
+	BatchCancelled = "cancelled"
+)
+
+// Batch instance statuses
+const (
+	InstancePending   = "pending"
+	InstanceRunning   = "running"
+	InstanceCompleted = "completed"
+	InstanceFailed    = "failed"
+	InstanceCancelled = "cancelled"
+	// The run finished but recorded no results to assess
+	InstanceMissing = "missing"
+)
+
+// Assessment defaults
+const (
+	defaultFrequencyToleranceHz = 0.5
+	defaultBaseFrequencyHz      = 50
+	// How long reading a finished instance's recorded results may take
+	runOutcomeTimeout = 30 * time.Second
+)
+
+// BatchParameters describes the distributions sampled for each batch instance
+type BatchParameters struct {
+	// Standard deviation of the per-instance load scaling factor (0.1 = 10%)
+	LoadStdDev float64 `json:"load_std_dev"`
+	// Default outage probabilities, overridden per component ID
+	PlantFailureProbability       float64            `json:"plant_failure_probability"`
+	LineFailureProbability        float64            `json:"line_failure_probability"`
+	ComponentFailureProbabilities map[string]float64 `json:"component_failure_probabilities,omitempty"`
+	// Frequency deviation from the base frequency counted as an excursion
+	FrequencyToleranceHz float64 `json:"frequency_tolerance_hz"`
+	Seed                 uint64  `json:"seed"`
+}
+
+// BatchInstance is a single sampled run of a batch
+type BatchInstance struct {
+	Index                   int        `json:"index"`
+	SimulationID            string     `json:"simulation_id,omitempty"`
+	Status                  string     `json:"status"`
+	LoadFactor              float64    `json:"load_factor"`
+	FailedComponents        []string   `json:"failed_components"`
+	Results                 int64      `json:"results"`
+	PeakLoadMW              float64    `json:"peak_load_mw"`
+	PeakGenerationMW        float64    `json:"peak_generation_mw"`
+	LossOfLoadResults       int64      `json:"loss_of_load_results"`
+	LossOfLoadHours         float64    `json:"loss_of_load_hours"`
+	UnservedEnergyMWh       float64    `json:"unserved_energy_mwh"`
+	MaxFrequencyDeviationHz float64    `json:"max_frequency_deviation_hz"`
+	FrequencyExcursions     int        `json:"frequency_excursions"`
+	Error                   string     `json:"error,omitempty"`
+	CompletedAt             *time.Time `json:"completed_at,omitempty"`
+
+	config SimulationConfig
+}
+
+// BatchReport aggregates outcome statistics across completed instances.
+// Missing instances recorded no results and are left out of the statistics.
+type BatchReport struct {
+	Instances               int     `json:"instances"`
+	Completed               int     `json:"completed"`
+	Failed                  int     `json:"failed"`
+	Missing                 int     `json:"missing"`
+	Pending                 int     `json:"pending"`
+	Running                 int     `json:"running"`
+	LOLP                    float64 `json:"lolp"`
+	LOLEHours               float64 `json:"lole_hours"`
+	ExpectedUnservedMWh     float64 `json:"expected_unserved_energy_mwh"`
+	InstancesWithLossOfLoad int     `json:"instances_with_loss_of_load"`
+	FrequencyExcursions     int     `json:"frequency_excursions"`
+	MeanFrequencyExcursions float64 `json:"mean_frequency_excursions"`
+	MaxFrequencyDeviationHz float64 `json:"max_frequency_deviation_hz"`
+	P95FrequencyDeviationHz float64 `json:"p95_frequency_deviation_hz"`
+	AssessmentMethodology   string  `json:"assessment_methodology"`
+}
+
+// Batch is a Monte Carlo set of simulations sampled from a base config
+type Batch struct {
+	ID            string           `json:"id"`
+	Name          string           `json:"name"`
+	Status        string           `json:"status"`
+	BaseConfig    SimulationConfig `json:"base_config"`
+	Parameters    BatchParameters  `json:"parameters"`
+	MaxConcurrent int              `json:"max_concurrent"`
+	Tags          []string         `json:"tags"`
+	Instances     []*BatchInstance `json:"instances"`
+	Report        BatchReport      `json:"report"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+
+	// Set when the batch runs the grid points of a parameter sweep experiment
+	experimentID string
+}
+
+// RunOutcome is how well a finished run's generation covered its load,
+// taken from the results it recorded
+type RunOutcome struct {
+	Results                 int64
+	PeakLoadMW              float64
+	PeakGenerationMW        float64
+	LossOfLoadResults       int64
+	LossOfLoadHours         float64
+	UnservedEnergyMWh       float64
+	MaxFrequencyDeviationHz float64
+	FrequencyExcursions     int64
+}
+
+// RunOutcomeSource reads the outcome of a finished run from its recorded
+// results. Deviations from the base frequency beyond the tolerance count as
+// excursions.
+type RunOutcomeSource func(ctx context.Context, simulationID string, baseFrequencyHz, toleranceHz float64) (*RunOutcome, error)
+
+// SetRunOutcomes sets where batch instance outcomes are read from. Without
+// it every finished instance is reported as missing.
+func (o *Orchestrator) SetRunOutcomes(source RunOutcomeSource) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.runOutcomes = source
+}
+
+// CreateBatch samples n instances from the base config and starts running them,
+// at most maxConcurrent at a time. Instance simulations are created when launched
+// and removed once their outcome has been recorded.
+func (o *Orchestrator) CreateBatch(name string, base SimulationConfig, params BatchParameters, n, maxConcurrent int, tags []string) (*Batch, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("batch must have at least one instance")
+	}
+	if params.LoadStdDev < 0 || params.PlantFailureProbability < 0 || params.PlantFailureProbability > 1 ||
+		params.LineFailureProbability < 0 || params.LineFailureProbability > 1 {
+		return nil, fmt.Errorf("invalid batch parameters: deviations must be non-negative and probabilities within [0, 1]")
+	}
+	for id, p := range params.ComponentFailureProbabilities {
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("invalid failure probability for component %s: %v", id, p)
+		}
+	}
+	if params.FrequencyToleranceHz <= 0 {
+		params.FrequencyToleranceHz = defaultFrequencyToleranceHz
+	}
+	if params.Seed == 0 {
+		params.Seed = uint64(time.Now().UnixNano())
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if maxConcurrent <= 0 || maxConcurrent > o.config.WorkerPoolSize {
+		maxConcurrent = o.config.WorkerPoolSize
+	}
+
+	rng := rand.New(rand.NewPCG(params.Seed, params.Seed^0x9e3779b97f4a7c15))
+	batch := &Batch{
+		ID:            o.newIDLocked(),
+		Name:          name,
+		Status:        BatchRunning,
+		BaseConfig:    base,
+		Parameters:    params,
+		MaxConcurrent: maxConcurrent,
+		Tags:          tags,
+		Instances:     make([]*BatchInstance, n),
+		CreatedAt:     time.Now(),
+	}
+	for i := range batch.Instances {
+		batch.Instances[i] = sampleInstance(i, base, params, rng)
+	}
+
+	o.batches[batch.ID] = batch
+	o.launchBatchInstancesLocked(batch)
+	o.refreshBatchReportLocked(batch)
+
+	logrus.WithFields(logrus.Fields{
+		"batch_id":       batch.ID,
+		"instances":      n,
+		"max_concurrent": maxConcurrent,
+		"seed":           params.Seed,
+	}).Info("Monte Carlo batch created")
+
+	return batch.snapshot(), nil
+}
+
+// GetBatch retrieves a batch by ID
+func (o *Orchestrator) GetBatch(id string) (*Batch, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	batch, exists := o.batches[id]
+	if !exists || batch.experimentID != "" {
+		return nil, ErrBatchNotFound
+	}
+
+	return batch.snapshot(), nil
+}
+
+// ListBatches returns all batches, newest first
+func (o *Orchestrator) ListBatches() []*Batch {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	batches := make([]*Batch, 0, len(o.batches))
+	for _, batch := range o.batches {
+		if batch.experimentID == "" {
+			batches = append(batches, batch.snapshot())
+		}
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].CreatedAt.After(batches[j].CreatedAt)
+	})
+
+	return batches
+}
+
+// CancelBatch stops launching pending instances and stops running ones
+func (o *Orchestrator) CancelBatch(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	batch, exists := o.batches[id]
+	if !exists || batch.experimentID != "" {
+		return ErrBatchNotFound
+	}
+	if err := o.cancelBatchLocked(batch); err != nil {
+		return err
+	}
+
+	logrus.WithField("batch_id", id).Info("Monte Carlo batch cancelled")
+	return nil
+}
+
+// cancelBatchLocked cancels pending instances and stops running ones
+// (must be called with lock held)
+func (o *Orchestrator) cancelBatchLocked(batch *Batch) error {
+	if batch.Status != BatchRunning {
+		return fmt.Errorf("batch is not running, current status: %s", batch.Status)
+	}
+
+	for _, instance := range batch.Instances {
+		switch instance.Status {
+		case InstancePending:
+			instance.Status = InstanceCancelled
+		case InstanceRunning:
+			if simulation, ok := o.simulations[instance.SimulationID]; ok && (simulation.Status == StatusRunning || simulation.Status == StatusQueued) {
+				if err := o.stopSimulationInternal(instance.SimulationID, StopGraceful); err != nil {
+					logrus.WithError(err).WithField("simulation_id", instance.SimulationID).Warn("Failed to stop batch instance")
+				}
+			}
+			o.discardLocked(instance.SimulationID)
+			delete(o.batchOf, instance.SimulationID)
+			instance.Status = InstanceCancelled
+		}
+	}
+
+	batch.Status = BatchCancelled
+	now := time.Now()
+	batch.CompletedAt = &now
+	o.refreshBatchReportLocked(batch)
+
+	return nil
+}
+
+// completeBatchInstanceLocked records the outcome of a finished batch instance
+// (must be called with lock held)
+func (o *Orchestrator) completeBatchInstanceLocked(simulation *Simulation) {
+	batchID, ok := o.batchOf[simulation.ID]
+	if !ok {
+		return
+	}
+	delete(o.batchOf, simulation.ID)
+	delete(o.simulations, simulation.ID)
+
+	batch := o.batches[batchID]
+	if batch == nil {
+		return
+	}
+
+	for _, instance := range batch.Instances {
+		if instance.SimulationID != simulation.ID || instance.Status != InstanceRunning {
+			continue
+		}
+		if simulation.Status == StatusError {
+			instance.Status = InstanceFailed
+			if simulation.Error != nil {
+				instance.Error = simulation.Error.Error()
+			}
+			now := time.Now()
+			instance.CompletedAt = &now
+			continue
+		}
+
+		// Reading the recorded results cannot happen under the lock; the
+		// instance counts as running until they are in
+		baseFrequency := simulation.Config.BaseFrequency
+		if baseFrequency <= 0 {
+			baseFrequency = defaultBaseFrequencyHz
+		}
+		go o.recordInstanceOutcome(batch, instance, o.runOutcomes, simulation.ID, baseFrequency, batch.Parameters.FrequencyToleranceHz)
+	}
+}
+
+// recordInstanceOutcome scores a finished instance from the results its run
+// recorded. Instances without results are reported as missing rather than
+// given an outcome.
+func (o *Orchestrator) recordInstanceOutcome(batch *Batch, instance *BatchInstance, source RunOutcomeSource, simulationID string, baseFrequency, toleranceHz float64) {
+	var outcome *RunOutcome
+	var err error
+	if source != nil {
+		ctx, cancel := context.WithTimeout(o.ctx, runOutcomeTimeout)
+		outcome, err = source(ctx, simulationID, baseFrequency, toleranceHz)
+		cancel()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// Cancelled while the results were read
+	if instance.SimulationID != simulationID || instance.Status != InstanceRunning {
+		return
+	}
+
+	switch {
+	case err != nil:
+		instance.Status = InstanceMissing
+		instance.Error = fmt.Sprintf("failed to read recorded results: %v", err)
+		logrus.WithError(err).WithField("simulation_id", simulationID).Warn("Failed to read batch instance results")
+	case outcome == nil || outcome.Results == 0:
+		instance.Status = InstanceMissing
+		instance.Error = "run recorded no results"
+	default:
+		instance.Status = InstanceCompleted
+		instance.Results = outcome.Results
+		instance.PeakLoadMW = outcome.PeakLoadMW
+		instance.PeakGenerationMW = outcome.PeakGenerationMW
+		instance.LossOfLoadResults = outcome.LossOfLoadResults
+		instance.LossOfLoadHours = outcome.LossOfLoadHours
+		instance.UnservedEnergyMWh = outcome.UnservedEnergyMWh
+		instance.MaxFrequencyDeviationHz = outcome.MaxFrequencyDeviationHz
+		instance.FrequencyExcursions = int(outcome.FrequencyExcursions)
+	}
+	now := time.Now()
+	instance.CompletedAt = &now
+
+	o.advanceBatchesLocked()
+}
+
+// advanceBatchesLocked launches pending instances of running batches and
+// finalizes finished ones (must be called with lock held)
+func (o *Orchestrator) advanceBatchesLocked() {
+	for _, batch := range o.batches {
+		if batch.Status != BatchRunning {
+			continue
+		}
+		o.launchBatchInstancesLocked(batch)
+		o.refreshBatchReportLocked(batch)
+
+		if batch.Report.Pending == 0 && batch.Report.Running == 0 {
+			batch.Status = BatchCompleted
+			now := time.Now()
+			batch.CompletedAt = &now
+
+			if batch.experimentID != "" {
+				logrus.WithField("experiment_id", batch.experimentID).Info("Parameter sweep experiment completed")
+				continue
+			}
+			logrus.WithFields(logrus.Fields{
+				"batch_id":             batch.ID,
+				"lolp":                 batch.Report.LOLP,
+				"frequency_excursions": batch.Report.FrequencyExcursions,
+			}).Info("Monte Carlo batch completed")
+		}
+	}
+}
+
+// launchBatchInstancesLocked starts pending instances up to the batch's
+// concurrency limit (must be called with lock held)
+func (o *Orchestrator) launchBatchInstancesLocked(batch *Batch) {
+	running := 0
+	for _, instance := range batch.Instances {
+		if instance.Status == InstanceRunning {
+			running++
+		}
+	}
+
+	for _, instance := range batch.Instances {
+		if running >= batch.MaxConcurrent {
+			return
+		}
+		if instance.Status != InstancePending {
+			continue
+		}
+
+		description, tags, metadata := "Monte Carlo batch instance", []string{"batch:" + batch.ID}, map[string]interface{}{
+			"batch_id":       batch.ID,
+			"batch_instance": instance.Index,
+		}
+		if batch.experimentID != "" {
+			description, tags, metadata = "Parameter sweep run", []string{"experiment:" + batch.experimentID}, map[string]interface{}{
+				"experiment_id":  batch.experimentID,
+				"experiment_run": instance.Index,
+			}
+		}
+
+		simulation, err := o.createSimulationLocked(
+			o.ctx,
+			fmt.Sprintf("%s #%d", batch.Name, instance.Index+1),
+			description,
+			instance.config,
+			append(tags, batch.Tags...),
+			metadata,
+		)
+		if err != nil {
+			// Orchestrator is at capacity; retry when another simulation completes
+			return
+		}
+
+		o.batchOf[simulation.ID] = batch.ID
+		instance.SimulationID = simulation.ID
+		instance.Status = InstanceRunning
+
+		if err := o.startSimulationInternal(o.ctx, simulation.ID); err != nil {
+			delete(o.batchOf, simulation.ID)
+			o.discardLocked(simulation.ID)
+			instance.Status = InstanceFailed
+			instance.Error = err.Error()
+			continue
+		}
+		running++
+	}
+}
+
+// refreshBatchReportLocked recomputes batch statistics (must be called with lock held)
+func (o *Orchestrator) refreshBatchReportLocked(batch *Batch) {
+	report := BatchReport{
+		Instances:             len(batch.Instances),
+		AssessmentMethodology: "recorded_results",
+	}
+
+	var results, lossResults int64
+	var deviations []float64
+	for _, instance := range batch.Instances {
+		switch instance.Status {
+		case InstancePending:
+			report.Pending++
+		case InstanceRunning:
+			report.Running++
+		case InstanceFailed:
+			report.Failed++
+		case InstanceMissing:
+			report.Missing++
+		case InstanceCompleted:
+			report.Completed++
+			results += instance.Results
+			lossResults += instance.LossOfLoadResults
+			report.LOLEHours += instance.LossOfLoadHours
+			report.ExpectedUnservedMWh += instance.UnservedEnergyMWh
+			report.FrequencyExcursions += instance.FrequencyExcursions
+			if instance.LossOfLoadResults > 0 {
+				report.InstancesWithLossOfLoad++
+			}
+			deviations = append(deviations, instance.MaxFrequencyDeviationHz)
+		}
+	}
+
+	if report.Completed > 0 {
+		completed := float64(report.Completed)
+		// Completed instances have at least one result each
+		report.LOLP = float64(lossResults) / float64(results)
+		report.LOLEHours /= completed
+		report.ExpectedUnservedMWh /= completed
+		report.MeanFrequencyExcursions = float64(report.FrequencyExcursions) / completed
+
+		sort.Float64s(deviations)
+		report.MaxFrequencyDeviationHz = deviations[len(deviations)-1]
+		report.P95FrequencyDeviationHz = deviations[int(math.Ceil(0.95*float64(len(deviations))))-1]
+	}
+
+	batch.Report = report
+}
+
+// snapshot copies a batch so it can be read after the lock is released
+// (must be called with lock held)
+func (b *Batch) snapshot() *Batch {
+	copied := *b
+	copied.Instances = make([]*BatchInstance, len(b.Instances))
+	for i, instance := range b.Instances {
+		instanceCopy := *instance
+		copied.Instances[i] = &instanceCopy
+	}
+	return &copied
+}
+
+// sampleInstance draws load scaling and component outages for one instance
+func sampleInstance(index int, base SimulationConfig, params BatchParameters, rng *rand.Rand) *BatchInstance {
+	instance := &BatchInstance{
+		Index:            index,
+		Status:           InstancePending,
+		LoadFactor:       math.Max(0, 1+rng.NormFloat64()*params.LoadStdDev),
+		FailedComponents: []string{},
+	}
+
+	config := *cloneConfig(base)
+	config.LoadProfile.BaseLoadMW = base.LoadProfile.BaseLoadMW * instance.LoadFactor
+
+	for i := range config.PowerPlants {
+		plant := &config.PowerPlants[i]
+		if plant.IsOperational && rng.Float64() < failureProbability(params, plant.ID, params.PlantFailureProbability) {
+			plant.IsOperational = false
+			instance.FailedComponents = append(instance.FailedComponents, plant.ID)
+		}
+	}
+	for i := range config.TransmissionLines {
+		line := &config.TransmissionLines[i]
+		if line.IsOperational && rng.Float64() < failureProbability(params, line.ID, params.LineFailureProbability) {
+			line.IsOperational = false
+			instance.FailedComponents = append(instance.FailedComponents, line.ID)
+		}
+	}
+
+	// Each instance gets its own seed, fixed by the batch seed
+	config.RandomSeed = params.Seed + uint64(index) + 1
+
+	instance.config = config
+	return instance
+}
+
+// failureProbability returns the outage probability of a component
+func failureProbability(params BatchParameters, id string, fallback float64) float64 {
+	if p, ok := params.ComponentFailureProbabilities[id]; ok {
+		return p
+	}
+	return fallback
+}
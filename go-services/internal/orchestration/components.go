@@ -0,0 +1,223 @@
+package orchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TopologyChange adds power plants and transmission lines to a running
+// simulation and removes them from it. A component removed and added with
+// the same ID is replaced.
+type TopologyChange struct {
+	AddPowerPlants          []PowerPlantConfig       `json:"add_power_plants,omitempty"`
+	AddTransmissionLines    []TransmissionLineConfig `json:"add_transmission_lines,omitempty"`
+	RemovePowerPlants       []string                 `json:"remove_power_plants,omitempty"`
+	RemoveTransmissionLines []string                 `json:"remove_transmission_lines,omitempty"`
+}
+
+// Empty reports whether the change adds or removes nothing
+func (t TopologyChange) Empty() bool {
+	return len(t.AddPowerPlants) == 0 && len(t.AddTransmissionLines) == 0 &&
+		len(t.RemovePowerPlants) == 0 && len(t.RemoveTransmissionLines) == 0
+}
+
+// Apply returns the config with the change made, checking that removed
+// components exist, added ones do not, and the resulting topology is valid
+func (t TopologyChange) Apply(config SimulationConfig) (SimulationConfig, error) {
+	if t.Empty() {
+		return config, fmt.Errorf("%w: nothing to add or remove", ErrInvalidTopology)
+	}
+
+	plants, err := removeComponents(config.PowerPlants, t.RemovePowerPlants, "power plant", func(p PowerPlantConfig) string { return p.ID })
+	if err != nil {
+		return config, err
+	}
+	plants, err = addComponents(plants, t.AddPowerPlants, "power plant", func(p PowerPlantConfig) string { return p.ID })
+	if err != nil {
+		return config, err
+	}
+
+	lines, err := removeComponents(config.TransmissionLines, t.RemoveTransmissionLines, "transmission line", func(l TransmissionLineConfig) string { return l.ID })
+	if err != nil {
+		return config, err
+	}
+	for _, line := range t.AddTransmissionLines {
+		if line.FromNode == line.ToNode {
+			return config, fmt.Errorf("%w: transmission line %s connects %s to itself", ErrInvalidTopology, line.ID, line.FromNode)
+		}
+	}
+	lines, err = addComponents(lines, t.AddTransmissionLines, "transmission line", func(l TransmissionLineConfig) string { return l.ID })
+	if err != nil {
+		return config, err
+	}
+
+	changed := config
+	changed.PowerPlants = plants
+	changed.TransmissionLines = lines
+	if err := changed.ValidateTopology(); err != nil {
+		return config, err
+	}
+	return changed, nil
+}
+
+// removeComponents returns a copy of components without the ones with the
+// given IDs, all of which have to exist
+func removeComponents[T any](components []T, ids []string, kind string, id func(T) string) ([]T, error) {
+	remove := make(map[string]bool, len(ids))
+	for _, componentID := range ids {
+		if remove[componentID] {
+			return nil, fmt.Errorf("%w: %s %s is removed twice", ErrInvalidTopology, kind, componentID)
+		}
+		remove[componentID] = true
+	}
+
+	kept := make([]T, 0, len(components))
+	for _, component := range components {
+		if remove[id(component)] {
+			delete(remove, id(component))
+			continue
+		}
+		kept = append(kept, component)
+	}
+	for _, componentID := range ids {
+		if remove[componentID] {
+			return nil, fmt.Errorf("%w: %s %s does not exist", ErrInvalidTopology, kind, componentID)
+		}
+	}
+	return kept, nil
+}
+
+// addComponents appends components whose IDs are not taken yet
+func addComponents[T any](components, added []T, kind string, id func(T) string) ([]T, error) {
+	taken := make(map[string]bool, len(components)+len(added))
+	for _, component := range components {
+		taken[id(component)] = true
+	}
+	for _, component := range added {
+		if id(component) == "" {
+			return nil, fmt.Errorf("%w: %s id is required", ErrInvalidTopology, kind)
+		}
+		if taken[id(component)] {
+			return nil, fmt.Errorf("%w: %s %s already exists", ErrInvalidTopology, kind, id(component))
+		}
+		taken[id(component)] = true
+	}
+	return append(components, added...), nil
+}
+
+// ModifyTopology adds and removes components of a running or paused
+// simulation. The engine makes the change first, without holding the lock;
+// the stored config and components follow once it succeeded. The engine is
+// put back on the stored config when they cannot be stored, or when the
+// simulation was modified in the meantime, which fails with
+// ErrVersionConflict.
+func (o *Orchestrator) ModifyTopology(ctx context.Context, id string, change TopologyChange) (*Simulation, error) {
+	o.mu.Lock()
+	simulation, config, err := o.prepareTopologyLocked(ctx, id, change)
+	if err != nil {
+		o.mu.Unlock()
+		return nil, err
+	}
+	controller := o.engineController
+	version := simulation.Version
+	o.mu.Unlock()
+
+	raw, err := json.Marshal(change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode topology change: %w", err)
+	}
+
+	engineCtx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+	defer cancel()
+
+	if err := controller.ModifySimulationTopology(engineCtx, id, string(raw)); err != nil {
+		return nil, fmt.Errorf("failed to change topology on engine: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if current, exists := o.simulations[id]; !exists || current != simulation || simulation.Version != version {
+		o.revertEngineConfigLocked(simulation, ErrVersionConflict)
+		return nil, ErrVersionConflict
+	}
+
+	previous := *simulation
+	simulation.Config = config
+	simulation.Version++
+	simulation.UpdatedAt = time.Now()
+	if err := o.saveConfigLocked(ctx, simulation); err != nil {
+		*simulation = previous
+		o.revertEngineConfigLocked(simulation, err)
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id":  id,
+		"plants_added":   len(change.AddPowerPlants),
+		"plants_removed": len(change.RemovePowerPlants),
+		"lines_added":    len(change.AddTransmissionLines),
+		"lines_removed":  len(change.RemoveTransmissionLines),
+		"version":        simulation.Version,
+	}).Info("Simulation topology modified")
+
+	return simulation, nil
+}
+
+// prepareTopologyLocked checks a topology change can be made to a simulation
+// and returns the simulation with the config it would have (must be called
+// with lock held)
+func (o *Orchestrator) prepareTopologyLocked(ctx context.Context, id string, change TopologyChange) (*Simulation, SimulationConfig, error) {
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, SimulationConfig{}, err
+	}
+
+	if simulation.Status != StatusRunning && simulation.Status != StatusPaused {
+		return nil, SimulationConfig{}, fmt.Errorf("%w, current status: %s", ErrSimulationNotActive, simulation.Status.String())
+	}
+
+	config, err := change.Apply(simulation.Config)
+	if err != nil {
+		return nil, SimulationConfig{}, err
+	}
+
+	// The new components have to run on the engine the simulation runs on
+	if o.engines != nil && simulation.Engine != "" {
+		if err := o.engines.Validate(simulation.Engine, config.Requirements()); err != nil {
+			return nil, SimulationConfig{}, err
+		}
+	}
+
+	if o.engineController == nil {
+		return nil, SimulationConfig{}, ErrNoEngineController
+	}
+
+	return simulation, config, nil
+}
+
+// revertEngineConfigLocked puts the engine back on a simulation's stored
+// config after a change it already made could not be stored. When that
+// fails as well, the run is marked as diverged from its stored config
+// (must be called with lock held).
+func (o *Orchestrator) revertEngineConfigLocked(simulation *Simulation, cause error) {
+	logger := logrus.WithError(cause).WithField("simulation_id", simulation.ID)
+
+	raw, err := json.Marshal(simulation.Config)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(o.ctx, engineControlTimeout)
+		err = o.engineController.UpdateSimulationConfig(ctx, simulation.ID, string(raw))
+		cancel()
+	}
+	if err != nil {
+		simulation.Diverged = true
+		logger.WithField("revert_error", err.Error()).Error("Failed to revert engine config after storing the change failed, run has diverged")
+		return
+	}
+
+	logger.Warn("Reverted engine config after storing the change failed")
+}
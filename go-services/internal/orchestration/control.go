@@ -0,0 +1,120 @@
+package orchestration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MaxStepTicks caps how far a single step request may advance a simulation
+const MaxStepTicks = 10000
+
+// engineControlTimeout bounds a single control call to the engine
+const engineControlTimeout = 5 * time.Second
+
+// EngineController controls simulations while they run on an engine
+type EngineController interface {
+	// SetSimulationSpeed applies a real-time factor to a running simulation
+	SetSimulationSpeed(ctx context.Context, simulationID string, speed float64) error
+	// StepSimulation advances a paused simulation by ticks and returns its state
+	StepSimulation(ctx context.Context, simulationID string, ticks int) (map[string]interface{}, error)
+	// DumpSimulationState returns the internal state of every component
+	DumpSimulationState(ctx context.Context, simulationID string) (map[string]interface{}, error)
+	// UpdateSimulationConfig replaces the config of a running or paused
+	// simulation with a JSON encoded one
+	UpdateSimulationConfig(ctx context.Context, simulationID string, config string) error
+	// ModifySimulationTopology adds and removes components of a running or
+	// paused simulation, given as a JSON encoded TopologyChange
+	ModifySimulationTopology(ctx context.Context, simulationID string, change string) error
+}
+
+// StepResult is the state of a simulation after stepping it
+type StepResult struct {
+	SimulationID string                 `json:"simulation_id"`
+	Ticks        int                    `json:"ticks"`
+	State        map[string]interface{} `json:"state"`
+}
+
+// SetEngineController sets how running simulations are controlled on the engine
+func (o *Orchestrator) SetEngineController(controller EngineController) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.engineController = controller
+}
+
+// StepSimulation advances a paused simulation by the given number of ticks,
+// leaving it paused, and returns the resulting state
+func (o *Orchestrator) StepSimulation(ctx context.Context, id string, ticks int) (*StepResult, error) {
+	if ticks < 1 || ticks > MaxStepTicks {
+		return nil, ErrInvalidStep
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, exists := o.simulations[id]
+	if !exists {
+		return nil, ErrSimulationNotFound
+	}
+
+	if simulation.Status != StatusPaused {
+		return nil, fmt.Errorf("simulation must be paused to step, current status: %s", simulation.Status.String())
+	}
+
+	if o.engineController == nil {
+		return nil, ErrNoEngineController
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+	defer cancel()
+
+	state, err := o.engineController.StepSimulation(ctx, id, ticks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to step simulation: %w", err)
+	}
+
+	simulation.UpdatedAt = time.Now()
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"ticks":         ticks,
+	}).Debug("Simulation stepped")
+
+	return &StepResult{
+		SimulationID: id,
+		Ticks:        ticks,
+		State:        state,
+	}, nil
+}
+
+// DumpState asks the engine for the complete state of a running or paused simulation
+func (o *Orchestrator) DumpState(ctx context.Context, id string) (map[string]interface{}, error) {
+	o.mu.RLock()
+	simulation, exists := o.simulations[id]
+	if !exists {
+		o.mu.RUnlock()
+		return nil, ErrSimulationNotFound
+	}
+	status := simulation.Status
+	controller := o.engineController
+	o.mu.RUnlock()
+
+	if status != StatusRunning && status != StatusPaused {
+		return nil, fmt.Errorf("simulation has no engine state, current status: %s", status.String())
+	}
+	if controller == nil {
+		return nil, ErrNoEngineController
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+	defer cancel()
+
+	state, err := controller.DumpSimulationState(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump simulation state: %w", err)
+	}
+	return state, nil
+}
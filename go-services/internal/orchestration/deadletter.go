@@ -0,0 +1,224 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/engine"
+)
+
+// defaultDeadLetterSize is how many failed jobs are kept when the config
+// does not say
+const defaultDeadLetterSize = 500
+
+// DeadLetter is a simulation job that failed for good, kept so it can be
+// inspected and retried
+type DeadLetter struct {
+	ID           string `json:"id"`
+	SimulationID string `json:"simulation_id"`
+	Name         string `json:"name"`
+	Error        string `json:"error"`
+	// Whether the error was transient; transient failures are only dead
+	// lettered once their automatic retries run out
+	Transient bool `json:"transient"`
+	Attempts  int  `json:"attempts"`
+	// The config the failed run was started with
+	Config    SimulationConfig  `json:"config"`
+	Engine    EngineDiagnostics `json:"engine"`
+	FailedAt  time.Time         `json:"failed_at"`
+	RetriedAt *time.Time        `json:"retried_at,omitempty"`
+}
+
+// EngineDiagnostics is what the engine had done with a run when it failed
+type EngineDiagnostics struct {
+	Engine      string `json:"engine,omitempty"`
+	EngineRunID string `json:"engine_run_id,omitempty"`
+	// The engine's load at the time, when it is registered
+	Status          *engine.Status `json:"status,omitempty"`
+	Ticks           int64          `json:"ticks"`
+	EventsProcessed int64          `json:"events_processed"`
+	AvgTickTimeMS   float64        `json:"avg_tick_time_ms"`
+	MemoryUsageMB   int64          `json:"memory_usage_mb"`
+}
+
+// IsTransient reports whether a job failure is worth retrying: errors
+// wrapping ErrTransient and engine calls that timed out
+func IsTransient(err error) bool {
+	return errors.Is(err, ErrTransient) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// failJobLocked handles a job that failed on its run. A transient failure
+// with retries left queues the run again after a backoff; anything else
+// fails the simulation and dead letters the job. (must be called with lock held)
+func (o *Orchestrator) failJobLocked(simulation *Simulation, job *SimulationJob, cause error) {
+	diagnostics := o.engineDiagnosticsLocked(simulation)
+	o.releaseEngineLocked(simulation)
+
+	transient := IsTransient(cause)
+	if transient && job.Attempt <= o.config.JobMaxRetries {
+		backoff := o.config.JobRetryBackoff << min(job.Attempt-1, 20)
+		if backoff <= 0 || backoff > o.config.JobMaxBackoff {
+			backoff = o.config.JobMaxBackoff
+		}
+
+		if simulation.Status != StatusQueued {
+			o.transitionLocked(simulation, StatusQueued, cause)
+		}
+		retry := *job
+		retry.Attempt++
+		retry.Err = nil
+		time.AfterFunc(backoff, func() { o.retryJob(&retry) })
+
+		logrus.WithError(cause).WithFields(logrus.Fields{
+			"simulation_id": simulation.ID,
+			"attempt":       job.Attempt,
+			"backoff":       backoff,
+		}).Warn("Simulation job failed, retrying")
+		return
+	}
+
+	o.releaseRunLeaseLocked(simulation)
+	o.transitionLocked(simulation, StatusError, cause)
+	o.addDeadLetterLocked(&DeadLetter{
+		ID:           o.newIDLocked(),
+		SimulationID: simulation.ID,
+		Name:         simulation.Name,
+		Error:        cause.Error(),
+		Transient:    transient,
+		Attempts:     job.Attempt,
+		Config:       job.Config,
+		Engine:       diagnostics,
+		FailedAt:     time.Now(),
+	})
+
+	logrus.WithError(cause).WithFields(logrus.Fields{
+		"simulation_id": simulation.ID,
+		"attempts":      job.Attempt,
+	}).Error("Simulation job failed, moved to dead letter")
+}
+
+// retryJob resubmits a job after its backoff, unless the run was stopped or
+// started again in the meantime
+func (o *Orchestrator) retryJob(job *SimulationJob) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, exists := o.simulations[job.SimulationID]
+	if !exists || job.Run != simulation.run || simulation.Status != StatusQueued {
+		return
+	}
+
+	if err := o.workerPool.SubmitJob(job); err != nil {
+		o.failJobLocked(simulation, job, fmt.Errorf("failed to resubmit simulation job: %w", err))
+		o.completeBatchInstanceLocked(simulation)
+		o.startReadyDependentsLocked()
+		o.advanceBatchesLocked()
+	}
+}
+
+// engineDiagnosticsLocked records the state of a simulation's run on its
+// engine (must be called with lock held)
+func (o *Orchestrator) engineDiagnosticsLocked(simulation *Simulation) EngineDiagnostics {
+	diagnostics := EngineDiagnostics{
+		Engine:          simulation.Engine,
+		EngineRunID:     simulation.engineRunID,
+		Ticks:           simulation.ticksMeasured,
+		EventsProcessed: simulation.EventsProcessed,
+		AvgTickTimeMS:   simulation.AvgTickTime,
+		MemoryUsageMB:   simulation.MemoryUsage,
+	}
+	if o.engines != nil && simulation.Engine != "" {
+		if status, err := o.engines.Get(simulation.Engine); err == nil {
+			diagnostics.Status = &status
+		}
+	}
+	return diagnostics
+}
+
+// addDeadLetterLocked stores a failed job, dropping the oldest once the
+// store is full (must be called with lock held)
+func (o *Orchestrator) addDeadLetterLocked(letter *DeadLetter) {
+	size := o.config.DeadLetterSize
+	if size <= 0 {
+		size = defaultDeadLetterSize
+	}
+	if len(o.deadLetters) >= size {
+		o.deadLetters = o.deadLetters[len(o.deadLetters)-size+1:]
+	}
+	o.deadLetters = append(o.deadLetters, letter)
+}
+
+// ListDeadLetters returns the failed jobs, newest first
+func (o *Orchestrator) ListDeadLetters() []*DeadLetter {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	letters := make([]*DeadLetter, 0, len(o.deadLetters))
+	for i := len(o.deadLetters) - 1; i >= 0; i-- {
+		letter := *o.deadLetters[i]
+		letters = append(letters, &letter)
+	}
+	return letters
+}
+
+// GetDeadLetter returns a failed job by ID
+func (o *Orchestrator) GetDeadLetter(id string) (*DeadLetter, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	letter := o.deadLetterLocked(id)
+	if letter == nil {
+		return nil, ErrDeadLetterNotFound
+	}
+	result := *letter
+	return &result, nil
+}
+
+// RetryDeadLetter starts a failed job's simulation again with its current
+// config. The job stays in the store with the time it was retried.
+func (o *Orchestrator) RetryDeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	o.mu.RLock()
+	var simulationID string
+	if letter := o.deadLetterLocked(id); letter != nil {
+		simulationID = letter.SimulationID
+	}
+	o.mu.RUnlock()
+	if simulationID != "" {
+		o.loadConcurrencyState(ctx, simulationID)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	letter := o.deadLetterLocked(id)
+	if letter == nil {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err := o.startSimulationInternal(ctx, letter.SimulationID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	letter.RetriedAt = &now
+	result := *letter
+
+	logrus.WithFields(logrus.Fields{
+		"dead_letter_id": id,
+		"simulation_id":  letter.SimulationID,
+	}).Info("Dead-lettered simulation job retried")
+	return &result, nil
+}
+
+// deadLetterLocked finds a failed job by ID (must be called with lock held)
+func (o *Orchestrator) deadLetterLocked(id string) *DeadLetter {
+	for _, letter := range o.deadLetters {
+		if letter.ID == id {
+			return letter
+		}
+	}
+	return nil
+}
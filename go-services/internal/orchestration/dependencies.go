@@ -0,0 +1,310 @@
+package orchestration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Dependency declares that a simulation must wait for another one to complete
+type Dependency struct {
+	SimulationID string `json:"simulation_id" binding:"required"`
+	HandoffState bool   `json:"handoff_state"`
+}
+
+// PipelineNode represents a simulation in a dependency pipeline
+type PipelineNode struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// PipelineEdge represents a dependency between two simulations
+type PipelineEdge struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	HandoffState bool   `json:"handoff_state"`
+}
+
+// Pipeline is the dependency graph reachable from a simulation
+type Pipeline struct {
+	Root  string         `json:"root"`
+	Nodes []PipelineNode `json:"nodes"`
+	Edges []PipelineEdge `json:"edges"`
+}
+
+// SetDependencies declares the simulations that must complete before the given one starts.
+// Once every dependency has completed the simulation is started automatically.
+func (o *Orchestrator) SetDependencies(ctx context.Context, id string, deps []Dependency) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if simulation.Status != StatusIdle && simulation.Status != StatusWaiting {
+		return fmt.Errorf("dependencies can only be set before a simulation starts, current status: %s", simulation.Status.String())
+	}
+
+	for _, dep := range deps {
+		if dep.SimulationID == id {
+			return ErrDependencyCycle
+		}
+		if _, err := o.lookupLocked(ctx, dep.SimulationID); err != nil {
+			return fmt.Errorf("dependency %s: %w", dep.SimulationID, err)
+		}
+	}
+
+	previous := simulation.DependsOn
+	simulation.DependsOn = deps
+	if o.hasCycleLocked(id) {
+		simulation.DependsOn = previous
+		return ErrDependencyCycle
+	}
+	if err := o.saveLocked(ctx, simulation); err != nil {
+		simulation.DependsOn = previous
+		return err
+	}
+
+	status := StatusIdle
+	if len(deps) > 0 {
+		status = StatusWaiting
+	}
+	if simulation.Status != status {
+		o.transitionLocked(simulation, status, nil)
+	}
+	simulation.UpdatedAt = time.Now()
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"dependencies":  len(deps),
+	}).Info("Simulation dependencies updated")
+
+	// Dependencies may already be satisfied
+	o.startReadyDependentsLocked()
+
+	return nil
+}
+
+// Pipeline returns the dependency graph connected to the given simulation.
+// Simulations in it are loaded from the repository when they are not in
+// memory. Dependents are found among the simulations in memory, where
+// waiting ones are always kept.
+func (o *Orchestrator) Pipeline(ctx context.Context, id string) (*Pipeline, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	root, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[string]bool{id: true}
+	queue := []*Simulation{root}
+	pipeline := &Pipeline{Root: id}
+
+	for len(queue) > 0 {
+		sim := queue[0]
+		queue = queue[1:]
+
+		pipeline.Nodes = append(pipeline.Nodes, PipelineNode{
+			ID:     sim.ID,
+			Name:   sim.Name,
+			Status: sim.Status.String(),
+		})
+
+		// Both upstream and downstream runs are included
+		var adjacent []string
+		for _, dep := range sim.DependsOn {
+			pipeline.Edges = append(pipeline.Edges, PipelineEdge{
+				From:         dep.SimulationID,
+				To:           sim.ID,
+				HandoffState: dep.HandoffState,
+			})
+			adjacent = append(adjacent, dep.SimulationID)
+		}
+		for otherID, other := range o.simulations {
+			for _, dep := range other.DependsOn {
+				if dep.SimulationID == sim.ID {
+					adjacent = append(adjacent, otherID)
+				}
+			}
+		}
+
+		for _, next := range adjacent {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+
+			nextSim, err := o.lookupLocked(ctx, next)
+			if errors.Is(err, ErrSimulationNotFound) {
+				// A deleted upstream keeps its edge but has no node
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			queue = append(queue, nextSim)
+		}
+	}
+
+	return pipeline, nil
+}
+
+// hasCycleLocked reports whether the dependency graph reachable from id contains a cycle
+// (must be called with lock held)
+func (o *Orchestrator) hasCycleLocked(id string) bool {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := make(map[string]int)
+
+	var visit func(string) bool
+	visit = func(current string) bool {
+		switch state[current] {
+		case inProgress:
+			return true
+		case done:
+			return false
+		}
+
+		state[current] = inProgress
+		if sim, ok := o.simulations[current]; ok {
+			for _, dep := range sim.DependsOn {
+				if visit(dep.SimulationID) {
+					return true
+				}
+			}
+		}
+		state[current] = done
+		return false
+	}
+
+	return visit(id)
+}
+
+// startReadyDependentsLocked starts waiting simulations whose dependencies have all completed
+// (must be called with lock held)
+func (o *Orchestrator) startReadyDependentsLocked() {
+	for id, sim := range o.simulations {
+		if sim.Status != StatusWaiting {
+			continue
+		}
+
+		ready := true
+		failed := false
+		for _, dep := range sim.DependsOn {
+			upstream, err := o.lookupLocked(o.ctx, dep.SimulationID)
+			if errors.Is(err, ErrSimulationNotFound) {
+				// The upstream was deleted, so it will never complete
+				failed = true
+				break
+			}
+			if err != nil {
+				// Checked again on the next transition
+				ready = false
+				continue
+			}
+			if dependencyBroken(upstream) {
+				failed = true
+				break
+			}
+			if upstream.Status != StatusCompleted {
+				ready = false
+			}
+		}
+
+		if failed {
+			o.transitionLocked(sim, StatusError, ErrDependencyFailed)
+			logrus.WithField("simulation_id", id).Warn("Simulation dependency failed, run will not start")
+			continue
+		}
+
+		if !ready {
+			continue
+		}
+
+		// A tenant at its concurrency limit is only temporary, so the run
+		// keeps waiting and is tried again on the next transition
+		if err := o.checkCapacityLocked(sim); err != nil {
+			logrus.WithError(err).WithField("simulation_id", id).Debug("Dependent simulation is ready but at its concurrency limit")
+			continue
+		}
+
+		for _, dep := range sim.DependsOn {
+			if dep.HandoffState {
+				o.handoffStateLocked(o.simulations[dep.SimulationID], sim)
+			}
+		}
+
+		o.transitionLocked(sim, StatusIdle, nil)
+		if err := o.startSimulationInternal(o.ctx, id); err != nil {
+			if errors.Is(err, ErrConcurrencyLimit) {
+				o.transitionLocked(sim, StatusWaiting, nil)
+				continue
+			}
+			o.transitionLocked(sim, StatusError, err)
+			logrus.WithError(err).WithField("simulation_id", id).Error("Failed to start dependent simulation")
+			continue
+		}
+
+		logrus.WithField("simulation_id", id).Info("Dependent simulation started")
+	}
+}
+
+// dependencyBroken reports whether an upstream simulation will not complete
+// for its dependents: it failed, or its run was stopped or canceled
+func dependencyBroken(upstream *Simulation) bool {
+	switch upstream.Status {
+	case StatusError:
+		return true
+	case StatusCompleted, StatusIdle:
+		return upstream.StoppedReason != "" && upstream.StoppedReason != StopReasonCompleted
+	}
+	return false
+}
+
+// handoffStateLocked seeds the downstream simulation with the plant outputs
+// the engine last reported for the upstream run (must be called with lock
+// held)
+func (o *Orchestrator) handoffStateLocked(upstream, downstream *Simulation) {
+	outputs := make(map[string]float64, len(upstream.plantOutputs))
+	for number, output := range upstream.plantOutputs {
+		if number >= 1 && number <= len(upstream.Config.PowerPlants) {
+			outputs[upstream.Config.PowerPlants[number-1].ID] = output
+		}
+	}
+
+	for i, plant := range downstream.Config.PowerPlants {
+		if output, ok := outputs[plant.ID]; ok {
+			downstream.Config.PowerPlants[i].CurrentOutputMW = output
+		}
+	}
+
+	downstream.InitialState = map[string]interface{}{
+		"source_simulation_id": upstream.ID,
+		"plant_outputs_mw":     outputs,
+		"events_processed":     upstream.EventsProcessed,
+		"completed_at":         upstream.EndTime,
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"from_simulation_id": upstream.ID,
+		"to_simulation_id":   downstream.ID,
+		"plants":             len(outputs),
+	})
+	if len(outputs) == 0 {
+		logger.Warn("No plant outputs were reported for the upstream run, dependent keeps its configured outputs")
+		return
+	}
+	logger.Info("Handed off final state to dependent simulation")
+}
@@ -0,0 +1,167 @@
+package orchestration
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// maxDiffSeriesLength is the array length above which changed values are
+// summarized by length instead of being returned in full
+const maxDiffSeriesLength = 32
+
+// FieldChange is a single changed field. Nested fields use dotted paths.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// ComponentChange lists the changed fields of a component present in both configs
+type ComponentChange struct {
+	ID      string        `json:"id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// CollectionDiff describes how a set of components differs, matched by ID
+type CollectionDiff struct {
+	Added   []any             `json:"added"`
+	Removed []any             `json:"removed"`
+	Changed []ComponentChange `json:"changed"`
+}
+
+// Empty reports whether the collections are identical
+func (d CollectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// ConfigDiff is a structured diff between two simulation configs
+type ConfigDiff struct {
+	Identical         bool           `json:"identical"`
+	Grid              []FieldChange  `json:"grid"`
+	LoadProfile       []FieldChange  `json:"load_profile"`
+	PowerPlants       CollectionDiff `json:"power_plants"`
+	TransmissionLines CollectionDiff `json:"transmission_lines"`
+	StorageUnits      CollectionDiff `json:"storage_units"`
+	Buses             CollectionDiff `json:"buses"`
+}
+
+// DiffConfigs compares two configs, reporting what changed from base to other
+func DiffConfigs(base, other SimulationConfig) ConfigDiff {
+	diff := ConfigDiff{
+		Grid: diffFields(
+			map[string]any{"base_frequency": base.BaseFrequency, "base_voltage": base.BaseVoltage},
+			map[string]any{"base_frequency": other.BaseFrequency, "base_voltage": other.BaseVoltage},
+		),
+		LoadProfile:       diffFields(toFields(base.LoadProfile), toFields(other.LoadProfile)),
+		PowerPlants:       diffCollection(base.PowerPlants, other.PowerPlants, func(p PowerPlantConfig) string { return p.ID }),
+		TransmissionLines: diffCollection(base.TransmissionLines, other.TransmissionLines, func(l TransmissionLineConfig) string { return l.ID }),
+		StorageUnits:      diffCollection(base.StorageUnits, other.StorageUnits, func(u StorageUnitConfig) string { return u.ID }),
+		Buses:             diffCollection(base.Buses, other.Buses, func(b BusConfig) string { return b.ID }),
+	}
+
+	diff.Identical = len(diff.Grid) == 0 && len(diff.LoadProfile) == 0 &&
+		diff.PowerPlants.Empty() && diff.TransmissionLines.Empty() &&
+		diff.StorageUnits.Empty() && diff.Buses.Empty()
+
+	return diff
+}
+
+// diffCollection matches components by ID and reports additions, removals and changes
+func diffCollection[T any](base, other []T, id func(T) string) CollectionDiff {
+	diff := CollectionDiff{
+		Added:   []any{},
+		Removed: []any{},
+		Changed: []ComponentChange{},
+	}
+
+	baseByID := make(map[string]T, len(base))
+	for _, component := range base {
+		baseByID[id(component)] = component
+	}
+	otherByID := make(map[string]T, len(other))
+	for _, component := range other {
+		otherByID[id(component)] = component
+	}
+
+	for _, component := range base {
+		if _, ok := otherByID[id(component)]; !ok {
+			diff.Removed = append(diff.Removed, component)
+		}
+	}
+	for _, component := range other {
+		previous, ok := baseByID[id(component)]
+		if !ok {
+			diff.Added = append(diff.Added, component)
+			continue
+		}
+		if changes := diffFields(toFields(previous), toFields(component)); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, ComponentChange{ID: id(component), Changes: changes})
+		}
+	}
+
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ID < diff.Changed[j].ID })
+	return diff
+}
+
+// DiffState compares two engine state dumps, reporting changed fields by
+// dotted path
+func DiffState(base, other map[string]any) []FieldChange {
+	return diffFields(toFields(base), toFields(other))
+}
+
+// toFields flattens a value into dotted JSON field paths
+func toFields(value any) map[string]any {
+	raw, _ := json.Marshal(value)
+	var decoded map[string]any
+	json.Unmarshal(raw, &decoded)
+
+	fields := make(map[string]any)
+	flattenFields("", decoded, fields)
+	return fields
+}
+
+func flattenFields(prefix string, value map[string]any, fields map[string]any) {
+	for key, nested := range value {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if object, ok := nested.(map[string]any); ok {
+			flattenFields(path, object, fields)
+			continue
+		}
+		fields[path] = nested
+	}
+}
+
+// diffFields compares flattened fields, sorted by path
+func diffFields(base, other map[string]any) []FieldChange {
+	paths := make(map[string]bool, len(base)+len(other))
+	for path := range base {
+		paths[path] = true
+	}
+	for path := range other {
+		paths[path] = true
+	}
+
+	changes := []FieldChange{}
+	for path := range paths {
+		from, to := base[path], other[path]
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: path, From: summarizeSeries(from), To: summarizeSeries(to)})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// summarizeSeries replaces long arrays, such as recorded load series, by their length
+func summarizeSeries(value any) any {
+	if series, ok := value.([]any); ok && len(series) > maxDiffSeriesLength {
+		return map[string]any{"length": len(series)}
+	}
+	return value
+}
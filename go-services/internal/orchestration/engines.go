@@ -0,0 +1,165 @@
+package orchestration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/engine"
+)
+
+// startOnEngineLocked creates and starts the simulation on its engine's
+// implementation. Engines registered by descriptor alone are skipped.
+// Must be called with lock held.
+func (o *Orchestrator) startOnEngineLocked(simulation *Simulation) error {
+	impl, ok := o.engineImplementationLocked(simulation)
+	if !ok {
+		return nil
+	}
+
+	config, err := json.Marshal(simulation.Config)
+	if err != nil {
+		return fmt.Errorf("failed to encode simulation config: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(o.ctx, engineControlTimeout)
+	defer cancel()
+
+	runID, err := impl.Create(ctx, engine.Spec{
+		Name:   simulation.Name,
+		Config: config,
+		Speed:  simulation.Speed,
+		Seed:   simulation.Config.RandomSeed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create simulation on engine %s: %w", simulation.Engine, err)
+	}
+	if err := impl.Start(ctx, runID); err != nil {
+		return fmt.Errorf("failed to start simulation on engine %s: %w", simulation.Engine, err)
+	}
+
+	simulation.engineRunID = runID
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulation.ID,
+		"engine":        simulation.Engine,
+		"engine_run_id": runID,
+	}).Debug("Simulation started on engine")
+	return nil
+}
+
+// stopOnEngineLocked stops the simulation's run on its engine, if it has one
+// (must be called with lock held)
+func (o *Orchestrator) stopOnEngineLocked(simulation *Simulation) {
+	if simulation.engineRunID == "" {
+		return
+	}
+	runID := simulation.engineRunID
+	simulation.engineRunID = ""
+
+	impl, ok := o.engineImplementationLocked(simulation)
+	if !ok {
+		return
+	}
+
+	// The orchestrator's context may already be cancelled during shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), engineControlTimeout)
+	defer cancel()
+
+	if err := impl.Stop(ctx, runID); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"simulation_id": simulation.ID,
+			"engine":        simulation.Engine,
+		}).Warn("Failed to stop simulation on engine")
+	}
+}
+
+// pauseOnEngineLocked freezes the simulation's run on its engine, keeping it
+// so it can be resumed. Must be called with lock held.
+func (o *Orchestrator) pauseOnEngineLocked(ctx context.Context, simulation *Simulation) error {
+	impl, ok := o.engineImplementationLocked(simulation)
+	if !ok || simulation.engineRunID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+	defer cancel()
+
+	if err := impl.Stop(ctx, simulation.engineRunID); err != nil {
+		return fmt.Errorf("failed to pause simulation on engine %s: %w", simulation.Engine, err)
+	}
+	return nil
+}
+
+// resumeOnEngineLocked continues the simulation's run on its engine from the
+// tick it was paused at. Must be called with lock held.
+func (o *Orchestrator) resumeOnEngineLocked(ctx context.Context, simulation *Simulation) error {
+	impl, ok := o.engineImplementationLocked(simulation)
+	if !ok || simulation.engineRunID == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+	defer cancel()
+
+	if err := impl.Start(ctx, simulation.engineRunID); err != nil {
+		return fmt.Errorf("failed to resume simulation on engine %s: %w", simulation.Engine, err)
+	}
+	return nil
+}
+
+// InjectFault fails a component of a running simulation on its engine
+func (o *Orchestrator) InjectFault(ctx context.Context, id string, fault engine.Fault) error {
+	if err := fault.Validate(); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, exists := o.simulations[id]
+	if !exists {
+		return ErrSimulationNotFound
+	}
+	if simulation.Status != StatusRunning && simulation.Status != StatusPaused {
+		return fmt.Errorf("%w, current status: %s", ErrNoEngineRun, simulation.Status.String())
+	}
+
+	impl, ok := o.engineImplementationLocked(simulation)
+	if !ok || simulation.engineRunID == "" {
+		return ErrNoEngineRun
+	}
+	if !impl.Descriptor().HasCapability(engine.CapabilityFaultInjection) {
+		return fmt.Errorf("%w: engine %s lacks capability %s", engine.ErrUnsupportedConfig, simulation.Engine, engine.CapabilityFaultInjection)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+	defer cancel()
+
+	if err := impl.Inject(ctx, simulation.engineRunID, fault); err != nil {
+		if errors.Is(err, engine.ErrInvalidFault) {
+			return err
+		}
+		return fmt.Errorf("failed to inject fault: %w", err)
+	}
+
+	simulation.UpdatedAt = time.Now()
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"component_id":  fault.ComponentID,
+		"fault_type":    fault.Type,
+	}).Info("Fault injected")
+	return nil
+}
+
+// engineImplementationLocked returns the implementation of the simulation's
+// engine (must be called with lock held)
+func (o *Orchestrator) engineImplementationLocked(simulation *Simulation) (engine.Engine, bool) {
+	if o.engines == nil || simulation.Engine == "" {
+		return nil, false
+	}
+	return o.engines.Engine(simulation.Engine)
+}
@@ -0,0 +1,410 @@
+package orchestration
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxExperimentRuns bounds the size of a sweep grid
+const maxExperimentRuns = 1000
+
+// Experiment result metrics, in matrix order
+var experimentMetrics = []string{
+	"peak_load_mw",
+	"peak_generation_mw",
+	"lole_hours",
+	"unserved_energy_mwh",
+	"max_frequency_deviation_hz",
+	"frequency_excursions",
+}
+
+// SweepDimension is one swept parameter. Values are taken from Values when set,
+// otherwise from Start to End inclusive in increments of Step.
+//
+// Supported parameters:
+//
+//	load.base_load_mw, load.peak_multiplier
+//	capacity.<plant type>           total capacity of all plants of the type, e.g. capacity.wind
+//	plant.<id>.max_capacity_mw
+//	storage.<id>.max_discharge_mw
+//	line.<id>.capacity_mw
+type SweepDimension struct {
+	Parameter string    `json:"parameter"`
+	Start     float64   `json:"start,omitempty"`
+	End       float64   `json:"end,omitempty"`
+	Step      float64   `json:"step,omitempty"`
+	Values    []float64 `json:"values,omitempty"`
+}
+
+// ExperimentRun is the simulation for a single grid point
+type ExperimentRun struct {
+	Index        int                `json:"index"`
+	Point        map[string]float64 `json:"point"`
+	SimulationID string             `json:"simulation_id,omitempty"`
+	Status       string             `json:"status"`
+	Metrics      map[string]float64 `json:"metrics,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// ExperimentMatrix lays results out for sensitivity plots. Each metric is a
+// row-major array over Axes; entries are null until their run completes.
+type ExperimentMatrix struct {
+	Dimensions []string              `json:"dimensions"`
+	Axes       [][]float64           `json:"axes"`
+	Shape      []int                 `json:"shape"`
+	Metrics    map[string][]*float64 `json:"metrics"`
+}
+
+// Experiment sweeps config parameters over a grid of values
+type Experiment struct {
+	ID            string           `json:"id"`
+	Name          string           `json:"name"`
+	Status        string           `json:"status"`
+	BaseConfig    SimulationConfig `json:"base_config"`
+	Dimensions    []SweepDimension `json:"dimensions"`
+	MaxConcurrent int              `json:"max_concurrent"`
+	Tags          []string         `json:"tags"`
+	Completed     int              `json:"completed"`
+	Failed        int              `json:"failed"`
+	Missing       int              `json:"missing"`
+	Pending       int              `json:"pending"`
+	Running       int              `json:"running"`
+	Runs          []*ExperimentRun `json:"runs"`
+	Matrix        ExperimentMatrix `json:"matrix"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+
+	axes    [][]float64
+	batchID string
+}
+
+// CreateExperiment expands the sweep dimensions into a grid, creates one run per
+// grid point and starts them, at most maxConcurrent at a time
+func (o *Orchestrator) CreateExperiment(name string, base SimulationConfig, dimensions []SweepDimension, maxConcurrent int, tags []string) (*Experiment, error) {
+	if len(dimensions) == 0 {
+		return nil, fmt.Errorf("%w: at least one dimension is required", ErrInvalidSweep)
+	}
+
+	seen := make(map[string]bool)
+	axes := make([][]float64, len(dimensions))
+	runs := 1
+	for i, dimension := range dimensions {
+		if seen[dimension.Parameter] {
+			return nil, fmt.Errorf("%w: parameter %s is swept twice", ErrInvalidSweep, dimension.Parameter)
+		}
+		seen[dimension.Parameter] = true
+
+		values, err := dimension.values()
+		if err != nil {
+			return nil, err
+		}
+		// Apply the first value to catch unknown parameters and component IDs up front
+		if err := applySweepParameter(cloneConfig(base), dimension.Parameter, values[0]); err != nil {
+			return nil, err
+		}
+
+		axes[i] = values
+		runs *= len(values)
+		if runs > maxExperimentRuns {
+			return nil, fmt.Errorf("%w: grid exceeds %d runs", ErrInvalidSweep, maxExperimentRuns)
+		}
+	}
+
+	instances := make([]*BatchInstance, runs)
+	for i := range instances {
+		config := cloneConfig(base)
+		for d, value := range gridPoint(axes, i) {
+			if err := applySweepParameter(config, dimensions[d].Parameter, value); err != nil {
+				return nil, err
+			}
+		}
+		instances[i] = &BatchInstance{
+			Index:            i,
+			Status:           InstancePending,
+			LoadFactor:       1,
+			FailedComponents: []string{},
+			config:           *config,
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if maxConcurrent <= 0 || maxConcurrent > o.config.WorkerPoolSize {
+		maxConcurrent = o.config.WorkerPoolSize
+	}
+
+	now := time.Now()
+	experiment := &Experiment{
+		ID:            o.newIDLocked(),
+		Name:          name,
+		BaseConfig:    base,
+		Dimensions:    dimensions,
+		MaxConcurrent: maxConcurrent,
+		Tags:          tags,
+		CreatedAt:     now,
+		axes:          axes,
+	}
+	batch := &Batch{
+		ID:            o.newIDLocked(),
+		Name:          name,
+		Status:        BatchRunning,
+		BaseConfig:    base,
+		Parameters:    BatchParameters{FrequencyToleranceHz: defaultFrequencyToleranceHz},
+		MaxConcurrent: maxConcurrent,
+		Tags:          tags,
+		Instances:     instances,
+		CreatedAt:     now,
+		experimentID:  experiment.ID,
+	}
+	experiment.batchID = batch.ID
+
+	o.experiments[experiment.ID] = experiment
+	o.batches[batch.ID] = batch
+	o.launchBatchInstancesLocked(batch)
+	o.refreshBatchReportLocked(batch)
+
+	logrus.WithFields(logrus.Fields{
+		"experiment_id":  experiment.ID,
+		"dimensions":     len(dimensions),
+		"runs":           runs,
+		"max_concurrent": maxConcurrent,
+	}).Info("Parameter sweep experiment created")
+
+	return o.experimentViewLocked(experiment), nil
+}
+
+// GetExperiment retrieves an experiment with its runs and result matrix
+func (o *Orchestrator) GetExperiment(id string) (*Experiment, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	experiment, exists := o.experiments[id]
+	if !exists {
+		return nil, ErrExperimentNotFound
+	}
+
+	return o.experimentViewLocked(experiment), nil
+}
+
+// ListExperiments returns all experiments, newest first
+func (o *Orchestrator) ListExperiments() []*Experiment {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	experiments := make([]*Experiment, 0, len(o.experiments))
+	for _, experiment := range o.experiments {
+		experiments = append(experiments, o.experimentViewLocked(experiment))
+	}
+	sort.Slice(experiments, func(i, j int) bool {
+		return experiments[i].CreatedAt.After(experiments[j].CreatedAt)
+	})
+
+	return experiments
+}
+
+// CancelExperiment stops launching pending runs and stops running ones
+func (o *Orchestrator) CancelExperiment(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	experiment, exists := o.experiments[id]
+	if !exists {
+		return ErrExperimentNotFound
+	}
+	if err := o.cancelBatchLocked(o.batches[experiment.batchID]); err != nil {
+		return fmt.Errorf("experiment is not running: %w", err)
+	}
+
+	logrus.WithField("experiment_id", id).Info("Parameter sweep experiment cancelled")
+	return nil
+}
+
+// experimentViewLocked builds the externally visible state of an experiment
+// from its underlying batch (must be called with lock held)
+func (o *Orchestrator) experimentViewLocked(experiment *Experiment) *Experiment {
+	view := *experiment
+	batch := o.batches[experiment.batchID]
+
+	view.Status = batch.Status
+	view.CompletedAt = batch.CompletedAt
+	view.Completed = batch.Report.Completed
+	view.Failed = batch.Report.Failed
+	view.Missing = batch.Report.Missing
+	view.Pending = batch.Report.Pending
+	view.Running = batch.Report.Running
+
+	view.Matrix = ExperimentMatrix{
+		Dimensions: make([]string, len(experiment.Dimensions)),
+		Axes:       experiment.axes,
+		Shape:      make([]int, len(experiment.axes)),
+		Metrics:    make(map[string][]*float64, len(experimentMetrics)),
+	}
+	for i, dimension := range experiment.Dimensions {
+		view.Matrix.Dimensions[i] = dimension.Parameter
+		view.Matrix.Shape[i] = len(experiment.axes[i])
+	}
+	for _, metric := range experimentMetrics {
+		view.Matrix.Metrics[metric] = make([]*float64, len(batch.Instances))
+	}
+
+	view.Runs = make([]*ExperimentRun, len(batch.Instances))
+	for i, instance := range batch.Instances {
+		run := &ExperimentRun{
+			Index:        instance.Index,
+			Point:        make(map[string]float64, len(experiment.Dimensions)),
+			SimulationID: instance.SimulationID,
+			Status:       instance.Status,
+			Error:        instance.Error,
+		}
+		for d, value := range gridPoint(experiment.axes, i) {
+			run.Point[experiment.Dimensions[d].Parameter] = value
+		}
+
+		if instance.Status == InstanceCompleted {
+			run.Metrics = runMetrics(instance)
+			for _, metric := range experimentMetrics {
+				value := run.Metrics[metric]
+				view.Matrix.Metrics[metric][i] = &value
+			}
+		}
+		view.Runs[i] = run
+	}
+
+	return &view
+}
+
+// runMetrics extracts the sweep metrics of a completed run
+func runMetrics(instance *BatchInstance) map[string]float64 {
+	return map[string]float64{
+		"peak_load_mw":               instance.PeakLoadMW,
+		"peak_generation_mw":         instance.PeakGenerationMW,
+		"lole_hours":                 instance.LossOfLoadHours,
+		"unserved_energy_mwh":        instance.UnservedEnergyMWh,
+		"max_frequency_deviation_hz": instance.MaxFrequencyDeviationHz,
+		"frequency_excursions":       float64(instance.FrequencyExcursions),
+	}
+}
+
+// values expands a dimension into its list of values
+func (d SweepDimension) values() ([]float64, error) {
+	if d.Parameter == "" {
+		return nil, fmt.Errorf("%w: parameter is required", ErrInvalidSweep)
+	}
+	if len(d.Values) > 0 {
+		return d.Values, nil
+	}
+	if d.Step <= 0 || d.End < d.Start {
+		return nil, fmt.Errorf("%w: %s needs values or a start <= end range with a positive step", ErrInvalidSweep, d.Parameter)
+	}
+
+	// Computed by index rather than accumulation so the end point is not lost to rounding
+	count := int(math.Floor((d.End-d.Start)/d.Step+1e-9)) + 1
+	if count > maxExperimentRuns {
+		return nil, fmt.Errorf("%w: %s has more than %d values", ErrInvalidSweep, d.Parameter, maxExperimentRuns)
+	}
+	values := make([]float64, count)
+	for i := range values {
+		values[i] = d.Start + float64(i)*d.Step
+	}
+	return values, nil
+}
+
+// gridPoint returns the axis values of the row-major grid index
+func gridPoint(axes [][]float64, index int) []float64 {
+	point := make([]float64, len(axes))
+	for d := len(axes) - 1; d >= 0; d-- {
+		point[d] = axes[d][index%len(axes[d])]
+		index /= len(axes[d])
+	}
+	return point
+}
+
+// cloneConfig copies a config deeply enough for sweep parameters to be applied
+func cloneConfig(base SimulationConfig) *SimulationConfig {
+	config := base
+	config.PowerPlants = append([]PowerPlantConfig(nil), base.PowerPlants...)
+	config.TransmissionLines = append([]TransmissionLineConfig(nil), base.TransmissionLines...)
+	config.StorageUnits = append([]StorageUnitConfig(nil), base.StorageUnits...)
+	return &config
+}
+
+// applySweepParameter sets a single swept parameter on a config
+func applySweepParameter(config *SimulationConfig, parameter string, value float64) error {
+	if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("%w: %s must be a finite non-negative value, got %v", ErrInvalidSweep, parameter, value)
+	}
+
+	parts := strings.Split(parameter, ".")
+	switch {
+	case parameter == "load.base_load_mw":
+		config.LoadProfile.BaseLoadMW = value
+		return nil
+
+	case parameter == "load.peak_multiplier":
+		config.LoadProfile.PeakMultiplier = value
+		return nil
+
+	case len(parts) == 2 && parts[0] == "capacity":
+		return setTypeCapacity(config, parts[1], value)
+
+	case len(parts) == 3 && parts[0] == "plant" && parts[2] == "max_capacity_mw":
+		for i := range config.PowerPlants {
+			if config.PowerPlants[i].ID == parts[1] {
+				config.PowerPlants[i].MaxCapacityMW = value
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: unknown power plant %s", ErrInvalidSweep, parts[1])
+
+	case len(parts) == 3 && parts[0] == "storage" && parts[2] == "max_discharge_mw":
+		for i := range config.StorageUnits {
+			if config.StorageUnits[i].ID == parts[1] {
+				config.StorageUnits[i].MaxDischargeMW = value
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: unknown storage unit %s", ErrInvalidSweep, parts[1])
+
+	case len(parts) == 3 && parts[0] == "line" && parts[2] == "capacity_mw":
+		for i := range config.TransmissionLines {
+			if config.TransmissionLines[i].ID == parts[1] {
+				config.TransmissionLines[i].CapacityMW = value
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: unknown transmission line %s", ErrInvalidSweep, parts[1])
+	}
+
+	return fmt.Errorf("%w: unsupported parameter %s", ErrInvalidSweep, parameter)
+}
+
+// setTypeCapacity scales plants of a type so their capacities sum to total,
+// preserving their relative sizes
+func setTypeCapacity(config *SimulationConfig, plantType string, total float64) error {
+	var matched []*PowerPlantConfig
+	var current float64
+	for i := range config.PowerPlants {
+		if strings.EqualFold(config.PowerPlants[i].Type, plantType) {
+			matched = append(matched, &config.PowerPlants[i])
+			current += config.PowerPlants[i].MaxCapacityMW
+		}
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("%w: no power plants of type %s", ErrInvalidSweep, plantType)
+	}
+
+	for _, plant := range matched {
+		if current > 0 {
+			plant.MaxCapacityMW *= total / current
+		} else {
+			plant.MaxCapacityMW = total / float64(len(matched))
+		}
+	}
+	return nil
+}
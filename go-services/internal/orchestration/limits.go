@@ -0,0 +1,291 @@
+package orchestration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// concurrencyOverridesTTL is how long an organization's limit overrides are
+// cached before they are looked up again
+const concurrencyOverridesTTL = time.Minute
+
+// clusterRunsTTL is how long the runs active across the cluster are cached
+// before they are looked up again
+const clusterRunsTTL = 5 * time.Second
+
+// ConcurrencyLimits cap how many simulations an organization or a user may
+// have queued, running or paused at once; zero is unlimited
+type ConcurrencyLimits struct {
+	PerOrganization int `json:"per_organization"`
+	PerUser         int `json:"per_user"`
+}
+
+// ConcurrencyOverrides are an organization's own limits; nil fields fall
+// back to the configured defaults
+type ConcurrencyOverrides struct {
+	PerOrganization *int `json:"per_organization,omitempty"`
+	PerUser         *int `json:"per_user,omitempty"`
+}
+
+// ConcurrencyOverridesSource looks up an organization's limit overrides
+type ConcurrencyOverridesSource func(organizationID uuid.UUID) (ConcurrencyOverrides, error)
+
+// ConcurrencyLimitError is returned when starting a simulation would take
+// its organization or owner over their limit. It matches ErrConcurrencyLimit.
+type ConcurrencyLimitError struct {
+	// "organization" or "user"
+	Scope  string
+	ID     uuid.UUID
+	Limit  int
+	Active int
+}
+
+func (e *ConcurrencyLimitError) Error() string {
+	return fmt.Sprintf("%s %s already has %d of its %d concurrent simulations queued, running or paused; stop one or wait for one to finish",
+		e.Scope, e.ID, e.Active, e.Limit)
+}
+
+// Is makes errors.Is(err, ErrConcurrencyLimit) match any limit error
+func (e *ConcurrencyLimitError) Is(target error) bool {
+	return target == ErrConcurrencyLimit
+}
+
+// ActiveRun is a simulation running or paused on any instance sharing the
+// simulations table
+type ActiveRun struct {
+	SimulationID   string
+	OrganizationID *uuid.UUID
+	OwnerID        *uuid.UUID
+}
+
+// ActiveRunsSource lists the simulations running or paused across the cluster
+type ActiveRunsSource func(ctx context.Context) ([]ActiveRun, error)
+
+// cachedOverrides is an organization's overrides as last looked up
+type cachedOverrides struct {
+	overrides ConcurrencyOverrides
+	loadedAt  time.Time
+}
+
+// SetConcurrencyOverrides sets where organizations' limit overrides are
+// looked up. Without a source every organization gets the defaults.
+func (o *Orchestrator) SetConcurrencyOverrides(source ConcurrencyOverridesSource) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.overridesSource = source
+	o.overrides = make(map[uuid.UUID]cachedOverrides)
+}
+
+// SetActiveRuns sets where the runs active on other instances are looked up,
+// so concurrency limits hold across the cluster. Without a source only the
+// simulations of this instance are counted.
+func (o *Orchestrator) SetActiveRuns(source ActiveRunsSource) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.activeRunsSource = source
+	o.clusterRuns = nil
+	o.clusterRunsAt = time.Time{}
+}
+
+// InvalidateConcurrencyLimits drops an organization's cached overrides, so
+// changed limits apply to the next start
+func (o *Orchestrator) InvalidateConcurrencyLimits(organizationID uuid.UUID) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.overrides, organizationID)
+}
+
+// ConcurrencyLimits returns the limits that apply to an organization's
+// simulations: the defaults with its overrides applied
+func (o *Orchestrator) ConcurrencyLimits(organizationID uuid.UUID) ConcurrencyLimits {
+	o.loadOverrides(organizationID)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.concurrencyLimitsLocked(&organizationID)
+}
+
+// loadConcurrencyState looks up what checking the concurrency limits of a
+// simulation needs and is no longer cached: its organization's overrides and
+// the runs active across the cluster. The lookups go to the database, so it
+// is called before the lock is taken.
+func (o *Orchestrator) loadConcurrencyState(ctx context.Context, id string) {
+	o.mu.RLock()
+	var organizationID *uuid.UUID
+	if simulation, exists := o.simulations[id]; exists {
+		organizationID = simulation.OrganizationID
+	}
+	o.mu.RUnlock()
+
+	if organizationID != nil {
+		o.loadOverrides(*organizationID)
+	}
+	o.loadClusterRuns(ctx)
+}
+
+// loadOverrides looks up an organization's overrides unless they are cached
+// and fresh. On failure the overrides known last stay in effect.
+func (o *Orchestrator) loadOverrides(organizationID uuid.UUID) {
+	o.mu.RLock()
+	source := o.overridesSource
+	cached, ok := o.overrides[organizationID]
+	o.mu.RUnlock()
+	if source == nil || (ok && time.Since(cached.loadedAt) < concurrencyOverridesTTL) {
+		return
+	}
+
+	overrides, err := source(organizationID)
+	if err != nil {
+		logrus.WithError(err).WithField("organization_id", organizationID).Warn("Failed to look up concurrency limit overrides")
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.overrides != nil {
+		o.overrides[organizationID] = cachedOverrides{overrides: overrides, loadedAt: time.Now()}
+	}
+}
+
+// loadClusterRuns looks up the runs active across the cluster unless they
+// are cached and fresh. On failure the runs known last are counted.
+func (o *Orchestrator) loadClusterRuns(ctx context.Context) {
+	o.mu.RLock()
+	source := o.activeRunsSource
+	fresh := time.Since(o.clusterRunsAt) < clusterRunsTTL
+	o.mu.RUnlock()
+	if source == nil || fresh {
+		return
+	}
+	o.refreshClusterRuns(ctx, source)
+}
+
+// refreshClusterRuns looks up the runs active across the cluster
+func (o *Orchestrator) refreshClusterRuns(ctx context.Context, source ActiveRunsSource) {
+	runs, err := source(ctx)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.clusterRunsLoading = false
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to look up active runs across the cluster")
+		return
+	}
+	o.clusterRuns = runs
+	o.clusterRunsAt = time.Now()
+}
+
+// concurrencyLimitsLocked resolves the limits of an organization, or the
+// defaults without one, from the cached overrides. Expired overrides stay
+// in effect while they are looked up again in the background.
+// (must be called with lock held)
+func (o *Orchestrator) concurrencyLimitsLocked(organizationID *uuid.UUID) ConcurrencyLimits {
+	limits := ConcurrencyLimits{
+		PerOrganization: o.config.MaxConcurrentPerOrganization,
+		PerUser:         o.config.MaxConcurrentPerUser,
+	}
+	if organizationID == nil || o.overridesSource == nil {
+		return limits
+	}
+
+	cached, ok := o.overrides[*organizationID]
+	if !ok || time.Since(cached.loadedAt) >= concurrencyOverridesTTL {
+		go o.loadOverrides(*organizationID)
+	}
+
+	if cached.overrides.PerOrganization != nil {
+		limits.PerOrganization = *cached.overrides.PerOrganization
+	}
+	if cached.overrides.PerUser != nil {
+		limits.PerUser = *cached.overrides.PerUser
+	}
+	return limits
+}
+
+// checkCapacityLocked fails with an error matching ErrConcurrencyLimit when
+// starting the simulation would exceed the global limit or its tenant's
+// (must be called with lock held)
+func (o *Orchestrator) checkCapacityLocked(simulation *Simulation) error {
+	if active := o.activeCountLocked(); active >= o.config.MaxConcurrentSimulations {
+		return fmt.Errorf("%w: maximum concurrent simulations reached: %d", ErrConcurrencyLimit, o.config.MaxConcurrentSimulations)
+	}
+	return o.checkConcurrencyLocked(simulation)
+}
+
+// checkConcurrencyLocked returns a ConcurrencyLimitError when starting the
+// simulation would take its organization or owner over their limit, counting
+// its runs on every instance (must be called with lock held)
+func (o *Orchestrator) checkConcurrencyLocked(simulation *Simulation) error {
+	if simulation.OrganizationID == nil && simulation.OwnerID == nil {
+		return nil
+	}
+	limits := o.concurrencyLimitsLocked(simulation.OrganizationID)
+
+	var byOrganization, byOwner int
+	count := func(organizationID, ownerID *uuid.UUID) {
+		if simulation.OrganizationID != nil && organizationID != nil && *organizationID == *simulation.OrganizationID {
+			byOrganization++
+		}
+		if simulation.OwnerID != nil && ownerID != nil && *ownerID == *simulation.OwnerID {
+			byOwner++
+		}
+	}
+	for _, other := range o.simulations {
+		if other != simulation && other.active() {
+			count(other.OrganizationID, other.OwnerID)
+		}
+	}
+	// Runs on other instances; the ones this instance knows are counted by
+	// their status here, which is current
+	for _, run := range o.clusterRunsLocked() {
+		if _, known := o.simulations[run.SimulationID]; !known {
+			count(run.OrganizationID, run.OwnerID)
+		}
+	}
+
+	if simulation.OwnerID != nil && limits.PerUser > 0 && byOwner >= limits.PerUser {
+		return &ConcurrencyLimitError{Scope: "user", ID: *simulation.OwnerID, Limit: limits.PerUser, Active: byOwner}
+	}
+	if simulation.OrganizationID != nil && limits.PerOrganization > 0 && byOrganization >= limits.PerOrganization {
+		return &ConcurrencyLimitError{Scope: "organization", ID: *simulation.OrganizationID, Limit: limits.PerOrganization, Active: byOrganization}
+	}
+	return nil
+}
+
+// clusterRunsLocked returns the runs active across the cluster as last
+// looked up, starting a lookup in the background once they have expired
+// (must be called with lock held)
+func (o *Orchestrator) clusterRunsLocked() []ActiveRun {
+	if o.activeRunsSource != nil && !o.clusterRunsLoading && time.Since(o.clusterRunsAt) >= clusterRunsTTL {
+		o.clusterRunsLoading = true
+		go o.refreshClusterRuns(o.ctx, o.activeRunsSource)
+	}
+	return o.clusterRuns
+}
+
+// activeCountLocked counts the simulations queued, running or paused here
+// (must be called with lock held)
+func (o *Orchestrator) activeCountLocked() int {
+	count := 0
+	for _, simulation := range o.simulations {
+		if simulation.active() {
+			count++
+		}
+	}
+	return count
+}
+
+// active reports whether the simulation counts against concurrency limits
+func (s *Simulation) active() bool {
+	return s.Status == StatusQueued || s.Status == StatusRunning || s.Status == StatusPaused
+}
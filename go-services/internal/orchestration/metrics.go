@@ -0,0 +1,147 @@
+package orchestration
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Component type used for simulation-wide runtime metrics
+const ComponentTypeSimulation = "simulation"
+
+// Component readings of a power plant's output. Plants are numbered from 1
+// in config order.
+const (
+	ComponentTypePowerPlant = "power_plant"
+	MetricOutputMW          = "output_mw"
+)
+
+// Component readings of a storage unit. Units are numbered from 1 in config
+// order; the engine may report any of the readings in a sample.
+const (
+	ComponentTypeStorageUnit = "storage_unit"
+	MetricStateOfCharge      = "state_of_charge"
+	MetricStoredEnergyMWh    = "stored_energy_mwh"
+	MetricPowerMW            = "power_mw"
+)
+
+// MetricsSample is a runtime report from the engine for one simulation
+type MetricsSample struct {
+	// All values of a sample share this timestamp, including persisted rows
+	Timestamp time.Time `json:"timestamp"`
+	// Cumulative number of engine events processed so far
+	EventsProcessed int64 `json:"events_processed" binding:"gte=0"`
+	// Ticks covered by this sample and their mean duration
+	Ticks         int64             `json:"ticks" binding:"gte=0"`
+	TickTimeMS    float64           `json:"tick_time_ms" binding:"gte=0"`
+	MemoryUsageMB int64             `json:"memory_usage_mb" binding:"gte=0"`
+	Components    []ComponentSample `json:"components" binding:"dive"`
+}
+
+// ComponentSample is a single component reading within a metrics sample.
+// Components are numbered from 1 in config order within their type.
+type ComponentSample struct {
+	Type   string  `json:"type" binding:"required"`
+	ID     int     `json:"id"`
+	Metric string  `json:"metric" binding:"required"`
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+}
+
+// MetricsSink persists runtime metrics samples
+type MetricsSink interface {
+	RecordSimulationMetrics(simulationID string, sample MetricsSample) error
+}
+
+// MetricsExporter receives every metrics sample as it arrives, unlike the
+// sink which is throttled. Implementations must not block.
+type MetricsExporter interface {
+	ExportSimulationMetrics(simulationID string, sample MetricsSample)
+}
+
+// SetMetricsSink sets where runtime metrics are persisted. Samples reach the
+// sink at most once per metrics persist interval for each simulation.
+func (o *Orchestrator) SetMetricsSink(sink MetricsSink) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.metricsSink = sink
+}
+
+// SetMetricsExporter sets where every metrics sample is exported
+func (o *Orchestrator) SetMetricsExporter(exporter MetricsExporter) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.metricsExporter = exporter
+}
+
+// RecordMetrics updates a simulation's runtime fields from an engine sample
+// and forwards it to the metrics sink when the persist interval has elapsed
+func (o *Orchestrator) RecordMetrics(simulationID string, sample MetricsSample) error {
+	if sample.Timestamp.IsZero() {
+		sample.Timestamp = time.Now().UTC()
+	}
+
+	o.mu.Lock()
+	simulation, exists := o.simulations[simulationID]
+	if !exists {
+		o.mu.Unlock()
+		return ErrSimulationNotFound
+	}
+
+	if sample.EventsProcessed > simulation.EventsProcessed {
+		simulation.EventsProcessed = sample.EventsProcessed
+	}
+	if sample.Ticks > 0 {
+		// Running mean over every tick reported so far
+		total := simulation.AvgTickTime*float64(simulation.ticksMeasured) + sample.TickTimeMS*float64(sample.Ticks)
+		simulation.ticksMeasured += sample.Ticks
+		simulation.AvgTickTime = total / float64(simulation.ticksMeasured)
+	}
+	if sample.MemoryUsageMB > 0 {
+		simulation.MemoryUsage = sample.MemoryUsageMB
+	}
+	for _, component := range sample.Components {
+		if component.Type == ComponentTypePowerPlant && component.Metric == MetricOutputMW {
+			if simulation.plantOutputs == nil {
+				simulation.plantOutputs = make(map[int]float64)
+			}
+			simulation.plantOutputs[component.ID] = component.Value
+		}
+	}
+	simulation.UpdatedAt = time.Now()
+
+	// Exported samples carry the cumulative event count and latest memory
+	// usage, but keep this sample's own tick time
+	exporter := o.metricsExporter
+	exported := sample
+	exported.EventsProcessed = simulation.EventsProcessed
+	exported.MemoryUsageMB = simulation.MemoryUsage
+
+	sink := o.metricsSink
+	persist := sink != nil && (simulation.metricsPersistedAt.IsZero() ||
+		sample.Timestamp.Sub(simulation.metricsPersistedAt) >= o.config.MetricsPersistInterval)
+	if persist {
+		simulation.metricsPersistedAt = sample.Timestamp
+		// Persist the aggregated runtime fields alongside the component readings
+		sample.EventsProcessed = simulation.EventsProcessed
+		sample.TickTimeMS = simulation.AvgTickTime
+		sample.MemoryUsageMB = simulation.MemoryUsage
+	}
+	o.mu.Unlock()
+
+	if exporter != nil {
+		exporter.ExportSimulationMetrics(simulationID, exported)
+	}
+
+	if !persist {
+		return nil
+	}
+
+	if err := sink.RecordSimulationMetrics(simulationID, sample); err != nil {
+		logrus.WithError(err).WithField("simulation_id", simulationID).Warn("Failed to persist simulation metrics")
+		return err
+	}
+	return nil
+}
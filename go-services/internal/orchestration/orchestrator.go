@@ -3,12 +3,18 @@ package orchestration
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
 	"voltedge/go-services/internal/config"
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/ids"
+	"voltedge/go-services/internal/lock"
+	"voltedge/go-services/internal/metadata"
 )
 
 // SimulationStatus represents the status of a simulation
@@ -20,6 +26,8 @@ const (
 	StatusPaused
 	StatusError
 	StatusCompleted
+	StatusWaiting
+	StatusQueued
 )
 
 func (s SimulationStatus) String() string {
@@ -34,6 +42,10 @@ func (s SimulationStatus) String() string {
 		return "error"
 	case StatusCompleted:
 		return "completed"
+	case StatusWaiting:
+		return "waiting"
+	case StatusQueued:
+		return "queued"
 	default:
 		return "unknown"
 	}
@@ -45,70 +57,147 @@ type Simulation struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	Status      SimulationStatus       `json:"status"`
+	Engine      string                 `json:"engine"`
 	Config      SimulationConfig       `json:"config"`
 	Tags        []string               `json:"tags"`
 	Metadata    map[string]interface{} `json:"metadata"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
+	// Organization its usage is metered against, if any
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	// User who created it, if any; counted against their concurrency limit
+	OwnerID *uuid.UUID `json:"owner_id,omitempty"`
+	// Incremented whenever the name, description, tags, metadata or, through
+	// a config plan or topology change, the config change
+	Version int64 `json:"version"`
+	// Real-time factor; SpeedUnlimited runs as fast as possible
+	Speed float64 `json:"speed"`
+
+	// Dependencies on other simulations
+	DependsOn    []Dependency           `json:"depends_on,omitempty"`
+	InitialState map[string]interface{} `json:"initial_state,omitempty"`
 
 	// Runtime information
 	StartTime *time.Time    `json:"start_time,omitempty"`
 	EndTime   *time.Time    `json:"end_time,omitempty"`
 	Duration  time.Duration `json:"duration,omitempty"`
 	Error     error         `json:"error,omitempty"`
+	// Why the last run ended; one of the StopReason constants
+	StoppedReason string `json:"stopped_reason,omitempty"`
+	// Set when a config change reached the engine but could neither be
+	// stored nor undone, so the run no longer matches the stored config
+	Diverged bool `json:"diverged,omitempty"`
 
 	// Performance metrics
 	EventsProcessed int64   `json:"events_processed"`
 	AvgTickTime     float64 `json:"avg_tick_time_ms"`
 	MemoryUsage     int64   `json:"memory_usage_mb"`
+
+	engineAcquired bool
+	// ID of the simulation on its engine's implementation while it runs there
+	engineRunID        string
+	runLease           lock.Lease
+	ticksMeasured      int64
+	metricsPersistedAt time.Time
+	// Output the engine last reported for each plant in this run, by plant
+	// number; handed off to dependents
+	plantOutputs map[int]float64
+	// When the simulation was last paused, and how long its run spent
+	// paused before that; progress does not advance while paused
+	pausedAt  time.Time
+	pausedFor time.Duration
+	// Counts the simulation's runs so a job can tell whether it is current
+	run uint64
+	// Loaded from the repository on demand rather than created or restored
+	// here; see cleanup
+	loaded bool
 }
 
 // SimulationConfig represents the configuration for a simulation
 type SimulationConfig struct {
-	PowerPlants       []PowerPlantConfig       `json:"power_plants"`
-	TransmissionLines []TransmissionLineConfig `json:"transmission_lines"`
+	PowerPlants       []PowerPlantConfig       `json:"power_plants" binding:"required"`
+	TransmissionLines []TransmissionLineConfig `json:"transmission_lines" binding:"required"`
+	StorageUnits      []StorageUnitConfig      `json:"storage_units"`
+	Buses             []BusConfig              `json:"buses,omitempty"`
 	BaseFrequency     float64                  `json:"base_frequency"`
 	BaseVoltage       float64                  `json:"base_voltage"`
 	LoadProfile       LoadProfile              `json:"load_profile"`
+	// Seeds the engine's randomness; zero picks one when the simulation is
+	// created, and the seed picked is kept here so the run can be reproduced
+	RandomSeed uint64 `json:"random_seed,omitempty"`
+	// Run length progress and ETA are measured against: a tick count, a
+	// running time, or both, in which case whichever is reached first counts
+	TargetTicks           int64   `json:"target_ticks,omitempty" binding:"gte=0"`
+	TargetDurationSeconds float64 `json:"target_duration_seconds,omitempty" binding:"gte=0"`
 }
 
 // PowerPlantConfig represents a power plant configuration
 type PowerPlantConfig struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	Type            string   `json:"type"`
-	MaxCapacityMW   float64  `json:"max_capacity_mw"`
-	CurrentOutputMW float64  `json:"current_output_mw"`
-	Efficiency      float64  `json:"efficiency"`
-	Location        Location `json:"location"`
-	IsOperational   bool     `json:"is_operational"`
+	ID                     string   `json:"id" binding:"required"`
+	Name                   string   `json:"name" binding:"required"`
+	Type                   string   `json:"type" binding:"required"`
+	MaxCapacityMW          float64  `json:"max_capacity_mw" binding:"required"`
+	CurrentOutputMW        float64  `json:"current_output_mw"`
+	Efficiency             float64  `json:"efficiency"`
+	EmissionFactorKgPerMWh float64  `json:"emission_factor_kg_per_mwh,omitempty"`
+	FuelCostPerMWh         float64  `json:"fuel_cost_per_mwh,omitempty"`
+	StartupCost            float64  `json:"startup_cost,omitempty"`
+	OMCostPerMWh           float64  `json:"om_cost_per_mwh,omitempty"`
+	Location               Location `json:"location" binding:"required"`
+	BusID                  string   `json:"bus_id,omitempty"`
+	IsOperational          bool     `json:"is_operational"`
 }
 
 // TransmissionLineConfig represents a transmission line configuration
 type TransmissionLineConfig struct {
-	ID              string  `json:"id"`
-	FromNode        string  `json:"from_node"`
-	ToNode          string  `json:"to_node"`
-	CapacityMW      float64 `json:"capacity_mw"`
-	LengthKM        float64 `json:"length_km"`
+	ID              string  `json:"id" binding:"required"`
+	FromNode        string  `json:"from_node" binding:"required"`
+	ToNode          string  `json:"to_node" binding:"required"`
+	CapacityMW      float64 `json:"capacity_mw" binding:"required"`
+	LengthKM        float64 `json:"length_km" binding:"required"`
 	ResistancePerKM float64 `json:"resistance_per_km"`
 	ReactancePerKM  float64 `json:"reactance_per_km"`
 	IsOperational   bool    `json:"is_operational"`
 }
 
+// StorageUnitConfig represents a battery/energy storage unit configuration
+type StorageUnitConfig struct {
+	ID                  string   `json:"id" binding:"required"`
+	Name                string   `json:"name" binding:"required"`
+	CapacityMWh         float64  `json:"capacity_mwh" binding:"required,gt=0"`
+	MaxChargeMW         float64  `json:"max_charge_mw" binding:"required,gt=0"`
+	MaxDischargeMW      float64  `json:"max_discharge_mw" binding:"required,gt=0"`
+	RoundTripEfficiency float64  `json:"round_trip_efficiency" binding:"omitempty,gt=0,lte=1"`
+	StateOfCharge       float64  `json:"state_of_charge" binding:"omitempty,gte=0,lte=1"`
+	Location            Location `json:"location" binding:"required"`
+	BusID               string   `json:"bus_id,omitempty"`
+	IsOperational       bool     `json:"is_operational"`
+}
+
 // LoadProfile represents the load profile configuration
 type LoadProfile struct {
-	BaseLoadMW      float64 `json:"base_load_mw"`
+	BaseLoadMW      float64 `json:"base_load_mw" binding:"required"`
 	PeakMultiplier  float64 `json:"peak_multiplier"`
 	DailyVariation  float64 `json:"daily_variation"`
 	RandomVariation float64 `json:"random_variation"`
+	// Recorded load series replayed instead of the synthetic profile when set
+	RecordedSeriesMW      []float64 `json:"recorded_series_mw,omitempty"`
+	SeriesIntervalSeconds float64   `json:"series_interval_seconds,omitempty"`
 }
 
-// Location represents a geographical location
+// Location represents a geographical location. X/Y are schematic grid
+// coordinates; lat/lon are optional WGS84 coordinates used for map output.
 type Location struct {
-	X    float64 `json:"x"`
-	Y    float64 `json:"y"`
-	Name string  `json:"name"`
+	X         float64  `json:"x" binding:"required"`
+	Y         float64  `json:"y" binding:"required"`
+	Latitude  *float64 `json:"lat,omitempty" binding:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude *float64 `json:"lon,omitempty" binding:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+	Name      string   `json:"name" binding:"required"`
+}
+
+// HasCoordinates reports whether the location carries a latitude and longitude
+func (l Location) HasCoordinates() bool {
+	return l.Latitude != nil && l.Longitude != nil
 }
 
 // HealthStatus represents the health status of a service
@@ -127,6 +216,37 @@ type Orchestrator struct {
 	cancel        context.CancelFunc
 	workerPool    *WorkerPool
 	cleanupTicker *time.Ticker
+	engines       *engine.Registry
+	batches       map[string]*Batch
+	batchOf       map[string]string
+	experiments   map[string]*Experiment
+	metricsSink   MetricsSink
+	// Receives every sample; see SetMetricsExporter
+	metricsExporter  MetricsExporter
+	locker           lock.Locker
+	engineController EngineController
+	transitionHooks  *transitionDispatcher
+	// Jobs that failed for good, oldest first
+	deadLetters []*DeadLetter
+	// Organizations' concurrency limit overrides; see SetConcurrencyOverrides
+	overridesSource ConcurrencyOverridesSource
+	overrides       map[uuid.UUID]cachedOverrides
+	// Generates IDs for simulations, batches and experiments; nil uses
+	// the ids package default, which the database shares
+	ids ids.Generator
+	// Where simulations are stored; see SetRepository
+	repository Repository
+	// What simulation metadata may hold; see SetMetadataLimits
+	metadataLimits config.MetadataLimits
+	// Config plans awaiting apply, by plan ID; see PlanConfigChange
+	plans map[string]*ConfigPlan
+	// Where batch instance outcomes are read from; see SetRunOutcomes
+	runOutcomes RunOutcomeSource
+	// Runs active across the cluster as last looked up; see SetActiveRuns
+	activeRunsSource   ActiveRunsSource
+	clusterRuns        []ActiveRun
+	clusterRunsAt      time.Time
+	clusterRunsLoading bool
 }
 
 // NewOrchestrator creates a new orchestrator instance
@@ -138,15 +258,164 @@ func NewOrchestrator(cfg *config.OrchestrationConfig) *Orchestrator {
 		simulations: make(map[string]*Simulation),
 		ctx:         ctx,
 		cancel:      cancel,
-		workerPool:  NewWorkerPool(cfg.WorkerPoolSize),
+		workerPool:  NewWorkerPool(cfg.WorkerPoolSize, cfg.JobQueueSize),
+		batches:     make(map[string]*Batch),
+		batchOf:     make(map[string]string),
+		experiments: make(map[string]*Experiment),
+		plans:       make(map[string]*ConfigPlan),
+
+		transitionHooks: newTransitionDispatcher(),
 	}
 }
 
+// SetEngineRegistry sets the registry used to place simulations on engines
+func (o *Orchestrator) SetEngineRegistry(registry *engine.Registry) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.engines = registry
+}
+
+// SetMetadataLimits sets the limits simulation metadata is checked against
+// before it is stored. Without them only the key rules apply.
+func (o *Orchestrator) SetMetadataLimits(limits config.MetadataLimits) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.metadataLimits = limits
+}
+
+// SetWorkerFaultInjector registers a function consulted while each
+// simulation job runs; the job fails with its error. Chaos experiments use
+// it to crash workers.
+func (o *Orchestrator) SetWorkerFaultInjector(faults func(simulationID string) error) {
+	o.workerPool.SetFaultInjector(faults)
+}
+
+// SetLocker sets the locker used to take a run lease per simulation, so
+// replicas sharing a database cannot run the same simulation twice
+func (o *Orchestrator) SetLocker(locker lock.Locker) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.locker = locker
+}
+
+// AssignEngine pins a simulation to a registered engine
+func (o *Orchestrator) AssignEngine(ctx context.Context, id, engineName string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if simulation.Status == StatusRunning || simulation.Status == StatusQueued {
+		return fmt.Errorf("cannot change the engine of a running simulation")
+	}
+
+	if o.engines != nil {
+		if err := o.engines.Validate(engineName, simulation.Config.Requirements()); err != nil {
+			return err
+		}
+	}
+
+	previous := simulation.Engine
+	simulation.Engine = engineName
+	if err := o.saveLocked(ctx, simulation); err != nil {
+		simulation.Engine = previous
+		return err
+	}
+	simulation.UpdatedAt = time.Now()
+	return nil
+}
+
+// AssignOrganization records the organization a simulation's usage is metered against
+func (o *Orchestrator) AssignOrganization(ctx context.Context, id string, organizationID uuid.UUID) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	previous := simulation.OrganizationID
+	simulation.OrganizationID = &organizationID
+	if err := o.saveLocked(ctx, simulation); err != nil {
+		simulation.OrganizationID = previous
+		return err
+	}
+	return nil
+}
+
+// AssignOwner records the user a simulation counts against
+func (o *Orchestrator) AssignOwner(ctx context.Context, id string, userID uuid.UUID) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	previous := simulation.OwnerID
+	simulation.OwnerID = &userID
+	if err := o.saveLocked(ctx, simulation); err != nil {
+		simulation.OwnerID = previous
+		return err
+	}
+	return nil
+}
+
+// RunningByOrganization counts running simulations per organization
+func (o *Orchestrator) RunningByOrganization() map[uuid.UUID]int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	running := make(map[uuid.UUID]int)
+	for _, simulation := range o.simulations {
+		if simulation.Status == StatusRunning && simulation.OrganizationID != nil {
+			running[*simulation.OrganizationID]++
+		}
+	}
+	return running
+}
+
+// Requirements returns the engine requirements implied by a simulation config
+func (c SimulationConfig) Requirements() engine.Requirements {
+	seen := make(map[string]bool)
+	var req engine.Requirements
+
+	add := func(componentType string) {
+		if !seen[componentType] {
+			seen[componentType] = true
+			req.ComponentTypes = append(req.ComponentTypes, componentType)
+		}
+	}
+
+	for _, plant := range c.PowerPlants {
+		add(engine.PlantComponent(plant.Type))
+	}
+	if len(c.TransmissionLines) > 0 {
+		add(engine.ComponentTransmissionLine)
+	}
+	if len(c.StorageUnits) > 0 {
+		add(engine.ComponentStorageUnit)
+		req.Capabilities = append(req.Capabilities, engine.CapabilityStorage)
+	}
+
+	return req
+}
+
 // Start starts the orchestrator
 func (o *Orchestrator) Start(ctx context.Context) error {
 	logrus.Info("Starting simulation orchestrator")
 
 	// Start worker pool
+	o.workerPool.SetStartHandler(o.handleJobStart)
+	o.workerPool.SetCompletionHandler(o.handleJobCompletion)
 	if err := o.workerPool.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start worker pool: %w", err)
 	}
@@ -155,6 +424,10 @@ func (o *Orchestrator) Start(ctx context.Context) error {
 	o.cleanupTicker = time.NewTicker(o.config.CleanupInterval)
 	go o.cleanupLoop()
 
+	go o.transitionHooks.run(o.ctx)
+
+	o.restoreWaiting(ctx)
+
 	logrus.Info("Simulation orchestrator started successfully")
 	return nil
 }
@@ -174,18 +447,29 @@ func (o *Orchestrator) Stop() {
 	logrus.Info("Simulation orchestrator stopped")
 }
 
-// CreateSimulation creates a new simulation
-func (o *Orchestrator) CreateSimulation(name, description string, config SimulationConfig, tags []string, metadata map[string]interface{}) (*Simulation, error) {
+// CreateSimulation creates a new simulation. Its metadata may hold reserved
+// keys, which callers set on behalf of the gateway.
+func (o *Orchestrator) CreateSimulation(ctx context.Context, name, description string, config SimulationConfig, tags []string, meta map[string]interface{}) (*Simulation, error) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	// Check if we've reached the maximum number of simulations
-	if len(o.simulations) >= o.config.MaxConcurrentSimulations {
-		return nil, fmt.Errorf("maximum concurrent simulations reached: %d", o.config.MaxConcurrentSimulations)
+	if err := metadata.Validate(meta, o.metadataLimits); err != nil {
+		return nil, err
 	}
+	return o.createSimulationLocked(ctx, name, description, config, tags, meta)
+}
 
+// createSimulationLocked registers a new simulation (must be called with lock held)
+func (o *Orchestrator) createSimulationLocked(ctx context.Context, name, description string, config SimulationConfig, tags []string, metadata map[string]interface{}) (*Simulation, error) {
 	// Generate unique ID
-	id := generateSimulationID()
+	id := o.newIDLocked()
+	for o.simulations[id] != nil {
+		id = o.newIDLocked()
+	}
+
+	if config.RandomSeed == 0 {
+		config.RandomSeed = uint64(time.Now().UnixNano())
+	}
 
 	simulation := &Simulation{
 		ID:          id,
@@ -197,30 +481,233 @@ func (o *Orchestrator) CreateSimulation(name, description string, config Simulat
 		Metadata:    metadata,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+		Version:     1,
+		Speed:       SpeedRealTime,
 	}
 
 	o.simulations[id] = simulation
+	if err := o.createLocked(ctx, simulation); err != nil {
+		delete(o.simulations, id)
+		return nil, err
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"simulation_id": id,
 		"name":          name,
 		"plants":        len(config.PowerPlants),
 		"lines":         len(config.TransmissionLines),
+		"storage_units": len(config.StorageUnits),
+		"random_seed":   config.RandomSeed,
 	}).Info("Simulation created")
 
 	return simulation, nil
 }
 
-// GetSimulation retrieves a simulation by ID
-func (o *Orchestrator) GetSimulation(id string) (*Simulation, error) {
+// RerunSimulation creates a new simulation with the config, engine, tags
+// and organization of an existing one. With sameSeed the new simulation
+// uses the seed of the original so it reproduces its results; otherwise it
+// gets a fresh seed.
+func (o *Orchestrator) RerunSimulation(ctx context.Context, id string, sameSeed bool) (*Simulation, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	source, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	config := *cloneConfig(source.Config)
+	if !sameSeed {
+		config.RandomSeed = 0
+	}
+
+	meta := make(map[string]interface{}, len(source.Metadata)+1)
+	for key, value := range source.Metadata {
+		meta[key] = value
+	}
+	meta[metadata.KeyRerunOf] = source.ID
+
+	simulation, err := o.createSimulationLocked(ctx, source.Name, source.Description, config, append([]string(nil), source.Tags...), meta)
+	if err != nil {
+		return nil, err
+	}
+	simulation.Engine = source.Engine
+	simulation.Speed = source.Speed
+	simulation.OrganizationID = source.OrganizationID
+	simulation.OwnerID = source.OwnerID
+	if err := o.saveLocked(ctx, simulation); err != nil {
+		o.discardLocked(simulation.ID)
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id":        simulation.ID,
+		"source_simulation_id": source.ID,
+		"same_seed":            sameSeed,
+	}).Info("Simulation rerun created")
+	return simulation, nil
+}
+
+// SimulationSpec is the definition of a simulation without its runtime
+// state, enough for another replica to take it over
+type SimulationSpec struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Engine      string                 `json:"engine,omitempty"`
+	Config      SimulationConfig       `json:"config"`
+	Tags        []string               `json:"tags"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Version     int64                  `json:"version"`
+
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	OwnerID        *uuid.UUID `json:"owner_id,omitempty"`
+
+	// A simulation with dependencies that has not run yet is restored as
+	// waiting for them
+	DependsOn []Dependency `json:"depends_on,omitempty"`
+}
+
+// Spec returns the simulation's definition
+func (s *Simulation) Spec() SimulationSpec {
+	return SimulationSpec{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		Engine:      s.Engine,
+		Config:      s.Config,
+		Tags:        s.Tags,
+		Metadata:    s.Metadata,
+		CreatedAt:   s.CreatedAt,
+		Version:     s.Version,
+
+		OrganizationID: s.OrganizationID,
+		OwnerID:        s.OwnerID,
+
+		DependsOn: s.DependsOn,
+	}
+}
+
+// RestoreSimulation registers an idle simulation from a spec, keeping its ID.
+// A simulation with dependencies waits for them again and starts once they
+// have completed. The ID must be a UUID or a legacy sim_* ID. Restoring a
+// simulation that already exists is a no-op.
+func (o *Orchestrator) RestoreSimulation(spec SimulationSpec) error {
+	if !ids.ValidSimulationID(spec.ID) {
+		return fmt.Errorf("invalid simulation id: %q", spec.ID)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, exists := o.simulations[spec.ID]; exists {
+		return nil
+	}
+
+	simulation := o.restoreLocked(spec, StatusIdle)
+
+	logrus.WithField("simulation_id", spec.ID).Info("Simulation restored")
+
+	if simulation.Status == StatusWaiting {
+		o.startReadyDependentsLocked()
+	}
+	return nil
+}
+
+// GetSimulation retrieves a simulation by ID, loading it from the
+// repository when it is not in memory
+func (o *Orchestrator) GetSimulation(ctx context.Context, id string) (*Simulation, error) {
+	o.mu.RLock()
+	simulation, exists := o.simulations[id]
+	o.mu.RUnlock()
+	if exists {
+		return simulation, nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return simulation, nil
+}
+
+// LoadedSimulation returns a simulation only if it is in memory. Unlike
+// GetSimulation it never loads one from the repository, so it tells whether
+// this process has the simulation rather than whether it exists.
+func (o *Orchestrator) LoadedSimulation(id string) (*Simulation, bool) {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 
 	simulation, exists := o.simulations[id]
-	if !exists {
-		return nil, ErrSimulationNotFound
+	return simulation, exists
+}
+
+// SimulationUpdate holds the fields of a simulation to change. Nil fields are left as they are.
+// Metadata replaces the simulation's metadata except for its reserved keys,
+// which it must not contain.
+type SimulationUpdate struct {
+	Name        *string
+	Description *string
+	Tags        *[]string
+	Metadata    map[string]interface{}
+}
+
+// UpdateSimulation applies an update to a simulation that is still at
+// expectedVersion. When the simulation has moved on it is returned unchanged
+// together with ErrVersionConflict.
+func (o *Orchestrator) UpdateSimulation(ctx context.Context, id string, expectedVersion int64, update SimulationUpdate) (*Simulation, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if simulation.Version != expectedVersion {
+		return simulation, ErrVersionConflict
+	}
+
+	if update.Metadata != nil {
+		if err := metadata.CheckReserved(update.Metadata, o.metadataLimits.ReservedPrefixes); err != nil {
+			return nil, err
+		}
+		update.Metadata = metadata.KeepReserved(update.Metadata, simulation.Metadata, o.metadataLimits.ReservedPrefixes)
+		if err := metadata.Validate(update.Metadata, o.metadataLimits); err != nil {
+			return nil, err
+		}
 	}
 
+	previous := *simulation
+	if update.Name != nil {
+		simulation.Name = *update.Name
+	}
+	if update.Description != nil {
+		simulation.Description = *update.Description
+	}
+	if update.Tags != nil {
+		simulation.Tags = *update.Tags
+	}
+	if update.Metadata != nil {
+		simulation.Metadata = update.Metadata
+	}
+	simulation.Version++
+	simulation.UpdatedAt = time.Now()
+	if err := o.saveLocked(ctx, simulation); err != nil {
+		*simulation = previous
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"version":       simulation.Version,
+	}).Info("Simulation updated")
+
 	return simulation, nil
 }
 
@@ -245,6 +732,14 @@ func (o *Orchestrator) ListSimulations(page, limit int, status string, tags []st
 		filtered = append(filtered, sim)
 	}
 
+	// Newest first, with a stable order so consecutive pages do not overlap
+	sort.Slice(filtered, func(i, j int) bool {
+		if !filtered[i].CreatedAt.Equal(filtered[j].CreatedAt) {
+			return filtered[i].CreatedAt.After(filtered[j].CreatedAt)
+		}
+		return filtered[i].ID < filtered[j].ID
+	})
+
 	// Apply pagination
 	total := len(filtered)
 	start := (page - 1) * limit
@@ -261,126 +756,354 @@ func (o *Orchestrator) ListSimulations(page, limit int, status string, tags []st
 	return filtered[start:end], total, nil
 }
 
-// DeleteSimulation deletes a simulation
-func (o *Orchestrator) DeleteSimulation(id string) error {
+// DeleteSimulation deletes a simulation, removing it from the repository too
+func (o *Orchestrator) DeleteSimulation(ctx context.Context, id string) error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	simulation, exists := o.simulations[id]
-	if !exists {
-		return ErrSimulationNotFound
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if o.repository != nil {
+		if err := o.repository.DeleteSimulation(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete stored simulation: %w", err)
+		}
 	}
 
-	// Stop simulation if it's running
-	if simulation.Status == StatusRunning {
-		if err := o.stopSimulationInternal(id); err != nil {
+	// Kill the run if there is one; its results have nowhere to go
+	switch simulation.Status {
+	case StatusQueued, StatusRunning, StatusPaused:
+		if err := o.stopSimulationInternal(id, StopForce); err != nil {
 			logrus.WithError(err).WithField("simulation_id", id).Error("Failed to stop simulation before deletion")
 		}
 	}
+	o.releaseRunLeaseLocked(simulation)
 
 	delete(o.simulations, id)
 
+	// Dependents waiting on it can no longer start
+	o.startReadyDependentsLocked()
+
 	logrus.WithField("simulation_id", id).Info("Simulation deleted")
 	return nil
 }
 
 // StartSimulation starts a simulation
-func (o *Orchestrator) StartSimulation(id string) error {
+func (o *Orchestrator) StartSimulation(ctx context.Context, id string) error {
+	o.loadConcurrencyState(ctx, id)
+
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	return o.startSimulationInternal(id)
+	return o.startSimulationInternal(ctx, id)
 }
 
-// StopSimulation stops a simulation
-func (o *Orchestrator) StopSimulation(id string) error {
+// StopSimulation stops a running or paused simulation in the given mode. A
+// queued simulation is taken off the queue and goes back to idle.
+func (o *Orchestrator) StopSimulation(ctx context.Context, id string, mode StopMode) error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	return o.stopSimulationInternal(id)
+	if _, err := o.lookupLocked(ctx, id); err != nil {
+		return err
+	}
+	if err := o.stopSimulationInternal(id, mode); err != nil {
+		return err
+	}
+
+	// Dependents waiting on it can no longer start
+	o.startReadyDependentsLocked()
+	return nil
 }
 
 // PauseSimulation pauses a simulation
-func (o *Orchestrator) PauseSimulation(id string) error {
+func (o *Orchestrator) PauseSimulation(ctx context.Context, id string) error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
-	simulation, exists := o.simulations[id]
-	if !exists {
-		return ErrSimulationNotFound
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	if simulation.Status != StatusRunning {
-		return fmt.Errorf("simulation is not running, current status: %s", simulation.Status.String())
+	if err := o.checkTransitionLocked(simulation, StatusPaused); err != nil {
+		return err
+	}
+	if err := o.pauseOnEngineLocked(ctx, simulation); err != nil {
+		return err
 	}
 
-	simulation.Status = StatusPaused
-	simulation.UpdatedAt = time.Now()
+	o.workerPool.PauseJob(id)
+	o.transitionLocked(simulation, StatusPaused, nil)
 
 	logrus.WithField("simulation_id", id).Info("Simulation paused")
 	return nil
 }
 
-// startSimulationInternal starts a simulation (must be called with lock held)
-func (o *Orchestrator) startSimulationInternal(id string) error {
-	simulation, exists := o.simulations[id]
-	if !exists {
-		return ErrSimulationNotFound
+// ResumeSimulation continues a paused simulation from where it was paused
+func (o *Orchestrator) ResumeSimulation(ctx context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	if simulation.Status == StatusRunning {
-		return fmt.Errorf("simulation is already running")
+	if simulation.Status != StatusPaused {
+		return fmt.Errorf("%w, current status: %s", ErrSimulationNotPaused, simulation.Status.String())
+	}
+	if err := o.resumeOnEngineLocked(ctx, simulation); err != nil {
+		return err
+	}
+
+	o.workerPool.ResumeJob(id)
+	o.transitionLocked(simulation, StatusRunning, nil)
+
+	logrus.WithField("simulation_id", id).Info("Simulation resumed")
+	return nil
+}
+
+// startSimulationInternal queues a simulation for the worker pool; it
+// starts on its engine once a worker picks it up (must be called with lock held)
+func (o *Orchestrator) startSimulationInternal(ctx context.Context, id string) error {
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if simulation.Status == StatusPaused {
+		return ErrSimulationPaused
+	}
+	if err := o.checkTransitionLocked(simulation, StatusQueued); err != nil {
+		return err
+	}
+	if err := o.checkCapacityLocked(simulation); err != nil {
+		return err
 	}
 
 	// Create a job for the worker pool
 	job := &SimulationJob{
 		SimulationID: id,
+		Run:          simulation.run + 1,
+		Attempt:      1,
 		Config:       simulation.Config,
-		Status:       &simulation.Status,
-		StartTime:    &simulation.StartTime,
-		EndTime:      &simulation.EndTime,
-		Error:        &simulation.Error,
-		Metrics:      &simulation.EventsProcessed,
+		InitialState: simulation.InitialState,
+		Speed:        simulation.Speed,
+	}
+
+	// Place the simulation on an engine
+	if o.engines != nil {
+		engineName, err := o.engines.Place(simulation.Config.Requirements(), simulation.Engine)
+		if err != nil {
+			return err
+		}
+		simulation.Engine = engineName
+	}
+
+	// Take the run lease so no other replica starts the same simulation
+	if o.locker != nil {
+		lease, ok, err := o.locker.TryAcquire(ctx, RunLockName(id))
+		if err != nil {
+			return fmt.Errorf("failed to acquire run lease: %w", err)
+		}
+		if !ok {
+			return ErrSimulationLocked
+		}
+		simulation.runLease = lease
+		go o.watchRunLease(id, lease)
 	}
 
 	// Submit job to worker pool
 	if err := o.workerPool.SubmitJob(job); err != nil {
+		o.releaseRunLeaseLocked(simulation)
 		return fmt.Errorf("failed to submit simulation job: %w", err)
 	}
 
-	simulation.Status = StatusRunning
-	now := time.Now()
-	simulation.StartTime = &now
-	simulation.UpdatedAt = now
+	simulation.run = job.Run
+	o.transitionLocked(simulation, StatusQueued, nil)
+
+	logrus.WithField("simulation_id", id).Info("Simulation queued")
+	return nil
+}
+
+// handleJobStart is invoked by the worker pool when a worker picks up a
+// job. It starts the run on its engine, or drops the job if the run was
+// canceled while it was queued.
+func (o *Orchestrator) handleJobStart(job *SimulationJob) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, exists := o.simulations[job.SimulationID]
+	if !exists || job.Run != simulation.run || simulation.Status != StatusQueued {
+		return ErrJobCanceled
+	}
+
+	if err := o.startOnEngineLocked(simulation); err != nil {
+		o.failJobLocked(simulation, job, err)
+
+		o.completeBatchInstanceLocked(simulation)
+		o.startReadyDependentsLocked()
+		o.advanceBatchesLocked()
+		return err
+	}
+
+	if o.engines != nil {
+		o.engines.Acquire(simulation.Engine)
+		simulation.engineAcquired = true
+	}
+	o.transitionLocked(simulation, StatusRunning, nil)
 
-	logrus.WithField("simulation_id", id).Info("Simulation started")
+	logrus.WithField("simulation_id", simulation.ID).Info("Simulation started")
 	return nil
 }
 
+// QueueStatus is where a queued simulation stands in the worker pool's queue
+type QueueStatus struct {
+	// 1 is next in line
+	Position int `json:"position"`
+	// Unset until the pool has finished a job to base the estimate on
+	EstimatedStartAt *time.Time `json:"estimated_start_at,omitempty"`
+}
+
+// QueueStatus returns a simulation's place in the queue, or nil when it is
+// not queued
+func (o *Orchestrator) QueueStatus(id string) *QueueStatus {
+	position, startAt, ok := o.workerPool.QueuePosition(id)
+	if !ok {
+		return nil
+	}
+
+	status := &QueueStatus{Position: position}
+	if !startAt.IsZero() {
+		status.EstimatedStartAt = &startAt
+	}
+	return status
+}
+
 // stopSimulationInternal stops a simulation (must be called with lock held)
-func (o *Orchestrator) stopSimulationInternal(id string) error {
+func (o *Orchestrator) stopSimulationInternal(id string, mode StopMode) error {
 	simulation, exists := o.simulations[id]
 	if !exists {
 		return ErrSimulationNotFound
 	}
 
-	if simulation.Status != StatusRunning {
-		return fmt.Errorf("simulation is not running, current status: %s", simulation.Status.String())
+	if simulation.Status == StatusQueued {
+		o.workerPool.RemoveJob(id)
+		o.releaseRunLeaseLocked(simulation)
+		o.transitionLocked(simulation, StatusIdle, ErrStopRequested)
+
+		logrus.WithField("simulation_id", id).Info("Queued simulation canceled")
+		return nil
+	}
+	if err := o.checkTransitionLocked(simulation, StatusCompleted); err != nil {
+		return err
 	}
 
-	// Cancel the job in the worker pool
-	o.workerPool.CancelJob(id)
+	// The engine freezes the run at its current tick either way; only a
+	// graceful stop lets the worker report the tick in progress
+	cause := ErrStopRequested
+	if mode == StopForce {
+		o.workerPool.CancelJob(id)
+		cause = ErrForceStopped
+	} else {
+		o.workerPool.StopJob(id)
+	}
+	o.releaseEngineLocked(simulation)
+	o.releaseRunLeaseLocked(simulation)
 
-	simulation.Status = StatusCompleted
-	now := time.Now()
-	simulation.EndTime = &now
-	simulation.Duration = now.Sub(*simulation.StartTime)
-	simulation.UpdatedAt = now
+	o.transitionLocked(simulation, StatusCompleted, cause)
 
-	logrus.WithField("simulation_id", id).Info("Simulation stopped")
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"mode":          mode,
+	}).Info("Simulation stopped")
 	return nil
 }
 
+// handleJobCompletion is invoked by the worker pool when a job finishes
+func (o *Orchestrator) handleJobCompletion(job *SimulationJob) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, exists := o.simulations[job.SimulationID]
+	if !exists {
+		return
+	}
+
+	// A job outlived by a stop or a newer run has nothing left to report,
+	// and whatever ended its run already released the engine and lease
+	if job.Run == simulation.run && (simulation.Status == StatusRunning || simulation.Status == StatusPaused) {
+		if job.Err != nil {
+			o.failJobLocked(simulation, job, job.Err)
+		} else {
+			o.transitionLocked(simulation, StatusCompleted, nil)
+			o.releaseEngineLocked(simulation)
+			o.releaseRunLeaseLocked(simulation)
+		}
+	}
+
+	o.completeBatchInstanceLocked(simulation)
+	o.startReadyDependentsLocked()
+	o.advanceBatchesLocked()
+}
+
+// releaseEngineLocked stops the simulation on its engine and frees its
+// engine slot (must be called with lock held)
+func (o *Orchestrator) releaseEngineLocked(simulation *Simulation) {
+	o.stopOnEngineLocked(simulation)
+	if o.engines != nil && simulation.engineAcquired {
+		o.engines.Release(simulation.Engine)
+		simulation.engineAcquired = false
+	}
+}
+
+// releaseRunLeaseLocked gives up the simulation's run lease (must be called with lock held)
+func (o *Orchestrator) releaseRunLeaseLocked(simulation *Simulation) {
+	if simulation.runLease == nil {
+		return
+	}
+
+	if err := simulation.runLease.Release(); err != nil {
+		logrus.WithError(err).WithField("simulation_id", simulation.ID).Warn("Failed to release run lease")
+	}
+	simulation.runLease = nil
+}
+
+// watchRunLease stops a run whose lease is lost, since another replica may
+// now be free to start it
+func (o *Orchestrator) watchRunLease(id string, lease lock.Lease) {
+	select {
+	case <-o.ctx.Done():
+		return
+	case <-lease.Done():
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, exists := o.simulations[id]
+	if !exists || simulation.runLease != lease {
+		return
+	}
+	simulation.runLease = nil
+
+	switch simulation.Status {
+	case StatusQueued:
+		o.workerPool.RemoveJob(id)
+	case StatusRunning, StatusPaused:
+		o.workerPool.CancelJob(id)
+	default:
+		return
+	}
+	o.releaseEngineLocked(simulation)
+	o.transitionLocked(simulation, StatusError, ErrRunLeaseLost)
+
+	logrus.WithField("simulation_id", id).Error("Simulation run lease lost, stopping run")
+}
+
 // Health returns the health status of the orchestrator
 func (o *Orchestrator) Health() HealthStatus {
 	o.mu.RLock()
@@ -393,7 +1116,7 @@ func (o *Orchestrator) Health() HealthStatus {
 	}
 
 	// Check if we're at capacity
-	if len(o.simulations) >= o.config.MaxConcurrentSimulations {
+	if o.activeCountLocked() >= o.config.MaxConcurrentSimulations {
 		status.IsHealthy = false
 		status.Message = "At maximum simulation capacity"
 	}
@@ -420,7 +1143,8 @@ func (o *Orchestrator) cleanupLoop() {
 	}
 }
 
-// cleanup removes old completed simulations
+// cleanup removes old completed simulations from memory. Stored ones are
+// loaded again when asked for.
 func (o *Orchestrator) cleanup() {
 	o.mu.Lock()
 	defer o.mu.Unlock()
@@ -428,9 +1152,31 @@ func (o *Orchestrator) cleanup() {
 	cutoff := time.Now().Add(-24 * time.Hour) // Keep completed simulations for 24 hours
 	var toDelete []string
 
+	// Upstreams of waiting simulations stay, so their dependents can tell
+	// how they ended
+	upstreams := make(map[string]bool)
+	for _, sim := range o.simulations {
+		if sim.Status == StatusWaiting {
+			for _, dep := range sim.DependsOn {
+				upstreams[dep.SimulationID] = true
+			}
+		}
+	}
+
 	for id, sim := range o.simulations {
+		if upstreams[id] {
+			continue
+		}
 		if sim.Status == StatusCompleted && sim.EndTime != nil && sim.EndTime.Before(cutoff) {
 			toDelete = append(toDelete, id)
+			continue
+		}
+		// Simulations loaded from the repository on demand stay stored
+		// there, so they only stay in memory while they take part in a run
+		if sim.loaded && !sim.active() && sim.Status != StatusWaiting {
+			if _, inBatch := o.batchOf[id]; !inBatch {
+				toDelete = append(toDelete, id)
+			}
 		}
 	}
 
@@ -446,8 +1192,26 @@ func (o *Orchestrator) cleanup() {
 
 // Helper functions
 
-func generateSimulationID() string {
-	return fmt.Sprintf("sim_%d", time.Now().UnixNano())
+// RunLockName returns the name of the lock held while a simulation runs
+func RunLockName(id string) string {
+	return "voltedge:simulation:" + id
+}
+
+// SetIDGenerator replaces the generator of simulation, batch and
+// experiment IDs
+func (o *Orchestrator) SetIDGenerator(generator ids.Generator) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ids = generator
+}
+
+// newIDLocked generates an ID (must be called with lock held)
+func (o *Orchestrator) newIDLocked() string {
+	if o.ids == nil {
+		return ids.New().String()
+	}
+	return o.ids.New().String()
 }
 
 func hasAnyTag(simulationTags, filterTags []string) bool {
@@ -463,7 +1227,33 @@ func hasAnyTag(simulationTags, filterTags []string) bool {
 
 // Errors
 var (
-	ErrSimulationNotFound = fmt.Errorf("simulation not found")
+	ErrSimulationNotFound  = fmt.Errorf("simulation not found")
+	ErrDependencyCycle     = fmt.Errorf("simulation dependencies contain a cycle")
+	ErrDependencyFailed    = fmt.Errorf("simulation dependency failed")
+	ErrInvalidTopology     = fmt.Errorf("invalid grid topology")
+	ErrBatchNotFound       = fmt.Errorf("batch not found")
+	ErrExperimentNotFound  = fmt.Errorf("experiment not found")
+	ErrInvalidSweep        = fmt.Errorf("invalid parameter sweep")
+	ErrVersionConflict     = fmt.Errorf("simulation was modified by another request")
+	ErrSimulationLocked    = fmt.Errorf("simulation is running on another instance")
+	ErrRunLeaseLost        = fmt.Errorf("simulation run lease was lost")
+	ErrInvalidSpeed        = fmt.Errorf("speed must be 0 (as fast as possible) or at least 0.1")
+	ErrNoEngineController  = fmt.Errorf("no engine connection to control the simulation")
+	ErrInvalidStep         = fmt.Errorf("ticks must be between 1 and 10000")
+	ErrNoEngineRun         = fmt.Errorf("simulation is not running on an engine")
+	ErrInvalidTransition   = fmt.Errorf("invalid simulation status transition")
+	ErrStopRequested       = fmt.Errorf("simulation was stopped")
+	ErrForceStopped        = fmt.Errorf("%w by force", ErrStopRequested)
+	ErrJobCanceled         = fmt.Errorf("simulation job was canceled")
+	ErrInvalidStopMode     = fmt.Errorf("stop mode must be graceful or force")
+	ErrSimulationNotPaused = fmt.Errorf("only paused simulations can be resumed")
+	ErrSimulationPaused    = fmt.Errorf("simulation is paused, resume it instead")
+	ErrQueueFull           = fmt.Errorf("simulation queue is full")
+	ErrTransient           = fmt.Errorf("transient failure")
+	ErrDeadLetterNotFound  = fmt.Errorf("dead-lettered job not found")
+	ErrConcurrencyLimit    = fmt.Errorf("concurrent simulation limit reached")
+	ErrSimulationNotActive = fmt.Errorf("simulation must be running or paused")
+	ErrPlanNotFound        = fmt.Errorf("config plan not found or expired")
+	ErrPlanStale           = fmt.Errorf("simulation was modified since the plan was made")
+	ErrStorageUnavailable  = fmt.Errorf("simulation storage is unavailable")
 )
-
-
@@ -0,0 +1,133 @@
+package orchestration
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Where a worker panic was recovered
+const (
+	// While processing a job, which fails with a PanicError
+	PanicScopeJob = "job"
+	// In the worker loop between jobs; the worker is restarted
+	PanicScopeWorker = "worker"
+)
+
+// PanicError is the failure of a job whose processing panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("simulation job panicked: %v", e.Value)
+}
+
+// WorkerCrash describes a panic recovered in a worker
+type WorkerCrash struct {
+	Scope    string
+	WorkerID int
+	// Empty when the panic was outside a job
+	SimulationID string
+	Panic        *PanicError
+}
+
+// SetCrashHandler registers a callback invoked for every panic recovered in
+// a worker
+func (wp *WorkerPool) SetCrashHandler(handler func(WorkerCrash)) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.onCrash = handler
+}
+
+// SetWorkerCrashHandler registers a callback invoked for every panic
+// recovered in a simulation worker, e.g. to count or report it
+func (o *Orchestrator) SetWorkerCrashHandler(handler func(WorkerCrash)) {
+	o.workerPool.SetCrashHandler(handler)
+}
+
+// recoverJob is deferred by processJob. A panicking job fails with a
+// PanicError, which is reported like any other job failure unless the
+// completion handler already ran, so the worker can take the next job.
+func (w *Worker) recoverJob(job *SimulationJob, completed *bool) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	crash := w.crashed(PanicScopeJob, job.SimulationID, value)
+
+	if *completed || w.ctx.Err() != nil {
+		return
+	}
+	w.pool.mu.RLock()
+	onComplete := w.pool.onComplete
+	w.pool.mu.RUnlock()
+	if onComplete == nil {
+		return
+	}
+
+	job.Err = crash
+	job.EndTime = time.Now()
+	*completed = true
+	w.guard("completion handler", func() { onComplete(job) })
+}
+
+// restartOnPanic is deferred by run. A panic outside a job would end the
+// worker's goroutine and shrink the pool, so the worker is started again.
+func (w *Worker) restartOnPanic() {
+	value := recover()
+	if value == nil {
+		return
+	}
+	w.crashed(PanicScopeWorker, "", value)
+
+	if w.ctx.Err() == nil {
+		go w.run()
+	}
+}
+
+// crashed logs a recovered panic and passes it to the crash handler
+func (w *Worker) crashed(scope, simulationID string, value any) *PanicError {
+	crash := &PanicError{Value: value, Stack: debug.Stack()}
+
+	logrus.WithFields(logrus.Fields{
+		"worker_id":     w.id,
+		"simulation_id": simulationID,
+		"scope":         scope,
+		"panic":         fmt.Sprint(value),
+		"stack":         string(crash.Stack),
+	}).Error("Recovered from panic in simulation worker")
+
+	w.pool.mu.RLock()
+	onCrash := w.pool.onCrash
+	w.pool.mu.RUnlock()
+	if onCrash != nil {
+		w.guard("crash handler", func() {
+			onCrash(WorkerCrash{
+				Scope:        scope,
+				WorkerID:     w.id,
+				SimulationID: simulationID,
+				Panic:        crash,
+			})
+		})
+	}
+	return crash
+}
+
+// guard calls a handler while recovering, so a handler panicking on the
+// way out of a panic cannot take the worker down
+func (w *Worker) guard(name string, handler func()) {
+	defer func() {
+		if value := recover(); value != nil {
+			logrus.WithFields(logrus.Fields{
+				"worker_id": w.id,
+				"panic":     fmt.Sprint(value),
+			}).Errorf("Worker %s panicked", name)
+		}
+	}()
+	handler()
+}
@@ -0,0 +1,242 @@
+package orchestration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// PlanTTL is how long a config plan can be applied after it was made
+const PlanTTL = 15 * time.Minute
+
+// ConfigPlan is a computed change to the config of a running simulation.
+// It is applied as planned only while the simulation is still at the
+// version it was planned against.
+type ConfigPlan struct {
+	ID           string     `json:"id"`
+	SimulationID string     `json:"simulation_id"`
+	BaseVersion  int64      `json:"base_version"`
+	Changes      ConfigDiff `json:"changes"`
+	Impact       PlanImpact `json:"impact"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+
+	config SimulationConfig
+}
+
+// StalePlanError is returned when a plan no longer applies because the
+// simulation was modified since it was made. It matches ErrPlanStale.
+type StalePlanError struct {
+	// The simulation's version when the plan was rejected
+	Version int64
+}
+
+func (e *StalePlanError) Error() string {
+	return ErrPlanStale.Error()
+}
+
+// Is makes errors.Is(err, ErrPlanStale) match any stale plan error
+func (e *StalePlanError) Is(target error) bool {
+	return target == ErrPlanStale
+}
+
+// PlanChange is a quantity before and after a planned change
+type PlanChange struct {
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Delta  float64 `json:"delta"`
+}
+
+// PlanImpact estimates what a planned change does to the grid. Capacities
+// count operational components only; the reserve margin is the share of
+// peak load the generation capacity exceeds it by.
+type PlanImpact struct {
+	GenerationCapacityMW   PlanChange `json:"generation_capacity_mw"`
+	StorageCapacityMWh     PlanChange `json:"storage_capacity_mwh"`
+	TransmissionCapacityMW PlanChange `json:"transmission_capacity_mw"`
+	PeakLoadMW             PlanChange `json:"peak_load_mw"`
+	ReserveMarginPercent   PlanChange `json:"reserve_margin_percent"`
+	IslandsBefore          int        `json:"islands_before"`
+	IslandsAfter           int        `json:"islands_after"`
+	// Feasibility problems of the initial dispatch of the new config
+	Warnings []PowerFlowWarning `json:"warnings"`
+}
+
+// EstimateImpact estimates the impact of changing a config from base to other
+func EstimateImpact(base, other SimulationConfig) PlanImpact {
+	before, after := gridTotals(base), gridTotals(other)
+
+	return PlanImpact{
+		GenerationCapacityMW:   planChange(before.generationMW, after.generationMW),
+		StorageCapacityMWh:     planChange(before.storageMWh, after.storageMWh),
+		TransmissionCapacityMW: planChange(before.transmissionMW, after.transmissionMW),
+		PeakLoadMW:             planChange(before.peakLoadMW, after.peakLoadMW),
+		ReserveMarginPercent:   planChange(before.reserveMargin(), after.reserveMargin()),
+		IslandsBefore:          len(base.BuildTopology().Islands),
+		IslandsAfter:           len(other.BuildTopology().Islands),
+		Warnings:               other.CheckPowerFlow().Warnings,
+	}
+}
+
+// gridCapacity sums the capacities of a config's operational components
+type gridCapacity struct {
+	generationMW   float64
+	storageMWh     float64
+	transmissionMW float64
+	peakLoadMW     float64
+}
+
+func gridTotals(c SimulationConfig) gridCapacity {
+	var totals gridCapacity
+	for _, plant := range c.PowerPlants {
+		if plant.IsOperational {
+			totals.generationMW += plant.MaxCapacityMW
+		}
+	}
+	for _, unit := range c.StorageUnits {
+		if unit.IsOperational {
+			totals.storageMWh += unit.CapacityMWh
+		}
+	}
+	for _, line := range c.TransmissionLines {
+		if line.IsOperational {
+			totals.transmissionMW += line.CapacityMW
+		}
+	}
+	totals.peakLoadMW = c.LoadProfile.BaseLoadMW * math.Max(c.LoadProfile.PeakMultiplier, 1)
+	return totals
+}
+
+func (g gridCapacity) reserveMargin() float64 {
+	if g.peakLoadMW <= 0 {
+		return 0
+	}
+	return (g.generationMW - g.peakLoadMW) / g.peakLoadMW * 100
+}
+
+func planChange(before, after float64) PlanChange {
+	return PlanChange{Before: before, After: after, Delta: after - before}
+}
+
+// PlanConfigChange computes the change set and impact of replacing the
+// config of a running or paused simulation, and keeps the plan for
+// ApplyConfigPlan. A zero random seed keeps the simulation's seed.
+func (o *Orchestrator) PlanConfigChange(ctx context.Context, id string, config SimulationConfig) (*ConfigPlan, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if simulation.Status != StatusRunning && simulation.Status != StatusPaused {
+		return nil, fmt.Errorf("%w, current status: %s", ErrSimulationNotActive, simulation.Status.String())
+	}
+
+	if config.RandomSeed == 0 {
+		config.RandomSeed = simulation.Config.RandomSeed
+	}
+
+	now := time.Now()
+	o.prunePlansLocked(now)
+
+	plan := &ConfigPlan{
+		ID:           uuid.NewString(),
+		SimulationID: id,
+		BaseVersion:  simulation.Version,
+		Changes:      DiffConfigs(simulation.Config, config),
+		Impact:       EstimateImpact(simulation.Config, config),
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(PlanTTL),
+		config:       config,
+	}
+	o.plans[plan.ID] = plan
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"plan_id":       plan.ID,
+		"identical":     plan.Changes.Identical,
+	}).Info("Simulation config change planned")
+
+	return plan, nil
+}
+
+// ApplyConfigPlan applies a plan to the simulation it was made for, on the
+// engine first and then to the stored config and components; the engine is
+// put back on the previous config when they cannot be stored. The plan is
+// used up once it is applied. A plan made against an older version fails
+// with a StalePlanError.
+func (o *Orchestrator) ApplyConfigPlan(ctx context.Context, id, planID string) (*Simulation, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.prunePlansLocked(time.Now())
+	plan, exists := o.plans[planID]
+	if !exists || plan.SimulationID != id {
+		return nil, ErrPlanNotFound
+	}
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if simulation.Version != plan.BaseVersion {
+		delete(o.plans, planID)
+		return nil, &StalePlanError{Version: simulation.Version}
+	}
+
+	if simulation.Status != StatusRunning && simulation.Status != StatusPaused {
+		return nil, fmt.Errorf("%w, current status: %s", ErrSimulationNotActive, simulation.Status.String())
+	}
+
+	if o.engineController == nil {
+		return nil, ErrNoEngineController
+	}
+
+	raw, err := json.Marshal(plan.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	engineCtx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+	defer cancel()
+
+	if err := o.engineController.UpdateSimulationConfig(engineCtx, id, string(raw)); err != nil {
+		return nil, fmt.Errorf("failed to apply config on engine: %w", err)
+	}
+
+	previous := *simulation
+	simulation.Config = plan.config
+	simulation.Version++
+	simulation.UpdatedAt = time.Now()
+	if err := o.saveConfigLocked(ctx, simulation); err != nil {
+		*simulation = previous
+		o.revertEngineConfigLocked(simulation, err)
+		return nil, err
+	}
+	delete(o.plans, planID)
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"plan_id":       planID,
+		"version":       simulation.Version,
+	}).Info("Simulation config plan applied")
+
+	return simulation, nil
+}
+
+// prunePlansLocked drops expired plans (must be called with lock held)
+func (o *Orchestrator) prunePlansLocked(now time.Time) {
+	for planID, plan := range o.plans {
+		if now.After(plan.ExpiresAt) {
+			delete(o.plans, planID)
+		}
+	}
+}
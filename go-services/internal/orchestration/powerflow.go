@@ -0,0 +1,292 @@
+package orchestration
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Power flow warning codes
+const (
+	WarningImbalance       = "imbalance"
+	WarningIslandImbalance = "island_imbalance"
+	WarningOverCapacity    = "plant_over_capacity"
+	WarningLineOverload    = "line_overload"
+	WarningFlowSkipped     = "power_flow_skipped"
+)
+
+const (
+	// Reactance assumed for lines that do not specify one, typical of overhead lines
+	defaultReactancePerKM = 0.4
+	// Larger grids are only checked for balance, keeping the check cheap
+	maxPowerFlowBuses = 1000
+	// Mismatches below this fraction of load (or 1 MW) are ignored
+	balanceTolerance = 0.01
+)
+
+// PowerFlowWarning is a feasibility problem found in a config's initial dispatch
+type PowerFlowWarning struct {
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	LineID  string  `json:"line_id,omitempty"`
+	PlantID string  `json:"plant_id,omitempty"`
+	ValueMW float64 `json:"value_mw,omitempty"`
+	LimitMW float64 `json:"limit_mw,omitempty"`
+}
+
+// LineFlow is the DC power flow estimate on a transmission line. Positive
+// flows run from FromNode to ToNode.
+type LineFlow struct {
+	LineID         string  `json:"line_id"`
+	FlowMW         float64 `json:"flow_mw"`
+	CapacityMW     float64 `json:"capacity_mw"`
+	LoadingPercent float64 `json:"loading_percent"`
+}
+
+// PowerFlowReport is the result of checking a config's initial dispatch
+type PowerFlowReport struct {
+	GenerationMW float64            `json:"generation_mw"`
+	LoadMW       float64            `json:"load_mw"`
+	ImbalanceMW  float64            `json:"imbalance_mw"`
+	LineFlows    []LineFlow         `json:"line_flows,omitempty"`
+	Warnings     []PowerFlowWarning `json:"warnings"`
+}
+
+// CheckPowerFlow runs a DC power flow over the initial dispatch: operational
+// plants at their current output against the base load. Configs carry no
+// per-bus loads, so the base load is spread evenly over the buses. Each
+// island's mismatch is shared by its generators in proportion to their output.
+// Without explicit buses only the overall balance is checked.
+func (c SimulationConfig) CheckPowerFlow() PowerFlowReport {
+	report := PowerFlowReport{
+		LoadMW:   c.LoadProfile.BaseLoadMW,
+		Warnings: []PowerFlowWarning{},
+	}
+
+	for _, plant := range c.PowerPlants {
+		if !plant.IsOperational {
+			continue
+		}
+		report.GenerationMW += plant.CurrentOutputMW
+		if plant.MaxCapacityMW > 0 && plant.CurrentOutputMW > plant.MaxCapacityMW {
+			report.Warnings = append(report.Warnings, PowerFlowWarning{
+				Code:    WarningOverCapacity,
+				Message: fmt.Sprintf("power plant %s outputs %.1f MW above its %.1f MW capacity", plant.ID, plant.CurrentOutputMW, plant.MaxCapacityMW),
+				PlantID: plant.ID,
+				ValueMW: plant.CurrentOutputMW,
+				LimitMW: plant.MaxCapacityMW,
+			})
+		}
+	}
+
+	report.ImbalanceMW = report.GenerationMW - report.LoadMW
+	if exceedsTolerance(report.ImbalanceMW, report.LoadMW) {
+		report.Warnings = append(report.Warnings, PowerFlowWarning{
+			Code:    WarningImbalance,
+			Message: fmt.Sprintf("generation of %.1f MW does not match load of %.1f MW", report.GenerationMW, report.LoadMW),
+			ValueMW: report.ImbalanceMW,
+		})
+	}
+
+	if len(c.Buses) == 0 {
+		return report
+	}
+	if len(c.Buses) > maxPowerFlowBuses {
+		report.Warnings = append(report.Warnings, PowerFlowWarning{
+			Code:    WarningFlowSkipped,
+			Message: fmt.Sprintf("line flows are not checked for grids over %d buses", maxPowerFlowBuses),
+		})
+		return report
+	}
+
+	c.solveDCPowerFlow(&report)
+	return report
+}
+
+// exceedsTolerance reports whether a mismatch is significant relative to load
+func exceedsTolerance(mismatch, load float64) bool {
+	return math.Abs(mismatch) > math.Max(1, balanceTolerance*math.Abs(load))
+}
+
+// solveDCPowerFlow computes line flows island by island and flags overloads
+func (c SimulationConfig) solveDCPowerFlow(report *PowerFlowReport) {
+	topology := c.BuildTopology()
+
+	index := make(map[string]int, len(c.Buses))
+	for i, bus := range c.Buses {
+		index[bus.ID] = i
+	}
+
+	generation := make([]float64, len(c.Buses))
+	for _, plant := range c.PowerPlants {
+		if i, ok := index[plant.BusID]; ok && plant.IsOperational {
+			generation[i] += plant.CurrentOutputMW
+		}
+	}
+	busLoad := c.LoadProfile.BaseLoadMW / float64(len(c.Buses))
+
+	// Net injection per bus after each island's generators pick up its mismatch
+	injection := make([]float64, len(c.Buses))
+	for _, island := range topology.Islands {
+		var islandGeneration float64
+		for _, id := range island {
+			islandGeneration += generation[index[id]]
+		}
+		islandLoad := busLoad * float64(len(island))
+		mismatch := islandGeneration - islandLoad
+
+		if len(topology.Islands) > 1 && exceedsTolerance(mismatch, islandLoad) {
+			report.Warnings = append(report.Warnings, PowerFlowWarning{
+				Code:    WarningIslandImbalance,
+				Message: fmt.Sprintf("island containing bus %s has %.1f MW generation for %.1f MW load", island[0], islandGeneration, islandLoad),
+				ValueMW: mismatch,
+			})
+		}
+
+		for _, id := range island {
+			i := index[id]
+			injection[i] = generation[i] - busLoad
+			if islandGeneration > 0 {
+				injection[i] -= mismatch * generation[i] / islandGeneration
+			} else {
+				// Nothing can serve this island; leave its buses balanced
+				injection[i] = 0
+			}
+		}
+	}
+
+	angles := c.busAngles(topology, index, injection)
+
+	for _, line := range c.TransmissionLines {
+		if !line.IsOperational {
+			continue
+		}
+		from, to := index[line.FromNode], index[line.ToNode]
+		flow := (angles[from] - angles[to]) / lineReactance(line)
+
+		lineFlow := LineFlow{LineID: line.ID, FlowMW: flow, CapacityMW: line.CapacityMW}
+		if line.CapacityMW > 0 {
+			lineFlow.LoadingPercent = math.Abs(flow) / line.CapacityMW * 100
+			if math.Abs(flow) > line.CapacityMW {
+				report.Warnings = append(report.Warnings, PowerFlowWarning{
+					Code:    WarningLineOverload,
+					Message: fmt.Sprintf("line %s carries %.1f MW over its %.1f MW capacity", line.ID, math.Abs(flow), line.CapacityMW),
+					LineID:  line.ID,
+					ValueMW: math.Abs(flow),
+					LimitMW: line.CapacityMW,
+				})
+			}
+		}
+		report.LineFlows = append(report.LineFlows, lineFlow)
+	}
+
+	sort.Slice(report.LineFlows, func(i, j int) bool {
+		return report.LineFlows[i].LoadingPercent > report.LineFlows[j].LoadingPercent
+	})
+}
+
+// busAngles solves B·θ = P for the bus voltage angles, with the first bus of
+// each island as its reference at angle zero
+func (c SimulationConfig) busAngles(topology Topology, index map[string]int, injection []float64) []float64 {
+	n := len(c.Buses)
+	susceptance := make([][]float64, n)
+	for i := range susceptance {
+		susceptance[i] = make([]float64, n)
+	}
+	for _, line := range c.TransmissionLines {
+		if !line.IsOperational {
+			continue
+		}
+		from, to := index[line.FromNode], index[line.ToNode]
+		b := 1 / lineReactance(line)
+		susceptance[from][from] += b
+		susceptance[to][to] += b
+		susceptance[from][to] -= b
+		susceptance[to][from] -= b
+	}
+
+	reference := make(map[int]bool, len(topology.Islands))
+	for _, island := range topology.Islands {
+		reference[index[island[0]]] = true
+	}
+
+	// Reduce the system by dropping the reference buses
+	var unknowns []int
+	for i := 0; i < n; i++ {
+		if !reference[i] {
+			unknowns = append(unknowns, i)
+		}
+	}
+
+	m := len(unknowns)
+	matrix := make([][]float64, m)
+	rhs := make([]float64, m)
+	for row, i := range unknowns {
+		matrix[row] = make([]float64, m)
+		for col, j := range unknowns {
+			matrix[row][col] = susceptance[i][j]
+		}
+		rhs[row] = injection[i]
+	}
+
+	solution := solveLinear(matrix, rhs)
+
+	angles := make([]float64, n)
+	for row, i := range unknowns {
+		angles[i] = solution[row]
+	}
+	return angles
+}
+
+// lineReactance returns a line's total reactance, assuming a typical value
+// when none is configured
+func lineReactance(line TransmissionLineConfig) float64 {
+	length := math.Max(line.LengthKM, 1)
+	if line.ReactancePerKM > 0 {
+		return line.ReactancePerKM * length
+	}
+	return defaultReactancePerKM * length
+}
+
+// solveLinear solves a·x = b by Gaussian elimination with partial pivoting.
+// a and b are overwritten.
+func solveLinear(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			continue
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			if factor == 0 {
+				continue
+			}
+			for k := col; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		if math.Abs(a[row][row]) < 1e-12 {
+			continue
+		}
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x
+}
@@ -0,0 +1,91 @@
+package orchestration
+
+import (
+	"math"
+	"time"
+)
+
+// Progress is how far a run is towards the target its config declares
+type Progress struct {
+	// 0-100; with both targets set, the one closer to being reached
+	Percent               float64 `json:"percent"`
+	Ticks                 int64   `json:"ticks"`
+	TargetTicks           int64   `json:"target_ticks,omitempty"`
+	ElapsedSeconds        float64 `json:"elapsed_seconds"`
+	TargetDurationSeconds float64 `json:"target_duration_seconds,omitempty"`
+	// Ticks per second of running time observed so far
+	TickRate float64 `json:"tick_rate"`
+	// Set while the run is running and the rate allows an estimate
+	ETASeconds          *float64   `json:"eta_seconds,omitempty"`
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty"`
+}
+
+// HasTarget reports whether the config declares a run length to measure
+// progress against
+func (c SimulationConfig) HasTarget() bool {
+	return c.TargetTicks > 0 || c.TargetDurationSeconds > 0
+}
+
+// Progress computes the simulation's progress as of now from the ticks its
+// engine has reported and the time it has been running, not counting pauses. It returns nil when
+// the config declares no target.
+func (s *Simulation) Progress(now time.Time) *Progress {
+	if !s.Config.HasTarget() {
+		return nil
+	}
+
+	progress := &Progress{
+		Ticks:                 s.ticksMeasured,
+		TargetTicks:           s.Config.TargetTicks,
+		TargetDurationSeconds: s.Config.TargetDurationSeconds,
+	}
+	if s.StartTime != nil {
+		end := now
+		switch {
+		case s.Status == StatusPaused && !s.pausedAt.IsZero():
+			end = s.pausedAt
+		case s.Status != StatusRunning && s.Status != StatusPaused && s.EndTime != nil:
+			end = *s.EndTime
+		}
+		if elapsed := (end.Sub(*s.StartTime) - s.pausedFor).Seconds(); elapsed > 0 {
+			progress.ElapsedSeconds = elapsed
+			progress.TickRate = float64(progress.Ticks) / elapsed
+		}
+	}
+
+	eta := math.Inf(1)
+	if progress.TargetTicks > 0 {
+		progress.Percent = 100 * float64(progress.Ticks) / float64(progress.TargetTicks)
+		if progress.TickRate > 0 {
+			remaining := math.Max(float64(progress.TargetTicks-progress.Ticks), 0)
+			eta = remaining / progress.TickRate
+		}
+	}
+	if progress.TargetDurationSeconds > 0 {
+		progress.Percent = math.Max(progress.Percent, 100*progress.ElapsedSeconds/progress.TargetDurationSeconds)
+		eta = math.Min(eta, math.Max(progress.TargetDurationSeconds-progress.ElapsedSeconds, 0))
+	}
+
+	if progress.Percent > 100 || s.Status == StatusCompleted {
+		progress.Percent = 100
+	}
+	if s.Status == StatusRunning && !math.IsInf(eta, 1) {
+		completion := now.Add(time.Duration(eta * float64(time.Second)))
+		progress.ETASeconds = &eta
+		progress.EstimatedCompletion = &completion
+	}
+	return progress
+}
+
+// SimulationProgress returns a simulation's progress as of now, or nil when
+// its config declares no target
+func (o *Orchestrator) SimulationProgress(id string) (*Progress, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	simulation, exists := o.simulations[id]
+	if !exists {
+		return nil, ErrSimulationNotFound
+	}
+	return simulation.Progress(time.Now()), nil
+}
@@ -0,0 +1,190 @@
+package orchestration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Repository stores simulations under the same ID the orchestrator gives
+// them, so a simulation outlives the process that created it and is loaded
+// again whenever it is asked for
+type Repository interface {
+	// CreateSimulation stores a new simulation together with the components
+	// of its config, all or nothing
+	CreateSimulation(ctx context.Context, spec SimulationSpec) error
+	// SaveSimulation creates or replaces a simulation's definition
+	SaveSimulation(ctx context.Context, spec SimulationSpec) error
+	// SaveSimulationConfig replaces a simulation's definition after its
+	// config changed, together with the components of the new config, all
+	// or nothing
+	SaveSimulationConfig(ctx context.Context, spec SimulationSpec) error
+	// LoadSimulation returns a stored simulation, or nil when there is none
+	LoadSimulation(ctx context.Context, id string) (*StoredSimulation, error)
+	// LoadWaitingSimulations returns the stored simulations that have
+	// dependencies and have not run yet
+	LoadWaitingSimulations(ctx context.Context) ([]StoredSimulation, error)
+	DeleteSimulation(ctx context.Context, id string) error
+}
+
+// StoredSimulation is a simulation as a Repository keeps it
+type StoredSimulation struct {
+	Spec SimulationSpec
+	// How its last run ended: StatusCompleted or StatusError. Simulations
+	// that never ran, or whose run was cut off, are StatusIdle.
+	Status SimulationStatus
+}
+
+// SetRepository sets where simulations are stored. Without one they only
+// live in memory.
+func (o *Orchestrator) SetRepository(repository Repository) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.repository = repository
+}
+
+// lookupLocked returns a simulation, loading it from the repository when it
+// is not in memory (must be called with the write lock held). It returns
+// ErrSimulationNotFound when there is no such simulation, and an error
+// matching ErrStorageUnavailable when it could not be loaded.
+func (o *Orchestrator) lookupLocked(ctx context.Context, id string) (*Simulation, error) {
+	if simulation, exists := o.simulations[id]; exists {
+		return simulation, nil
+	}
+	if o.repository == nil {
+		return nil, ErrSimulationNotFound
+	}
+
+	stored, err := o.repository.LoadSimulation(ctx, id)
+	if err != nil {
+		logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to load simulation")
+		return nil, fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+	}
+	if stored == nil {
+		return nil, ErrSimulationNotFound
+	}
+
+	simulation := o.restoreLocked(stored.Spec, stored.Status)
+	// Loaded simulations are dropped again once they are not running, see cleanup
+	simulation.loaded = true
+
+	logrus.WithField("simulation_id", id).Debug("Simulation loaded")
+	return simulation, nil
+}
+
+// restoreWaiting loads the stored simulations that wait for their
+// dependencies, so they still start once those complete after a restart
+func (o *Orchestrator) restoreWaiting(ctx context.Context) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.repository == nil {
+		return
+	}
+
+	stored, err := o.repository.LoadWaitingSimulations(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load waiting simulations")
+		return
+	}
+
+	restored := 0
+	for _, waiting := range stored {
+		if _, exists := o.simulations[waiting.Spec.ID]; exists {
+			continue
+		}
+		simulation := o.restoreLocked(waiting.Spec, waiting.Status)
+		simulation.loaded = true
+		restored++
+	}
+	if restored == 0 {
+		return
+	}
+
+	logrus.WithField("count", restored).Info("Restored waiting simulations")
+	o.startReadyDependentsLocked()
+}
+
+// restoreLocked registers a simulation from its spec in the given status
+// (must be called with lock held). An idle simulation with dependencies is
+// restored waiting for them. Only running jobs count against
+// MaxConcurrentSimulations, so restoring one is never refused.
+func (o *Orchestrator) restoreLocked(spec SimulationSpec, status SimulationStatus) *Simulation {
+	if status == StatusIdle && len(spec.DependsOn) > 0 {
+		status = StatusWaiting
+	}
+	simulation := &Simulation{
+		ID:          spec.ID,
+		Name:        spec.Name,
+		Description: spec.Description,
+		Status:      status,
+		Engine:      spec.Engine,
+		Config:      spec.Config,
+		Tags:        spec.Tags,
+		Metadata:    spec.Metadata,
+		CreatedAt:   spec.CreatedAt,
+		UpdatedAt:   time.Now(),
+		Version:     spec.Version,
+		Speed:       SpeedRealTime,
+
+		OrganizationID: spec.OrganizationID,
+		OwnerID:        spec.OwnerID,
+
+		DependsOn: spec.DependsOn,
+	}
+	o.simulations[spec.ID] = simulation
+	return simulation
+}
+
+// createLocked stores a new simulation in the repository, if there is one
+// (must be called with lock held)
+func (o *Orchestrator) createLocked(ctx context.Context, simulation *Simulation) error {
+	if o.repository == nil {
+		return nil
+	}
+	if err := o.repository.CreateSimulation(ctx, simulation.Spec()); err != nil {
+		return fmt.Errorf("failed to store simulation: %w", err)
+	}
+	return nil
+}
+
+// saveLocked stores a simulation's definition in the repository, if there
+// is one (must be called with lock held)
+func (o *Orchestrator) saveLocked(ctx context.Context, simulation *Simulation) error {
+	if o.repository == nil {
+		return nil
+	}
+	if err := o.repository.SaveSimulation(ctx, simulation.Spec()); err != nil {
+		return fmt.Errorf("failed to store simulation: %w", err)
+	}
+	return nil
+}
+
+// saveConfigLocked stores a simulation's definition and the components of
+// its changed config in the repository, if there is one (must be called
+// with lock held)
+func (o *Orchestrator) saveConfigLocked(ctx context.Context, simulation *Simulation) error {
+	if o.repository == nil {
+		return nil
+	}
+	if err := o.repository.SaveSimulationConfig(ctx, simulation.Spec()); err != nil {
+		return fmt.Errorf("failed to store simulation config: %w", err)
+	}
+	return nil
+}
+
+// discardLocked removes a simulation that never became a run of its own,
+// such as a canceled batch instance, from memory and the repository
+// (must be called with lock held)
+func (o *Orchestrator) discardLocked(id string) {
+	delete(o.simulations, id)
+	if o.repository == nil {
+		return
+	}
+	if err := o.repository.DeleteSimulation(o.ctx, id); err != nil {
+		logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to delete stored simulation")
+	}
+}
@@ -0,0 +1,71 @@
+package orchestration
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SpeedRealTime runs one simulated second per wall-clock second
+	SpeedRealTime = 1.0
+	// SpeedUnlimited runs ticks back to back, as fast as the engine can
+	SpeedUnlimited = 0.0
+	// MinSpeed is the slowest supported real-time factor
+	MinSpeed = 0.1
+)
+
+// ValidateSpeed checks a real-time factor is SpeedUnlimited or at least MinSpeed
+func ValidateSpeed(speed float64) error {
+	if math.IsNaN(speed) || math.IsInf(speed, 0) {
+		return ErrInvalidSpeed
+	}
+	if speed != SpeedUnlimited && speed < MinSpeed {
+		return ErrInvalidSpeed
+	}
+	return nil
+}
+
+// SetSimulationSpeed changes a simulation's real-time factor, propagating it
+// to the engine when the simulation is running or paused. Without an engine
+// controller the change takes effect the next time the simulation starts.
+func (o *Orchestrator) SetSimulationSpeed(ctx context.Context, id string, speed float64) (*Simulation, error) {
+	if err := ValidateSpeed(speed); err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	simulation, err := o.lookupLocked(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if simulation.Status == StatusCompleted || simulation.Status == StatusError {
+		return nil, fmt.Errorf("cannot change the speed of a %s simulation", simulation.Status.String())
+	}
+
+	active := simulation.Status == StatusRunning || simulation.Status == StatusPaused
+	if active && o.engineController != nil {
+		ctx, cancel := context.WithTimeout(ctx, engineControlTimeout)
+		defer cancel()
+
+		if err := o.engineController.SetSimulationSpeed(ctx, id, speed); err != nil {
+			return nil, fmt.Errorf("failed to change engine speed: %w", err)
+		}
+	}
+
+	simulation.Speed = speed
+	simulation.UpdatedAt = time.Now()
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": id,
+		"speed":         speed,
+	}).Info("Simulation speed changed")
+
+	return simulation, nil
+}
@@ -0,0 +1,217 @@
+package orchestration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// transitions lists the statuses a simulation may move to from each status
+var transitions = map[SimulationStatus][]SimulationStatus{
+	StatusIdle:    {StatusWaiting, StatusQueued, StatusError},
+	StatusWaiting: {StatusIdle, StatusError},
+	// A queued simulation canceled before it starts goes back to idle
+	StatusQueued: {StatusRunning, StatusIdle, StatusError},
+	// A run failing transiently is queued again to be retried
+	StatusRunning: {StatusPaused, StatusCompleted, StatusError, StatusQueued},
+	StatusPaused:  {StatusRunning, StatusCompleted, StatusError},
+	// A finished simulation can be started again
+	StatusCompleted: {StatusQueued},
+	StatusError:     {StatusQueued},
+}
+
+// CanTransition reports whether a simulation may move from one status to another
+func CanTransition(from, to SimulationStatus) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError is returned when a simulation is asked to move to a status
+// its current status does not allow. It matches ErrInvalidTransition.
+type TransitionError struct {
+	SimulationID string
+	From         SimulationStatus
+	To           SimulationStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("simulation %s cannot move from %s to %s", e.SimulationID, e.From, e.To)
+}
+
+// Is makes errors.Is(err, ErrInvalidTransition) match any transition error
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Transition is a change of a simulation's status
+type Transition struct {
+	SimulationID string
+	From         SimulationStatus
+	To           SimulationStatus
+	At           time.Time
+	// Why the simulation moved to StatusError, or ErrStopRequested (or
+	// ErrForceStopped, which matches it) when a run was stopped instead of
+	// finishing on its own
+	Err error
+	// How long the run lasted, once it has ended
+	Duration time.Duration
+}
+
+// TransitionHook is called with every transition. Hooks run one at a time,
+// in the order the transitions happened, outside the orchestrator's lock.
+type TransitionHook func(Transition)
+
+// OnTransition registers a hook called after each status transition
+func (o *Orchestrator) OnTransition(hook TransitionHook) {
+	o.transitionHooks.add(hook)
+}
+
+// checkTransitionLocked returns the error moving the simulation to the
+// given status would fail with (must be called with lock held)
+func (o *Orchestrator) checkTransitionLocked(simulation *Simulation, to SimulationStatus) error {
+	if !CanTransition(simulation.Status, to) {
+		return &TransitionError{SimulationID: simulation.ID, From: simulation.Status, To: to}
+	}
+	return nil
+}
+
+// transitionLocked moves a simulation to a new status, keeping its run times
+// in step, and queues the transition for the hooks. cause is recorded as the
+// simulation's error when it moves to StatusError. (must be called with lock held)
+func (o *Orchestrator) transitionLocked(simulation *Simulation, to SimulationStatus, cause error) error {
+	if err := o.checkTransitionLocked(simulation, to); err != nil {
+		return err
+	}
+
+	from := simulation.Status
+	now := time.Now()
+	simulation.Status = to
+	simulation.UpdatedAt = now
+	if from == StatusPaused {
+		simulation.pausedFor += now.Sub(simulation.pausedAt)
+	}
+
+	switch to {
+	case StatusQueued:
+		simulation.StoppedReason = ""
+	case StatusIdle:
+		if from == StatusQueued {
+			simulation.StoppedReason = StopReasonCanceled
+		}
+	case StatusRunning:
+		if from != StatusPaused {
+			simulation.StartTime = &now
+			simulation.EndTime = nil
+			simulation.Duration = 0
+			simulation.Error = nil
+			simulation.StoppedReason = ""
+			simulation.Diverged = false
+			simulation.plantOutputs = nil
+			simulation.pausedFor = 0
+		}
+	case StatusPaused:
+		simulation.pausedAt = now
+	case StatusCompleted, StatusError:
+		if to == StatusError {
+			simulation.Error = cause
+		}
+		simulation.StoppedReason = stopReason(to, cause)
+		if simulation.StartTime != nil && (from == StatusRunning || from == StatusPaused) {
+			simulation.EndTime = &now
+			simulation.Duration = now.Sub(*simulation.StartTime)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id": simulation.ID,
+		"from":          from.String(),
+		"to":            to.String(),
+	}).Debug("Simulation status transitioned")
+
+	o.transitionHooks.enqueue(Transition{
+		SimulationID: simulation.ID,
+		From:         from,
+		To:           to,
+		At:           now,
+		Err:          cause,
+		Duration:     simulation.Duration,
+	})
+	return nil
+}
+
+// transitionDispatcher delivers transitions to hooks in order. Transitions
+// are queued under the orchestrator's lock and delivered from a goroutine,
+// so hooks may call back into the orchestrator.
+type transitionDispatcher struct {
+	mu    sync.Mutex
+	hooks []TransitionHook
+	queue []Transition
+	wake  chan struct{}
+}
+
+func newTransitionDispatcher() *transitionDispatcher {
+	return &transitionDispatcher{wake: make(chan struct{}, 1)}
+}
+
+func (d *transitionDispatcher) add(hook TransitionHook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.hooks = append(d.hooks, hook)
+}
+
+// enqueue queues a transition for delivery; it never blocks
+func (d *transitionDispatcher) enqueue(transition Transition) {
+	d.mu.Lock()
+	if len(d.hooks) == 0 {
+		d.mu.Unlock()
+		return
+	}
+	d.queue = append(d.queue, transition)
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued transitions until ctx is canceled
+func (d *transitionDispatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		}
+
+		d.mu.Lock()
+		queue, hooks := d.queue, d.hooks
+		d.queue = nil
+		d.mu.Unlock()
+
+		for _, transition := range queue {
+			for _, hook := range hooks {
+				d.call(hook, transition)
+			}
+		}
+	}
+}
+
+// call runs one hook, keeping a panicking hook from stopping delivery
+func (d *transitionDispatcher) call(hook TransitionHook, transition Transition) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.WithField("simulation_id", transition.SimulationID).
+				Errorf("Simulation transition hook panicked: %v", r)
+		}
+	}()
+	hook(transition)
+}
@@ -0,0 +1,53 @@
+package orchestration
+
+import (
+	"errors"
+	"fmt"
+)
+
+// StopMode is how a running simulation is stopped
+type StopMode string
+
+const (
+	// StopGraceful lets the run finish the tick it is on and report its
+	// results before it ends
+	StopGraceful StopMode = "graceful"
+	// StopForce kills the run at once, dropping the tick in progress
+	StopForce StopMode = "force"
+)
+
+// ParseStopMode parses a stop mode; empty means StopGraceful
+func ParseStopMode(mode string) (StopMode, error) {
+	switch StopMode(mode) {
+	case "", StopGraceful:
+		return StopGraceful, nil
+	case StopForce:
+		return StopForce, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidStopMode, mode)
+	}
+}
+
+// Reasons a run ended, recorded as its stopped reason
+const (
+	StopReasonCompleted = "completed"
+	StopReasonStopped   = "stopped"
+	StopReasonKilled    = "killed"
+	StopReasonFailed    = "failed"
+	// Stopped while queued, before it started
+	StopReasonCanceled = "canceled"
+)
+
+// stopReason names why a run that moved to a finished status ended
+func stopReason(to SimulationStatus, cause error) string {
+	switch {
+	case errors.Is(cause, ErrForceStopped):
+		return StopReasonKilled
+	case errors.Is(cause, ErrStopRequested):
+		return StopReasonStopped
+	case to == StatusError:
+		return StopReasonFailed
+	default:
+		return StopReasonCompleted
+	}
+}
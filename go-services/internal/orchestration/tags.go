@@ -0,0 +1,75 @@
+package orchestration
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReplaceTags rewrites the tags of every simulation, experiment and batch,
+// replacing each of names with replacement. An empty replacement removes the
+// tags instead. It returns the number of resources changed.
+func (o *Orchestrator) ReplaceTags(names []string, replacement string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	changed := 0
+	for _, simulation := range o.simulations {
+		if tags, ok := replaceTags(simulation.Tags, names, replacement); ok {
+			simulation.Tags = tags
+			simulation.Version++
+			simulation.UpdatedAt = time.Now()
+			changed++
+		}
+	}
+	for _, experiment := range o.experiments {
+		if tags, ok := replaceTags(experiment.Tags, names, replacement); ok {
+			experiment.Tags = tags
+			changed++
+		}
+	}
+	for _, batch := range o.batches {
+		if tags, ok := replaceTags(batch.Tags, names, replacement); ok {
+			batch.Tags = tags
+			changed++
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"tags":        names,
+		"replacement": replacement,
+		"changed":     changed,
+	}).Info("Tags replaced")
+
+	return changed
+}
+
+// replaceTags returns tags with names replaced, keeping the first position
+// of each tag, and whether anything changed
+func replaceTags(tags, names []string, replacement string) ([]string, bool) {
+	replace := make(map[string]bool, len(names))
+	for _, name := range names {
+		replace[name] = true
+	}
+
+	changed := false
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if replace[tag] {
+			changed = true
+			if replacement == "" {
+				continue
+			}
+			tag = replacement
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	if !changed {
+		return tags, false
+	}
+	return result, true
+}
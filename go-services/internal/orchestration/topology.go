@@ -0,0 +1,189 @@
+package orchestration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Bus types
+const (
+	BusTypeBus        = "bus"
+	BusTypeSubstation = "substation"
+)
+
+// BusConfig represents an electrical bus or substation that components connect to
+type BusConfig struct {
+	ID        string   `json:"id" binding:"required"`
+	Name      string   `json:"name" binding:"required"`
+	Type      string   `json:"type" binding:"omitempty,oneof=bus substation"`
+	VoltageKV float64  `json:"voltage_kv" binding:"required,gt=0"`
+	Location  Location `json:"location"`
+}
+
+// TopologyNode is a bus together with the components attached to it
+type TopologyNode struct {
+	Bus          BusConfig `json:"bus"`
+	PowerPlants  []string  `json:"power_plants"`
+	StorageUnits []string  `json:"storage_units"`
+	Lines        []string  `json:"lines"`
+}
+
+// TopologyEdge is a transmission line between two buses
+type TopologyEdge struct {
+	LineID        string  `json:"line_id"`
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	CapacityMW    float64 `json:"capacity_mw"`
+	IsOperational bool    `json:"is_operational"`
+}
+
+// Topology is the explicit grid graph of a simulation
+type Topology struct {
+	Nodes     []TopologyNode `json:"nodes"`
+	Edges     []TopologyEdge `json:"edges"`
+	Islands   [][]string     `json:"islands"`
+	Connected bool           `json:"connected"`
+}
+
+// ValidateTopology checks that every component references a defined bus.
+// Configs without buses keep the legacy abstract-node behaviour and are accepted.
+func (c SimulationConfig) ValidateTopology() error {
+	if len(c.Buses) == 0 {
+		return nil
+	}
+
+	buses := make(map[string]BusConfig, len(c.Buses))
+	for _, bus := range c.Buses {
+		if bus.ID == "" {
+			return fmt.Errorf("%w: bus id is required", ErrInvalidTopology)
+		}
+		if _, dup := buses[bus.ID]; dup {
+			return fmt.Errorf("%w: duplicate bus id %s", ErrInvalidTopology, bus.ID)
+		}
+		if bus.Type != "" && bus.Type != BusTypeBus && bus.Type != BusTypeSubstation {
+			return fmt.Errorf("%w: bus %s has unknown type %s", ErrInvalidTopology, bus.ID, bus.Type)
+		}
+		if bus.VoltageKV <= 0 {
+			return fmt.Errorf("%w: bus %s must have a positive voltage level", ErrInvalidTopology, bus.ID)
+		}
+		buses[bus.ID] = bus
+	}
+
+	var problems []string
+	for _, line := range c.TransmissionLines {
+		if _, ok := buses[line.FromNode]; !ok {
+			problems = append(problems, fmt.Sprintf("line %s references unknown bus %s", line.ID, line.FromNode))
+		}
+		if _, ok := buses[line.ToNode]; !ok {
+			problems = append(problems, fmt.Sprintf("line %s references unknown bus %s", line.ID, line.ToNode))
+		}
+		if line.FromNode == line.ToNode {
+			problems = append(problems, fmt.Sprintf("line %s connects bus %s to itself", line.ID, line.FromNode))
+		}
+	}
+	for _, plant := range c.PowerPlants {
+		if _, ok := buses[plant.BusID]; !ok {
+			problems = append(problems, fmt.Sprintf("power plant %s references unknown bus %q", plant.ID, plant.BusID))
+		}
+	}
+	for _, unit := range c.StorageUnits {
+		if _, ok := buses[unit.BusID]; !ok {
+			problems = append(problems, fmt.Sprintf("storage unit %s references unknown bus %q", unit.ID, unit.BusID))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidTopology, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// BuildTopology builds the bus graph of a simulation config. When no buses are
+// declared, implicit buses are derived from the line endpoints.
+func (c SimulationConfig) BuildTopology() Topology {
+	buses := c.Buses
+	if len(buses) == 0 {
+		seen := make(map[string]bool)
+		for _, line := range c.TransmissionLines {
+			for _, node := range []string{line.FromNode, line.ToNode} {
+				if !seen[node] {
+					seen[node] = true
+					buses = append(buses, BusConfig{ID: node, Name: node, Type: BusTypeBus})
+				}
+			}
+		}
+		sort.Slice(buses, func(i, j int) bool { return buses[i].ID < buses[j].ID })
+	}
+
+	index := make(map[string]int, len(buses))
+	topology := Topology{Nodes: make([]TopologyNode, len(buses))}
+	for i, bus := range buses {
+		index[bus.ID] = i
+		topology.Nodes[i] = TopologyNode{
+			Bus:          bus,
+			PowerPlants:  []string{},
+			StorageUnits: []string{},
+			Lines:        []string{},
+		}
+	}
+
+	for _, plant := range c.PowerPlants {
+		if i, ok := index[plant.BusID]; ok {
+			topology.Nodes[i].PowerPlants = append(topology.Nodes[i].PowerPlants, plant.ID)
+		}
+	}
+	for _, unit := range c.StorageUnits {
+		if i, ok := index[unit.BusID]; ok {
+			topology.Nodes[i].StorageUnits = append(topology.Nodes[i].StorageUnits, unit.ID)
+		}
+	}
+
+	adjacent := make(map[string][]string)
+	for _, line := range c.TransmissionLines {
+		topology.Edges = append(topology.Edges, TopologyEdge{
+			LineID:        line.ID,
+			From:          line.FromNode,
+			To:            line.ToNode,
+			CapacityMW:    line.CapacityMW,
+			IsOperational: line.IsOperational,
+		})
+		for _, node := range []string{line.FromNode, line.ToNode} {
+			if i, ok := index[node]; ok {
+				topology.Nodes[i].Lines = append(topology.Nodes[i].Lines, line.ID)
+			}
+		}
+		if line.IsOperational {
+			adjacent[line.FromNode] = append(adjacent[line.FromNode], line.ToNode)
+			adjacent[line.ToNode] = append(adjacent[line.ToNode], line.FromNode)
+		}
+	}
+
+	// Find electrical islands over operational lines
+	visited := make(map[string]bool)
+	for _, bus := range buses {
+		if visited[bus.ID] {
+			continue
+		}
+		var island []string
+		stack := []string{bus.ID}
+		visited[bus.ID] = true
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			island = append(island, current)
+			for _, next := range adjacent[current] {
+				if !visited[next] {
+					visited[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		sort.Strings(island)
+		topology.Islands = append(topology.Islands, island)
+	}
+	topology.Connected = len(topology.Islands) <= 1
+
+	return topology
+}
@@ -9,49 +9,80 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// SimulationJob represents a job for the worker pool
+// SimulationJob represents a job for the worker pool. The worker records
+// the outcome on the job; the orchestrator applies it to the simulation.
 type SimulationJob struct {
 	SimulationID string
+	// The simulation's run the job was submitted for
+	Run          uint64
+	// 1 for the first try, counting up as transient failures are retried
+	Attempt      int
 	Config       SimulationConfig
-	Status       *SimulationStatus
-	StartTime    **time.Time
-	EndTime      **time.Time
-	Error        *error
-	Metrics      *int64
+	InitialState map[string]interface{}
+	Speed        float64
+	EndTime      time.Time
+	Err          error
+
+	// Canceled to kill the job; see CancelJob
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// WorkerPool manages a pool of workers for simulation jobs
+// jobTimeSmoothing weighs the latest job in the moving average of job
+// durations queue wait estimates are based on
+const jobTimeSmoothing = 0.2
+
+// WorkerPool manages a pool of workers for simulation jobs. Jobs beyond
+// what the workers can take wait in a first-in, first-out queue.
 type WorkerPool struct {
 	size        int
-	jobs        chan *SimulationJob
+	queueSize   int
+	// Jobs waiting for a worker, oldest first
+	queue       []*SimulationJob
+	// Signalled when a job is queued
+	wake        chan struct{}
+	busy        int
+	avgJobTime  time.Duration
 	ctx         context.Context
 	cancel      context.CancelFunc
 	workers     []*Worker
 	mu          sync.RWMutex
 	isRunning   bool
+	onStart     func(*SimulationJob) error
+	onComplete  func(*SimulationJob)
+	faults      func(string) error
+	onCrash     func(WorkerCrash)
+	// Closed when the paused simulation's job may continue
+	paused map[string]chan struct{}
+	// Latest job submitted for each simulation
+	active map[string]*SimulationJob
 }
 
 // Worker represents a single worker in the pool
 type Worker struct {
 	id       int
-	jobs     <-chan *SimulationJob
 	ctx      context.Context
 	cancel   context.CancelFunc
 	mu       sync.RWMutex
 	isActive bool
+	pool     *WorkerPool
 }
 
-// NewWorkerPool creates a new worker pool
-func NewWorkerPool(size int) *WorkerPool {
+// NewWorkerPool creates a new worker pool whose queue holds up to queueSize
+// jobs; zero or less leaves it unbounded
+func NewWorkerPool(size, queueSize int) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	return &WorkerPool{
 		size:    size,
-		jobs:    make(chan *SimulationJob, size*2), // Buffer for better performance
+		queueSize: queueSize,
+		wake:    make(chan struct{}, size),
 		ctx:     ctx,
 		cancel:  cancel,
 		workers: make([]*Worker, size),
 		isRunning: false,
+		paused:  make(map[string]chan struct{}),
+		active:  make(map[string]*SimulationJob),
 	}
 }
 
@@ -71,10 +102,10 @@ func (wp *WorkerPool) Start(ctx context.Context) error {
 		workerCtx, workerCancel := context.WithCancel(ctx)
 		worker := &Worker{
 			id:       i,
-			jobs:     wp.jobs,
 			ctx:      workerCtx,
 			cancel:   workerCancel,
 			isActive: true,
+			pool:     wp,
 		}
 		
 		wp.workers[i] = worker
@@ -97,47 +128,220 @@ func (wp *WorkerPool) Stop() {
 	
 	logrus.Info("Stopping worker pool")
 	
-	// Cancel all workers
+	// Cancel all workers; queued jobs are left for reconciliation
 	for _, worker := range wp.workers {
 		worker.cancel()
 	}
 	
-	// Close jobs channel
-	close(wp.jobs)
-	
 	wp.isRunning = false
 	logrus.Info("Worker pool stopped")
 }
 
-// SubmitJob submits a job to the worker pool
+// SetStartHandler registers a callback invoked when a worker picks up a
+// job. The job is dropped if it returns an error.
+func (wp *WorkerPool) SetStartHandler(handler func(*SimulationJob) error) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.onStart = handler
+}
+
+// SetCompletionHandler registers a callback invoked after each job finishes
+func (wp *WorkerPool) SetCompletionHandler(handler func(*SimulationJob)) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.onComplete = handler
+}
+
+// SetFaultInjector registers a function consulted while each job runs; a
+// job fails with its error as if its worker had crashed
+func (wp *WorkerPool) SetFaultInjector(faults func(simulationID string) error) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.faults = faults
+}
+
+// SubmitJob queues a job for the next free worker. It fails with
+// ErrQueueFull when the queue is at capacity.
 func (wp *WorkerPool) SubmitJob(job *SimulationJob) error {
-	wp.mu.RLock()
-	defer wp.mu.RUnlock()
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
 	
 	if !wp.isRunning {
 		return fmt.Errorf("worker pool is not running")
 	}
-	
+	if wp.queueSize > 0 && len(wp.queue) >= wp.queueSize {
+		return ErrQueueFull
+	}
+
+	job.ctx, job.cancel = context.WithCancel(wp.ctx)
+	wp.queue = append(wp.queue, job)
+	wp.active[job.SimulationID] = job
 	select {
-	case wp.jobs <- job:
-		logrus.WithField("simulation_id", job.SimulationID).Info("Job submitted to worker pool")
-		return nil
-	case <-wp.ctx.Done():
-		return fmt.Errorf("worker pool is shutting down")
+	case wp.wake <- struct{}{}:
 	default:
-		return fmt.Errorf("worker pool is full")
 	}
+
+	logrus.WithFields(logrus.Fields{
+		"simulation_id":  job.SimulationID,
+		"queue_position": len(wp.queue),
+	}).Info("Job queued in worker pool")
+	return nil
+}
+
+// RemoveJob takes a simulation's job out of the queue before a worker picks
+// it up. It reports false when the simulation has no queued job.
+func (wp *WorkerPool) RemoveJob(simulationID string) bool {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	for i, job := range wp.queue {
+		if job.SimulationID == simulationID {
+			wp.queue = append(wp.queue[:i], wp.queue[i+1:]...)
+			delete(wp.active, simulationID)
+			job.cancel()
+			return true
+		}
+	}
+	return false
+}
+
+// QueuePosition returns the 1-based position of a simulation's queued job
+// and when it is expected to start. The estimate assumes jobs take as long
+// as they have on average, and is zero until one has finished.
+func (wp *WorkerPool) QueuePosition(simulationID string) (int, time.Time, bool) {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+
+	for i, job := range wp.queue {
+		if job.SimulationID != simulationID {
+			continue
+		}
+		position := i + 1
+
+		// Free workers take the first jobs straight away; the rest start as
+		// workers come free, a pool's worth at a time
+		now := time.Now()
+		ahead := position - (wp.size - wp.busy)
+		if ahead <= 0 {
+			return position, now, true
+		}
+		if wp.avgJobTime == 0 {
+			return position, time.Time{}, true
+		}
+		rounds := (ahead + wp.size - 1) / wp.size
+		return position, now.Add(time.Duration(rounds) * wp.avgJobTime), true
+	}
+	return 0, time.Time{}, false
 }
 
-// CancelJob cancels a job in the worker pool
+// next waits for a queued job, returning nil once ctx is done
+func (wp *WorkerPool) next(ctx context.Context) *SimulationJob {
+	for {
+		wp.mu.Lock()
+		if len(wp.queue) > 0 {
+			job := wp.queue[0]
+			wp.queue[0] = nil
+			wp.queue = wp.queue[1:]
+			wp.busy++
+			wp.mu.Unlock()
+			return job
+		}
+		wp.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wp.wake:
+		}
+	}
+}
+
+// recordJobTime folds a finished job's duration into the average
+func (wp *WorkerPool) recordJobTime(elapsed time.Duration) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.avgJobTime == 0 {
+		wp.avgJobTime = elapsed
+		return
+	}
+	wp.avgJobTime += time.Duration(jobTimeSmoothing * float64(elapsed-wp.avgJobTime))
+}
+
+// PauseJob holds a simulation's job before it finishes until ResumeJob or
+// CancelJob is called
+func (wp *WorkerPool) PauseJob(simulationID string) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if _, ok := wp.paused[simulationID]; !ok {
+		wp.paused[simulationID] = make(chan struct{})
+	}
+}
+
+// ResumeJob lets a paused simulation's job continue
+func (wp *WorkerPool) ResumeJob(simulationID string) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if gate, ok := wp.paused[simulationID]; ok {
+		close(gate)
+		delete(wp.paused, simulationID)
+	}
+}
+
+// waitWhilePaused blocks while the simulation's job is paused
+func (wp *WorkerPool) waitWhilePaused(ctx context.Context, simulationID string) {
+	wp.mu.RLock()
+	gate, ok := wp.paused[simulationID]
+	wp.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	select {
+	case <-gate:
+	case <-ctx.Done():
+	}
+}
+
+// StopJob lets a simulation's job finish the tick it is on and report its
+// results, then end without waiting for any more
+func (wp *WorkerPool) StopJob(simulationID string) {
+	logrus.WithField("simulation_id", simulationID).Info("Stopping job in worker pool")
+
+	// A paused job must not wait for a resume that will never come
+	wp.ResumeJob(simulationID)
+}
+
+// CancelJob kills a simulation's running job at once. Results of the tick
+// in progress are dropped.
 func (wp *WorkerPool) CancelJob(simulationID string) {
 	logrus.WithField("simulation_id", simulationID).Info("Canceling job in worker pool")
-	
-	// TODO: Implement job cancellation logic
-	// This would typically involve:
-	// 1. Finding the job in the queue
-	// 2. Removing it from the queue
-	// 3. Canceling any running execution
+
+	wp.ResumeJob(simulationID)
+
+	wp.mu.RLock()
+	job, ok := wp.active[simulationID]
+	wp.mu.RUnlock()
+	if ok {
+		job.cancel()
+	}
+}
+
+// finishJob forgets a job once its worker is done with it
+func (wp *WorkerPool) finishJob(job *SimulationJob) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.busy--
+	if wp.active[job.SimulationID] == job {
+		delete(wp.active, job.SimulationID)
+	}
+	job.cancel()
 }
 
 // Health returns the health status of the worker pool
@@ -178,20 +382,16 @@ func (wp *WorkerPool) Health() HealthStatus {
 // run runs the worker
 func (w *Worker) run() {
 	logrus.WithField("worker_id", w.id).Info("Worker started")
+	defer w.restartOnPanic()
 	
 	for {
-		select {
-		case <-w.ctx.Done():
+		job := w.pool.next(w.ctx)
+		if job == nil {
 			logrus.WithField("worker_id", w.id).Info("Worker stopping")
 			return
-		case job := <-w.jobs:
-			if job == nil {
-				logrus.WithField("worker_id", w.id).Info("Worker received nil job, stopping")
-				return
-			}
-			
-			w.processJob(job)
 		}
+
+		w.processJob(job)
 	}
 }
 
@@ -202,33 +402,78 @@ func (w *Worker) processJob(job *SimulationJob) {
 		"simulation_id": job.SimulationID,
 	}).Info("Processing simulation job")
 	
-	// Set job status to running
-	*job.Status = StatusRunning
-	now := time.Now()
-	*job.StartTime = &now
+	defer w.pool.finishJob(job)
+	// Stopping the worker also releases a job waiting out a pause
+	defer context.AfterFunc(w.ctx, job.cancel)()
+	// A panic fails the job instead of killing the worker
+	completed := false
+	defer w.recoverJob(job, &completed)
 	
 	// TODO: Implement actual simulation processing
 	// This would typically involve:
 	// 1. Starting the simulation
 	// 2. Monitoring its progress
 	// 3. Handling errors and completion
+
+	w.pool.mu.RLock()
+	onStart := w.pool.onStart
+	onComplete := w.pool.onComplete
+	faults := w.pool.faults
+	w.pool.mu.RUnlock()
+
+	if onStart != nil {
+		if err := onStart(job); err != nil {
+			logrus.WithError(err).WithField("simulation_id", job.SimulationID).Warn("Simulation job not started")
+			return
+		}
+	}
+	now := time.Now()
+
+	// Simulate some work; a killed job drops the tick in progress
+	select {
+	case <-time.After(100 * time.Millisecond):
+		// A paused run does not finish until it is resumed
+		w.pool.waitWhilePaused(job.ctx, job.SimulationID)
+	case <-job.ctx.Done():
+	}
+	if w.ctx.Err() != nil {
+		// Shutting down; the run's outcome is left to reconciliation
+		return
+	}
+
+	var err error
+	if job.ctx.Err() != nil {
+		err = ErrJobCanceled
+	} else {
+		w.pool.recordJobTime(time.Since(now))
+
+		if faults != nil {
+			err = faults(job.SimulationID)
+		}
+	}
 	
-	// Simulate some work
-	time.Sleep(100 * time.Millisecond)
-	
-	// Update metrics
-	*job.Metrics = 1000 // Simulate events processed
-	
-	// Mark job as completed
-	*job.Status = StatusCompleted
 	endTime := time.Now()
-	*job.EndTime = &endTime
-	
-	logrus.WithFields(logrus.Fields{
-		"worker_id":     w.id,
-		"simulation_id": job.SimulationID,
-		"duration":      endTime.Sub(now),
-	}).Info("Simulation job completed")
+	job.EndTime = endTime
+	if err != nil {
+		// Mark job as failed
+		job.Err = err
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"worker_id":     w.id,
+			"simulation_id": job.SimulationID,
+		}).Error("Simulation job failed")
+	} else {
+		logrus.WithFields(logrus.Fields{
+			"worker_id":     w.id,
+			"simulation_id": job.SimulationID,
+			"duration":      endTime.Sub(now),
+		}).Info("Simulation job completed")
+	}
+
+	if onComplete != nil {
+		completed = true
+		onComplete(job)
+	}
 }
 
 
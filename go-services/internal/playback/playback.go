@@ -0,0 +1,501 @@
+package playback
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Session states
+const (
+	StatePlaying  = "playing"
+	StatePaused   = "paused"
+	StateFinished = "finished"
+)
+
+// Control actions
+const (
+	ActionPlay  = "play"
+	ActionPause = "pause"
+	ActionStep  = "step"
+	ActionSeek  = "seek"
+	ActionSpeed = "speed"
+)
+
+// Stream event types
+const (
+	EventFrame = "frame"
+	EventState = "state"
+	EventEnd   = "end"
+)
+
+// ResultSource provides recorded simulation results in chronological order
+type ResultSource interface {
+	GetSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]database.SimulationResult, error)
+}
+
+// Options configures playback sessions
+type Options struct {
+	// Sessions without an attached stream are dropped after this long
+	IdleTimeout time.Duration
+	MaxFrames   int
+	MaxSpeed    float64
+	// Longest real-time wait between two frames, so gaps in the recording do not stall playback
+	MaxFrameGap time.Duration
+}
+
+// Frame is one recorded tick replayed to clients
+type Frame struct {
+	Index                int            `json:"index"`
+	Timestamp            time.Time      `json:"timestamp"`
+	TickNumber           int            `json:"tick_number"`
+	TotalGenerationMW    float64        `json:"total_generation_mw"`
+	TotalConsumptionMW   float64        `json:"total_consumption_mw"`
+	GridFrequencyHz      float64        `json:"grid_frequency_hz"`
+	GridVoltageKV        float64        `json:"grid_voltage_kv"`
+	EfficiencyPercentage float64        `json:"efficiency_percentage"`
+	FaultCount           int            `json:"fault_count"`
+	Metadata             map[string]any `json:"metadata,omitempty"`
+}
+
+// Info is a snapshot of a playback session
+type Info struct {
+	ID           string    `json:"id"`
+	SimulationID uuid.UUID `json:"simulation_id"`
+	State        string    `json:"state"`
+	Speed        float64   `json:"speed"`
+	Position     int       `json:"position"`
+	Frames       int       `json:"frames"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	// Timestamp of the next frame to be played
+	Current   *time.Time `json:"current,omitempty"`
+	Streaming bool       `json:"streaming"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Event is sent to a streaming client
+type Event struct {
+	Type    string `json:"type"`
+	Frame   *Frame `json:"frame,omitempty"`
+	Session *Info  `json:"session,omitempty"`
+}
+
+// Command changes the playback of a session
+type Command struct {
+	Action    string     `json:"action" binding:"required,oneof=play pause step seek speed"`
+	Speed     float64    `json:"speed"`
+	Timestamp *time.Time `json:"timestamp"`
+}
+
+// session replays the frames of one simulation
+type session struct {
+	mu           sync.Mutex
+	id           string
+	simulationID uuid.UUID
+	frames       []Frame
+	state        string
+	speed        float64
+	position     int
+	steps        int
+	// The next frame is sent without waiting, after a seek or resume
+	resync     bool
+	streaming  bool
+	closed     bool
+	createdAt  time.Time
+	lastActive time.Time
+	// Signals the streaming loop that a control command arrived
+	wake chan struct{}
+}
+
+// Manager owns playback sessions
+type Manager struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	results  ResultSource
+	opts     Options
+}
+
+// NewManager creates a new playback manager
+func NewManager(results ResultSource, opts Options) *Manager {
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 30 * time.Minute
+	}
+	if opts.MaxFrames <= 0 {
+		opts.MaxFrames = 100000
+	}
+	if opts.MaxSpeed <= 0 {
+		opts.MaxSpeed = 1000
+	}
+	if opts.MaxFrameGap <= 0 {
+		opts.MaxFrameGap = 5 * time.Second
+	}
+
+	return &Manager{
+		sessions: make(map[string]*session),
+		results:  results,
+		opts:     opts,
+	}
+}
+
+// Create loads the recorded results of a simulation into a new paused session
+func (m *Manager) Create(ctx context.Context, simulationID uuid.UUID, speed float64, from, to *time.Time) (*Info, error) {
+	if speed == 0 {
+		speed = 1
+	}
+	if err := m.validateSpeed(speed); err != nil {
+		return nil, err
+	}
+
+	results, err := m.results.GetSimulationResultsInRange(ctx, simulationID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+	if len(results) > m.opts.MaxFrames {
+		return nil, fmt.Errorf("%w: %d results exceed the limit of %d; narrow the window with from/to", ErrTooManyFrames, len(results), m.opts.MaxFrames)
+	}
+
+	frames := make([]Frame, len(results))
+	for i, result := range results {
+		frames[i] = Frame{
+			Index:                i,
+			Timestamp:            result.Timestamp,
+			TickNumber:           result.TickNumber,
+			TotalGenerationMW:    result.TotalGenerationMW,
+			TotalConsumptionMW:   result.TotalConsumptionMW,
+			GridFrequencyHz:      result.GridFrequencyHz,
+			GridVoltageKV:        result.GridVoltageKV,
+			EfficiencyPercentage: result.EfficiencyPercentage,
+			FaultCount:           result.FaultCount,
+			Metadata:             result.Metadata,
+		}
+	}
+
+	now := time.Now().UTC()
+	s := &session{
+		id:           fmt.Sprintf("play_%d", now.UnixNano()),
+		simulationID: simulationID,
+		frames:       frames,
+		state:        StatePaused,
+		speed:        speed,
+		resync:       true,
+		createdAt:    now,
+		lastActive:   now,
+		wake:         make(chan struct{}, 1),
+	}
+
+	m.mu.Lock()
+	m.expireLocked(now)
+	m.sessions[s.id] = s
+	m.mu.Unlock()
+
+	info := s.info()
+	return &info, nil
+}
+
+// Get returns a session snapshot
+func (m *Manager) Get(id string) (*Info, error) {
+	s, err := m.session(id)
+	if err != nil {
+		return nil, err
+	}
+	info := s.info()
+	return &info, nil
+}
+
+// Delete ends a session and closes its stream
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	s, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	s.mu.Lock()
+	s.state = StateFinished
+	s.position = len(s.frames)
+	s.closed = true
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// Control applies a playback command
+func (m *Manager) Control(id string, cmd Command) (*Info, error) {
+	s, err := m.session(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Action == ActionSpeed || (cmd.Action == ActionPlay && cmd.Speed != 0) {
+		if err := m.validateSpeed(cmd.Speed); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	switch cmd.Action {
+	case ActionPlay:
+		if cmd.Speed != 0 {
+			s.speed = cmd.Speed
+		}
+		if s.position >= len(s.frames) {
+			s.position = 0
+		}
+		s.state = StatePlaying
+		s.resync = true
+	case ActionPause:
+		if s.state == StatePlaying {
+			s.state = StatePaused
+		}
+	case ActionStep:
+		if s.position < len(s.frames) {
+			s.state = StatePaused
+			s.steps++
+		}
+	case ActionSeek:
+		if cmd.Timestamp == nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%w: seek requires a timestamp", ErrInvalidCommand)
+		}
+		target := *cmd.Timestamp
+		s.position = sort.Search(len(s.frames), func(i int) bool { return !s.frames[i].Timestamp.Before(target) })
+		if s.state == StateFinished && s.position < len(s.frames) {
+			s.state = StatePaused
+		}
+		s.steps = 0
+		s.resync = true
+	case ActionSpeed:
+		s.speed = cmd.Speed
+	default:
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: unknown action %s", ErrInvalidCommand, cmd.Action)
+	}
+	s.lastActive = time.Now().UTC()
+	info := s.infoLocked()
+	s.mu.Unlock()
+
+	s.notify()
+	return &info, nil
+}
+
+// Stream replays a session, calling emit for every event until the session is
+// deleted, the context is cancelled or emit fails. A session has at most one stream.
+func (m *Manager) Stream(ctx context.Context, id string, emit func(Event) error) error {
+	s, err := m.session(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.streaming {
+		s.mu.Unlock()
+		return ErrAlreadyStreaming
+	}
+	s.streaming = true
+	info := s.infoLocked()
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.streaming = false
+		s.lastActive = time.Now().UTC()
+		s.mu.Unlock()
+	}()
+
+	if err := emit(Event{Type: EventState, Session: &info}); err != nil {
+		return err
+	}
+
+	ended := false
+	for {
+		s.mu.Lock()
+		if s.position >= len(s.frames) {
+			// Stay attached after the last frame so clients can seek back or replay
+			s.state = StateFinished
+			closed := s.closed
+			info := s.infoLocked()
+			s.mu.Unlock()
+
+			if !ended {
+				ended = true
+				if err := emit(Event{Type: EventEnd, Session: &info}); err != nil {
+					return err
+				}
+			}
+			if closed {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-s.wake:
+				if err := m.emitState(s, emit); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		ended = false
+
+		playing := s.state == StatePlaying
+		ready := s.steps > 0 || (playing && s.resync)
+		var delay time.Duration
+		if playing && !ready {
+			delay = m.frameDelayLocked(s)
+		}
+		s.mu.Unlock()
+
+		if !playing && !ready {
+			// Paused: wait for a command
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-s.wake:
+				if err := m.emitState(s, emit); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-s.wake:
+				// Re-evaluate with the new state; a changed speed restarts the wait
+				timer.Stop()
+				if err := m.emitState(s, emit); err != nil {
+					return err
+				}
+				continue
+			case <-timer.C:
+			}
+		}
+
+		s.mu.Lock()
+		if s.position >= len(s.frames) {
+			s.mu.Unlock()
+			continue
+		}
+		frame := s.frames[s.position]
+		s.position++
+		if s.steps > 0 && s.state != StatePlaying {
+			s.steps--
+		}
+		s.resync = false
+		s.lastActive = time.Now().UTC()
+		s.mu.Unlock()
+
+		if err := emit(Event{Type: EventFrame, Frame: &frame}); err != nil {
+			return err
+		}
+	}
+}
+
+// frameDelayLocked returns the wait before the next frame at the current speed
+// (must be called with the session lock held)
+func (m *Manager) frameDelayLocked(s *session) time.Duration {
+	if s.position == 0 {
+		return 0
+	}
+	gap := s.frames[s.position].Timestamp.Sub(s.frames[s.position-1].Timestamp)
+	delay := time.Duration(float64(gap) / s.speed)
+	if delay > m.opts.MaxFrameGap {
+		delay = m.opts.MaxFrameGap
+	}
+	return delay
+}
+
+func (m *Manager) emitState(s *session, emit func(Event) error) error {
+	info := s.info()
+	return emit(Event{Type: EventState, Session: &info})
+}
+
+func (m *Manager) validateSpeed(speed float64) error {
+	if speed <= 0 || speed > m.opts.MaxSpeed {
+		return fmt.Errorf("%w: speed must be greater than 0 and at most %g", ErrInvalidCommand, m.opts.MaxSpeed)
+	}
+	return nil
+}
+
+func (m *Manager) session(id string) (*session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expireLocked(time.Now().UTC())
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return s, nil
+}
+
+// expireLocked drops idle sessions without a stream (must be called with lock held)
+func (m *Manager) expireLocked(now time.Time) {
+	for id, s := range m.sessions {
+		s.mu.Lock()
+		idle := !s.streaming && now.Sub(s.lastActive) > m.opts.IdleTimeout
+		s.mu.Unlock()
+		if idle {
+			delete(m.sessions, id)
+		}
+	}
+}
+
+func (s *session) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *session) info() Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.infoLocked()
+}
+
+// infoLocked snapshots the session (must be called with the session lock held)
+func (s *session) infoLocked() Info {
+	info := Info{
+		ID:           s.id,
+		SimulationID: s.simulationID,
+		State:        s.state,
+		Speed:        s.speed,
+		Position:     s.position,
+		Frames:       len(s.frames),
+		Start:        s.frames[0].Timestamp,
+		End:          s.frames[len(s.frames)-1].Timestamp,
+		Streaming:    s.streaming,
+		CreatedAt:    s.createdAt,
+	}
+	if s.position < len(s.frames) {
+		current := s.frames[s.position].Timestamp
+		info.Current = &current
+	}
+	return info
+}
+
+// Errors
+var (
+	ErrSessionNotFound  = fmt.Errorf("playback session not found")
+	ErrNoResults        = fmt.Errorf("simulation has no recorded results to play back")
+	ErrTooManyFrames    = fmt.Errorf("too many results to play back")
+	ErrInvalidCommand   = fmt.Errorf("invalid playback command")
+	ErrAlreadyStreaming = fmt.Errorf("playback session is already being streamed")
+)
@@ -0,0 +1,75 @@
+package prediction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxResponseBytes bounds the forecast body read from an external service
+const maxResponseBytes = 1 << 20
+
+// HTTP delegates forecasting to an external ML service. The service receives
+// the Input as JSON and must answer with a Forecast.
+type HTTP struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTP creates a backend posting to the given endpoint
+func NewHTTP(endpoint string, timeout time.Duration) *HTTP {
+	return &HTTP{
+		endpoint: endpoint,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// Name returns the backend name
+func (b *HTTP) Name() string {
+	return "http"
+}
+
+// Predict posts the input to the external service
+func (b *HTTP) Predict(ctx context.Context, input Input) (*Forecast, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prediction input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prediction request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VoltEdge-Predictions/1.0")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("prediction request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("prediction service returned status %d", resp.StatusCode)
+	}
+
+	var forecast Forecast
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&forecast); err != nil {
+		return nil, fmt.Errorf("invalid prediction response: %w", err)
+	}
+	if forecast.PredictedLoadMW < 0 || forecast.Confidence < 0 || forecast.Confidence > 1 ||
+		forecast.FailureProbability < 0 || forecast.FailureProbability > 1 {
+		return nil, fmt.Errorf("invalid prediction response: values out of range")
+	}
+	if forecast.Model == "" {
+		forecast.Model = "external"
+	}
+
+	return &forecast, nil
+}
@@ -0,0 +1,287 @@
+package prediction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Sample is a single recorded tick passed to a backend
+type Sample struct {
+	Timestamp       time.Time `json:"timestamp"`
+	ConsumptionMW   float64   `json:"consumption_mw"`
+	GenerationMW    float64   `json:"generation_mw"`
+	GridFrequencyHz float64   `json:"grid_frequency_hz"`
+	FaultCount      int       `json:"fault_count"`
+}
+
+// Input is the history a backend forecasts from, ordered oldest first
+type Input struct {
+	SimulationID   uuid.UUID `json:"simulation_id"`
+	HorizonSeconds float64   `json:"horizon_seconds"`
+	Samples        []Sample  `json:"samples"`
+}
+
+// Forecast is a backend's prediction at the end of the horizon
+type Forecast struct {
+	PredictedLoadMW     float64 `json:"predicted_load_mw"`
+	FailureProbability  float64 `json:"failure_probability"`
+	OptimalGenerationMW float64 `json:"optimal_generation_mw"`
+	Confidence          float64 `json:"confidence"`
+	Model               string  `json:"model"`
+}
+
+// Backend produces forecasts from recorded simulation history
+type Backend interface {
+	Name() string
+	Predict(ctx context.Context, input Input) (*Forecast, error)
+}
+
+// ResultSource provides recorded simulation results
+type ResultSource interface {
+	GetLatestSimulationResults(ctx context.Context, simulationID uuid.UUID, limit int) ([]database.SimulationResult, error)
+}
+
+// SnapshotStore persists forecasts and the observations they are scored against
+type SnapshotStore interface {
+	SavePredictionSnapshot(snapshot *database.PredictionSnapshot) error
+	GetDuePredictionSnapshots(simulationID uuid.UUID, until time.Time) ([]database.PredictionSnapshot, error)
+	ScorePredictionSnapshot(id uuid.UUID, actualLoadMW, absoluteErrorMW float64) error
+	GetScoredPredictionSnapshots(simulationID uuid.UUID, limit int) ([]database.PredictionSnapshot, error)
+	GetResultAt(simulationID uuid.UUID, at time.Time) (*database.SimulationResult, error)
+}
+
+// Options configures a prediction service
+type Options struct {
+	Horizon     time.Duration
+	HistorySize int
+	// Used when the primary backend fails; nil disables fallback
+	Fallback Backend
+}
+
+// Prediction is a forecast together with the context it was produced in
+type Prediction struct {
+	Forecast
+	SimulationID   uuid.UUID `json:"simulation_id"`
+	Backend        string    `json:"backend"`
+	HorizonSeconds float64   `json:"horizon_seconds"`
+	BasedOn        time.Time `json:"based_on"`
+	TargetTime     time.Time `json:"target_time"`
+	Samples        int       `json:"samples"`
+	SnapshotID     uuid.UUID `json:"snapshot_id"`
+}
+
+// Accuracy summarizes how scored forecasts compared with observed load
+type Accuracy struct {
+	SimulationID uuid.UUID                     `json:"simulation_id"`
+	Scored       int                           `json:"scored"`
+	MAEMW        float64                       `json:"mae_mw"`
+	MAPE         float64                       `json:"mape"`
+	RMSEMW       float64                       `json:"rmse_mw"`
+	ByBackend    map[string]BackendAccuracy    `json:"by_backend"`
+	Recent       []database.PredictionSnapshot `json:"recent"`
+}
+
+// BackendAccuracy is the accuracy of a single backend
+type BackendAccuracy struct {
+	Scored int     `json:"scored"`
+	MAEMW  float64 `json:"mae_mw"`
+	MAPE   float64 `json:"mape"`
+}
+
+// Service forecasts simulations and tracks forecast accuracy
+type Service struct {
+	backend   Backend
+	results   ResultSource
+	snapshots SnapshotStore
+	opts      Options
+}
+
+// NewService creates a new prediction service
+func NewService(backend Backend, results ResultSource, snapshots SnapshotStore, opts Options) *Service {
+	if opts.Horizon <= 0 {
+		opts.Horizon = time.Hour
+	}
+	if opts.HistorySize <= 0 {
+		opts.HistorySize = 288
+	}
+
+	return &Service{
+		backend:   backend,
+		results:   results,
+		snapshots: snapshots,
+		opts:      opts,
+	}
+}
+
+// Predict forecasts a simulation over the configured horizon and stores a
+// snapshot of the forecast. Snapshots that have come due are scored first.
+func (s *Service) Predict(ctx context.Context, simulationID uuid.UUID) (*Prediction, error) {
+	results, err := s.results.GetLatestSimulationResults(ctx, simulationID, s.opts.HistorySize)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoHistory
+	}
+
+	latest := results[0].Timestamp
+	s.scoreDue(simulationID, latest)
+
+	input := Input{
+		SimulationID:   simulationID,
+		HorizonSeconds: s.opts.Horizon.Seconds(),
+		Samples:        make([]Sample, len(results)),
+	}
+	// Results arrive newest first; backends expect chronological order
+	for i, result := range results {
+		input.Samples[len(results)-1-i] = Sample{
+			Timestamp:       result.Timestamp,
+			ConsumptionMW:   result.TotalConsumptionMW,
+			GenerationMW:    result.TotalGenerationMW,
+			GridFrequencyHz: result.GridFrequencyHz,
+			FaultCount:      result.FaultCount,
+		}
+	}
+
+	backend := s.backend
+	forecast, err := backend.Predict(ctx, input)
+	if err != nil {
+		if s.opts.Fallback == nil {
+			return nil, fmt.Errorf("%s backend failed: %w", backend.Name(), err)
+		}
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"backend":  backend.Name(),
+			"fallback": s.opts.Fallback.Name(),
+		}).Warn("Prediction backend failed, using fallback")
+
+		backend = s.opts.Fallback
+		if forecast, err = backend.Predict(ctx, input); err != nil {
+			return nil, fmt.Errorf("%s backend failed: %w", backend.Name(), err)
+		}
+	}
+
+	prediction := &Prediction{
+		Forecast:       *forecast,
+		SimulationID:   simulationID,
+		Backend:        backend.Name(),
+		HorizonSeconds: input.HorizonSeconds,
+		BasedOn:        latest,
+		TargetTime:     latest.Add(s.opts.Horizon),
+		Samples:        len(results),
+	}
+
+	snapshot := &database.PredictionSnapshot{
+		SimulationID:        simulationID,
+		Backend:             prediction.Backend,
+		Model:               forecast.Model,
+		GeneratedAt:         time.Now().UTC(),
+		TargetTime:          prediction.TargetTime,
+		HorizonSeconds:      prediction.HorizonSeconds,
+		PredictedLoadMW:     forecast.PredictedLoadMW,
+		FailureProbability:  forecast.FailureProbability,
+		OptimalGenerationMW: forecast.OptimalGenerationMW,
+		Confidence:          forecast.Confidence,
+	}
+	// A lost snapshot only affects accuracy tracking, so the forecast is still returned
+	if err := s.snapshots.SavePredictionSnapshot(snapshot); err == nil {
+		prediction.SnapshotID = snapshot.ID
+	}
+
+	return prediction, nil
+}
+
+// Accuracy scores any due snapshots and summarizes the most recent scored ones
+func (s *Service) Accuracy(ctx context.Context, simulationID uuid.UUID, limit int) (*Accuracy, error) {
+	latest, err := s.results.GetLatestSimulationResults(ctx, simulationID, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) > 0 {
+		s.scoreDue(simulationID, latest[0].Timestamp)
+	}
+
+	snapshots, err := s.snapshots.GetScoredPredictionSnapshots(simulationID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	accuracy := &Accuracy{
+		SimulationID: simulationID,
+		ByBackend:    make(map[string]BackendAccuracy),
+		Recent:       snapshots,
+	}
+
+	var sumAbs, sumSq, sumPct float64
+	var pctCount int
+	backendPct := make(map[string]int)
+	for _, snapshot := range snapshots {
+		if snapshot.AbsoluteErrorMW == nil || snapshot.ActualLoadMW == nil {
+			continue
+		}
+		absErr := *snapshot.AbsoluteErrorMW
+		accuracy.Scored++
+		sumAbs += absErr
+		sumSq += absErr * absErr
+
+		backend := accuracy.ByBackend[snapshot.Backend]
+		backend.Scored++
+		backend.MAEMW += absErr
+		if *snapshot.ActualLoadMW > 0 {
+			pct := absErr / *snapshot.ActualLoadMW
+			sumPct += pct
+			pctCount++
+			backend.MAPE += pct
+			backendPct[snapshot.Backend]++
+		}
+		accuracy.ByBackend[snapshot.Backend] = backend
+	}
+
+	if accuracy.Scored > 0 {
+		accuracy.MAEMW = sumAbs / float64(accuracy.Scored)
+		accuracy.RMSEMW = math.Sqrt(sumSq / float64(accuracy.Scored))
+	}
+	if pctCount > 0 {
+		accuracy.MAPE = sumPct / float64(pctCount)
+	}
+	for name, backend := range accuracy.ByBackend {
+		backend.MAEMW /= float64(backend.Scored)
+		if backendPct[name] > 0 {
+			backend.MAPE /= float64(backendPct[name])
+		}
+		accuracy.ByBackend[name] = backend
+	}
+
+	return accuracy, nil
+}
+
+// scoreDue compares snapshots whose target time has been reached against the
+// first result recorded at or after the target
+func (s *Service) scoreDue(simulationID uuid.UUID, latest time.Time) {
+	due, err := s.snapshots.GetDuePredictionSnapshots(simulationID, latest)
+	if err != nil {
+		return
+	}
+
+	for _, snapshot := range due {
+		actual, err := s.snapshots.GetResultAt(simulationID, snapshot.TargetTime)
+		if err != nil || actual == nil {
+			continue
+		}
+		absErr := math.Abs(snapshot.PredictedLoadMW - actual.TotalConsumptionMW)
+		if err := s.snapshots.ScorePredictionSnapshot(snapshot.ID, actual.TotalConsumptionMW, absErr); err != nil {
+			return
+		}
+	}
+}
+
+// Errors
+var (
+	ErrNoHistory = fmt.Errorf("no recorded results to forecast from")
+)
@@ -0,0 +1,141 @@
+package prediction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Smoothing factors searched when fitting the statistical forecaster
+var (
+	holtAlphas = []float64{0.2, 0.4, 0.6, 0.8}
+	holtBetas  = []float64{0.05, 0.1, 0.2}
+)
+
+// samplesForFullConfidence is the history length below which confidence is scaled down
+const samplesForFullConfidence = 30
+
+// Statistical forecasts load with Holt's linear trend method fitted to the
+// recorded consumption. It needs no external service.
+type Statistical struct{}
+
+// NewStatistical creates the built-in statistical backend
+func NewStatistical() *Statistical {
+	return &Statistical{}
+}
+
+// Name returns the backend name
+func (b *Statistical) Name() string {
+	return "statistical"
+}
+
+// Predict fits the smoothing factors with the lowest one-step error and
+// projects the trend over the horizon
+func (b *Statistical) Predict(ctx context.Context, input Input) (*Forecast, error) {
+	if len(input.Samples) == 0 {
+		return nil, ErrNoHistory
+	}
+
+	load := make([]float64, len(input.Samples))
+	var meanLoad float64
+	for i, sample := range input.Samples {
+		load[i] = sample.ConsumptionMW
+		meanLoad += sample.ConsumptionMW
+	}
+	meanLoad /= float64(len(load))
+
+	steps := input.HorizonSeconds / sampleInterval(input.Samples).Seconds()
+
+	forecast := &Forecast{
+		PredictedLoadMW: load[len(load)-1],
+		Model:           "persistence",
+	}
+
+	var rmse float64
+	if len(load) >= 3 {
+		bestSSE := math.Inf(1)
+		for _, alpha := range holtAlphas {
+			for _, beta := range holtBetas {
+				level, trend, sse := fitHolt(load, alpha, beta)
+				if sse < bestSSE {
+					bestSSE = sse
+					forecast.PredictedLoadMW = level + steps*trend
+					forecast.Model = fmt.Sprintf("holt_linear(alpha=%.2f,beta=%.2f)", alpha, beta)
+				}
+			}
+		}
+		rmse = math.Sqrt(bestSSE / float64(len(load)-2))
+	}
+	forecast.PredictedLoadMW = math.Max(0, forecast.PredictedLoadMW)
+
+	// Forecast error grows roughly with the square root of the number of steps ahead
+	if meanLoad > 0 && len(load) >= 3 {
+		spread := rmse * math.Sqrt(math.Max(1, steps)) / meanLoad
+		forecast.Confidence = clamp01(1-spread) * math.Min(1, float64(len(load))/samplesForFullConfidence)
+	}
+
+	var faultyTicks int
+	var totalConsumption, totalGeneration float64
+	for _, sample := range input.Samples {
+		if sample.FaultCount > 0 {
+			faultyTicks++
+		}
+		if sample.GenerationMW > 0 {
+			totalConsumption += sample.ConsumptionMW
+			totalGeneration += sample.GenerationMW
+		}
+	}
+
+	// Probability of at least one faulty tick over the horizon at the observed rate
+	faultRate := float64(faultyTicks) / float64(len(input.Samples))
+	forecast.FailureProbability = clamp01(1 - math.Pow(1-faultRate, math.Max(1, steps)))
+
+	// Generation needed to serve the forecast at the observed delivery ratio
+	deliveryRatio := 1.0
+	if totalGeneration > 0 && totalConsumption > 0 {
+		deliveryRatio = math.Min(1, totalConsumption/totalGeneration)
+	}
+	forecast.OptimalGenerationMW = forecast.PredictedLoadMW / deliveryRatio
+
+	return forecast, nil
+}
+
+// fitHolt runs Holt's method over the series and returns the final level and
+// trend with the sum of squared one-step-ahead errors
+func fitHolt(series []float64, alpha, beta float64) (level, trend, sse float64) {
+	level = series[0]
+	trend = series[1] - series[0]
+
+	for _, value := range series[1:] {
+		predicted := level + trend
+		sse += (value - predicted) * (value - predicted)
+
+		previous := level
+		level = alpha*value + (1-alpha)*(level+trend)
+		trend = beta*(level-previous) + (1-beta)*trend
+	}
+
+	return level, trend, sse
+}
+
+// sampleInterval returns the median spacing between samples, defaulting to a minute
+func sampleInterval(samples []Sample) time.Duration {
+	var gaps []time.Duration
+	for i := 1; i < len(samples); i++ {
+		if gap := samples[i].Timestamp.Sub(samples[i-1].Timestamp); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return time.Minute
+	}
+
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
+
+func clamp01(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
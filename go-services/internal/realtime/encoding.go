@@ -0,0 +1,108 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Streaming formats a client may select
+const (
+	FormatJSON    = "json"
+	FormatMsgPack = "msgpack"
+)
+
+// Content types of the streaming formats
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgPack = "application/msgpack"
+)
+
+// msgpackHandle is shared by all encoders and decoders; it is safe for
+// concurrent use once configured
+var msgpackHandle = newMsgpackHandle()
+
+func newMsgpackHandle() *codec.MsgpackHandle {
+	handle := &codec.MsgpackHandle{}
+	// Timestamps use the msgpack timestamp extension
+	handle.WriteExt = true
+	handle.RawToString = true
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return handle
+}
+
+// ParseFormat validates a requested streaming format. An empty format means JSON.
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatMsgPack:
+		return FormatMsgPack, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+}
+
+// Binary reports whether a format produces binary rather than text payloads
+func Binary(format string) bool {
+	return format == FormatMsgPack
+}
+
+// ContentType returns the content type of a format
+func ContentType(format string) string {
+	if format == FormatMsgPack {
+		return ContentTypeMsgPack
+	}
+	return ContentTypeJSON
+}
+
+// Encoder encodes streamed payloads in one format, reusing its buffer
+// between payloads. It is not safe for concurrent use.
+type Encoder struct {
+	format  string
+	buf     []byte
+	msgpack *codec.Encoder
+}
+
+// NewEncoder creates an encoder for a format returned by ParseFormat
+func NewEncoder(format string) *Encoder {
+	e := &Encoder{format: format}
+	if format == FormatMsgPack {
+		e.msgpack = codec.NewEncoderBytes(&e.buf, msgpackHandle)
+	}
+	return e
+}
+
+// Format returns the encoder's format
+func (e *Encoder) Format() string {
+	return e.format
+}
+
+// Encode encodes v. The returned bytes are only valid until the next call.
+func (e *Encoder) Encode(v interface{}) ([]byte, error) {
+	if e.msgpack == nil {
+		return json.Marshal(v)
+	}
+
+	e.buf = e.buf[:0]
+	e.msgpack.ResetBytes(&e.buf)
+	if err := e.msgpack.Encode(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// Decode decodes a payload sent by a client in the given format
+func Decode(format string, data []byte, v interface{}) error {
+	if format == FormatMsgPack {
+		return codec.NewDecoderBytes(data, msgpackHandle).Decode(v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Errors
+var (
+	ErrUnknownFormat = fmt.Errorf("unknown streaming format, expected json or msgpack")
+)
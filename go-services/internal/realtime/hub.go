@@ -0,0 +1,277 @@
+package realtime
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Message types
+const (
+	MessageAlertAcknowledged = "alert.acknowledged"
+	MessageAlertResolved     = "alert.resolved"
+	MessageResultsSample     = "results.sample"
+	MessageTopologySnapshot  = "topology.snapshot"
+)
+
+// Topic kinds and channels, see ParseTopic
+const (
+	TopicSimulation = "sim"
+	TopicGrid       = "grid"
+
+	ChannelResults  = "results"
+	ChannelAlerts   = "alerts"
+	ChannelTopology = "topology"
+)
+
+// Message is an update pushed to connected clients
+type Message struct {
+	Type string `json:"type"`
+	// What the message is about, e.g. sim.<id>.alerts
+	Topic     string      `json:"topic"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// Topic is a parsed <kind>.<id>.<channel> topic
+type Topic struct {
+	Kind    string
+	ID      string
+	Channel string
+}
+
+// AlertsTopic is the topic of a simulation's alert updates
+func AlertsTopic(simulationID string) string {
+	return TopicSimulation + "." + simulationID + "." + ChannelAlerts
+}
+
+// ResultsTopic is the topic of a simulation's metrics samples
+func ResultsTopic(simulationID string) string {
+	return TopicSimulation + "." + simulationID + "." + ChannelResults
+}
+
+// TopologyTopic is the topic of a simulation grid's topology
+func TopologyTopic(simulationID string) string {
+	return TopicGrid + "." + simulationID + "." + ChannelTopology
+}
+
+// ParseTopic splits a topic into its parts. Only sim.<id>.results,
+// sim.<id>.alerts and grid.<id>.topology are valid.
+func ParseTopic(topic string) (Topic, bool) {
+	first := strings.IndexByte(topic, '.')
+	last := strings.LastIndexByte(topic, '.')
+	if first <= 0 || last <= first+1 || last == len(topic)-1 {
+		return Topic{}, false
+	}
+
+	parsed := Topic{
+		Kind:    topic[:first],
+		ID:      topic[first+1 : last],
+		Channel: topic[last+1:],
+	}
+
+	switch {
+	case parsed.Kind == TopicSimulation && (parsed.Channel == ChannelResults || parsed.Channel == ChannelAlerts):
+		return parsed, true
+	case parsed.Kind == TopicGrid && parsed.Channel == ChannelTopology:
+		return parsed, true
+	default:
+		return Topic{}, false
+	}
+}
+
+// Hub routes published messages to the subscribers of their topic
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+}
+
+// Subscriber receives messages published to its topics after it subscribed
+// to them. Each topic may hold a limited number of undelivered messages;
+// further ones are dropped rather than blocking publishers.
+type Subscriber struct {
+	limit int
+	ready chan struct{}
+
+	mu     sync.Mutex
+	topics map[string]*topicState
+	queue  []Message
+
+	dropped atomic.Int64
+}
+
+// topicState tracks a subscribed topic's undelivered and dropped messages
+type topicState struct {
+	pending int
+	dropped int64
+}
+
+// NewHub creates a new hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber without topics. Each topic it later adds
+// may hold up to limit undelivered messages.
+func (h *Hub) Subscribe(limit int) *Subscriber {
+	if limit <= 0 {
+		limit = 1
+	}
+	subscriber := &Subscriber{
+		limit:  limit,
+		ready:  make(chan struct{}, 1),
+		topics: make(map[string]*topicState),
+	}
+
+	h.mu.Lock()
+	h.subscribers[subscriber] = struct{}{}
+	h.mu.Unlock()
+
+	return subscriber
+}
+
+// Unsubscribe removes a subscriber from every topic
+func (h *Hub) Unsubscribe(subscriber *Subscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, subscriber)
+	h.mu.Unlock()
+}
+
+// Publish delivers a message to the subscribers of its topic without blocking
+func (h *Hub) Publish(msg Message) {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now().UTC()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscriber := range h.subscribers {
+		subscriber.deliver(msg)
+	}
+}
+
+// Subscribers returns the number of current subscribers
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subscribers)
+}
+
+// Add subscribes to topics. Topics already subscribed to are left as they are.
+func (s *Subscriber) Add(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; !ok {
+			s.topics[topic] = &topicState{}
+		}
+	}
+}
+
+// Remove unsubscribes from topics and discards their undelivered messages
+func (s *Subscriber) Remove(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := false
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; ok {
+			delete(s.topics, topic)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+
+	kept := s.queue[:0]
+	for _, msg := range s.queue {
+		if _, ok := s.topics[msg.Topic]; ok {
+			kept = append(kept, msg)
+		}
+	}
+	clear(s.queue[len(kept):])
+	s.queue = kept
+}
+
+// Topics returns the subscribed topics, sorted
+func (s *Subscriber) Topics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Ready signals that messages are waiting to be drained
+func (s *Subscriber) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Drain returns the undelivered messages in publish order and empties the queue
+func (s *Subscriber) Drain() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := s.queue
+	s.queue = nil
+	for _, state := range s.topics {
+		state.pending = 0
+	}
+	return messages
+}
+
+// Dropped returns how many messages were dropped because a topic's limit was reached
+func (s *Subscriber) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// DroppedByTopic returns the dropped message count of each subscribed topic
+// that has dropped messages
+func (s *Subscriber) DroppedByTopic() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := make(map[string]int64)
+	for topic, state := range s.topics {
+		if state.dropped > 0 {
+			dropped[topic] = state.dropped
+		}
+	}
+	return dropped
+}
+
+// deliver queues a message when the subscriber has its topic and room for it
+func (s *Subscriber) deliver(msg Message) {
+	s.mu.Lock()
+	state, ok := s.topics[msg.Topic]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	if state.pending >= s.limit {
+		state.dropped++
+		s.mu.Unlock()
+		s.dropped.Add(1)
+		return
+	}
+	state.pending++
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,344 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/lock"
+	"voltedge/go-services/internal/notifications"
+	"voltedge/go-services/internal/observability"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// Database simulation statuses involved in reconciliation
+const (
+	StatusRunning     = "running"
+	StatusPaused      = "paused"
+	StatusCompleted   = "completed"
+	StatusFailed      = "failed"
+	StatusStopped     = "stopped"
+	StatusInterrupted = "interrupted"
+)
+
+// Reconciliation actions
+const (
+	// The orchestrator has no run for a row that claims to be active
+	ActionInterrupted = "interrupted"
+	// The row's status was brought in line with the orchestrator
+	ActionStatusSynced = "status_synced"
+)
+
+// lockName guards passes so only one replica reconciles at a time
+const lockName = "voltedge:reconcile"
+
+// maxActions bounds the history of actions kept for the admin API
+const maxActions = 200
+
+// Store is the database side of reconciliation
+type Store interface {
+	GetSimulationsByStatus(ctx context.Context, statuses ...string) ([]database.Simulation, error)
+	TransitionSimulationStatus(ctx context.Context, id uuid.UUID, from, to, reason string, events ...*database.OutboxEvent) (bool, error)
+}
+
+// Runtime is the orchestrator side of reconciliation
+type Runtime interface {
+	// LoadedSimulation returns a simulation this process has in memory.
+	// Stored simulations that are not loaded have no run here.
+	LoadedSimulation(id string) (*orchestration.Simulation, bool)
+}
+
+// Options configures the reconciler
+type Options struct {
+	// Time between passes; zero disables the background loop
+	Interval time.Duration
+	// Rows started more recently than this are left alone, since their run
+	// may not have reached the orchestrator yet
+	GracePeriod time.Duration
+	// Optional; when set, passes are skipped while another replica holds the lock
+	Locker lock.Locker
+}
+
+// Action is a correction made to a simulation row
+type Action struct {
+	SimulationID uuid.UUID `json:"simulation_id"`
+	Action       string    `json:"action"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Reason       string    `json:"reason"`
+	At           time.Time `json:"at"`
+}
+
+// Report summarizes a reconciliation pass
+type Report struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Checked    int       `json:"checked"`
+	Divergent  int       `json:"divergent"`
+	Actions    []Action  `json:"actions"`
+	// Another replica was reconciling, so this pass did nothing
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Reconciler detects simulation rows whose status disagrees with the
+// orchestrator, such as rows left "running" by a previous process, and
+// corrects them
+type Reconciler struct {
+	store   Store
+	runtime Runtime
+	opts    Options
+
+	// Serializes passes
+	runMu sync.Mutex
+
+	mu      sync.RWMutex
+	last    *Report
+	actions []Action
+}
+
+// New creates a new reconciler
+func New(store Store, runtime Runtime, opts Options) *Reconciler {
+	if opts.GracePeriod < 0 {
+		opts.GracePeriod = 0
+	}
+
+	return &Reconciler{
+		store:   store,
+		runtime: runtime,
+		opts:    opts,
+	}
+}
+
+// Start runs a pass immediately and then every interval until ctx is done
+func (r *Reconciler) Start(ctx context.Context) {
+	if r.opts.Interval <= 0 {
+		logrus.Info("Reconciliation loop disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			r.Run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run performs a single reconciliation pass
+func (r *Reconciler) Run(ctx context.Context) *Report {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	report := &Report{StartedAt: time.Now(), Actions: []Action{}}
+
+	if r.opts.Locker == nil {
+		r.reconcile(ctx, report)
+	} else {
+		ran, err := lock.RunExclusive(ctx, r.opts.Locker, lockName, func(ctx context.Context) {
+			r.reconcile(ctx, report)
+		})
+		if err != nil {
+			report.Error = err.Error()
+		}
+		report.Skipped = !ran && err == nil
+	}
+
+	report.FinishedAt = time.Now()
+	if report.Skipped {
+		return report
+	}
+
+	var err error
+	if report.Error != "" {
+		err = errors.New(report.Error)
+	}
+	observability.RecordReconcileRun(err, report.Divergent)
+
+	r.mu.Lock()
+	r.last = report
+	r.actions = append(r.actions, report.Actions...)
+	if len(r.actions) > maxActions {
+		r.actions = r.actions[len(r.actions)-maxActions:]
+	}
+	r.mu.Unlock()
+
+	return report
+}
+
+// reconcile checks active rows against the orchestrator, recording corrections in report
+func (r *Reconciler) reconcile(ctx context.Context, report *Report) {
+	rows, err := r.store.GetSimulationsByStatus(ctx, StatusRunning, StatusPaused)
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	for _, row := range rows {
+		report.Checked++
+
+		to, reason, diverged := r.expectedStatus(ctx, row, report.StartedAt)
+		if !diverged {
+			continue
+		}
+		report.Divergent++
+
+		// Only failures carry the reason into the row's error message
+		message := ""
+		if to == StatusInterrupted || to == StatusFailed {
+			message = reason
+		}
+
+		// The event is published in the same transaction as the status change
+		var events []*database.OutboxEvent
+		if event, ok := statusEvent(row, to, reason); ok {
+			outboxEvent, err := notifications.NewOutboxEvent(event)
+			if err != nil {
+				report.Error = err.Error()
+				continue
+			}
+			events = append(events, outboxEvent)
+		}
+
+		changed, err := r.store.TransitionSimulationStatus(ctx, row.ID, row.Status, to, message, events...)
+		if err != nil {
+			report.Error = err.Error()
+			continue
+		}
+		// Someone else moved the row on since it was read
+		if !changed {
+			continue
+		}
+
+		action := Action{
+			SimulationID: row.ID,
+			Action:       ActionStatusSynced,
+			From:         row.Status,
+			To:           to,
+			Reason:       reason,
+			At:           time.Now(),
+		}
+		if to == StatusInterrupted {
+			action.Action = ActionInterrupted
+		}
+		report.Actions = append(report.Actions, action)
+		observability.RecordReconcileAction(action.Action)
+
+		logrus.WithFields(logrus.Fields{
+			"simulation_id": row.ID,
+			"from":          row.Status,
+			"to":            to,
+		}).Warn("Reconciled simulation status")
+	}
+}
+
+// LastReport returns the report of the most recent pass, or nil before the first
+func (r *Reconciler) LastReport() *Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.last
+}
+
+// Actions returns recent corrections, oldest first
+func (r *Reconciler) Actions() []Action {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	actions := make([]Action, len(r.actions))
+	copy(actions, r.actions)
+	return actions
+}
+
+// expectedStatus returns the status an active row should have according to the orchestrator
+func (r *Reconciler) expectedStatus(ctx context.Context, row database.Simulation, now time.Time) (string, string, bool) {
+	simulation, loaded := r.runtime.LoadedSimulation(row.ID.String())
+	if !loaded {
+		if row.StartedAt != nil && now.Sub(*row.StartedAt) < r.opts.GracePeriod {
+			return "", "", false
+		}
+		if r.runningElsewhere(ctx, row.ID.String()) {
+			return "", "", false
+		}
+		return StatusInterrupted, "run not found in orchestrator, likely lost in a restart", true
+	}
+
+	var status string
+	switch simulation.Status {
+	case orchestration.StatusRunning, orchestration.StatusWaiting, orchestration.StatusQueued:
+		status = StatusRunning
+	case orchestration.StatusPaused:
+		status = StatusPaused
+	case orchestration.StatusCompleted:
+		status = StatusCompleted
+	case orchestration.StatusError:
+		status = StatusFailed
+	default:
+		status = StatusStopped
+	}
+
+	if status == row.Status {
+		return "", "", false
+	}
+	return status, fmt.Sprintf("orchestrator reports %s", simulation.Status), true
+}
+
+// runningElsewhere reports whether another replica holds the simulation's run lease
+func (r *Reconciler) runningElsewhere(ctx context.Context, id string) bool {
+	if r.opts.Locker == nil {
+		return false
+	}
+
+	lease, ok, err := r.opts.Locker.TryAcquire(ctx, orchestration.RunLockName(id))
+	if err != nil {
+		// Leave the row alone rather than interrupt a run we cannot see
+		return true
+	}
+	if ok {
+		lease.Release()
+	}
+	return !ok
+}
+
+// statusEvent returns the notification announcing a reconciled status, if any
+func statusEvent(row database.Simulation, to, reason string) (notifications.Event, bool) {
+	event := notifications.Event{
+		// Stable so a repeated pass cannot publish the same correction twice
+		ID:           fmt.Sprintf("reconcile:%s:%s:%s", row.ID, row.Status, to),
+		SimulationID: row.ID.String(),
+		Message:      reason,
+		Data: map[string]interface{}{
+			"name":       row.Name,
+			"status":     to,
+			"reconciled": true,
+		},
+	}
+
+	switch to {
+	case StatusInterrupted, StatusFailed:
+		event.Type = notifications.EventSimulationFailed
+		event.Severity = "error"
+	case StatusCompleted:
+		event.Type = notifications.EventSimulationCompleted
+		event.Severity = "info"
+	case StatusStopped:
+		event.Type = notifications.EventSimulationStopped
+		event.Severity = "info"
+	default:
+		return event, false
+	}
+	return event, true
+}
@@ -0,0 +1,94 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"voltedge/go-services/internal/engine"
+)
+
+// recordedEngine records the calls made to an engine
+type recordedEngine struct {
+	engine.Engine
+	recorder *Recorder
+}
+
+// WrapEngine returns an engine that records every call to e before
+// returning its result. Streamed states are not recorded, only the call
+// that opened the stream.
+func WrapEngine(e engine.Engine, recorder *Recorder) engine.Engine {
+	return &recordedEngine{Engine: e, recorder: recorder}
+}
+
+// Create records the spec and the ID the engine returned
+func (e *recordedEngine) Create(ctx context.Context, spec engine.Spec) (string, error) {
+	started := time.Now()
+	id, err := e.Engine.Create(ctx, spec)
+
+	request := e.recorder.SanitizeValue(map[string]interface{}{
+		"name":   spec.Name,
+		"config": spec.Config,
+		"speed":  spec.Speed,
+		"seed":   spec.Seed,
+	})
+	var result json.RawMessage
+	if err == nil {
+		result = e.recorder.SanitizeValue(map[string]string{"run_id": id})
+	}
+	e.record("Create", id, request, result, started, err)
+	return id, err
+}
+
+// Start records the start of a run
+func (e *recordedEngine) Start(ctx context.Context, id string) error {
+	started := time.Now()
+	err := e.Engine.Start(ctx, id)
+	e.record("Start", id, nil, nil, started, err)
+	return err
+}
+
+// Stop records the stop of a run
+func (e *recordedEngine) Stop(ctx context.Context, id string) error {
+	started := time.Now()
+	err := e.Engine.Stop(ctx, id)
+	e.record("Stop", id, nil, nil, started, err)
+	return err
+}
+
+// Stream records that a stream was opened
+func (e *recordedEngine) Stream(ctx context.Context, id string) (<-chan engine.State, error) {
+	started := time.Now()
+	states, err := e.Engine.Stream(ctx, id)
+	e.record("Stream", id, nil, nil, started, err)
+	return states, err
+}
+
+// Inject records the injected fault
+func (e *recordedEngine) Inject(ctx context.Context, id string, fault engine.Fault) error {
+	started := time.Now()
+	err := e.Engine.Inject(ctx, id, fault)
+	e.record("Inject", id, e.recorder.SanitizeValue(fault), nil, started, err)
+	return err
+}
+
+// record appends an engine call to the recording
+func (e *recordedEngine) record(method, runID string, request, result json.RawMessage, started time.Time, err error) {
+	call := &EngineCall{
+		Engine:  e.Descriptor().Name,
+		Method:  method,
+		RunID:   runID,
+		Request: request,
+		Result:  result,
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	e.recorder.Record(Entry{
+		Kind:       KindEngine,
+		Time:       started.UTC(),
+		DurationMS: float64(time.Since(started).Microseconds()) / 1000,
+		Engine:     call,
+	})
+}
@@ -0,0 +1,275 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of recorded entries
+const (
+	KindHTTP   = "http"
+	KindEngine = "engine"
+)
+
+// Redacted replaces sensitive values in recorded payloads
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields are the JSON keys redacted when no list is configured
+var DefaultRedactFields = []string{
+	"password", "token", "access_token", "refresh_token", "secret",
+	"api_key", "authorization", "bearer_token", "client_secret",
+}
+
+// recordedHeaders are the request headers kept in a recording. Authorization
+// and cookies are never recorded; replay supplies its own credentials.
+var recordedHeaders = []string{"Content-Type", "Accept", "If-Match", "If-None-Match", "Idempotency-Key"}
+
+// Entry is one line of a recording
+type Entry struct {
+	Seq        int64         `json:"seq"`
+	Kind       string        `json:"kind"`
+	Time       time.Time     `json:"time"`
+	DurationMS float64       `json:"duration_ms"`
+	HTTP       *HTTPExchange `json:"http,omitempty"`
+	Engine     *EngineCall   `json:"engine,omitempty"`
+}
+
+// HTTPExchange is an API request and the response it got
+type HTTPExchange struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query,omitempty"`
+	Route   string            `json:"route,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Sanitized JSON body; absent when the body was empty, not JSON or too large
+	Body json.RawMessage `json:"body,omitempty"`
+	// Why the body was left out, if it was
+	BodyOmitted string          `json:"body_omitted,omitempty"`
+	Status      int             `json:"status"`
+	Response    json.RawMessage `json:"response,omitempty"`
+}
+
+// EngineCall is a call the gateway made to a simulation engine
+type EngineCall struct {
+	Engine string `json:"engine"`
+	Method string `json:"method"`
+	// The engine's ID of the simulation, when the call has one
+	RunID   string          `json:"run_id,omitempty"`
+	Request json.RawMessage `json:"request,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Options configures a recorder
+type Options struct {
+	// File the recording is appended to
+	Path string
+	// Bodies larger than this are not recorded
+	MaxBodyBytes int
+	// JSON keys whose values are redacted, matched case-insensitively;
+	// DefaultRedactFields when empty
+	RedactFields []string
+}
+
+// Recorder appends sanitized API traffic and engine calls to a JSON lines
+// file that the replay command can re-drive
+type Recorder struct {
+	opts   Options
+	redact map[string]bool
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	seq    int64
+}
+
+// New opens the recording file for appending
+func New(opts Options) (*Recorder, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("%w: path is required", ErrInvalidOptions)
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = 64 << 10
+	}
+	if len(opts.RedactFields) == 0 {
+		opts.RedactFields = DefaultRedactFields
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	file, err := os.OpenFile(opts.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+
+	redact := make(map[string]bool, len(opts.RedactFields))
+	for _, field := range opts.RedactFields {
+		redact[strings.ToLower(field)] = true
+	}
+
+	logrus.WithField("path", opts.Path).Warn("Recording API traffic for replay")
+	return &Recorder{
+		opts:   opts,
+		redact: redact,
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// MaxBodyBytes is the largest body the recorder keeps
+func (r *Recorder) MaxBodyBytes() int {
+	return r.opts.MaxBodyBytes
+}
+
+// Record appends an entry, numbering it and stamping it if it has no time.
+// Entries are flushed as they are written so a crash loses at most one.
+func (r *Recorder) Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return
+	}
+
+	r.seq++
+	entry.Seq = r.seq
+	line, err := json.Marshal(entry)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to encode recording entry")
+		return
+	}
+	line = append(line, '\n')
+	if _, err := r.writer.Write(line); err == nil {
+		err = r.writer.Flush()
+	}
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to write recording entry")
+	}
+}
+
+// Close flushes and closes the recording file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	flushErr := r.writer.Flush()
+	closeErr := r.file.Close()
+	r.file = nil
+	return errors.Join(flushErr, closeErr)
+}
+
+// Headers returns the request headers worth recording
+func (r *Recorder) Headers(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for _, name := range recordedHeaders {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// Sanitize returns a JSON body with the values of sensitive keys redacted.
+// Bodies that are empty, not JSON or over the size limit are dropped, with
+// the reason.
+func (r *Recorder) Sanitize(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if len(body) > r.opts.MaxBodyBytes {
+		return nil, fmt.Sprintf("body of %d bytes exceeds the %d byte limit", len(body), r.opts.MaxBodyBytes)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, fmt.Sprintf("non-JSON body of %d bytes", len(body))
+	}
+	sanitized, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		return nil, fmt.Sprintf("body could not be re-encoded: %v", err)
+	}
+	return sanitized, ""
+}
+
+// SanitizeValue encodes a value as sanitized JSON
+func (r *Recorder) SanitizeValue(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	sanitized, _ := r.Sanitize(encoded)
+	return sanitized
+}
+
+// redactValue replaces the values of sensitive keys throughout a decoded
+// JSON value
+func (r *Recorder) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if r.redact[strings.ToLower(key)] {
+				v[key] = Redacted
+			} else {
+				v[key] = r.redactValue(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = r.redactValue(inner)
+		}
+	}
+	return value
+}
+
+// Reader reads the entries of a recording in order
+type Reader struct {
+	decoder *json.Decoder
+}
+
+// NewReader reads a recording from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{decoder: json.NewDecoder(r)}
+}
+
+// Next returns the next entry, or io.EOF after the last
+func (r *Reader) Next() (*Entry, error) {
+	var entry Entry
+	if err := r.decoder.Decode(&entry); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: %v", ErrCorruptRecording, err)
+	}
+	return &entry, nil
+}
+
+// Errors
+var (
+	ErrInvalidOptions   = fmt.Errorf("invalid recording options")
+	ErrCorruptRecording = fmt.Errorf("corrupt recording")
+)
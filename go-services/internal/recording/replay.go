@@ -0,0 +1,205 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ReplayOptions configures a replay
+type ReplayOptions struct {
+	// Base URL of the gateway to re-drive, e.g. http://localhost:8080
+	Target string
+	// Bearer token sent with every request, since recordings hold none
+	Token string
+	// Replays the gaps between requests divided by this factor; zero sends
+	// them back to back
+	Pace float64
+	// Defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+// Mismatch is a replayed request whose status differed from the recording
+type Mismatch struct {
+	Seq            int64  `json:"seq"`
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	RecordedStatus int    `json:"recorded_status"`
+	ReplayedStatus int    `json:"replayed_status"`
+	// Error message of the replayed response, if it had one
+	Message string `json:"message,omitempty"`
+}
+
+// ReplayReport summarizes a replay
+type ReplayReport struct {
+	Requests int `json:"requests"`
+	Matched  int `json:"matched"`
+	// Engine calls in the recording; they happen again as a side effect of
+	// the requests rather than being sent
+	EngineCalls int        `json:"engine_calls"`
+	Mismatches  []Mismatch `json:"mismatches"`
+}
+
+// Replay sends the recorded API requests to a gateway in order and compares
+// the status codes it returns with the recorded ones. IDs the target
+// assigns to created resources replace the recorded ones in later requests.
+// onResult, if set, is called after every request.
+func Replay(ctx context.Context, reader *Reader, opts ReplayOptions, onResult func(exchange *HTTPExchange, status int, mismatch *Mismatch)) (*ReplayReport, error) {
+	if opts.Target == "" {
+		return nil, fmt.Errorf("%w: target is required", ErrInvalidOptions)
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	target := strings.TrimRight(opts.Target, "/")
+
+	report := &ReplayReport{Mismatches: []Mismatch{}}
+	ids := make(map[string]string)
+	var previous time.Time
+
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+
+		if entry.Kind == KindEngine {
+			report.EngineCalls++
+			continue
+		}
+		if entry.Kind != KindHTTP || entry.HTTP == nil {
+			continue
+		}
+
+		if opts.Pace > 0 && !previous.IsZero() {
+			if gap := entry.Time.Sub(previous); gap > 0 {
+				select {
+				case <-time.After(time.Duration(float64(gap) / opts.Pace)):
+				case <-ctx.Done():
+					return report, ctx.Err()
+				}
+			}
+		}
+		previous = entry.Time
+
+		exchange := entry.HTTP
+		status, response, err := send(ctx, client, target, opts.Token, exchange, ids)
+		if err != nil {
+			return report, fmt.Errorf("request %d (%s %s) failed: %w", entry.Seq, exchange.Method, exchange.Path, err)
+		}
+		report.Requests++
+		mapCreatedID(ids, exchange.Response, response)
+
+		var mismatch *Mismatch
+		if status == exchange.Status {
+			report.Matched++
+		} else {
+			report.Mismatches = append(report.Mismatches, Mismatch{
+				Seq:            entry.Seq,
+				Method:         exchange.Method,
+				Path:           exchange.Path,
+				RecordedStatus: exchange.Status,
+				ReplayedStatus: status,
+				Message:        errorMessage(response),
+			})
+			mismatch = &report.Mismatches[len(report.Mismatches)-1]
+		}
+		if onResult != nil {
+			onResult(exchange, status, mismatch)
+		}
+	}
+}
+
+// send re-issues a recorded request with recorded IDs replaced by the
+// target's, returning the status and body of the response
+func send(ctx context.Context, client *http.Client, target, token string, exchange *HTTPExchange, ids map[string]string) (int, []byte, error) {
+	url := target + replaceIDs(exchange.Path, ids)
+	if exchange.Query != "" {
+		url += "?" + replaceIDs(exchange.Query, ids)
+	}
+
+	var body io.Reader
+	if len(exchange.Body) > 0 {
+		body = strings.NewReader(replaceIDs(string(exchange.Body), ids))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, exchange.Method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	for name, value := range exchange.Headers {
+		req.Header.Set(name, value)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, response, nil
+}
+
+// replaceIDs substitutes the target's IDs for recorded ones
+func replaceIDs(s string, ids map[string]string) string {
+	for recorded, replayed := range ids {
+		s = strings.ReplaceAll(s, recorded, replayed)
+	}
+	return s
+}
+
+// mapCreatedID remembers the ID the target gave a resource that had a
+// different ID in the recording
+func mapCreatedID(ids map[string]string, recorded json.RawMessage, replayed []byte) {
+	recordedID := responseID(recorded)
+	replayedID := responseID(replayed)
+	if recordedID != "" && replayedID != "" && recordedID != replayedID {
+		ids[recordedID] = replayedID
+	}
+}
+
+// responseID returns the id field of a response's data, if it has one
+func responseID(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var envelope struct {
+		Data struct {
+			ID interface{} `json:"id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(body, &envelope) != nil {
+		return ""
+	}
+	if id, ok := envelope.Data.ID.(string); ok {
+		return id
+	}
+	return ""
+}
+
+// errorMessage returns the message of an error response
+func errorMessage(body []byte) string {
+	var response struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(bytes.TrimSpace(body), &response) != nil {
+		return ""
+	}
+	return response.Message
+}
@@ -0,0 +1,74 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Label is a time series label
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value at a time, in milliseconds since the epoch
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries is a labelled series of samples
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// encodeWriteRequest encodes series as a prometheus.WriteRequest message:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label        { string name = 1; string value = 2; }
+//	message Sample       { double value = 1; int64 timestamp = 2; }
+//
+// Labels are sorted by name, as receivers require.
+func encodeWriteRequest(series []TimeSeries) []byte {
+	var buf, message []byte
+	for i := range series {
+		message = encodeTimeSeries(message[:0], &series[i])
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, message)
+	}
+	return buf
+}
+
+func encodeTimeSeries(buf []byte, series *TimeSeries) []byte {
+	sort.Slice(series.Labels, func(i, j int) bool {
+		return series.Labels[i].Name < series.Labels[j].Name
+	})
+
+	for _, label := range series.Labels {
+		size := protowire.SizeTag(1) + protowire.SizeBytes(len(label.Name)) +
+			protowire.SizeTag(2) + protowire.SizeBytes(len(label.Value))
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendVarint(buf, uint64(size))
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendString(buf, label.Name)
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendString(buf, label.Value)
+	}
+
+	for _, sample := range series.Samples {
+		size := protowire.SizeTag(1) + protowire.SizeFixed64() +
+			protowire.SizeTag(2) + protowire.SizeVarint(uint64(sample.Timestamp))
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendVarint(buf, uint64(size))
+		buf = protowire.AppendTag(buf, 1, protowire.Fixed64Type)
+		buf = protowire.AppendFixed64(buf, math.Float64bits(sample.Value))
+		buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, uint64(sample.Timestamp))
+	}
+
+	return buf
+}
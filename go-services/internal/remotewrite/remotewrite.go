@@ -0,0 +1,343 @@
+// Package remotewrite pushes per-simulation telemetry to a Prometheus
+// remote-write endpoint such as Prometheus, VictoriaMetrics or Mimir.
+// Scraping suits process metrics, but simulations come and go and report
+// every tick, so their series are pushed with their own timestamps instead.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/observability"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// Sample results recorded in observability
+const (
+	resultSent    = "sent"
+	resultFailed  = "failed"
+	resultDropped = "dropped"
+)
+
+// Options configures the exporter
+type Options struct {
+	URL         string
+	BearerToken string
+	Username    string
+	Password    string
+	// Added to every series
+	ExternalLabels map[string]string
+	// Series per request
+	BatchSize int
+	// Partial batches are sent after this long
+	FlushInterval time.Duration
+	// Series buffered while the endpoint is slow or down. When the queue is
+	// full new series are dropped rather than stalling the simulations.
+	QueueSize    int
+	Timeout      time.Duration
+	MaxRetries   int
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+}
+
+// Exporter batches time series and sends them to a remote-write endpoint
+type Exporter struct {
+	opts   Options
+	client *http.Client
+	queue  chan TimeSeries
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// New creates a new exporter. Start must be called before series are sent.
+func New(opts Options) *Exporter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.QueueSize < opts.BatchSize {
+		opts.QueueSize = opts.BatchSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = time.Second
+	}
+	if opts.MaxBackoff < opts.RetryBackoff {
+		opts.MaxBackoff = opts.RetryBackoff
+	}
+
+	return &Exporter{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		queue:  make(chan TimeSeries, opts.QueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start sends queued series until ctx is done or Stop is called
+func (e *Exporter) Start(ctx context.Context) {
+	ctx, e.cancel = context.WithCancel(ctx)
+	go e.run(ctx)
+
+	logrus.WithField("url", e.opts.URL).Info("Remote-write exporter started")
+}
+
+// Stop sends what is still queued and waits for the exporter to finish
+func (e *Exporter) Stop() {
+	e.once.Do(func() {
+		if e.cancel != nil {
+			e.cancel()
+			<-e.done
+		}
+	})
+}
+
+// ExportSimulationMetrics queues the series of an orchestrator metrics sample
+func (e *Exporter) ExportSimulationMetrics(simulationID string, sample orchestration.MetricsSample) {
+	e.Enqueue(e.seriesOf(simulationID, sample)...)
+}
+
+// Enqueue queues series without blocking, dropping those that do not fit
+func (e *Exporter) Enqueue(series ...TimeSeries) {
+	dropped := 0
+	for i := range series {
+		select {
+		case e.queue <- series[i]:
+		default:
+			dropped += len(series[i].Samples)
+		}
+	}
+	if dropped > 0 {
+		observability.RecordRemoteWriteSamples(resultDropped, dropped)
+	}
+}
+
+// seriesOf converts a metrics sample to one series per value
+func (e *Exporter) seriesOf(simulationID string, sample orchestration.MetricsSample) []TimeSeries {
+	timestamp := sample.Timestamp.UnixMilli()
+	series := make([]TimeSeries, 0, len(sample.Components)+3)
+
+	add := func(name string, value float64, labels ...Label) {
+		all := make([]Label, 0, len(labels)+len(e.opts.ExternalLabels)+2)
+		for key, value := range e.opts.ExternalLabels {
+			all = append(all, Label{Name: key, Value: value})
+		}
+		all = append(all, Label{Name: "__name__", Value: name}, Label{Name: "simulation_id", Value: simulationID})
+		all = append(all, labels...)
+
+		series = append(series, TimeSeries{
+			Labels:  all,
+			Samples: []Sample{{Value: value, Timestamp: timestamp}},
+		})
+	}
+
+	add("voltedge_simulation_events_processed_total", float64(sample.EventsProcessed))
+	if sample.Ticks > 0 {
+		add("voltedge_simulation_tick_time_ms", sample.TickTimeMS)
+	}
+	if sample.MemoryUsageMB > 0 {
+		add("voltedge_simulation_memory_usage_mb", float64(sample.MemoryUsageMB))
+	}
+
+	for _, component := range sample.Components {
+		labels := []Label{
+			{Name: "component_type", Value: component.Type},
+			{Name: "component_id", Value: strconv.Itoa(component.ID)},
+		}
+		if component.Unit != "" {
+			labels = append(labels, Label{Name: "unit", Value: component.Unit})
+		}
+		add("voltedge_component_"+metricName(component.Metric), component.Value, labels...)
+	}
+
+	return series
+}
+
+// metricName makes a component metric name valid in Prometheus
+func metricName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// run batches queued series and sends them until ctx is done
+func (e *Exporter) run(ctx context.Context) {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]TimeSeries, 0, e.opts.BatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) > 0 {
+			e.send(ctx, batch)
+			batch = batch[:0]
+		}
+		observability.SetRemoteWriteQueueLength(len(e.queue))
+	}
+
+	for {
+		select {
+		case series := <-e.queue:
+			batch = append(batch, series)
+			if len(batch) >= e.opts.BatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			e.drain(batch)
+			return
+		}
+	}
+}
+
+// drain sends the pending batch and whatever is still queued, within one timeout
+func (e *Exporter) drain(batch []TimeSeries) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.Timeout)
+	defer cancel()
+
+	for {
+		select {
+		case series := <-e.queue:
+			batch = append(batch, series)
+			if len(batch) < e.opts.BatchSize {
+				continue
+			}
+		default:
+			if len(batch) > 0 {
+				e.send(ctx, batch)
+			}
+			observability.SetRemoteWriteQueueLength(0)
+			return
+		}
+
+		e.send(ctx, batch)
+		batch = batch[:0]
+	}
+}
+
+// send posts a batch, retrying failures that may be temporary
+func (e *Exporter) send(ctx context.Context, batch []TimeSeries) {
+	body := encode(batch)
+	samples := 0
+	for i := range batch {
+		samples += len(batch[i].Samples)
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := e.post(ctx, body)
+		if err == nil {
+			observability.RecordRemoteWriteSamples(resultSent, samples)
+			return
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= e.opts.MaxRetries || ctx.Err() != nil {
+			observability.RecordRemoteWriteSamples(resultFailed, samples)
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"samples":  samples,
+				"attempts": attempt + 1,
+			}).Warn("Dropped remote-write batch")
+			return
+		}
+
+		// Exponential backoff, or longer if the endpoint asked for it
+		backoff := e.opts.RetryBackoff << min(attempt, 20)
+		if backoff <= 0 || backoff > e.opts.MaxBackoff {
+			backoff = e.opts.MaxBackoff
+		}
+		backoff = max(backoff, retryAfter)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// post sends an encoded request once, returning any delay the endpoint asked for
+func (e *Exporter) post(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "voltedge-remote-write")
+	if e.opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+e.opts.BearerToken)
+	} else if e.opts.Username != "" {
+		req.SetBasicAuth(e.opts.Username, e.opts.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("remote write returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+
+	// Only throttling and server errors are worth retrying
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, &permanentError{err}
+	}
+
+	var retryAfter time.Duration
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return retryAfter, err
+}
+
+// encode builds a snappy-compressed write request
+func encode(batch []TimeSeries) []byte {
+	return snappy.Encode(nil, encodeWriteRequest(batch))
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
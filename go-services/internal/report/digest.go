@@ -0,0 +1,139 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"voltedge/go-services/internal/database"
+)
+
+const (
+	// Runs and alerts listed in a digest, the rest are only counted
+	maxDigestRuns   = 50
+	maxDigestAlerts = 50
+)
+
+// Alert severities, most severe first, as digests list their counts
+var digestSeverities = []string{"critical", "error", "warning", "info"}
+
+// Digest summarises the runs, experiments and alerts of a period for a
+// scheduled report
+type Digest struct {
+	Schedule    string
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+	// Runs that started or finished in the period, most recent first
+	Runs     []DigestRun
+	MoreRuns int64
+	// Experiments with runs in the period
+	Experiments []DigestExperiment
+	AlertCounts []SeverityCount
+	// Alerts triggered in the period, newest first
+	Alerts     []database.Alert
+	MoreAlerts int64
+}
+
+// DigestRun is a run listed in a digest
+type DigestRun struct {
+	Simulation database.Simulation
+	// Frequency stability score, when the run has one
+	StabilityScore *float64
+}
+
+// DigestExperiment is an experiment listed in a digest
+type DigestExperiment struct {
+	ID        string
+	Name      string
+	Status    string
+	Runs      int
+	Completed int
+	Failed    int
+}
+
+// SeverityCount is the number of alerts of a severity
+type SeverityCount struct {
+	Severity string
+	Count    int64
+}
+
+// Empty reports whether nothing happened in the period
+func (d *Digest) Empty() bool {
+	return len(d.Runs) == 0 && len(d.Experiments) == 0 && len(d.AlertCounts) == 0
+}
+
+// Subject returns the email subject of a digest
+func (d *Digest) Subject() string {
+	var alerts int64
+	for _, count := range d.AlertCounts {
+		alerts += count.Count
+	}
+	runs := int64(len(d.Runs)) + d.MoreRuns
+	period := d.From.Format("Jan 2, 2006")
+	if d.Schedule == database.ReportScheduleWeekly {
+		period = "the week of " + period
+	}
+	return fmt.Sprintf("VoltEdge %s report for %s: %d runs, %d alerts", d.Schedule, period, runs, alerts)
+}
+
+var digestTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04 MST")
+	},
+	"optionalTime": formatTime,
+	"score": func(value *float64) string {
+		if value == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f", *value)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>VoltEdge {{.Schedule}} report</title>
+</head>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #1a202c; max-width: 760px;">
+<h1 style="font-size: 22px; margin-bottom: 4px;">VoltEdge {{.Schedule}} report</h1>
+<div style="color: #718096; font-size: 13px;">{{formatTime .From}} to {{formatTime .To}}</div>
+
+<h2 style="font-size: 17px; margin-top: 24px;">Alerts</h2>
+{{if .AlertCounts}}<p>{{range $i, $c := .AlertCounts}}{{if $i}}, {{end}}<strong>{{$c.Count}}</strong> {{$c.Severity}}{{end}}</p>
+<table style="border-collapse: collapse; width: 100%; font-size: 13px;">
+<tr style="background: #f7fafc;"><th align="left">Triggered</th><th align="left">Simulation</th><th align="left">Severity</th><th align="left">Type</th><th align="left">Message</th></tr>
+{{range .Alerts}}<tr><td>{{formatTime .TriggeredAt}}</td><td>{{.Simulation.Name}}</td><td>{{.Severity}}</td><td>{{.AlertType}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+{{if .MoreAlerts}}<p style="color: #718096;">{{.MoreAlerts}} older alerts not shown</p>{{end}}
+{{else}}<p style="color: #718096;">No alerts were triggered.</p>{{end}}
+
+<h2 style="font-size: 17px; margin-top: 24px;">Experiments</h2>
+{{if .Experiments}}<table style="border-collapse: collapse; width: 100%; font-size: 13px;">
+<tr style="background: #f7fafc;"><th align="left">Experiment</th><th align="left">Status</th><th align="right">Completed</th><th align="right">Failed</th><th align="right">Runs</th></tr>
+{{range .Experiments}}<tr><td>{{.Name}}</td><td>{{.Status}}</td><td align="right">{{.Completed}}</td><td align="right">{{.Failed}}</td><td align="right">{{.Runs}}</td></tr>
+{{end}}</table>
+{{else}}<p style="color: #718096;">No experiments ran.</p>{{end}}
+
+<h2 style="font-size: 17px; margin-top: 24px;">Runs</h2>
+{{if .Runs}}<table style="border-collapse: collapse; width: 100%; font-size: 13px;">
+<tr style="background: #f7fafc;"><th align="left">Simulation</th><th align="left">Status</th><th align="left">Started</th><th align="left">Completed</th><th align="right">Stability</th></tr>
+{{range .Runs}}<tr><td>{{.Simulation.Name}}</td><td>{{.Simulation.Status}}</td><td>{{optionalTime .Simulation.StartedAt}}</td><td>{{optionalTime .Simulation.CompletedAt}}</td><td align="right">{{score .StabilityScore}}</td></tr>
+{{end}}</table>
+{{if .MoreRuns}}<p style="color: #718096;">{{.MoreRuns}} more runs not shown</p>{{end}}
+{{else}}<p style="color: #718096;">No runs started or finished.</p>{{end}}
+
+<p style="color: #718096; font-size: 12px; margin-top: 32px;">You receive this report because scheduled reports are turned on in your notification preferences.</p>
+</body>
+</html>
+`))
+
+// RenderDigestHTML renders a digest as an HTML email body. Styles are inline
+// since mail clients drop style sheets.
+func RenderDigestHTML(digest *Digest) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := digestTemplate.Execute(&buf, digest); err != nil {
+		return nil, fmt.Errorf("failed to render digest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
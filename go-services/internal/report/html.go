@@ -0,0 +1,139 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+)
+
+// SVG chart layout: the plot area sits inside the margins
+const (
+	svgWidth, svgHeight = 760.0, 260.0
+	svgLeft, svgRight   = 64.0, 16.0
+	svgTop, svgBottom   = 16.0, 40.0
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"svg":      chartSVG,
+	"timeline": timelineRow,
+	"formatTime": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Simulation.Name}} - VoltEdge run report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1a202c; margin: 32px auto; max-width: 820px; }
+h1 { font-size: 24px; margin-bottom: 4px; }
+h2 { font-size: 18px; margin-top: 32px; border-bottom: 1px solid #e2e8f0; padding-bottom: 4px; }
+.meta { color: #718096; font-size: 13px; }
+.kpis { display: grid; grid-template-columns: repeat(2, 1fr); gap: 16px; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #edf2f7; }
+th { background: #f7fafc; }
+td.value { text-align: right; font-variant-numeric: tabular-nums; }
+svg { display: block; margin: 8px 0; }
+</style>
+</head>
+<body>
+<h1>{{.Simulation.Name}}</h1>
+<div class="meta">Run report generated {{formatTime .GeneratedAt}}</div>
+{{with .Simulation.Description}}<p>{{.}}</p>{{end}}
+
+<h2>Key figures</h2>
+<div class="kpis">
+{{range .Tables}}<table>
+<tr><th colspan="2">{{.Title}}</th></tr>
+{{range .Rows}}<tr><td>{{index . 0}}</td><td class="value">{{index . 1}}</td></tr>
+{{end}}</table>
+{{end}}</div>
+
+<h2>Charts</h2>
+{{range .Charts}}<h3>{{.Title}} ({{.Unit}})</h3>
+{{svg .}}
+{{end}}
+
+<h2>Fault timeline</h2>
+{{if .Faults}}<table>
+<tr>{{range .TimelineColumns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Faults}}<tr>{{range timeline .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{if .MoreFaults}}<p class="meta">{{.MoreFaults}} later faults not shown</p>{{end}}
+{{else}}<p class="meta">No faults were recorded.</p>{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML renders a report as a standalone HTML page with inline SVG charts
+func RenderHTML(report *Report) ([]byte, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, struct {
+		*Report
+		Tables          []table
+		TimelineColumns []string
+	}{report, report.tables(), timelineColumns})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// chartSVG draws a chart as an SVG line chart with axis labels and a legend
+func chartSVG(chart Chart) template.HTML {
+	plotWidth := svgWidth - svgLeft - svgRight
+	plotHeight := svgHeight - svgTop - svgBottom
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-size="11" font-family="Helvetica, Arial, sans-serif">`,
+		svgWidth, svgHeight, svgWidth, svgHeight)
+	fmt.Fprintf(&b, `<rect x="%g" y="%g" width="%g" height="%g" fill="#fff" stroke="#cbd5e0"/>`, svgLeft, svgTop, plotWidth, plotHeight)
+
+	from, to, low, high, ok := chart.bounds()
+	if !ok {
+		fmt.Fprintf(&b, `<text x="%g" y="%g" text-anchor="middle" fill="#718096">No results</text></svg>`, svgWidth/2, svgTop+plotHeight/2)
+		return template.HTML(b.String())
+	}
+
+	// Horizontal grid lines with value labels
+	for i := 0; i <= 4; i++ {
+		y := svgTop + plotHeight*float64(i)/4
+		value := high - (high-low)*float64(i)/4
+		fmt.Fprintf(&b, `<line x1="%g" y1="%.1f" x2="%g" y2="%.1f" stroke="#edf2f7"/>`, svgLeft, y, svgLeft+plotWidth, y)
+		fmt.Fprintf(&b, `<text x="%g" y="%.1f" text-anchor="end" fill="#4a5568">%s</text>`, svgLeft-6, y+4, axisLabel(value))
+	}
+	fmt.Fprintf(&b, `<text x="%g" y="%g" fill="#4a5568">%s</text>`, svgLeft, svgHeight-22, template.HTMLEscapeString(from.UTC().Format(time.RFC3339)))
+	fmt.Fprintf(&b, `<text x="%g" y="%g" text-anchor="end" fill="#4a5568">%s</text>`, svgLeft+plotWidth, svgHeight-22, template.HTMLEscapeString(to.UTC().Format(time.RFC3339)))
+
+	for i, line := range chart.project(plotWidth, plotHeight) {
+		series := chart.Series[i]
+		points := make([]string, len(line))
+		for j, point := range line {
+			points[j] = fmt.Sprintf("%.1f,%.1f", svgLeft+point[0], svgTop+point[1])
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, series.Color, strings.Join(points, " "))
+
+		x := svgLeft + float64(i)*140
+		fmt.Fprintf(&b, `<rect x="%g" y="%g" width="10" height="10" fill="%s"/>`, x, svgHeight-14, series.Color)
+		fmt.Fprintf(&b, `<text x="%g" y="%g" fill="#1a202c">%s</text>`, x+14, svgHeight-5, template.HTMLEscapeString(series.Name))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// axisLabel formats an axis value with precision suited to its size
+func axisLabel(value float64) string {
+	switch abs := math.Abs(value); {
+	case abs >= 1000:
+		return fmt.Sprintf("%.0f", value)
+	case abs >= 100:
+		return fmt.Sprintf("%.1f", value)
+	default:
+		return fmt.Sprintf("%.2f", value)
+	}
+}
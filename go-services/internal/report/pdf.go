@@ -0,0 +1,293 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A4 page layout in points
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 48.0
+	pdfChartH     = 170.0
+)
+
+// timelineOffsets are the x offsets of the fault timeline columns
+var timelineOffsets = []float64{0, 120, 230, 340, 410}
+
+// RenderPDF renders a report as a PDF document. Text uses the standard
+// Helvetica fonts and charts are drawn as vector paths, so the document needs
+// no embedded resources.
+func RenderPDF(report *Report) ([]byte, error) {
+	doc := newPDFDocument()
+
+	doc.text(pdfMargin, 20, true, report.Simulation.Name)
+	doc.y += 8
+	doc.text(pdfMargin, 9, false, "Run report generated "+report.GeneratedAt.UTC().Format(time.RFC3339))
+	if description := report.Simulation.Description; description != "" {
+		doc.y += 4
+		for _, line := range wrap(description, 100) {
+			doc.text(pdfMargin, 10, false, line)
+		}
+	}
+
+	doc.heading("Key figures")
+	for _, t := range report.tables() {
+		doc.ensure(16 + float64(len(t.Rows))*14)
+		doc.text(pdfMargin, 11, true, t.Title)
+		for _, row := range t.Rows {
+			doc.y += 14
+			doc.textAt(pdfMargin+8, doc.y, 9, false, row[0])
+			doc.textAt(pdfMargin+200, doc.y, 9, false, truncate(row[1], 60))
+		}
+		doc.y += 12
+	}
+
+	doc.heading("Charts")
+	for _, chart := range report.Charts {
+		doc.ensure(pdfChartH + 50)
+		doc.text(pdfMargin, 11, true, fmt.Sprintf("%s (%s)", chart.Title, chart.Unit))
+		doc.y += 8
+		doc.chart(chart)
+	}
+
+	doc.heading("Fault timeline")
+	if len(report.Faults) == 0 {
+		doc.text(pdfMargin, 9, false, "No faults were recorded.")
+	} else {
+		doc.ensure(30)
+		doc.timelineRow(timelineColumns, true)
+		for _, fault := range report.Faults {
+			doc.ensure(14)
+			doc.timelineRow(timelineRow(fault), false)
+		}
+		if report.MoreFaults > 0 {
+			doc.y += 6
+			doc.text(pdfMargin, 9, false, fmt.Sprintf("%d later faults not shown", report.MoreFaults))
+		}
+	}
+
+	return doc.bytes(report.Simulation.Name), nil
+}
+
+// pdfDocument lays out content top to bottom over as many pages as it needs
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	// Distance of the last line from the top of the current page
+	y float64
+}
+
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{}
+	doc.newPage()
+	return doc
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfMargin
+}
+
+func (d *pdfDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// ensure starts a new page unless height more points fit on this one
+func (d *pdfDocument) ensure(height float64) {
+	if d.y+height > pdfPageHeight-pdfMargin {
+		d.newPage()
+	}
+}
+
+func (d *pdfDocument) heading(title string) {
+	d.ensure(60)
+	d.y += 18
+	d.text(pdfMargin, 14, true, title)
+	d.y += 6
+	d.line(pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y, "#cbd5e0")
+	d.y += 8
+}
+
+// text writes a line below the previous one
+func (d *pdfDocument) text(x, size float64, bold bool, s string) {
+	d.y += size + 2
+	d.textAt(x, d.y, size, bold, s)
+}
+
+// textAt writes text with its baseline at top-based y
+func (d *pdfDocument) textAt(x, y, size float64, bold bool, s string) {
+	d.textColored(x, y, size, bold, s, "#1a202c")
+}
+
+func (d *pdfDocument) textColored(x, y, size float64, bold bool, s, color string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "%s rg BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", pdfColor(color), font, size, x, pdfPageHeight-y, pdfString(s))
+}
+
+func (d *pdfDocument) line(x1, y1, x2, y2 float64, color string) {
+	fmt.Fprintf(d.page(), "%s RG 0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfColor(color), x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// chart draws a line chart below the current line
+func (d *pdfDocument) chart(chart Chart) {
+	left, top := pdfMargin+48, d.y
+	width, height := pdfPageWidth-pdfMargin-left, pdfChartH
+	page := d.page()
+
+	fmt.Fprintf(page, "%s RG 0.5 w %.2f %.2f %.2f %.2f re S\n", pdfColor("#cbd5e0"), left, pdfPageHeight-top-height, width, height)
+
+	from, to, low, high, ok := chart.bounds()
+	if !ok {
+		d.textColored(left+width/2-24, top+height/2, 9, false, "No results", "#718096")
+		d.y += height + 16
+		return
+	}
+
+	for i := 0; i <= 4; i++ {
+		y := top + height*float64(i)/4
+		value := high - (high-low)*float64(i)/4
+		d.line(left, y, left+width, y, "#edf2f7")
+		d.textColored(pdfMargin, y+3, 7, false, axisLabel(value), "#4a5568")
+	}
+	d.textColored(left, top+height+10, 7, false, from.UTC().Format(time.RFC3339), "#4a5568")
+	d.textColored(left+width-72, top+height+10, 7, false, to.UTC().Format(time.RFC3339), "#4a5568")
+
+	for i, line := range chart.project(width, height) {
+		series := chart.Series[i]
+		if len(line) > 0 {
+			var path strings.Builder
+			for j, point := range line {
+				op := "l"
+				if j == 0 {
+					op = "m"
+				}
+				fmt.Fprintf(&path, "%.2f %.2f %s ", left+point[0], pdfPageHeight-top-point[1], op)
+			}
+			fmt.Fprintf(page, "%s RG 1 w %sS\n", pdfColor(series.Color), path.String())
+		}
+
+		x := left + float64(i)*120
+		legendY := top + height + 24
+		fmt.Fprintf(page, "%s rg %.2f %.2f 8 8 re f\n", pdfColor(series.Color), x, pdfPageHeight-legendY)
+		d.textAt(x+12, legendY, 8, false, series.Name)
+	}
+
+	d.y += height + 36
+}
+
+func (d *pdfDocument) timelineRow(cells []string, header bool) {
+	d.y += 12
+	for i, cell := range cells {
+		d.textAt(pdfMargin+timelineOffsets[i], d.y, 8, header, truncate(cell, 24))
+	}
+	if header {
+		d.line(pdfMargin, d.y+4, pdfPageWidth-pdfMargin, d.y+4, "#cbd5e0")
+		d.y += 4
+	}
+}
+
+// bytes assembles the pages into a PDF file, numbering them in the footer
+func (d *pdfDocument) bytes(title string) []byte {
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(page, "%s rg BT /F1 8 Tf %.2f %.2f Td (%s) Tj ET\n", pdfColor("#718096"), pdfPageWidth-pdfMargin-56, pdfMargin/2, footer)
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page is then a
+	// page object followed by its content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = strconv.Itoa(5+2*i) + " 0 R"
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+	object(fmt.Sprintf("<< /Title (%s) /Producer (VoltEdge) /CreationDate (D:%s) >>", pdfString(title), time.Now().UTC().Format("20060102150405Z")))
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, len(offsets), xref)
+	return out.Bytes()
+}
+
+// pdfString escapes text for a PDF literal string in WinAnsi encoding,
+// replacing characters outside Latin-1
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfColor converts a #rrggbb color to PDF RGB components
+func pdfColor(hex string) string {
+	value, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return "0 0 0"
+	}
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(value>>16&0xff)/255, float64(value>>8&0xff)/255, float64(value&0xff)/255)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// wrap breaks text into lines of at most width characters at spaces
+func wrap(s string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,276 @@
+// Package report renders post-run simulation reports, with charts, KPI
+// tables and a fault timeline, as HTML or PDF
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/analytics"
+	"voltedge/go-services/internal/database"
+)
+
+// Report formats
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+const (
+	// Results are averaged down to at most this many points per chart
+	maxChartPoints = 400
+	// Faults listed in the timeline, newest dropped first
+	maxTimelineFaults = 200
+)
+
+// ErrSimulationNotFound is returned for reports on simulations that do not exist
+var ErrSimulationNotFound = errors.New("simulation not found")
+
+// ResultSource reads a simulation's results, oldest first
+type ResultSource interface {
+	GetSimulationResultsInRange(ctx context.Context, simulationID uuid.UUID, from, to *time.Time) ([]database.SimulationResult, error)
+}
+
+// Report is everything a rendered report shows
+type Report struct {
+	Simulation  *database.Simulation
+	GeneratedAt time.Time
+	Statistics  *database.SimulationStatistics
+	Stability   *database.StabilityScore
+	Energy      *analytics.EnergyReport
+	Reliability *analytics.ReliabilityReport
+	Charts      []Chart
+	// Fault timeline, oldest first
+	Faults []database.FaultEvent
+	// Faults left out of the timeline
+	MoreFaults int
+}
+
+// Chart is a time series chart of one or more result fields
+type Chart struct {
+	Title  string
+	Unit   string
+	Series []Series
+}
+
+// Series is one line of a chart
+type Series struct {
+	Name   string
+	Color  string
+	Points []Point
+}
+
+// Point is a value at a time
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Generator collects and renders reports
+type Generator struct {
+	simulations *database.SimulationService
+	results     ResultSource
+}
+
+// NewGenerator creates a report generator reading results from results,
+// which may serve archived results too
+func NewGenerator(simulations *database.SimulationService, results ResultSource) *Generator {
+	return &Generator{
+		simulations: simulations,
+		results:     results,
+	}
+}
+
+// ContentType returns the content type of a report format
+func ContentType(format string) string {
+	if format == FormatPDF {
+		return "application/pdf"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// Render collects a simulation's report and renders it in a format
+func (g *Generator) Render(ctx context.Context, simulationID uuid.UUID, format string) ([]byte, error) {
+	if format != FormatHTML && format != FormatPDF {
+		return nil, fmt.Errorf("unknown report format %q", format)
+	}
+
+	report, err := g.Collect(ctx, simulationID)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatPDF {
+		return RenderPDF(report)
+	}
+	return RenderHTML(report)
+}
+
+// Collect gathers the contents of a simulation's report
+func (g *Generator) Collect(ctx context.Context, simulationID uuid.UUID) (*Report, error) {
+	simulation, err := g.simulations.GetSimulation(ctx, simulationID)
+	if err != nil {
+		return nil, err
+	}
+	if simulation == nil {
+		return nil, ErrSimulationNotFound
+	}
+
+	statistics, err := g.simulations.GetSimulationStatistics(ctx, simulationID)
+	if err != nil {
+		return nil, err
+	}
+	results, err := g.results.GetSimulationResultsInRange(ctx, simulationID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Simulation:  simulation,
+		GeneratedAt: time.Now().UTC(),
+		Statistics:  statistics,
+		Charts:      resultCharts(results),
+		Faults:      []database.FaultEvent{},
+	}
+
+	var faults []database.FaultEvent
+	if len(results) > 0 {
+		from, to := results[0].Timestamp, results[len(results)-1].Timestamp
+		faults, err = g.simulations.FaultEventsOverlapping(ctx, simulationID, &from, &to)
+		if err != nil {
+			return nil, err
+		}
+		report.Energy = analytics.ComputeEnergyBalance(results, simulation.PowerPlants, faults, 0)
+		report.Reliability = analytics.ComputeReliability(faults, from, to)
+	}
+
+	report.Stability, err = g.simulations.GetStabilityScore(ctx, simulationID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Stability == nil && len(results) > 0 {
+		kpis := analytics.NewFrequencyStability(simulationID, analytics.NominalFrequency(simulation), analytics.DefaultStabilityBandHz)
+		for i := range results {
+			kpis.Add(&results[i])
+		}
+		report.Stability = kpis.Score()
+	}
+
+	if len(faults) > maxTimelineFaults {
+		report.MoreFaults = len(faults) - maxTimelineFaults
+		faults = faults[:maxTimelineFaults]
+	}
+	if faults != nil {
+		report.Faults = faults
+	}
+
+	return report, nil
+}
+
+// resultCharts builds the report's charts from results ordered by time
+func resultCharts(results []database.SimulationResult) []Chart {
+	field := func(value func(*database.SimulationResult) float64) []Point {
+		return downsample(results, value)
+	}
+	return []Chart{
+		{
+			Title: "Generation and consumption",
+			Unit:  "MW",
+			Series: []Series{
+				{Name: "Generation", Color: "#2b6cb0", Points: field(func(r *database.SimulationResult) float64 { return r.TotalGenerationMW })},
+				{Name: "Consumption", Color: "#dd6b20", Points: field(func(r *database.SimulationResult) float64 { return r.TotalConsumptionMW })},
+			},
+		},
+		{
+			Title:  "Grid frequency",
+			Unit:   "Hz",
+			Series: []Series{{Name: "Frequency", Color: "#2f855a", Points: field(func(r *database.SimulationResult) float64 { return r.GridFrequencyHz })}},
+		},
+		{
+			Title:  "Grid voltage",
+			Unit:   "kV",
+			Series: []Series{{Name: "Voltage", Color: "#6b46c1", Points: field(func(r *database.SimulationResult) float64 { return r.GridVoltageKV })}},
+		},
+	}
+}
+
+// downsample averages results into at most maxChartPoints points
+func downsample(results []database.SimulationResult, value func(*database.SimulationResult) float64) []Point {
+	if len(results) <= maxChartPoints {
+		points := make([]Point, len(results))
+		for i := range results {
+			points[i] = Point{Time: results[i].Timestamp, Value: value(&results[i])}
+		}
+		return points
+	}
+
+	points := make([]Point, 0, maxChartPoints)
+	size := float64(len(results)) / maxChartPoints
+	for i := 0; i < maxChartPoints; i++ {
+		start, end := int(float64(i)*size), int(float64(i+1)*size)
+		var sum float64
+		for j := start; j < end; j++ {
+			sum += value(&results[j])
+		}
+		middle := results[(start+end-1)/2].Timestamp
+		points = append(points, Point{Time: middle, Value: sum / float64(end-start)})
+	}
+	return points
+}
+
+// bounds returns the time and value ranges of a chart, padding flat values
+// so they can be drawn
+func (c Chart) bounds() (from, to time.Time, low, high float64, ok bool) {
+	for _, series := range c.Series {
+		for _, point := range series.Points {
+			if !ok {
+				from, to, low, high, ok = point.Time, point.Time, point.Value, point.Value, true
+				continue
+			}
+			if point.Time.Before(from) {
+				from = point.Time
+			}
+			if point.Time.After(to) {
+				to = point.Time
+			}
+			if point.Value < low {
+				low = point.Value
+			}
+			if point.Value > high {
+				high = point.Value
+			}
+		}
+	}
+	if high == low {
+		low, high = low-1, high+1
+	}
+	return from, to, low, high, ok
+}
+
+// project maps a chart's points into a width x height box with y growing
+// downwards
+func (c Chart) project(width, height float64) [][][2]float64 {
+	from, to, low, high, ok := c.bounds()
+	lines := make([][][2]float64, len(c.Series))
+	if !ok {
+		return lines
+	}
+	span := to.Sub(from).Seconds()
+
+	for i, series := range c.Series {
+		line := make([][2]float64, len(series.Points))
+		for j, point := range series.Points {
+			x := width / 2
+			if span > 0 {
+				x = point.Time.Sub(from).Seconds() / span * width
+			}
+			y := height - (point.Value-low)/(high-low)*height
+			line[j] = [2]float64{x, y}
+		}
+		lines[i] = line
+	}
+	return lines
+}
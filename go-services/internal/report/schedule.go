@@ -0,0 +1,257 @@
+package report
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// Mailer sends emails
+type Mailer interface {
+	SendMail(recipients []string, subject, contentType string, body []byte) error
+}
+
+// ExperimentSource lists parameter sweep experiments
+type ExperimentSource interface {
+	ListExperiments() []*orchestration.Experiment
+}
+
+// Scheduler emails users who opted in a digest of their organization's runs,
+// experiments and alerts after every day or week
+type Scheduler struct {
+	generator   *Generator
+	preferences *database.NotificationPreferenceService
+	mailer      Mailer
+	experiments ExperimentSource
+}
+
+// NewScheduler creates a scheduler for reports collected by generator. Digests
+// list no experiments when experiments is nil.
+func NewScheduler(generator *Generator, preferences *database.NotificationPreferenceService, mailer Mailer, experiments ExperimentSource) *Scheduler {
+	return &Scheduler{
+		generator:   generator,
+		preferences: preferences,
+		mailer:      mailer,
+		experiments: experiments,
+	}
+}
+
+// Run sends scheduled reports as their periods end, checking every interval
+// until ctx is done
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if sent := s.SendDue(ctx, time.Now()); sent > 0 {
+			logrus.WithField("reports", sent).Info("Sent scheduled reports")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends every scheduled report whose period ended by now, returning
+// how many were sent. Each period is claimed before sending so only one
+// instance sends it; periods without activity are claimed but not sent.
+func (s *Scheduler) SendDue(ctx context.Context, now time.Time) int {
+	recipients, err := s.preferences.GetScheduledReportRecipients()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load scheduled report recipients")
+		return 0
+	}
+
+	sent := 0
+	for i := range recipients {
+		if ctx.Err() != nil {
+			break
+		}
+		recipient := &recipients[i]
+		logger := logrus.WithField("user_id", recipient.UserID)
+
+		from, to := reportPeriod(recipient.ReportSchedule, reportLocation(recipient.Timezone), now)
+		if recipient.LastReportAt != nil && !recipient.LastReportAt.Before(to) {
+			continue
+		}
+
+		scope := database.ActivityScope{OrganizationID: recipient.OrganizationID, UserID: recipient.UserID}
+		digest, err := s.CollectDigest(ctx, scope, from, to)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to collect scheduled report")
+			continue
+		}
+		digest.Schedule = recipient.ReportSchedule
+
+		claimed, err := s.preferences.ClaimScheduledReport(recipient.UserID, to)
+		if err != nil || !claimed || digest.Empty() {
+			continue
+		}
+
+		if err := s.send(recipient.Email, digest); err != nil {
+			logger.WithError(err).Warn("Failed to send scheduled report")
+			if err := s.preferences.ReleaseScheduledReport(recipient.UserID, to, recipient.LastReportAt); err != nil {
+				logger.WithError(err).Warn("Failed to release scheduled report")
+			}
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+func (s *Scheduler) send(email string, digest *Digest) error {
+	body, err := RenderDigestHTML(digest)
+	if err != nil {
+		return err
+	}
+	return s.mailer.SendMail([]string{email}, digest.Subject(), "text/html; charset=UTF-8", body)
+}
+
+// CollectDigest gathers the runs, experiments and alerts of a scope in
+// [from, to)
+func (s *Scheduler) CollectDigest(ctx context.Context, scope database.ActivityScope, from, to time.Time) (*Digest, error) {
+	simulations := s.generator.simulations
+	digest := &Digest{
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	runs, total, err := simulations.ListRunsInPeriod(ctx, scope, from, to, maxDigestRuns)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(runs))
+	for i := range runs {
+		ids[i] = runs[i].ID
+	}
+	scores := make(map[uuid.UUID]float64)
+	if len(ids) > 0 {
+		ranks, err := simulations.RankStability(ctx, ids, len(ids), 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, rank := range ranks {
+			scores[rank.SimulationID] = rank.Score
+		}
+	}
+	for _, run := range runs {
+		entry := DigestRun{Simulation: run}
+		if score, ok := scores[run.ID]; ok {
+			entry.StabilityScore = &score
+		}
+		digest.Runs = append(digest.Runs, entry)
+	}
+	digest.MoreRuns = total - int64(len(runs))
+
+	digest.Alerts, err = s.collectAlerts(ctx, digest, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Experiments, err = s.collectExperiments(ctx, scope, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// collectAlerts fills in a digest's alert counts and returns the alerts it lists
+func (s *Scheduler) collectAlerts(ctx context.Context, digest *Digest, scope database.ActivityScope) ([]database.Alert, error) {
+	alerts, counts, err := s.generator.simulations.ListAlertsInPeriod(ctx, scope, digest.From, digest.To, maxDigestAlerts)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for severity, count := range counts {
+		digest.AlertCounts = append(digest.AlertCounts, SeverityCount{Severity: severity, Count: count})
+		total += count
+	}
+	// Known severities first, most severe first, then the rest by name
+	rank := func(severity string) int {
+		if i := slices.Index(digestSeverities, severity); i >= 0 {
+			return i
+		}
+		return len(digestSeverities)
+	}
+	sort.Slice(digest.AlertCounts, func(i, j int) bool {
+		a, b := digest.AlertCounts[i].Severity, digest.AlertCounts[j].Severity
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		return a < b
+	})
+	digest.MoreAlerts = total - int64(len(alerts))
+	return alerts, nil
+}
+
+// collectExperiments returns the experiments active in [from, to) with runs
+// in scope
+func (s *Scheduler) collectExperiments(ctx context.Context, scope database.ActivityScope, from, to time.Time) ([]DigestExperiment, error) {
+	if s.experiments == nil {
+		return nil, nil
+	}
+
+	var experiments []DigestExperiment
+	for _, experiment := range s.experiments.ListExperiments() {
+		if !experiment.CreatedAt.Before(to) || (experiment.CompletedAt != nil && experiment.CompletedAt.Before(from)) {
+			continue
+		}
+
+		var ids []uuid.UUID
+		for _, run := range experiment.Runs {
+			if id, err := uuid.Parse(run.SimulationID); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		inScope, err := s.generator.simulations.SimulationsInScope(ctx, scope, ids)
+		if err != nil {
+			return nil, err
+		}
+		if len(inScope) == 0 {
+			continue
+		}
+
+		experiments = append(experiments, DigestExperiment{
+			ID:        experiment.ID,
+			Name:      experiment.Name,
+			Status:    experiment.Status,
+			Runs:      len(experiment.Runs),
+			Completed: experiment.Completed,
+			Failed:    experiment.Failed,
+		})
+	}
+	return experiments, nil
+}
+
+// reportPeriod returns the last whole day, or week starting on Monday, in loc
+// that ended by now
+func reportPeriod(schedule string, loc *time.Location, now time.Time) (from, to time.Time) {
+	local := now.In(loc)
+	to = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if schedule == database.ReportScheduleWeekly {
+		to = to.AddDate(0, 0, -(int(to.Weekday())+6)%7)
+		return to.AddDate(0, 0, -7), to
+	}
+	return to.AddDate(0, 0, -1), to
+}
+
+// reportLocation returns a preference's timezone, falling back to UTC
+func reportLocation(timezone string) *time.Location {
+	if loc, err := time.LoadLocation(timezone); err == nil && timezone != "" {
+		return loc
+	}
+	return time.UTC
+}
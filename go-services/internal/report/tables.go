@@ -0,0 +1,128 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"voltedge/go-services/internal/database"
+)
+
+// table is a titled list of label and value rows
+type table struct {
+	Title string
+	Rows  [][2]string
+}
+
+// timelineColumns head the fault timeline
+var timelineColumns = []string{"Time", "Component", "Type", "Severity", "Resolved"}
+
+// tables returns the report's KPI tables, skipping those without data
+func (r *Report) tables() []table {
+	sim := r.Simulation
+	tables := []table{{
+		Title: "Run",
+		Rows: [][2]string{
+			{"Simulation", sim.Name},
+			{"ID", sim.ID.String()},
+			{"Status", sim.Status},
+			{"Engine", orDash(sim.Engine)},
+			{"Started", formatTime(sim.StartedAt)},
+			{"Completed", formatTime(sim.CompletedAt)},
+		},
+	}}
+
+	if stats := r.Statistics; stats != nil {
+		tables = append(tables, table{
+			Title: "Results",
+			Rows: [][2]string{
+				{"Results", strconv.FormatInt(stats.TotalResults, 10)},
+				{"Faults", strconv.FormatInt(stats.FaultCount, 10)},
+				{"Active alerts", strconv.FormatInt(stats.ActiveAlerts, 10)},
+				{"Average generation", formatMetric(stats.Generation.Avg, "MW")},
+				{"Peak generation", formatMetric(stats.Generation.Max, "MW")},
+				{"Average consumption", formatMetric(stats.Consumption.Avg, "MW")},
+				{"Peak consumption", formatMetric(stats.Consumption.Max, "MW")},
+				{"Average efficiency", formatMetric(stats.Efficiency.Avg, "%")},
+			},
+		})
+	}
+
+	if energy := r.Energy; energy != nil {
+		tables = append(tables, table{
+			Title: "Energy",
+			Rows: [][2]string{
+				{"Generated", formatFloat(energy.GeneratedMWh, "MWh")},
+				{"Consumed", formatFloat(energy.ConsumedMWh, "MWh")},
+				{"Losses", fmt.Sprintf("%s (%.2f%%)", formatFloat(energy.LossesMWh, "MWh"), energy.LossesPercent)},
+				{"Unserved during faults", formatFloat(energy.UnservedDuringFaultsMWh, "MWh")},
+				{"Renewables share", fmt.Sprintf("%.1f%%", energy.RenewableSharePercent)},
+			},
+		})
+	}
+
+	if stability := r.Stability; stability != nil {
+		tables = append(tables, table{
+			Title: "Frequency stability",
+			Rows: [][2]string{
+				{"Score", fmt.Sprintf("%.1f / 100", stability.Score)},
+				{"Nominal", formatFloat(stability.NominalFrequencyHz, "Hz")},
+				{"Time outside band", fmt.Sprintf("%.1f s (%.2f%%, band ±%g Hz)", stability.SecondsOutsideBand, stability.PercentOutsideBand, stability.BandHz)},
+				{"Nadir", formatMetric(stability.NadirHz, "Hz")},
+				{"Largest deviation", formatFloat(stability.MaxDeviationHz, "Hz")},
+				{"Largest RoCoF", formatFloat(stability.MaxRoCoFHzPerS, "Hz/s")},
+			},
+		})
+	}
+
+	if reliability := r.Reliability; reliability != nil {
+		tables = append(tables, table{
+			Title: "Reliability",
+			Rows: [][2]string{
+				{"Failures", strconv.Itoa(reliability.Failures)},
+				{"Components affected", strconv.Itoa(len(reliability.Components))},
+				{"MTBF", formatMetric(reliability.MTBFHours, "h")},
+				{"MTTR", formatMetric(reliability.MTTRHours, "h")},
+				{"Average availability", fmt.Sprintf("%.2f%%", reliability.AverageAvailabilityPercent)},
+			},
+		})
+	}
+
+	return tables
+}
+
+// timelineRow returns a fault's timeline cells
+func timelineRow(fault database.FaultEvent) []string {
+	return []string{
+		fault.Timestamp.UTC().Format(time.RFC3339),
+		fmt.Sprintf("%s %d", fault.ComponentType, fault.ComponentID),
+		fault.FaultType,
+		fault.Severity,
+		formatTime(fault.ResolvedAt),
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatFloat(value float64, unit string) string {
+	return strconv.FormatFloat(value, 'f', 2, 64) + " " + unit
+}
+
+func formatMetric(value *float64, unit string) string {
+	if value == nil {
+		return "-"
+	}
+	return formatFloat(*value, unit)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
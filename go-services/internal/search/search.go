@@ -0,0 +1,210 @@
+// Package search matches free-text queries against simulations, experiments,
+// batches and tags, and highlights the matching words for display
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Hit types
+const (
+	TypeSimulation = "simulation"
+	TypeExperiment = "experiment"
+	TypeBatch      = "batch"
+	TypeTag        = "tag"
+)
+
+// Types lists every hit type
+var Types = []string{TypeSimulation, TypeExperiment, TypeBatch, TypeTag}
+
+const (
+	// maxTerms caps the words of a query that are matched
+	maxTerms = 8
+	// fragmentContext is how many characters are kept either side of the
+	// first match in a highlight
+	fragmentContext = 60
+	// HighlightStart and HighlightEnd wrap matched words in highlights. The
+	// rest of a highlight is HTML-escaped.
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// Field is a piece of text a query is matched against. Matches in fields
+// with a higher weight rank a hit higher.
+type Field struct {
+	Name   string
+	Text   string
+	Weight float64
+}
+
+// Hit is a resource matching a query
+type Hit struct {
+	Type  string  `json:"type"`
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+	// Fragments of the matching fields by field name
+	Highlights map[string]string `json:"highlights"`
+}
+
+// Terms splits a query into lowercase words. Words are runs of letters and
+// digits, the same way the database's simple text search configuration
+// splits text, so both sides agree on what matches.
+func Terms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range words(strings.ToLower(query)) {
+		term := word.text
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// TSQuery builds a text search query matching documents that contain every
+// term as a word prefix, e.g. "north:* & plant:*"
+func TSQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Match scores a resource against the terms. Every term must start a word
+// in at least one field. The score sums the weights of the fields matching
+// each term, so hits matching in names outrank those matching in metadata.
+func Match(terms []string, fields []Field) (float64, map[string]string, bool) {
+	if len(terms) == 0 {
+		return 0, nil, false
+	}
+
+	matched := make(map[string]bool, len(terms))
+	highlights := make(map[string]string)
+	score := 0.0
+	for _, field := range fields {
+		fragment, found := highlight(field.Text, terms)
+		if len(found) == 0 {
+			continue
+		}
+		highlights[field.Name] = fragment
+		for _, term := range found {
+			matched[term] = true
+		}
+		score += field.Weight * float64(len(found))
+	}
+
+	if len(matched) < len(terms) {
+		return 0, nil, false
+	}
+	return score, highlights, true
+}
+
+// Sort orders hits by descending score, then by title and ID
+func Sort(hits []Hit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Title != hits[j].Title {
+			return hits[i].Title < hits[j].Title
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+// word is a word of a text with its byte offsets
+type word struct {
+	text       string
+	start, end int
+}
+
+func words(text string) []word {
+	var result []word
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			result = append(result, word{text: text[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		result = append(result, word{text: text[start:], start: start, end: len(text)})
+	}
+	return result
+}
+
+// highlight marks the words of text starting with one of the terms and cuts
+// a fragment around the first of them. It returns the terms found.
+func highlight(text string, terms []string) (string, []string) {
+	var marked []word
+	found := make(map[string]bool)
+	var foundTerms []string
+	for _, w := range words(text) {
+		lower := strings.ToLower(w.text)
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) {
+				marked = append(marked, w)
+				if !found[term] {
+					found[term] = true
+					foundTerms = append(foundTerms, term)
+				}
+				break
+			}
+		}
+	}
+	if len(marked) == 0 {
+		return "", nil
+	}
+
+	from := clampToRune(text, marked[0].start-fragmentContext)
+	to := clampToRune(text, marked[0].end+fragmentContext)
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, w := range marked {
+		if w.start < pos || w.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:w.start]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(text[w.start:w.end]))
+		b.WriteString(HighlightEnd)
+		pos = w.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), foundTerms
+}
+
+// clampToRune limits an offset to text and moves it back to a rune boundary
+func clampToRune(text string, offset int) int {
+	if offset <= 0 {
+		return 0
+	}
+	if offset >= len(text) {
+		return len(text)
+	}
+	for offset > 0 && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	return offset
+}
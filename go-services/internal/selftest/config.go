@@ -0,0 +1,61 @@
+package selftest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/orchestration"
+)
+
+// selfTestSeed makes the self-test's runs reproducible
+const selfTestSeed = 1
+
+// simulationConfig returns the self-test's grid: one plant feeding one load
+// over one line, run for a single tick
+func simulationConfig() orchestration.SimulationConfig {
+	return orchestration.SimulationConfig{
+		PowerPlants: []orchestration.PowerPlantConfig{{
+			ID:              "self-test-plant",
+			Name:            "Self-test plant",
+			Type:            "gas",
+			MaxCapacityMW:   100,
+			CurrentOutputMW: 50,
+			Efficiency:      0.5,
+			Location:        orchestration.Location{X: 0, Y: 0, Name: "Self-test plant"},
+			IsOperational:   true,
+		}},
+		TransmissionLines: []orchestration.TransmissionLineConfig{{
+			ID:              "self-test-line",
+			FromNode:        "self-test-plant",
+			ToNode:          "self-test-load",
+			CapacityMW:      100,
+			LengthKM:        10,
+			ResistancePerKM: 0.05,
+			ReactancePerKM:  0.3,
+			IsOperational:   true,
+		}},
+		BaseFrequency: 50,
+		BaseVoltage:   230,
+		LoadProfile: orchestration.LoadProfile{
+			BaseLoadMW:     50,
+			PeakMultiplier: 1,
+		},
+		RandomSeed:  selfTestSeed,
+		TargetTicks: 1,
+	}
+}
+
+// engineSpec is what the stream check creates the grid from on the engine
+func engineSpec(config orchestration.SimulationConfig) (engine.Spec, error) {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return engine.Spec{}, fmt.Errorf("failed to encode simulation config: %w", err)
+	}
+	return engine.Spec{
+		Name:   "VoltEdge self-test stream",
+		Config: raw,
+		Speed:  1,
+		Seed:   config.RandomSeed,
+	}, nil
+}
@@ -0,0 +1,337 @@
+// Package selftest runs a tiny built-in simulation end to end when the
+// gateway starts, so a miswired deployment fails its readiness check
+// instead of the first user's simulation.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/observability"
+	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/internal/realtime"
+)
+
+// Checks the self-test makes, in order
+const (
+	// The simulation is stored in the database
+	CheckDatabase = "database"
+	// The simulation runs to completion on its engine
+	CheckEngine = "engine"
+	// A state streamed by the engine reaches a realtime subscriber
+	CheckStream = "stream"
+)
+
+// Tag marks the self-test's simulation
+const Tag = "self-test"
+
+// Store is the database side of the self-test
+type Store interface {
+	SimulationExists(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// Runtime is the orchestrator side of the self-test
+type Runtime interface {
+	CreateSimulation(ctx context.Context, name, description string, config orchestration.SimulationConfig, tags []string, metadata map[string]interface{}) (*orchestration.Simulation, error)
+	StartSimulation(ctx context.Context, id string) error
+	DeleteSimulation(ctx context.Context, id string) error
+	OnTransition(hook orchestration.TransitionHook)
+}
+
+// Options configures the self-test
+type Options struct {
+	// Limit on the whole test
+	Timeout time.Duration
+	// Engines the simulation's state is streamed from
+	Engines *engine.Registry
+	// Hub the streamed state is published through
+	Hub *realtime.Hub
+}
+
+// Check is the outcome of one check
+type Check struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report summarizes a self-test
+type Report struct {
+	Passed       bool      `json:"passed"`
+	SimulationID string    `json:"simulation_id,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Checks       []Check   `json:"checks"`
+}
+
+// SelfTest creates, runs and verifies a simulation through the same
+// orchestrator, database, engine and realtime hub user simulations use
+type SelfTest struct {
+	store   Store
+	runtime Runtime
+	opts    Options
+
+	mu   sync.RWMutex
+	last *Report
+	// Receives the transitions of the simulation under test
+	simulationID string
+	transitions  chan orchestration.Transition
+}
+
+// New creates a self-test. It watches the runtime's transitions, so it must
+// be created before the runtime starts running simulations.
+func New(store Store, runtime Runtime, opts Options) *SelfTest {
+	t := &SelfTest{
+		store:   store,
+		runtime: runtime,
+		opts:    opts,
+	}
+	runtime.OnTransition(t.recordTransition)
+	return t
+}
+
+// LastReport returns the report of the last self-test, or nil while none
+// has finished
+func (t *SelfTest) LastReport() *Report {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.last
+}
+
+// Run runs the self-test and returns its report. The simulation is deleted
+// afterwards, whether it passed or not.
+func (t *SelfTest) Run(ctx context.Context) *Report {
+	ctx, cancel := context.WithTimeout(ctx, t.opts.Timeout)
+	defer cancel()
+
+	report := &Report{StartedAt: time.Now().UTC()}
+	logrus.Info("Running startup self-test")
+
+	config := simulationConfig()
+	transitions := make(chan orchestration.Transition, 8)
+
+	var simulation *orchestration.Simulation
+	stored := report.check(CheckDatabase, func() error {
+		var err error
+		simulation, err = t.runtime.CreateSimulation(ctx, "VoltEdge self-test", "Created by the startup self-test", config, []string{Tag}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create simulation: %w", err)
+		}
+		report.SimulationID = simulation.ID
+
+		id, err := uuid.Parse(simulation.ID)
+		if err != nil {
+			return fmt.Errorf("simulation ID %s is not stored in the database", simulation.ID)
+		}
+		exists, err := t.store.SimulationExists(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to read simulation back: %w", err)
+		}
+		if !exists {
+			return errors.New("simulation was not stored")
+		}
+		return nil
+	})
+
+	if simulation != nil {
+		t.watch(simulation.ID, transitions)
+		defer t.watch("", nil)
+		defer t.cleanup(simulation.ID)
+	}
+
+	if stored {
+		report.check(CheckEngine, func() error {
+			if err := t.runtime.StartSimulation(ctx, simulation.ID); err != nil {
+				return fmt.Errorf("failed to start simulation: %w", err)
+			}
+			return awaitCompletion(ctx, transitions)
+		})
+	} else {
+		report.skip(CheckEngine)
+	}
+
+	report.check(CheckStream, func() error {
+		return t.stream(ctx, config)
+	})
+
+	report.FinishedAt = time.Now().UTC()
+	report.Passed = true
+	for _, check := range report.Checks {
+		report.Passed = report.Passed && check.Passed
+		observability.RecordSelfTestCheck(check.Name, check.Passed)
+	}
+	observability.RecordSelfTest(report.Passed, report.FinishedAt.Sub(report.StartedAt))
+
+	t.mu.Lock()
+	t.last = report
+	t.mu.Unlock()
+
+	entry := logrus.WithFields(logrus.Fields{
+		"simulation_id": report.SimulationID,
+		"duration":      report.FinishedAt.Sub(report.StartedAt),
+	})
+	if report.Passed {
+		entry.Info("Startup self-test passed")
+	} else {
+		entry.WithField("checks", report.Checks).Error("Startup self-test failed")
+	}
+	return report
+}
+
+// Err returns an error naming the failed checks, or nil when the test passed
+func (r *Report) Err() error {
+	if r.Passed {
+		return nil
+	}
+	var errs []error
+	for _, check := range r.Checks {
+		if !check.Passed {
+			errs = append(errs, fmt.Errorf("%s: %s", check.Name, check.Error))
+		}
+	}
+	return fmt.Errorf("self-test failed: %w", errors.Join(errs...))
+}
+
+// check runs a check and records its outcome
+func (r *Report) check(name string, run func() error) bool {
+	start := time.Now()
+	err := run()
+
+	check := Check{Name: name, Passed: err == nil, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	r.Checks = append(r.Checks, check)
+	return check.Passed
+}
+
+// skip records a check that could not run because an earlier one failed
+func (r *Report) skip(name string) {
+	r.Checks = append(r.Checks, Check{Name: name, Error: "skipped after an earlier check failed"})
+}
+
+// awaitCompletion waits for the simulation under test to finish its run
+func awaitCompletion(ctx context.Context, transitions <-chan orchestration.Transition) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("simulation did not complete: %w", ctx.Err())
+		case transition := <-transitions:
+			switch transition.To {
+			case orchestration.StatusCompleted:
+				return nil
+			case orchestration.StatusError:
+				return fmt.Errorf("simulation failed: %w", transition.Err)
+			}
+		}
+	}
+}
+
+// stream runs the config on its engine directly and publishes the first
+// state the engine streams to a topic the test subscribes to
+func (t *SelfTest) stream(ctx context.Context, config orchestration.SimulationConfig) error {
+	if t.opts.Engines == nil || t.opts.Hub == nil {
+		return errors.New("no engine registry or realtime hub to stream through")
+	}
+	name, err := t.opts.Engines.Place(config.Requirements(), "")
+	if err != nil {
+		return err
+	}
+	impl, ok := t.opts.Engines.Engine(name)
+	if !ok {
+		return fmt.Errorf("engine %s has no implementation to stream from", name)
+	}
+
+	spec, err := engineSpec(config)
+	if err != nil {
+		return err
+	}
+	runID, err := impl.Create(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("failed to create simulation on engine %s: %w", name, err)
+	}
+	if err := impl.Start(ctx, runID); err != nil {
+		return fmt.Errorf("failed to start simulation on engine %s: %w", name, err)
+	}
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := impl.Stop(stopCtx, runID); err != nil {
+			logrus.WithError(err).WithField("engine", name).Warn("Failed to stop self-test simulation on engine")
+		}
+	}()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	states, err := impl.Stream(streamCtx, runID)
+	if err != nil {
+		return fmt.Errorf("failed to stream from engine %s: %w", name, err)
+	}
+
+	subscriber := t.opts.Hub.Subscribe(1)
+	defer t.opts.Hub.Unsubscribe(subscriber)
+	topic := realtime.ResultsTopic(Tag + "-" + runID)
+	subscriber.Add(topic)
+
+	select {
+	case state, ok := <-states:
+		if !ok {
+			return fmt.Errorf("engine %s closed the stream without a state", name)
+		}
+		t.opts.Hub.Publish(realtime.Message{Type: realtime.MessageResultsSample, Topic: topic, Data: state})
+	case <-ctx.Done():
+		return fmt.Errorf("engine %s streamed no state: %w", name, ctx.Err())
+	}
+
+	select {
+	case <-subscriber.Ready():
+		if len(subscriber.Drain()) == 0 {
+			return errors.New("subscriber received no message")
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("streamed state did not reach the subscriber: %w", ctx.Err())
+	}
+}
+
+// cleanup deletes the simulation under test
+func (t *SelfTest) cleanup(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := t.runtime.DeleteSimulation(ctx, id); err != nil {
+		logrus.WithError(err).WithField("simulation_id", id).Warn("Failed to delete self-test simulation")
+	}
+}
+
+// watch directs the transitions of a simulation to a channel; an empty ID
+// stops watching
+func (t *SelfTest) watch(id string, transitions chan orchestration.Transition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.simulationID = id
+	t.transitions = transitions
+}
+
+func (t *SelfTest) recordTransition(transition orchestration.Transition) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.simulationID == "" || transition.SimulationID != t.simulationID {
+		return
+	}
+	select {
+	case t.transitions <- transition:
+	default:
+	}
+}
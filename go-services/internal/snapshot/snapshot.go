@@ -0,0 +1,125 @@
+// Package snapshot stores dumps of a simulation's engine state in object
+// storage as versioned, gzip-compressed JSON blobs
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/google/uuid"
+
+	"voltedge/go-services/internal/backup"
+	"voltedge/go-services/internal/database"
+)
+
+// Store is the database side of snapshots
+type Store interface {
+	CreateSnapshot(snapshot *database.SimulationSnapshot) error
+	GetSnapshot(simulationID string, version int) (*database.SimulationSnapshot, error)
+	ListSnapshots(simulationID string, limit, offset int) ([]database.SimulationSnapshot, error)
+}
+
+// Snapshots saves and loads engine state dumps
+type Snapshots struct {
+	store   Store
+	storage backup.Storage
+}
+
+// New creates snapshots kept in storage and recorded in store
+func New(store Store, storage backup.Storage) *Snapshots {
+	return &Snapshots{
+		store:   store,
+		storage: storage,
+	}
+}
+
+// Save writes a state dump and records it as the simulation's next version
+func (s *Snapshots) Save(ctx context.Context, simulationID string, state map[string]interface{}, createdBy *uuid.UUID) (*database.SimulationSnapshot, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(state); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	snapshot := &database.SimulationSnapshot{
+		SimulationID: simulationID,
+		// Named independently of the version, which is only assigned once recorded
+		Object:    path.Join("snapshots", simulationID, database.NewID().String()+".json.gz"),
+		SizeBytes: int64(buf.Len()),
+		Checksum:  hex.EncodeToString(sum[:]),
+		CreatedBy: createdBy,
+	}
+
+	w, err := s.storage.Create(ctx, snapshot.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot object: %w", err)
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("failed to write snapshot object: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot object: %w", err)
+	}
+
+	if err := s.store.CreateSnapshot(snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Load reads the state dump a snapshot points to, verifying its checksum
+func (s *Snapshots) Load(ctx context.Context, snapshot *database.SimulationSnapshot) (map[string]interface{}, error) {
+	r, err := s.storage.Open(ctx, snapshot.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot object: %w", err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot object: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != snapshot.Checksum {
+		return nil, ErrChecksumMismatch
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	var state map[string]interface{}
+	if err := json.NewDecoder(gz).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return state, nil
+}
+
+// Get returns a version of a simulation's snapshots, or nil when it does not exist
+func (s *Snapshots) Get(simulationID string, version int) (*database.SimulationSnapshot, error) {
+	return s.store.GetSnapshot(simulationID, version)
+}
+
+// List returns a simulation's snapshots, newest first
+func (s *Snapshots) List(simulationID string, limit, offset int) ([]database.SimulationSnapshot, error) {
+	return s.store.ListSnapshots(simulationID, limit, offset)
+}
+
+// Errors
+var (
+	ErrChecksumMismatch = fmt.Errorf("snapshot object does not match its checksum")
+)
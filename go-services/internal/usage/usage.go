@@ -0,0 +1,432 @@
+// Package usage meters API calls, simulation-hours and stored results per
+// organization and enforces their quotas
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"voltedge/go-services/internal/database"
+)
+
+// Quota names, also the keys of an organization's quota overrides
+const (
+	QuotaAPICallsPerDay          = "api_calls_per_day"
+	QuotaSimulationHoursPerMonth = "simulation_hours_per_month"
+	QuotaResultRows              = "result_rows"
+)
+
+// Store is the database side of metering
+type Store interface {
+	AddUsage(counters []database.UsageCounter) error
+	SumUsage(organizationID uuid.UUID, metric string, from, to time.Time) (float64, error)
+	DailyUsage(organizationID uuid.UUID, from, to time.Time) ([]database.UsageCounter, error)
+	CountResultRows(organizationID uuid.UUID) (int64, error)
+}
+
+// OrganizationSource looks up organizations for their quota overrides
+type OrganizationSource interface {
+	GetOrganization(id uuid.UUID) (*database.Organization, error)
+}
+
+// SimulationSource reports which organizations have simulations running
+type SimulationSource interface {
+	RunningByOrganization() map[uuid.UUID]int
+}
+
+// Quotas are the usage limits of an organization; zero means unlimited
+type Quotas struct {
+	APICallsPerDay          int64   `json:"api_calls_per_day"`
+	SimulationHoursPerMonth float64 `json:"simulation_hours_per_month"`
+	ResultRows              int64   `json:"result_rows"`
+}
+
+// Options configures the meter
+type Options struct {
+	// Pending usage is written and cached totals refreshed this often
+	FlushInterval time.Duration
+	// Applied to organizations without overrides
+	DefaultQuotas Quotas
+}
+
+// Current is an organization's usage in its current quota periods
+type Current struct {
+	APICallsToday            int64   `json:"api_calls_today"`
+	SimulationHoursThisMonth float64 `json:"simulation_hours_this_month"`
+	ResultRows               int64   `json:"result_rows"`
+}
+
+// DailyUsage is an organization's metered usage on one UTC day
+type DailyUsage struct {
+	Day             string  `json:"day"`
+	APICalls        int64   `json:"api_calls"`
+	SimulationHours float64 `json:"simulation_hours"`
+}
+
+// Report is an organization's usage over a period, for billing and chargeback
+type Report struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	// Days in [From, To)
+	From            time.Time    `json:"from"`
+	To              time.Time    `json:"to"`
+	APICalls        int64        `json:"api_calls"`
+	SimulationHours float64      `json:"simulation_hours"`
+	Daily           []DailyUsage `json:"daily"`
+	Current         Current      `json:"current"`
+	Quotas          Quotas       `json:"quotas"`
+}
+
+// counterKey identifies a pending counter
+type counterKey struct {
+	organizationID uuid.UUID
+	day            time.Time
+	metric         string
+}
+
+// cachedUsage is an organization's current usage, stored plus pending
+type cachedUsage struct {
+	current  Current
+	quotas   Quotas
+	day      time.Time
+	loadedAt time.Time
+}
+
+// Meter accumulates usage in memory, writes it to the store every flush
+// interval and checks quotas against cached totals. Each replica meters its
+// own requests; totals converge across replicas as they flush.
+type Meter struct {
+	store Store
+	orgs  OrganizationSource
+	sims  SimulationSource
+	opts  Options
+
+	mu          sync.Mutex
+	pending     map[counterKey]float64
+	cache       map[uuid.UUID]*cachedUsage
+	lastSampled time.Time
+}
+
+// New creates a new meter
+func New(store Store, orgs OrganizationSource, sims SimulationSource, opts Options) *Meter {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 30 * time.Second
+	}
+
+	return &Meter{
+		store:       store,
+		orgs:        orgs,
+		sims:        sims,
+		opts:        opts,
+		pending:     make(map[counterKey]float64),
+		cache:       make(map[uuid.UUID]*cachedUsage),
+		lastSampled: time.Now(),
+	}
+}
+
+// Start runs the meter in the background until ctx is done
+func (m *Meter) Start(ctx context.Context) {
+	go m.Run(ctx)
+}
+
+// Run samples running simulations and flushes usage every flush interval
+// until ctx is done, flushing once more before it returns
+func (m *Meter) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.sample(time.Now())
+			m.Flush()
+			return
+		case now := <-ticker.C:
+			m.sample(now)
+			m.Flush()
+		}
+	}
+}
+
+// RecordAPICall counts an API call against an organization
+func (m *Meter) RecordAPICall(organizationID uuid.UUID) {
+	now := time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending[counterKey{organizationID, startOfDay(now), database.UsageAPICalls}]++
+	if cached, ok := m.cache[organizationID]; ok && cached.day.Equal(startOfDay(now)) {
+		cached.current.APICallsToday++
+	}
+}
+
+// APICallsExceeded reports whether an organization has used up today's API calls
+func (m *Meter) APICallsExceeded(organizationID uuid.UUID) (bool, error) {
+	usage, err := m.usage(organizationID)
+	if err != nil {
+		return false, err
+	}
+	limit := usage.quotas.APICallsPerDay
+	return limit > 0 && usage.current.APICallsToday >= limit, nil
+}
+
+// CheckSimulationQuota returns ErrSimulationHoursExceeded or
+// ErrResultRowsExceeded when an organization may not run more simulations
+func (m *Meter) CheckSimulationQuota(organizationID uuid.UUID) error {
+	usage, err := m.usage(organizationID)
+	if err != nil {
+		return err
+	}
+
+	quotas := usage.quotas
+	if quotas.SimulationHoursPerMonth > 0 && usage.current.SimulationHoursThisMonth >= quotas.SimulationHoursPerMonth {
+		return ErrSimulationHoursExceeded
+	}
+	if quotas.ResultRows > 0 && usage.current.ResultRows >= quotas.ResultRows {
+		return ErrResultRowsExceeded
+	}
+	return nil
+}
+
+// Quotas returns an organization's quotas: the defaults with its overrides applied
+func (m *Meter) Quotas(organizationID uuid.UUID) (Quotas, error) {
+	quotas := m.opts.DefaultQuotas
+
+	organization, err := m.orgs.GetOrganization(organizationID)
+	if err != nil {
+		return quotas, err
+	}
+	if organization == nil {
+		return quotas, nil
+	}
+
+	overrides := organization.QuotaOverrides()
+	if limit, ok := overrides[QuotaAPICallsPerDay]; ok {
+		quotas.APICallsPerDay = int64(limit)
+	}
+	if limit, ok := overrides[QuotaSimulationHoursPerMonth]; ok {
+		quotas.SimulationHoursPerMonth = limit
+	}
+	if limit, ok := overrides[QuotaResultRows]; ok {
+		quotas.ResultRows = int64(limit)
+	}
+	return quotas, nil
+}
+
+// Invalidate drops an organization's cached usage and quotas, so changed
+// quotas apply to the next check
+func (m *Meter) Invalidate(organizationID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.cache, organizationID)
+}
+
+// Report returns an organization's usage over the days in [from, to)
+// together with its current usage and quotas
+func (m *Meter) Report(organizationID uuid.UUID, from, to time.Time) (*Report, error) {
+	// Include this replica's pending usage
+	m.Flush()
+	m.Invalidate(organizationID)
+
+	from, to = startOfDay(from), startOfDay(to)
+	counters, err := m.store.DailyUsage(organizationID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := m.usage(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		OrganizationID: organizationID,
+		From:           from,
+		To:             to,
+		Daily:          []DailyUsage{},
+		Current:        usage.current,
+		Quotas:         usage.quotas,
+	}
+
+	byDay := make(map[string]*DailyUsage)
+	for _, counter := range counters {
+		day := counter.Day.UTC().Format(time.DateOnly)
+		daily, ok := byDay[day]
+		if !ok {
+			report.Daily = append(report.Daily, DailyUsage{Day: day})
+			daily = &report.Daily[len(report.Daily)-1]
+			byDay[day] = daily
+		}
+
+		switch counter.Metric {
+		case database.UsageAPICalls:
+			daily.APICalls += int64(counter.Value)
+			report.APICalls += int64(counter.Value)
+		case database.UsageSimulationSeconds:
+			daily.SimulationHours += counter.Value / 3600
+			report.SimulationHours += counter.Value / 3600
+		}
+	}
+
+	return report, nil
+}
+
+// Flush writes pending usage to the store, keeping it pending on failure
+func (m *Meter) Flush() {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[counterKey]float64)
+	m.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	counters := make([]database.UsageCounter, 0, len(pending))
+	for key, value := range pending {
+		counters = append(counters, database.UsageCounter{
+			OrganizationID: key.organizationID,
+			Day:            key.day,
+			Metric:         key.metric,
+			Value:          value,
+			UpdatedAt:      now,
+		})
+	}
+
+	if err := m.store.AddUsage(counters); err != nil {
+		logrus.WithError(err).WithField("counters", len(counters)).Warn("Failed to flush usage, retrying next interval")
+
+		m.mu.Lock()
+		for key, value := range pending {
+			m.pending[key] += value
+		}
+		m.mu.Unlock()
+	}
+}
+
+// sample meters the simulation time of running simulations since the last sample
+func (m *Meter) sample(now time.Time) {
+	running := m.sims.RunningByOrganization()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elapsed := now.Sub(m.lastSampled).Seconds()
+	m.lastSampled = now
+	if elapsed <= 0 {
+		return
+	}
+
+	day := startOfDay(now.UTC())
+	for organizationID, count := range running {
+		seconds := elapsed * float64(count)
+		m.pending[counterKey{organizationID, day, database.UsageSimulationSeconds}] += seconds
+		if cached, ok := m.cache[organizationID]; ok {
+			cached.current.SimulationHoursThisMonth += seconds / 3600
+		}
+	}
+}
+
+// usage returns an organization's cached usage, reloading it from the store
+// when it is older than the flush interval or from a previous day
+func (m *Meter) usage(organizationID uuid.UUID) (cachedUsage, error) {
+	now := time.Now().UTC()
+	today := startOfDay(now)
+
+	m.mu.Lock()
+	cached, ok := m.cache[organizationID]
+	if ok && cached.day.Equal(today) && now.Sub(cached.loadedAt) < m.opts.FlushInterval {
+		usage := *cached
+		m.mu.Unlock()
+		return usage, nil
+	}
+	m.mu.Unlock()
+
+	loaded, err := m.load(organizationID, now)
+	if err != nil {
+		return cachedUsage{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Usage recorded here but not flushed yet is not in the store
+	month := startOfMonth(now)
+	for key, value := range m.pending {
+		if key.organizationID != organizationID {
+			continue
+		}
+		switch {
+		case key.metric == database.UsageAPICalls && key.day.Equal(today):
+			loaded.current.APICallsToday += int64(value)
+		case key.metric == database.UsageSimulationSeconds && !key.day.Before(month):
+			loaded.current.SimulationHoursThisMonth += value / 3600
+		}
+	}
+
+	m.cache[organizationID] = loaded
+	return *loaded, nil
+}
+
+// load reads an organization's current usage and quotas from the store
+func (m *Meter) load(organizationID uuid.UUID, now time.Time) (*cachedUsage, error) {
+	today := startOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	quotas, err := m.Quotas(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiCalls, err := m.store.SumUsage(organizationID, database.UsageAPICalls, today, tomorrow)
+	if err != nil {
+		return nil, err
+	}
+	seconds, err := m.store.SumUsage(organizationID, database.UsageSimulationSeconds, startOfMonth(now), tomorrow)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.store.CountResultRows(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachedUsage{
+		current: Current{
+			APICallsToday:            int64(apiCalls),
+			SimulationHoursThisMonth: seconds / 3600,
+			ResultRows:               rows,
+		},
+		quotas:   quotas,
+		day:      today,
+		loadedAt: now,
+	}, nil
+}
+
+// ResetAt returns when the daily API call quota resets
+func ResetAt(now time.Time) time.Time {
+	return startOfDay(now.UTC()).AddDate(0, 0, 1)
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Errors
+var (
+	ErrSimulationHoursExceeded = fmt.Errorf("simulation-hours quota exceeded for this month")
+	ErrResultRowsExceeded      = fmt.Errorf("stored result rows quota exceeded")
+)
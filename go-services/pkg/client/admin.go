@@ -0,0 +1,329 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Login exchanges credentials for a token, which the client then sends with
+// every request
+func (c *Client) Login(ctx context.Context, req LoginRequest) (*TokenResponse, error) {
+	var token TokenResponse
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/auth/login"), body: req}, &token); err != nil {
+		return nil, err
+	}
+	c.SetToken(token.Token)
+	return &token, nil
+}
+
+// ListMyLogins lists the caller's most recent login attempts, newest first;
+// zero uses the server's default limit
+func (c *Client) ListMyLogins(ctx context.Context, limit int) ([]LoginAttempt, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var attempts []LoginAttempt
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/users/me/logins"), query: query}, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// ListMySessions lists the caller's active sessions, newest first
+func (c *Client) ListMySessions(ctx context.Context) ([]UserSession, error) {
+	var sessions []UserSession
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/users/me/sessions")}, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeMySession revokes one of the caller's sessions so its token stops
+// working
+func (c *Client) RevokeMySession(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/users/me/sessions", id.String())}, nil)
+	return err
+}
+
+// Impersonate issues a token acting as another user. The client's own token
+// is left unchanged; pass the returned token to another client to use it.
+func (c *Client) Impersonate(ctx context.Context, req ImpersonationRequest) (*TokenResponse, error) {
+	var token TokenResponse
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/admin/impersonate"), body: req}, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// AuditLogQuery filters ListAuditLogs
+type AuditLogQuery struct {
+	ListOptions
+	ActorID        *uuid.UUID
+	ImpersonatorID *uuid.UUID
+	// Keep only impersonated or only direct calls
+	Impersonated *bool
+	From, To     time.Time
+}
+
+// ListAuditLogs returns a page of audit log entries, newest first
+func (c *Client) ListAuditLogs(ctx context.Context, q AuditLogQuery) (*AuditLogPage, error) {
+	query := q.values()
+	if q.ActorID != nil {
+		query.Set("actor_id", q.ActorID.String())
+	}
+	if q.ImpersonatorID != nil {
+		query.Set("impersonator_id", q.ImpersonatorID.String())
+	}
+	if q.Impersonated != nil {
+		query.Set("impersonated", strconv.FormatBool(*q.Impersonated))
+	}
+	setTimeRange(query, q.From, q.To)
+
+	var page AuditLogPage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/audit"), query: query}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// UpdateImpersonationPolicy sets whether an organization allows impersonation
+func (c *Client) UpdateImpersonationPolicy(ctx context.Context, organizationID uuid.UUID, req ImpersonationPolicyRequest) (*ImpersonationPolicyRequest, error) {
+	var policy ImpersonationPolicyRequest
+	path := apiPath("/organizations", organizationID.String(), "impersonation-policy")
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: path, body: req}, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// GetOrganizationUsage reports an organization's usage against its quotas
+// for the days from through to; zero times cover the current month
+func (c *Client) GetOrganizationUsage(ctx context.Context, organizationID uuid.UUID, from, to time.Time) (*UsageReport, error) {
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set("from", from.Format(time.DateOnly))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.Format(time.DateOnly))
+	}
+
+	var report UsageReport
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/organizations", organizationID.String(), "usage"), query: query}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// UpdateOrganizationQuotas changes an organization's quotas; nil fields keep their value
+func (c *Client) UpdateOrganizationQuotas(ctx context.Context, organizationID uuid.UUID, req QuotaRequest) (*Quotas, error) {
+	var quotas Quotas
+	path := apiPath("/admin/organizations", organizationID.String(), "quotas")
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: path, body: req}, &quotas); err != nil {
+		return nil, err
+	}
+	return &quotas, nil
+}
+
+// UpdateOrganizationConcurrency sets an organization's concurrency limit
+// overrides; nil fields fall back to the configured defaults
+func (c *Client) UpdateOrganizationConcurrency(ctx context.Context, organizationID uuid.UUID, req ConcurrencyLimitsRequest) (*ConcurrencyLimits, error) {
+	var limits ConcurrencyLimits
+	path := apiPath("/admin/organizations", organizationID.String(), "concurrency")
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: path, body: req}, &limits); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// GetLargestMetadata lists the largest stored metadata payloads, optionally of one table
+func (c *Client) GetLargestMetadata(ctx context.Context, table string, limit int) (*LargestMetadata, error) {
+	query := url.Values{}
+	setString(query, "table", table)
+	setInt(query, "limit", limit)
+
+	var largest LargestMetadata
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/metadata/largest"), query: query}, &largest); err != nil {
+		return nil, err
+	}
+	return &largest, nil
+}
+
+// MigrateOversizedMetadata moves oversized metadata payloads to artifacts
+func (c *Client) MigrateOversizedMetadata(ctx context.Context, req MetadataMigrationRequest) ([]MetadataMigration, error) {
+	var migrations []MetadataMigration
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/admin/metadata/migrate"), body: req}, &migrations); err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+// GetArtifact downloads a stored artifact
+func (c *Client) GetArtifact(ctx context.Context, id uuid.UUID) (*Download, error) {
+	data, contentType, err := c.doBytes(ctx, request{method: http.MethodGet, path: apiPath("/artifacts", id.String())})
+	if err != nil {
+		return nil, err
+	}
+	return &Download{ContentType: contentType, Data: data}, nil
+}
+
+// GetDatabaseStats returns the latest sample of the gateway's database
+// connection pool
+func (c *Client) GetDatabaseStats(ctx context.Context) (*DatabaseStats, error) {
+	var stats DatabaseStats
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/db/stats")}, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetLogLevels returns the gateway's log level and the subsystem overrides
+func (c *Client) GetLogLevels(ctx context.Context) (*LogLevels, error) {
+	var levels LogLevels
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/log-level")}, &levels); err != nil {
+		return nil, err
+	}
+	return &levels, nil
+}
+
+// UpdateLogLevels changes the gateway's log level or those of its subsystems
+// until it restarts
+func (c *Client) UpdateLogLevels(ctx context.Context, req LogLevelRequest) (*LogLevels, error) {
+	var levels LogLevels
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/admin/log-level"), body: req}, &levels); err != nil {
+		return nil, err
+	}
+	return &levels, nil
+}
+
+// GetReconciliation reports the last reconciliation pass and recent corrections
+func (c *Client) GetReconciliation(ctx context.Context) (*Reconciliation, error) {
+	var reconciliation Reconciliation
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/reconciliation")}, &reconciliation); err != nil {
+		return nil, err
+	}
+	return &reconciliation, nil
+}
+
+// RunReconciliation runs a reconciliation pass now
+func (c *Client) RunReconciliation(ctx context.Context) (*ReconciliationReport, error) {
+	var report ReconciliationReport
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/admin/reconciliation/run")}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetCluster returns the gateway replicas and the simulations each owns
+func (c *Client) GetCluster(ctx context.Context) (*ClusterStatus, error) {
+	var status ClusterStatus
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/cluster")}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetArchive reports the last archival pass and a page of archived results
+func (c *Client) GetArchive(ctx context.Context, limit, offset int) (*Archive, error) {
+	query := url.Values{}
+	setInt(query, "limit", limit)
+	setInt(query, "offset", offset)
+
+	var archive Archive
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/archive"), query: query}, &archive); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// RunArchive runs an archival pass now
+func (c *Client) RunArchive(ctx context.Context) (*ArchiveReport, error) {
+	var report ArchiveReport
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/admin/archive/run")}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ArchiveSimulation moves a simulation's results to the archive store now
+func (c *Client) ArchiveSimulation(ctx context.Context, simulationID uuid.UUID) (*ResultArchive, error) {
+	var archived ResultArchive
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/admin/archive/simulations", simulationID.String())}, &archived); err != nil {
+		return nil, err
+	}
+	return &archived, nil
+}
+
+// RehydrateSimulation restores a simulation's archived results to the database
+func (c *Client) RehydrateSimulation(ctx context.Context, simulationID uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/admin/archive/simulations", simulationID.String(), "rehydrate")}, nil)
+	return err
+}
+
+// GetChaos returns the running chaos experiments
+func (c *Client) GetChaos(ctx context.Context) (*ChaosStatus, error) {
+	var status ChaosStatus
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/chaos")}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// StartChaosExperiment starts injecting a fault, replacing any experiment
+// already running for it
+func (c *Client) StartChaosExperiment(ctx context.Context, fault string, req ChaosExperimentRequest) (*ChaosExperiment, error) {
+	var experiment ChaosExperiment
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/admin/chaos", fault), body: req}, &experiment); err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// StopChaosExperiment stops injecting a fault
+func (c *Client) StopChaosExperiment(ctx context.Context, fault string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/admin/chaos", fault)}, nil)
+	return err
+}
+
+// StopChaosExperiments stops every chaos experiment and returns how many were running
+func (c *Client) StopChaosExperiments(ctx context.Context) (int, error) {
+	var result struct {
+		Stopped int `json:"stopped"`
+	}
+	if _, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/admin/chaos")}, &result); err != nil {
+		return 0, err
+	}
+	return result.Stopped, nil
+}
+
+// ListDeadLetters returns the simulation jobs that failed for good, newest first
+func (c *Client) ListDeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/jobs")}, &letters); err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// GetDeadLetter returns a failed job with its config snapshot and engine diagnostics
+func (c *Client) GetDeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	var letter DeadLetter
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/admin/jobs", id)}, &letter); err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+// RetryDeadLetter starts a failed job's simulation again
+func (c *Client) RetryDeadLetter(ctx context.Context, id string) (*DeadLetter, error) {
+	var letter DeadLetter
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/admin/jobs", id, "retry")}, &letter); err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// CreateChannel creates a Slack, PagerDuty or email notification channel
+func (c *Client) CreateChannel(ctx context.Context, req ChannelRequest) (*Channel, error) {
+	var channel Channel
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/alerting/channels"), body: req}, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// ListChannels returns a page of notification channels
+func (c *Client) ListChannels(ctx context.Context, opts ListOptions) ([]Channel, error) {
+	var channels []Channel
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/alerting/channels"), query: opts.values()}, &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// GetChannel returns a notification channel
+func (c *Client) GetChannel(ctx context.Context, id uuid.UUID) (*Channel, error) {
+	var channel Channel
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/alerting/channels", id.String())}, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// UpdateChannel replaces a notification channel. version must be the
+// channel's current version; omitted secrets keep their value.
+func (c *Client) UpdateChannel(ctx context.Context, id uuid.UUID, version int64, req ChannelRequest) (*Channel, error) {
+	var channel Channel
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/alerting/channels", id.String()), body: req, version: version}, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// DeleteChannel deletes a notification channel
+func (c *Client) DeleteChannel(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/alerting/channels", id.String())}, nil)
+	return err
+}
+
+// TestChannel sends a test alert through a channel. req may be nil.
+func (c *Client) TestChannel(ctx context.Context, id uuid.UUID, req *TestNotificationRequest) error {
+	call := request{method: http.MethodPost, path: apiPath("/alerting/channels", id.String(), "test")}
+	if req != nil {
+		call.body = req
+	}
+	_, err := c.do(ctx, call, nil)
+	return err
+}
+
+// CreateAlertRoute creates a route sending matching alerts to a channel
+func (c *Client) CreateAlertRoute(ctx context.Context, req AlertRouteRequest) (*AlertRoute, error) {
+	var route AlertRoute
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/alerting/routes"), body: req}, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// ListAlertRoutes returns a page of alert routes
+func (c *Client) ListAlertRoutes(ctx context.Context, opts ListOptions) ([]AlertRoute, error) {
+	var routes []AlertRoute
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/alerting/routes"), query: opts.values()}, &routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// GetAlertRoute returns an alert route
+func (c *Client) GetAlertRoute(ctx context.Context, id uuid.UUID) (*AlertRoute, error) {
+	var route AlertRoute
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/alerting/routes", id.String())}, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// UpdateAlertRoute replaces an alert route. version must be the route's current version.
+func (c *Client) UpdateAlertRoute(ctx context.Context, id uuid.UUID, version int64, req AlertRouteRequest) (*AlertRoute, error) {
+	var route AlertRoute
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/alerting/routes", id.String()), body: req, version: version}, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// DeleteAlertRoute deletes an alert route
+func (c *Client) DeleteAlertRoute(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/alerting/routes", id.String())}, nil)
+	return err
+}
+
+// ListAlertNotifications returns a page of sent alert notifications,
+// optionally of one route
+func (c *Client) ListAlertNotifications(ctx context.Context, routeID *uuid.UUID, opts ListOptions) ([]AlertNotification, error) {
+	query := opts.values()
+	if routeID != nil {
+		query.Set("route_id", routeID.String())
+	}
+
+	var notifications []AlertNotification
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/alerting/notifications"), query: query}, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// CreateSilence creates an alert silence
+func (c *Client) CreateSilence(ctx context.Context, req SilenceRequest) (*Silence, error) {
+	var silence Silence
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/alerting/silences"), body: req}, &silence); err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
+// ListSilences returns a page of alert silences; state optionally keeps only
+// pending, active or expired ones
+func (c *Client) ListSilences(ctx context.Context, state string, opts ListOptions) ([]Silence, error) {
+	query := opts.values()
+	setString(query, "state", state)
+
+	var silences []Silence
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/alerting/silences"), query: query}, &silences); err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
+
+// GetSilence returns an alert silence
+func (c *Client) GetSilence(ctx context.Context, id uuid.UUID) (*Silence, error) {
+	var silence Silence
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/alerting/silences", id.String())}, &silence); err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
+// UpdateSilence replaces an alert silence. version must be the silence's current version.
+func (c *Client) UpdateSilence(ctx context.Context, id uuid.UUID, version int64, req SilenceRequest) (*Silence, error) {
+	var silence Silence
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/alerting/silences", id.String()), body: req, version: version}, &silence); err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
+// ExpireSilence ends an alert silence now
+func (c *Client) ExpireSilence(ctx context.Context, id uuid.UUID) (*Silence, error) {
+	var silence Silence
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/alerting/silences", id.String(), "expire")}, &silence); err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
+// DeleteSilence deletes an alert silence
+func (c *Client) DeleteSilence(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/alerting/silences", id.String())}, nil)
+	return err
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// CreateBatch starts a Monte Carlo batch of sampled simulations
+func (c *Client) CreateBatch(ctx context.Context, req CreateBatchRequest) (*Batch, error) {
+	var batch Batch
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/batches"), body: req}, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListBatches lists Monte Carlo batches
+func (c *Client) ListBatches(ctx context.Context) ([]Batch, error) {
+	var batches []Batch
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/batches")}, &batches); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// GetBatch returns a batch with its progress and statistics
+func (c *Client) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/batches", id)}, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CancelBatch stops a running batch
+func (c *Client) CancelBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/batches", id, "cancel")}, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CreateExperiment starts a parameter sweep
+func (c *Client) CreateExperiment(ctx context.Context, req CreateExperimentRequest) (*Experiment, error) {
+	var experiment Experiment
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/experiments"), body: req}, &experiment); err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// ListExperiments lists parameter sweeps
+func (c *Client) ListExperiments(ctx context.Context) ([]Experiment, error) {
+	var experiments []Experiment
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/experiments")}, &experiments); err != nil {
+		return nil, err
+	}
+	return experiments, nil
+}
+
+// GetExperiment returns a parameter sweep with its runs
+func (c *Client) GetExperiment(ctx context.Context, id string) (*Experiment, error) {
+	var experiment Experiment
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/experiments", id)}, &experiment); err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// CancelExperiment stops a running parameter sweep
+func (c *Client) CancelExperiment(ctx context.Context, id string) (*Experiment, error) {
+	var experiment Experiment
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/experiments", id, "cancel")}, &experiment); err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
@@ -0,0 +1,524 @@
+// Package client is a Go client for the VoltEdge gateway API. It wraps every
+// REST endpoint in a typed method, handles bearer tokens, retries requests
+// that are safe to retry and streams playback sessions and live updates.
+// Paginated lists such as ListSimulations are iterators to range over.
+//
+//	c := client.New("http://localhost:8080")
+//	if _, err := c.Login(ctx, client.LoginRequest{Email: "ops@example.com", Password: password}); err != nil {
+//		return err
+//	}
+//	simulation, err := c.CreateSimulation(ctx, client.CreateSimulationRequest{...})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	defaultUserAgent     = "voltedge-go-client"
+	defaultTimeout       = 30 * time.Second
+	defaultWebSocketPath = "/ws"
+	ingestTokenHeader    = "X-VoltEdge-Ingest-Token"
+	serviceTokenHeader   = "X-VoltEdge-Service-Token"
+)
+
+// RetryPolicy controls how failed requests are retried. Rate-limited
+// requests (429) are always retried; network errors and 502, 503 and 504
+// responses are retried for GET, HEAD, PUT and DELETE only, since the
+// gateway may already have acted on other methods.
+type RetryPolicy struct {
+	// Total attempts including the first; 1 disables retries
+	MaxAttempts int
+	// Backoff before the first retry, doubled for each further one
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used unless WithRetry is given
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  200 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// Client calls a VoltEdge gateway. It is safe for concurrent use.
+type Client struct {
+	baseURL       string
+	http          *http.Client
+	timeout       time.Duration
+	retry         RetryPolicy
+	userAgent     string
+	ingestToken   string
+	serviceToken  string
+	webSocketPath string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithToken authenticates every request with a bearer token
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient sends requests through the given HTTP client. Its Timeout
+// also cuts off streams, so prefer WithTimeout for bounding calls.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.http = httpClient
+	}
+}
+
+// WithTimeout bounds each call, retries included. Streams are not bounded.
+// Zero disables the timeout; the default is 30 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry replaces the default retry policy
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithIngestToken sets the shared token IngestResultBatch authenticates with
+func WithIngestToken(token string) Option {
+	return func(c *Client) {
+		c.ingestToken = token
+	}
+}
+
+// WithServiceToken sets the signed service token IngestResultBatch
+// authenticates with. It then calls the internal API, so baseURL must be
+// that of the gateway's internal listener when it has one.
+func WithServiceToken(token string) Option {
+	return func(c *Client) {
+		c.serviceToken = token
+	}
+}
+
+// WithWebSocketPath sets the path of the gateway's WebSocket endpoint when
+// it is not the default /ws
+func WithWebSocketPath(path string) Option {
+	return func(c *Client) {
+		c.webSocketPath = path
+	}
+}
+
+// New creates a client for the gateway at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		http:          &http.Client{},
+		timeout:       defaultTimeout,
+		retry:         DefaultRetryPolicy,
+		userAgent:     defaultUserAgent,
+		webSocketPath: defaultWebSocketPath,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.MaxAttempts < 1 {
+		c.retry.MaxAttempts = 1
+	}
+	return c
+}
+
+// BaseURL returns the gateway the client calls
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Token returns the bearer token requests are sent with
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// SetToken replaces the bearer token requests are sent with; empty sends
+// requests anonymously
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+// APIError is an error response from the gateway
+type APIError struct {
+	StatusCode int
+	// Machine-readable code, e.g. API_ERROR or VERSION_CONFLICT
+	Code    string
+	Message string
+	Details map[string]interface{}
+	// How long the gateway asked to wait before retrying, if it said
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("gateway returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("gateway returned %d: %s", e.StatusCode, e.Message)
+}
+
+// CurrentVersion returns the version a VERSION_CONFLICT error reported
+func (e *APIError) CurrentVersion() (int64, bool) {
+	if e.Code != "VERSION_CONFLICT" {
+		return 0, false
+	}
+	version, ok := e.Details["current_version"].(float64)
+	return int64(version), ok
+}
+
+// IsNotFound reports whether err is a 404 response
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is a 409 response, such as a stale version
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// request describes one API call
+type request struct {
+	method string
+	// Path below the gateway root, including /api/v1 for API routes
+	path  string
+	query url.Values
+	// Encoded as JSON unless rawBody is set
+	body        interface{}
+	rawBody     []byte
+	contentType string
+	// Sent as If-Match when non-zero
+	version int64
+	header  http.Header
+}
+
+// Pagination describes a page of a page-numbered list
+type Pagination struct {
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Total int64 `json:"total"`
+}
+
+// envelope is the body of a successful API response
+type envelope struct {
+	Success    bool            `json:"success"`
+	Data       json.RawMessage `json:"data"`
+	Message    string          `json:"message"`
+	Pagination *Pagination     `json:"pagination"`
+}
+
+// do sends a request and decodes the data field of the response into out,
+// if out is not nil. The envelope is returned for callers needing more.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (*envelope, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body envelope
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid response from %s %s: %w", req.method, req.path, err)
+	}
+	if out != nil && len(body.Data) > 0 {
+		if err := json.Unmarshal(body.Data, out); err != nil {
+			return nil, fmt.Errorf("invalid response data from %s %s: %w", req.method, req.path, err)
+		}
+	}
+	return &body, nil
+}
+
+// doJSON sends a request to an endpoint that answers with bare JSON rather
+// than the usual envelope
+func (c *Client) doJSON(ctx context.Context, req request, out interface{}) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// doBytes sends a request and returns the raw body and content type of the response
+func (c *Client) doBytes(ctx context.Context, req request) ([]byte, string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// withTimeout applies the client's call timeout to ctx
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// send sends a request, retrying as the retry policy allows, and returns the
+// response of the first successful attempt. Error responses are returned as
+// *APIError. The caller closes the response body.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	body := req.rawBody
+	if body == nil && req.body != nil {
+		encoded, err := json.Marshal(req.body)
+		if err != nil {
+			return nil, err
+		}
+		body = encoded
+	}
+
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(ctx, req, target, body)
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			apiErr := readAPIError(resp)
+			err, wait = apiErr, apiErr.RetryAfter
+		} else if ctx.Err() != nil {
+			return nil, err
+		}
+
+		if attempt >= c.retry.MaxAttempts || !retryable(req.method, err) {
+			return nil, err
+		}
+		if wait <= 0 {
+			wait = c.backoff(attempt)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// attempt sends a request once
+func (c *Client) attempt(ctx context.Context, req request, target string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	for name, values := range req.header {
+		httpReq.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	if body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if req.version > 0 {
+		httpReq.Header.Set("If-Match", strconv.Quote(strconv.FormatInt(req.version, 10)))
+	}
+	if token := c.Token(); token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return c.http.Do(httpReq)
+}
+
+// readAPIError reads an error response and closes its body
+func readAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	var body struct {
+		Error   string                 `json:"error"`
+		Message string                 `json:"message"`
+		Code    string                 `json:"code"`
+		Details map[string]interface{} `json:"details"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body) == nil {
+		apiErr.Code = body.Code
+		apiErr.Message = body.Message
+		apiErr.Details = body.Details
+		if apiErr.Message == "" {
+			apiErr.Message = body.Error
+		}
+	}
+	return apiErr
+}
+
+// retryable reports whether a failed attempt may be sent again
+func retryable(method string, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			// Rejected before reaching the handler
+			return true
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent(method)
+		}
+		return false
+	}
+	return idempotent(method)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered wait before retry number attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.retry.MinBackoff << (attempt - 1)
+	if c.retry.MaxBackoff > 0 && (wait > c.retry.MaxBackoff || wait <= 0) {
+		wait = c.retry.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	// Between half and all of the backoff so clients spread out
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// apiPath joins an API route with escaped path segments,
+// e.g. apiPath("/simulations", id, "start")
+func apiPath(route string, segments ...string) string {
+	var path strings.Builder
+	path.WriteString(apiPrefix)
+	path.WriteString(route)
+	for _, segment := range segments {
+		path.WriteByte('/')
+		path.WriteString(url.PathEscape(segment))
+	}
+	return path.String()
+}
+
+// ListOptions selects a page of a page-numbered list. Zero values use the
+// gateway's defaults.
+type ListOptions struct {
+	Page  int
+	Limit int
+}
+
+func (o ListOptions) values() url.Values {
+	query := url.Values{}
+	setInt(query, "page", o.Page)
+	setInt(query, "limit", o.Limit)
+	return query
+}
+
+func setInt(query url.Values, key string, value int) {
+	if value != 0 {
+		query.Set(key, strconv.Itoa(value))
+	}
+}
+
+func setFloat(query url.Values, key string, value float64) {
+	if value != 0 {
+		query.Set(key, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+}
+
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setList(query url.Values, key string, values []string) {
+	if len(values) > 0 {
+		query.Set(key, strings.Join(values, ","))
+	}
+}
+
+func setTime(query url.Values, key string, value time.Time) {
+	if !value.IsZero() {
+		query.Set(key, value.UTC().Format(time.RFC3339))
+	}
+}
+
+// setTimeRange sets the RFC3339 from and to parameters most range queries take
+func setTimeRange(query url.Values, from, to time.Time) {
+	if !from.IsZero() {
+		query.Set("from", from.UTC().Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		query.Set("to", to.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventQuery filters a simulation's fault events or alerts
+type EventQuery struct {
+	From, To   time.Time
+	Severities []string
+	Types      []string
+	// Alerts only: active, acknowledged or resolved
+	Status      string
+	ComponentID *int
+	// NextCursor of the previous page; empty starts at the newest event
+	Cursor string
+	Limit  int
+}
+
+func (q EventQuery) values() url.Values {
+	query := url.Values{}
+	setTimeRange(query, q.From, q.To)
+	setList(query, "severity", q.Severities)
+	setList(query, "type", q.Types)
+	setString(query, "status", q.Status)
+	if q.ComponentID != nil {
+		query.Set("component_id", strconv.Itoa(*q.ComponentID))
+	}
+	setString(query, "cursor", q.Cursor)
+	setInt(query, "limit", q.Limit)
+	return query
+}
+
+// ListFaultEvents returns a page of a simulation's fault events, newest first
+func (c *Client) ListFaultEvents(ctx context.Context, simulationID string, q EventQuery) (*FaultEventPage, error) {
+	var page FaultEventPage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", simulationID, "faults"), query: q.values()}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// CountFaultEvents counts a simulation's matching fault events by severity.
+// Cursor and Limit are ignored.
+func (c *Client) CountFaultEvents(ctx context.Context, simulationID string, q EventQuery) (*EventCounts, error) {
+	var counts EventCounts
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", simulationID, "faults", "counts"), query: q.values()}, &counts); err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+// ExportFaultEvents streams every matching fault event as csv or ndjson.
+// The caller closes the returned reader.
+func (c *Client) ExportFaultEvents(ctx context.Context, simulationID, format string, q EventQuery) (io.ReadCloser, error) {
+	return c.export(ctx, apiPath("/simulations", simulationID, "faults", "export"), format, q)
+}
+
+// ListAlerts returns a page of a simulation's alerts, newest first
+func (c *Client) ListAlerts(ctx context.Context, simulationID string, q EventQuery) (*AlertPage, error) {
+	var page AlertPage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", simulationID, "alerts"), query: q.values()}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// CountAlerts counts a simulation's matching alerts by severity. Cursor and
+// Limit are ignored.
+func (c *Client) CountAlerts(ctx context.Context, simulationID string, q EventQuery) (*EventCounts, error) {
+	var counts EventCounts
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", simulationID, "alerts", "counts"), query: q.values()}, &counts); err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+// ExportAlerts streams every matching alert as csv or ndjson. The caller
+// closes the returned reader.
+func (c *Client) ExportAlerts(ctx context.Context, simulationID, format string, q EventQuery) (io.ReadCloser, error) {
+	return c.export(ctx, apiPath("/simulations", simulationID, "alerts", "export"), format, q)
+}
+
+// export opens an event export. Exports can be long, so the call timeout
+// does not apply.
+func (c *Client) export(ctx context.Context, path, format string, q EventQuery) (io.ReadCloser, error) {
+	query := q.values()
+	query.Del("cursor")
+	query.Del("limit")
+	setString(query, "format", format)
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: path, query: query})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// TimelineQuery filters GetSimulationTimeline
+type TimelineQuery struct {
+	From, To time.Time
+	// Entry kinds to include; empty includes all
+	Kinds []string
+	// Frequency excursion detection; zero uses the gateway's defaults
+	NominalHz   float64
+	ThresholdHz float64
+	Cursor      string
+	Limit       int
+}
+
+// GetSimulationTimeline returns a page of a persisted simulation's timeline, newest first
+func (c *Client) GetSimulationTimeline(ctx context.Context, simulationID string, q TimelineQuery) (*TimelinePage, error) {
+	query := url.Values{}
+	setTimeRange(query, q.From, q.To)
+	setList(query, "kind", q.Kinds)
+	setFloat(query, "nominal_hz", q.NominalHz)
+	setFloat(query, "threshold_hz", q.ThresholdHz)
+	setString(query, "cursor", q.Cursor)
+	setInt(query, "limit", q.Limit)
+
+	var page TimelinePage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", simulationID, "timeline"), query: query}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AcknowledgeAlert acknowledges an active alert
+func (c *Client) AcknowledgeAlert(ctx context.Context, id uuid.UUID) (*Alert, error) {
+	var alert Alert
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/alerts", id.String(), "ack")}, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// AcknowledgeAlerts acknowledges every listed alert that is still active
+func (c *Client) AcknowledgeAlerts(ctx context.Context, ids []uuid.UUID) (*BulkAcknowledgeResponse, error) {
+	var response BulkAcknowledgeResponse
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/alerts/ack"), body: BulkAcknowledgeRequest{IDs: ids}}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ResolveAlert resolves an alert whether or not it was acknowledged
+func (c *Client) ResolveAlert(ctx context.Context, id uuid.UUID) (*Alert, error) {
+	var alert Alert
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/alerts", id.String(), "resolve")}, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
@@ -0,0 +1,354 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetGridState returns the latest recorded state of a simulation's grid
+func (c *Client) GetGridState(ctx context.Context, simulationID string) (*GridState, error) {
+	var state GridState
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/grid/state", simulationID)}, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// GetGridComponents returns the components of a simulation's grid
+func (c *Client) GetGridComponents(ctx context.Context, simulationID string) (map[string]interface{}, error) {
+	var components map[string]interface{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/grid/components", simulationID)}, &components); err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// GetGridTopology returns the buses and branches of a simulation's grid
+func (c *Client) GetGridTopology(ctx context.Context, simulationID string) (*Topology, error) {
+	var topology Topology
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/grid/topology", simulationID)}, &topology); err != nil {
+		return nil, err
+	}
+	return &topology, nil
+}
+
+// GetGridGeoJSON returns a simulation's grid as a GeoJSON FeatureCollection
+func (c *Client) GetGridGeoJSON(ctx context.Context, simulationID string) (*GeoJSON, error) {
+	var collection GeoJSON
+	if err := c.doJSON(ctx, request{method: http.MethodGet, path: apiPath("/grid/geojson", simulationID)}, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// InjectFailure fails a component of a running simulation
+func (c *Client) InjectFailure(ctx context.Context, simulationID string, req FailureRequest) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/grid/failures", simulationID), body: req}, nil)
+	return err
+}
+
+// ListPowerPlants lists power plants
+func (c *Client) ListPowerPlants(ctx context.Context) ([]map[string]interface{}, error) {
+	var plants []map[string]interface{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/plants")}, &plants); err != nil {
+		return nil, err
+	}
+	return plants, nil
+}
+
+// GetPowerPlant returns a power plant
+func (c *Client) GetPowerPlant(ctx context.Context, id string) (map[string]interface{}, error) {
+	var plant map[string]interface{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/plants", id)}, &plant); err != nil {
+		return nil, err
+	}
+	return plant, nil
+}
+
+// ControlPowerPlant sends a control command to a power plant
+func (c *Client) ControlPowerPlant(ctx context.Context, id string, req ControlRequest) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/plants", id, "control"), body: req}, nil)
+	return err
+}
+
+// ListTransmissionLines lists transmission lines
+func (c *Client) ListTransmissionLines(ctx context.Context) ([]map[string]interface{}, error) {
+	var lines []map[string]interface{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/transmission")}, &lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// GetTransmissionLine returns a transmission line
+func (c *Client) GetTransmissionLine(ctx context.Context, id string) (map[string]interface{}, error) {
+	var line map[string]interface{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/transmission", id)}, &line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// ControlTransmissionLine sends a control command to a transmission line
+func (c *Client) ControlTransmissionLine(ctx context.Context, id string, req ControlRequest) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/transmission", id, "control"), body: req}, nil)
+	return err
+}
+
+// ListEngines returns the registered simulation engines with their load
+func (c *Client) ListEngines(ctx context.Context) ([]Engine, error) {
+	var engines []Engine
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/engines")}, &engines); err != nil {
+		return nil, err
+	}
+	return engines, nil
+}
+
+// GetEngine returns a registered simulation engine
+func (c *Client) GetEngine(ctx context.Context, name string) (*Engine, error) {
+	var status Engine
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/engines", name)}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetPerformanceMetrics returns a simulation's runtime performance
+func (c *Client) GetPerformanceMetrics(ctx context.Context, simulationID string) (map[string]interface{}, error) {
+	var metrics map[string]interface{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/performance", simulationID)}, &metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// GetSimulationHistory returns a simulation's generation and consumption history
+func (c *Client) GetSimulationHistory(ctx context.Context, simulationID string) ([]map[string]interface{}, error) {
+	var history []map[string]interface{}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/history", simulationID)}, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetPredictions forecasts load, fault likelihood and required generation
+func (c *Client) GetPredictions(ctx context.Context, simulationID string) (*Prediction, error) {
+	var predicted Prediction
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/predictions", simulationID)}, &predicted); err != nil {
+		return nil, err
+	}
+	return &predicted, nil
+}
+
+// GetPredictionAccuracy compares up to limit stored forecasts with the load later observed
+func (c *Client) GetPredictionAccuracy(ctx context.Context, simulationID string, limit int) (*PredictionAccuracy, error) {
+	query := url.Values{}
+	setInt(query, "limit", limit)
+
+	var accuracy PredictionAccuracy
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/predictions", simulationID, "accuracy"), query: query}, &accuracy); err != nil {
+		return nil, err
+	}
+	return &accuracy, nil
+}
+
+// GetEmissions computes carbon emissions over a simulation's results; zero
+// times cover the whole run
+func (c *Client) GetEmissions(ctx context.Context, simulationID string, from, to time.Time) (*EmissionsReport, error) {
+	query := url.Values{}
+	setTimeRange(query, from, to)
+
+	var report EmissionsReport
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/emissions", simulationID), query: query}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetCosts computes operating costs over a simulation's results; zero times
+// cover the whole run
+func (c *Client) GetCosts(ctx context.Context, simulationID string, from, to time.Time) (*CostReport, error) {
+	query := url.Values{}
+	setTimeRange(query, from, to)
+
+	var report CostReport
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/costs", simulationID), query: query}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetDispatchSuggestion returns a cost-optimal dispatch for demandMW, or for
+// the latest recorded consumption when demandMW is nil
+func (c *Client) GetDispatchSuggestion(ctx context.Context, simulationID string, demandMW *float64) (*DispatchSuggestion, error) {
+	query := url.Values{}
+	if demandMW != nil {
+		query.Set("demand_mw", strconv.FormatFloat(*demandMW, 'f', -1, 64))
+	}
+
+	var suggestion DispatchSuggestion
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/costs", simulationID, "dispatch"), query: query}, &suggestion); err != nil {
+		return nil, err
+	}
+	return &suggestion, nil
+}
+
+// GetEnergyBalance reports a simulation's energy balance, losses and
+// renewables share; zero times cover the whole run and a positive step breaks
+// the totals down into windows of that length
+func (c *Client) GetEnergyBalance(ctx context.Context, simulationID string, from, to time.Time, step time.Duration) (*EnergyReport, error) {
+	query := url.Values{}
+	setTimeRange(query, from, to)
+	if step > 0 {
+		query.Set("step", step.String())
+	}
+
+	var report EnergyReport
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/energy", simulationID), query: query}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetReliability computes per-component MTBF, MTTR and availability from a
+// simulation's faults; zero times default to the range of its results
+func (c *Client) GetReliability(ctx context.Context, simulationID string, from, to time.Time) (*ReliabilityReport, error) {
+	query := url.Values{}
+	setTimeRange(query, from, to)
+
+	var report ReliabilityReport
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/reliability", simulationID), query: query}, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ExportReliability returns the per-component reliability report as CSV.
+// The caller closes the returned reader.
+func (c *Client) ExportReliability(ctx context.Context, simulationID string, from, to time.Time) (io.ReadCloser, error) {
+	query := url.Values{}
+	setTimeRange(query, from, to)
+	query.Set("format", "csv")
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: apiPath("/analytics/reliability", simulationID), query: query})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// GetStabilityScore returns a run's frequency stability KPIs, computing them
+// if the run has none yet
+func (c *Client) GetStabilityScore(ctx context.Context, simulationID string) (*StabilityScore, error) {
+	var score StabilityScore
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/stability", simulationID)}, &score); err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// RecomputeStabilityScore computes a run's frequency stability KPIs again
+// around nominalHz and within bandHz; zero values keep the defaults
+func (c *Client) RecomputeStabilityScore(ctx context.Context, simulationID string, nominalHz, bandHz float64) (*StabilityScore, error) {
+	query := url.Values{}
+	query.Set("recompute", "true")
+	if nominalHz > 0 {
+		query.Set("nominal_hz", strconv.FormatFloat(nominalHz, 'f', -1, 64))
+	}
+	if bandHz > 0 {
+		query.Set("band_hz", strconv.FormatFloat(bandHz, 'f', -1, 64))
+	}
+
+	var score StabilityScore
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/stability", simulationID), query: query}, &score); err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// RankStability lists runs by stability score, most stable first, optionally
+// only the given simulations
+func (c *Client) RankStability(ctx context.Context, simulationIDs []string, limit, offset int) ([]StabilityRank, error) {
+	query := url.Values{}
+	if len(simulationIDs) > 0 {
+		query.Set("simulation_id", strings.Join(simulationIDs, ","))
+	}
+	setInt(query, "limit", limit)
+	setInt(query, "offset", offset)
+
+	var ranks []StabilityRank
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/stability"), query: query}, &ranks); err != nil {
+		return nil, err
+	}
+	return ranks, nil
+}
+
+// TopComponentsQuery ranks components by an aggregate of one metric
+type TopComponentsQuery struct {
+	Metric        string
+	ComponentType string
+	// Aggregate to rank by; empty uses max
+	Aggregation string
+	Ascending   bool
+	N           int
+	From, To    time.Time
+}
+
+// GetTopComponents ranks a simulation's components, e.g. the ten most loaded lines
+func (c *Client) GetTopComponents(ctx context.Context, simulationID string, q TopComponentsQuery) ([]ComponentRank, error) {
+	query := url.Values{"metric": {q.Metric}}
+	setString(query, "component_type", q.ComponentType)
+	setString(query, "agg", q.Aggregation)
+	if q.Ascending {
+		query.Set("order", "asc")
+	}
+	setInt(query, "n", q.N)
+	setTimeRange(query, q.From, q.To)
+
+	var ranks []ComponentRank
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/top", simulationID), query: query}, &ranks); err != nil {
+		return nil, err
+	}
+	return ranks, nil
+}
+
+// PercentilesQuery selects the distributions GetPercentiles summarizes
+type PercentilesQuery struct {
+	// Result fields, e.g. frequency_deviation_hz
+	Fields []string
+	// Component metrics, e.g. line_loading, optionally of one component type
+	Metrics       []string
+	ComponentType string
+	// Percentiles between 0 and 100; empty uses 50, 95 and 99
+	Percentiles []float64
+	NominalHz   float64
+	From, To    time.Time
+}
+
+// GetPercentiles summarizes the distribution of result fields and component metrics
+func (c *Client) GetPercentiles(ctx context.Context, simulationID string, q PercentilesQuery) ([]PercentileSummary, error) {
+	query := url.Values{}
+	setList(query, "field", q.Fields)
+	setList(query, "metric", q.Metrics)
+	setString(query, "component_type", q.ComponentType)
+	if len(q.Percentiles) > 0 {
+		percentiles := make([]string, len(q.Percentiles))
+		for i, p := range q.Percentiles {
+			percentiles[i] = strconv.FormatFloat(p, 'f', -1, 64)
+		}
+		setList(query, "p", percentiles)
+	}
+	setFloat(query, "nominal_hz", q.NominalHz)
+	setTimeRange(query, q.From, q.To)
+
+	var summaries []PercentileSummary
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/analytics/percentiles", simulationID), query: query}, &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// IngestResultBatch stores a batch of results pushed by a simulation engine.
+// The service token set with WithServiceToken or the ingest token set with
+// WithIngestToken authenticates the call.
+func (c *Client) IngestResultBatch(ctx context.Context, req ResultBatchRequest) (*IngestSummary, error) {
+	call := request{
+		method: http.MethodPost,
+		// The colon is part of the route and must not be escaped
+		path: apiPrefix + "/internal/results:batch",
+		body: req,
+	}
+	switch {
+	case c.serviceToken != "":
+		call.path = "/internal/v1/results:batch"
+		call.header = http.Header{serviceTokenHeader: {c.serviceToken}}
+	case c.ingestToken != "":
+		call.header = http.Header{ingestTokenHeader: {c.ingestToken}}
+	}
+
+	var summary IngestSummary
+	if _, err := c.do(ctx, call, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GrafanaTest checks the Grafana datasource endpoint
+func (c *Client) GrafanaTest(ctx context.Context) error {
+	var status struct {
+		Status string `json:"status"`
+	}
+	return c.doJSON(ctx, request{method: http.MethodGet, path: apiPath("/grafana")}, &status)
+}
+
+// GrafanaSearch lists the metric targets Grafana can query
+func (c *Client) GrafanaSearch(ctx context.Context, req GrafanaSearchRequest) ([]GrafanaSearchResult, error) {
+	var results []GrafanaSearchResult
+	if err := c.doJSON(ctx, request{method: http.MethodPost, path: apiPath("/grafana/search"), body: req}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GrafanaQuery runs a Grafana query. Each element is a GrafanaTimeSeries or
+// a GrafanaTable depending on the target's type.
+func (c *Client) GrafanaQuery(ctx context.Context, req GrafanaQueryRequest) ([]json.RawMessage, error) {
+	var results []json.RawMessage
+	if err := c.doJSON(ctx, request{method: http.MethodPost, path: apiPath("/grafana/query"), body: req}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GrafanaAnnotations returns fault events as Grafana annotations
+func (c *Client) GrafanaAnnotations(ctx context.Context, req GrafanaAnnotationRequest) ([]GrafanaAnnotation, error) {
+	var annotations []GrafanaAnnotation
+	if err := c.doJSON(ctx, request{method: http.MethodPost, path: apiPath("/grafana/annotations"), body: req}, &annotations); err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// GrafanaSeries returns one target as a flat array of points
+func (c *Client) GrafanaSeries(ctx context.Context, target string, from, to time.Time) ([]GrafanaPoint, error) {
+	query := url.Values{"target": {target}}
+	setTimeRange(query, from, to)
+
+	var points []GrafanaPoint
+	if err := c.doJSON(ctx, request{method: http.MethodGet, path: apiPath("/grafana/series"), query: query}, &points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// Health returns the gateway's health check. An unhealthy gateway answers
+// with 503, which is returned as the health report rather than an error.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.attempt(ctx, request{method: http.MethodGet}, c.baseURL+"/health", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, readAPIError(resp)
+	}
+	var health Health
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to decode health check: %w", err)
+	}
+	return &health, nil
+}
+
+// Readiness returns the gateway's readiness check, including the startup
+// self-test. A gateway that is not ready answers with 503, which is returned
+// as the readiness report rather than an error.
+func (c *Client) Readiness(ctx context.Context) (*Readiness, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := c.attempt(ctx, request{method: http.MethodGet}, c.baseURL+"/readyz", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, readAPIError(resp)
+	}
+	var readiness Readiness
+	if err := json.NewDecoder(resp.Body).Decode(&readiness); err != nil {
+		return nil, fmt.Errorf("failed to decode readiness check: %w", err)
+	}
+	return &readiness, nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+
+	"voltedge/go-services/internal/realtime"
+)
+
+// iteratorPageSize is the page size iterators request when none is set
+const iteratorPageSize = 100
+
+// ListSimulations iterates over every simulation matching opts, fetching
+// pages as the loop advances. opts.Page sets the first page and opts.Limit
+// the page size. An error ends the iteration after it is yielded:
+//
+//	for simulation, err := range c.ListSimulations(ctx, client.SimulationListOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(simulation.ID, simulation.Status)
+//	}
+func (c *Client) ListSimulations(ctx context.Context, opts SimulationListOptions) iter.Seq2[Simulation, error] {
+	return paginate(opts.ListOptions, func(page ListOptions) ([]Simulation, int64, error) {
+		opts.ListOptions = page
+		result, err := c.ListSimulationsPage(ctx, opts)
+		if err != nil {
+			return nil, 0, err
+		}
+		return result.Simulations, result.Pagination.Total, nil
+	})
+}
+
+// paginate iterates over the items of numbered pages until a page comes back
+// short or the reported total is reached
+func paginate[T any](start ListOptions, fetch func(ListOptions) ([]T, int64, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		page := start
+		if page.Page < 1 {
+			page.Page = 1
+		}
+		if page.Limit <= 0 {
+			page.Limit = iteratorPageSize
+		}
+
+		for {
+			items, total, err := fetch(page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) < page.Limit || int64(page.Page*page.Limit) >= total {
+				return
+			}
+			page.Page++
+		}
+	}
+}
+
+// StreamResults subscribes to a simulation's results and delivers each
+// metrics sample, with the run's progress, on the returned channel. The channel is closed, and the
+// connection with it, when ctx is canceled or the connection drops.
+func (c *Client) StreamResults(ctx context.Context, simulationID string) (<-chan ResultsFrame, error) {
+	sub, err := c.Subscribe(ctx, ResultsTopic(simulationID))
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make(chan ResultsFrame)
+	go func() {
+		defer close(frames)
+		defer sub.Close()
+
+		for {
+			select {
+			case msg, ok := <-sub.Messages():
+				if !ok {
+					return
+				}
+				if msg.Type != realtime.MessageResultsSample {
+					continue
+				}
+				var frame ResultsFrame
+				if err := json.Unmarshal(msg.Data, &frame); err != nil {
+					continue
+				}
+				select {
+				case frames <- frame:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return frames, nil
+}
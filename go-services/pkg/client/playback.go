@@ -0,0 +1,167 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// CreatePlaybackSession opens a playback of a persisted simulation's results
+func (c *Client) CreatePlaybackSession(ctx context.Context, req CreatePlaybackRequest) (*PlaybackSession, error) {
+	var session PlaybackSession
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/playback"), body: req}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetPlaybackSession returns a playback session
+func (c *Client) GetPlaybackSession(ctx context.Context, id string) (*PlaybackSession, error) {
+	var session PlaybackSession
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/playback", id)}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeletePlaybackSession closes a playback session and ends its stream
+func (c *Client) DeletePlaybackSession(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/playback", id)}, nil)
+	return err
+}
+
+// ControlPlayback plays, pauses, steps, seeks or changes the speed of a session
+func (c *Client) ControlPlayback(ctx context.Context, id string, cmd PlaybackCommand) (*PlaybackSession, error) {
+	var session PlaybackSession
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/playback", id, "control"), body: cmd}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// PlaybackStream reads the server-sent events of a playback session
+type PlaybackStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	done   bool
+}
+
+// StreamPlayback opens the event stream of a playback session. The stream
+// stays open until the last frame is played, the session is deleted or ctx is
+// canceled; the call timeout does not apply.
+func (c *Client) StreamPlayback(ctx context.Context, id string) (*PlaybackStream, error) {
+	resp, err := c.send(ctx, request{
+		method: http.MethodGet,
+		path:   apiPath("/playback", id, "stream"),
+		header: http.Header{"Accept": {"text/event-stream"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PlaybackStream{body: resp.Body, reader: bufio.NewReader(resp.Body)}, nil
+}
+
+// Next blocks until the next frame or state event. It returns io.EOF once the
+// playback has ended.
+func (s *PlaybackStream) Next() (*PlaybackEvent, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+	for {
+		eventType, data, err := s.readEvent()
+		if err != nil {
+			s.done = true
+			return nil, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch eventType {
+		case "end":
+			s.done = true
+			return nil, io.EOF
+		case "error":
+			s.done = true
+			var failure struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(data, &failure); err != nil || failure.Error == "" {
+				return nil, fmt.Errorf("playback stream failed: %s", data)
+			}
+			return nil, errors.New(failure.Error)
+		}
+
+		var event PlaybackEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			s.done = true
+			return nil, fmt.Errorf("failed to decode playback event: %w", err)
+		}
+		if event.Type == "" {
+			event.Type = eventType
+		}
+		return &event, nil
+	}
+}
+
+// Close closes the stream
+func (s *PlaybackStream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// readEvent reads one server-sent event, returning its type and data. Data
+// split over several lines is joined with newlines.
+func (s *PlaybackStream) readEvent() (string, []byte, error) {
+	var (
+		eventType string
+		data      bytes.Buffer
+		seen      bool
+	)
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+			if seen && errors.Is(err, io.EOF) {
+				return eventType, data.Bytes(), nil
+			}
+			return "", nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if seen {
+				return eventType, data.Bytes(), nil
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+			seen = true
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			seen = true
+		}
+		if err != nil {
+			return eventType, data.Bytes(), nil
+		}
+	}
+}
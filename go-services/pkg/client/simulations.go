@@ -0,0 +1,479 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SimulationListOptions filters ListSimulations and ListSimulationsPage
+type SimulationListOptions struct {
+	ListOptions
+	Status string
+	// Simulations carrying any of the listed tags
+	Tags []string
+
+	// The filters below need a server with a database
+	OwnerID        string
+	OrganizationID string
+	CreatedFrom    time.Time
+	CreatedTo      time.Time
+	StartedFrom    time.Time
+	StartedTo      time.Time
+	// Substring of the name, ignoring case
+	Name string
+	// Only simulations that recorded faults, or with false none
+	HasFaults *bool
+	// Only simulations whose last run lasted at least this long
+	MinDuration time.Duration
+}
+
+// CreateSimulation creates a simulation without starting it
+func (c *Client) CreateSimulation(ctx context.Context, req CreateSimulationRequest) (*Simulation, error) {
+	var simulation Simulation
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations"), body: req}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// ListSimulationsPage returns one page of simulations. ListSimulations
+// iterates over every page.
+func (c *Client) ListSimulationsPage(ctx context.Context, opts SimulationListOptions) (*SimulationPage, error) {
+	query := opts.values()
+	setString(query, "status", opts.Status)
+	for _, tag := range opts.Tags {
+		query.Add("tags", tag)
+	}
+	setString(query, "owner", opts.OwnerID)
+	setString(query, "organization", opts.OrganizationID)
+	setTime(query, "created_from", opts.CreatedFrom)
+	setTime(query, "created_to", opts.CreatedTo)
+	setTime(query, "started_from", opts.StartedFrom)
+	setTime(query, "started_to", opts.StartedTo)
+	setString(query, "name", opts.Name)
+	if opts.HasFaults != nil {
+		query.Set("has_faults", strconv.FormatBool(*opts.HasFaults))
+	}
+	if opts.MinDuration > 0 {
+		query.Set("min_duration", opts.MinDuration.String())
+	}
+
+	page := &SimulationPage{}
+	body, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations"), query: query}, &page.Simulations)
+	if err != nil {
+		return nil, err
+	}
+	if body.Pagination != nil {
+		page.Pagination = *body.Pagination
+	}
+	return page, nil
+}
+
+// ResultWindow selects a window of a simulation's results. Both ends of the
+// tick and time ranges are inclusive; zero values leave them open.
+type ResultWindow struct {
+	FromTick, ToTick *int
+	From, To         time.Time
+	// Oldest first instead of newest first
+	Ascending bool
+	Limit     int
+	Offset    int
+}
+
+func (w ResultWindow) values() url.Values {
+	query := url.Values{}
+	if w.FromTick != nil {
+		query.Set("from_tick", strconv.Itoa(*w.FromTick))
+	}
+	if w.ToTick != nil {
+		query.Set("to_tick", strconv.Itoa(*w.ToTick))
+	}
+	setTimeRange(query, w.From, w.To)
+	if w.Ascending {
+		query.Set("order", "asc")
+	}
+	setInt(query, "limit", w.Limit)
+	setInt(query, "offset", w.Offset)
+	return query
+}
+
+// ListSimulationResults returns the results of a simulation within a window
+func (c *Client) ListSimulationResults(ctx context.Context, id string, window ResultWindow) ([]SimulationResult, error) {
+	var results []SimulationResult
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "results"), query: window.values()}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetLatestResults returns a simulation's newest results, newest first. A
+// zero limit returns the latest one.
+func (c *Client) GetLatestResults(ctx context.Context, id string, limit int) ([]SimulationResult, error) {
+	query := url.Values{}
+	setInt(query, "limit", limit)
+
+	var results []SimulationResult
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "results", "latest"), query: query}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetSimulationStatistics returns a simulation's result, fault and alert
+// statistics
+func (c *Client) GetSimulationStatistics(ctx context.Context, id string) (*SimulationStatistics, error) {
+	var stats SimulationStatistics
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "statistics")}, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetSimulationReport downloads a simulation's run report as "html" or
+// "pdf"; an empty format means html. The caller closes the returned reader.
+func (c *Client) GetSimulationReport(ctx context.Context, id, format string) (io.ReadCloser, error) {
+	query := url.Values{}
+	setString(query, "format", format)
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "report"), query: query})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ExportSimulationResults streams the results of a simulation within a
+// window as csv or ndjson. A zero Limit exports the whole window. Exports
+// can be long, so the call timeout does not apply; the caller closes the
+// returned reader.
+func (c *Client) ExportSimulationResults(ctx context.Context, id, format string, window ResultWindow) (io.ReadCloser, error) {
+	query := window.values()
+	setString(query, "format", format)
+
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "results", "export"), query: query})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// GetSimulation returns a simulation. Its Version is the one UpdateSimulation expects.
+func (c *Client) GetSimulation(ctx context.Context, id string) (*Simulation, error) {
+	var simulation Simulation
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id)}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// UpdateSimulation changes a simulation's name, description, tags or
+// metadata. version must be the simulation's current version; a stale one
+// fails with a conflict (see IsConflict).
+func (c *Client) UpdateSimulation(ctx context.Context, id string, version int64, req UpdateSimulationRequest) (*Simulation, error) {
+	var simulation Simulation
+	if _, err := c.do(ctx, request{method: http.MethodPatch, path: apiPath("/simulations", id), body: req, version: version}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// DeleteSimulation deletes a simulation
+func (c *Client) DeleteSimulation(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/simulations", id)}, nil)
+	return err
+}
+
+// StartSimulation starts or resumes a simulation
+func (c *Client) StartSimulation(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "start")}, nil)
+	return err
+}
+
+// StopSimulation stops a running or paused simulation once it finishes its
+// current tick and reports its results
+func (c *Client) StopSimulation(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "stop")}, nil)
+	return err
+}
+
+// ForceStopSimulation kills a running or paused simulation at once, dropping
+// the tick in progress instead of letting it finish
+func (c *Client) ForceStopSimulation(ctx context.Context, id string) error {
+	query := url.Values{"mode": {"force"}}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "stop"), query: query}, nil)
+	return err
+}
+
+// PauseSimulation pauses a running simulation
+func (c *Client) PauseSimulation(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "pause")}, nil)
+	return err
+}
+
+// ResumeSimulation continues a paused simulation from where it was paused
+func (c *Client) ResumeSimulation(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "resume")}, nil)
+	return err
+}
+
+// SetSimulationSpeed sets a simulation's real-time factor; 0 runs as fast as possible
+func (c *Client) SetSimulationSpeed(ctx context.Context, id string, speed float64) (*Simulation, error) {
+	var simulation Simulation
+	body := map[string]float64{"speed": speed}
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "speed"), body: body}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// StepSimulation advances a paused simulation by ticks and returns the resulting state
+func (c *Client) StepSimulation(ctx context.Context, id string, ticks int) (*StepResult, error) {
+	query := url.Values{}
+	setInt(query, "ticks", ticks)
+
+	var result StepResult
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "step"), query: query}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PlanSimulationConfig computes the changes and estimated impact of changing
+// a running simulation's config, without changing anything
+func (c *Client) PlanSimulationConfig(ctx context.Context, id string, config SimulationConfig) (*ConfigPlan, error) {
+	var plan ConfigPlan
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "plan"), body: PlanConfigRequest{Config: config}}, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ApplySimulationConfig applies a plan made by PlanSimulationConfig to the
+// running simulation
+func (c *Client) ApplySimulationConfig(ctx context.Context, id, planID string) (*Simulation, error) {
+	var simulation Simulation
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "apply"), body: ApplyConfigPlanRequest{PlanID: planID}}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// ModifySimulationComponents adds power plants and transmission lines to a
+// running simulation and removes them from it
+func (c *Client) ModifySimulationComponents(ctx context.Context, id string, req TopologyChangeRequest) (*Simulation, error) {
+	var simulation Simulation
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "components"), body: req}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// RerunSimulation creates a new simulation from another's config. With
+// sameSeed the new run reproduces the original's randomness.
+func (c *Client) RerunSimulation(ctx context.Context, id string, sameSeed bool) (*Simulation, error) {
+	query := url.Values{}
+	if sameSeed {
+		query.Set("same_seed", "true")
+	}
+
+	var simulation Simulation
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "rerun"), query: query}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// RedispatchSimulation creates a what-if simulation replaying a past run's
+// recorded load against a new grid
+func (c *Client) RedispatchSimulation(ctx context.Context, id string, req RedispatchRequest) (*Simulation, error) {
+	var simulation Simulation
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "redispatch"), body: req}, &simulation); err != nil {
+		return nil, err
+	}
+	return &simulation, nil
+}
+
+// ImportOptions controls ImportSimulation
+type ImportOptions struct {
+	// matpower (default) or psse
+	Format string
+	// Create a simulation from the converted config instead of only converting it
+	Create bool
+	// Name of the created simulation; defaults to the case name
+	Name string
+}
+
+// ImportSimulation converts a MATPOWER or PSS/E case into a simulation config
+func (c *Client) ImportSimulation(ctx context.Context, data []byte, opts ImportOptions) (*ImportSimulationResponse, error) {
+	query := url.Values{}
+	setString(query, "format", opts.Format)
+	setString(query, "name", opts.Name)
+	if opts.Create {
+		query.Set("create", "true")
+	}
+
+	var response ImportSimulationResponse
+	req := request{
+		method:      http.MethodPost,
+		path:        apiPath("/simulations/import"),
+		query:       query,
+		rawBody:     data,
+		contentType: "text/plain",
+	}
+	if _, err := c.do(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ExportSimulation writes a simulation's grid model in an interchange format,
+// cim by default
+func (c *Client) ExportSimulation(ctx context.Context, id, format string) (*Download, error) {
+	query := url.Values{}
+	setString(query, "format", format)
+
+	data, contentType, err := c.doBytes(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "export"), query: query})
+	if err != nil {
+		return nil, err
+	}
+	return &Download{ContentType: contentType, Data: data}, nil
+}
+
+// TakeSnapshot stores a snapshot of a running simulation's engine state
+func (c *Client) TakeSnapshot(ctx context.Context, id string) (*Snapshot, error) {
+	var snapshot Snapshot
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "snapshot")}, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots lists a simulation's stored snapshots, newest first
+func (c *Client) ListSnapshots(ctx context.Context, id string, opts ListOptions) ([]SnapshotInfo, error) {
+	var snapshots []SnapshotInfo
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "snapshots"), query: opts.values()}, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// GetSnapshot returns a stored snapshot with its state
+func (c *Client) GetSnapshot(ctx context.Context, id string, version int) (*Snapshot, error) {
+	var snapshot Snapshot
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "snapshots", strconv.Itoa(version))}, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// DiffSnapshots compares two stored snapshots of a simulation
+func (c *Client) DiffSnapshots(ctx context.Context, id string, version, otherVersion int) (*SnapshotDiff, error) {
+	var diff SnapshotDiff
+	path := apiPath("/simulations", id, "snapshots", strconv.Itoa(version), "diff", strconv.Itoa(otherVersion))
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path}, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// GetSimulationPipeline returns the dependency graph a simulation belongs to
+func (c *Client) GetSimulationPipeline(ctx context.Context, id string) (*Pipeline, error) {
+	var pipeline Pipeline
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "pipeline")}, &pipeline); err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+// RecordSimulationMetrics pushes a runtime metrics sample, as engines do.
+// It is an internal API call, authenticated by the service token set with
+// WithServiceToken.
+func (c *Client) RecordSimulationMetrics(ctx context.Context, id string, sample MetricsSample) (*MetricsRecorded, error) {
+	call := request{
+		method: http.MethodPost,
+		path:   "/internal/v1/simulations/" + url.PathEscape(id) + "/metrics",
+		header: http.Header{serviceTokenHeader: {c.serviceToken}},
+		body:   sample,
+	}
+
+	var recorded MetricsRecorded
+	if _, err := c.do(ctx, call, &recorded); err != nil {
+		return nil, err
+	}
+	return &recorded, nil
+}
+
+// MetricsQueryOptions bounds QueryComponentMetrics
+type MetricsQueryOptions struct {
+	From, To time.Time
+	// Width of the aggregation buckets; needs an aggregation in the query
+	Step  time.Duration
+	Limit int
+}
+
+// QueryComponentMetrics runs a metrics query expression, e.g.
+// avg(line_loading{component_type="line"}), over a simulation's component metrics
+func (c *Client) QueryComponentMetrics(ctx context.Context, id, expr string, opts MetricsQueryOptions) (*MetricsQueryResult, error) {
+	query := url.Values{"q": {expr}}
+	setTimeRange(query, opts.From, opts.To)
+	if opts.Step > 0 {
+		query.Set("step", opts.Step.String())
+	}
+	setInt(query, "limit", opts.Limit)
+
+	var result MetricsQueryResult
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id) + "/metrics/query", query: query}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DiffSimulations returns a structured diff from one simulation's config to another's
+func (c *Client) DiffSimulations(ctx context.Context, id, otherID string) (*SimulationDiff, error) {
+	var diff SimulationDiff
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "diff", otherID)}, &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// CreateShareLink creates an expiring link giving read-only access to a
+// simulation without logging in. The token is only returned here.
+func (c *Client) CreateShareLink(ctx context.Context, id string, req ShareLinkRequest) (*ShareLinkResponse, error) {
+	var link ShareLinkResponse
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/simulations", id, "share-links"), body: req}, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListShareLinks lists a simulation's share links, newest first
+func (c *Client) ListShareLinks(ctx context.Context, id string) ([]ShareLink, error) {
+	var links []ShareLink
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/simulations", id, "share-links")}, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// RevokeShareLink revokes a share link so its token stops working
+func (c *Client) RevokeShareLink(ctx context.Context, id string, linkID uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/simulations", id, "share-links", linkID.String())}, nil)
+	return err
+}
+
+// GetSharedSimulation returns the simulation behind a share link token. It
+// needs no credentials.
+func (c *Client) GetSharedSimulation(ctx context.Context, token string) (*SharedSimulation, error) {
+	var shared SharedSimulation
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/shared", token)}, &shared); err != nil {
+		return nil, err
+	}
+	return &shared, nil
+}
@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"voltedge/go-services/internal/realtime"
+)
+
+// messageBuffer is how many pushed messages a Subscription holds before the
+// read loop waits for the caller
+const messageBuffer = 256
+
+// ResultsTopic names the topic carrying a simulation's results
+func ResultsTopic(simulationID string) string {
+	return realtime.ResultsTopic(simulationID)
+}
+
+// AlertsTopic names the topic carrying a simulation's alerts
+func AlertsTopic(simulationID string) string {
+	return realtime.AlertsTopic(simulationID)
+}
+
+// TopologyTopic names the topic carrying a simulation's topology changes
+func TopologyTopic(simulationID string) string {
+	return realtime.TopologyTopic(simulationID)
+}
+
+// Message is a message pushed on a subscribed topic
+type Message struct {
+	Type      string          `json:"type"`
+	Topic     string          `json:"topic"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Decode decodes the message's data into v
+func (m Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Data, v)
+}
+
+// Subscription is a WebSocket connection to the gateway receiving the
+// messages of its subscribed topics
+type Subscription struct {
+	conn     *websocket.Conn
+	messages chan Message
+
+	writeMu sync.Mutex
+	nextID  atomic.Int64
+
+	mu      sync.Mutex
+	pending map[string]chan SubscriptionAck
+	err     error
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Subscribe opens a WebSocket connection subscribed to topics. It fails if
+// any topic is rejected. The connection lives until Close is called or it
+// drops; ctx only bounds the dial and the initial subscription.
+func (c *Client) Subscribe(ctx context.Context, topics ...string) (*Subscription, error) {
+	target, err := c.webSocketURL()
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{"User-Agent": {c.userAgent}}
+	if token := c.Token(); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: c.timeout,
+		Subprotocols:     []string{"voltedge.json"},
+	}
+	conn, resp, err := dialer.DialContext(ctx, target, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 300 {
+			return nil, readAPIError(resp)
+		}
+		return nil, fmt.Errorf("failed to connect to %s: %w", target, err)
+	}
+
+	sub := &Subscription{
+		conn:     conn,
+		messages: make(chan Message, messageBuffer),
+		pending:  make(map[string]chan SubscriptionAck),
+		done:     make(chan struct{}),
+	}
+	go sub.readLoop()
+
+	if len(topics) > 0 {
+		if _, err := sub.Subscribe(ctx, topics...); err != nil {
+			sub.Close()
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+// webSocketURL derives the WebSocket endpoint from the base URL
+func (c *Client) webSocketURL() (string, error) {
+	target, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	switch target.Scheme {
+	case "https":
+		target.Scheme = "wss"
+	default:
+		target.Scheme = "ws"
+	}
+	target.Path = strings.TrimSuffix(target.Path, "/") + c.webSocketPath
+	return target.String(), nil
+}
+
+// Messages returns the messages pushed on the subscribed topics. The channel
+// is closed when the connection ends; Err then reports why.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Subscribe adds topics to the connection. It returns the ack with an error
+// if the gateway rejected the request or any of the topics.
+func (s *Subscription) Subscribe(ctx context.Context, topics ...string) (*SubscriptionAck, error) {
+	return s.request(ctx, "subscribe", topics)
+}
+
+// Unsubscribe removes topics from the connection
+func (s *Subscription) Unsubscribe(ctx context.Context, topics ...string) (*SubscriptionAck, error) {
+	return s.request(ctx, "unsubscribe", topics)
+}
+
+// Err returns why the connection ended, or nil while it is open or after Close
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close closes the connection
+func (s *Subscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.writeMu.Lock()
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		s.writeMu.Unlock()
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// request sends a subscription request and waits for its ack
+func (s *Subscription) request(ctx context.Context, action string, topics []string) (*SubscriptionAck, error) {
+	id := strconv.FormatInt(s.nextID.Add(1), 10)
+	reply := make(chan SubscriptionAck, 1)
+
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.pending[id] = reply
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	s.writeMu.Lock()
+	err := s.conn.WriteJSON(SubscriptionRequest{Action: action, ID: id, Topics: topics})
+	s.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", action, err)
+	}
+
+	select {
+	case ack := <-reply:
+		if ack.Error != "" {
+			return &ack, fmt.Errorf("%s failed: %s", action, ack.Error)
+		}
+		if len(ack.Rejected) > 0 {
+			reasons := make([]string, 0, len(ack.Rejected))
+			for topic, reason := range ack.Rejected {
+				reasons = append(reasons, topic+": "+reason)
+			}
+			return &ack, fmt.Errorf("topics rejected: %s", strings.Join(reasons, ", "))
+		}
+		return &ack, nil
+	case <-s.done:
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("subscription closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readLoop routes acks to the requests waiting for them and everything else
+// to Messages until the connection ends
+func (s *Subscription) readLoop() {
+	defer close(s.messages)
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.Type == "ack" {
+			var ack SubscriptionAck
+			if err := json.Unmarshal(data, &ack); err != nil {
+				continue
+			}
+			s.mu.Lock()
+			reply, ok := s.pending[ack.ID]
+			s.mu.Unlock()
+			if ok {
+				reply <- ack
+			}
+			continue
+		}
+
+		select {
+		case s.messages <- msg:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// fail records why the connection ended unless it was closed on purpose
+func (s *Subscription) fail(err error) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
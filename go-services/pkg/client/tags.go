@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// CreateTag creates a tag
+func (c *Client) CreateTag(ctx context.Context, req TagRequest) (*Tag, error) {
+	var tag Tag
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/tags"), body: req}, &tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// ListTags returns a page of tags, optionally only those starting with prefix
+func (c *Client) ListTags(ctx context.Context, prefix string, opts ListOptions) (*TagPage, error) {
+	query := opts.values()
+	setString(query, "prefix", prefix)
+
+	var page TagPage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/tags"), query: query}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// AutocompleteTags suggests the most used tags starting with prefix
+func (c *Client) AutocompleteTags(ctx context.Context, prefix string, limit int) ([]Tag, error) {
+	query := url.Values{}
+	setString(query, "prefix", prefix)
+	setInt(query, "limit", limit)
+
+	var tags []Tag
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/tags/autocomplete"), query: query}, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// TagSearchOptions selects the resources SearchTaggedResources returns
+type TagSearchOptions struct {
+	ListOptions
+	// simulation, experiment or batch; empty searches all
+	ResourceTypes []string
+	// Require every tag instead of any of them
+	MatchAll bool
+}
+
+// SearchTaggedResources finds the resources carrying the given tags
+func (c *Client) SearchTaggedResources(ctx context.Context, tags []string, opts TagSearchOptions) (*TaggedResourcePage, error) {
+	query := opts.values()
+	setList(query, "tags", tags)
+	setList(query, "type", opts.ResourceTypes)
+	if opts.MatchAll {
+		query.Set("match", "all")
+	}
+
+	var page TaggedResourcePage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/tags/search"), query: query}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// MergeTags folds the source tags into the target tag and returns the target
+func (c *Client) MergeTags(ctx context.Context, req MergeTagsRequest) (*Tag, error) {
+	var tag Tag
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/tags/merge"), body: req}, &tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetTag returns a tag
+func (c *Client) GetTag(ctx context.Context, id uuid.UUID) (*Tag, error) {
+	var tag Tag
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/tags", id.String())}, &tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// UpdateTag renames or recolors a tag
+func (c *Client) UpdateTag(ctx context.Context, id uuid.UUID, req TagRequest) (*Tag, error) {
+	var tag Tag
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/tags", id.String()), body: req}, &tag); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// DeleteTag deletes a tag and removes it from every resource
+func (c *Client) DeleteTag(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/tags", id.String())}, nil)
+	return err
+}
+
+// Search runs a full-text search over simulations, experiments, batches and
+// tags. types optionally limits the hit types.
+func (c *Client) Search(ctx context.Context, q string, types []string, limit int) (*SearchResult, error) {
+	query := url.Values{"q": {q}}
+	setList(query, "type", types)
+	setInt(query, "limit", limit)
+
+	var result SearchResult
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/search"), query: query}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateDashboard creates a dashboard owned by the caller
+func (c *Client) CreateDashboard(ctx context.Context, req DashboardRequest) (*Dashboard, error) {
+	var dashboard Dashboard
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/dashboards"), body: req}, &dashboard); err != nil {
+		return nil, err
+	}
+	return &dashboard, nil
+}
+
+// ListDashboards returns a page of the dashboards the caller can see
+func (c *Client) ListDashboards(ctx context.Context, opts ListOptions) (*DashboardPage, error) {
+	var page DashboardPage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/dashboards"), query: opts.values()}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetDashboard returns a dashboard
+func (c *Client) GetDashboard(ctx context.Context, id uuid.UUID) (*Dashboard, error) {
+	var dashboard Dashboard
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/dashboards", id.String())}, &dashboard); err != nil {
+		return nil, err
+	}
+	return &dashboard, nil
+}
+
+// UpdateDashboard saves a new revision of a dashboard. version must be the
+// dashboard's current version.
+func (c *Client) UpdateDashboard(ctx context.Context, id uuid.UUID, version int64, req DashboardRequest) (*Dashboard, error) {
+	var dashboard Dashboard
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/dashboards", id.String()), body: req, version: version}, &dashboard); err != nil {
+		return nil, err
+	}
+	return &dashboard, nil
+}
+
+// DeleteDashboard deletes a dashboard
+func (c *Client) DeleteDashboard(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/dashboards", id.String())}, nil)
+	return err
+}
+
+// SetDashboardShares replaces the users a dashboard is shared with
+func (c *Client) SetDashboardShares(ctx context.Context, id uuid.UUID, shares []DashboardShareRequest) (*Dashboard, error) {
+	var dashboard Dashboard
+	body := DashboardSharesRequest{Shares: shares}
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/dashboards", id.String(), "shares"), body: body}, &dashboard); err != nil {
+		return nil, err
+	}
+	return &dashboard, nil
+}
+
+// ListDashboardRevisions returns a page of a dashboard's revisions, newest first
+func (c *Client) ListDashboardRevisions(ctx context.Context, id uuid.UUID, opts ListOptions) (*DashboardRevisionPage, error) {
+	var page DashboardRevisionPage
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/dashboards", id.String(), "revisions"), query: opts.values()}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetDashboardRevision returns one revision of a dashboard with its document
+func (c *Client) GetDashboardRevision(ctx context.Context, id uuid.UUID, revision int64) (*DashboardRevision, error) {
+	var result DashboardRevision
+	path := apiPath("/dashboards", id.String(), "revisions", strconv.FormatInt(revision, 10))
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: path}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RestoreDashboardRevision saves an earlier revision as a new one. version
+// must be the dashboard's current version.
+func (c *Client) RestoreDashboardRevision(ctx context.Context, id uuid.UUID, revision, version int64) (*Dashboard, error) {
+	var dashboard Dashboard
+	path := apiPath("/dashboards", id.String(), "revisions", strconv.FormatInt(revision, 10), "restore")
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: path, version: version}, &dashboard); err != nil {
+		return nil, err
+	}
+	return &dashboard, nil
+}
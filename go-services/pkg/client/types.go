@@ -0,0 +1,368 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"voltedge/go-services/internal/analytics"
+	"voltedge/go-services/internal/api"
+	"voltedge/go-services/internal/archive"
+	"voltedge/go-services/internal/auth"
+	"voltedge/go-services/internal/chaos"
+	"voltedge/go-services/internal/cluster"
+	"voltedge/go-services/internal/config"
+	"voltedge/go-services/internal/database"
+	"voltedge/go-services/internal/engine"
+	"voltedge/go-services/internal/gridmodel"
+	"voltedge/go-services/internal/logging"
+	"voltedge/go-services/internal/notifications"
+	"voltedge/go-services/internal/orchestration"
+	"voltedge/go-services/internal/playback"
+	"voltedge/go-services/internal/prediction"
+	"voltedge/go-services/internal/reconcile"
+	"voltedge/go-services/internal/search"
+	"voltedge/go-services/internal/usage"
+)
+
+// The request and response types are the gateway's own, so the client
+// cannot drift from the API it calls.
+
+// Simulations
+type (
+	CreateSimulationRequest  = api.CreateSimulationRequest
+	UpdateSimulationRequest  = api.UpdateSimulationRequest
+	DependencyRequest        = api.DependencyRequest
+	SimulationConfig         = api.SimulationConfig
+	PowerPlantConfig         = api.PowerPlantConfig
+	TransmissionLineConfig   = api.TransmissionLineConfig
+	StorageUnitConfig        = api.StorageUnitConfig
+	BusConfig                = api.BusConfig
+	LoadProfile              = api.LoadProfile
+	Location                 = api.Location
+	Simulation               = api.SimulationResponse
+	ImportSimulationResponse = api.ImportSimulationResponse
+	RedispatchRequest        = api.RedispatchRequest
+	ResampleRequest          = api.ResampleRequest
+	Snapshot                 = api.SnapshotResponse
+	SnapshotInfo             = database.SimulationSnapshot
+	StepResult               = orchestration.StepResult
+	Pipeline                 = orchestration.Pipeline
+	MetricsSample            = orchestration.MetricsSample
+	ComponentSample          = orchestration.ComponentSample
+	ResultsFrame             = api.ResultsFrame
+	SimulationResult         = database.SimulationResult
+	Progress                 = orchestration.Progress
+	QueueStatus              = orchestration.QueueStatus
+	ConfigDiff               = orchestration.ConfigDiff
+	FieldChange              = orchestration.FieldChange
+	PowerFlowWarning         = orchestration.PowerFlowWarning
+	Topology                 = orchestration.Topology
+	GridState                = api.GridState
+	SimulationStatistics     = database.SimulationStatistics
+	MetricStatistics         = database.MetricStatistics
+	GeoJSON                  = gridmodel.FeatureCollection
+	ShareLinkRequest         = api.ShareLinkRequest
+	ShareLink                = database.ShareLink
+	ShareLinkResponse        = api.ShareLinkResponse
+	SharedSimulation         = api.SharedSimulationResponse
+	PlanConfigRequest        = api.PlanConfigRequest
+	ApplyConfigPlanRequest   = api.ApplyConfigPlanRequest
+	TopologyChangeRequest    = api.TopologyChangeRequest
+	ConfigPlan               = orchestration.ConfigPlan
+	PlanImpact               = orchestration.PlanImpact
+)
+
+// Batches and experiments
+type (
+	CreateBatchRequest      = api.CreateBatchRequest
+	BatchParametersRequest  = api.BatchParametersRequest
+	Batch                   = orchestration.Batch
+	CreateExperimentRequest = api.CreateExperimentRequest
+	SweepDimension          = orchestration.SweepDimension
+	Experiment              = orchestration.Experiment
+)
+
+// Events, alerts and alert routing
+type (
+	FaultEvent              = database.FaultEvent
+	Alert                   = database.Alert
+	TimelineEntry           = database.TimelineEntry
+	EventCounts             = api.EventCounts
+	SeverityCount           = database.SeverityCount
+	BulkAcknowledgeRequest  = api.BulkAcknowledgeRequest
+	BulkAcknowledgeResponse = api.BulkAcknowledgeResponse
+	ChannelRequest          = api.ChannelRequest
+	Channel                 = database.NotificationChannel
+	TestNotificationRequest = api.TestNotificationRequest
+	AlertRouteRequest       = api.AlertRouteRequest
+	AlertRoute              = database.AlertRoute
+	AlertNotification       = database.AlertNotification
+	SilenceRequest          = api.SilenceRequest
+	Silence                 = api.SilenceResponse
+)
+
+// Analytics
+type (
+	EmissionsReport      = analytics.EmissionsReport
+	CostReport           = analytics.CostReport
+	DispatchSuggestion   = analytics.DispatchSuggestion
+	EnergyReport         = analytics.EnergyReport
+	EnergyBalance        = analytics.EnergyBalance
+	ReliabilityReport    = analytics.ReliabilityReport
+	StabilityScore       = database.StabilityScore
+	StabilityRank        = database.StabilityRank
+	ComponentReliability = analytics.ComponentReliability
+	Prediction           = prediction.Prediction
+	PredictionAccuracy   = prediction.Accuracy
+	ComponentRank        = database.ComponentRank
+	PercentileSummary    = database.PercentileSummary
+	MetricsQueryResult   = database.MetricsQueryResult
+)
+
+// Tags, search and dashboards
+type (
+	TagRequest             = api.TagRequest
+	MergeTagsRequest       = api.MergeTagsRequest
+	Tag                    = database.Tag
+	TaggedResource         = database.TaggedResource
+	SearchHit              = search.Hit
+	DashboardRequest       = api.DashboardRequest
+	DashboardSharesRequest = api.DashboardSharesRequest
+	DashboardShareRequest  = api.DashboardShareRequest
+	Dashboard              = database.Dashboard
+	DashboardRevision      = database.DashboardRevision
+)
+
+// Playback
+type (
+	CreatePlaybackRequest = api.CreatePlaybackRequest
+	PlaybackCommand       = playback.Command
+	PlaybackSession       = playback.Info
+	PlaybackEvent         = playback.Event
+	PlaybackFrame         = playback.Frame
+)
+
+// Webhooks and notifications
+type (
+	WebhookRequest                = api.WebhookRequest
+	Webhook                       = database.WebhookSubscription
+	TemplatePreviewRequest        = api.TemplatePreviewRequest
+	TemplatePreview               = api.TemplatePreviewResponse
+	NotificationTemplate          = notifications.DefaultTemplate
+	NotificationEvent             = notifications.Event
+	NotificationPreferenceRequest = api.NotificationPreferenceRequest
+	NotificationPreference        = database.NotificationPreference
+)
+
+// Authentication, organizations and administration
+type (
+	LoginRequest               = api.LoginRequest
+	TokenResponse              = api.TokenResponse
+	Impersonation              = auth.Impersonation
+	LoginAttempt               = database.LoginAttempt
+	UserSession                = api.UserSessionResponse
+	ImpersonationRequest       = api.ImpersonationRequest
+	ImpersonationPolicyRequest = api.ImpersonationPolicyRequest
+	AuditLog                   = database.AuditLog
+	QuotaRequest               = api.QuotaRequest
+	ConcurrencyLimitsRequest   = api.ConcurrencyLimitsRequest
+	ConcurrencyLimits          = orchestration.ConcurrencyLimits
+	Quotas                     = usage.Quotas
+	UsageReport                = usage.Report
+	MetadataMigrationRequest   = api.MetadataMigrationRequest
+	MetadataMigration          = database.MetadataMigration
+	MetadataSize               = database.MetadataSize
+	MetadataLimits             = config.MetadataLimits
+	DatabaseStats              = database.PoolStats
+	LogLevelRequest            = api.LogLevelRequest
+	LogLevels                  = logging.Levels
+	Reconciliation             = api.ReconciliationResponse
+	ReconciliationReport       = reconcile.Report
+	Archive                    = api.ArchiveResponse
+	ArchiveReport              = archive.Report
+	ResultArchive              = database.ResultArchive
+	ClusterStatus              = cluster.Status
+	ChaosExperimentRequest     = api.ChaosExperimentRequest
+	ChaosStatus                = api.ChaosResponse
+	ChaosExperiment            = chaos.Experiment
+	DeadLetter                 = orchestration.DeadLetter
+	Engine                     = engine.Status
+)
+
+// Ingest
+type (
+	ResultBatchRequest = api.ResultBatchRequest
+	IngestResult       = api.IngestResult
+	IngestMetric       = api.IngestMetric
+	IngestFault        = api.IngestFault
+)
+
+// Grafana datasource
+type (
+	GrafanaQueryRequest      = api.GrafanaQueryRequest
+	GrafanaSearchRequest     = api.GrafanaSearchRequest
+	GrafanaSearchResult      = api.GrafanaSearchResult
+	GrafanaAnnotationRequest = api.GrafanaAnnotationRequest
+	GrafanaAnnotation        = api.GrafanaAnnotation
+	GrafanaTimeSeries        = api.GrafanaTimeSeries
+	GrafanaTable             = api.GrafanaTable
+	GrafanaPoint             = api.GrafanaPoint
+)
+
+// Live updates
+type (
+	SubscriptionRequest = api.SubscriptionRequest
+	SubscriptionAck     = api.SubscriptionAck
+)
+
+// The types below describe responses the gateway builds ad hoc
+
+// SimulationPage is a page of ListSimulationsPage
+type SimulationPage struct {
+	Simulations []Simulation
+	Pagination  Pagination
+}
+
+// FaultEventPage is a cursor-paginated page of fault events
+type FaultEventPage struct {
+	Events     []FaultEvent `json:"events"`
+	Count      int          `json:"count"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// AlertPage is a cursor-paginated page of alerts
+type AlertPage struct {
+	Events     []Alert `json:"events"`
+	Count      int     `json:"count"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// TimelinePage is a cursor-paginated page of a simulation's timeline
+type TimelinePage struct {
+	Events     []TimelineEntry `json:"events"`
+	Count      int             `json:"count"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// SnapshotDiff lists the state changes between two snapshots
+type SnapshotDiff struct {
+	SimulationID string        `json:"simulation_id"`
+	Version      int           `json:"version"`
+	OtherVersion int           `json:"other_version"`
+	Changes      []FieldChange `json:"changes"`
+}
+
+// SimulationDiff is the config diff from one simulation to another
+type SimulationDiff struct {
+	SimulationID string     `json:"simulation_id"`
+	OtherID      string     `json:"other_id"`
+	Diff         ConfigDiff `json:"diff"`
+}
+
+// MetricsRecorded reports a simulation's runtime metrics after a sample was recorded
+type MetricsRecorded struct {
+	SimulationID    string  `json:"simulation_id"`
+	EventsProcessed int64   `json:"events_processed"`
+	AvgTickTimeMS   float64 `json:"avg_tick_time_ms"`
+	MemoryUsageMB   int64   `json:"memory_usage_mb"`
+}
+
+// TagPage is a page of ListTags
+type TagPage struct {
+	Tags  []Tag `json:"tags"`
+	Total int64 `json:"total"`
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+}
+
+// TaggedResourcePage is a page of SearchTaggedResources
+type TaggedResourcePage struct {
+	Resources []TaggedResource `json:"resources"`
+	Total     int64            `json:"total"`
+	Page      int              `json:"page"`
+	Limit     int              `json:"limit"`
+}
+
+// SearchResult holds the ranked hits of a global search
+type SearchResult struct {
+	Query string      `json:"query"`
+	Terms []string    `json:"terms"`
+	Hits  []SearchHit `json:"hits"`
+	Count int         `json:"count"`
+}
+
+// DashboardPage is a page of ListDashboards
+type DashboardPage struct {
+	Dashboards []Dashboard `json:"dashboards"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+}
+
+// DashboardRevisionPage is a page of ListDashboardRevisions
+type DashboardRevisionPage struct {
+	Revisions []DashboardRevision `json:"revisions"`
+	Total     int64               `json:"total"`
+	Page      int                 `json:"page"`
+	Limit     int                 `json:"limit"`
+}
+
+// AuditLogPage is a page of ListAuditLogs
+type AuditLogPage struct {
+	Entries []AuditLog `json:"entries"`
+	Total   int64      `json:"total"`
+	Page    int        `json:"page"`
+	Limit   int        `json:"limit"`
+}
+
+// NotificationTemplates lists the built-in webhook payload templates
+type NotificationTemplates struct {
+	LatestVersion int                    `json:"latest_version"`
+	Templates     []NotificationTemplate `json:"templates"`
+}
+
+// LargestMetadata lists the largest metadata payloads with the configured limits
+type LargestMetadata struct {
+	Limits   MetadataLimits `json:"limits"`
+	Payloads []MetadataSize `json:"payloads"`
+}
+
+// IngestSummary reports what a result batch stored
+type IngestSummary struct {
+	SimulationID string `json:"simulation_id"`
+	Results      int    `json:"results"`
+	Metrics      int    `json:"metrics"`
+	Faults       int    `json:"faults"`
+	FirstTick    int    `json:"first_tick"`
+	LastTick     int    `json:"last_tick"`
+}
+
+// Health is the gateway's health check
+type Health struct {
+	Status    string                     `json:"status"`
+	Timestamp time.Time                  `json:"timestamp"`
+	Version   string                     `json:"version"`
+	Services  map[string]json.RawMessage `json:"services"`
+}
+
+// Readiness is the gateway's readiness check
+type Readiness = api.ReadinessResponse
+
+// Download is a document the gateway serves as is, such as an artifact or
+// a grid model export
+type Download struct {
+	ContentType string
+	Data        []byte
+}
+
+// ControlRequest commands a power plant or transmission line
+type ControlRequest struct {
+	Action string  `json:"action"`
+	Value  float64 `json:"value,omitempty"`
+}
+
+// FailureRequest injects a failure into a running simulation
+type FailureRequest struct {
+	ComponentID string `json:"component_id"`
+	FailureType string `json:"failure_type"`
+}
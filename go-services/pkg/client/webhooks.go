@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// CreateWebhook subscribes a URL to simulation events
+func (c *Client) CreateWebhook(ctx context.Context, req WebhookRequest) (*Webhook, error) {
+	var webhook Webhook
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/webhooks"), body: req}, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks returns a page of webhook subscriptions
+func (c *Client) ListWebhooks(ctx context.Context, opts ListOptions) ([]Webhook, error) {
+	var webhooks []Webhook
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/webhooks"), query: opts.values()}, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns a webhook subscription
+func (c *Client) GetWebhook(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	var webhook Webhook
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/webhooks", id.String())}, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook replaces a webhook subscription. version must be the
+// subscription's current version.
+func (c *Client) UpdateWebhook(ctx context.Context, id uuid.UUID, version int64, req WebhookRequest) (*Webhook, error) {
+	var webhook Webhook
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/webhooks", id.String()), body: req, version: version}, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// DeleteWebhook deletes a webhook subscription
+func (c *Client) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/webhooks", id.String())}, nil)
+	return err
+}
+
+// PreviewWebhook renders the payload a webhook would deliver. req may be nil
+// to preview a sample event.
+func (c *Client) PreviewWebhook(ctx context.Context, id uuid.UUID, req *TemplatePreviewRequest) (*TemplatePreview, error) {
+	call := request{method: http.MethodPost, path: apiPath("/webhooks", id.String(), "preview")}
+	if req != nil {
+		call.body = req
+	}
+
+	var preview TemplatePreview
+	if _, err := c.do(ctx, call, &preview); err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// ListNotificationTemplates returns the built-in webhook payload templates
+func (c *Client) ListNotificationTemplates(ctx context.Context) (*NotificationTemplates, error) {
+	var templates NotificationTemplates
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/notifications/templates")}, &templates); err != nil {
+		return nil, err
+	}
+	return &templates, nil
+}
+
+// PreviewNotificationTemplate renders a payload template against a sample event
+func (c *Client) PreviewNotificationTemplate(ctx context.Context, req TemplatePreviewRequest) (*TemplatePreview, error) {
+	var preview TemplatePreview
+	if _, err := c.do(ctx, request{method: http.MethodPost, path: apiPath("/notifications/templates/preview"), body: req}, &preview); err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// GetNotificationPreferences returns the caller's notification preferences
+func (c *Client) GetNotificationPreferences(ctx context.Context) (*NotificationPreference, error) {
+	var preference NotificationPreference
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: apiPath("/notifications/preferences")}, &preference); err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// SetNotificationPreferences saves the caller's notification preferences
+func (c *Client) SetNotificationPreferences(ctx context.Context, req NotificationPreferenceRequest) (*NotificationPreference, error) {
+	var preference NotificationPreference
+	if _, err := c.do(ctx, request{method: http.MethodPut, path: apiPath("/notifications/preferences"), body: req}, &preference); err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// ResetNotificationPreferences restores the caller's default notification preferences
+func (c *Client) ResetNotificationPreferences(ctx context.Context) (*NotificationPreference, error) {
+	var preference NotificationPreference
+	if _, err := c.do(ctx, request{method: http.MethodDelete, path: apiPath("/notifications/preferences")}, &preference); err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}